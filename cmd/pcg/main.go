@@ -0,0 +1,82 @@
+// Command pcg provides tools for debugging the artifacts of the PCG, i.e. seeds, tuples, rings and public parameters,
+// and for planning experiments.
+//
+// Usage:
+//
+//	go run ./cmd/pcg inspect seed.bin
+//	go run ./cmd/pcg experiment sweep.yaml
+//
+// inspect prints a summary of the serialized artifact (see pcg.Inspect), which never contains secret values.
+// experiment validates the experiment configuration (see experiment.Load) and prints the security level and the
+// estimated memory of each of its points, e.g. to check a sweep before running it via the benchmarks of pcg/bench.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/experiment"
+	"log"
+	"os"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: pcg inspect <file>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       pcg experiment <config>")
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch flag.Arg(0) {
+	case "inspect":
+		inspect(flag.Arg(1))
+	case "experiment":
+		plan(flag.Arg(1))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// inspect prints the summary of the artifact at path.
+func inspect(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	description, err := pcg.Inspect(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(description)
+}
+
+// plan prints the points of the experiment configuration at path.
+func plan(path string) {
+	config, err := experiment.Load(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("experiment %q: %d points, ring %s, eval %s, PRG %q, hardened %v, threads %d\n", config.Name,
+		len(config.Points), config.Ring, config.Eval, config.DPF.PRG, config.DPF.Hardened, config.Threads)
+	for _, point := range config.Points {
+		generator, err := config.NewPCG(point)
+		if err != nil {
+			log.Fatalf("point %s: %v", point, err)
+		}
+		level := generator.SecurityLevel()
+		fmt.Printf("  %s: %d bits of security, %s\n", point, level.Effective, generator.EstimateMemory())
+		if path := config.FixturePath(generator); path != "" {
+			if _, err := os.Stat(path); err != nil {
+				fmt.Printf("    no fixture at %s\n", path)
+			}
+		}
+	}
+	if path := config.ResultPath(".csv"); path != "" {
+		fmt.Printf("results are written to %s\n", path)
+	}
+}
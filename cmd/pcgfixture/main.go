@@ -0,0 +1,81 @@
+// Command pcgfixture generates the seeds, random polynomials and ring of the given parameters and writes them to a
+// fixture file, which the benchmarks of pcg/bench load instead of running TrustedSeedGen (see PCG_BENCH_FIXTURES).
+// Given an experiment configuration, it generates the fixtures of all points of the experiment into its fixture
+// directory instead (see experiment.Config).
+//
+// Usage:
+//
+//	go run ./cmd/pcgfixture -dir fixtures -N 20 -n 2 -tau 2 -c 4 -t 16
+//	go run ./cmd/pcgfixture -config sweep.yaml
+package main
+
+import (
+	"flag"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/experiment"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory the fixture is written to")
+	lambda := flag.Int("lambda", 128, "security parameter")
+	N := flag.Int("N", 10, "domain of the PCG, i.e. it generates 2^N tuples")
+	n := flag.Int("n", 2, "number of parties")
+	tau := flag.Int("tau", 2, "threshold of the signature scheme")
+	c := flag.Int("c", 4, "first security parameter of the Module-LPN assumption")
+	t := flag.Int("t", 16, "second security parameter of the Module-LPN assumption")
+	configPath := flag.String("config", "", "experiment configuration, whose fixtures are generated instead")
+	flag.Parse()
+
+	if *configPath != "" {
+		generateExperiment(*configPath)
+		return
+	}
+
+	generator, err := pcg.NewPCG(*lambda, *N, *n, *tau, *c, *t)
+	if err != nil {
+		log.Fatal(err)
+	}
+	generate(generator, filepath.Join(*dir, generator.FixtureName()))
+}
+
+// generateExperiment generates the fixtures of all points of the experiment configuration at path.
+func generateExperiment(path string) {
+	config, err := experiment.Load(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if config.Output.Fixtures == "" {
+		log.Fatalf("experiment %q has no fixture directory", config.Name)
+	}
+	defer config.LimitThreads()()
+	for _, point := range config.Points {
+		generator, err := config.NewPCG(point)
+		if err != nil {
+			log.Fatalf("point %s: %v", point, err)
+		}
+		generate(generator, config.FixturePath(generator))
+	}
+}
+
+// generate generates the fixture of the PCG and writes it to path.
+func generate(generator *pcg.PCG, path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	start := time.Now()
+	fixture, err := generator.GenerateFixture()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("generated fixture in %v", time.Since(start))
+
+	if err := generator.SaveFixture(path, fixture); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote fixture to %s", path)
+}
@@ -1,6 +1,8 @@
 package dpf
 
 import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
 	"math/big"
 )
 
@@ -27,13 +29,86 @@ type Key interface {
 	TypeID() KeyType
 }
 
+// DomainKey is implemented by Keys that know the bit length of the domain they were generated for, e.g. to describe
+// keys for debugging without evaluating them.
+type DomainKey interface {
+	Key
+	DomainBitLength() int
+}
+
 // DPF is an interface for Distributed Point Functions.
+// Implementations are only interchangeable if they agree on the OutputGroup, as the outputs of DPFs of different
+// groups do not combine, e.g. when a DSPF aggregates them, even though they share this interface.
 type DPF interface {
 	Gen(specialPointX *big.Int, nonZeroElementY *big.Int) (Key, Key, error)
 	Eval(key Key, x *big.Int) (*big.Int, error)
 	FullEval(key Key) ([]*big.Int, error)
 	FullEvalFast(key Key) ([]*big.Int, error)
 	CombineResults(y1 *big.Int, y2 *big.Int) *big.Int
-	ChangeDomain(domain int)
+	ChangeDomain(domain int) error
 	GetDomain() int
+	// OutputGroup returns the group of the outputs, i.e. of the non-zero element and the partial evaluations, in which
+	// CombineResults adds the partial evaluations of both keys.
+	OutputGroup() OutputGroup
+	// KeyType returns the type of the keys generated and evaluated by the DPF.
+	KeyType() KeyType
+}
+
+// FrOutputGroupName is the name of the output group FrOutputGroup.
+const FrOutputGroupName = "BLS12-381 Fr"
+
+// ErrIncompatibleDPFs is returned by CheckCompatible if two DPFs differ in their output groups or key types.
+var ErrIncompatibleDPFs = errors.New("the DPFs differ in their output groups or key types")
+
+// OutputGroup describes the additive group Z_Modulus the outputs of a DPF live in.
+type OutputGroup struct {
+	Name    string   // Name identifies the group in messages, e.g. FrOutputGroupName.
+	Modulus *big.Int // Modulus is the order of the group.
+}
+
+// FrOutputGroup returns the scalar field Fr of BLS12-381, i.e. the output group of the DPFs of the PCG.
+func FrOutputGroup() OutputGroup {
+	return OutputGroup{Name: FrOutputGroupName, Modulus: new(big.Int).Set(frModulusBig)}
+}
+
+// Equal returns whether both groups have the same modulus. The names are not compared.
+func (g OutputGroup) Equal(o OutputGroup) bool {
+	return g.Modulus != nil && o.Modulus != nil && g.Modulus.Cmp(o.Modulus) == 0
+}
+
+// IsFr returns whether the group is the scalar field Fr of BLS12-381, s.t. the outputs may be aggregated as bls12381.Fr.
+func (g OutputGroup) IsFr() bool {
+	return g.Modulus != nil && g.Modulus.Cmp(frModulusBig) == 0
+}
+
+// String returns the name of the group, or Z_Modulus if it has none.
+func (g OutputGroup) String() string {
+	if g.Name != "" {
+		return g.Name
+	}
+	if g.Modulus == nil {
+		return "Z_?"
+	}
+	return "Z_" + g.Modulus.String()
+}
+
+// CheckCompatible returns ErrIncompatibleDPFs if the DPFs do not share their output group and key type, i.e. if the
+// keys or outputs of one cannot be used with the other.
+func CheckCompatible(a, b DPF) error {
+	if !a.OutputGroup().Equal(b.OutputGroup()) || a.KeyType() != b.KeyType() {
+		return ErrIncompatibleDPFs
+	}
+	return nil
+}
+
+// BlockEvaluator is implemented by DPFs that can stream their full evaluation in blocks of consecutive points,
+// s.t. consumers need not hold the full evaluation of a key in memory.
+type BlockEvaluator interface {
+	FullEvalBlocks(key Key, blockSize int, fn func(offset int, block []*bls12381.Fr) error) error
+}
+
+// PrefixEvaluator is implemented by DPFs that can evaluate the subtree of the domain under a prefix of the input bits
+// (most significant bit first), s.t. a full evaluation can be split into chunks, e.g. across multiple machines.
+type PrefixEvaluator interface {
+	EvalPrefix(key Key, prefix []uint, depth int) ([]*big.Int, error)
 }
@@ -4,6 +4,8 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"math/big"
 )
@@ -51,18 +53,98 @@ func PRG(seed []byte, length int) []byte {
 	return output
 }
 
-func XORBytes(arrays ...[]byte) []byte {
-	// Assume all byte slices have the same length for simplicity.
-	n := len(arrays[0])
-	result := make([]byte, n)
-	for i := 0; i < n; i++ {
-		for _, arr := range arrays {
-			result[i] ^= arr[i]
+// PRGInto writes len(dst) pseudorandom bytes of the PRG (see PRG) for the given seed to dst and returns dst.
+// It computes the AES-CTR key stream block by block, s.t. only the cipher is allocated. This suits the short outputs
+// of tree expansions, while PRG is faster for long outputs.
+func PRGInto(dst, seed []byte) []byte {
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		panic(err)
+	}
+
+	var counter, stream [aes.BlockSize]byte // counter is the big endian block counter, starting at the zero IV
+	for offset := 0; offset < len(dst); offset += aes.BlockSize {
+		block.Encrypt(stream[:], counter[:])
+		copy(dst[offset:], stream[:])
+		for i := aes.BlockSize - 1; i >= 0; i-- {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
 		}
 	}
+	return dst
+}
+
+// ExpandMessageXMD implements expand_message_xmd of RFC 9380 with SHA-256.
+// It expands msg into length uniformly random bytes, domain separated by dst.
+func ExpandMessageXMD(msg, dst []byte, length int) ([]byte, error) {
+	const hashSize = sha256.Size
+	const blockSize = sha256.BlockSize
+	ell := (length + hashSize - 1) / hashSize
+	if ell > 255 || length > 65535 {
+		return nil, errors.New("the requested length is too large for expand_message_xmd")
+	}
+	if len(dst) > 255 {
+		return nil, errors.New("the domain separation tag must be at most 255 bytes long")
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	// b_0 = H(Z_pad || msg || l_i_b_str || 0 || DST_prime)
+	h := sha256.New()
+	h.Write(make([]byte, blockSize))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	// b_i = H(strxor(b_0, b_(i-1)) || i || DST_prime), where b_1 = H(b_0 || 1 || DST_prime)
+	output := make([]byte, 0, ell*hashSize)
+	bi := make([]byte, hashSize)
+	for i := 1; i <= ell; i++ {
+		h.Reset()
+		h.Write(XORBytes(b0, bi))
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		output = append(output, bi...)
+	}
+	return output[:length], nil
+}
+
+// XORBytes returns the XOR of the byte slices, which must all have the same length.
+func XORBytes(arrays ...[]byte) []byte {
+	result := make([]byte, len(arrays[0]))
+	copy(result, arrays[0])
+	for _, arr := range arrays[1:] {
+		XORBytesInto(result, result, arr)
+	}
 	return result
 }
 
+// XORBytesInto writes the XOR of a and b, which must have the same length, to dst and returns dst[:len(a)].
+// dst is reused if its capacity suffices, otherwise a new slice is allocated. dst may alias a or b.
+// The bytes are XORed word-at-a-time, i.e. 8 bytes at once.
+func XORBytesInto(dst, a, b []byte) []byte {
+	n := len(a)
+	if len(b) != n {
+		panic("XORBytesInto: slices must have the same length")
+	}
+	if cap(dst) < n {
+		dst = make([]byte, n)
+	}
+	dst = dst[:n]
+
+	i := 0
+	for ; i+8 <= n; i += 8 {
+		binary.LittleEndian.PutUint64(dst[i:], binary.LittleEndian.Uint64(a[i:])^binary.LittleEndian.Uint64(b[i:]))
+	}
+	for ; i < n; i++ {
+		dst[i] = a[i] ^ b[i]
+	}
+	return dst
+}
+
 // NextPrime returns the next prime number greater than n.
 func NextPrime(n *big.Int) *big.Int {
 	// Make a copy of n to avoid modifying the input value
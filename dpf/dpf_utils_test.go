@@ -1,6 +1,7 @@
 package dpf
 
 import (
+	"encoding/hex"
 	"testing"
 )
 
@@ -82,3 +83,85 @@ func BenchmarkAES256(b *testing.B) {
 		PRG(seed, outputLength)
 	}
 }
+
+// TestExpandMessageXMD tests ExpandMessageXMD against the SHA-256 test vectors of RFC 9380 (Appendix K.1).
+func TestExpandMessageXMD(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	vectors := []struct {
+		msg    string
+		length int
+		want   string
+	}{
+		{"", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	}
+	for _, v := range vectors {
+		got, err := ExpandMessageXMD([]byte(v.msg), dst, v.length)
+		if err != nil {
+			t.Fatalf("ExpandMessageXMD() returned an error: %v", err)
+		}
+		if hex.EncodeToString(got) != v.want {
+			t.Errorf("ExpandMessageXMD(%q) = %x, want %s", v.msg, got, v.want)
+		}
+	}
+
+	// Lengths that are no multiple of the hash size are truncated
+	got, err := ExpandMessageXMD([]byte("abc"), dst, 48)
+	if err != nil || len(got) != 48 {
+		t.Errorf("ExpandMessageXMD() returned %d bytes and error %v, want 48 bytes", len(got), err)
+	}
+
+	if _, err := ExpandMessageXMD(nil, dst, 256*32); err == nil {
+		t.Errorf("ExpandMessageXMD() accepted a length exceeding 255 hash blocks")
+	}
+}
+
+// TestPRGInto tests that PRGInto produces the output of PRG and reuses sufficiently large buffers.
+func TestPRGInto(t *testing.T) {
+	seed := RandomSeed(16)
+	for _, length := range []int{1, 16, 34, 100} {
+		want := PRG(seed, length)
+		if got := PRGInto(nil, seed); len(got) != 0 {
+			t.Errorf("PRGInto() returned %d bytes for an empty buffer", len(got))
+		}
+		buf := make([]byte, length)
+		got := PRGInto(buf, seed)
+		if string(got) != string(want) {
+			t.Errorf("PRGInto() = %x, want %x", got, want)
+		}
+		if &got[0] != &buf[0] {
+			t.Errorf("PRGInto() did not write into the given buffer")
+		}
+	}
+}
+
+// TestXORBytesInto tests XORBytesInto against a bytewise XOR, including aliasing of dst and the inputs.
+func TestXORBytesInto(t *testing.T) {
+	for _, length := range []int{0, 1, 7, 8, 9, 16, 33} {
+		a, b := RandomSeed(length), RandomSeed(length)
+		want := make([]byte, length)
+		for i := range want {
+			want[i] = a[i] ^ b[i]
+		}
+
+		if got := XORBytesInto(nil, a, b); string(got) != string(want) {
+			t.Errorf("XORBytesInto(nil) = %x, want %x", got, want)
+		}
+		if got := XORBytes(a, b); string(got) != string(want) {
+			t.Errorf("XORBytes() = %x, want %x", got, want)
+		}
+
+		// XOR in place
+		aliased := append([]byte{}, a...)
+		if got := XORBytesInto(aliased, aliased, b); string(got) != string(want) || string(aliased) != string(want) {
+			t.Errorf("XORBytesInto(a, a, b) = %x, want %x", got, want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("XORBytesInto() did not panic for inputs of different lengths")
+		}
+	}()
+	XORBytesInto(nil, make([]byte, 2), make([]byte, 3))
+}
@@ -0,0 +1,182 @@
+package dpf
+
+import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"math/big"
+	"math/bits"
+)
+
+// Constant-time arithmetic on elements of the scalar field Fr of BLS12-381. In contrast to the arithmetic of
+// bls12381.Fr, the execution time and memory access pattern of these functions are independent of the values, i.e.
+// they do not branch on secret data. They operate on the limbs directly and are thus independent of the encoding of
+// the limbs (Montgomery or regular form).
+
+// frModulus holds the limbs of the modulus q of Fr in little-endian order.
+var frModulus = [4]uint64{0xffffffff00000001, 0x53bda402fffe5bfe, 0x3339d80809a1d805, 0x73eda753299d7d48}
+
+// frModulusBig is the modulus q of Fr.
+var frModulusBig = func() *big.Int {
+	q := new(big.Int)
+	for k := len(frModulus) - 1; k >= 0; k-- {
+		q.Lsh(q, 64)
+		q.Or(q, new(big.Int).SetUint64(frModulus[k]))
+	}
+	return q
+}()
+
+// frInverseExponent is q-2, s.t. x^(q-2) is the inverse of x for x != 0.
+var frInverseExponent = new(big.Int).Sub(frModulusBig, big.NewInt(2))
+
+// frByteSize is the length of the canonical encoding of an element of Fr (see bls12381.Fr.ToBytes).
+const frByteSize = 32
+
+// Errors of the checked conversions to Fr (see FrFromBigCanonical and FrFromBytesCanonical).
+var (
+	ErrFrOverlong     = errors.New("value exceeds the 32 bytes of an element of Fr")
+	ErrFrNegative     = errors.New("negative value cannot be converted to an element of Fr")
+	ErrFrNonCanonical = errors.New("encoding of an element of Fr is not 32 bytes long or not reduced modulo q")
+)
+
+// ConstantTimeAddFr sets z = x + y in constant time. The inputs must be reduced, i.e. less than q.
+func ConstantTimeAddFr(z, x, y *bls12381.Fr) {
+	var sum, diff [4]uint64
+	var carry, borrow uint64
+	// q < 2^255, hence the sum of two reduced elements does not overflow.
+	sum[0], carry = bits.Add64(x[0], y[0], 0)
+	sum[1], carry = bits.Add64(x[1], y[1], carry)
+	sum[2], carry = bits.Add64(x[2], y[2], carry)
+	sum[3], _ = bits.Add64(x[3], y[3], carry)
+
+	diff[0], borrow = bits.Sub64(sum[0], frModulus[0], 0)
+	diff[1], borrow = bits.Sub64(sum[1], frModulus[1], borrow)
+	diff[2], borrow = bits.Sub64(sum[2], frModulus[2], borrow)
+	diff[3], borrow = bits.Sub64(sum[3], frModulus[3], borrow)
+
+	// Keep the sum if subtracting q borrowed, i.e. if the sum is less than q.
+	mask := -borrow
+	for k := range z {
+		z[k] = sum[k]&mask | diff[k]&^mask
+	}
+}
+
+// ConstantTimeSubFr sets z = x - y in constant time. The inputs must be reduced, i.e. less than q.
+func ConstantTimeSubFr(z, x, y *bls12381.Fr) {
+	var diff [4]uint64
+	var borrow, carry uint64
+	diff[0], borrow = bits.Sub64(x[0], y[0], 0)
+	diff[1], borrow = bits.Sub64(x[1], y[1], borrow)
+	diff[2], borrow = bits.Sub64(x[2], y[2], borrow)
+	diff[3], borrow = bits.Sub64(x[3], y[3], borrow)
+
+	// Add q back if the subtraction borrowed.
+	mask := -borrow
+	z[0], carry = bits.Add64(diff[0], frModulus[0]&mask, 0)
+	z[1], carry = bits.Add64(diff[1], frModulus[1]&mask, carry)
+	z[2], carry = bits.Add64(diff[2], frModulus[2]&mask, carry)
+	z[3], _ = bits.Add64(diff[3], frModulus[3]&mask, carry)
+}
+
+// ConstantTimeNegFr sets z = -x in constant time. The input must be reduced, i.e. less than q.
+func ConstantTimeNegFr(z, x *bls12381.Fr) {
+	// q - x is q for x = 0, hence the result is masked to zero in that case.
+	mask := -uint64(1 ^ ConstantTimeIsZeroFr(x))
+	var borrow uint64
+	z[0], borrow = bits.Sub64(frModulus[0], x[0], 0)
+	z[1], borrow = bits.Sub64(frModulus[1], x[1], borrow)
+	z[2], borrow = bits.Sub64(frModulus[2], x[2], borrow)
+	z[3], _ = bits.Sub64(frModulus[3], x[3], borrow)
+	for k := range z {
+		z[k] &= mask
+	}
+}
+
+// ConstantTimeSelectFr sets z = a if choice is 1 and z = b if choice is 0 in constant time.
+// Its behavior is undefined if choice takes any other value.
+func ConstantTimeSelectFr(z, a, b *bls12381.Fr, choice int) {
+	mask := -uint64(choice)
+	for k := range z {
+		z[k] = a[k]&mask | b[k]&^mask
+	}
+}
+
+// ConstantTimeIsZeroFr returns 1 if x is zero and 0 otherwise in constant time.
+func ConstantTimeIsZeroFr(x *bls12381.Fr) int {
+	or := x[0] | x[1] | x[2] | x[3]
+	return int(1 ^ (or|-or)>>63)
+}
+
+// ConstantTimeEqualFr returns 1 if x equals y and 0 otherwise in constant time. The inputs must be reduced.
+func ConstantTimeEqualFr(x, y *bls12381.Fr) int {
+	diff := (x[0] ^ y[0]) | (x[1] ^ y[1]) | (x[2] ^ y[2]) | (x[3] ^ y[3])
+	return int(1 ^ (diff|-diff)>>63)
+}
+
+// ConstantTimeCompareFr returns -1, 0 or 1 if x is less than, equal to or greater than y in constant time, where the
+// elements are ordered as the integers in [0, q). The inputs must be reduced.
+func ConstantTimeCompareFr(x, y *bls12381.Fr) int {
+	var less, greater uint64
+	// x - y borrows iff x < y, and y - x borrows iff y < x.
+	_, less = bits.Sub64(x[0], y[0], 0)
+	_, less = bits.Sub64(x[1], y[1], less)
+	_, less = bits.Sub64(x[2], y[2], less)
+	_, less = bits.Sub64(x[3], y[3], less)
+	_, greater = bits.Sub64(y[0], x[0], 0)
+	_, greater = bits.Sub64(y[1], x[1], greater)
+	_, greater = bits.Sub64(y[2], x[2], greater)
+	_, greater = bits.Sub64(y[3], x[3], greater)
+	return int(greater) - int(less)
+}
+
+// ConstantTimeInverseFr sets z = x^-1 in constant time via Fermat's little theorem, i.e. z = x^(q-2). In contrast
+// to bls12381.Fr.Inverse (a binary extended Euclidean algorithm), the exponentiation only branches on the public
+// exponent. z is zero for x = 0.
+func ConstantTimeInverseFr(z, x *bls12381.Fr) {
+	z.Exp(x, frInverseExponent)
+}
+
+// ConstantTimeFrFromBig converts a reduced integer, i.e. less than q, to a field element. The integer is encoded into a
+// fixed width of 32 bytes, s.t. the conversion does not depend on its length. Note that math/big itself does not
+// guarantee constant-time operations.
+func ConstantTimeFrFromBig(y *big.Int) *bls12381.Fr {
+	var buf [32]byte
+	if y.BitLen() > 8*len(buf) {
+		// Not a reduced integer and hence not secret-dependent in the intended use; fall back to a reduction.
+		return bls12381.NewFr().FromBytes(y.Bytes())
+	}
+	y.FillBytes(buf[:])
+	return bls12381.NewFr().FromBytes(buf[:])
+}
+
+// Checked conversions to Fr. bls12381.Fr.FromBytes only reduces values greater than q, i.e. it yields the
+// non-canonical limbs of q itself instead of zero, which compare unequal to zero and break the constant-time
+// arithmetic above. The conversions of values from untrusted sources, e.g. deserialized artifacts, remote workers or
+// the inputs of Gen, must therefore use these functions instead.
+
+// FrFromBigCanonical converts a non-negative integer of at most 32 bytes to an element of Fr, reducing it modulo q
+// explicitly. It returns ErrFrNegative or ErrFrOverlong for other integers, as they are no encodings of elements but
+// garbage, e.g. of a failed computation, that must not be silently reduced.
+func FrFromBigCanonical(y *big.Int) (*bls12381.Fr, error) {
+	if y == nil || y.Sign() < 0 {
+		return nil, ErrFrNegative
+	}
+	if y.BitLen() > 8*frByteSize {
+		return nil, ErrFrOverlong
+	}
+	var buf [frByteSize]byte
+	if y.Cmp(frModulusBig) >= 0 {
+		new(big.Int).Mod(y, frModulusBig).FillBytes(buf[:])
+	} else {
+		y.FillBytes(buf[:])
+	}
+	return bls12381.NewFr().FromBytes(buf[:]), nil
+}
+
+// FrFromBytesCanonical decodes the canonical encoding of an element of Fr, i.e. 32 big-endian bytes of a value less
+// than q as output by bls12381.Fr.ToBytes. It returns ErrFrNonCanonical for all other encodings.
+func FrFromBytesCanonical(data []byte) (*bls12381.Fr, error) {
+	if len(data) != frByteSize || new(big.Int).SetBytes(data).Cmp(frModulusBig) >= 0 {
+		return nil, ErrFrNonCanonical
+	}
+	return bls12381.NewFr().FromBytes(data), nil
+}
@@ -0,0 +1,177 @@
+package dpf
+
+import (
+	"crypto/rand"
+	bls12381 "github.com/kilic/bls12-381"
+	"math/big"
+	"testing"
+)
+
+// fieldEdgeCases returns random field elements along with the edge cases 0, 1 and q-1.
+func fieldEdgeCases(t *testing.T) []*bls12381.Fr {
+	minusOne := bls12381.NewFr()
+	minusOne.Neg(bls12381.NewFr().One())
+	values := []*bls12381.Fr{bls12381.NewFr().Zero(), bls12381.NewFr().One(), minusOne}
+	for k := 0; k < 16; k++ {
+		value, err := bls12381.NewFr().Rand(rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to sample a field element: %v", err)
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// TestConstantTimeFr tests that the constant-time arithmetic matches the arithmetic of bls12381.Fr.
+func TestConstantTimeFr(t *testing.T) {
+	values := fieldEdgeCases(t)
+	got, want := bls12381.NewFr(), bls12381.NewFr()
+	for _, x := range values {
+		for _, y := range values {
+			ConstantTimeAddFr(got, x, y)
+			if want.Add(x, y); !got.Equal(want) {
+				t.Errorf("ConstantTimeAddFr(%v, %v) = %v, want %v", x, y, got, want)
+			}
+			ConstantTimeSubFr(got, x, y)
+			if want.Sub(x, y); !got.Equal(want) {
+				t.Errorf("ConstantTimeSubFr(%v, %v) = %v, want %v", x, y, got, want)
+			}
+		}
+		ConstantTimeNegFr(got, x)
+		if want.Neg(x); !got.Equal(want) {
+			t.Errorf("ConstantTimeNegFr(%v) = %v, want %v", x, got, want)
+		}
+		if (ConstantTimeIsZeroFr(x) == 1) != x.IsZero() {
+			t.Errorf("ConstantTimeIsZeroFr(%v) = %d", x, ConstantTimeIsZeroFr(x))
+		}
+		for _, y := range values {
+			if (ConstantTimeEqualFr(x, y) == 1) != x.Equal(y) {
+				t.Errorf("ConstantTimeEqualFr(%v, %v) = %d", x, y, ConstantTimeEqualFr(x, y))
+			}
+			if got, want := ConstantTimeCompareFr(x, y), x.ToBig().Cmp(y.ToBig()); got != want {
+				t.Errorf("ConstantTimeCompareFr(%v, %v) = %d, want %d", x, y, got, want)
+			}
+		}
+		if !x.IsZero() {
+			ConstantTimeInverseFr(got, x)
+			if want.Inverse(x); !got.Equal(want) {
+				t.Errorf("ConstantTimeInverseFr(%v) = %v, want %v", x, got, want)
+			}
+		}
+		if got := ConstantTimeFrFromBig(x.ToBig()); !got.Equal(x) {
+			t.Errorf("ConstantTimeFrFromBig(%v) = %v", x, got)
+		}
+	}
+}
+
+// TestConstantTimeSelectFr tests that ConstantTimeSelectFr selects according to the choice, also in place.
+func TestConstantTimeSelectFr(t *testing.T) {
+	a, b := bls12381.NewFr().One(), bls12381.NewFr().Zero()
+	z := bls12381.NewFr()
+	if ConstantTimeSelectFr(z, a, b, 1); !z.Equal(a) {
+		t.Errorf("ConstantTimeSelectFr() with choice 1 = %v, want %v", z, a)
+	}
+	if ConstantTimeSelectFr(z, a, b, 0); !z.Equal(b) {
+		t.Errorf("ConstantTimeSelectFr() with choice 0 = %v, want %v", z, b)
+	}
+	z.Set(a)
+	if ConstantTimeSelectFr(z, b, z, 0); !z.Equal(a) {
+		t.Errorf("ConstantTimeSelectFr() in place = %v, want %v", z, a)
+	}
+}
+
+// TestConstantTimeFrFromBigUnreduced tests that integers beyond 256 bits are reduced.
+func TestConstantTimeFrFromBigUnreduced(t *testing.T) {
+	y := new(big.Int).Lsh(big.NewInt(1), 300)
+	want := bls12381.NewFr().FromBytes(y.Bytes())
+	if got := ConstantTimeFrFromBig(y); !got.Equal(want) {
+		t.Errorf("ConstantTimeFrFromBig() = %v, want %v", got, want)
+	}
+}
+
+// TestFrFromBigCanonical tests the explicit reduction around the modulus and the rejection of invalid integers.
+func TestFrFromBigCanonical(t *testing.T) {
+	q := frModulusBig
+	for _, tc := range []struct {
+		y    *big.Int
+		want *big.Int
+	}{
+		{big.NewInt(0), big.NewInt(0)},
+		{new(big.Int).Sub(q, big.NewInt(1)), new(big.Int).Sub(q, big.NewInt(1))},
+		{new(big.Int).Set(q), big.NewInt(0)}, // FromBytes keeps the non-canonical limbs of q
+		{new(big.Int).Add(q, big.NewInt(1)), big.NewInt(1)},
+		{new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)), nil},
+	} {
+		got, err := FrFromBigCanonical(tc.y)
+		if err != nil {
+			t.Fatalf("FrFromBigCanonical(%v) failed: %v", tc.y, err)
+		}
+		want := tc.want
+		if want == nil {
+			want = new(big.Int).Mod(tc.y, q)
+		}
+		if got.ToBig().Cmp(want) != 0 || ConstantTimeCompareFr(got, ConstantTimeFrFromBig(want)) != 0 {
+			t.Errorf("FrFromBigCanonical(%v) = %v, want %v", tc.y, got.ToBig(), want)
+		}
+	}
+	if got, _ := FrFromBigCanonical(q); ConstantTimeIsZeroFr(got) != 1 {
+		t.Errorf("FrFromBigCanonical(q) is not zero")
+	}
+
+	if _, err := FrFromBigCanonical(new(big.Int).Lsh(big.NewInt(1), 256)); err != ErrFrOverlong {
+		t.Errorf("FrFromBigCanonical(2^256) = %v, want %v", err, ErrFrOverlong)
+	}
+	if _, err := FrFromBigCanonical(big.NewInt(-1)); err != ErrFrNegative {
+		t.Errorf("FrFromBigCanonical(-1) = %v, want %v", err, ErrFrNegative)
+	}
+	if _, err := FrFromBigCanonical(nil); err != ErrFrNegative {
+		t.Errorf("FrFromBigCanonical(nil) = %v, want %v", err, ErrFrNegative)
+	}
+}
+
+// TestFrFromBytesCanonical tests that exactly the canonical encodings of field elements are accepted.
+func TestFrFromBytesCanonical(t *testing.T) {
+	for _, x := range fieldEdgeCases(t) {
+		if got, err := FrFromBytesCanonical(x.ToBytes()); err != nil || !got.Equal(x) {
+			t.Errorf("FrFromBytesCanonical(%v) = %v, %v", x, got, err)
+		}
+	}
+
+	for _, data := range [][]byte{
+		frModulusBig.FillBytes(make([]byte, 32)), // q
+		new(big.Int).Add(frModulusBig, big.NewInt(1)).FillBytes(make([]byte, 32)),
+		{1},                 // too short
+		make([]byte, 33),    // too long, even if zero
+		make([]byte, 0, 32), // empty
+	} {
+		if _, err := FrFromBytesCanonical(data); err != ErrFrNonCanonical {
+			t.Errorf("FrFromBytesCanonical(%x) = %v, want %v", data, err, ErrFrNonCanonical)
+		}
+	}
+}
+
+// TestOutputGroup tests the descriptor of the output group Fr.
+func TestOutputGroup(t *testing.T) {
+	fr := FrOutputGroup()
+	if !fr.IsFr() || fr.String() != FrOutputGroupName {
+		t.Errorf("FrOutputGroup() = %v, want Fr", fr)
+	}
+	fr.Modulus.SetInt64(0) // the descriptor holds a copy of the modulus
+	if !FrOutputGroup().IsFr() {
+		t.Errorf("modifying the descriptor changed the modulus of Fr")
+	}
+
+	other := OutputGroup{Modulus: new(big.Int).Lsh(big.NewInt(1), 128)}
+	if other.IsFr() || other.Equal(FrOutputGroup()) {
+		t.Errorf("%v must not be Fr", other)
+	}
+	if !other.Equal(OutputGroup{Name: "other", Modulus: new(big.Int).Lsh(big.NewInt(1), 128)}) {
+		t.Errorf("groups of the same modulus must be equal")
+	}
+	if other.String() != "Z_"+other.Modulus.String() {
+		t.Errorf("String() = %v, want Z_2^128", other)
+	}
+	if (OutputGroup{}).Equal(OutputGroup{}) || (OutputGroup{}).IsFr() {
+		t.Errorf("groups without modulus must not be equal")
+	}
+}
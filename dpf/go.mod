@@ -0,0 +1,15 @@
+module github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf
+
+go 1.21.3
+
+require (
+	github.com/kilic/bls12-381 v0.1.0
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.9.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
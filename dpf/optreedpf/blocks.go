@@ -0,0 +1,81 @@
+package optreedpf
+
+import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"math/bits"
+)
+
+// FullEvalBlocks evaluates a DPF key at all points in the domain and streams the results in blocks of blockSize
+// consecutive points to fn, where offset is the point of block[0]. The blocks are passed in ascending order.
+// blockSize must be a power of two of at most 2^DomainBitLength. The block is reused between the calls to fn, i.e.
+// fn must not retain it. If fn returns an error, the evaluation stops and the error is returned.
+func (d *OpTreeDPF) FullEvalBlocks(key dpf.Key, blockSize int, fn func(offset int, block []*bls12381.Fr) error) error {
+	tkey, ok := key.(*Key)
+	if !ok {
+		return errors.New("the given key is not a tree-based DPF key")
+	}
+	if tkey.ID > 1 {
+		return errors.New("the given key is invalid as its ID can only be 0 or 1")
+	}
+	if err := d.checkCorrectionWords(tkey); err != nil {
+		return err
+	}
+	if err := d.checkFullEvalDomain(); err != nil {
+		return err
+	}
+	if blockSize < 1 || blockSize&(blockSize-1) != 0 || blockSize > 1<<d.DomainBitLength {
+		return errors.New("the block size must be a power of two of at most the size of the domain")
+	}
+
+	block := make([]*bls12381.Fr, blockSize)
+	for i := range block {
+		block[i] = bls12381.NewFr()
+	}
+	blockLevels := bits.TrailingZeros(uint(blockSize))
+	return d.traverseBlocks(tkey.S, tkey.ID != 0, tkey.CW, d.DomainBitLength, 0, blockLevels, tkey.ID, block, d.levelBuffers(), fn)
+}
+
+// traverseBlocks descends the tree until the remaining subtree has the size of a block, which it then evaluates.
+// i is the amount of levels below the node and offset is the first point of its subtree.
+// buffers holds a PRG output buffer per level (see traverse).
+func (d *OpTreeDPF) traverseBlocks(s []byte, t bool, CW []CorrectionWord, i, offset, blockLevels int, partyID uint8, block []*bls12381.Fr, buffers [][]byte, fn func(offset int, block []*bls12381.Fr) error) error {
+	if i == blockLevels {
+		if err := d.fillBlock(s, t, CW, i, partyID, block, buffers); err != nil {
+			return err
+		}
+		return fn(offset, block)
+	}
+
+	sl, tl, sr, tr, err := d.expandNode(s, t, CW[d.DomainBitLength-i], buffers[i-1])
+	if err != nil {
+		return err
+	}
+	if err := d.traverseBlocks(sl, tl, CW, i-1, offset, blockLevels, partyID, block, buffers, fn); err != nil {
+		return err
+	}
+	return d.traverseBlocks(sr, tr, CW, i-1, offset+1<<(i-1), blockLevels, partyID, block, buffers, fn)
+}
+
+// fillBlock evaluates the subtree of the node with i levels below it into out, which holds 2^i elements.
+func (d *OpTreeDPF) fillBlock(s []byte, t bool, CW []CorrectionWord, i int, partyID uint8, out []*bls12381.Fr, buffers [][]byte) error {
+	if i == 0 {
+		partialResult, err := d.evalGroupCalcFr(s, CW[d.DomainBitLength].S, partyID, t)
+		if err != nil {
+			return err
+		}
+		out[0].Set(partialResult)
+		return nil
+	}
+
+	sl, tl, sr, tr, err := d.expandNode(s, t, CW[d.DomainBitLength-i], buffers[i-1])
+	if err != nil {
+		return err
+	}
+	half := len(out) / 2
+	if err := d.fillBlock(sl, tl, CW, i-1, partyID, out[:half], buffers); err != nil {
+		return err
+	}
+	return d.fillBlock(sr, tr, CW, i-1, partyID, out[half:], buffers)
+}
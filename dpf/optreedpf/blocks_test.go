@@ -0,0 +1,68 @@
+package optreedpf_test
+
+import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestOpTreeDPFFullEvalBlocks(t *testing.T) {
+	domain := 6
+	d, err := optreedpf.InitFactory(128, domain)
+	assert.Nil(t, err)
+	k1, _, err := d.Gen(big.NewInt(42), big.NewInt(9))
+	assert.Nil(t, err)
+
+	expected, err := d.FullEval(k1)
+	assert.Nil(t, err)
+
+	for blockSize := 1; blockSize <= 1<<domain; blockSize <<= 1 {
+		nextOffset := 0
+		err := d.FullEvalBlocks(k1, blockSize, func(offset int, block []*bls12381.Fr) error {
+			assert.Equal(t, nextOffset, offset)
+			assert.Len(t, block, blockSize)
+			for i, y := range block {
+				assert.Equal(t, 0, y.ToBig().Cmp(expected[offset+i]), "blockSize=%d, x=%d", blockSize, offset+i)
+			}
+			nextOffset += len(block)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1<<domain, nextOffset)
+	}
+}
+
+func TestOpTreeDPFFullEvalBlocksErrors(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 6)
+	assert.Nil(t, err)
+	k1, _, err := d.Gen(big.NewInt(42), big.NewInt(9))
+	assert.Nil(t, err)
+
+	noop := func(offset int, block []*bls12381.Fr) error { return nil }
+	for _, blockSize := range []int{0, 3, 128} {
+		assert.NotNil(t, d.FullEvalBlocks(k1, blockSize, noop))
+	}
+	assert.NotNil(t, d.FullEvalBlocks(&optreedpf.Key{ID: 2}, 8, noop))
+
+	// The error of fn stops the evaluation
+	errStop := errors.New("stop")
+	calls := 0
+	err = d.FullEvalBlocks(k1, 8, func(offset int, block []*bls12381.Fr) error {
+		calls++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, calls)
+}
+
+func BenchmarkOpTreeDPFFullEvalBlocks128_n16(b *testing.B) {
+	d, _ := optreedpf.InitFactory(128, 16)
+	k1, _, _ := d.Gen(big.NewInt(42), big.NewInt(9))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = d.FullEvalBlocks(k1, 1024, func(offset int, block []*bls12381.Fr) error { return nil })
+	}
+}
@@ -0,0 +1,61 @@
+package optreedpf
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/prgsplit"
+)
+
+// convertDST is the domain separation tag of the hash-to-field conversion.
+const convertDST = "pcg-bbs-plus/optreedpf/convert/v1"
+
+// Converter maps the final seed of a path in the DPF tree (lambda/8 bytes) to a field element.
+// Gen and Eval must use the same Converter, as the keys do not record it.
+type Converter interface {
+	Convert(seed []byte) (*bls12381.Fr, error)
+}
+
+// HashToFieldConverter converts seeds via hash-to-field, i.e. expand_message_xmd of RFC 9380 with SHA-256 followed by
+// a reduction mod q. It expands to ceil((log2(q) + lambda)/8) bytes, s.t. the statistical distance of the output to the
+// uniform distribution over Fr is at most 2^-lambda.
+type HashToFieldConverter struct {
+	length int
+}
+
+// NewHashToFieldConverter returns a HashToFieldConverter for the security parameter lambda in bits.
+func NewHashToFieldConverter(lambda int) *HashToFieldConverter {
+	return &HashToFieldConverter{length: (255 + lambda + 7) / 8}
+}
+
+// Convert implements Converter.
+func (c *HashToFieldConverter) Convert(seed []byte) (*bls12381.Fr, error) {
+	uniform, err := dpf.ExpandMessageXMD(seed, []byte(convertDST), c.length)
+	if err != nil {
+		return nil, err
+	}
+	return bls12381.NewFr().FromBytes(uniform), nil // FromBytes reduces mod q
+}
+
+// LegacyConverter converts seeds by reducing the output of the PRG of the DPF mod q.
+// The output is modulo biased, as the PRG outputs only 2*(lambda/8+1) bytes. It is kept to reproduce keys, evaluations
+// and benchmarks of earlier versions.
+type LegacyConverter struct {
+	prgOutputLength int
+}
+
+// NewLegacyConverter returns a LegacyConverter for the security parameter lambda in bits.
+// It uses the same PRG output length as the seed expansion (see prgsplit.NewLayout).
+func NewLegacyConverter(lambda int) (*LegacyConverter, error) {
+	layout, err := prgsplit.NewLayout(lambda)
+	if err != nil {
+		return nil, err
+	}
+	return &LegacyConverter{prgOutputLength: layout.OutputLength()}, nil
+}
+
+// Convert implements Converter.
+func (c *LegacyConverter) Convert(seed []byte) (*bls12381.Fr, error) {
+	// BLS12-381 has a prime order, so we can directly return the group element given by the PRG mod q according to the formal definition.
+	prgOutput := dpf.PRG(seed, c.prgOutputLength)
+	return bls12381.NewFr().FromBytes(prgOutput), nil
+}
@@ -0,0 +1,74 @@
+package optreedpf_test
+
+import (
+	"encoding/hex"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestConvertersGenAndEval(t *testing.T) {
+	for _, lambda := range []int{128, 192, 256} {
+		for _, legacy := range []bool{false, true} {
+			d, err := optreedpf.InitFactory(lambda, 8)
+			assert.Nil(t, err)
+			if legacy {
+				d.UseLegacyConversion()
+			}
+
+			x := big.NewInt(77)
+			y := new(big.Int).Set(d.BetaMax)
+			k1, k2, err := d.Gen(x, y)
+			assert.Nil(t, err)
+
+			res1, err := d.FullEvalFast(k1)
+			assert.Nil(t, err)
+			res2, err := d.FullEvalFast(k2)
+			assert.Nil(t, err)
+			combined, err := d.CombineMultipleResults(res1, res2)
+			assert.Nil(t, err)
+			for i, res := range combined {
+				if i == 77 {
+					assert.Equal(t, y, res)
+				} else {
+					assert.Equal(t, 0, res.Sign())
+				}
+			}
+		}
+	}
+}
+
+func TestConvertersAreIncompatible(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	k1, k2, err := d.Gen(big.NewInt(3), big.NewInt(5))
+	assert.Nil(t, err)
+
+	// Keys generated with the default converter do not evaluate correctly with the legacy converter
+	d.UseLegacyConversion()
+	res1, err := d.Eval(k1, big.NewInt(3))
+	assert.Nil(t, err)
+	res2, err := d.Eval(k2, big.NewInt(3))
+	assert.Nil(t, err)
+	assert.NotEqual(t, big.NewInt(5), d.CombineResults(res1, res2))
+}
+
+func TestHashToFieldConverter(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	c := optreedpf.NewHashToFieldConverter(128)
+
+	e1, err := c.Convert(seed)
+	assert.Nil(t, err)
+	e2, err := c.Convert(seed)
+	assert.Nil(t, err)
+	assert.True(t, e1.Equal(e2))
+
+	seed[0] ^= 1
+	e3, err := c.Convert(seed)
+	assert.Nil(t, err)
+	assert.False(t, e1.Equal(e3))
+
+	_, err = optreedpf.NewLegacyConverter(100)
+	assert.NotNil(t, err)
+}
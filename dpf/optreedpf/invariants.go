@@ -0,0 +1,38 @@
+package optreedpf
+
+import (
+	"errors"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"math/big"
+)
+
+// ErrInvariant is returned by Gen in the pcgdebug mode if the generated keys do not reconstruct the point function.
+var ErrInvariant = errors.New("invariant violated: the generated DPF keys do not reconstruct the point function")
+
+// checkKeyInvariants checks that the keys of Gen reconstruct beta at alpha and 0 at a neighbouring point of alpha.
+// It only runs with the pcgdebug build tag, as it costs two evaluations of each key.
+func (d *OpTreeDPF) checkKeyInvariants(alpha, beta *big.Int, keyAlice, keyBob dpf.Key) error {
+	if !invariantChecks {
+		return nil
+	}
+	other := new(big.Int).Xor(alpha, big.NewInt(1)) // differs from alpha in the last level of the tree
+	for _, point := range []struct {
+		x, y *big.Int
+	}{{alpha, beta}, {other, big.NewInt(0)}} {
+		if point.x.Cmp(d.AlphaMax) == 1 {
+			continue // the domain holds a single point
+		}
+		yAlice, err := d.Eval(keyAlice, point.x)
+		if err != nil {
+			return err
+		}
+		yBob, err := d.Eval(keyBob, point.x)
+		if err != nil {
+			return err
+		}
+		if d.CombineResults(yAlice, yBob).Cmp(point.y) != 0 {
+			return ErrInvariant
+		}
+	}
+	return nil
+}
@@ -0,0 +1,6 @@
+//go:build !pcgdebug
+
+package optreedpf
+
+// invariantChecks disables the invariant checks of Gen (see invariants.go). Build with the pcgdebug tag to enable them.
+const invariantChecks = false
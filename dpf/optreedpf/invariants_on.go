@@ -0,0 +1,6 @@
+//go:build pcgdebug
+
+package optreedpf
+
+// invariantChecks enables the invariant checks of Gen (see invariants.go), as the DPF is built with the pcgdebug tag.
+const invariantChecks = true
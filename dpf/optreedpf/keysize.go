@@ -0,0 +1,34 @@
+package optreedpf
+
+import "github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+
+// MeasureKeySize returns the size in bytes of the serialization of the larger of two keys generated for the
+// given lambda and input domain. The special point and non-zero element are chosen to maximize the size.
+func MeasureKeySize(lambda, inputDomain int) (int, error) {
+	d, err := InitFactory(lambda, inputDomain)
+	if err != nil {
+		return 0, err
+	}
+	return d.KeySize()
+}
+
+// KeySize returns the size in bytes of the serialization of the larger of two keys generated by this DPF.
+// The special point and non-zero element are chosen to maximize the size.
+func (d *OpTreeDPF) KeySize() (int, error) {
+	keyAlice, keyBob, err := d.Gen(d.AlphaMax, d.BetaMax)
+	if err != nil {
+		return 0, err
+	}
+
+	size := 0
+	for _, key := range []dpf.Key{keyAlice, keyBob} {
+		data, err := key.Serialize()
+		if err != nil {
+			return 0, err
+		}
+		if len(data) > size {
+			size = len(data)
+		}
+	}
+	return size, nil
+}
@@ -2,55 +2,238 @@ package optreedpf
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/gob"
 	"errors"
 	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/prgsplit"
 	"math/big"
-	"pcg-bbs-plus/dpf"
+	"math/bits"
 )
 
 // Key is a concrete implementation of the Key interface for this Tree based DPF.
 type Key struct {
-	ID uint8                  // ID identifies the party the key belongs to.
-	S  []byte                 // S is the initial seed.
-	CW map[int]CorrectionWord // CW includes the corrections words.
+	ID uint8            // ID identifies the party the key belongs to.
+	S  []byte           // S is the initial seed.
+	CW []CorrectionWord // CW holds the correction word of each level, i.e. CW[i] is the correction word of level i.
 }
 
-// Serialize serializes the Key into a byte slice for storage or transmission.
-func (k *Key) Serialize() ([]byte, error) {
-	var buffer bytes.Buffer
-	encoder := gob.NewEncoder(&buffer)
+// keyFormatMagic prefixes the binary encoding of a Key (see Serialize). Keys serialized by previous versions are gob
+// encoded, whose streams never start with it.
+var keyFormatMagic = []byte{'O', 'T', 'K', 1}
 
-	if err := encoder.Encode(k); err != nil {
-		return nil, err
+// legacyKey is the gob format of Keys serialized by previous versions with the correction words in level order.
+type legacyKey struct {
+	ID uint8
+	S  []byte
+	CW []CorrectionWord
+}
+
+// legacyMapKey is the initial gob format of Keys, which stored the correction words in a map from their level.
+type legacyMapKey struct {
+	ID uint8
+	S  []byte
+	CW map[int]CorrectionWord
+}
+
+// MaxDomainBitLength is the largest domain bit length supported by the DPF. It bounds the amount of correction words of
+// deserialized keys.
+const MaxDomainBitLength = 1024
+
+// MaxSerializedKeySize is the maximum size of a serialized key accepted by Deserialize. It covers keys of
+// MaxDomainBitLength with lambda=256 in the gob formats of previous versions.
+const MaxSerializedKeySize = 128 << 10
+
+// ErrKeyTooLarge is returned (wrapped in a DeserializeError) if a serialized key exceeds MaxSerializedKeySize.
+var ErrKeyTooLarge = errors.New("the serialized key exceeds the maximum key size")
+
+// DeserializeError is returned by Deserialize if the serialized key is malformed. Serialized keys may cross trust
+// boundaries, hence Deserialize checks all lengths before allocating and the shape of the key after decoding it.
+type DeserializeError struct {
+	Reason string // Reason describes why the key was rejected.
+	Err    error  // Err is the underlying error, e.g. ErrKeyTooLarge or the error of the gob decoder. It may be nil.
+}
+
+func (e *DeserializeError) Error() string {
+	if e.Err != nil {
+		return "invalid serialized key: " + e.Reason + ": " + e.Err.Error()
 	}
+	return "invalid serialized key: " + e.Reason
+}
 
-	return buffer.Bytes(), nil
+func (e *DeserializeError) Unwrap() error {
+	return e.Err
+}
+
+// Serialize serializes the Key into a byte slice for storage or transmission.
+// The encoding is deterministic, s.t. equal keys serialize to equal bytes (e.g. for signing). It consists of
+// keyFormatMagic, the ID, the length-prefixed initial seed and the length-prefixed correction words, each of which is
+// its length-prefixed seed followed by a byte holding Tl and Tr.
+func (k *Key) Serialize() ([]byte, error) {
+	size := len(keyFormatMagic) + 1 + 2*binary.MaxVarintLen64 + len(k.S)
+	for _, cw := range k.CW {
+		size += binary.MaxVarintLen64 + len(cw.S) + 1
+	}
+
+	data := make([]byte, 0, size)
+	data = append(data, keyFormatMagic...)
+	data = append(data, k.ID)
+	data = binary.AppendUvarint(data, uint64(len(k.S)))
+	data = append(data, k.S...)
+	data = binary.AppendUvarint(data, uint64(len(k.CW)))
+	for _, cw := range k.CW {
+		data = binary.AppendUvarint(data, uint64(len(cw.S)))
+		data = append(data, cw.S...)
+		var flags byte
+		if cw.Tl {
+			flags |= 1
+		}
+		if cw.Tr {
+			flags |= 2
+		}
+		data = append(data, flags)
+	}
+	return data, nil
 }
 
 // Deserialize takes a byte slice and populates the Key with the serialized data.
+// It also accepts the gob encoded keys of previous versions (see legacyKey and legacyMapKey).
+// It returns a DeserializeError and leaves the Key unchanged if the data exceeds MaxSerializedKeySize or does not
+// hold a well-formed key, i.e. a key with ID 0 or 1, a seed of lambda/8 bytes for lambda in (128, 192, 256), between
+// 2 and MaxDomainBitLength+1 correction words whose seeds have the length of the initial seed and a final correction
+// word holding the canonical encoding of a field element.
 func (k *Key) Deserialize(data []byte) error {
-	buffer := bytes.NewBuffer(data)
-	decoder := gob.NewDecoder(buffer)
-
-	if err := decoder.Decode(k); err != nil {
+	if len(data) > MaxSerializedKeySize {
+		return &DeserializeError{Reason: "size check", Err: ErrKeyTooLarge}
+	}
+	var key *Key
+	var err error
+	if bytes.HasPrefix(data, keyFormatMagic) {
+		key, err = deserializeBinary(data[len(keyFormatMagic):])
+	} else {
+		key, err = deserializeLegacy(data)
+	}
+	if err != nil {
+		return err
+	}
+	if err := key.checkShape(); err != nil {
 		return err
 	}
 
+	k.ID, k.S, k.CW = key.ID, key.S, key.CW
+	return nil
+}
+
+// deserializeBinary deserializes a key in the binary format of Serialize without keyFormatMagic.
+func deserializeBinary(data []byte) (*Key, error) {
+	reader := bytes.NewReader(data)
+	id, err := reader.ReadByte()
+	if err != nil {
+		return nil, &DeserializeError{Reason: "the serialized key is truncated"}
+	}
+	s, err := readLengthPrefixed(reader)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := binary.ReadUvarint(reader)
+	if err != nil || levels > uint64(reader.Len()) || levels > MaxDomainBitLength+1 { // each correction word takes at least two bytes
+		return nil, &DeserializeError{Reason: "the serialized key holds an invalid amount of correction words"}
+	}
+	cws := make([]CorrectionWord, levels)
+	for i := range cws {
+		if cws[i].S, err = readLengthPrefixed(reader); err != nil {
+			return nil, err
+		}
+		flags, err := reader.ReadByte()
+		if err != nil || flags > 3 {
+			return nil, &DeserializeError{Reason: "the serialized key holds invalid control bits"}
+		}
+		cws[i].Tl, cws[i].Tr = flags&1 != 0, flags&2 != 0
+	}
+	if reader.Len() != 0 {
+		return nil, &DeserializeError{Reason: "the serialized key has trailing bytes"}
+	}
+	return &Key{ID: id, S: s, CW: cws}, nil
+}
+
+// deserializeLegacy deserializes a gob encoded key of a previous version.
+// The size of the data is bounded by MaxSerializedKeySize, which also bounds the allocations of the gob decoder.
+func deserializeLegacy(data []byte) (*Key, error) {
+	var sk legacyKey
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&sk); err == nil {
+		return &Key{ID: sk.ID, S: sk.S, CW: sk.CW}, nil
+	}
+
+	var mk legacyMapKey
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&mk); err != nil {
+		return nil, &DeserializeError{Reason: "the serialized key is neither in the current nor in a legacy format", Err: err}
+	}
+	if len(mk.CW) > MaxDomainBitLength+1 {
+		return nil, &DeserializeError{Reason: "the serialized key holds an invalid amount of correction words"}
+	}
+	cws := make([]CorrectionWord, len(mk.CW))
+	for level := range cws {
+		cw, ok := mk.CW[level]
+		if !ok {
+			return nil, &DeserializeError{Reason: "correction words must be given for the levels [0, len(CW))"}
+		}
+		cws[level] = cw
+	}
+	return &Key{ID: mk.ID, S: mk.S, CW: cws}, nil
+}
+
+// checkShape checks the shape of a deserialized key (see Deserialize). Whether the amount of correction words matches
+// the domain is checked by the evaluations (see checkCorrectionWords), as the domain is not part of the key.
+func (k *Key) checkShape() error {
+	if k.ID > 1 {
+		return &DeserializeError{Reason: "the ID of the key must be 0 or 1"}
+	}
+	if len(k.S) != 16 && len(k.S) != 24 && len(k.S) != 32 {
+		return &DeserializeError{Reason: "the initial seed must be lambda/8 bytes long"}
+	}
+	if len(k.CW) < 2 || len(k.CW) > MaxDomainBitLength+1 {
+		return &DeserializeError{Reason: "the serialized key holds an invalid amount of correction words"}
+	}
+	for _, cw := range k.CW[:len(k.CW)-1] {
+		if len(cw.S) != len(k.S) {
+			return &DeserializeError{Reason: "the seeds of the correction words must have the length of the initial seed"}
+		}
+	}
+	if _, err := dpf.FrFromBytesCanonical(k.CW[len(k.CW)-1].S); err != nil {
+		return &DeserializeError{Reason: "the final correction word must hold a field element", Err: err}
+	}
 	return nil
 }
 
+// readLengthPrefixed reads a byte slice prefixed by its length from the reader.
+func readLengthPrefixed(reader *bytes.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(reader)
+	if err != nil || length > uint64(reader.Len()) {
+		return nil, &DeserializeError{Reason: "the serialized key is truncated"}
+	}
+	data := make([]byte, length)
+	_, _ = reader.Read(data)
+	return data, nil
+}
+
 // TypeID returns the identifier of the Key.
 func (k *Key) TypeID() dpf.KeyType {
 	return dpf.OpTreeDPFKeyID
 }
 
+// DomainBitLength returns the bit length of the domain of the Key, which holds a correction word per level and a final
+// correction word. It returns -1 for empty keys.
+func (k *Key) DomainBitLength() int {
+	return max(len(k.CW)-1, -1)
+}
+
 // EmptyKey creates and returns a new instance of an empty Key.
 func EmptyKey() *Key {
 	return &Key{
 		ID: 2, // ID is set to != 0 and != 1 to indicate an empty key
 		S:  []byte{},
-		CW: make(map[int]CorrectionWord),
+		CW: []CorrectionWord{},
 	}
 }
 
@@ -61,34 +244,51 @@ type CorrectionWord struct {
 }
 
 type OpTreeDPF struct {
-	Lambda          int      // Lambda is the security parameter and interpreted in number of bits.
-	prgOutputLength int      // prgOutputLength sets how many bytes the PRG used in the TreeDPF returns.
-	DomainBitLength int      // DomainBitLength is the bit length of the DPFs input domain.
-	AlphaMax        *big.Int // AlphaMax is the maximum value of the special point. It is equal to 2^DomainBitLength - 1.
-	BetaMax         *big.Int // BetaMax is the maximum value of the non-zero element.
+	Lambda          int             // Lambda is the security parameter and interpreted in number of bits.
+	prgOutputLength int             // prgOutputLength sets how many bytes the PRG used in the TreeDPF returns.
+	layout          prgsplit.Layout // layout defines how the PRG output is split into seeds and control bits.
+	converter       Converter       // converter maps the final seeds of the tree to field elements.
+	constantTime    bool            // constantTime is set if the results are computed and combined in constant time (see SetConstantTime).
+	DomainBitLength int             // DomainBitLength is the bit length of the DPFs input domain.
+	AlphaMax        *big.Int        // AlphaMax is the maximum value of the special point. It is equal to 2^DomainBitLength - 1.
+	BetaMax         *big.Int        // BetaMax is the maximum value of the non-zero element.
 }
 
+// maxFullEvalDomain is the largest domain bit length whose full evaluation can be indexed by an int.
+const maxFullEvalDomain = bits.UintSize - 2
+
 // InitFactory initializes a new OpTreeDPF structure.
 // lambda is the security parameter and interpreted in number of bits.
-// inputDomain describes the bit length of input domain of the DPF. It limits the non-zero element to be within [0, 2^n - 1].
-// The constructor returns an error if lambda is not one of (128, 192, 256).
+// inputDomain describes the bit length of input domain of the DPF. It limits the special point to be within [0, 2^n - 1].
+// The domain is independent of lambda, i.e. any domain of at least one bit is supported, e.g. the small domains of the
+// PCG. The seeds and the conversion of the final seeds only depend on lambda, while the depth of the tree only
+// depends on the domain. Note that the full evaluations are limited to domains of at most maxFullEvalDomain bits.
+// The constructor returns an error if lambda is not one of (128, 192, 256) or the domain is not within
+// [1, MaxDomainBitLength] bits.
 func InitFactory(lambda, inputDomain int) (*OpTreeDPF, error) {
 	if lambda != 128 && lambda != 192 && lambda != 256 {
 		return nil, errors.New("lambda must be 128, 192, or 256")
 
 	}
+	if err := checkDomain(inputDomain); err != nil {
+		return nil, err
+	}
 
-	prgOutputLength := 2 * (lambda/8 + 1)
+	layout, err := prgsplit.NewLayout(lambda)
+	if err != nil {
+		return nil, err
+	}
 
-	alphaMax := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(inputDomain)), nil)
-	alphaMax.Sub(alphaMax, big.NewInt(1))
+	alphaMax := domainMax(inputDomain)
 
 	betaMax, _ := new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
 	betaMax.Sub(betaMax, big.NewInt(1))
 
 	return &OpTreeDPF{
 		Lambda:          lambda,
-		prgOutputLength: prgOutputLength,
+		prgOutputLength: layout.OutputLength(),
+		layout:          layout,
+		converter:       NewHashToFieldConverter(lambda),
 		DomainBitLength: inputDomain,
 		AlphaMax:        alphaMax,
 		BetaMax:         betaMax,
@@ -98,53 +298,67 @@ func InitFactory(lambda, inputDomain int) (*OpTreeDPF, error) {
 // Gen generates two DPF keys based on a given special point and non-zero element.
 // This method follows the Gen algorithm described in the aforementioned paper.
 func (d *OpTreeDPF) Gen(specialPointX *big.Int, nonZeroElementY *big.Int) (dpf.Key, dpf.Key, error) {
-	n := d.DomainBitLength // Syntactic sugar to resemble the formal description of the algorithm.
-	if specialPointX.Cmp(d.AlphaMax) == 1 {
-		return &Key{}, &Key{}, errors.New("the special point is too large. It must be within the Domain of the DPF")
+	if specialPointX.Sign() < 0 || specialPointX.Cmp(d.AlphaMax) == 1 {
+		return &Key{}, &Key{}, errors.New("the special point must be within the domain [0, 2^DomainBitLength - 1] of the DPF")
 
 	}
 
 	beta := nonZeroElementY // Syntactic sugar to resemble the formal description of the algorithm.
+	if beta.Sign() < 0 {
+		return &Key{}, &Key{}, errors.New("the non-zero element must not be negative")
+	}
 	if beta.Cmp(d.BetaMax) == 1 {
 		return &Key{}, &Key{}, errors.New("the non-zero element is too large for the group order used")
 	}
 
+	seedLength := d.Lambda / 8
+	return d.genWithSeeds(specialPointX, beta, dpf.RandomSeed(seedLength), dpf.RandomSeed(seedLength))
+}
+
+// genWithSeeds runs the Gen algorithm with the given initial seeds of Alice and Bob instead of sampling them.
+// It allows to reproduce keys deterministically, e.g. for test vectors.
+func (d *OpTreeDPF) genWithSeeds(specialPointX *big.Int, beta *big.Int, seedAlice, seedBob []byte) (dpf.Key, dpf.Key, error) {
+	n := d.DomainBitLength // Syntactic sugar to resemble the formal description of the algorithm.
+	if len(seedAlice) != d.Lambda/8 || len(seedBob) != d.Lambda/8 {
+		return &Key{}, &Key{}, errors.New("the initial seeds must be lambda/8 bytes long")
+	}
+
 	// Extend the bit length of specialPointX to DomainBitLength.
 	alpha, err := dpf.ExtendBigIntToBitLength(specialPointX, d.DomainBitLength)
 	if err != nil {
 		return &Key{}, &Key{}, err
 	}
 
-	seedLength := d.Lambda / 8
-
 	// Initialize Alice and Bob IDs
 	const ALICE = 0
 	const BOB = 1
-
-	// Initialize nested maps
 	parties := []int{ALICE, BOB}
-	CW := make(map[int]CorrectionWord)
-	s := dpf.InitializeMap2LevelsBytes(parties, dpf.MakeRange(0, n))
-	t := dpf.InitializeMap2LevelsBool(parties, dpf.MakeRange(0, n))
+	CW := make([]CorrectionWord, n+1)
 
 	// Step 2: Initialize with random seeds
-	s[ALICE][0] = dpf.RandomSeed(seedLength)
-	s[BOB][0] = dpf.RandomSeed(seedLength)
+	s := [2][]byte{seedAlice, seedBob}
 
 	// Step 3: Set t0 and t1
-	t[ALICE][0] = false // = 0
-	t[BOB][0] = true    // = 1
+	t := [2]bool{false, true} // = 0, = 1
+
+	// The PRG outputs of each party alternate between two buffers, s.t. the seeds of the previous level (which are
+	// sub-slices of the other buffer) stay valid while expanding them.
+	var buffers [2][2][]byte
+	for party := range parties {
+		for i := range buffers[party] {
+			buffers[party][i] = make([]byte, d.prgOutputLength)
+		}
+	}
 
 	// Step 4: Create Tree
 	const L = 0
 	const R = 1
-	sTmp := dpf.InitializeMap2LevelsBytes(parties, []int{L, R})
-	tTmp := dpf.InitializeMap2LevelsBool(parties, []int{L, R})
+	var sTmp [2][2][]byte
+	var tTmp [2][2]bool
 	for i := 1; i <= n; i++ {
 		// Step 5: Call PRG
 		for party := range parties {
-			prgOutput := dpf.PRG(s[party][i-1], d.prgOutputLength)
-			sTmp[party][L], tTmp[party][L], sTmp[party][R], tTmp[party][R], err = splitPRGOutput(prgOutput, d.Lambda)
+			sTmp[party][L], tTmp[party][L], sTmp[party][R], tTmp[party][R], err = d.layout.ExpandInto(buffers[party][i%2], s[party])
 			if err != nil {
 				return nil, nil, err
 			}
@@ -158,8 +372,8 @@ func (d *OpTreeDPF) Gen(specialPointX *big.Int, nonZeroElementY *big.Int) (dpf.K
 			loose = L
 		}
 
-		sCW := dpf.XORBytes(sTmp[ALICE][loose], sTmp[BOB][loose])
-		tCW := make([]bool, 2)
+		sCW := dpf.XORBytesInto(nil, sTmp[ALICE][loose], sTmp[BOB][loose])
+		var tCW [2]bool
 		tCW[L] = tTmp[ALICE][L] != tTmp[BOB][L] != alphaBool != true // != is eq to XOR
 		tCW[R] = tTmp[ALICE][R] != tTmp[BOB][R] != alphaBool
 
@@ -172,25 +386,23 @@ func (d *OpTreeDPF) Gen(specialPointX *big.Int, nonZeroElementY *big.Int) (dpf.K
 		// Step 12-13: Set next S and t
 		for party := range parties {
 			// t_b^(i-1) is the previous control bit
-			tPrevBit := t[party][i-1]
-
-			// Update seeds and control bits
-			if tPrevBit {
-				// If tPrevBit is true, XOR with correction word
-				s[party][i] = dpf.XORBytes(sTmp[party][keep], sCW)
-				t[party][i] = tTmp[party][keep] != tCW[keep] // != is eq to XOR
+			if t[party] {
+				// If the previous control bit is true, XOR with correction word (in place, as sTmp is not used afterward)
+				s[party] = dpf.XORBytesInto(sTmp[party][keep], sTmp[party][keep], sCW)
+				t[party] = tTmp[party][keep] != tCW[keep] // != is eq to XOR
 			} else {
-				// If tPrevBit is false, use the value from the keep branch
-				s[party][i] = sTmp[party][keep]
-				t[party][i] = tTmp[party][keep]
+				// If the previous control bit is false, use the value from the keep branch
+				s[party] = sTmp[party][keep]
+				t[party] = tTmp[party][keep]
 			}
 		}
 	}
 
 	// Step 15: Compute final "Correction Word" and hide beta in it.
-	finalSeedAlice := new(big.Int).SetBytes(s[ALICE][n])
-	finalSeedBob := new(big.Int).SetBytes(s[BOB][n])
-	res, err := d.genGroupCalc(finalSeedAlice, finalSeedBob, beta, t[BOB][n])
+	res, err := d.genGroupCalc(s[ALICE], s[BOB], beta, t[BOB])
+	if err != nil {
+		return nil, nil, err
+	}
 
 	CW[n] = CorrectionWord{
 		S:  res,
@@ -201,14 +413,17 @@ func (d *OpTreeDPF) Gen(specialPointX *big.Int, nonZeroElementY *big.Int) (dpf.K
 	// Step 16: Create DPF keys
 	keyAlice := Key{
 		ID: ALICE,
-		S:  s[ALICE][0],
+		S:  seedAlice,
 		CW: CW,
 	}
 	keyBob := Key{
 		ID: BOB,
-		S:  s[BOB][0],
+		S:  seedBob,
 		CW: CW,
 	}
+	if err := d.checkKeyInvariants(specialPointX, beta, &keyAlice, &keyBob); err != nil {
+		return nil, nil, err
+	}
 	return &keyAlice, &keyBob, nil
 }
 
@@ -223,10 +438,13 @@ func (d *OpTreeDPF) Eval(key dpf.Key, x *big.Int) (*big.Int, error) {
 	if tkey.ID > 1 {
 		return nil, errors.New("the given key is invalid as its ID can only be 0 or 1")
 	}
+	if err := d.checkCorrectionWords(tkey); err != nil {
+		return nil, err
+	}
 
 	n := d.DomainBitLength
-	if x.Cmp(d.AlphaMax) == 1 {
-		return nil, errors.New("the given point is too large. It must be within [0, 2^Lambda - 1]")
+	if x.Sign() < 0 || x.Cmp(d.AlphaMax) == 1 {
+		return nil, errors.New("the given point must be within the domain [0, 2^DomainBitLength - 1] of the DPF")
 	}
 
 	a, err := dpf.ExtendBigIntToBitLength(x, d.DomainBitLength)
@@ -237,26 +455,11 @@ func (d *OpTreeDPF) Eval(key dpf.Key, x *big.Int) (*big.Int, error) {
 	// Step: 1: Parse key
 	s := tkey.S
 	t := tkey.ID != 0 // Interpret ID as boolean
+	// tau alternates between two buffers, s.t. s (a sub-slice of the previous tau) stays valid while expanding it.
+	buffers := [2][]byte{make([]byte, d.prgOutputLength), make([]byte, d.prgOutputLength)}
 	for i := 1; i <= n; i++ {
-		// Step 3: Parse correction word
-		scw := tkey.CW[i-1].S
-		tcwl := tkey.CW[i-1].Tl
-		tcwr := tkey.CW[i-1].Tr
-
-		// Step 4: Calculate tau
-		tau := dpf.PRG(s, d.prgOutputLength)
-		if t {
-			appendedSlices := append(scw, boolToByteSlice(tcwl)...)
-			appendedSlices = append(appendedSlices, scw...)
-			appendedSlices = append(appendedSlices, boolToByteSlice(tcwr)...)
-			if len(appendedSlices) != len(tau) {
-				return nil, errors.New("length of appended slices does not match length of tau")
-			}
-			tau = dpf.XORBytes(tau, appendedSlices)
-		}
-
-		// Step 5: Parse tau as PRG output
-		sl, tl, sr, tr, err := splitPRGOutput(tau, d.Lambda)
+		// Step 3-5: Calculate tau via the correction word and parse it as PRG output
+		sl, tl, sr, tr, err := d.expandNode(s, t, tkey.CW[i-1], buffers[i%2])
 		if err != nil {
 			return nil, err
 		}
@@ -271,8 +474,7 @@ func (d *OpTreeDPF) Eval(key dpf.Key, x *big.Int) (*big.Int, error) {
 		}
 	}
 	// Step 10: Calculate partial result
-	finalSeed := new(big.Int).SetBytes(s)
-	partialResult, err := d.evalGroupCalc(finalSeed, tkey.CW[n].S, tkey.ID, t)
+	partialResult, err := d.evalGroupCalc(s, tkey.CW[n].S, tkey.ID, t)
 	if err != nil {
 		return nil, err
 	}
@@ -283,9 +485,25 @@ func (d *OpTreeDPF) GetDomain() int {
 	return d.DomainBitLength
 }
 
+// OutputGroup implements dpf.DPF. The outputs are elements of Fr, i.e. the DPF is a backend of the PCG.
+func (d *OpTreeDPF) OutputGroup() dpf.OutputGroup {
+	return dpf.FrOutputGroup()
+}
+
+// KeyType implements dpf.DPF.
+func (d *OpTreeDPF) KeyType() dpf.KeyType {
+	return dpf.OpTreeDPFKeyID
+}
+
 // CombineResults combines the results of two partial evaluations into a single result.
-// It performs simple finite field addition.
+// It performs simple finite field addition in the output group Fr, in constant time if the DPF is set to constant time
+// (see SetConstantTime).
 func (d *OpTreeDPF) CombineResults(y1 *big.Int, y2 *big.Int) *big.Int {
+	if d.constantTime {
+		res := dpf.ConstantTimeFrFromBig(y1)
+		dpf.ConstantTimeAddFr(res, res, dpf.ConstantTimeFrFromBig(y2))
+		return res.ToBig()
+	}
 	y1C := bls12381.NewFr().FromBytes(y1.Bytes())
 	y2C := bls12381.NewFr().FromBytes(y2.Bytes())
 
@@ -320,15 +538,24 @@ func (d *OpTreeDPF) FullEval(key dpf.Key) ([]*big.Int, error) {
 	if tkey.ID > 1 {
 		return nil, errors.New("the given key is invalid as its ID can only be 0 or 1")
 	}
+	if err := d.checkCorrectionWords(tkey); err != nil {
+		return nil, err
+	}
+	if err := d.checkFullEvalDomain(); err != nil {
+		return nil, err
+	}
 
 	initT := tkey.ID != 0 // Interpret ID as boolean
 	initS := tkey.S
 
-	res, err := d.traverse(initS, initT, &tkey.CW, d.DomainBitLength, tkey.ID)
+	res, err := d.traverse(initS, initT, tkey.CW, d.DomainBitLength, tkey.ID, d.levelBuffers())
 
 	if err != nil {
 		return nil, err
 	}
+	if err := d.checkOutputLength(res); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
@@ -343,49 +570,44 @@ func (d *OpTreeDPF) FullEvalFast(key dpf.Key) ([]*big.Int, error) {
 	if tkey.ID > 1 {
 		return nil, errors.New("the given key is invalid as its ID can only be 0 or 1")
 	}
+	if err := d.checkCorrectionWords(tkey); err != nil {
+		return nil, err
+	}
+	if err := d.checkFullEvalDomain(); err != nil {
+		return nil, err
+	}
 
 	initT := tkey.ID != 0 // Interpret ID as boolean
 	initS := tkey.S
 
-	res, err := d.traverse(initS, initT, &tkey.CW, d.DomainBitLength, tkey.ID)
+	res, err := d.traverse(initS, initT, tkey.CW, d.DomainBitLength, tkey.ID, d.levelBuffers())
 
 	if err != nil {
 		return nil, err
 	}
+	if err := d.checkOutputLength(res); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
-func (d *OpTreeDPF) traverse(s []byte, t bool, CW *map[int]CorrectionWord, i int, partyID uint8) ([]*big.Int, error) {
+// traverse evaluates the subtree of the node with seed s and control bit t, which has i levels below it.
+// buffers holds a PRG output buffer per level, s.t. the expansions of the depth-first traversal do not allocate.
+func (d *OpTreeDPF) traverse(s []byte, t bool, CW []CorrectionWord, i int, partyID uint8, buffers [][]byte) ([]*big.Int, error) {
 	if i > 0 {
-		pos := d.DomainBitLength - i
-
-		// Generate tau
-		tau := dpf.PRG(s, d.prgOutputLength)
-		if t {
-			appendedSlices := append(append(append(make([]byte, 0, len(s)+2*len((*CW)[pos].S)), (*CW)[pos].S...), boolToByteSlice((*CW)[pos].Tl)...), (*CW)[pos].S...)
-			appendedSlices = append(appendedSlices, boolToByteSlice((*CW)[pos].Tr)...)
-			if len(appendedSlices) != len(tau) {
-				return nil, errors.New("length of appended slices does not match length of tau")
-			}
-			tau = dpf.XORBytes(tau, appendedSlices)
-		}
-
-		// Parse tau as PRG output
-		sl, tl, sr, tr, err := splitPRGOutput(tau, d.Lambda)
+		sl, tl, sr, tr, err := d.expandNode(s, t, CW[d.DomainBitLength-i], buffers[i-1])
 		if err != nil {
 			return nil, err
 		}
 
-		// Clear the parent slice to free memory
-		tau = nil
-
-		left, err := d.traverse(sl, tl, CW, i-1, partyID)
+		// The children are sub-slices of buffers[i-1], which is not used by the traversal of the subtree of sl
+		left, err := d.traverse(sl, tl, CW, i-1, partyID, buffers)
 		if err != nil {
 			return nil, err
 		}
 		defer func() { left = nil }()
 
-		right, err := d.traverse(sr, tr, CW, i-1, partyID)
+		right, err := d.traverse(sr, tr, CW, i-1, partyID, buffers)
 		if err != nil {
 			return nil, err
 		}
@@ -396,8 +618,7 @@ func (d *OpTreeDPF) traverse(s []byte, t bool, CW *map[int]CorrectionWord, i int
 
 		return result, nil
 	} else {
-		finalSeed := new(big.Int).SetBytes(s)
-		partialResult, err := d.evalGroupCalc(finalSeed, (*CW)[d.DomainBitLength].S, partyID, t)
+		partialResult, err := d.evalGroupCalc(s, CW[d.DomainBitLength].S, partyID, t)
 		if err != nil {
 			return nil, err
 		}
@@ -405,15 +626,82 @@ func (d *OpTreeDPF) traverse(s []byte, t bool, CW *map[int]CorrectionWord, i int
 	}
 }
 
-// ChangeDomain changes the domain of the DPF.
-func (d *OpTreeDPF) ChangeDomain(domain int) {
+// expandNode expands the node with seed s and control bit t into its children using the correction word of its level.
+// The PRG output is written to out, which must hold prgOutputLength bytes, and the children are sub-slices of it.
+func (d *OpTreeDPF) expandNode(s []byte, t bool, cw CorrectionWord, out []byte) ([]byte, bool, []byte, bool, error) {
+	// Generate tau
+	tau := d.layout.Backend().ExpandInto(out, s)
+	if t {
+		if err := d.layout.Correct(tau, cw.S, cw.Tl, cw.Tr); err != nil {
+			return nil, false, nil, false, err
+		}
+	}
+
+	// Parse tau as PRG output
+	return d.layout.Split(tau)
+}
+
+// levelBuffers returns a PRG output buffer for each level of the tree (see traverse).
+func (d *OpTreeDPF) levelBuffers() [][]byte {
+	backing := make([]byte, d.DomainBitLength*d.prgOutputLength)
+	buffers := make([][]byte, d.DomainBitLength)
+	for i := range buffers {
+		buffers[i] = backing[i*d.prgOutputLength : (i+1)*d.prgOutputLength : (i+1)*d.prgOutputLength]
+	}
+	return buffers
+}
+
+// checkCorrectionWords checks that the key holds a correction word for each level of the domain and the final one.
+func (d *OpTreeDPF) checkCorrectionWords(key *Key) error {
+	if len(key.CW) != d.DomainBitLength+1 {
+		return errors.New("the given key does not hold a correction word for each level of the domain of the DPF")
+	}
+	return nil
+}
+
+// checkFullEvalDomain checks that the full evaluation of the domain can be indexed by an int.
+func (d *OpTreeDPF) checkFullEvalDomain() error {
+	if d.DomainBitLength > maxFullEvalDomain {
+		return errors.New("the domain of the DPF is too large for a full evaluation")
+	}
+	return nil
+}
+
+// checkOutputLength checks that a full evaluation holds exactly one result for each point of the domain.
+func (d *OpTreeDPF) checkOutputLength(res []*big.Int) error {
+	if len(res) != 1<<d.DomainBitLength {
+		return errors.New("the full evaluation does not hold a result for each point of the domain")
+	}
+	return nil
+}
+
+// ChangeDomain changes the domain of the DPF. It returns an error and keeps the domain if the domain is not within
+// [1, MaxDomainBitLength] bits (see InitFactory).
+func (d *OpTreeDPF) ChangeDomain(domain int) error {
+	if err := checkDomain(domain); err != nil {
+		return err
+	}
 	d.DomainBitLength = domain
-	d.AlphaMax = new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(domain)), nil)
-	d.AlphaMax.Sub(d.AlphaMax, big.NewInt(1))
+	d.AlphaMax = domainMax(domain)
+	return nil
+}
+
+// checkDomain checks that the domain bit length is supported by the DPF.
+func checkDomain(domain int) error {
+	if domain < 1 || domain > MaxDomainBitLength {
+		return errors.New("the domain of the DPF must be within [1, MaxDomainBitLength] bits")
+	}
+	return nil
+}
+
+// domainMax returns the largest point 2^domain - 1 of the domain.
+func domainMax(domain int) *big.Int {
+	alphaMax := new(big.Int).Lsh(big.NewInt(1), uint(domain))
+	return alphaMax.Sub(alphaMax, big.NewInt(1))
 }
 
 // genGroupCalc calculates the group element representation of the final correction word.
-func (d *OpTreeDPF) genGroupCalc(finalSeedAlice, finalSeedBob, beta *big.Int, t bool) ([]byte, error) {
+func (d *OpTreeDPF) genGroupCalc(finalSeedAlice, finalSeedBob []byte, beta *big.Int, t bool) ([]byte, error) {
 	finalSeedAliceC, err := d.convert(finalSeedAlice)
 	if err != nil {
 		return nil, err
@@ -423,7 +711,10 @@ func (d *OpTreeDPF) genGroupCalc(finalSeedAlice, finalSeedBob, beta *big.Int, t
 		return nil, err
 	}
 
-	betaC := bls12381.NewFr().FromBytes(beta.Bytes())
+	betaC, err := dpf.FrFromBigCanonical(beta)
+	if err != nil {
+		return nil, err
+	}
 
 	// Calculate beta - finalSeedAliceC + finalSeedBobC:
 	finalSeedAliceC.Neg(finalSeedAliceC)
@@ -439,59 +730,86 @@ func (d *OpTreeDPF) genGroupCalc(finalSeedAlice, finalSeedBob, beta *big.Int, t
 }
 
 // evalGroupCalc calculates a partial result from the final seed.
-func (d *OpTreeDPF) evalGroupCalc(finalSeed *big.Int, cw []byte, id uint8, t bool) (*big.Int, error) {
+func (d *OpTreeDPF) evalGroupCalc(finalSeed []byte, cw []byte, id uint8, t bool) (*big.Int, error) {
+	res, err := d.evalGroupCalcFr(finalSeed, cw, id, t)
+	if err != nil {
+		return nil, err
+	}
+	return res.ToBig(), nil
+}
+
+// evalGroupCalcFr calculates a partial result from the final seed as field element.
+func (d *OpTreeDPF) evalGroupCalcFr(finalSeed []byte, cw []byte, id uint8, t bool) (*bls12381.Fr, error) {
 	finalSeedC, err := d.convert(finalSeed)
 	if err != nil {
 		return nil, err
 	}
-	cwC := bls12381.NewFr().FromBytes(cw)
-	res := bls12381.NewFr().Set(finalSeedC)
+	if d.constantTime {
+		// Select the (masked) correction word and the negation instead of branching on the control bit and the ID.
+		var masked, negated bls12381.Fr
+		dpf.ConstantTimeSelectFr(&masked, bls12381.NewFr().FromBytes(cw), &masked, boolToInt(t))
+		dpf.ConstantTimeAddFr(finalSeedC, finalSeedC, &masked)
+		dpf.ConstantTimeNegFr(&negated, finalSeedC)
+		dpf.ConstantTimeSelectFr(finalSeedC, &negated, finalSeedC, int(id&1))
+		return finalSeedC, nil
+	}
+	res := finalSeedC
 	if t {
-		res.Add(finalSeedC, cwC)
+		res.Add(finalSeedC, bls12381.NewFr().FromBytes(cw))
 	}
 	if id == 1 {
 		res.Neg(res)
 	}
 
-	return res.ToBig(), nil
+	return res, nil
 }
 
-// convert converts a given big.Int to a group element.
-func (d *OpTreeDPF) convert(input *big.Int) (*bls12381.Fr, error) {
-	inputExtended, err := dpf.ExtendBigIntToBitLength(input, d.Lambda)
-	if err != nil {
-		return nil, err
-	}
-	inputExBytes := dpf.ConvertBitArrayToBytes(inputExtended)
-
-	// BLS12-381 has a prime order, so we can directly return the group element given by the PRG mod q according to the formal definition.
-	prgOutput := dpf.PRG(inputExBytes, d.prgOutputLength)
-	element := bls12381.NewFr().FromBytes(prgOutput)
-
-	return element, nil
+// SetConstantTime sets whether the partial results are computed and combined in constant time, i.e. without branching
+// on the control bits, the ID of the key or the values of the results. It is disabled by default and enabled in the
+// hardened mode of the PCG. The keys are compatible either way.
+// Note that the conversions between big.Int and field elements at the interface of the DPF are not constant time.
+func (d *OpTreeDPF) SetConstantTime(enabled bool) {
+	d.constantTime = enabled
 }
 
-// splitPRGOutput splits the output of the PRG into two seeds and two control bits.
-func splitPRGOutput(prgOutput []byte, lambda int) ([]byte, bool, []byte, bool, error) {
-	lambdaBytes := lambda / 8
-	if len(prgOutput) < 2*(lambdaBytes+1) {
-		return nil, false, nil, false, errors.New("insufficient length of PRG output")
+// boolToInt returns 1 for true and 0 for false.
+func boolToInt(b bool) int {
+	if b {
+		return 1
 	}
+	return 0
+}
+
+// SetConverter sets the conversion of the final seeds to field elements. By default, a HashToFieldConverter is used.
+// Keys are only compatible with a DPF using the same converter as the DPF that generated them.
+func (d *OpTreeDPF) SetConverter(converter Converter) {
+	d.converter = converter
+}
 
-	sL := prgOutput[:lambdaBytes]
-	tL := (prgOutput[lambdaBytes] & 1) != 0 // First bit of the second byte
-	sR := prgOutput[lambdaBytes+1 : 2*lambdaBytes+1]
-	tR := (prgOutput[2*(lambdaBytes)+1] & 1) != 0 // First bit of the last byte
+// SetPRG sets the PRG backend of the seed expansion. By default, the dpf.AESPRG is used.
+// Keys are only compatible with a DPF using the same PRG backend as the DPF that generated them.
+func (d *OpTreeDPF) SetPRG(prg dpf.PRGBackend) {
+	d.layout.PRG = prg
+}
 
-	return sL, tL, sR, tR, nil
+// UseLegacyConversion switches to the (modulo biased) LegacyConverter to reproduce keys of earlier versions.
+func (d *OpTreeDPF) UseLegacyConversion() {
+	converter, _ := NewLegacyConverter(d.Lambda) // lambda is validated by InitFactory
+	d.converter = converter
 }
 
-func boolToByteSlice(b bool) []byte {
-	var byteValue byte
-	if b {
-		byteValue = 1
-	} else {
-		byteValue = 0
+// convert converts a final seed of lambda/8 bytes to a group element.
+// The seed is passed to the converter in the encoding of dpf.ConvertBitArrayToBytes of its bits, which reverses the
+// order of all bits, i.e. the k-th byte is the bit reversal of the k-th byte from the end. It is computed directly
+// to avoid the allocation of the bit array per leaf of the tree.
+func (d *OpTreeDPF) convert(seed []byte) (*bls12381.Fr, error) {
+	if len(seed) != d.Lambda/8 {
+		return nil, errors.New("the final seed must be lambda/8 bytes long")
+	}
+	var buf [32]byte // lambda <= 256
+	reversed := buf[:len(seed)]
+	for k := range reversed {
+		reversed[k] = bits.Reverse8(seed[len(seed)-1-k])
 	}
-	return []byte{byteValue}
+	return d.converter.Convert(reversed)
 }
@@ -1,10 +1,15 @@
 package optreedpf_test
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
 	"github.com/stretchr/testify/assert"
 	"math/big"
-	"pcg-bbs-plus/dpf/optreedpf"
 
 	"testing"
 )
@@ -44,6 +49,164 @@ func TestOpTreeDPFKeySerializationAndDeserialization(t *testing.T) {
 	assert.Nil(t, err)
 
 	assert.Equal(t, k1, deserialized)
+
+	// The encoding is deterministic
+	for i := 0; i < 10; i++ {
+		again, err := deserialized.Serialize()
+		assert.Nil(t, err)
+		assert.Equal(t, serialized, again)
+	}
+}
+
+func TestOpTreeDPFKeyDeserializeLegacy(t *testing.T) {
+	d, _ := optreedpf.InitFactory(128, 10)
+	k1, _, err := d.Gen(big.NewInt(5), big.NewInt(10))
+	assert.Nil(t, err)
+	key := k1.(*optreedpf.Key)
+	expected, err := d.FullEval(key)
+	assert.Nil(t, err)
+
+	// Gob encodings of the previous formats with the correction words as a slice and as a map from their level
+	cwMap := make(map[int]optreedpf.CorrectionWord, len(key.CW))
+	for level, cw := range key.CW {
+		cwMap[level] = cw
+	}
+	legacyFormats := []interface{}{
+		struct {
+			ID uint8
+			S  []byte
+			CW []optreedpf.CorrectionWord
+		}{key.ID, key.S, key.CW},
+		struct {
+			ID uint8
+			S  []byte
+			CW map[int]optreedpf.CorrectionWord
+		}{key.ID, key.S, cwMap},
+	}
+
+	current, err := key.Serialize()
+	assert.Nil(t, err)
+	for _, legacy := range legacyFormats {
+		var buffer bytes.Buffer
+		assert.Nil(t, gob.NewEncoder(&buffer).Encode(legacy))
+		assert.Greater(t, buffer.Len(), len(current))
+
+		deserialized := new(optreedpf.Key)
+		assert.Nil(t, deserialized.Deserialize(buffer.Bytes()))
+		assert.Equal(t, key, deserialized)
+		res, err := d.FullEval(deserialized)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, res)
+
+		// Migrated keys serialize to the current format
+		migrated, err := deserialized.Serialize()
+		assert.Nil(t, err)
+		assert.Equal(t, current, migrated)
+	}
+}
+
+func TestOpTreeDPFKeyDeserializeInvalid(t *testing.T) {
+	d, _ := optreedpf.InitFactory(128, 16)
+	k1, _, err := d.Gen(big.NewInt(5), big.NewInt(10))
+	assert.Nil(t, err)
+	serialized, err := k1.Serialize()
+	assert.Nil(t, err)
+
+	for _, data := range [][]byte{serialized[:len(serialized)-1], append(serialized, 0), serialized[:5], {}} {
+		assert.NotNil(t, new(optreedpf.Key).Deserialize(data))
+	}
+
+	// Keys must hold a correction word per level
+	key := k1.(*optreedpf.Key)
+	truncated := &optreedpf.Key{ID: key.ID, S: key.S, CW: key.CW[:len(key.CW)-1]}
+	_, err = d.FullEval(truncated)
+	assert.NotNil(t, err)
+	_, err = d.Eval(truncated, big.NewInt(5))
+	assert.NotNil(t, err)
+
+	// The final correction word must be reduced modulo q
+	q, _ := new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+	cw := append([]optreedpf.CorrectionWord(nil), key.CW...)
+	cw[len(cw)-1] = optreedpf.CorrectionWord{S: q.FillBytes(make([]byte, 32))}
+	serialized, err = (&optreedpf.Key{ID: key.ID, S: key.S, CW: cw}).Serialize()
+	assert.Nil(t, err)
+	err = new(optreedpf.Key).Deserialize(serialized)
+	assert.True(t, errors.Is(err, dpf.ErrFrNonCanonical))
+}
+
+func TestOpTreeDPFKeyDeserializeLimits(t *testing.T) {
+	d, _ := optreedpf.InitFactory(128, 8)
+	k1, _, err := d.Gen(big.NewInt(5), big.NewInt(10))
+	assert.Nil(t, err)
+	key := k1.(*optreedpf.Key)
+
+	var deserializeErr *optreedpf.DeserializeError
+	assert.ErrorIs(t, new(optreedpf.Key).Deserialize(make([]byte, optreedpf.MaxSerializedKeySize+1)), optreedpf.ErrKeyTooLarge)
+
+	// Keys of a valid encoding but an invalid shape are rejected and leave the key unchanged
+	final := key.CW[len(key.CW)-1]
+	malformed := []*optreedpf.Key{
+		{ID: 2, S: key.S, CW: key.CW},
+		{ID: 0, S: key.S[:15], CW: key.CW},
+		{ID: 0, S: key.S, CW: key.CW[len(key.CW)-1:]},
+		{ID: 0, S: key.S, CW: append([]optreedpf.CorrectionWord{{S: make([]byte, 24)}}, key.CW[1:]...)},
+		{ID: 0, S: key.S, CW: append(append([]optreedpf.CorrectionWord{}, key.CW[:len(key.CW)-1]...), optreedpf.CorrectionWord{S: final.S[:16]})},
+		{ID: 0, S: key.S, CW: make([]optreedpf.CorrectionWord, optreedpf.MaxDomainBitLength+2)},
+	}
+	for _, m := range malformed {
+		data, err := m.Serialize()
+		assert.Nil(t, err)
+		deserialized := optreedpf.EmptyKey()
+		assert.True(t, errors.As(deserialized.Deserialize(data), &deserializeErr))
+		assert.Equal(t, optreedpf.EmptyKey(), deserialized)
+
+		// The legacy gob format is checked in the same way
+		var buffer bytes.Buffer
+		assert.Nil(t, gob.NewEncoder(&buffer).Encode(struct {
+			ID uint8
+			S  []byte
+			CW []optreedpf.CorrectionWord
+		}{m.ID, m.S, m.CW}))
+		assert.True(t, errors.As(deserialized.Deserialize(buffer.Bytes()), &deserializeErr))
+	}
+
+	// A huge amount of correction words is rejected before allocating them
+	data := []byte{'O', 'T', 'K', 1, 0, 16}
+	data = append(data, key.S...)
+	data = binary.AppendUvarint(data, 1<<40)
+	assert.True(t, errors.As(new(optreedpf.Key).Deserialize(data), &deserializeErr))
+
+	_, err = optreedpf.InitFactory(128, optreedpf.MaxDomainBitLength+1)
+	assert.NotNil(t, err)
+}
+
+func FuzzOpTreeDPFKeyDeserialize(f *testing.F) {
+	d, _ := optreedpf.InitFactory(128, 4)
+	k1, _, _ := d.Gen(big.NewInt(5), big.NewInt(10))
+	serialized, _ := k1.Serialize()
+	f.Add(serialized)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		key := new(optreedpf.Key)
+		if err := key.Deserialize(data); err != nil {
+			return
+		}
+		// Accepted keys round-trip and are rejected or evaluated without panics
+		again, err := key.Serialize()
+		assert.Nil(t, err)
+		assert.Nil(t, new(optreedpf.Key).Deserialize(again))
+		_, _ = d.Eval(key, big.NewInt(3))
+	})
+}
+
+func TestOpTreeDPFTestVectors(t *testing.T) {
+	assert.Nil(t, optreedpf.VerifyAgainstTestVectors())
+}
+
+func TestOpTreeDPFTestVectorMismatch(t *testing.T) {
+	tv := optreedpf.TestVectors[0]
+	tv.EvalAlice = append([]string{}, tv.EvalAlice...)
+	tv.EvalAlice[0] = "00"
+	assert.NotNil(t, tv.Verify())
 }
 
 func TestOpTreeDPFGenAndEval128(t *testing.T) {
@@ -58,6 +221,95 @@ func TestOpTreeDPFGenAndEval256(t *testing.T) {
 	testOpTreeDPFGenAndEval(t, 256, 256)
 }
 
+func TestOpTreeDPFHashPRG(t *testing.T) {
+	for _, lambda := range []int{128, 192, 256} {
+		domain := 6
+		d, err := optreedpf.InitFactory(lambda, domain)
+		assert.Nil(t, err)
+		d.SetPRG(dpf.HashPRG{})
+
+		x, y := big.NewInt(37), big.NewInt(1234)
+		k1, k2, err := d.Gen(x, y)
+		assert.Nil(t, err)
+
+		res1, err := d.FullEval(k1)
+		assert.Nil(t, err)
+		res2, err := d.FullEval(k2)
+		assert.Nil(t, err)
+		res, err := d.CombineMultipleResults(res1, res2)
+		assert.Nil(t, err)
+		for i, r := range res {
+			if i == 37 {
+				assert.Equal(t, y, r)
+			} else {
+				assert.Equal(t, 0, r.Sign())
+			}
+		}
+
+		// Keys of the hash-based backend do not evaluate correctly with the default backend
+		aes, err := optreedpf.InitFactory(lambda, domain)
+		assert.Nil(t, err)
+		e1, err := aes.Eval(k1, x)
+		assert.Nil(t, err)
+		e2, err := aes.Eval(k2, x)
+		assert.Nil(t, err)
+		assert.NotEqual(t, y, aes.CombineResults(e1, e2))
+	}
+}
+
+func TestOpTreeDPFConstantTime(t *testing.T) {
+	for _, lambda := range []int{128, 192, 256} {
+		d, err := optreedpf.InitFactory(lambda, 6)
+		assert.Nil(t, err)
+		x, y := big.NewInt(21), big.NewInt(4321)
+		k1, k2, err := d.Gen(x, y)
+		assert.Nil(t, err)
+		res1, err := d.FullEval(k1)
+		assert.Nil(t, err)
+		res2, err := d.FullEval(k2)
+		assert.Nil(t, err)
+
+		// The constant-time evaluation yields the same partial results, which combine to the point function
+		d.SetConstantTime(true)
+		ct1, err := d.FullEval(k1)
+		assert.Nil(t, err)
+		ct2, err := d.FullEval(k2)
+		assert.Nil(t, err)
+		assert.Equal(t, res1, ct1)
+		assert.Equal(t, res2, ct2)
+		res, err := d.CombineMultipleResults(ct1, ct2)
+		assert.Nil(t, err)
+		for i, r := range res {
+			if i == 21 {
+				assert.Equal(t, y, r)
+			} else {
+				assert.Equal(t, 0, r.Sign())
+			}
+		}
+	}
+}
+
+func TestOpTreeDPFOutputGroup(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 6)
+	assert.Nil(t, err)
+	assert.True(t, d.OutputGroup().IsFr())
+	assert.Equal(t, dpf.OpTreeDPFKeyID, d.KeyType())
+	k1, _, err := d.Gen(big.NewInt(1), big.NewInt(1))
+	assert.Nil(t, err)
+	assert.Equal(t, d.KeyType(), k1.TypeID())
+
+	// The results combine in the output group, i.e. q-1 and 1 combine to 0
+	minusOne := new(big.Int).Sub(d.OutputGroup().Modulus, big.NewInt(1))
+	for _, constantTime := range []bool{false, true} {
+		d.SetConstantTime(constantTime)
+		assert.Equal(t, 0, d.CombineResults(minusOne, big.NewInt(1)).Sign())
+	}
+
+	other, err := optreedpf.InitFactory(256, 8)
+	assert.Nil(t, err)
+	assert.Nil(t, dpf.CheckCompatible(d, other))
+}
+
 func TestOpTreeDPFStress(t *testing.T) {
 	lambda := 256
 	domain := 256
@@ -107,6 +359,7 @@ func TestOpTreeDPFGenAndEvalToZero(t *testing.T) {
 	res1, err := d.Eval(k1, x)
 	assert.Nil(t, err)
 	res2, err := d.Eval(k2, x)
+	assert.Nil(t, err)
 	assert.Equal(t, y, d.CombineResults(res1, res2))
 
 	res1, err = d.Eval(k1, wx1)
@@ -171,6 +424,77 @@ func TestOpTreeDPFFullEval256(t *testing.T) {
 	testOpTreeDPFFullEval(t, 256, 14) // Using small domains here as FullEval is computationally expensive
 }
 
+func TestOpTreeDPFSmallDomains(t *testing.T) {
+	// The domain is independent of lambda, e.g. the PCG uses domains of 10 to 22 bits with lambda=128
+	for _, lambda := range []int{128, 192, 256} {
+		for domain := 1; domain <= 4; domain++ {
+			d, err := optreedpf.InitFactory(lambda, domain)
+			assert.Nil(t, err)
+			for x := int64(0); x < 1<<domain; x++ {
+				y := big.NewInt(x + 1)
+				k1, k2, err := d.Gen(big.NewInt(x), y)
+				assert.Nil(t, err)
+
+				res1, err := d.FullEval(k1)
+				assert.Nil(t, err)
+				res2, err := d.FullEval(k2)
+				assert.Nil(t, err)
+				assert.Len(t, res1, 1<<domain)
+				res, err := d.CombineMultipleResults(res1, res2)
+				assert.Nil(t, err)
+				for point, val := range res {
+					if int64(point) == x {
+						assert.Equal(t, y, val)
+					} else {
+						assert.Zero(t, val.Sign())
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestOpTreeDPFDomainValidation(t *testing.T) {
+	for _, domain := range []int{0, -1} {
+		d, err := optreedpf.InitFactory(128, domain)
+		assert.NotNil(t, err)
+		assert.Nil(t, d)
+	}
+
+	d, err := optreedpf.InitFactory(128, 3)
+	assert.Nil(t, err)
+	assert.NotNil(t, d.ChangeDomain(0))
+	assert.Equal(t, 3, d.GetDomain())
+
+	// Points outside of [0, 2^3 - 1] are rejected instead of being truncated or evaluated at their absolute value
+	for _, x := range []*big.Int{big.NewInt(-1), big.NewInt(8)} {
+		_, _, err = d.Gen(x, big.NewInt(1))
+		assert.NotNil(t, err)
+	}
+	_, _, err = d.Gen(big.NewInt(1), big.NewInt(-1))
+	assert.NotNil(t, err)
+	k1, _, err := d.Gen(big.NewInt(5), big.NewInt(1))
+	assert.Nil(t, err)
+	for _, x := range []*big.Int{big.NewInt(-5), big.NewInt(8)} {
+		_, err = d.Eval(k1, x)
+		assert.NotNil(t, err)
+	}
+
+	// Keys of another domain are rejected
+	assert.Nil(t, d.ChangeDomain(4))
+	_, err = d.FullEval(k1)
+	assert.NotNil(t, err)
+
+	// Domains too large for a full evaluation still support Gen and Eval
+	assert.Nil(t, d.ChangeDomain(128))
+	k1, k2, err := d.Gen(big.NewInt(5), big.NewInt(1))
+	assert.Nil(t, err)
+	_, err = d.FullEval(k1)
+	assert.NotNil(t, err)
+	_, err = d.FullEvalFast(k2)
+	assert.NotNil(t, err)
+}
+
 func testOpTreeDPFFullEval(t *testing.T, lambda int, domain int) {
 	d, err := optreedpf.InitFactory(lambda, domain)
 	assert.Nil(t, err)
@@ -247,6 +571,30 @@ func testOpTreeDPFFullEvalParallel(t *testing.T, lambda int, domain int) {
 	assert.Equal(t, 1, nonZeroCount, "There should be exactly one non-zero value in the result")
 }
 
+// keySizeThresholds holds the maximum accepted key sizes in bytes per lambda and input domain.
+// The thresholds are ~3% above the measured sizes of the gob encoding. Lower them when the encoding is improved.
+var keySizeThresholds = []struct {
+	lambda, domain, maxBytes int
+}{
+	{128, 8, 385}, {128, 16, 565}, {128, 32, 940}, {128, 64, 1665}, {128, 128, 3185},
+	{192, 8, 455}, {192, 16, 710}, {192, 32, 1205}, {192, 64, 2210}, {192, 128, 4255},
+	{256, 8, 525}, {256, 16, 845}, {256, 32, 1475}, {256, 64, 2735}, {256, 128, 5325},
+}
+
+func TestKeySizeRegression(t *testing.T) {
+	for _, threshold := range keySizeThresholds {
+		size, err := optreedpf.MeasureKeySize(threshold.lambda, threshold.domain)
+		assert.Nil(t, err)
+		assert.LessOrEqual(t, size, threshold.maxBytes, "key size for lambda=%d and domain=%d exceeds the threshold", threshold.lambda, threshold.domain)
+
+		// Each level adds a correction word with a seed of lambda bits, hence the size is at least domain*lambda/8
+		assert.Greater(t, size, threshold.domain*threshold.lambda/8)
+	}
+
+	_, err := optreedpf.MeasureKeySize(100, 8)
+	assert.NotNil(t, err)
+}
+
 // Benchmarks:
 func BenchmarkOpTreeDPFGen128_n32(b *testing.B)  { benchmarkOpTreeDPFGen(b, 128, 32) }
 func BenchmarkOpTreeDPFGen128_n64(b *testing.B)  { benchmarkOpTreeDPFGen(b, 128, 64) }
@@ -387,3 +735,25 @@ func benchmarkOpTreeDPFFullEvalFast(b *testing.B, lambda, domain int) {
 		}
 	}
 }
+
+func BenchmarkOpTreeDPFKeySize128_n32(b *testing.B) { benchmarkOpTreeDPFKeySize(b, 128, 32) }
+func BenchmarkOpTreeDPFKeySize192_n32(b *testing.B) { benchmarkOpTreeDPFKeySize(b, 192, 32) }
+func BenchmarkOpTreeDPFKeySize256_n32(b *testing.B) { benchmarkOpTreeDPFKeySize(b, 256, 32) }
+
+// benchmarkOpTreeDPFKeySize reports the serialized key size as bytes/key alongside the time of Gen and Serialize.
+func benchmarkOpTreeDPFKeySize(b *testing.B, lambda, domain int) {
+	d, err := optreedpf.InitFactory(lambda, domain)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	size := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		size, err = d.KeySize()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(size), "bytes/key")
+}
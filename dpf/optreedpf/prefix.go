@@ -0,0 +1,65 @@
+package optreedpf
+
+import (
+	"errors"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"math/big"
+)
+
+// EvalPrefix evaluates a DPF key at all points of the subtree under the given prefix of the input bits, i.e. at the
+// 2^(DomainBitLength-depth) points whose depth most significant bits are the prefix. The results are ordered by the
+// point, i.e. the j-th result is the evaluation at prefix * 2^(DomainBitLength-depth) + j. A depth of 0 is equivalent
+// to FullEval, while a depth of DomainBitLength is equivalent to Eval at the point given by the prefix.
+// It allows to split the full evaluation into chunks, e.g. to evaluate it in parts or on multiple machines.
+func (d *OpTreeDPF) EvalPrefix(key dpf.Key, prefix []uint, depth int) ([]*big.Int, error) {
+	// Use a type assertion to convert dpf.Key to the concrete key type for this dpf implementation.
+	tkey, ok := key.(*Key)
+	if !ok {
+		return nil, errors.New("the given key is not a tree-based DPF key")
+	}
+	if tkey.ID > 1 {
+		return nil, errors.New("the given key is invalid as its ID can only be 0 or 1")
+	}
+	if err := d.checkCorrectionWords(tkey); err != nil {
+		return nil, err
+	}
+	if depth < 0 || depth > d.DomainBitLength {
+		return nil, errors.New("the depth of the prefix must be within [0, DomainBitLength]")
+	}
+	if len(prefix) != depth {
+		return nil, errors.New("the prefix must hold depth bits")
+	}
+	if d.DomainBitLength-depth > maxFullEvalDomain {
+		return nil, errors.New("the subtree of the prefix is too large for a full evaluation")
+	}
+
+	// Walk down the path of the prefix (see Eval)
+	s := tkey.S
+	t := tkey.ID != 0 // Interpret ID as boolean
+	buffers := [2][]byte{make([]byte, d.prgOutputLength), make([]byte, d.prgOutputLength)}
+	for i := 1; i <= depth; i++ {
+		if prefix[i-1] > 1 {
+			return nil, errors.New("the prefix must only hold bits")
+		}
+		sl, tl, sr, tr, err := d.expandNode(s, t, tkey.CW[i-1], buffers[i%2])
+		if err != nil {
+			return nil, err
+		}
+		if prefix[i-1] == 0 {
+			s, t = sl, tl
+		} else {
+			s, t = sr, tr
+		}
+	}
+
+	// Evaluate the subtree below the node of the prefix (see FullEval)
+	levels := d.DomainBitLength - depth
+	res, err := d.traverse(s, t, tkey.CW, levels, tkey.ID, d.levelBuffers()[:levels])
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 1<<levels {
+		return nil, errors.New("the evaluation does not hold a result for each point of the subtree")
+	}
+	return res, nil
+}
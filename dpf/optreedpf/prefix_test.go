@@ -0,0 +1,49 @@
+package optreedpf_test
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestOpTreeDPFEvalPrefix(t *testing.T) {
+	domain := 6
+	d, err := optreedpf.InitFactory(128, domain)
+	assert.Nil(t, err)
+	k1, _, err := d.Gen(big.NewInt(42), big.NewInt(9))
+	assert.Nil(t, err)
+
+	expected, err := d.FullEval(k1)
+	assert.Nil(t, err)
+
+	for depth := 0; depth <= domain; depth++ {
+		chunk := 1 << (domain - depth)
+		for p := 0; p < 1<<depth; p++ {
+			prefix, err := dpf.ExtendBigIntToBitLength(big.NewInt(int64(p)), depth)
+			assert.Nil(t, err)
+			res, err := d.EvalPrefix(k1, prefix, depth)
+			assert.Nil(t, err)
+			assert.Equal(t, expected[p*chunk:(p+1)*chunk], res, "depth=%d, prefix=%d", depth, p)
+		}
+	}
+}
+
+func TestOpTreeDPFEvalPrefixErrors(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 6)
+	assert.Nil(t, err)
+	k1, _, err := d.Gen(big.NewInt(42), big.NewInt(9))
+	assert.Nil(t, err)
+
+	_, err = d.EvalPrefix(k1, []uint{0, 1}, 1)
+	assert.NotNil(t, err)
+	_, err = d.EvalPrefix(k1, nil, -1)
+	assert.NotNil(t, err)
+	_, err = d.EvalPrefix(k1, make([]uint, 7), 7)
+	assert.NotNil(t, err)
+	_, err = d.EvalPrefix(k1, []uint{2}, 1)
+	assert.NotNil(t, err)
+	_, err = d.EvalPrefix(&optreedpf.Key{ID: 2}, nil, 0)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,356 @@
+package optreedpf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"math/big"
+)
+
+// TestVector holds a canonical input/output pair of the OpTreeDPF.
+// Fixed initial seeds lead to fixed keys and thereby to fixed evaluations, which allows refactorings of the
+// PRG or convert logic (and alternative implementations) to prove bit-compatibility with this implementation.
+type TestVector struct {
+	Lambda         int      // Lambda is the security parameter of the DPF.
+	Domain         int      // Domain is the bit length of the input domain of the DPF.
+	Legacy         bool     // Legacy is set if the vector was generated with the LegacyConverter.
+	PRG            string   // PRG is the name of the PRG backend (see dpf.PRGBackendByName). Empty refers to the default AES-CTR.
+	SeedAlice      string   // SeedAlice is the hex encoded initial seed of Alice.
+	SeedBob        string   // SeedBob is the hex encoded initial seed of Bob.
+	Alpha          int64    // Alpha is the special point.
+	Beta           string   // Beta is the hex encoded non-zero element.
+	FinalCW        string   // FinalCW is the hex encoded final correction word hiding beta.
+	EvalPoints     []int64  // EvalPoints are the points Alice's key is evaluated at.
+	EvalAlice      []string // EvalAlice holds the hex encoded partial results of Alice's key at EvalPoints.
+	FullEvalDigest string   // FullEvalDigest is the hex encoded SHA-256 digest over the full evaluations of both keys.
+}
+
+// TestVectors are the canonical test vectors of the OpTreeDPF across several domains.
+// The legacy vectors pin the LegacyConverter, the remaining ones the default HashToFieldConverter.
+// The vectors with a PRG pin the hash-based backend for all lambda, where the first one shares its inputs with a vector
+// of the default backend.
+var TestVectors = []TestVector{
+	{
+		Lambda:     128,
+		Domain:     4,
+		Legacy:     true,
+		SeedAlice:  "000102030405060708090a0b0c0d0e0f",
+		SeedBob:    "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		Alpha:      5,
+		Beta:       "2a",
+		FinalCW:    "706f9656057a3fc9c43c4823643c0b6e1ec66f475cada9729b7ef0052da98b08",
+		EvalPoints: []int64{0, 5, 15},
+		EvalAlice: []string{
+			"598e58e2cb1042ce0d8fa5a73685b4ef2da3c1b7407804941e785b06bca62cc0",
+			"5cfa5c668ac0b033ed600b2a450b8e5fa130012c3617408b702153247a577e26",
+			"37f9b70ee1dd2563fdb08f0cccda2e8bc44958d2eb2db300a496d6ba9f5f3645",
+		},
+		FullEvalDigest: "4a6fec8d802b2a8218bbe02d5a48c988f059c37c01985cc0d4fd615d51c116f7",
+	},
+	{
+		Lambda:     128,
+		Domain:     8,
+		Legacy:     true,
+		SeedAlice:  "00112233445566778899aabbccddeeff",
+		SeedBob:    "ffeeddccbbaa99887766554433221100",
+		Alpha:      200,
+		Beta:       "73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000000",
+		FinalCW:    "189e90ecadf6ed268f25dfee71feefb23e3f002f8b89d3b8b6531403a2f6bd51",
+		EvalPoints: []int64{0, 199, 200, 255},
+		EvalAlice: []string{
+			"3f175dd1305ad391fd25338af6c0dbd9e6fe2464debe945c33ddaeb4570757f7",
+			"1f0cae128b19cc9238c8a45a831d9f1eef5c30703c54487e6941106c05d8ea17",
+			"610182c0e0eb7c03a3ba228057b738095cf3e7ab5dc7cf5f723b5ea7a2931076",
+			"69a8ed6b5ba80e3f7f5886adbacd042f9d5cc12ef787fdeb4b6abc6393d4d304",
+		},
+		FullEvalDigest: "9a4bd6d7787d311ebcbbfecf38e0b31395b8d085569b51c32677d671aae4600d",
+	},
+	{
+		Lambda:     128,
+		Domain:     10,
+		Legacy:     true,
+		SeedAlice:  "0f0e0d0c0b0a09080706050403020100",
+		SeedBob:    "8899aabbccddeeff0011223344556677",
+		Alpha:      0,
+		Beta:       "0123456789abcdef0123456789abcdef",
+		FinalCW:    "5702bd809444c42ad01e7ca0407a2be1c7fd4b00536e3743ab7c463338b79fe9",
+		EvalPoints: []int64{0, 1, 512, 1023},
+		EvalAlice: []string{
+			"37bf01f08abcd98a12367acd08720f06d0d2888fef348e4b55edf3d2e852e40e",
+			"5700dd17169e69a91f8eef59c21d4b232c4e0712657b8d5edc455d7f9b98a103",
+			"3266cb33f092256b8762045b54742aa6655f012ff7cd74cc71482d2cec056114",
+			"44f54c569f747e2c063d23d2432b9eea31d7a987f18fd1fb5e00b95a71fe95f4",
+		},
+		FullEvalDigest: "075b3e672b84e2e6cee85883ac430cedc4b0fc7709eb49345a1a9a54228fe90a",
+	},
+	{
+		Lambda:     128,
+		Domain:     12,
+		Legacy:     true,
+		SeedAlice:  "a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5",
+		SeedBob:    "5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a",
+		Alpha:      4095,
+		Beta:       "01",
+		FinalCW:    "531af5360aac5a0b9f7ed6e56381c96ee0dcf093d4ecdb7e7b3ec0b1d8695c5b",
+		EvalPoints: []int64{0, 2048, 4094, 4095},
+		EvalAlice: []string{
+			"6362e45fb24f18db851927d5e0595df4531087355edee1d849d42b8796681d93",
+			"3dc51f352cbb4cc78f8418770e16cb349547765458fde1730fb556373c70fab0",
+			"3905752859733a763ac23ed372e265c148a1ec65d824a1207b63e776927d6f80",
+			"2dfe042673ed2a24a67bcbd965319be7883a326b511dd070ba62c9862587cf86",
+		},
+		FullEvalDigest: "df46a071a6b17c58e8c87d22db48a0a749d1de7f7a0d8e6e381e013de8a2b8a5",
+	},
+	{
+		Lambda:     128,
+		Domain:     4,
+		SeedAlice:  "000102030405060708090a0b0c0d0e0f",
+		SeedBob:    "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		Alpha:      5,
+		Beta:       "2a",
+		FinalCW:    "11aeef2ec10973dcd30f3ed2c269dc8567d097172244573978ed4b0bb208a92c",
+		EvalPoints: []int64{0, 5, 15},
+		EvalAlice: []string{
+			"4fb33f406ae8b761a521b6f46ca8d8f191c9d82e0c882514d3d03562cb5bf567",
+			"03d20f3bd8cc2ed7c8b99b33103ec642fc547d0b00fff633b50a7ee73bad690a",
+			"30245f70ac24e9938764600fe088cbaa7240de0a022e51bc3c252c05dc3c7aba",
+		},
+		FullEvalDigest: "dd4ada6901a09be3233b8b63d45b45715bb0e667c5ca5ebe0f1d236fea5bca01",
+	},
+	{
+		Lambda:     128,
+		Domain:     8,
+		SeedAlice:  "00112233445566778899aabbccddeeff",
+		SeedBob:    "ffeeddccbbaa99887766554433221100",
+		Alpha:      200,
+		Beta:       "73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000000",
+		FinalCW:    "427621aba2df984b36d8a9b7bff6bc8ca652889c8f516e5669aae425426ecdc0",
+		EvalPoints: []int64{0, 199, 200, 255},
+		EvalAlice: []string{
+			"3d78e5d4b4c0953a13d0548ef64276ac316a28aee53aed23c35ed473805fd5f1",
+			"5ccdfeb0db9df63eb42f61c12cf75cae97d4dc6cfad366c42519155ae617dcdb",
+			"6292c8dd53002fa2f4de8c1f94b8ad0c02090e740fab866a409813cf6afdb16f",
+			"5a8dd1cd00be4e4e6a6a74f0ed18042c4690db0e02414397bb4772d72b0a0796",
+		},
+		FullEvalDigest: "2a0888a61b4bc5b77ca3f28d19ecde109f809119e77cfcf48215fc9f354f2ba9",
+	},
+	{
+		Lambda:     128,
+		Domain:     8,
+		PRG:        dpf.PRGNameSHA512,
+		SeedAlice:  "00112233445566778899aabbccddeeff",
+		SeedBob:    "ffeeddccbbaa99887766554433221100",
+		Alpha:      200,
+		Beta:       "73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000000",
+		FinalCW:    "364b27f6c3104bff6a4c5fefa16a54efc56869d35733192f7bc800eb9bbc3106",
+		EvalPoints: []int64{0, 199, 200, 255},
+		EvalAlice: []string{
+			"281782f35641f9fb15f57907900a60c52c949b8d275e0e1a5b45822253ed3b20",
+			"5f7d34789124966240b916bb414debd3e81e182da2448db342263fbbb2e7ea21",
+			"199e62d9c8f12869230a1708fae0267199de2b59c6a500c0f14aa2f90cced8dd",
+			"45e686f34e6dd27b70af338271bc326022b981ad357fd2b5473b6dd0bc8dd83f",
+		},
+		FullEvalDigest: "04cb7e66f89c4575711bfc1b8c2ef0945d0d13e2fdf4475dba0da9ba88b03f25",
+	},
+	{
+		Lambda:     192,
+		Domain:     6,
+		PRG:        dpf.PRGNameSHA512,
+		SeedAlice:  "000102030405060708090a0b0c0d0e0f1011121314151617",
+		SeedBob:    "f0f1f2f3f4f5f6f7f8f9fafbfcfdfefff0f1f2f3f4f5f6f7",
+		Alpha:      17,
+		Beta:       "2a",
+		FinalCW:    "68027d6d92bf5d0b8bbe24c67285e7c0d7da95373c4d1c462399bd4a1f99e663",
+		EvalPoints: []int64{0, 17, 63},
+		EvalAlice: []string{
+			"2f5731340ed3079f0c882c290973f55216cd91f20ee50f6eabf93068ca1d982f",
+			"419a67e41ca4fdfe44f756ae2fa8e584a6ef45c2a28ea6ec1d9b369e43737d29",
+			"690025b2e424bc2b988325b106a7892aefb736f99b7a7765d50cc5dad25a1814",
+		},
+		FullEvalDigest: "e305d70ea7b381c4f0ade0014660d5a53348ada5d77eddcf3a7a185a159a49e4",
+	},
+	{
+		Lambda:     256,
+		Domain:     5,
+		PRG:        dpf.PRGNameSHA512,
+		SeedAlice:  "a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5",
+		SeedBob:    "5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a",
+		Alpha:      31,
+		Beta:       "1",
+		FinalCW:    "4a9754247e63a1158ad5cecca2b2efc50878cf4b918e7a4f8a2f8486295553d6",
+		EvalPoints: []int64{0, 30, 31},
+		EvalAlice: []string{
+			"7263816324f5ec831b54f5ef8836c3b5df335473a7dd9de4044b061e182fc6ac",
+			"6262e8e39b1044ac01c553562d2d392d9fd3722cc6e0f4e30e88a78aedc51b30",
+			"5d9367f3505b2538255b6c2288529ad04b4da4c12922f29932fa71ee3460cf3b",
+		},
+		FullEvalDigest: "e6290863a9c7c3b8dd4d0d10082994fbb259d00a6aedff90cbd358f67c42b9b5",
+	},
+}
+
+// GenerateTestVector deterministically generates the keys for the given initial seeds and records the resulting outputs.
+// It is used to (re-)create the canonical TestVectors.
+// If legacy is set, the keys are generated with the LegacyConverter. prg is the name of the PRG backend.
+func GenerateTestVector(lambda, domain int, legacy bool, prg string, seedAlice, seedBob []byte, alpha int64, beta *big.Int, evalPoints []int64) (*TestVector, error) {
+	d, err := newTestVectorDPF(lambda, domain, legacy, prg)
+	if err != nil {
+		return nil, err
+	}
+
+	keyAlice, keyBob, err := d.genWithSeeds(big.NewInt(alpha), beta, seedAlice, seedBob)
+	if err != nil {
+		return nil, err
+	}
+
+	evalAlice := make([]string, len(evalPoints))
+	for i, x := range evalPoints {
+		y, err := d.Eval(keyAlice, big.NewInt(x))
+		if err != nil {
+			return nil, err
+		}
+		evalAlice[i] = hex.EncodeToString(y.Bytes())
+	}
+
+	digest, err := d.fullEvalDigest(keyAlice, keyBob)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TestVector{
+		Lambda:         lambda,
+		Legacy:         legacy,
+		PRG:            prg,
+		Domain:         domain,
+		SeedAlice:      hex.EncodeToString(seedAlice),
+		SeedBob:        hex.EncodeToString(seedBob),
+		Alpha:          alpha,
+		Beta:           beta.Text(16),
+		FinalCW:        hex.EncodeToString(keyAlice.(*Key).CW[domain].S),
+		EvalPoints:     evalPoints,
+		EvalAlice:      evalAlice,
+		FullEvalDigest: hex.EncodeToString(digest),
+	}, nil
+}
+
+// Verify regenerates the keys of the test vector and checks that all recorded outputs are reproduced bit by bit.
+// It additionally checks that the combined full evaluation is beta at alpha and zero everywhere else.
+func (tv *TestVector) Verify() error {
+	seedAlice, err := hex.DecodeString(tv.SeedAlice)
+	if err != nil {
+		return fmt.Errorf("invalid seed of Alice: %w", err)
+	}
+	seedBob, err := hex.DecodeString(tv.SeedBob)
+	if err != nil {
+		return fmt.Errorf("invalid seed of Bob: %w", err)
+	}
+	beta, ok := new(big.Int).SetString(tv.Beta, 16)
+	if !ok {
+		return fmt.Errorf("invalid beta %q", tv.Beta)
+	}
+
+	d, err := newTestVectorDPF(tv.Lambda, tv.Domain, tv.Legacy, tv.PRG)
+	if err != nil {
+		return err
+	}
+	keyAlice, keyBob, err := d.genWithSeeds(big.NewInt(tv.Alpha), beta, seedAlice, seedBob)
+	if err != nil {
+		return err
+	}
+
+	finalCW := hex.EncodeToString(keyAlice.(*Key).CW[tv.Domain].S)
+	if finalCW != tv.FinalCW {
+		return fmt.Errorf("final correction word mismatch: got %s, want %s", finalCW, tv.FinalCW)
+	}
+
+	if len(tv.EvalPoints) != len(tv.EvalAlice) {
+		return fmt.Errorf("test vector holds %d eval points but %d results", len(tv.EvalPoints), len(tv.EvalAlice))
+	}
+	for i, x := range tv.EvalPoints {
+		y, err := d.Eval(keyAlice, big.NewInt(x))
+		if err != nil {
+			return err
+		}
+		if got := hex.EncodeToString(y.Bytes()); got != tv.EvalAlice[i] {
+			return fmt.Errorf("evaluation mismatch at x=%d: got %s, want %s", x, got, tv.EvalAlice[i])
+		}
+	}
+
+	digest, err := d.fullEvalDigest(keyAlice, keyBob)
+	if err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(digest); got != tv.FullEvalDigest {
+		return fmt.Errorf("full evaluation digest mismatch: got %s, want %s", got, tv.FullEvalDigest)
+	}
+
+	resAlice, err := d.FullEval(keyAlice)
+	if err != nil {
+		return err
+	}
+	resBob, err := d.FullEval(keyBob)
+	if err != nil {
+		return err
+	}
+	res, err := d.CombineMultipleResults(resAlice, resBob)
+	if err != nil {
+		return err
+	}
+	for x, y := range res {
+		expected := big.NewInt(0)
+		if int64(x) == tv.Alpha {
+			expected = beta
+		}
+		if y.Cmp(expected) != 0 {
+			return fmt.Errorf("combined evaluation at x=%d is %s, want %s", x, y.Text(16), expected.Text(16))
+		}
+	}
+
+	return nil
+}
+
+// VerifyAgainstTestVectors checks that this implementation reproduces all canonical TestVectors.
+func VerifyAgainstTestVectors() error {
+	for i := range TestVectors {
+		if err := TestVectors[i].Verify(); err != nil {
+			return fmt.Errorf("test vector %d (lambda=%d, domain=%d): %w", i, TestVectors[i].Lambda, TestVectors[i].Domain, err)
+		}
+	}
+	return nil
+}
+
+// newTestVectorDPF returns the DPF of a test vector with the given converter and PRG backend.
+func newTestVectorDPF(lambda, domain int, legacy bool, prg string) (*OpTreeDPF, error) {
+	d, err := InitFactory(lambda, domain)
+	if err != nil {
+		return nil, err
+	}
+	if legacy {
+		d.UseLegacyConversion()
+	}
+	backend, err := dpf.PRGBackendByName(prg)
+	if err != nil {
+		return nil, err
+	}
+	d.SetPRG(backend)
+	return d, nil
+}
+
+// fullEvalDigest returns the SHA-256 digest over the full evaluations of both keys.
+// Each partial result is encoded as a 32 byte big-endian field element; Alice's outputs precede Bob's.
+func (d *OpTreeDPF) fullEvalDigest(keyAlice, keyBob dpf.Key) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, key := range []dpf.Key{keyAlice, keyBob} {
+		res, err := d.FullEval(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, y := range res {
+			buf.Write(bls12381.NewFr().FromBytes(y.Bytes()).ToBytes())
+		}
+	}
+	digest := sha256.Sum256(buf.Bytes())
+	return digest[:], nil
+}
@@ -0,0 +1,84 @@
+package dpf
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+)
+
+// Names of the PRG backends (see PRGBackendByName).
+const (
+	PRGNameAES    = "aes-ctr"
+	PRGNameSHA512 = "sha512-ctr"
+)
+
+// hashPRGDST is the domain separation tag of the HashPRG.
+const hashPRGDST = "pcg-bbs-plus/dpf/prg/sha512/v1"
+
+// maxHashPRGSeed is the maximum seed length of the HashPRG in bytes.
+const maxHashPRGSeed = 64
+
+// PRGBackend expands seeds into pseudorandom bytes, e.g. for the seed expansion of tree-based DPFs.
+// Keys of a DPF are only compatible with a DPF using the same backend, as the keys do not record it.
+type PRGBackend interface {
+	// ExpandInto writes len(dst) pseudorandom bytes for the seed to dst and returns dst.
+	ExpandInto(dst, seed []byte) []byte
+	// Name returns the name of the backend.
+	Name() string
+}
+
+// AESPRG is the default PRGBackend. It expands seeds via AES-CTR with a zero IV keyed by the seed (see PRG), hence
+// seeds must be valid AES keys of 16, 24 or 32 bytes.
+type AESPRG struct{}
+
+// ExpandInto implements PRGBackend.
+func (AESPRG) ExpandInto(dst, seed []byte) []byte {
+	return PRGInto(dst, seed)
+}
+
+// Name implements PRGBackend.
+func (AESPRG) Name() string {
+	return PRGNameAES
+}
+
+// HashPRG is a hash-based PRGBackend. The i-th 64 byte block of the output is SHA-512(dst || len(seed) || seed || i),
+// where dst is a fixed domain separation tag, len(seed) is a single byte and i is a big-endian uint32. In contrast to
+// AESPRG, the expansion is the same for all lambda and does not tie the seed length to the AES key sizes.
+// Seeds must hold at most 64 bytes.
+type HashPRG struct{}
+
+// ExpandInto implements PRGBackend.
+func (HashPRG) ExpandInto(dst, seed []byte) []byte {
+	if len(seed) > maxHashPRGSeed {
+		panic("dpf: the seed of the HashPRG must hold at most 64 bytes")
+	}
+
+	var input [len(hashPRGDST) + 1 + maxHashPRGSeed + 4]byte
+	n := copy(input[:], hashPRGDST)
+	input[n] = byte(len(seed))
+	n++
+	n += copy(input[n:], seed)
+	for offset, counter := 0, uint32(0); offset < len(dst); offset, counter = offset+sha512.Size, counter+1 {
+		binary.BigEndian.PutUint32(input[n:], counter)
+		block := sha512.Sum512(input[:n+4])
+		copy(dst[offset:], block[:])
+	}
+	return dst
+}
+
+// Name implements PRGBackend.
+func (HashPRG) Name() string {
+	return PRGNameSHA512
+}
+
+// PRGBackendByName returns the PRGBackend of the given name. The empty name refers to the default AESPRG.
+func PRGBackendByName(name string) (PRGBackend, error) {
+	switch name {
+	case "", PRGNameAES:
+		return AESPRG{}, nil
+	case PRGNameSHA512:
+		return HashPRG{}, nil
+	default:
+		return nil, errors.New("unknown PRG backend")
+	}
+}
@@ -0,0 +1,61 @@
+package dpf
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestHashPRGVector tests the HashPRG against an output computed with an independent SHA-512 implementation.
+func TestHashPRGVector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	want := "8bba2a40f2e70fbe26ed2da395a5033ddef0e80c5801317eb4e3d46789ac39fae1fef8d5911cc14f031c0d7bf87055d0078940e0e3cb9f66dea49be210d06b99d9cccaab5211939ae82aae9d50176d6d"
+	got := HashPRG{}.ExpandInto(make([]byte, 80), seed)
+	if hex.EncodeToString(got) != want {
+		t.Errorf("HashPRG.ExpandInto() = %x, want %s", got, want)
+	}
+
+	// Shorter outputs are prefixes of longer ones
+	prefix := HashPRG{}.ExpandInto(make([]byte, 34), seed)
+	if string(prefix) != string(got[:34]) {
+		t.Errorf("HashPRG.ExpandInto() is not prefix consistent")
+	}
+}
+
+// TestPRGBackends tests that the backends are deterministic, separate seeds of all lambda and differ from each other.
+func TestPRGBackends(t *testing.T) {
+	for _, name := range []string{PRGNameAES, PRGNameSHA512} {
+		backend, err := PRGBackendByName(name)
+		if err != nil {
+			t.Fatalf("PRGBackendByName(%q) returned an error: %v", name, err)
+		}
+		if backend.Name() != name {
+			t.Errorf("PRGBackendByName(%q).Name() = %q", name, backend.Name())
+		}
+		for _, length := range []int{16, 24, 32} {
+			seed := RandomSeed(length)
+			out0 := backend.ExpandInto(make([]byte, 50), seed)
+			out1 := backend.ExpandInto(make([]byte, 50), seed)
+			if string(out0) != string(out1) {
+				t.Errorf("%s is not deterministic for %d byte seeds", name, length)
+			}
+			if other := backend.ExpandInto(make([]byte, 50), RandomSeed(length)); string(other) == string(out0) {
+				t.Errorf("%s produced the same output for different seeds", name)
+			}
+		}
+	}
+
+	seed := RandomSeed(16)
+	if string(AESPRG{}.ExpandInto(make([]byte, 34), seed)) != string(PRG(seed, 34)) {
+		t.Errorf("AESPRG does not match PRG")
+	}
+	if string(AESPRG{}.ExpandInto(make([]byte, 34), seed)) == string(HashPRG{}.ExpandInto(make([]byte, 34), seed)) {
+		t.Errorf("AESPRG and HashPRG produced the same output")
+	}
+
+	if backend, err := PRGBackendByName(""); err != nil || backend.Name() != PRGNameAES {
+		t.Errorf("PRGBackendByName(\"\") did not return the default backend")
+	}
+	if _, err := PRGBackendByName("blake3"); err == nil {
+		t.Errorf("PRGBackendByName() accepted an unknown backend")
+	}
+}
@@ -0,0 +1,114 @@
+// Package prgsplit implements the seed expansion of tree-based DPFs.
+// A seed is expanded via the PRG into two child seeds and two control bits, which are laid out as
+// [sL | tL | sR | tR], where each control bit t is stored in the least significant bit of ControlBytes bytes.
+package prgsplit
+
+import (
+	"errors"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+)
+
+// Layout describes how the PRG output is split into two seeds and two control bits.
+type Layout struct {
+	SeedBytes    int            // SeedBytes is the length of each child seed in bytes.
+	ControlBytes int            // ControlBytes is the amount of bytes reserved for each control bit.
+	PRG          dpf.PRGBackend // PRG is the backend of the expansion. nil selects the default dpf.AESPRG.
+}
+
+// NewLayout returns the default layout for the security parameter lambda (in bits).
+// Each seed holds lambda/8 bytes and each control bit is stored in a single byte.
+func NewLayout(lambda int) (Layout, error) {
+	if lambda <= 0 || lambda%8 != 0 {
+		return Layout{}, errors.New("lambda must be a positive multiple of 8")
+	}
+	return Layout{SeedBytes: lambda / 8, ControlBytes: 1}, nil
+}
+
+// OutputLength returns the amount of bytes the PRG has to output for a single expansion.
+func (l Layout) OutputLength() int {
+	return 2 * (l.SeedBytes + l.ControlBytes)
+}
+
+// Backend returns the PRG backend of the layout.
+func (l Layout) Backend() dpf.PRGBackend {
+	if l.PRG == nil {
+		return dpf.AESPRG{}
+	}
+	return l.PRG
+}
+
+// Expand expands the seed via the PRG and splits the output into two child seeds and two control bits.
+func (l Layout) Expand(seed []byte) ([]byte, bool, []byte, bool, error) {
+	return l.Split(l.Backend().ExpandInto(make([]byte, l.OutputLength()), seed))
+}
+
+// ExpandInto works like Expand, but writes the PRG output to out, which must hold OutputLength bytes, instead of
+// allocating it. The returned seeds are sub-slices of out, i.e. out must not be reused while they are in use.
+func (l Layout) ExpandInto(out, seed []byte) ([]byte, bool, []byte, bool, error) {
+	if len(out) != l.OutputLength() {
+		return nil, false, nil, false, errors.New("the output buffer must hold OutputLength bytes")
+	}
+	return l.Split(l.Backend().ExpandInto(out, seed))
+}
+
+// Correct XORs the correction word (s, tL, s, tR) onto the PRG output in place, i.e. it is equivalent to XORing the
+// output of Join(s, tL, s, tR) without allocating it.
+func (l Layout) Correct(prgOutput, s []byte, tL, tR bool) error {
+	if len(prgOutput) < l.OutputLength() {
+		return errors.New("insufficient length of PRG output")
+	}
+	if len(s) != l.SeedBytes {
+		return errors.New("seeds must be of the length given by the layout")
+	}
+
+	half := l.SeedBytes + l.ControlBytes
+	dpf.XORBytesInto(prgOutput[:l.SeedBytes], prgOutput[:l.SeedBytes], s)
+	dpf.XORBytesInto(prgOutput[half:half+l.SeedBytes], prgOutput[half:half+l.SeedBytes], s)
+	if tL {
+		prgOutput[l.SeedBytes] ^= 1
+	}
+	if tR {
+		prgOutput[half+l.SeedBytes] ^= 1
+	}
+	return nil
+}
+
+// Split splits the output of the PRG into two seeds and two control bits.
+// The returned seeds are sub-slices of prgOutput.
+func (l Layout) Split(prgOutput []byte) ([]byte, bool, []byte, bool, error) {
+	if len(prgOutput) < l.OutputLength() {
+		return nil, false, nil, false, errors.New("insufficient length of PRG output")
+	}
+
+	half := l.SeedBytes + l.ControlBytes
+	sL := prgOutput[:l.SeedBytes]
+	tL := (prgOutput[l.SeedBytes] & 1) != 0 // Least significant bit of the first control byte
+	sR := prgOutput[half : half+l.SeedBytes]
+	tR := (prgOutput[half+l.SeedBytes] & 1) != 0
+
+	return sL, tL, sR, tR, nil
+}
+
+// Join is the inverse of Split, i.e. it lays out two seeds and two control bits as a PRG output.
+// This is used to XOR correction words onto the PRG output.
+func (l Layout) Join(sL []byte, tL bool, sR []byte, tR bool) ([]byte, error) {
+	if len(sL) != l.SeedBytes || len(sR) != l.SeedBytes {
+		return nil, errors.New("seeds must be of the length given by the layout")
+	}
+
+	out := make([]byte, 0, l.OutputLength())
+	out = append(out, sL...)
+	out = append(out, controlBytes(tL, l.ControlBytes)...)
+	out = append(out, sR...)
+	out = append(out, controlBytes(tR, l.ControlBytes)...)
+	return out, nil
+}
+
+// controlBytes encodes the control bit t into n bytes.
+func controlBytes(t bool, n int) []byte {
+	b := make([]byte, n)
+	if t {
+		b[0] = 1
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+package prgsplit
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewLayout(t *testing.T) {
+	for _, lambda := range []int{128, 192, 256} {
+		layout, err := NewLayout(lambda)
+		assert.Nil(t, err)
+		assert.Equal(t, lambda/8, layout.SeedBytes)
+		assert.Equal(t, 2*(lambda/8+1), layout.OutputLength())
+	}
+
+	_, err := NewLayout(100)
+	assert.NotNil(t, err)
+	_, err = NewLayout(0)
+	assert.NotNil(t, err)
+}
+
+func TestSplitBitExtraction(t *testing.T) {
+	layout := Layout{SeedBytes: 2, ControlBytes: 1}
+	out := []byte{0xaa, 0xbb, 0xfe, 0xcc, 0xdd, 0x03}
+
+	sL, tL, sR, tR, err := layout.Split(out)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xaa, 0xbb}, sL)
+	assert.False(t, tL) // Only the least significant bit of 0xfe counts
+	assert.Equal(t, []byte{0xcc, 0xdd}, sR)
+	assert.True(t, tR)
+
+	_, _, _, _, err = layout.Split(out[:5])
+	assert.NotNil(t, err)
+
+	// Layouts with multiple control bytes
+	layout = Layout{SeedBytes: 1, ControlBytes: 2}
+	sL, tL, sR, tR, err = layout.Split([]byte{0x01, 0x01, 0x00, 0x02, 0x00, 0x01})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x01}, sL)
+	assert.True(t, tL)
+	assert.Equal(t, []byte{0x02}, sR)
+	assert.False(t, tR)
+}
+
+func TestJoinSplit(t *testing.T) {
+	layout, err := NewLayout(128)
+	assert.Nil(t, err)
+
+	sL, sR := dpf.RandomSeed(16), dpf.RandomSeed(16)
+	joined, err := layout.Join(sL, true, sR, false)
+	assert.Nil(t, err)
+	assert.Len(t, joined, layout.OutputLength())
+
+	sL2, tL, sR2, tR, err := layout.Split(joined)
+	assert.Nil(t, err)
+	assert.Equal(t, sL, sL2)
+	assert.True(t, tL)
+	assert.Equal(t, sR, sR2)
+	assert.False(t, tR)
+
+	_, err = layout.Join(sL[:15], true, sR, false)
+	assert.NotNil(t, err)
+}
+
+func TestExpand(t *testing.T) {
+	layout, err := NewLayout(128)
+	assert.Nil(t, err)
+
+	seed := dpf.RandomSeed(16)
+	sL, tL, sR, tR, err := layout.Expand(seed)
+	assert.Nil(t, err)
+
+	out := dpf.PRG(seed, layout.OutputLength())
+	assert.Equal(t, out[:16], sL)
+	assert.Equal(t, out[16]&1 == 1, tL)
+	assert.Equal(t, out[17:33], sR)
+	assert.Equal(t, out[33]&1 == 1, tR)
+}
+
+func TestExpandInto(t *testing.T) {
+	layout, err := NewLayout(192)
+	assert.Nil(t, err)
+
+	seed := dpf.RandomSeed(24)
+	sL, tL, sR, tR, err := layout.Expand(seed)
+	assert.Nil(t, err)
+
+	out := make([]byte, layout.OutputLength())
+	sL2, tL2, sR2, tR2, err := layout.ExpandInto(out, seed)
+	assert.Nil(t, err)
+	assert.Equal(t, sL, sL2)
+	assert.Equal(t, tL, tL2)
+	assert.Equal(t, sR, sR2)
+	assert.Equal(t, tR, tR2)
+
+	_, _, _, _, err = layout.ExpandInto(out[:1], seed)
+	assert.NotNil(t, err)
+}
+
+func TestCorrect(t *testing.T) {
+	layout, err := NewLayout(128)
+	assert.Nil(t, err)
+
+	for _, bits := range [][2]bool{{false, false}, {true, false}, {false, true}, {true, true}} {
+		out := dpf.PRG(dpf.RandomSeed(16), layout.OutputLength())
+		s := dpf.RandomSeed(16)
+		joined, err := layout.Join(s, bits[0], s, bits[1])
+		assert.Nil(t, err)
+		want := dpf.XORBytes(out, joined)
+
+		assert.Nil(t, layout.Correct(out, s, bits[0], bits[1]))
+		assert.Equal(t, want, out)
+	}
+
+	assert.NotNil(t, layout.Correct(make([]byte, layout.OutputLength()), make([]byte, 15), true, true))
+	assert.NotNil(t, layout.Correct(make([]byte, 3), make([]byte, 16), true, true))
+}
@@ -0,0 +1,205 @@
+package dspf
+
+import (
+	"errors"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// AggregationTarget accumulates the full evaluations of the DPF keys of a DSPF key into a single result.
+// It allows the aggregation to happen directly in the output group of the DPF, s.t. no conversion is required.
+// Add is only called sequentially, i.e. implementations need not be safe for concurrent use.
+type AggregationTarget interface {
+	// Init prepares the target to aggregate evaluations of the given length. Previous results are discarded.
+	Init(length int)
+	// Add adds val to the aggregate at position i.
+	Add(i int, val *big.Int)
+}
+
+// FrAggregationTarget is an AggregationTarget that additionally accepts values in the scalar field of BLS12-381.
+// If the base DPF of a DSPF is a dpf.BlockEvaluator, such targets are fed block-wise without intermediate big.Ints.
+type FrAggregationTarget interface {
+	AggregationTarget
+	// AddFr adds val to the aggregate at position i.
+	AddFr(i int, val *bls12381.Fr)
+}
+
+// FrAggregator aggregates DPF evaluations in the scalar field of BLS12-381.
+type FrAggregator struct {
+	values []*bls12381.Fr
+	tmp    *bls12381.Fr
+}
+
+// NewFrAggregator returns a new FrAggregator.
+func NewFrAggregator() *FrAggregator {
+	return &FrAggregator{tmp: bls12381.NewFr()}
+}
+
+// Init prepares the aggregator for evaluations of the given length.
+func (a *FrAggregator) Init(length int) {
+	a.values = make([]*bls12381.Fr, length)
+	for i := range a.values {
+		a.values[i] = bls12381.NewFr().Zero()
+	}
+}
+
+// Add adds val to the aggregate at position i.
+func (a *FrAggregator) Add(i int, val *big.Int) {
+	a.tmp.FromBytes(val.Bytes())
+	a.values[i].Add(a.values[i], a.tmp)
+}
+
+// AddFr adds val to the aggregate at position i.
+func (a *FrAggregator) AddFr(i int, val *bls12381.Fr) {
+	a.values[i].Add(a.values[i], val)
+}
+
+// Values returns the aggregated values.
+func (a *FrAggregator) Values() []*bls12381.Fr {
+	return a.values
+}
+
+// ModAggregator aggregates DPF evaluations in the integers modulo a given modulus.
+// This suits DPFs whose output group is not the scalar field of BLS12-381.
+type ModAggregator struct {
+	modulus *big.Int
+	values  []*big.Int
+}
+
+// NewModAggregator returns a new ModAggregator for the given modulus.
+func NewModAggregator(modulus *big.Int) (*ModAggregator, error) {
+	if modulus == nil || modulus.Sign() <= 0 {
+		return nil, errors.New("modulus must be positive")
+	}
+	return &ModAggregator{modulus: new(big.Int).Set(modulus)}, nil
+}
+
+// Init prepares the aggregator for evaluations of the given length.
+func (a *ModAggregator) Init(length int) {
+	a.values = make([]*big.Int, length)
+	for i := range a.values {
+		a.values[i] = big.NewInt(0)
+	}
+}
+
+// Add adds val to the aggregate at position i.
+func (a *ModAggregator) Add(i int, val *big.Int) {
+	a.values[i].Add(a.values[i], val)
+	a.values[i].Mod(a.values[i], a.modulus)
+}
+
+// Values returns the aggregated values.
+func (a *ModAggregator) Values() []*big.Int {
+	return a.values
+}
+
+// Aggregate aggregates already computed full evaluations (e.g. from FullEval) into target.
+func Aggregate(ys [][]*big.Int, target AggregationTarget) error {
+	if len(ys) == 0 {
+		return errors.New("no evaluations to aggregate")
+	}
+	target.Init(len(ys[0]))
+	for _, y := range ys {
+		if len(y) != len(ys[0]) {
+			return errors.New("all evaluations must have the same length")
+		}
+		for i, val := range y {
+			if err := addEvaluation(target, i, val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addEvaluation adds the DPF output val to the aggregate at position i. FrAggregationTargets receive the output via
+// the checked dpf.FrFromBigCanonical, s.t. outputs that are no field elements fail instead of being silently reduced.
+func addEvaluation(target AggregationTarget, i int, val *big.Int) error {
+	frTarget, ok := target.(FrAggregationTarget)
+	if !ok {
+		target.Add(i, val)
+		return nil
+	}
+	fr, err := dpf.FrFromBigCanonical(val)
+	if err != nil {
+		return fmt.Errorf("invalid DPF output at position %d: %w", i, err)
+	}
+	frTarget.AddFr(i, fr)
+	return nil
+}
+
+// aggregateSubdomains aggregates the full evaluations of DPF keys whose domain is a subdomain of the aggregate, e.g.
+// a segment or a bucket, into target. position maps the point j of key i to its position in the aggregate, where
+// negative positions are skipped. The keys are evaluated in parallel, and if the evaluation of one or more keys
+// fails, the error of the key with the lowest index is returned.
+func aggregateSubdomains(baseDPF dpf.DPF, keys []dpf.Key, length int, target AggregationTarget, position func(i, j int) int) error {
+	if err := checkKeyTypes(baseDPF, keys); err != nil {
+		return err
+	}
+	if err := checkTarget(baseDPF, target); err != nil {
+		return err
+	}
+	evaluator, blockwise := baseDPF.(dpf.BlockEvaluator)
+	frTarget, ok := target.(FrAggregationTarget)
+	blockwise = blockwise && ok
+	subdomainLength := 1 << baseDPF.GetDomain()
+	target.Init(length)
+
+	var next atomic.Int64
+	var mu sync.Mutex // mu serializes the calls to target
+	errs := make([]error, len(keys))
+	wg := sync.WaitGroup{}
+	for w := 0; w < min(runtime.NumCPU(), len(keys)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(keys) {
+					return
+				}
+				if blockwise {
+					errs[i] = evaluator.FullEvalBlocks(keys[i], min(evalBlockSize, subdomainLength), func(offset int, block []*bls12381.Fr) error {
+						mu.Lock()
+						defer mu.Unlock()
+						for j, val := range block {
+							if pos := position(i, offset+j); pos >= 0 {
+								frTarget.AddFr(pos, val)
+							}
+						}
+						return nil
+					})
+					continue
+				}
+				ys, err := baseDPF.FullEvalFast(keys[i])
+				if err == nil && len(ys) != subdomainLength {
+					err = fmt.Errorf("full evaluation has length %d but is expected to be %d", len(ys), subdomainLength)
+				}
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				mu.Lock()
+				for j, val := range ys {
+					if pos := position(i, j); pos >= 0 {
+						target.Add(pos, val)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range errs {
+		if errs[i] != nil {
+			return fmt.Errorf("failed to evaluate DPF key %d: %w", i, errs[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package dspf
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestAggregateMatchesFullEvalFastAggregated(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+
+	k1, _, err := dspf.Gen([]*big.Int{big.NewInt(4), big.NewInt(9)}, []*big.Int{big.NewInt(2), big.NewInt(3)})
+	assert.Nil(t, err)
+
+	ys, err := dspf.FullEval(k1)
+	assert.Nil(t, err)
+	target := NewFrAggregator()
+	assert.Nil(t, Aggregate(ys, target))
+
+	expected, err := dspf.FullEvalFastAggregated(k1)
+	assert.Nil(t, err)
+	assert.Len(t, target.Values(), len(expected))
+	for i := range expected {
+		assert.True(t, expected[i].Equal(target.Values()[i]))
+	}
+
+	assert.NotNil(t, Aggregate(nil, target))
+	assert.NotNil(t, Aggregate([][]*big.Int{ys[0], ys[1][1:]}, target))
+}
+
+func TestAggregateChecksOutputs(t *testing.T) {
+	q, _ := new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+	target := NewFrAggregator()
+
+	// q is reduced to zero explicitly
+	assert.Nil(t, Aggregate([][]*big.Int{{q, big.NewInt(1)}}, target))
+	assert.True(t, target.Values()[0].IsZero())
+	assert.True(t, target.Values()[1].IsOne())
+
+	// Outputs that are no field elements are rejected
+	assert.NotNil(t, Aggregate([][]*big.Int{{big.NewInt(-1)}}, target))
+	assert.NotNil(t, Aggregate([][]*big.Int{{new(big.Int).Lsh(big.NewInt(1), 256)}}, target))
+
+	// Other targets receive the outputs as they are
+	mod, err := NewModAggregator(big.NewInt(7))
+	assert.Nil(t, err)
+	assert.Nil(t, Aggregate([][]*big.Int{{big.NewInt(9)}}, mod))
+	assert.Equal(t, big.NewInt(2), mod.Values()[0])
+}
+
+func TestFullEvalFastAggregatedIntoModAggregator(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+
+	specialPoints := []*big.Int{big.NewInt(1), big.NewInt(200)}
+	nonZeroElements := []*big.Int{big.NewInt(42), big.NewInt(1337)}
+	k1, k2, err := dspf.Gen(specialPoints, nonZeroElements)
+	assert.Nil(t, err)
+
+	// The output group of the DPF is the scalar field of BLS12-381.
+	modulus, _ := new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+	target1, err := NewModAggregator(modulus)
+	assert.Nil(t, err)
+	target2, err := NewModAggregator(modulus)
+	assert.Nil(t, err)
+	assert.Nil(t, dspf.FullEvalFastAggregatedInto(k1, target1))
+	assert.Nil(t, dspf.FullEvalFastAggregatedInto(k2, target2))
+
+	for x := range target1.Values() {
+		res := new(big.Int).Add(target1.Values()[x], target2.Values()[x])
+		res.Mod(res, modulus)
+		expected := big.NewInt(0)
+		for i, sp := range specialPoints {
+			if sp.Int64() == int64(x) {
+				expected = nonZeroElements[i]
+			}
+		}
+		assert.Equal(t, 0, res.Cmp(expected))
+	}
+
+	_, err = NewModAggregator(big.NewInt(0))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,385 @@
+package dspf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// Parameters of the cuckoo hashing of the BucketizedDSPF.
+// Each point is assigned to bucketHashes distinct buckets. The amount of buckets is at least 1.5*t and is increased
+// until the assignment of t special points fails with probability at most 2^-bucketFailureBits (see numBucketsFor).
+const (
+	bucketHashes        = 3
+	bucketFactorNum     = 3 // The amount of buckets is at least ceil(t * bucketFactorNum / bucketFactorDen).
+	bucketFactorDen     = 2
+	bucketFailureBits   = 40
+	bucketSeedLength    = 16
+	maxHashSeedAttempts = 64
+)
+
+// bucketHashDomainSeparator separates the derivation of the public hash seeds of the BucketizedDSPF.
+const bucketHashDomainSeparator = "pcg-bbs-plus/dspf/bucketized/hash"
+
+// ErrBucketAssignment is returned by BucketizedDSPF.Gen if the special points cannot be assigned to distinct buckets,
+// which happens with probability at most 2^-bucketFailureBits for uniformly random special points.
+var ErrBucketAssignment = errors.New("failed to assign the special points to distinct buckets")
+
+// BucketizedDSPF is a DSPF that batches its DPFs via cuckoo hashing to reduce the cost of full evaluations.
+// Each point of the domain is assigned to bucketHashes of m >= 1.5*t buckets by public hash functions. Gen places each
+// special point into one of its buckets, s.t. no bucket holds more than one special point, and generates one DPF per
+// bucket over the (much smaller) domain of the positions within the bucket. A full evaluation thereby evaluates
+// m DPFs over about 3*2^N/m points each, i.e. O(2^N) points in total instead of t*2^N.
+//
+// The hash functions are fixed by the domain and t, i.e. independent of the special points, s.t. the keys do not
+// leak information about them. If the special points cannot be assigned to buckets, Gen fails with
+// ErrBucketAssignment instead of resampling the hash functions.
+type BucketizedDSPF struct {
+	baseDPF      dpf.DPF        // baseDPF evaluates the DPFs of the buckets and is set to the domain of the buckets.
+	domain       int            // domain is the bit length of the domain of the DSPF.
+	t            int            // t is the maximum amount of special points.
+	numBuckets   int            // numBuckets is the amount of buckets m.
+	bucketDomain int            // bucketDomain is the bit length of the domain of a single bucket.
+	logger       logging.Logger // logger receives the log messages of the DSPF. It defaults to a no-op logger.
+
+	tablesOnce sync.Once
+	tables     *bucketTables // tables holds the buckets of the public hash seed, computed on first use.
+	tablesErr  error
+}
+
+// BucketizedKey is a key of the BucketizedDSPF.
+type BucketizedKey struct {
+	Key             // Key holds the DPF keys of the buckets, indexed by the bucket.
+	HashSeed []byte // HashSeed determines the public hash functions that assign the domain points to the buckets (see BucketizedDSPF.HashSeed).
+}
+
+// bucketTables holds the domain points of each bucket in ascending order for a given hash seed.
+type bucketTables struct {
+	seed    []byte
+	buckets [][]int
+}
+
+// NewBucketizedDSPF creates a new BucketizedDSPF for up to t special points in a domain of the given bit length.
+// The domain of baseDPF is changed to the domain of the buckets, hence it must not be shared with other DSPFs.
+func NewBucketizedDSPF(baseDPF dpf.DPF, domain, t int) (*BucketizedDSPF, error) {
+	if baseDPF == nil {
+		return nil, errors.New("base DPF must not be nil")
+	}
+	if domain < 1 || domain > 32 {
+		return nil, errors.New("domain must be within [1, 32]")
+	}
+	if t < 1 || t > 1<<domain {
+		return nil, errors.New("t must be within [1, 2^domain]")
+	}
+
+	numBuckets := numBucketsFor(t)
+	// The capacity of a bucket is twice its expected size, s.t. overflows are unlikely for large domains.
+	expectedSize := (bucketHashes<<domain + numBuckets - 1) / numBuckets
+	bucketDomain := 1
+	for 1<<bucketDomain < 2*expectedSize {
+		bucketDomain++
+	}
+	bucketDomain = min(bucketDomain, domain)
+	if err := baseDPF.ChangeDomain(bucketDomain); err != nil {
+		return nil, err
+	}
+
+	return &BucketizedDSPF{
+		baseDPF:      baseDPF,
+		domain:       domain,
+		t:            t,
+		numBuckets:   numBuckets,
+		bucketDomain: bucketDomain,
+		logger:       logging.NopLogger{},
+	}, nil
+}
+
+// SetLogger sets the logger of the DSPF. A nil logger discards all messages.
+func (d *BucketizedDSPF) SetLogger(logger logging.Logger) {
+	d.logger = logging.OrNop(logger)
+}
+
+// OutputGroup returns the output group of the base DPF, in which the results of both keys combine.
+func (d *BucketizedDSPF) OutputGroup() dpf.OutputGroup {
+	return d.baseDPF.OutputGroup()
+}
+
+// NumBuckets returns the amount of buckets, i.e. the amount of DPF keys per key.
+func (d *BucketizedDSPF) NumBuckets() int {
+	return d.numBuckets
+}
+
+// BucketDomain returns the bit length of the domain of the DPF of each bucket.
+func (d *BucketizedDSPF) BucketDomain() int {
+	return d.bucketDomain
+}
+
+// HashSeed returns the public hash seed of the buckets, which is derived from the domain and t. Its buckets are
+// computed on the first call, which hashes all points of the domain.
+func (d *BucketizedDSPF) HashSeed() ([]byte, error) {
+	tables, err := d.publicTables()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), tables.seed...), nil
+}
+
+// Gen generates keys for up to t distinct special points and their non-zero elements. It returns ErrBucketAssignment
+// if the special points cannot be assigned to distinct buckets.
+func (d *BucketizedDSPF) Gen(specialPoints []*big.Int, nonZeroElements []*big.Int) (BucketizedKey, BucketizedKey, error) {
+	if len(specialPoints) != len(nonZeroElements) {
+		return BucketizedKey{}, BucketizedKey{}, errors.New("the number of special points and non-zero elements must match")
+	}
+	if len(specialPoints) > d.t {
+		return BucketizedKey{}, BucketizedKey{}, fmt.Errorf("at most t=%d special points are supported", d.t)
+	}
+	points := make([]int, len(specialPoints))
+	seen := make(map[int]bool, len(specialPoints))
+	for i, sp := range specialPoints {
+		if sp.Sign() < 0 || sp.BitLen() > d.domain {
+			return BucketizedKey{}, BucketizedKey{}, errors.New("the special point is too large. It must be within the Domain of the DSPF")
+		}
+		points[i] = int(sp.Int64())
+		if seen[points[i]] {
+			return BucketizedKey{}, BucketizedKey{}, errors.New("the special points must be distinct")
+		}
+		seen[points[i]] = true
+	}
+
+	tables, err := d.publicTables()
+	if err != nil {
+		return BucketizedKey{}, BucketizedKey{}, err
+	}
+	assignment, err := cuckooInsert(points, d.numBuckets, tables.seed)
+	if err != nil {
+		d.logger.Debugf("assignment of %d points to %d buckets failed", len(points), d.numBuckets)
+		return BucketizedKey{}, BucketizedKey{}, err
+	}
+
+	// Each bucket gets a DPF key, where buckets without special point use a zero non-zero element at a random position.
+	// This hides which buckets hold a special point.
+	keyAlice := BucketizedKey{HashSeed: tables.seed}
+	keyBob := BucketizedKey{HashSeed: tables.seed}
+	bucketSize := big.NewInt(1 << d.bucketDomain)
+	for b := 0; b < d.numBuckets; b++ {
+		position, err := rand.Int(rand.Reader, bucketSize)
+		if err != nil {
+			return BucketizedKey{}, BucketizedKey{}, err
+		}
+		beta := big.NewInt(0)
+		if i := assignment[b]; i >= 0 {
+			position.SetInt64(int64(sort.SearchInts(tables.buckets[b], points[i])))
+			beta = nonZeroElements[i]
+		}
+		key1, key2, err := d.baseDPF.Gen(position, beta)
+		if err != nil {
+			return BucketizedKey{}, BucketizedKey{}, err
+		}
+		keyAlice.DPFKeys = append(keyAlice.DPFKeys, key1)
+		keyBob.DPFKeys = append(keyBob.DPFKeys, key2)
+	}
+	return keyAlice, keyBob, nil
+}
+
+// Eval evaluates the key on a given point x and returns the share of the sum of all DPFs whose bucket contains x.
+func (d *BucketizedDSPF) Eval(key BucketizedKey, x *big.Int) (*big.Int, error) {
+	if x.Sign() < 0 || x.BitLen() > d.domain {
+		return nil, errors.New("x must be within the Domain of the DSPF")
+	}
+	tables, err := d.keyTables(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := big.NewInt(0)
+	for _, b := range bucketsOf(tables.seed, int(x.Int64()), d.numBuckets) {
+		position := sort.SearchInts(tables.buckets[b], int(x.Int64()))
+		y, err := d.baseDPF.Eval(key.DPFKeys[b], big.NewInt(int64(position)))
+		if err != nil {
+			return nil, err
+		}
+		sum = d.baseDPF.CombineResults(sum, y)
+	}
+	return sum, nil
+}
+
+// FullEvalFastAggregated evaluates the key on all points in the domain and returns the aggregated result, just like
+// DSPF.FullEvalFastAggregated.
+func (d *BucketizedDSPF) FullEvalFastAggregated(key BucketizedKey) ([]*bls12381.Fr, error) {
+	target := NewFrAggregator()
+	if err := d.FullEvalFastAggregatedInto(key, target); err != nil {
+		return nil, err
+	}
+	return target.Values(), nil
+}
+
+// FullEvalFastAggregatedInto works like FullEvalFastAggregated, but aggregates the results into the given target.
+// The buckets are evaluated in parallel. If the evaluation of one or more buckets fails, the error of the bucket with
+// the lowest index is returned.
+func (d *BucketizedDSPF) FullEvalFastAggregatedInto(key BucketizedKey, target AggregationTarget) error {
+	tables, err := d.keyTables(key)
+	if err != nil {
+		return err
+	}
+
+	// Positions beyond the points of the bucket are padding and hold shares of zero.
+	return aggregateSubdomains(d.baseDPF, key.DPFKeys, 1<<d.domain, target, func(b, j int) int {
+		if j < len(tables.buckets[b]) {
+			return tables.buckets[b][j]
+		}
+		return -1
+	})
+}
+
+// keyTables returns the buckets of the public hash seed after checking that the key fits the DSPF.
+func (d *BucketizedDSPF) keyTables(key BucketizedKey) (*bucketTables, error) {
+	if len(key.DPFKeys) != d.numBuckets {
+		return nil, fmt.Errorf("key holds %d DPF keys but %d buckets are expected", len(key.DPFKeys), d.numBuckets)
+	}
+	tables, err := d.publicTables()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(key.HashSeed, tables.seed) {
+		return nil, errors.New("the hash seed of the key differs from the public hash seed of the DSPF")
+	}
+	return tables, nil
+}
+
+// publicTables returns the buckets of the public hash seed. The seed is derived from the domain and t. If a bucket
+// of a seed exceeds its capacity, the next seed is derived, which only depends on public values.
+func (d *BucketizedDSPF) publicTables() (*bucketTables, error) {
+	d.tablesOnce.Do(func() {
+		for attempt := 0; attempt < maxHashSeedAttempts; attempt++ {
+			if d.tables, d.tablesErr = d.bucketTables(publicHashSeed(d.domain, d.t, attempt)); d.tablesErr == nil {
+				return
+			}
+			d.logger.Debugf("hash seed %d overflows a bucket: %v", attempt, d.tablesErr)
+		}
+		d.tablesErr = fmt.Errorf("no hash seed of %d attempts fits the buckets: %w", maxHashSeedAttempts, d.tablesErr)
+	})
+	return d.tables, d.tablesErr
+}
+
+// publicHashSeed derives the hash seed of the given attempt for the domain and t.
+func publicHashSeed(domain, t, attempt int) []byte {
+	h := sha256.New()
+	h.Write([]byte(bucketHashDomainSeparator))
+	for _, v := range []int{domain, t, attempt} {
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+	}
+	return h.Sum(nil)[:bucketSeedLength]
+}
+
+// bucketTables returns the buckets of the given hash seed or an error if a bucket exceeds its capacity.
+func (d *BucketizedDSPF) bucketTables(seed []byte) (*bucketTables, error) {
+	buckets := make([][]int, d.numBuckets)
+	for x := 0; x < 1<<d.domain; x++ {
+		for _, b := range bucketsOf(seed, x, d.numBuckets) {
+			if len(buckets[b]) == 1<<d.bucketDomain {
+				return nil, fmt.Errorf("bucket %d exceeds its capacity of %d points", b, 1<<d.bucketDomain)
+			}
+			buckets[b] = append(buckets[b], x)
+		}
+	}
+	return &bucketTables{seed: append([]byte(nil), seed...), buckets: buckets}, nil
+}
+
+// bucketsOf returns the bucketHashes distinct buckets the point x is assigned to by the hash functions of the given
+// seed. numBuckets must be at least bucketHashes.
+func bucketsOf(seed []byte, x, numBuckets int) []int {
+	buf := make([]byte, 0, len(seed)+16)
+	buf = append(buf, seed...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(x))
+
+	buckets := make([]int, 0, bucketHashes)
+	for counter := uint64(0); len(buckets) < bucketHashes; counter++ {
+		digest := sha256.Sum256(binary.BigEndian.AppendUint64(buf, counter))
+		for h := 0; h < len(digest)/8 && len(buckets) < bucketHashes; h++ {
+			b := int(binary.BigEndian.Uint64(digest[8*h:]) % uint64(numBuckets))
+			duplicate := false
+			for _, other := range buckets {
+				duplicate = duplicate || other == b
+			}
+			if !duplicate {
+				buckets = append(buckets, b)
+			}
+		}
+	}
+	return buckets
+}
+
+// numBucketsFor returns the amount of buckets for t special points, i.e. the smallest m >= max(1.5*t, bucketHashes)
+// for which the union bound on the failure probability of the assignment is at most 2^-bucketFailureBits. The
+// assignment fails iff k points share fewer than k buckets. As each point has bucketHashes distinct buckets, this
+// requires k > bucketHashes and happens with probability at most
+// sum_k binomial(t, k) * binomial(m, k-1) * (binomial(k-1, 3) / binomial(m, 3))^k.
+func numBucketsFor(t int) int {
+	m := max((t*bucketFactorNum+bucketFactorDen-1)/bucketFactorDen, bucketHashes)
+	for log2AssignmentFailure(t, m) > -bucketFailureBits {
+		m++
+	}
+	return m
+}
+
+// log2AssignmentFailure returns log2 of the union bound of numBucketsFor on the failure probability of assigning t
+// points to m buckets.
+func log2AssignmentFailure(t, m int) float64 {
+	logBinomial := func(n, k int) float64 {
+		a, _ := math.Lgamma(float64(n + 1))
+		b, _ := math.Lgamma(float64(k + 1))
+		c, _ := math.Lgamma(float64(n - k + 1))
+		return a - b - c
+	}
+	sum := 0.0
+	for k := bucketHashes + 1; k <= t; k++ {
+		sum += math.Exp(logBinomial(t, k) + logBinomial(m, k-1) + float64(k)*(logBinomial(k-1, bucketHashes)-logBinomial(m, bucketHashes)))
+	}
+	return math.Log2(sum) // -Inf for t <= bucketHashes, for which the assignment never fails
+}
+
+// cuckooInsert assigns each point to one of its buckets, s.t. each bucket holds at most one point, via augmenting
+// paths. It finds an assignment whenever one exists and returns ErrBucketAssignment otherwise.
+// It returns the index of the point of each bucket, or -1 for empty buckets.
+func cuckooInsert(points []int, numBuckets int, seed []byte) ([]int, error) {
+	assignment := make([]int, numBuckets)
+	for b := range assignment {
+		assignment[b] = -1
+	}
+	candidates := make([][]int, len(points))
+	for i, point := range points {
+		candidates[i] = bucketsOf(seed, point, numBuckets)
+	}
+
+	// augment places point i, possibly moving the points of its candidate buckets to other buckets
+	var augment func(i int, visited []bool) bool
+	augment = func(i int, visited []bool) bool {
+		for _, b := range candidates[i] {
+			if visited[b] {
+				continue
+			}
+			visited[b] = true
+			if assignment[b] < 0 || augment(assignment[b], visited) {
+				assignment[b] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range points {
+		if !augment(i, make([]bool, numBuckets)) {
+			return nil, ErrBucketAssignment
+		}
+	}
+	return assignment, nil
+}
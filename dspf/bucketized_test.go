@@ -0,0 +1,232 @@
+package dspf
+
+import (
+	"crypto/rand"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestBucketizedDSPFFullEvalFastAggregated(t *testing.T) {
+	for _, params := range []struct{ domain, t int }{{6, 1}, {8, 4}, {12, 16}} {
+		d, err := optreedpf.InitFactory(128, params.domain)
+		assert.Nil(t, err)
+		dspf, err := NewBucketizedDSPF(d, params.domain, params.t)
+		assert.Nil(t, err)
+		assert.LessOrEqual(t, dspf.BucketDomain(), params.domain)
+
+		specialPoints, nonZeroElements := randomDistinctPoints(t, params.domain, params.t, d.BetaMax)
+		k1, k2, err := dspf.Gen(specialPoints, nonZeroElements)
+		assert.Nil(t, err)
+		assert.Equal(t, dspf.NumBuckets(), k1.AmountOfDPFKeys())
+		assert.Equal(t, k1.HashSeed, k2.HashSeed)
+
+		ys1, err := dspf.FullEvalFastAggregated(k1)
+		assert.Nil(t, err)
+		ys2, err := dspf.FullEvalFastAggregated(k2)
+		assert.Nil(t, err)
+		assert.Equal(t, 1<<params.domain, len(ys1))
+
+		expected := make(map[int64]*big.Int)
+		for i, sp := range specialPoints {
+			expected[sp.Int64()] = nonZeroElements[i]
+		}
+		for x := range ys1 {
+			res := bls12381.NewFr()
+			res.Add(ys1[x], ys2[x])
+			want, ok := expected[int64(x)]
+			if !ok {
+				want = big.NewInt(0)
+			}
+			assert.Equal(t, 0, res.ToBig().Cmp(want), "domain %d, x=%d", params.domain, x)
+		}
+
+		// Eval matches the full evaluation
+		for _, x := range []*big.Int{specialPoints[0], big.NewInt(0), big.NewInt(1<<params.domain - 1)} {
+			y1, err := dspf.Eval(k1, x)
+			assert.Nil(t, err)
+			y2, err := dspf.Eval(k2, x)
+			assert.Nil(t, err)
+			res := bls12381.NewFr()
+			res.Add(ys1[x.Int64()], ys2[x.Int64()])
+			assert.Equal(t, 0, d.CombineResults(y1, y2).Cmp(res.ToBig()))
+		}
+	}
+}
+
+func TestBucketizedDSPFModAggregator(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf, err := NewBucketizedDSPF(d, 8, 4)
+	assert.Nil(t, err)
+
+	specialPoints, nonZeroElements := randomDistinctPoints(t, 8, 4, d.BetaMax)
+	k1, _, err := dspf.Gen(specialPoints, nonZeroElements)
+	assert.Nil(t, err)
+
+	// The big.Int path yields the same aggregate as the block-wise path
+	expected, err := dspf.FullEvalFastAggregated(k1)
+	assert.Nil(t, err)
+	modulus, _ := new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+	target, err := NewModAggregator(modulus)
+	assert.Nil(t, err)
+	assert.Nil(t, dspf.FullEvalFastAggregatedInto(k1, target))
+	for x, val := range target.Values() {
+		assert.Equal(t, 0, val.Cmp(expected[x].ToBig()))
+	}
+}
+
+func TestBucketizedDSPFInvalidInputs(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	_, err = NewBucketizedDSPF(d, 8, 0)
+	assert.NotNil(t, err)
+	_, err = NewBucketizedDSPF(nil, 8, 4)
+	assert.NotNil(t, err)
+	dspf, err := NewBucketizedDSPF(d, 8, 2)
+	assert.Nil(t, err)
+
+	_, _, err = dspf.Gen([]*big.Int{big.NewInt(1)}, []*big.Int{big.NewInt(1), big.NewInt(2)})
+	assert.NotNil(t, err)
+	_, _, err = dspf.Gen([]*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}, []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)})
+	assert.NotNil(t, err)
+	_, _, err = dspf.Gen([]*big.Int{big.NewInt(1), big.NewInt(1)}, []*big.Int{big.NewInt(1), big.NewInt(2)})
+	assert.NotNil(t, err)
+	_, _, err = dspf.Gen([]*big.Int{big.NewInt(256)}, []*big.Int{big.NewInt(1)})
+	assert.NotNil(t, err)
+
+	k1, _, err := dspf.Gen([]*big.Int{big.NewInt(1)}, []*big.Int{big.NewInt(1)})
+	assert.Nil(t, err)
+	k1.DPFKeys = k1.DPFKeys[1:]
+	_, err = dspf.FullEvalFastAggregated(k1)
+	assert.NotNil(t, err)
+	_, err = dspf.Eval(k1, big.NewInt(256))
+	assert.NotNil(t, err)
+}
+
+func TestBucketizedDSPFPublicHashSeed(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf, err := NewBucketizedDSPF(d, 8, 4)
+	assert.Nil(t, err)
+	seed, err := dspf.HashSeed()
+	assert.Nil(t, err)
+
+	// The hash seed does not depend on the special points
+	for i := 0; i < 3; i++ {
+		specialPoints, nonZeroElements := randomDistinctPoints(t, 8, 4, d.BetaMax)
+		k1, k2, err := dspf.Gen(specialPoints, nonZeroElements)
+		assert.Nil(t, err)
+		assert.Equal(t, seed, k1.HashSeed)
+		assert.Equal(t, seed, k2.HashSeed)
+	}
+
+	// Keys of another hash seed are rejected
+	k1, _, err := dspf.Gen([]*big.Int{big.NewInt(1)}, []*big.Int{big.NewInt(1)})
+	assert.Nil(t, err)
+	k1.HashSeed = make([]byte, bucketSeedLength)
+	_, err = dspf.FullEvalFastAggregated(k1)
+	assert.NotNil(t, err)
+}
+
+func TestNumBucketsFor(t *testing.T) {
+	assert.Equal(t, bucketHashes, numBucketsFor(1))
+	assert.Equal(t, bucketHashes, numBucketsFor(2))
+	for _, tt := range []int{4, 16, 256} {
+		m := numBucketsFor(tt)
+		assert.GreaterOrEqual(t, 2*m, 3*tt)
+		assert.LessOrEqual(t, log2AssignmentFailure(tt, m), float64(-bucketFailureBits))
+		assert.Greater(t, log2AssignmentFailure(tt, m-1), float64(-bucketFailureBits))
+	}
+}
+
+func TestCuckooInsert(t *testing.T) {
+	seed := make([]byte, bucketSeedLength)
+	points := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	assignment, err := cuckooInsert(points, 12, seed)
+	assert.Nil(t, err)
+	placed := make(map[int]bool)
+	for b, i := range assignment {
+		if i < 0 {
+			continue
+		}
+		assert.False(t, placed[i])
+		placed[i] = true
+		assert.Contains(t, bucketsOf(seed, points[i], 12), b)
+	}
+	assert.Equal(t, len(points), len(placed))
+	for _, point := range points {
+		assert.Len(t, bucketsOf(seed, point, 12), bucketHashes)
+	}
+
+	// More points than buckets cannot be assigned
+	_, err = cuckooInsert([]int{1, 2, 3, 4}, 3, seed)
+	assert.ErrorIs(t, err, ErrBucketAssignment)
+}
+
+func randomDistinctPoints(t *testing.T, domain, amount int, betaMax *big.Int) ([]*big.Int, []*big.Int) {
+	maxInputX := new(big.Int).Lsh(big.NewInt(1), uint(domain))
+	seen := make(map[int64]bool)
+	specialPoints := make([]*big.Int, 0, amount)
+	nonZeroElements := make([]*big.Int, 0, amount)
+	for len(specialPoints) < amount {
+		x, err := rand.Int(rand.Reader, maxInputX)
+		assert.Nil(t, err)
+		if seen[x.Int64()] {
+			continue
+		}
+		seen[x.Int64()] = true
+		y, err := rand.Int(rand.Reader, betaMax)
+		assert.Nil(t, err)
+		specialPoints = append(specialPoints, x)
+		nonZeroElements = append(nonZeroElements, y.Add(y, big.NewInt(1)))
+	}
+	return specialPoints, nonZeroElements
+}
+
+// The bucketized DSPF is compared to the DSPF via the BenchmarkOpTreeDSPFFullEvalFast128_* benchmarks.
+func BenchmarkBucketizedDSPFFullEvalFast128_n14_t16(b *testing.B) {
+	benchmarkBucketizedDSPFFullEvalFast(b, 128, 14, 16)
+}
+func BenchmarkBucketizedDSPFFullEvalFast128_n16_t16(b *testing.B) {
+	benchmarkBucketizedDSPFFullEvalFast(b, 128, 16, 16)
+}
+func BenchmarkBucketizedDSPFFullEvalFast128_n14_t256(b *testing.B) {
+	benchmarkBucketizedDSPFFullEvalFast(b, 128, 14, 256)
+}
+func BenchmarkBucketizedDSPFFullEvalFast128_n16_t256(b *testing.B) {
+	benchmarkBucketizedDSPFFullEvalFast(b, 128, 16, 256)
+}
+
+func benchmarkBucketizedDSPFFullEvalFast(b *testing.B, lambda, domain, t int) {
+	d, err := optreedpf.InitFactory(lambda, domain)
+	if err != nil {
+		b.Fatal(err)
+	}
+	dspf, err := NewBucketizedDSPF(d, domain, t)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	specialPoints := make([]*big.Int, t)
+	nonZeroElements := make([]*big.Int, t)
+	for i := 0; i < t; i++ {
+		specialPoints[i] = big.NewInt(int64(i * ((1 << domain) / t)))
+		nonZeroElements[i] = big.NewInt(int64(i + 1))
+	}
+
+	k1, _, err := dspf.Gen(specialPoints, nonZeroElements)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := dspf.FullEvalFastAggregated(k1)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package dspf
+
+import (
+	"errors"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Worker evaluates chunks of a distributed full evaluation (see DistributedDSPF). A chunk is the subtree of the domain
+// under a prefix of the input bits, i.e. the 2^(domain-depth) points whose depth most significant bits are the prefix.
+// Workers may be local (see LocalWorker) or remote (see RemoteWorker).
+type Worker interface {
+	// EvalChunk evaluates the DSPF key with the given domain (in bits) at all points of the chunk of the prefix and
+	// returns the aggregate of the evaluations of its DPF keys, ordered by the point.
+	EvalChunk(domain int, dspfKey Key, prefix []uint, depth int) ([]*bls12381.Fr, error)
+}
+
+// FullEvalPrefixAggregated evaluates each DPF of the DSPF on all points under the prefix of depth bits and aggregates
+// the results (see dpf.PrefixEvaluator). The j-th result is the aggregate at prefix * 2^(domain-depth) + j.
+// The base DPF must be a dpf.PrefixEvaluator. The DPF keys are evaluated in parallel, and if the evaluation of one or
+// more keys fails, the error of the key with the lowest index is returned. The output group of the base DPF must be Fr.
+func (d *DSPF) FullEvalPrefixAggregated(dspfKey Key, prefix []uint, depth int) ([]*bls12381.Fr, error) {
+	evaluator, ok := d.baseDPF.(dpf.PrefixEvaluator)
+	if !ok {
+		return nil, errors.New("the base DPF does not support the evaluation of prefixes")
+	}
+	if err := checkFrOutputs(d.baseDPF); err != nil {
+		return nil, err
+	}
+	if err := checkKeyTypes(d.baseDPF, dspfKey.DPFKeys); err != nil {
+		return nil, err
+	}
+	domain := d.baseDPF.GetDomain()
+	if depth < 0 || depth > domain {
+		return nil, errors.New("the depth of the prefix must be within [0, domain]")
+	}
+	length := 1 << (domain - depth)
+
+	target := NewFrAggregator()
+	target.Init(length)
+	var next atomic.Int64
+	var mu sync.Mutex // mu serializes the calls to target
+	errs := make([]error, len(dspfKey.DPFKeys))
+	wg := sync.WaitGroup{}
+	for w := 0; w < min(runtime.NumCPU(), len(dspfKey.DPFKeys)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(dspfKey.DPFKeys) {
+					return
+				}
+				ys, err := evaluator.EvalPrefix(dspfKey.DPFKeys[i], prefix, depth)
+				if err == nil && len(ys) != length {
+					err = fmt.Errorf("evaluation of the prefix has length %d but is expected to be %d", len(ys), length)
+				}
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				mu.Lock()
+				for j, val := range ys {
+					target.Add(j, val)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range errs {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to evaluate DPF key %d: %w", i, errs[i])
+		}
+	}
+	return target.Values(), nil
+}
+
+// LocalWorker is a Worker that evaluates chunks in the current process.
+type LocalWorker struct {
+	dspfs map[int]*DSPF // dspfs maps the domain of each DSPF to the DSPF.
+}
+
+// NewLocalWorker returns a LocalWorker that evaluates chunks with the given DSPFs, whose base DPFs must be
+// dpf.PrefixEvaluators with outputs in Fr. Each chunk is evaluated by the DSPF of its domain. All base DPFs must be
+// compatible (see dpf.CheckCompatible), s.t. the worker evaluates the keys of a single backend.
+func NewLocalWorker(dspfs ...*DSPF) (*LocalWorker, error) {
+	w := &LocalWorker{dspfs: make(map[int]*DSPF, len(dspfs))}
+	for _, d := range dspfs {
+		if _, ok := d.baseDPF.(dpf.PrefixEvaluator); !ok {
+			return nil, errors.New("the base DPF does not support the evaluation of prefixes")
+		}
+		if err := checkFrOutputs(d.baseDPF); err != nil {
+			return nil, err
+		}
+		if err := dpf.CheckCompatible(dspfs[0].baseDPF, d.baseDPF); err != nil {
+			return nil, err
+		}
+		domain := d.baseDPF.GetDomain()
+		if _, ok := w.dspfs[domain]; ok {
+			return nil, fmt.Errorf("multiple DSPFs with domain %d", domain)
+		}
+		w.dspfs[domain] = d
+	}
+	return w, nil
+}
+
+// EvalChunk implements Worker.
+func (w *LocalWorker) EvalChunk(domain int, dspfKey Key, prefix []uint, depth int) ([]*bls12381.Fr, error) {
+	d, ok := w.dspfs[domain]
+	if !ok {
+		return nil, fmt.Errorf("the worker holds no DSPF with domain %d", domain)
+	}
+	return d.FullEvalPrefixAggregated(dspfKey, prefix, depth)
+}
+
+// DistributedDSPF is a DSPF whose full evaluations are split by prefix into 2^depth chunks, which are distributed
+// across workers. Each worker evaluates one chunk at a time, and the chunks are merged into the full evaluation.
+// Gen is performed locally by the underlying DSPF.
+type DistributedDSPF struct {
+	dspf    *DSPF          // dspf generates the keys and defines the domain of the evaluations.
+	workers []Worker       // workers evaluate the chunks.
+	depth   int            // depth is the bit length of the prefixes, i.e. the full evaluation is split into 2^depth chunks.
+	logger  logging.Logger // logger receives the log messages of the DSPF. It defaults to a no-op logger.
+}
+
+// NewDistributedDSPF returns a DistributedDSPF that splits the full evaluations of the given DSPF into 2^depth chunks
+// and distributes them across the workers. The depth must be within [0, domain] of the DSPF. The DSPFs of local workers
+// for the domain must be compatible with the DSPF (see dpf.CheckCompatible), remote workers are checked by their
+// results only.
+func NewDistributedDSPF(dspf *DSPF, workers []Worker, depth int) (*DistributedDSPF, error) {
+	if len(workers) == 0 {
+		return nil, errors.New("at least one worker is required")
+	}
+	if depth < 0 || depth > dspf.baseDPF.GetDomain() {
+		return nil, errors.New("the depth of the prefixes must be within [0, domain]")
+	}
+	for i, worker := range workers {
+		local, ok := worker.(*LocalWorker)
+		if !ok {
+			continue
+		}
+		if d, ok := local.dspfs[dspf.baseDPF.GetDomain()]; ok {
+			if err := dpf.CheckCompatible(dspf.baseDPF, d.baseDPF); err != nil {
+				return nil, fmt.Errorf("worker %d: %w", i, err)
+			}
+		}
+	}
+	return &DistributedDSPF{dspf: dspf, workers: workers, depth: depth, logger: logging.NopLogger{}}, nil
+}
+
+// SetLogger sets the logger of the DSPF. A nil logger discards all messages.
+func (d *DistributedDSPF) SetLogger(logger logging.Logger) {
+	d.logger = logging.OrNop(logger)
+	d.dspf.SetLogger(logger)
+}
+
+// OutputGroup implements Scheme.
+func (d *DistributedDSPF) OutputGroup() dpf.OutputGroup {
+	return d.dspf.OutputGroup()
+}
+
+// Gen generates keys for a DSPFt given t special points and non-zero elements (see DSPF.Gen).
+func (d *DistributedDSPF) Gen(specialPoints []*big.Int, nonZeroElements []*big.Int) (Key, Key, error) {
+	return d.dspf.Gen(specialPoints, nonZeroElements)
+}
+
+// FullEvalFastAggregated evaluates each DPF of the DSPF on all points in the domain and aggregates the results by
+// distributing the chunks across the workers. If the evaluation of one or more chunks fails, the error of the chunk
+// with the lowest prefix is returned.
+func (d *DistributedDSPF) FullEvalFastAggregated(dspfKey Key) ([]*bls12381.Fr, error) {
+	domain := d.dspf.baseDPF.GetDomain()
+	numChunks := 1 << d.depth
+	chunkLength := 1 << (domain - d.depth)
+	res := make([]*bls12381.Fr, 1<<domain)
+
+	var next atomic.Int64
+	errs := make([]error, numChunks)
+	wg := sync.WaitGroup{}
+	for _, worker := range d.workers[:min(len(d.workers), numChunks)] {
+		wg.Add(1)
+		go func(worker Worker) {
+			defer wg.Done()
+			for {
+				chunk := int(next.Add(1) - 1)
+				if chunk >= numChunks {
+					return
+				}
+				prefix, err := dpf.ExtendBigIntToBitLength(big.NewInt(int64(chunk)), d.depth)
+				if err != nil {
+					errs[chunk] = err
+					continue
+				}
+				values, err := worker.EvalChunk(domain, dspfKey, prefix, d.depth)
+				if err == nil && len(values) != chunkLength {
+					err = fmt.Errorf("chunk has length %d but is expected to be %d", len(values), chunkLength)
+				}
+				if err != nil {
+					errs[chunk] = err
+					continue
+				}
+				copy(res[chunk*chunkLength:], values) // Chunks are disjoint, hence no lock is required
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	for chunk := range errs {
+		if errs[chunk] != nil {
+			d.logger.Debugf("distributed full evaluation of DSPF key with %d DPF keys failed at chunk %d: %v", len(dspfKey.DPFKeys), chunk, errs[chunk])
+			return nil, fmt.Errorf("failed to evaluate chunk %d: %w", chunk, errs[chunk])
+		}
+	}
+	return res, nil
+}
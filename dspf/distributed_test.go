@@ -0,0 +1,54 @@
+package dspf
+
+import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+// failingWorker fails the evaluation of all chunks.
+type failingWorker struct{}
+
+func (failingWorker) EvalChunk(int, Key, []uint, int) ([]*bls12381.Fr, error) {
+	return nil, errors.New("failed")
+}
+
+func TestDistributedDSPF(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+	k1, _, err := dspf.Gen([]*big.Int{big.NewInt(4), big.NewInt(200)}, []*big.Int{big.NewInt(2), big.NewInt(3)})
+	assert.Nil(t, err)
+	expected, err := dspf.FullEvalFastAggregated(k1)
+	assert.Nil(t, err)
+
+	worker, err := NewLocalWorker(dspf)
+	assert.Nil(t, err)
+	for _, depth := range []int{0, 1, 3, 8} {
+		distributed, err := NewDistributedDSPF(dspf, []Worker{worker, worker, worker}, depth)
+		assert.Nil(t, err)
+		actual, err := distributed.FullEvalFastAggregated(k1)
+		assert.Nil(t, err)
+		assert.Len(t, actual, len(expected))
+		for i := range expected {
+			assert.True(t, expected[i].Equal(actual[i]), "depth=%d, x=%d", depth, i)
+		}
+	}
+
+	distributed, err := NewDistributedDSPF(dspf, []Worker{worker, failingWorker{}}, 2)
+	assert.Nil(t, err)
+	_, err = distributed.FullEvalFastAggregated(k1)
+	assert.NotNil(t, err)
+
+	_, err = NewDistributedDSPF(dspf, nil, 2)
+	assert.NotNil(t, err)
+	_, err = NewDistributedDSPF(dspf, []Worker{worker}, 9)
+	assert.NotNil(t, err)
+	_, err = NewLocalWorker(dspf, dspf) // duplicate domain
+	assert.NotNil(t, err)
+	_, err = worker.EvalChunk(9, k1, nil, 0) // unknown domain
+	assert.NotNil(t, err)
+}
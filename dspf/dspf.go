@@ -1,32 +1,67 @@
 package dspf
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging"
 	"math/big"
-	"pcg-bbs-plus/dpf"
 	"runtime"
 	"sync"
+	"sync/atomic"
 )
 
+// ErrDuplicateSpecialPoints is returned by DSPF.Gen if a special point is given more than once and duplicates are not
+// allowed (see SetAllowDuplicates).
+var ErrDuplicateSpecialPoints = errors.New("the special points must be distinct")
+
+// ErrTooManySpecialPoints is returned by DSPF.Gen if more distinct special points are given than the domain holds.
+var ErrTooManySpecialPoints = errors.New("the amount of special points exceeds the size of the domain")
+
 // DSPF is a Distributed Sum Of Point Function. It uses multiple DPFs to realize a multipoint function.
 type DSPF struct {
-	baseDPF dpf.DPF // The base DPF used to construct the DSPF
+	baseDPF         dpf.DPF        // The base DPF used to construct the DSPF
+	logger          logging.Logger // logger receives the log messages of the DSPF. It defaults to a no-op logger.
+	allowDuplicates bool           // allowDuplicates is set if Gen accepts duplicate special points (see SetAllowDuplicates).
 }
 
 // NewDSPFFactory creates a new DSPF factory with a given base DPF and domain.
 func NewDSPFFactory(baseDPF dpf.DPF) *DSPF {
 	return &DSPF{
 		baseDPF: baseDPF,
+		logger:  logging.NopLogger{},
 	}
 }
 
+// SetLogger sets the logger of the DSPF. A nil logger discards all messages.
+func (d *DSPF) SetLogger(logger logging.Logger) {
+	d.logger = logging.OrNop(logger)
+}
+
+// SetAllowDuplicates sets whether Gen accepts duplicate special points. By default, duplicates are rejected.
+// If they are allowed, the DPFs of duplicate points are generated independently, s.t. the full evaluation holds the sum
+// of their non-zero elements at the point. Note that the evaluations of the individual DPFs (see Eval) then reveal to
+// each party that a point is hit by multiple DPFs.
+func (d *DSPF) SetAllowDuplicates(allow bool) {
+	d.allowDuplicates = allow
+}
+
 // Gen generates keys for a DSPFt given t special points and non-zero elements.
+// It returns ErrDuplicateSpecialPoints if a special point is given more than once, unless duplicates are allowed, and
+// ErrTooManySpecialPoints if the distinct points cannot fit into the domain, before any key is generated.
 func (d *DSPF) Gen(specialPoints []*big.Int, nonZeroElements []*big.Int) (Key, Key, error) {
 	// Check if the inputs are valid: same length and non-nil
 	if len(specialPoints) != len(nonZeroElements) {
 		return Key{}, Key{}, errors.New("the number of special points and non-zero elements must match")
 	}
+	if domain := d.baseDPF.GetDomain(); !d.allowDuplicates && domain < 63 && int64(len(specialPoints)) > int64(1)<<domain {
+		return Key{}, Key{}, ErrTooManySpecialPoints
+	}
+	if !d.allowDuplicates && hasDuplicatePoints(specialPoints) {
+		return Key{}, Key{}, ErrDuplicateSpecialPoints
+	}
 
 	// Generate DPF keys for each (specialPoint, nonZeroElement) pair
 	var keyAlice Key
@@ -42,8 +77,16 @@ func (d *DSPF) Gen(specialPoints []*big.Int, nonZeroElements []*big.Int) (Key, K
 	return keyAlice, keyBob, nil
 }
 
+// OutputGroup implements Scheme.
+func (d *DSPF) OutputGroup() dpf.OutputGroup {
+	return d.baseDPF.OutputGroup()
+}
+
 // Eval evaluates the DSPFt on a given point x.
 func (d *DSPF) Eval(dspfKey Key, x *big.Int) ([]*big.Int, error) {
+	if err := checkKeyTypes(d.baseDPF, dspfKey.DPFKeys); err != nil {
+		return nil, err
+	}
 	ys := make([]*big.Int, len(dspfKey.DPFKeys))
 	for i, key := range dspfKey.DPFKeys {
 		y, err := d.baseDPF.Eval(key, x)
@@ -96,6 +139,9 @@ func (d *DSPF) CombineSingleResult(y1 []*big.Int, y2 []*big.Int) (*big.Int, erro
 
 // FullEval evaluates each DPF of the DSPF on all points in the domain.
 func (d *DSPF) FullEval(dspfKey Key) ([][]*big.Int, error) {
+	if err := checkKeyTypes(d.baseDPF, dspfKey.DPFKeys); err != nil {
+		return nil, err
+	}
 	ys := make([][]*big.Int, len(dspfKey.DPFKeys))
 	for i, key := range dspfKey.DPFKeys {
 		y, err := d.baseDPF.FullEval(key)
@@ -111,6 +157,9 @@ func (d *DSPF) FullEval(dspfKey Key) ([][]*big.Int, error) {
 // It parallelizes the evaluation of each DPF.
 // Warning: For large Domains use FullEvalFastAggregated instead to avoid memory issues.
 func (d *DSPF) FullEvalFast(dspfKey Key) ([][]*big.Int, error) {
+	if err := checkKeyTypes(d.baseDPF, dspfKey.DPFKeys); err != nil {
+		return nil, err
+	}
 	ys := make([][]*big.Int, len(dspfKey.DPFKeys))
 	errCh := make(chan error, 1)
 	wg := sync.WaitGroup{}
@@ -143,80 +192,240 @@ func (d *DSPF) FullEvalFast(dspfKey Key) ([][]*big.Int, error) {
 	return ys, nil
 }
 
-type AggregatedResult struct {
-	ys  []*bls12381.Fr
-	mtx sync.Mutex
+// indexedResult is the full evaluation of the DPF key at position index of a DSPF key.
+type indexedResult struct {
+	index int
+	ys    []*big.Int
+	err   error
 }
 
 // FullEvalFastAggregated evaluates each DPF of the DSPF on all points in the domain.
 // It parallelizes the evaluation of each DPF. It aggregates the results in a single result.
 // This also uses a worker pool to parallelize the aggregation efficiently in oder to avoid memory issues.
+// Each result is accounted to the index of its DPF key, s.t. a missing, duplicate or malformed result is detected.
+// If the evaluation of one or more keys fails, the error of the key with the lowest index is returned.
 func (d *DSPF) FullEvalFastAggregated(dspfKey Key) ([]*bls12381.Fr, error) {
-	expectedLen := big.NewInt(0).Exp(big.NewInt(2), big.NewInt(int64(d.baseDPF.GetDomain())), nil)
-	numWorkers := runtime.NumCPU()
+	target := NewFrAggregator()
+	if err := d.FullEvalFastAggregatedInto(dspfKey, target); err != nil {
+		return nil, err
+	}
+	return target.Values(), nil
+}
 
-	aggResult := AggregatedResult{
-		ys: make([]*bls12381.Fr, expectedLen.Int64()),
+// FullEvalFastAggregatedInto works like FullEvalFastAggregated, but aggregates the results into the given target.
+// The content of target is only meaningful if no error is returned.
+// If the base DPF is a dpf.BlockEvaluator and target is a FrAggregationTarget, the evaluations are streamed block-wise
+// into target, s.t. no full evaluation of a single DPF key is held in memory.
+func (d *DSPF) FullEvalFastAggregatedInto(dspfKey Key, target AggregationTarget) error {
+	return d.FullEvalFastAggregatedContext(context.Background(), dspfKey, target)
+}
+
+// FullEvalFastAggregatedContext works like FullEvalFastAggregatedInto, but stops the evaluation if ctx is done and
+// returns the error of ctx. Keys that are being evaluated by the base DPF are finished, but no further keys are started.
+// All workers have returned once FullEvalFastAggregatedContext returns, also on errors.
+// If target is a FrAggregationTarget, the output group of the base DPF must be Fr (see ErrOutputGroupNotFr).
+func (d *DSPF) FullEvalFastAggregatedContext(ctx context.Context, dspfKey Key, target AggregationTarget) error {
+	if err := checkKeyTypes(d.baseDPF, dspfKey.DPFKeys); err != nil {
+		return err
+	}
+	if err := checkTarget(d.baseDPF, target); err != nil {
+		return err
 	}
-	for i := range aggResult.ys {
-		aggResult.ys[i] = bls12381.NewFr().Zero()
+	if evaluator, ok := d.baseDPF.(dpf.BlockEvaluator); ok {
+		if frTarget, ok := target.(FrAggregationTarget); ok {
+			return d.fullEvalBlocksAggregatedInto(ctx, dspfKey, evaluator, frTarget)
+		}
 	}
 
-	errCh := make(chan error, 1)
-	jobsCh := make(chan dpf.Key, len(dspfKey.DPFKeys))
-	resultsCh := make(chan []*big.Int, len(dspfKey.DPFKeys))
+	expectedLen := big.NewInt(0).Exp(big.NewInt(2), big.NewInt(int64(d.baseDPF.GetDomain())), nil)
+	numKeys := len(dspfKey.DPFKeys)
+	numWorkers := runtime.NumCPU()
+	if numWorkers > numKeys {
+		numWorkers = numKeys
+	}
+
+	length := int(expectedLen.Int64())
+	target.Init(length)
+
+	// ctx is canceled on return, s.t. no worker blocks or keeps evaluating once the results are no longer received.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Keys with an index above the lowest failed index so far are skipped, as their errors would not be reported.
+	// Keys below it are still evaluated, which keeps the reported error deterministic.
+	var lowestFailed atomic.Int64
+	lowestFailed.Store(int64(numKeys))
+
+	// The jobs are buffered, s.t. sending them never blocks. The results are sent until ctx is done, hence resultsCh
+	// is only closed by its single owner below after all workers returned.
+	jobsCh := make(chan int, numKeys)
+	for i := range dspfKey.DPFKeys {
+		jobsCh <- i
+	}
+	close(jobsCh)
+	resultsCh := make(chan indexedResult)
 	wg := sync.WaitGroup{}
 
 	// Start workers
 	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
 		go func() {
-			for key := range jobsCh {
-				y, err := d.baseDPF.FullEvalFast(key)
-				if err != nil {
-					errCh <- err
+			defer wg.Done()
+			for i := range jobsCh {
+				result := indexedResult{index: i}
+				if ctx.Err() != nil {
+					return
+				}
+				if int64(i) <= lowestFailed.Load() {
+					result.ys, result.err = d.baseDPF.FullEvalFast(dspfKey.DPFKeys[i])
+					if result.err == nil && len(result.ys) != length {
+						result.err = fmt.Errorf("full evaluation has length %d but is expected to be %d", len(result.ys), length)
+					}
+					if result.err != nil {
+						for {
+							current := lowestFailed.Load()
+							if int64(i) >= current || lowestFailed.CompareAndSwap(current, int64(i)) {
+								break
+							}
+						}
+					}
+				}
+				select {
+				case resultsCh <- result:
+				case <-ctx.Done():
 					return
 				}
-				resultsCh <- y
 			}
 		}()
 	}
+	go func() {
+		wg.Wait()
+		close(resultsCh)
+	}()
 
-	// Send jobs
-	for _, key := range dspfKey.DPFKeys {
-		wg.Add(1)
-		jobsCh <- key
+	// Handle results. The channel is always drained, s.t. all workers return before this function does.
+	received := make([]bool, numKeys)
+	errs := make([]error, numKeys)
+	var poolErr error
+	for res := range resultsCh {
+		if poolErr != nil {
+			continue
+		}
+		if received[res.index] {
+			poolErr = fmt.Errorf("received duplicate result for DPF key %d", res.index)
+			cancel()
+			continue
+		}
+		received[res.index] = true
+		if res.err != nil {
+			errs[res.index] = res.err
+			continue
+		}
+		if res.ys == nil || lowestFailed.Load() < int64(numKeys) {
+			continue // skipped or no longer needed, as the aggregation fails anyway
+		}
+		for i, val := range res.ys {
+			if err := addEvaluation(target, i, val); err != nil {
+				poolErr = fmt.Errorf("DPF key %d: %w", res.index, err)
+				cancel()
+				break
+			}
+		}
 	}
-	close(jobsCh)
 
-	// Handle results
-	var aggError error
-	go func() {
-		for range dspfKey.DPFKeys {
-			select {
-			case y := <-resultsCh:
-				aggResult.mtx.Lock()
-				for i, bigIntVal := range y {
-					val := bls12381.NewFr().FromBytes(bigIntVal.Bytes())
-					aggResult.ys[i].Add(aggResult.ys[i], val)
+	if poolErr != nil {
+		return poolErr
+	}
+	for i := range errs {
+		if errs[i] != nil {
+			d.logger.Debugf("full evaluation of DSPF key with %d DPF keys failed at key %d: %v", numKeys, i, errs[i])
+			return fmt.Errorf("failed to evaluate DPF key %d: %w", i, errs[i])
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for i := range received {
+		if !received[i] {
+			return fmt.Errorf("missing result for DPF key %d", i)
+		}
+	}
+
+	return nil
+}
+
+// evalBlockSize is the amount of consecutive points per block of a block-wise full evaluation.
+const evalBlockSize = 1 << 10
+
+// errAborted aborts the block-wise evaluation of a DPF key, as a key with a lower index already failed.
+var errAborted = errors.New("aborted")
+
+// fullEvalBlocksAggregatedInto implements FullEvalFastAggregatedInto for base DPFs that evaluate block-wise.
+// The DPF keys are evaluated in parallel and each block is added to target while holding a lock.
+func (d *DSPF) fullEvalBlocksAggregatedInto(ctx context.Context, dspfKey Key, evaluator dpf.BlockEvaluator, target FrAggregationTarget) error {
+	length := 1 << d.baseDPF.GetDomain()
+	blockSize := min(evalBlockSize, length)
+	numKeys := len(dspfKey.DPFKeys)
+	numWorkers := min(runtime.NumCPU(), numKeys)
+	target.Init(length)
+
+	// As in FullEvalFastAggregatedInto, keys above the lowest failed index are skipped or aborted, while keys below
+	// it are still evaluated, which keeps the reported error deterministic.
+	var lowestFailed atomic.Int64
+	lowestFailed.Store(int64(numKeys))
+	var next atomic.Int64
+	var mu sync.Mutex // mu serializes the calls to target
+	errs := make([]error, numKeys)
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= numKeys || int64(i) > lowestFailed.Load() || ctx.Err() != nil {
+					return // Keys are handed out in ascending order, so all remaining keys are skipped as well
 				}
-				aggResult.mtx.Unlock()
-				wg.Done()
-			case err := <-errCh:
-				if aggError == nil {
-					aggError = err
+
+				evaluated := 0
+				err := evaluator.FullEvalBlocks(dspfKey.DPFKeys[i], blockSize, func(offset int, block []*bls12381.Fr) error {
+					if int64(i) > lowestFailed.Load() || ctx.Err() != nil {
+						return errAborted
+					}
+					mu.Lock()
+					for j, val := range block {
+						target.AddFr(offset+j, val)
+					}
+					mu.Unlock()
+					evaluated += len(block)
+					return nil
+				})
+				if err == nil && evaluated != length {
+					err = fmt.Errorf("full evaluation has length %d but is expected to be %d", evaluated, length)
+				}
+				if err != nil {
+					errs[i] = err
+					for {
+						current := lowestFailed.Load()
+						if int64(i) >= current || lowestFailed.CompareAndSwap(current, int64(i)) {
+							break
+						}
+					}
 				}
-				close(resultsCh)
-				wg.Done()
-				return
 			}
-		}
-	}()
-
+		}()
+	}
 	wg.Wait()
 
-	if aggError != nil {
-		return nil, aggError
+	if err := ctx.Err(); err != nil {
+		return err // the keys aborted by ctx are no failures of their own
 	}
-
-	return aggResult.ys, nil
+	for i := range errs {
+		if errs[i] != nil {
+			d.logger.Debugf("full evaluation of DSPF key with %d DPF keys failed at key %d: %v", numKeys, i, errs[i])
+			return fmt.Errorf("failed to evaluate DPF key %d: %w", i, errs[i])
+		}
+	}
+	return nil
 }
@@ -3,8 +3,9 @@ package dspf
 import (
 	"bytes"
 	"encoding/gob"
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
 	"io"
-	"pcg-bbs-plus/dpf"
 )
 
 // Key holds the DPF keys the DSPF is constructed on.
@@ -81,3 +82,42 @@ func (k *Key) DeserializeKeys(data []byte) error {
 func (k *Key) AmountOfDPFKeys() int {
 	return len(k.DPFKeys)
 }
+
+// KeyDescription is a summary of a Key for debugging. It holds no secret values, i.e. neither seeds nor correction
+// words, s.t. it can be shared to compare keys across machines.
+type KeyDescription struct {
+	DPFKeys  int           // DPFKeys is the amount of DPF keys, i.e. of special points.
+	KeyTypes []dpf.KeyType // KeyTypes are the distinct types of the DPF keys in order of their first occurrence.
+	Domain   int           // Domain is the bit length of the domain of the DPF keys, -1 if unknown or not uniform.
+	Bytes    int           // Bytes is the size of the serialized key (see SerializeKeys).
+}
+
+// Describe returns a summary of the key (see KeyDescription).
+func (k *Key) Describe() (*KeyDescription, error) {
+	data, err := k.SerializeKeys()
+	if err != nil {
+		return nil, err
+	}
+	description := &KeyDescription{DPFKeys: len(k.DPFKeys), Domain: -1, Bytes: len(data)}
+	seen := make(map[dpf.KeyType]bool)
+	for i, key := range k.DPFKeys {
+		if typeID := key.TypeID(); !seen[typeID] {
+			seen[typeID] = true
+			description.KeyTypes = append(description.KeyTypes, typeID)
+		}
+		domain := -1
+		if domainKey, ok := key.(dpf.DomainKey); ok {
+			domain = domainKey.DomainBitLength()
+		}
+		if i == 0 {
+			description.Domain = domain
+		} else if domain != description.Domain {
+			description.Domain = -1
+		}
+	}
+	return description, nil
+}
+
+func (d *KeyDescription) String() string {
+	return fmt.Sprintf("%d DPF keys of types %v, domain 2^%d, %d bytes", d.DPFKeys, d.KeyTypes, d.Domain, d.Bytes)
+}
@@ -1,12 +1,17 @@
 package dspf
 
 import (
+	"context"
 	"crypto/rand"
+	"errors"
 	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
 	"github.com/stretchr/testify/assert"
 	"math/big"
-	"pcg-bbs-plus/dpf/optreedpf"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestDSPFGenMismatchedLengths(t *testing.T) {
@@ -20,18 +25,57 @@ func TestDSPFGenMismatchedLengths(t *testing.T) {
 	}
 }
 
-// We allow duplicate special points for now
-// func TestDSPFGenDuplicateSpecialPoints(t *testing.T) {
-//	var dspfInstance DSPF
-//	specialPoint := big.NewInt(1)
-//	specialPoints := []*big.Int{specialPoint, specialPoint}
-//	nonZeroElements := []*big.Int{big.NewInt(2), big.NewInt(3)}
-//
-//	_, _, err := dspfInstance.Gen(specialPoints, nonZeroElements)
-//	if err == nil || err.Error() != fmt.Sprintf("duplicate special point: %s", specialPoint.Text(10)) {
-//		t.Errorf("Gen did not return the correct error for duplicate special points")
-//	}
-// }
+func TestDSPFGenDuplicateSpecialPoints(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+	specialPoints := []*big.Int{big.NewInt(1), big.NewInt(7), big.NewInt(1)}
+	nonZeroElements := []*big.Int{big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+
+	// Duplicates are rejected by default
+	_, _, err = dspf.Gen(specialPoints, nonZeroElements)
+	assert.ErrorIs(t, err, ErrDuplicateSpecialPoints)
+
+	// If duplicates are allowed, their non-zero elements add up
+	dspf.SetAllowDuplicates(true)
+	k1, k2, err := dspf.Gen(specialPoints, nonZeroElements)
+	assert.Nil(t, err)
+	ys1, err := dspf.FullEvalFastAggregated(k1)
+	assert.Nil(t, err)
+	ys2, err := dspf.FullEvalFastAggregated(k2)
+	assert.Nil(t, err)
+	sum := bls12381.NewFr()
+	sum.Add(ys1[1], ys2[1])
+	assert.Equal(t, int64(6), sum.ToBig().Int64())
+	sum.Add(ys1[7], ys2[7])
+	assert.Equal(t, int64(3), sum.ToBig().Int64())
+
+	dspf.SetAllowDuplicates(false)
+	_, _, err = dspf.Gen(specialPoints, nonZeroElements)
+	assert.ErrorIs(t, err, ErrDuplicateSpecialPoints)
+}
+
+func TestDSPFGenTooManySpecialPoints(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 2)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+	specialPoints := make([]*big.Int, 5)
+	nonZeroElements := make([]*big.Int, 5)
+	for i := range specialPoints {
+		specialPoints[i], nonZeroElements[i] = big.NewInt(int64(i%4)), big.NewInt(1)
+	}
+
+	// 5 points cannot be distinct in a domain of 4 points
+	_, _, err = dspf.Gen(specialPoints, nonZeroElements)
+	assert.ErrorIs(t, err, ErrTooManySpecialPoints)
+	_, _, err = dspf.Gen(specialPoints[:4], nonZeroElements[:4])
+	assert.Nil(t, err)
+
+	// Duplicates may exceed the domain
+	dspf.SetAllowDuplicates(true)
+	_, _, err = dspf.Gen(specialPoints, nonZeroElements)
+	assert.Nil(t, err)
+}
 
 func TestDSPFGenEvalOpTreeDPF(t *testing.T) {
 	treedpf12864, err := optreedpf.InitFactory(128, 64)
@@ -312,6 +356,312 @@ func TestDSPFFullEvalFastOpTreeDPFSum(t *testing.T) {
 	}
 }
 
+func TestDSPFFullEvalFastAggregatedOpTreeDPF(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+
+	specialPoints := []*big.Int{big.NewInt(3), big.NewInt(17), big.NewInt(255)}
+	nonZeroElements := []*big.Int{big.NewInt(5), big.NewInt(7), big.NewInt(11)}
+	k1, k2, err := dspf.Gen(specialPoints, nonZeroElements)
+	assert.Nil(t, err)
+
+	ys1, err := dspf.FullEvalFastAggregated(k1)
+	assert.Nil(t, err)
+	ys2, err := dspf.FullEvalFastAggregated(k2)
+	assert.Nil(t, err)
+
+	for x := range ys1 {
+		res := bls12381.NewFr()
+		res.Add(ys1[x], ys2[x])
+		expected := big.NewInt(0)
+		for i, sp := range specialPoints {
+			if sp.Int64() == int64(x) {
+				expected = nonZeroElements[i]
+			}
+		}
+		assert.Equal(t, 0, res.ToBig().Cmp(expected))
+	}
+}
+
+func TestDSPFFullEvalFastAggregatedFailingKeys(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+
+	specialPoints := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)}
+	nonZeroElements := []*big.Int{big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(1)}
+	k1, _, err := dspf.Gen(specialPoints, nonZeroElements)
+	assert.Nil(t, err)
+
+	// Inject invalid keys at index 1 and 3. The error of the lowest index has to be reported, independent of scheduling.
+	k1.DPFKeys[1] = &optreedpf.Key{ID: 2}
+	k1.DPFKeys[3] = &optreedpf.Key{ID: 3}
+	for i := 0; i < 20; i++ {
+		ys, err := dspf.FullEvalFastAggregated(k1)
+		assert.Nil(t, ys)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "DPF key 1:")
+	}
+
+	// A missing key fails as well
+	k1.DPFKeys[0] = nil
+	ys, err := dspf.FullEvalFastAggregated(k1)
+	assert.Nil(t, ys)
+	assert.Contains(t, err.Error(), "DPF key 0:")
+}
+
+func TestDSPFFullEvalFastAggregatedMalformedResult(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+
+	k1, _, err := dspf.Gen([]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(1), big.NewInt(1)})
+	assert.Nil(t, err)
+
+	// The base DPF returns results that are too short, which must not be aggregated silently.
+	truncated := NewDSPFFactory(truncatingDPF{d})
+	ys, err := truncated.FullEvalFastAggregated(k1)
+	assert.Nil(t, ys)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "length")
+
+	// The same holds for targets that are not fed block-wise
+	target, err := NewModAggregator(d.BetaMax)
+	assert.Nil(t, err)
+	err = truncated.FullEvalFastAggregatedInto(k1, target)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "length")
+}
+
+func TestDSPFFullEvalFastAggregatedBlockwiseMatchesFullEval(t *testing.T) {
+	for _, domain := range []int{4, 10, 12} { // below, at and above the block size
+		d, err := optreedpf.InitFactory(128, domain)
+		assert.Nil(t, err)
+		dspf := NewDSPFFactory(d)
+
+		specialPoints := []*big.Int{big.NewInt(0), big.NewInt(5), big.NewInt(1<<domain - 1)}
+		nonZeroElements := []*big.Int{big.NewInt(3), big.NewInt(4), big.NewInt(5)}
+		k1, _, err := dspf.Gen(specialPoints, nonZeroElements)
+		assert.Nil(t, err)
+
+		// Block-wise aggregation into an FrAggregator
+		blockwise, err := dspf.FullEvalFastAggregated(k1)
+		assert.Nil(t, err)
+
+		// Aggregation of the full evaluations
+		ys, err := dspf.FullEval(k1)
+		assert.Nil(t, err)
+		target := NewFrAggregator()
+		assert.Nil(t, Aggregate(ys, target))
+
+		assert.Len(t, blockwise, 1<<domain)
+		for i := range blockwise {
+			assert.True(t, blockwise[i].Equal(target.Values()[i]), "domain=%d, x=%d", domain, i)
+		}
+	}
+}
+
+// truncatingDPF is a faulty DPF whose full evaluations are one element too short.
+type truncatingDPF struct {
+	*optreedpf.OpTreeDPF
+}
+
+func (d truncatingDPF) FullEvalFast(key dpf.Key) ([]*big.Int, error) {
+	ys, err := d.OpTreeDPF.FullEvalFast(key)
+	if err != nil {
+		return nil, err
+	}
+	return ys[1:], nil
+}
+
+func (d truncatingDPF) FullEvalBlocks(key dpf.Key, blockSize int, fn func(offset int, block []*bls12381.Fr) error) error {
+	return d.OpTreeDPF.FullEvalBlocks(key, blockSize, func(offset int, block []*bls12381.Fr) error {
+		if offset+len(block) == 1<<d.DomainBitLength {
+			block = block[:len(block)-1]
+		}
+		return fn(offset, block)
+	})
+}
+
+// foreignDPF is an OpTreeDPF that claims another output group and key type, e.g. a DPF of another backend.
+type foreignDPF struct {
+	*optreedpf.OpTreeDPF
+	group   dpf.OutputGroup
+	keyType dpf.KeyType
+}
+
+func (d foreignDPF) OutputGroup() dpf.OutputGroup {
+	return d.group
+}
+
+func (d foreignDPF) KeyType() dpf.KeyType {
+	return d.keyType
+}
+
+func TestDSPFChecksOutputGroupAndKeyType(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+	assert.True(t, dspf.OutputGroup().IsFr())
+	k1, _, err := dspf.Gen([]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(1), big.NewInt(1)})
+	assert.Nil(t, err)
+
+	// Outputs of another group must not be aggregated as elements of Fr, but into targets of their group
+	group := dpf.OutputGroup{Modulus: new(big.Int).Lsh(big.NewInt(1), 128)}
+	other := NewDSPFFactory(foreignDPF{OpTreeDPF: d, group: group, keyType: dpf.OpTreeDPFKeyID})
+	ys, err := other.FullEvalFastAggregated(k1)
+	assert.Nil(t, ys)
+	assert.ErrorIs(t, err, ErrOutputGroupNotFr)
+	target, err := NewModAggregator(group.Modulus)
+	assert.Nil(t, err)
+	assert.Nil(t, other.FullEvalFastAggregatedInto(k1, target))
+	_, err = other.FullEvalPrefixAggregated(k1, nil, 0)
+	assert.ErrorIs(t, err, ErrOutputGroupNotFr)
+	_, err = NewLocalWorker(other)
+	assert.ErrorIs(t, err, ErrOutputGroupNotFr)
+
+	// Keys of another type are rejected by all evaluations
+	other = NewDSPFFactory(foreignDPF{OpTreeDPF: d, group: dpf.FrOutputGroup(), keyType: "OtherKey"})
+	_, err = other.Eval(k1, big.NewInt(1))
+	assert.ErrorContains(t, err, "OtherKey")
+	_, err = other.FullEval(k1)
+	assert.ErrorContains(t, err, "OtherKey")
+	_, err = other.FullEvalFast(k1)
+	assert.ErrorContains(t, err, "OtherKey")
+	_, err = other.FullEvalFastAggregated(k1)
+	assert.ErrorContains(t, err, "OtherKey")
+
+	// Workers only hold DSPFs of compatible backends
+	d2, err := optreedpf.InitFactory(128, 9)
+	assert.Nil(t, err)
+	_, err = NewLocalWorker(NewDSPFFactory(d2), other)
+	assert.ErrorIs(t, err, dpf.ErrIncompatibleDPFs)
+	worker, err := NewLocalWorker(other)
+	assert.Nil(t, err)
+	_, err = NewDistributedDSPF(dspf, []Worker{worker}, 1)
+	assert.ErrorIs(t, err, dpf.ErrIncompatibleDPFs)
+}
+
+func TestDSPFFullEvalFastAggregatedFailureMidEvaluation(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 12) // above the block size, s.t. block-wise evaluations fail mid-key
+	assert.Nil(t, err)
+	specialPoints := make([]*big.Int, 32)
+	nonZeroElements := make([]*big.Int, len(specialPoints))
+	for i := range specialPoints {
+		specialPoints[i], nonZeroElements[i] = big.NewInt(int64(i)), big.NewInt(1)
+	}
+	k1, _, err := NewDSPFFactory(d).Gen(specialPoints, nonZeroElements)
+	assert.Nil(t, err)
+
+	before := runtime.NumGoroutine()
+	for _, blockwise := range []bool{false, true} {
+		failing := NewDSPFFactory(newFailingDPF(d, blockwise, time.Millisecond, k1.DPFKeys[5], k1.DPFKeys[20]))
+		for i := 0; i < 3; i++ {
+			ys, err := failing.FullEvalFastAggregated(k1)
+			assert.Nil(t, ys)
+			assert.ErrorIs(t, err, errInjected, "blockwise=%v", blockwise)
+			assert.Contains(t, err.Error(), "DPF key 5:", "blockwise=%v", blockwise)
+		}
+	}
+	assertNoGoroutineLeak(t, before)
+}
+
+func TestDSPFFullEvalFastAggregatedContext(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 12) // above the block size, s.t. block-wise evaluations fail mid-key
+	assert.Nil(t, err)
+	specialPoints := make([]*big.Int, 32)
+	nonZeroElements := make([]*big.Int, len(specialPoints))
+	for i := range specialPoints {
+		specialPoints[i], nonZeroElements[i] = big.NewInt(int64(i)), big.NewInt(1)
+	}
+	k1, _, err := NewDSPFFactory(d).Gen(specialPoints, nonZeroElements)
+	assert.Nil(t, err)
+
+	before := runtime.NumGoroutine()
+	for _, blockwise := range []bool{false, true} {
+		slow := NewDSPFFactory(newFailingDPF(d, blockwise, 20*time.Millisecond))
+
+		// A context that is done before the evaluation starts no key
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := slow.FullEvalFastAggregatedContext(ctx, k1, NewFrAggregator())
+		assert.ErrorIs(t, err, context.Canceled, "blockwise=%v", blockwise)
+
+		// A context that is done during the evaluation stops it
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+		err = slow.FullEvalFastAggregatedContext(ctx, k1, NewFrAggregator())
+		cancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded, "blockwise=%v", blockwise)
+
+		// Without cancellation, the result matches the plain evaluation
+		expected, err := NewDSPFFactory(d).FullEvalFastAggregated(k1)
+		assert.Nil(t, err)
+		target := NewFrAggregator()
+		fast := NewDSPFFactory(newFailingDPF(d, blockwise, 0))
+		assert.Nil(t, fast.FullEvalFastAggregatedContext(context.Background(), k1, target))
+		for i := range expected {
+			assert.True(t, expected[i].Equal(target.Values()[i]))
+		}
+	}
+	assertNoGoroutineLeak(t, before)
+}
+
+// assertNoGoroutineLeak waits until the amount of goroutines drops to before again and fails if it does not.
+func assertNoGoroutineLeak(t *testing.T, before int) {
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "goroutines leaked")
+}
+
+// errInjected is the error of the keys failing in a failingDPF.
+var errInjected = errors.New("injected failure")
+
+// failingDPF is a slow DPF, whose evaluations of the given keys fail after some delay, i.e. while other keys are being
+// evaluated. It only implements dpf.BlockEvaluator if blockwise is set.
+type failingDPF struct {
+	dpf.DPF
+	delay   time.Duration // delay is the delay of each evaluation, or of each block if blockwise is set
+	failing map[dpf.Key]bool
+}
+
+// failingBlockDPF is a failingDPF that evaluates block-wise.
+type failingBlockDPF struct {
+	failingDPF
+	evaluator dpf.BlockEvaluator
+}
+
+func newFailingDPF(d *optreedpf.OpTreeDPF, blockwise bool, delay time.Duration, failing ...dpf.Key) dpf.DPF {
+	f := failingDPF{DPF: d, delay: delay, failing: make(map[dpf.Key]bool)}
+	for _, key := range failing {
+		f.failing[key] = true
+	}
+	if blockwise {
+		return failingBlockDPF{failingDPF: f, evaluator: d}
+	}
+	return f
+}
+
+func (d failingDPF) FullEvalFast(key dpf.Key) ([]*big.Int, error) {
+	time.Sleep(d.delay)
+	if d.failing[key] {
+		return nil, errInjected
+	}
+	return d.DPF.FullEvalFast(key)
+}
+
+func (d failingBlockDPF) FullEvalBlocks(key dpf.Key, blockSize int, fn func(offset int, block []*bls12381.Fr) error) error {
+	return d.evaluator.FullEvalBlocks(key, blockSize, func(offset int, block []*bls12381.Fr) error {
+		time.Sleep(d.delay)
+		if d.failing[key] && offset > 0 {
+			return errInjected
+		}
+		return fn(offset, block)
+	})
+}
+
 // Benchmarks:
 
 // The parameters chosen below are similar to the ones used in the PCG.
@@ -543,3 +893,32 @@ func benchmarkOpTreeDSPFFullEvalSequential(b *testing.B, lambda, domain, t int)
 		}
 	}
 }
+
+func TestKeyDescribe(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	dspf := NewDSPFFactory(d)
+	k1, _, err := dspf.Gen([]*big.Int{big.NewInt(1), big.NewInt(7)}, []*big.Int{big.NewInt(2), big.NewInt(3)})
+	assert.Nil(t, err)
+
+	description, err := k1.Describe()
+	assert.Nil(t, err)
+	data, err := k1.SerializeKeys()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, description.DPFKeys)
+	assert.Equal(t, []dpf.KeyType{dpf.OpTreeDPFKeyID}, description.KeyTypes)
+	assert.Equal(t, 8, description.Domain)
+	assert.Equal(t, len(data), description.Bytes)
+	assert.Contains(t, description.String(), "domain 2^8")
+
+	// Keys of mixed domains have no uniform domain
+	d16, err := optreedpf.InitFactory(128, 16)
+	assert.Nil(t, err)
+	k16, _, err := NewDSPFFactory(d16).Gen([]*big.Int{big.NewInt(1)}, []*big.Int{big.NewInt(2)})
+	assert.Nil(t, err)
+	mixed := Key{DPFKeys: append(k1.DPFKeys, k16.DPFKeys...)}
+	description, err = mixed.Describe()
+	assert.Nil(t, err)
+	assert.Equal(t, -1, description.Domain)
+	assert.Equal(t, 3, description.DPFKeys)
+}
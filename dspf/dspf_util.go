@@ -2,8 +2,10 @@ package dspf
 
 import (
 	"errors"
-	"pcg-bbs-plus/dpf"
-	"pcg-bbs-plus/dpf/optreedpf"
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"math/big"
 )
 
 // CreateKeyFromTypeID is a helper function that instantiates a DPF key based on the typeID.
@@ -16,3 +18,48 @@ func CreateKeyFromTypeID(typeID dpf.KeyType) (dpf.Key, error) {
 		return nil, errors.New("unknown key type")
 	}
 }
+
+// hasDuplicatePoints returns whether a point is given more than once.
+func hasDuplicatePoints(points []*big.Int) bool {
+	seen := make(map[string]struct{}, len(points))
+	for _, point := range points {
+		key := point.Text(16)
+		if _, ok := seen[key]; ok {
+			return true
+		}
+		seen[key] = struct{}{}
+	}
+	return false
+}
+
+// ErrOutputGroupNotFr is returned if the outputs of the base DPF are aggregated as elements of Fr, although the output
+// group of the base DPF is not Fr (see dpf.OutputGroup).
+var ErrOutputGroupNotFr = errors.New("the output group of the base DPF is not Fr")
+
+// checkKeyTypes checks that all DPF keys are of the key type of the base DPF, s.t. keys of another backend are
+// rejected instead of being evaluated by a DPF that interprets them differently. Missing keys are left to the base DPF.
+func checkKeyTypes(baseDPF dpf.DPF, keys []dpf.Key) error {
+	for i, key := range keys {
+		if key != nil && key.TypeID() != baseDPF.KeyType() {
+			return fmt.Errorf("DPF key %d is of type %s but the base DPF expects %s", i, key.TypeID(), baseDPF.KeyType())
+		}
+	}
+	return nil
+}
+
+// checkFrOutputs returns ErrOutputGroupNotFr if the outputs of the base DPF are no elements of Fr.
+func checkFrOutputs(baseDPF dpf.DPF) error {
+	if group := baseDPF.OutputGroup(); !group.IsFr() {
+		return fmt.Errorf("%w: %s", ErrOutputGroupNotFr, group)
+	}
+	return nil
+}
+
+// checkTarget checks that target only receives elements of Fr as such, i.e. that the output group of the base DPF is
+// Fr if target is a FrAggregationTarget.
+func checkTarget(baseDPF dpf.DPF, target AggregationTarget) error {
+	if _, ok := target.(FrAggregationTarget); ok {
+		return checkFrOutputs(baseDPF)
+	}
+	return nil
+}
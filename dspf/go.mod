@@ -0,0 +1,23 @@
+module github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf
+
+go 1.21.3
+
+require (
+	github.com/kilic/bls12-381 v0.1.0
+	github.com/stretchr/testify v1.8.4
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf v0.1.0
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging v0.1.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.9.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The DPF and logging modules are resolved from this repository, also outside the workspace.
+replace (
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf => ../dpf
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging => ../logging
+)
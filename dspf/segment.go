@@ -0,0 +1,145 @@
+package dspf
+
+import (
+	"errors"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging"
+	"math/big"
+)
+
+// Scheme is implemented by the DSPF constructions that operate on a Key, s.t. they are interchangeable for consumers
+// like the PCG.
+type Scheme interface {
+	Gen(specialPoints []*big.Int, nonZeroElements []*big.Int) (Key, Key, error)
+	FullEvalFastAggregated(dspfKey Key) ([]*bls12381.Fr, error)
+	SetLogger(logger logging.Logger)
+	// OutputGroup returns the output group of the base DPF, in which the results of both keys combine. The aggregated
+	// evaluations are elements of Fr, hence consumers check that it is Fr (see dpf.OutputGroup.IsFr).
+	OutputGroup() dpf.OutputGroup
+}
+
+// SegmentDSPF is a DSPF whose i-th DPF only covers the segment [offsets[i], offsets[i] + 2^segmentDomain) of the
+// domain. It suits structured (e.g. regular) special points, where the segment of each point is public, and reduces
+// the work of a full evaluation from t*2^domain to t*2^segmentDomain points.
+type SegmentDSPF struct {
+	baseDPF       dpf.DPF        // baseDPF evaluates the DPFs of the segments and is set to the domain of a segment.
+	domain        int            // domain is the bit length of the domain of the DSPF.
+	segmentDomain int            // segmentDomain is the bit length of the domain of each segment.
+	offsets       []int          // offsets holds the start of the segment of each DPF.
+	logger        logging.Logger // logger receives the log messages of the DSPF. It defaults to a no-op logger.
+}
+
+// NewSegmentDSPF creates a new SegmentDSPF, whose i-th special point must be within the segment starting at
+// offsets[i]. Segments may overlap, but must be within the domain.
+// The domain of baseDPF is changed to the domain of the segments, hence it must not be shared with other DSPFs.
+func NewSegmentDSPF(baseDPF dpf.DPF, domain, segmentDomain int, offsets []int) (*SegmentDSPF, error) {
+	if baseDPF == nil {
+		return nil, errors.New("base DPF must not be nil")
+	}
+	if segmentDomain < 1 || segmentDomain > domain {
+		return nil, errors.New("segment domain must be within [1, domain]")
+	}
+	for _, offset := range offsets {
+		if offset < 0 || offset+1<<segmentDomain > 1<<domain {
+			return nil, errors.New("all segments must be within the domain")
+		}
+	}
+	if err := baseDPF.ChangeDomain(segmentDomain); err != nil {
+		return nil, err
+	}
+
+	return &SegmentDSPF{
+		baseDPF:       baseDPF,
+		domain:        domain,
+		segmentDomain: segmentDomain,
+		offsets:       append([]int(nil), offsets...),
+		logger:        logging.NopLogger{},
+	}, nil
+}
+
+// SetLogger sets the logger of the DSPF. A nil logger discards all messages.
+func (d *SegmentDSPF) SetLogger(logger logging.Logger) {
+	d.logger = logging.OrNop(logger)
+}
+
+// OutputGroup implements Scheme.
+func (d *SegmentDSPF) OutputGroup() dpf.OutputGroup {
+	return d.baseDPF.OutputGroup()
+}
+
+// Gen generates keys for a DSPF with one special point per segment.
+func (d *SegmentDSPF) Gen(specialPoints []*big.Int, nonZeroElements []*big.Int) (Key, Key, error) {
+	if len(specialPoints) != len(nonZeroElements) {
+		return Key{}, Key{}, errors.New("the number of special points and non-zero elements must match")
+	}
+	if len(specialPoints) != len(d.offsets) {
+		return Key{}, Key{}, fmt.Errorf("the number of special points must be the number of segments %d", len(d.offsets))
+	}
+
+	var keyAlice Key
+	var keyBob Key
+	for i, sp := range specialPoints {
+		position := new(big.Int).Sub(sp, big.NewInt(int64(d.offsets[i])))
+		if position.Sign() < 0 || position.BitLen() > d.segmentDomain {
+			return Key{}, Key{}, fmt.Errorf("special point %d is not within its segment", i)
+		}
+		key1, key2, err := d.baseDPF.Gen(position, nonZeroElements[i])
+		if err != nil {
+			return Key{}, Key{}, err
+		}
+		keyAlice.DPFKeys = append(keyAlice.DPFKeys, key1)
+		keyBob.DPFKeys = append(keyBob.DPFKeys, key2)
+	}
+	return keyAlice, keyBob, nil
+}
+
+// Eval evaluates each DPF of the DSPF on a given point x. DPFs whose segment does not contain x evaluate to zero.
+func (d *SegmentDSPF) Eval(dspfKey Key, x *big.Int) ([]*big.Int, error) {
+	if err := d.checkKey(dspfKey); err != nil {
+		return nil, err
+	}
+	ys := make([]*big.Int, len(dspfKey.DPFKeys))
+	for i, key := range dspfKey.DPFKeys {
+		position := new(big.Int).Sub(x, big.NewInt(int64(d.offsets[i])))
+		if position.Sign() < 0 || position.BitLen() > d.segmentDomain {
+			ys[i] = big.NewInt(0)
+			continue
+		}
+		y, err := d.baseDPF.Eval(key, position)
+		if err != nil {
+			return nil, err
+		}
+		ys[i] = y
+	}
+	return ys, nil
+}
+
+// FullEvalFastAggregated evaluates each DPF of the DSPF on all points of its segment and aggregates the results in a
+// single result over the whole domain, just like DSPF.FullEvalFastAggregated.
+func (d *SegmentDSPF) FullEvalFastAggregated(dspfKey Key) ([]*bls12381.Fr, error) {
+	target := NewFrAggregator()
+	if err := d.FullEvalFastAggregatedInto(dspfKey, target); err != nil {
+		return nil, err
+	}
+	return target.Values(), nil
+}
+
+// FullEvalFastAggregatedInto works like FullEvalFastAggregated, but aggregates the results into the given target.
+func (d *SegmentDSPF) FullEvalFastAggregatedInto(dspfKey Key, target AggregationTarget) error {
+	if err := d.checkKey(dspfKey); err != nil {
+		return err
+	}
+	return aggregateSubdomains(d.baseDPF, dspfKey.DPFKeys, 1<<d.domain, target, func(i, j int) int {
+		return d.offsets[i] + j
+	})
+}
+
+// checkKey checks that the key holds one DPF key per segment.
+func (d *SegmentDSPF) checkKey(dspfKey Key) error {
+	if len(dspfKey.DPFKeys) != len(d.offsets) {
+		return fmt.Errorf("key holds %d DPF keys but %d segments are expected", len(dspfKey.DPFKeys), len(d.offsets))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package dspf
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestSegmentDSPFFullEvalFastAggregated(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	// Overlapping segments of length 64 as used for sums of regular exponents
+	offsets := []int{0, 32, 64, 192}
+	dspf, err := NewSegmentDSPF(d, 8, 6, offsets)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, d.GetDomain())
+
+	specialPoints := []*big.Int{big.NewInt(3), big.NewInt(95), big.NewInt(64), big.NewInt(255)}
+	nonZeroElements := []*big.Int{big.NewInt(5), big.NewInt(7), big.NewInt(11), big.NewInt(13)}
+	k1, k2, err := dspf.Gen(specialPoints, nonZeroElements)
+	assert.Nil(t, err)
+
+	ys1, err := dspf.FullEvalFastAggregated(k1)
+	assert.Nil(t, err)
+	ys2, err := dspf.FullEvalFastAggregated(k2)
+	assert.Nil(t, err)
+	assert.Equal(t, 256, len(ys1))
+
+	// The big.Int path yields the same aggregate as the block-wise path
+	modulus, _ := new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+	target, err := NewModAggregator(modulus)
+	assert.Nil(t, err)
+	assert.Nil(t, dspf.FullEvalFastAggregatedInto(k1, target))
+
+	for x := range ys1 {
+		res := bls12381.NewFr()
+		res.Add(ys1[x], ys2[x])
+		expected := big.NewInt(0)
+		for i, sp := range specialPoints {
+			if sp.Int64() == int64(x) {
+				expected = nonZeroElements[i]
+			}
+		}
+		assert.Equal(t, 0, res.ToBig().Cmp(expected))
+		assert.Equal(t, 0, target.Values()[x].Cmp(ys1[x].ToBig()))
+	}
+
+	y1, err := dspf.Eval(k1, big.NewInt(95))
+	assert.Nil(t, err)
+	y2, err := dspf.Eval(k2, big.NewInt(95))
+	assert.Nil(t, err)
+	combined, err := NewDSPFFactory(d).CombineSingleResult(y1, y2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, combined.Cmp(big.NewInt(7)))
+}
+
+func TestSegmentDSPFInvalidInputs(t *testing.T) {
+	d, err := optreedpf.InitFactory(128, 8)
+	assert.Nil(t, err)
+	_, err = NewSegmentDSPF(d, 8, 9, []int{0})
+	assert.NotNil(t, err)
+	_, err = NewSegmentDSPF(d, 8, 6, []int{193})
+	assert.NotNil(t, err)
+
+	dspf, err := NewSegmentDSPF(d, 8, 6, []int{0, 64})
+	assert.Nil(t, err)
+	_, _, err = dspf.Gen([]*big.Int{big.NewInt(1)}, []*big.Int{big.NewInt(1)})
+	assert.NotNil(t, err)
+	_, _, err = dspf.Gen([]*big.Int{big.NewInt(1), big.NewInt(63)}, []*big.Int{big.NewInt(1), big.NewInt(1)})
+	assert.NotNil(t, err)
+
+	k1, _, err := dspf.Gen([]*big.Int{big.NewInt(1), big.NewInt(64)}, []*big.Int{big.NewInt(1), big.NewInt(1)})
+	assert.Nil(t, err)
+	k1.DPFKeys = k1.DPFKeys[1:]
+	_, err = dspf.FullEvalFastAggregated(k1)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,98 @@
+package dspf
+
+import (
+	"errors"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"net"
+	"net/rpc"
+)
+
+// workerServiceName is the name of the RPC service of a served Worker.
+const workerServiceName = "DSPFWorker"
+
+// ChunkArgs is the wire format of a call of Worker.EvalChunk (exported, as net/rpc requires exported types).
+// The DSPF key is serialized via Key.SerializeKeys.
+type ChunkArgs struct {
+	Domain int
+	Key    []byte
+	Prefix []uint
+	Depth  int
+}
+
+// ChunkReply is the wire format of the result of Worker.EvalChunk. Each value is encoded as 32 bytes (see Fr.ToBytes).
+type ChunkReply struct {
+	Values [][]byte
+}
+
+// workerService exposes a Worker via net/rpc.
+type workerService struct {
+	worker Worker
+}
+
+// EvalChunk is the RPC method of workerService.
+func (s *workerService) EvalChunk(args ChunkArgs, reply *ChunkReply) error {
+	var key Key
+	if err := key.DeserializeKeys(args.Key); err != nil {
+		return fmt.Errorf("failed to deserialize DSPF key: %w", err)
+	}
+	values, err := s.worker.EvalChunk(args.Domain, key, args.Prefix, args.Depth)
+	if err != nil {
+		return err
+	}
+	reply.Values = make([][]byte, len(values))
+	for i, val := range values {
+		reply.Values[i] = val.ToBytes()
+	}
+	return nil
+}
+
+// ServeWorker serves the worker on the listener via net/rpc, s.t. coordinators on other machines can reach it via
+// DialWorker. It blocks until the listener is closed.
+func ServeWorker(listener net.Listener, worker Worker) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(workerServiceName, &workerService{worker: worker}); err != nil {
+		return err
+	}
+	server.Accept(listener)
+	return nil
+}
+
+// RemoteWorker is a Worker that evaluates chunks on a worker served by ServeWorker. It is safe for concurrent use.
+type RemoteWorker struct {
+	client *rpc.Client
+}
+
+// DialWorker connects to a worker served by ServeWorker at the given address, e.g. "tcp" and "host:port".
+func DialWorker(network, address string) (*RemoteWorker, error) {
+	client, err := rpc.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteWorker{client: client}, nil
+}
+
+// EvalChunk implements Worker.
+func (w *RemoteWorker) EvalChunk(domain int, dspfKey Key, prefix []uint, depth int) ([]*bls12381.Fr, error) {
+	data, err := dspfKey.SerializeKeys()
+	if err != nil {
+		return nil, err
+	}
+	var reply ChunkReply
+	if err := w.client.Call(workerServiceName+".EvalChunk", ChunkArgs{Domain: domain, Key: data, Prefix: prefix, Depth: depth}, &reply); err != nil {
+		return nil, err
+	}
+	values := make([]*bls12381.Fr, len(reply.Values))
+	for i, encoded := range reply.Values {
+		if values[i], err = dpf.FrFromBytesCanonical(encoded); err != nil {
+			return nil, errors.New("the worker returned a malformed value")
+		}
+	}
+	return values, nil
+}
+
+// Close closes the connection to the worker.
+func (w *RemoteWorker) Close() error {
+	return w.client.Close()
+}
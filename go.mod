@@ -1,4 +1,4 @@
-module pcg-bbs-plus
+module github.com/leandro-ro/Threshold-BBS-Plus-PCG
 
 go 1.21.3
 
@@ -6,6 +6,10 @@ require (
 	github.com/consensys/gnark-crypto v0.12.1
 	github.com/kilic/bls12-381 v0.1.0
 	github.com/stretchr/testify v1.8.4
+	gopkg.in/yaml.v3 v3.0.1
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf v0.1.0
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf v0.1.0
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging v0.1.0
 )
 
 require (
@@ -17,6 +21,13 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rogpeppe/go-internal v1.11.0 // indirect
 	golang.org/x/sys v0.9.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	rsc.io/tmplfunc v0.0.3 // indirect
 )
+
+
+// The nested modules are developed along with the PCG and resolved from this repository, also outside the workspace.
+replace (
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf => ./dpf
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf => ./dspf
+	github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging => ./logging
+)
@@ -0,0 +1,53 @@
+package logging
+
+import "log"
+
+// Logger is the interface of the loggers used by the PCG, DSPF and the tuple generators.
+// It allows consumers of the library to control verbosity and destinations of the log messages.
+type Logger interface {
+	// Debugf logs detailed messages, e.g. timings of the individual evaluation steps.
+	Debugf(format string, args ...interface{})
+	// Infof logs messages of general interest, e.g. the total time of an evaluation.
+	Infof(format string, args ...interface{})
+}
+
+// NopLogger is a Logger that discards all messages. It is the default of all components.
+type NopLogger struct{}
+
+// Debugf discards the message.
+func (NopLogger) Debugf(string, ...interface{}) {}
+
+// Infof discards the message.
+func (NopLogger) Infof(string, ...interface{}) {}
+
+// StdLogger is a Logger that writes to a standard library logger.
+type StdLogger struct {
+	logger *log.Logger
+	debug  bool
+}
+
+// NewStdLogger returns a Logger writing to the given standard library logger.
+// Debug messages are only written if debug is set.
+func NewStdLogger(logger *log.Logger, debug bool) *StdLogger {
+	return &StdLogger{logger: logger, debug: debug}
+}
+
+// Debugf writes the message if debug messages are enabled.
+func (l *StdLogger) Debugf(format string, args ...interface{}) {
+	if l.debug {
+		l.logger.Printf("DEBUG "+format, args...)
+	}
+}
+
+// Infof writes the message.
+func (l *StdLogger) Infof(format string, args ...interface{}) {
+	l.logger.Printf("INFO "+format, args...)
+}
+
+// OrNop returns logger, or a NopLogger if logger is nil.
+func OrNop(logger Logger) Logger {
+	if logger == nil {
+		return NopLogger{}
+	}
+	return logger
+}
@@ -0,0 +1,27 @@
+package logging
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0), false)
+	logger.Debugf("hidden %d", 1)
+	logger.Infof("shown %d", 2)
+	assert.Equal(t, "INFO shown 2\n", buf.String())
+
+	buf.Reset()
+	logger = NewStdLogger(log.New(&buf, "", 0), true)
+	logger.Debugf("shown %d", 1)
+	assert.Equal(t, "DEBUG shown 1\n", buf.String())
+}
+
+func TestOrNop(t *testing.T) {
+	assert.Equal(t, NopLogger{}, OrNop(nil))
+	logger := NewStdLogger(log.Default(), false)
+	assert.Equal(t, logger, OrNop(logger))
+}
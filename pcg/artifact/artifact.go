@@ -0,0 +1,204 @@
+// Package artifact implements the header that prefixes the serialized artifacts of the PCG (seeds, tuples, rings and
+// public parameters). The header records the kind and format version of the artifact, the revisions of the conventions
+// it was produced under and the digest of the PCG parameters. Decode checks the header against a compatibility matrix,
+// s.t. artifacts of other code revisions fail loudly instead of silently producing invalid correlations.
+package artifact
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Kind identifies the kind of a serialized artifact.
+type Kind uint8
+
+const (
+	KindSeed             Kind = iota + 1 // KindSeed is a seed of a party (see pcg.Seed).
+	KindTuple                            // KindTuple is a BBS+ tuple (see tuplegen.BBSPlusTuple).
+	KindRing                             // KindRing is a ring (see pcg.Ring).
+	KindPublicParameters                 // KindPublicParameters are the public parameters of the dealer (see pcg.PublicParameters).
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindSeed:
+		return "seed"
+	case KindTuple:
+		return "tuple"
+	case KindRing:
+		return "ring"
+	case KindPublicParameters:
+		return "public parameters"
+	default:
+		return fmt.Sprintf("Kind(%d)", uint8(k))
+	}
+}
+
+// Convention identifies a convention that artifacts depend on beyond their encoding. Artifacts produced under another
+// revision of a convention can still be decoded, but would silently break the correlations.
+type Convention uint8
+
+const (
+	ConventionKeyLayout        Convention = iota // ConventionKeyLayout is the layout of the correction words of DPF keys.
+	ConventionExponentSampling                   // ConventionExponentSampling is the sampling of the noise exponents and the handling of duplicates.
+	ConventionFieldConversion                    // ConventionFieldConversion is the conversion of the DPF outputs to field elements.
+	ConventionRootOrder                          // ConventionRootOrder is the order of the roots of the ring, i.e. the i-th root is omega^(2i+1).
+	ConventionSkSharing                          // ConventionSkSharing is the scheme the sk term is shared with, i.e. additive for tau = n.
+	numConventions
+)
+
+func (c Convention) String() string {
+	switch c {
+	case ConventionKeyLayout:
+		return "key layout"
+	case ConventionExponentSampling:
+		return "exponent sampling"
+	case ConventionFieldConversion:
+		return "field conversion"
+	case ConventionRootOrder:
+		return "root order"
+	case ConventionSkSharing:
+		return "sk sharing"
+	default:
+		return fmt.Sprintf("Convention(%d)", uint8(c))
+	}
+}
+
+// Conventions holds the revision of each Convention.
+type Conventions [numConventions]uint8
+
+// Current holds the revisions of the conventions of this code revision. The revision of a convention must be
+// increased whenever it changes incompatibly.
+var Current = Conventions{
+	ConventionKeyLayout:        1,
+	ConventionExponentSampling: 1,
+	ConventionFieldConversion:  1,
+	ConventionRootOrder:        1,
+	ConventionSkSharing:        1,
+}
+
+// compatibility is an entry of the compatibility matrix.
+type compatibility struct {
+	minVersion  uint16       // minVersion is the oldest format version that can be decoded.
+	maxVersion  uint16       // maxVersion is the current format version, i.e. the version Encode writes.
+	conventions []Convention // conventions are the conventions artifacts of the kind depend on.
+}
+
+// matrix is the compatibility matrix of the artifact kinds. Format versions below the first version with a header
+// denote the legacy formats without header, which are recognized by the absence of the magic (see HasHeader).
+var matrix = map[Kind]compatibility{
+	KindSeed:             {minVersion: 2, maxVersion: 3, conventions: []Convention{ConventionKeyLayout, ConventionExponentSampling, ConventionFieldConversion, ConventionSkSharing}},
+	KindTuple:            {minVersion: 3, maxVersion: 3, conventions: []Convention{ConventionRootOrder}},
+	KindRing:             {minVersion: 2, maxVersion: 2, conventions: []Convention{ConventionRootOrder}},
+	KindPublicParameters: {minVersion: 1, maxVersion: 1, conventions: []Convention{ConventionKeyLayout, ConventionExponentSampling, ConventionFieldConversion}},
+}
+
+// magic prefixes the header. Its first byte is zero, hence it never starts a gob stream, whose messages are prefixed by
+// a non-zero length. This distinguishes the legacy gob formats without header.
+var magic = []byte{0, 'P', 'C', 'G'}
+
+// headerSize is the size of an encoded header: magic, kind, version, the amount of conventions, the conventions and
+// the parameters digest.
+const headerSize = 4 + 1 + 2 + 1 + int(numConventions) + 32
+
+// ErrNoHeader is returned by Decode if the data does not start with a header, e.g. for artifacts in a legacy format.
+var ErrNoHeader = errors.New("the serialized artifact holds no header")
+
+// IncompatibleError is returned by Decode if the header is incompatible with this code revision.
+type IncompatibleError struct {
+	Kind   Kind   // Kind is the expected kind of the artifact.
+	Reason string // Reason describes the incompatibility.
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("incompatible %s: %s", e.Kind, e.Reason)
+}
+
+// Header prefixes every serialized artifact.
+type Header struct {
+	Kind         Kind
+	Version      uint16      // Version is the format version of the artifact.
+	Conventions  Conventions // Conventions are the revisions of the conventions the artifact was produced under.
+	ParamsDigest [32]byte    // ParamsDigest identifies the PCG parameters. It is zero for artifacts independent of them.
+}
+
+// NewHeader returns the header of the current format version and conventions for an artifact of the given kind.
+func NewHeader(kind Kind, paramsDigest [32]byte) *Header {
+	return &Header{Kind: kind, Version: matrix[kind].maxVersion, Conventions: Current, ParamsDigest: paramsDigest}
+}
+
+// Encode returns the encoding of the header, to which the artifact is appended.
+func (h *Header) Encode() []byte {
+	data := make([]byte, 0, headerSize)
+	data = append(data, magic...)
+	data = append(data, byte(h.Kind))
+	data = binary.BigEndian.AppendUint16(data, h.Version)
+	data = append(data, byte(numConventions))
+	data = append(data, h.Conventions[:]...)
+	return append(data, h.ParamsDigest[:]...)
+}
+
+// HasHeader reports whether the data starts with the magic of a header.
+func HasHeader(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// KindOf returns the kind recorded in the header at the start of the data without checking the header, e.g. to
+// dispatch the decoding of an artifact of unknown kind. It returns ErrNoHeader if the data does not start with a header.
+func KindOf(data []byte) (Kind, error) {
+	if !HasHeader(data) {
+		return 0, ErrNoHeader
+	}
+	if len(data) <= len(magic) {
+		return 0, fmt.Errorf("the header is truncated")
+	}
+	return Kind(data[len(magic)]), nil
+}
+
+// Decode decodes the header at the start of the data and checks it against the compatibility matrix, i.e. the kind
+// must match, the format version must be supported and the conventions the kind depends on must match Current.
+// It returns the header and the remaining data, i.e. the artifact. It returns ErrNoHeader if the data does not start
+// with a header and an IncompatibleError if the header is incompatible.
+func Decode(data []byte, kind Kind) (*Header, []byte, error) {
+	if !HasHeader(data) {
+		return nil, nil, ErrNoHeader
+	}
+	entry, ok := matrix[kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown artifact kind %v", kind)
+	}
+	if len(data) < headerSize-int(numConventions) {
+		return nil, nil, &IncompatibleError{Kind: kind, Reason: "the header is truncated"}
+	}
+
+	h := &Header{Kind: Kind(data[4]), Version: binary.BigEndian.Uint16(data[5:7])}
+	if h.Kind != kind {
+		return nil, nil, &IncompatibleError{Kind: kind, Reason: fmt.Sprintf("the artifact is a %s", h.Kind)}
+	}
+	if h.Version > entry.maxVersion {
+		return nil, nil, &IncompatibleError{Kind: kind, Reason: fmt.Sprintf("format version %d was produced by a newer revision (supported: %d to %d)", h.Version, entry.minVersion, entry.maxVersion)}
+	}
+	if h.Version < entry.minVersion {
+		return nil, nil, &IncompatibleError{Kind: kind, Reason: fmt.Sprintf("format version %d is no longer supported (supported: %d to %d)", h.Version, entry.minVersion, entry.maxVersion)}
+	}
+
+	// The amount of conventions is encoded, s.t. newer revisions with more conventions are reported as incompatible
+	// conventions rather than as a malformed header.
+	count := int(data[7])
+	rest := data[8:]
+	if len(rest) < count+32 {
+		return nil, nil, &IncompatibleError{Kind: kind, Reason: "the header is truncated"}
+	}
+	var revisions [256]uint8
+	copy(revisions[:], rest[:count])
+	for _, convention := range entry.conventions {
+		if revisions[convention] != Current[convention] {
+			return nil, nil, &IncompatibleError{Kind: kind, Reason: fmt.Sprintf("revision %d of the %s convention differs from revision %d of this code", revisions[convention], convention, Current[convention])}
+		}
+	}
+	copy(h.Conventions[:], revisions[:])
+	copy(h.ParamsDigest[:], rest[count:count+32])
+	return h, rest[count+32:], nil
+}
@@ -0,0 +1,79 @@
+package artifact
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHeaderRoundTrip(t *testing.T) {
+	digest := [32]byte{1, 2, 3}
+	data := append(NewHeader(KindSeed, digest).Encode(), "artifact"...)
+	assert.True(t, HasHeader(data))
+
+	header, rest, err := Decode(data, KindSeed)
+	assert.Nil(t, err)
+	assert.Equal(t, KindSeed, header.Kind)
+	assert.Equal(t, matrix[KindSeed].maxVersion, header.Version)
+	assert.Equal(t, Current, header.Conventions)
+	assert.Equal(t, digest, header.ParamsDigest)
+	assert.Equal(t, []byte("artifact"), rest)
+
+	kind, err := KindOf(data)
+	assert.Nil(t, err)
+	assert.Equal(t, KindSeed, kind)
+	_, err = KindOf(magic)
+	assert.NotNil(t, err)
+}
+
+func TestDecodeIncompatible(t *testing.T) {
+	var incompatible *IncompatibleError
+
+	// Wrong kind
+	data := NewHeader(KindTuple, [32]byte{}).Encode()
+	_, _, err := Decode(data, KindSeed)
+	assert.True(t, errors.As(err, &incompatible))
+
+	// Newer and outdated format versions
+	for _, version := range []uint16{matrix[KindRing].maxVersion + 1, matrix[KindRing].minVersion - 1} {
+		header := NewHeader(KindRing, [32]byte{})
+		header.Version = version
+		_, _, err = Decode(header.Encode(), KindRing)
+		assert.True(t, errors.As(err, &incompatible))
+	}
+
+	// Another revision of a convention the kind depends on
+	header := NewHeader(KindSeed, [32]byte{})
+	header.Conventions[ConventionKeyLayout]++
+	_, _, err = Decode(header.Encode(), KindSeed)
+	assert.True(t, errors.As(err, &incompatible))
+	assert.Contains(t, err.Error(), "key layout")
+
+	// Conventions the kind does not depend on are ignored
+	header = NewHeader(KindTuple, [32]byte{})
+	header.Conventions[ConventionKeyLayout]++
+	_, _, err = Decode(header.Encode(), KindTuple)
+	assert.Nil(t, err)
+
+	// Truncated headers
+	data = NewHeader(KindSeed, [32]byte{}).Encode()
+	for _, truncated := range [][]byte{data[:6], data[:len(data)-1]} {
+		_, _, err = Decode(truncated, KindSeed)
+		assert.True(t, errors.As(err, &incompatible))
+	}
+}
+
+func TestDecodeNoHeader(t *testing.T) {
+	// Legacy gob streams never start with the magic
+	var buf bytes.Buffer
+	assert.Nil(t, gob.NewEncoder(&buf).Encode(1))
+	assert.False(t, HasHeader(buf.Bytes()))
+	_, _, err := Decode(buf.Bytes(), KindSeed)
+	assert.ErrorIs(t, err, ErrNoHeader)
+	_, _, err = Decode(nil, KindSeed)
+	assert.ErrorIs(t, err, ErrNoHeader)
+	_, err = KindOf(buf.Bytes())
+	assert.ErrorIs(t, err, ErrNoHeader)
+}
@@ -0,0 +1,166 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"math/big"
+)
+
+// DealerSecrets holds the plaintext sparse vectors and sk shares the dealer embedded into the seeds. The dealer
+// retains them in audit mode (see TrustedSeedGenAudited) and reveals them to an auditor, who checks the seeds against
+// them via Audit. The vectors are indexed by party and by r < c, e.g. AOmega.At(i, r) are the exponents of u_r of
+// party i.
+// Revealing the secrets reveals the sk and all tuples of the seeds, hence they must only be revealed after the seeds
+// are no longer used or to a trusted auditor.
+type DealerSecrets struct {
+	SkShares []*bls12381.Fr // SkShares are the shares of sk indexed by their evaluation point (see Seed.VerifyShare)
+	AOmega   *ExponentMatrix
+	EEta     *ExponentMatrix
+	SPhi     *ExponentMatrix
+	ABeta    *CoefficientMatrix
+	EGamma   *CoefficientMatrix
+	SEpsilon *CoefficientMatrix
+}
+
+// TrustedSeedGenAudited works like TrustedSeedGen, but additionally returns the secrets of the dealer, s.t. a
+// semi-trusted dealer can be audited post-hoc (see Audit).
+func (p *PCG) TrustedSeedGenAudited() ([]*Seed, *DealerSecrets, error) {
+	return p.trustedSeedGen(nil, nil)
+}
+
+// Audit checks that the seed was generated from the revealed secrets of the dealer, i.e. that its sk share, sparse
+// vectors and DSPF keys encode exactly the secrets. As each seed holds both keys of each DSPF key pair, the keys are
+// reconstructed via full evaluations, which makes the audit about as expensive as the Eval of all parties.
+// It returns an error identifying the first mismatch.
+func (p *PCG) Audit(seed *Seed, secrets *DealerSecrets) error {
+	if seed == nil || secrets == nil {
+		return fmt.Errorf("seed and secrets must not be nil")
+	}
+	if err := p.checkSecretsShape(secrets); err != nil {
+		return fmt.Errorf("invalid secrets: %w", err)
+	}
+	if seed.index < 0 || seed.index >= p.n {
+		return fmt.Errorf("seed index %d is not within [0, n=%d)", seed.index, p.n)
+	}
+
+	i := seed.index
+	if seed.skShareIndex != p.skShareIndex(i, len(secrets.SkShares)) || !seed.ski.Equal(secrets.SkShares[seed.skShareIndex]) {
+		return fmt.Errorf("sk share of party %d does not match the secrets", i)
+	}
+	if !seed.exponents.aOmega.Equal(secrets.AOmega.Party(i)) || !seed.exponents.eEta.Equal(secrets.EEta.Party(i)) ||
+		!seed.exponents.sPhi.Equal(secrets.SPhi.Party(i)) {
+		return fmt.Errorf("exponents of party %d do not match the secrets", i)
+	}
+	if !seed.coefficients.aBeta.Equal(secrets.ABeta.Party(i)) || !seed.coefficients.eGamma.Equal(secrets.EGamma.Party(i)) ||
+		!seed.coefficients.sEpsilon.Equal(secrets.SEpsilon.Party(i)) {
+		return fmt.Errorf("coefficients of party %d do not match the secrets", i)
+	}
+
+	arena := newOuterArena(p.t * p.t)
+	for i := 0; i < p.n; i++ {
+		for j := 0; j < p.n; j++ {
+			if i == j {
+				continue
+			}
+			for r := 0; r < p.c; r++ {
+				values := scalarMulFr(secrets.SkShares[p.skShareIndex(j, len(secrets.SkShares))], secrets.ABeta.At(i, r))
+				if err := auditKeyPair(p.dspfN, seed.U.At(i, j, r), secrets.AOmega.At(i, r), values); err != nil {
+					return fmt.Errorf("VOLE key U[%d][%d][%d]: %w", i, j, r, err)
+				}
+				for s := 0; s < p.c; s++ {
+					points, values := arena.outerSumAndProduct(secrets.AOmega.At(i, r), secrets.SPhi.At(j, s), secrets.ABeta.At(i, r), secrets.SEpsilon.At(j, s))
+					if err := auditKeyPair(p.dspf2N, seed.C.AtOLE(i, j, r, s), points, values); err != nil {
+						return fmt.Errorf("OLE key C[%d][%d][%d][%d]: %w", i, j, r, s, err)
+					}
+					points, values = arena.outerSumAndProduct(secrets.AOmega.At(i, r), secrets.EEta.At(j, s), secrets.ABeta.At(i, r), secrets.EGamma.At(j, s))
+					if err := auditKeyPair(p.dspf2N, seed.V.AtOLE(i, j, r, s), points, values); err != nil {
+						return fmt.Errorf("OLE key V[%d][%d][%d][%d]: %w", i, j, r, s, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// auditKeyPair checks that the sum of the full evaluations of both keys of the pair is the sparse vector with the
+// given values at the given points. Duplicate points add up, as in the DSPF.
+func auditKeyPair(scheme dspf.Scheme, keys *DSPFKeyPair, points []*big.Int, values []*bls12381.Fr) error {
+	if keys == nil {
+		return fmt.Errorf("key pair is missing")
+	}
+	eval0, err := scheme.FullEvalFastAggregated(keys.Key0)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate the first key: %w", err)
+	}
+	eval1, err := scheme.FullEvalFastAggregated(keys.Key1)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate the second key: %w", err)
+	}
+	if len(eval0) != len(eval1) {
+		return fmt.Errorf("evaluations of the keys differ in length")
+	}
+
+	expected := make(map[int]*bls12381.Fr, len(points))
+	for k, point := range points {
+		if !point.IsInt64() || point.Int64() < 0 || point.Int64() >= int64(len(eval0)) {
+			return fmt.Errorf("special point %v is outside of the domain", point)
+		}
+		x := int(point.Int64())
+		if _, ok := expected[x]; !ok {
+			expected[x] = bls12381.NewFr()
+		}
+		expected[x].Add(expected[x], values[k])
+	}
+
+	sum := bls12381.NewFr()
+	for x := range eval0 {
+		sum.Add(eval0[x], eval1[x])
+		want, ok := expected[x]
+		if ok && !sum.Equal(want) || !ok && !sum.IsZero() {
+			return fmt.Errorf("keys do not encode the secrets at point %d", x)
+		}
+	}
+	return nil
+}
+
+// checkSecretsShape checks that the secrets hold the vectors of all n parties with c vectors of t elements each.
+func (p *PCG) checkSecretsShape(secrets *DealerSecrets) error {
+	if len(secrets.SkShares) < 2 {
+		return fmt.Errorf("secrets hold %d sk shares but at least 2 are expected", len(secrets.SkShares))
+	}
+	for name, m := range map[string]*ExponentMatrix{"AOmega": secrets.AOmega, "EEta": secrets.EEta, "SPhi": secrets.SPhi} {
+		if m == nil {
+			return fmt.Errorf("secrets hold no exponents %s", name)
+		}
+		if err := p.checkMatrixShape(m); err != nil {
+			return fmt.Errorf("exponents %s: %w", name, err)
+		}
+	}
+	for name, m := range map[string]*CoefficientMatrix{"ABeta": secrets.ABeta, "EGamma": secrets.EGamma, "SEpsilon": secrets.SEpsilon} {
+		if m == nil {
+			return fmt.Errorf("secrets hold no coefficients %s", name)
+		}
+		if err := p.checkMatrixShape(m); err != nil {
+			return fmt.Errorf("coefficients %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkMatrixShape checks that the matrix holds the vectors of n parties with c vectors of t elements each.
+func (p *PCG) checkMatrixShape(m matrixShape) error {
+	if m.Parties() != p.n || m.Blocks() != p.c || m.Slots() != p.t {
+		return fmt.Errorf("matrix of %d parties with %d vectors of %d elements each, but n=%d, c=%d and t=%d are expected",
+			m.Parties(), m.Blocks(), m.Slots(), p.n, p.c, p.t)
+	}
+	return nil
+}
+
+// matrixShape is implemented by ExponentMatrix and CoefficientMatrix.
+type matrixShape interface {
+	Parties() int
+	Blocks() int
+	Slots() int
+}
@@ -0,0 +1,48 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	pcg, err := NewPCG(128, 5, 3, 3, 2, 2)
+	assert.Nil(t, err)
+	seeds, secrets, err := pcg.TrustedSeedGenAudited()
+	assert.Nil(t, err)
+	for _, seed := range seeds {
+		assert.Nil(t, pcg.Audit(seed, secrets))
+	}
+
+	// Secrets of another run do not match
+	_, otherSecrets, err := pcg.TrustedSeedGenAudited()
+	assert.Nil(t, err)
+	assert.NotNil(t, pcg.Audit(seeds[0], otherSecrets))
+
+	// A key pair encoding a different vector is detected, even if the seed's own vectors match
+	tampered := *seeds[1]
+	tampered.V = seeds[1].V.Clone()
+	points := outerSumBigInt(secrets.AOmega.At(2, 0), secrets.EEta.At(0, 1))
+	points[0] = new(big.Int).Add(points[0], big.NewInt(1)) // shift a single point
+	values := outerProductFr(secrets.ABeta.At(2, 0), secrets.EGamma.At(0, 1))
+	points, values, err = pcg.mergeDuplicatePoints(points, values, pcg.doubleDomain()) // the shifted point may collide
+	assert.Nil(t, err)
+	key0, key1, err := pcg.dspf2N.Gen(points, frSliceToBigIntSlice(values))
+	assert.Nil(t, err)
+	*tampered.V.AtOLE(2, 0, 0, 1) = DSPFKeyPair{key0, key1}
+	err = pcg.Audit(&tampered, secrets)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "V[2][0][0][1]")
+
+	// A modified coefficient of the seed is detected
+	tampered = *seeds[1]
+	tampered.coefficients.eGamma, err = NewCoefficientMatrix([][][]*bls12381.Fr{{{bls12381.NewFr().One(), bls12381.NewFr().One()}, seeds[1].coefficients.eGamma.At(0, 1)}})
+	assert.Nil(t, err)
+	assert.NotNil(t, pcg.Audit(&tampered, secrets))
+
+	// Secrets of different parameters are rejected
+	secrets.SPhi = secrets.SPhi.Party(0)
+	assert.NotNil(t, pcg.Audit(seeds[0], secrets))
+}
@@ -0,0 +1,26 @@
+package bench
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg"
+	"log"
+	"testing"
+)
+
+// BenchmarkCompareRings compares the end-to-end timings of EvalCombined with GetRing(true) and GetRing(false).
+// Run it with -benchtime=1x, as each iteration runs the whole sweep.
+func BenchmarkCompareRings(b *testing.B) {
+	sweep := []pcg.RingBenchParams{
+		pcg.NewRingBenchParams(10, 2, 4, 16),
+		pcg.NewRingBenchParams(12, 2, 4, 16),
+		pcg.NewRingBenchParams(14, 2, 4, 16),
+	}
+	for i := 0; i < b.N; i++ {
+		comparisons, err := pcg.CompareRings(128, sweep)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, comparison := range comparisons {
+			log.Printf("------------------- COMPARE RINGS (GetRing(true) vs. GetRing(false)) --------------------\n%s", comparison)
+		}
+	}
+}
@@ -2,10 +2,11 @@ package bench
 
 import (
 	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
 	"math/big"
 	"math/rand"
-	"pcg-bbs-plus/pcg"
-	"pcg-bbs-plus/pcg/poly"
 	"testing"
 )
 
@@ -43,7 +44,48 @@ func BenchmarkDeriveTuple_N20(b *testing.B) {
 	benchmarkDeriveTuple(b, 20)
 }
 
+// The batch benchmarks derive 16 tuples per operation at once (see BBSPlusTupleGenerator.GenBBSPlusTuples).
+func BenchmarkDeriveTupleBatch_N12(b *testing.B) {
+	benchmarkDeriveTupleBatch(b, 12, 16)
+}
+func BenchmarkDeriveTupleBatch_N16(b *testing.B) {
+	benchmarkDeriveTupleBatch(b, 16, 16)
+}
+
 func benchmarkDeriveTuple(b *testing.B, N int) {
+	tupleGenerator, ring := newDeriveTupleGenerator(b, N)
+
+	root := ring.Roots[10]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = tupleGenerator.GenBBSPlusTuple(root)
+	}
+	b.StopTimer()
+
+	stats := tupleGenerator.Stats()
+	b.ReportMetric(stats.TuplesPerSecond(), "tuples/s")
+	for share := range stats.ShareTime {
+		b.ReportMetric(float64(stats.ShareTime[share].Nanoseconds())/float64(b.N), tuplegen.ShareType(share).String()+"-ns/op")
+	}
+}
+
+func benchmarkDeriveTupleBatch(b *testing.B, N, batch int) {
+	tupleGenerator, ring := newDeriveTupleGenerator(b, N)
+
+	roots := ring.Roots[10 : 10+batch]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tupleGenerator.GenBBSPlusTuples(roots); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(tupleGenerator.Stats().TuplesPerSecond(), "tuples/s")
+}
+
+// newDeriveTupleGenerator returns a generator over random polynomials for the domain 2^N and the ring of the domain.
+func newDeriveTupleGenerator(b *testing.B, N int) (*pcg.BBSPlusTupleGenerator, *pcg.Ring) {
 	c, t := 4, 16
 	pcgenerator, err := pcg.NewPCG(128, N, 2, 2, c, t)
 	if err != nil {
@@ -69,13 +111,7 @@ func benchmarkDeriveTuple(b *testing.B, N int) {
 	ePoly := randomPoly(pow2N)
 	sPoly := randomPoly(pow2N)
 
-	tupleGenerator := pcg.NewBBSPlusTupleGenerator(sk, aPoly, ePoly, sPoly, alphaPoly, delta0Poly, delta1Poly)
-
-	root := ring.Roots[10]
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_ = tupleGenerator.GenBBSPlusTuple(root)
-	}
+	return pcg.NewBBSPlusTupleGenerator(sk, aPoly, ePoly, sPoly, alphaPoly, delta0Poly, delta1Poly), ring
 }
 
 func randomPoly(n *big.Int) *poly.Polynomial {
@@ -1,8 +1,8 @@
 package bench
 
 import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg"
 	"log"
-	"pcg-bbs-plus/pcg"
 	"testing"
 )
 
@@ -251,24 +251,11 @@ func benchmarkOpEvalCombined(b *testing.B, N, tau, n, c, t int) {
 		b.Fatal(err)
 	}
 
-	seeds, err := pcg.TrustedSeedGen()
-	if err != nil {
-		b.Fatal(err)
-	}
-
-	randPolys, err := pcg.PickRandomPolynomials()
-	if err != nil {
-		b.Fatal(err)
-	}
-
-	ring, err := pcg.GetRing(false)
-	if err != nil {
-		b.Fatal(err)
-	}
+	fixture := loadFixture(b, pcg)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, err = pcg.EvalCombined(seeds[0], randPolys, ring.Div)
+		_, err = pcg.EvalCombined(fixture.Seeds[0], fixture.Rand, fixture.Ring.Prepared())
 		if err != nil {
 			b.Fatal(err)
 		}
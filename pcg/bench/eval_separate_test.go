@@ -1,8 +1,8 @@
 package bench
 
 import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg"
 	"log"
-	"pcg-bbs-plus/pcg"
 	"testing"
 )
 
@@ -226,24 +226,11 @@ func benchmarkOpEvalSeparate(b *testing.B, N, tau, n, c, t int) {
 		b.Fatal(err)
 	}
 
-	seeds, err := pcg.TrustedSeedGen()
-	if err != nil {
-		b.Fatal(err)
-	}
-
-	randPolys, err := pcg.PickRandomPolynomials()
-	if err != nil {
-		b.Fatal(err)
-	}
-
-	ring, err := pcg.GetRing(false)
-	if err != nil {
-		b.Fatal(err)
-	}
+	fixture := loadFixture(b, pcg)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, err = pcg.EvalSeparate(seeds[0], randPolys, ring.Div)
+		_, err = pcg.EvalSeparate(fixture.Seeds[0], fixture.Rand, fixture.Ring.Prepared())
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -0,0 +1,63 @@
+package bench
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg"
+	"log"
+	"testing"
+)
+
+// Eval of the seeds of all parties with and without a shared EvalSession. The small t keeps the DSPF evaluations,
+// which the session does not affect, from dominating the comparison:
+func BenchmarkOpEvalAllSeeds3outof3_N10_WithoutSession(b *testing.B) {
+	benchmarkOpEvalAllSeeds(b, 10, 3, 3, 4, 4, false)
+}
+func BenchmarkOpEvalAllSeeds3outof3_N10_WithSession(b *testing.B) {
+	benchmarkOpEvalAllSeeds(b, 10, 3, 3, 4, 4, true)
+}
+func BenchmarkOpEvalAllSeeds3outof3_N12_WithoutSession(b *testing.B) {
+	benchmarkOpEvalAllSeeds(b, 12, 3, 3, 4, 4, false)
+}
+func BenchmarkOpEvalAllSeeds3outof3_N12_WithSession(b *testing.B) {
+	benchmarkOpEvalAllSeeds(b, 12, 3, 3, 4, 4, true)
+}
+func BenchmarkOpEvalAllSeeds3outof3_N14_WithoutSession(b *testing.B) {
+	benchmarkOpEvalAllSeeds(b, 14, 3, 3, 4, 4, false)
+}
+func BenchmarkOpEvalAllSeeds3outof3_N14_WithSession(b *testing.B) {
+	benchmarkOpEvalAllSeeds(b, 14, 3, 3, 4, 4, true)
+}
+
+// benchmarkOpEvalAllSeeds evaluates the seeds of all parties sequentially, either via EvalCombined, which prepares
+// the random polynomials per seed, or via the EvalSeed of one session created within the timed loop.
+func benchmarkOpEvalAllSeeds(b *testing.B, N, tau, n, c, t int, withSession bool) {
+	log.Printf("------------------- BENCHMARK EVAL ALL SEEDS (n-out-of-n PCG) --------------------")
+	log.Printf("N: %d, tau: %d, n: %d, c: %d, t: %d, session: %v\n", N, tau, n, c, t, withSession)
+	pcg, err := pcg.NewPCG(128, N, n, tau, c, t)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	fixture := loadFixture(b, pcg)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !withSession {
+			for _, seed := range fixture.Seeds {
+				if _, err = pcg.EvalCombined(seed, fixture.Rand, fixture.Ring.Prepared()); err != nil {
+					b.Fatal(err)
+				}
+			}
+			continue
+		}
+
+		session, err := pcg.NewEvalSession(fixture.Rand, fixture.Ring.Prepared())
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, seed := range fixture.Seeds {
+			if _, err = session.EvalSeed(seed); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package bench
+
+import (
+	"encoding/csv"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/experiment"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// experimentEnv names the environment variable holding the path of the experiment configuration of BenchmarkExperiment.
+const experimentEnv = "PCG_BENCH_EXPERIMENT"
+
+// experimentResult is the result of a point of an experiment.
+type experimentResult struct {
+	point experiment.Point
+	ring  time.Duration // ring is the time to generate the ring
+	eval  time.Duration // eval is the time per evaluation
+}
+
+// BenchmarkExperiment runs the sweep of the experiment configuration in PCG_BENCH_EXPERIMENT (see experiment.Load), with
+// a sub-benchmark per point. The fixtures are loaded from the fixture directory of the experiment, if any, and the
+// results are written to <name>.csv in its output directory, if any.
+func BenchmarkExperiment(b *testing.B) {
+	path := os.Getenv(experimentEnv)
+	if path == "" {
+		b.Skipf("%s is not set", experimentEnv)
+	}
+	config, err := experiment.Load(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer config.LimitThreads()()
+
+	results := make([]*experimentResult, len(config.Points))
+	for i, point := range config.Points {
+		result := &experimentResult{point: point}
+		results[i] = result
+		b.Run(point.String(), func(b *testing.B) {
+			benchmarkExperimentPoint(b, config, result)
+		})
+	}
+	if err := writeExperimentResults(config, results); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func benchmarkExperimentPoint(b *testing.B, config *experiment.Config, result *experimentResult) {
+	log.Printf("------------------- BENCHMARK EXPERIMENT %s (%s) --------------------", config.Name, result.point)
+	generator, err := config.NewPCG(result.point)
+	if err != nil {
+		b.Fatal(err)
+	}
+	fixture := loadFixtureAt(b, generator, config.FixturePath(generator))
+
+	start := time.Now()
+	ring, err := config.GetRing(generator)
+	if err != nil {
+		b.Fatal(err)
+	}
+	result.ring = time.Since(start)
+
+	b.ResetTimer()
+	start = time.Now()
+	for i := 0; i < b.N; i++ {
+		if config.Eval == experiment.EvalSeparate {
+			_, err = generator.EvalSeparate(fixture.Seeds[0], fixture.Rand, ring.Prepared())
+		} else {
+			_, err = generator.EvalCombined(fixture.Seeds[0], fixture.Rand, ring.Prepared())
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	result.eval = time.Since(start) / time.Duration(b.N) // the last run, i.e. the one of the largest b.N, is kept
+	b.ReportMetric(float64(result.ring.Nanoseconds()), "ring-ns")
+}
+
+// writeExperimentResults writes the results to the result file of the experiment, if it has an output directory.
+func writeExperimentResults(config *experiment.Config, results []*experimentResult) error {
+	path := config.ResultPath(".csv")
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	_ = w.Write([]string{"point", "lambda", "N", "n", "tau", "c", "t", "regular_noise", "ring", "eval", "ring_ns", "eval_ns"})
+	for _, r := range results {
+		p := r.point
+		_ = w.Write([]string{p.String(), strconv.Itoa(p.Lambda), strconv.Itoa(p.N), strconv.Itoa(p.Parties),
+			strconv.Itoa(p.Tau), strconv.Itoa(p.C), strconv.Itoa(p.T), strconv.FormatBool(p.RegularNoise),
+			string(config.Ring), string(config.Eval), strconv.FormatInt(r.ring.Nanoseconds(), 10),
+			strconv.FormatInt(r.eval.Nanoseconds(), 10)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	log.Printf("wrote results to %s", path)
+	return file.Close()
+}
@@ -0,0 +1,46 @@
+package bench
+
+import (
+	"errors"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixturesEnv names the environment variable holding the directory of the fixtures generated by cmd/pcgfixture.
+const fixturesEnv = "PCG_BENCH_FIXTURES"
+
+// loadFixture loads the fixture of the parameters of the PCG from the directory in PCG_BENCH_FIXTURES. If the variable
+// is not set or the directory holds no fixture of the parameters, the fixture is generated in-process.
+func loadFixture(b *testing.B, generator *pcg.PCG) *pcg.Fixture {
+	path := ""
+	if dir := os.Getenv(fixturesEnv); dir != "" {
+		path = filepath.Join(dir, generator.FixtureName())
+	}
+	return loadFixtureAt(b, generator, path)
+}
+
+// loadFixtureAt loads the fixture of the PCG from path. If path is empty or holds no fixture, the fixture is generated
+// in-process.
+func loadFixtureAt(b *testing.B, generator *pcg.PCG, path string) *pcg.Fixture {
+	if path != "" {
+		fixture, err := generator.LoadFixture(path)
+		if err == nil {
+			log.Printf("loaded fixture %s", path)
+			return fixture
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			b.Fatal(err)
+		}
+		log.Printf("no fixture at %s, generating it", path)
+	}
+
+	fixture, err := generator.GenerateFixture()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return fixture
+}
@@ -0,0 +1,142 @@
+package pcg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+)
+
+// consistencyDomainSeparator separates the consistency digest from other uses of the hash function.
+const consistencyDomainSeparator = "pcg-bbs-plus/consistency"
+
+// ConsistencyCommitment is the non-secret output of a party after EvalCombined to detect misconfigurations before
+// tuples are consumed. It is exchanged between all parties and checked via VerifyConsistency.
+// The tuples at RootIndices are revealed in the exponent and must not be used for signatures.
+type ConsistencyCommitment struct {
+	Digest      [32]byte            // Digest is a hash chain over the public inputs. It is equal for all consistent parties.
+	RootIndices []int               // RootIndices are the challenge roots derived from Digest
+	A           []*bls12381.PointG1 // A holds g1^a_i for each challenge root
+	Alpha       []*bls12381.PointG1 // Alpha holds g1^alpha_i for each challenge root
+	Delta       []*bls12381.PointG1 // Delta holds g1^delta_i for each challenge root
+	S           []*bls12381.PointG2 // S holds g2^s_i for each challenge root
+	SkE         []*bls12381.PointG2 // SkE holds g2^(sk_i + e_i) for each challenge root
+}
+
+// CommitConsistency computes the ConsistencyCommitment of the party holding the seed for the given generator
+// returned by EvalCombined, the random polynomials and the ring used for the evaluation.
+// challenges is the amount of challenge roots, whose tuples are burned by the commitment.
+func (p *PCG) CommitConsistency(seed *Seed, generator *BBSPlusTupleGenerator, rand []*poly.Polynomial, ring *Ring, challenges int) (*ConsistencyCommitment, error) {
+	if challenges < 1 || challenges > ring.Size() {
+		return nil, fmt.Errorf("amount of challenges must be in [1, %d] but is %d", ring.Size(), challenges)
+	}
+
+	digest := p.consistencyDigest(seed, rand, ring)
+	commitment := &ConsistencyCommitment{
+		Digest:      digest,
+		RootIndices: challengeIndices(digest, challenges, ring.Size()),
+	}
+
+	g1 := bls12381.NewG1()
+	g2 := bls12381.NewG2()
+	for _, index := range commitment.RootIndices {
+		tuple, err := generator.GenBBSPlusTupleAt(ring, index)
+		if err != nil {
+			return nil, err
+		}
+		skE := bls12381.NewFr()
+		skE.Add(tuple.SkShare, tuple.EShare)
+
+		commitment.A = append(commitment.A, g1.MulScalar(g1.New(), g1.One(), tuple.AShare))
+		commitment.Alpha = append(commitment.Alpha, g1.MulScalar(g1.New(), g1.One(), tuple.AlphaShare))
+		commitment.Delta = append(commitment.Delta, g1.MulScalar(g1.New(), g1.One(), tuple.DeltaShare))
+		commitment.S = append(commitment.S, g2.MulScalar(g2.New(), g2.One(), tuple.SShare))
+		commitment.SkE = append(commitment.SkE, g2.MulScalar(g2.New(), g2.One(), skE))
+	}
+	return commitment, nil
+}
+
+// VerifyConsistency verifies the commitments of all parties. It returns an error if the parties used different
+// public inputs (seed generation, PCG parameters, random polynomials or ring) or if the correlations
+// alpha = a*s and delta = a*(sk+e) do not hold at the challenge roots.
+func VerifyConsistency(commitments []*ConsistencyCommitment) error {
+	if len(commitments) == 0 {
+		return fmt.Errorf("no commitments given")
+	}
+	first := commitments[0]
+	for i, commitment := range commitments {
+		if commitment.Digest != first.Digest {
+			return fmt.Errorf("digest of party %d does not match the digest of party 0", i)
+		}
+		if len(commitment.RootIndices) != len(first.RootIndices) || len(commitment.A) != len(first.RootIndices) ||
+			len(commitment.Alpha) != len(first.RootIndices) || len(commitment.Delta) != len(first.RootIndices) ||
+			len(commitment.S) != len(first.RootIndices) || len(commitment.SkE) != len(first.RootIndices) {
+			return fmt.Errorf("commitment of party %d is malformed", i)
+		}
+	}
+
+	g1 := bls12381.NewG1()
+	g2 := bls12381.NewG2()
+	for k, index := range first.RootIndices {
+		a, alpha, delta := g1.Zero(), g1.Zero(), g1.Zero()
+		s, skE := g2.Zero(), g2.Zero()
+		for _, commitment := range commitments {
+			g1.Add(a, a, commitment.A[k])
+			g1.Add(alpha, alpha, commitment.Alpha[k])
+			g1.Add(delta, delta, commitment.Delta[k])
+			g2.Add(s, s, commitment.S[k])
+			g2.Add(skE, skE, commitment.SkE[k])
+		}
+
+		// e(g1^a, g2^s) = e(g1^alpha, g2)
+		if !bls12381.NewEngine().AddPair(a, s).AddPairInv(alpha, g2.One()).Check() {
+			return fmt.Errorf("alpha correlation does not hold at root %d", index)
+		}
+		// e(g1^a, g2^(sk+e)) = e(g1^delta, g2)
+		if !bls12381.NewEngine().AddPair(a, skE).AddPairInv(delta, g2.One()).Check() {
+			return fmt.Errorf("delta correlation does not hold at root %d", index)
+		}
+	}
+	return nil
+}
+
+// consistencyDigest computes the hash chain h_(k+1) = H(h_k || item_k) over the public inputs of the evaluation.
+func (p *PCG) consistencyDigest(seed *Seed, rand []*poly.Polynomial, ring *Ring) [32]byte {
+	digest := sha256.Sum256([]byte(consistencyDomainSeparator))
+	chain := func(item []byte) {
+		h := sha256.New()
+		h.Write(digest[:])
+		h.Write(item)
+		copy(digest[:], h.Sum(nil))
+	}
+
+	paramsDigest := p.paramsDigest()
+	chain(paramsDigest[:])
+	seedHash := seed.hash()
+	chain(seedHash[:])
+	for _, r := range rand {
+		randDigest := r.Digest()
+		chain(randDigest[:])
+	}
+	divDigest := ring.Div.Digest()
+	chain(divDigest[:])
+	return digest
+}
+
+// challengeIndices derives the given amount of distinct root indices in [0, size) from the digest.
+func challengeIndices(digest [32]byte, amount, size int) []int {
+	indices := make([]int, 0, amount)
+	seen := make(map[int]bool, amount)
+	for counter := uint64(0); len(indices) < amount; counter++ {
+		h := sha256.New()
+		h.Write(digest[:])
+		h.Write(binary.BigEndian.AppendUint64(nil, counter))
+		index := int(binary.BigEndian.Uint64(h.Sum(nil)) % uint64(size))
+		if !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
+	}
+	return indices
+}
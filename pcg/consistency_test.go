@@ -0,0 +1,54 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConsistency(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4) // Small lpn parameters for testing.
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+
+	commitments := make([]*ConsistencyCommitment, len(seeds))
+	for i, seed := range seeds {
+		generator, err := pcg.EvalCombined(seed, randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		commitments[i], err = pcg.CommitConsistency(seed, generator, randPolys, ring, 3)
+		assert.Nil(t, err)
+		assert.Len(t, commitments[i].RootIndices, 3)
+	}
+	assert.Equal(t, commitments[0].RootIndices, commitments[1].RootIndices)
+	assert.Nil(t, VerifyConsistency(commitments))
+
+	// A party that used different random polynomials is detected by the digest
+	otherRandPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	generator, err := pcg.EvalCombined(seeds[1], otherRandPolys, ring.Prepared())
+	assert.Nil(t, err)
+	inconsistent, err := pcg.CommitConsistency(seeds[1], generator, otherRandPolys, ring, 3)
+	assert.Nil(t, err)
+	assert.NotNil(t, VerifyConsistency([]*ConsistencyCommitment{commitments[0], inconsistent}))
+
+	// Inconsistent shares are detected by the correlation check
+	g1 := bls12381.NewG1()
+	tampered := *commitments[1]
+	tampered.Alpha = append([]*bls12381.PointG1{g1.One()}, commitments[1].Alpha[1:]...)
+	err = VerifyConsistency([]*ConsistencyCommitment{commitments[0], &tampered})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "alpha")
+
+	tampered = *commitments[1]
+	tampered.Delta = commitments[1].Delta[1:]
+	assert.NotNil(t, VerifyConsistency([]*ConsistencyCommitment{commitments[0], &tampered}))
+
+	_, err = pcg.CommitConsistency(seeds[0], generator, randPolys, ring, 0)
+	assert.NotNil(t, err)
+	assert.NotNil(t, VerifyConsistency(nil))
+}
@@ -0,0 +1,165 @@
+package pcg
+
+import (
+	"errors"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"sync"
+)
+
+// ErrExhausted is returned by a CorrelationSource that has no correlations left.
+var ErrExhausted = errors.New("the correlation source is exhausted")
+
+// OLE holds a party's shares of an OLE correlation (a multiplication triple): the sum of the A shares of all parties
+// times the sum of their S shares equals the sum of their Alpha shares.
+type OLE struct {
+	A     *bls12381.Fr
+	S     *bls12381.Fr
+	Alpha *bls12381.Fr // Alpha is the share of a*s
+}
+
+// EqualsConstantTime returns whether the shares of the OLE correlations are equal. The shares are compared in
+// constant time (see tuplegen.EqualSharesConstantTime).
+func (o *OLE) EqualsConstantTime(other *OLE) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+	return tuplegen.EqualSharesConstantTime(o.shares(), other.shares())
+}
+
+// CompareOLEs returns -1, 0 or 1 if the shares of a are less than, equal to or greater than those of b in the canonical
+// order, i.e. lexicographically by A, S and Alpha, where nil correlations and shares precede all others. The shares
+// are compared in constant time.
+func CompareOLEs(a, b *OLE) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return tuplegen.CompareSharesConstantTime(a.shares(), b.shares())
+}
+
+// shares returns the shares of the OLE correlation in the canonical order.
+func (o *OLE) shares() []*bls12381.Fr {
+	return []*bls12381.Fr{o.A, o.S, o.Alpha}
+}
+
+// VOLE holds a party's shares of a VOLE correlation with the secret key: the sum of the SkShares of all parties times
+// the sum of their A shares equals the sum of their Delta0 shares. The secret key is the same for all VOLEs of a PCG.
+type VOLE struct {
+	SkShare *bls12381.Fr
+	A       *bls12381.Fr
+	Delta0  *bls12381.Fr // Delta0 is the share of sk*a
+}
+
+// CorrelationSource provides correlated randomness for the preprocessing of MPC protocols. Each call consumes a fresh
+// root of the ring, hence the parties must consume the correlations in the same order to obtain matching shares.
+// Once all roots are consumed, the calls return ErrExhausted.
+type CorrelationSource interface {
+	// NextOLE returns the shares of the next OLE correlation.
+	NextOLE() (*OLE, error)
+	// NextVOLE returns the shares of the next VOLE correlation.
+	NextVOLE() (*VOLE, error)
+	// NextBBSTuple returns the next BBS+ tuple.
+	NextBBSTuple() (*BBSPlusTuple, error)
+}
+
+// CorrelationStream is a CorrelationSource over the tuple generator of a party for the n-out-of-n setting. It consumes
+// a range of tuple sequence numbers in ascending order, i.e. the roots of the ring in the order of Ring.TupleRoot, and
+// is safe for concurrent use. A call that fails still consumes its root, s.t. the consumption of the parties stays
+// aligned.
+type CorrelationStream struct {
+	generator *BBSPlusTupleGenerator
+	ring      *Ring
+	mu        sync.Mutex // mu guards next
+	next      int        // next is the sequence number of the next tuple to consume
+	end       int        // end is the exclusive upper bound of the sequence numbers
+}
+
+// NewCorrelationStream returns a CorrelationStream consuming the tuples of the ring with sequence numbers in
+// [start, end), e.g. [0, ring.Size()) for all roots. The generator must be the result of EvalCombined or EvalSeed, as
+// NextVOLE requires a tuplegen.VOLEProvider.
+func NewCorrelationStream(generator *BBSPlusTupleGenerator, ring *Ring, start, end int) (*CorrelationStream, error) {
+	if start < 0 || start > end {
+		return nil, fmt.Errorf("invalid sequence range [%d, %d)", start, end)
+	}
+	if end > ring.Size() {
+		return nil, fmt.Errorf("sequence range [%d, %d) exceeds the %d roots of the ring", start, end, ring.Size())
+	}
+	return &CorrelationStream{generator: generator, ring: ring, next: start, end: end}, nil
+}
+
+// Remaining returns the amount of correlations left.
+func (c *CorrelationStream) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.end - c.next
+}
+
+// consume reserves the sequence number of the next tuple.
+func (c *CorrelationStream) consume() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.next >= c.end {
+		return -1, ErrExhausted
+	}
+	sequence := c.next
+	c.next++
+	return sequence, nil
+}
+
+// nextRoot reserves the next tuple and returns its root (see Ring.TupleRoot).
+func (c *CorrelationStream) nextRoot() (*bls12381.Fr, error) {
+	sequence, err := c.consume()
+	if err != nil {
+		return nil, err
+	}
+	return c.ring.TupleRoot(sequence)
+}
+
+// NextOLE implements CorrelationSource.
+func (c *CorrelationStream) NextOLE() (*OLE, error) {
+	root, err := c.nextRoot()
+	if err != nil {
+		return nil, err
+	}
+	shares, err := c.generator.Provider().SharesAt(root)
+	if err != nil {
+		return nil, err
+	}
+	return &OLE{A: shares.A, S: shares.S, Alpha: shares.Alpha}, nil
+}
+
+// NextVOLE implements CorrelationSource.
+func (c *CorrelationStream) NextVOLE() (*VOLE, error) {
+	provider, ok := c.generator.Provider().(tuplegen.VOLEProvider)
+	if !ok {
+		return nil, fmt.Errorf("the share provider of the generator does not provide VOLE correlations")
+	}
+	root, err := c.nextRoot()
+	if err != nil {
+		return nil, err
+	}
+	shares, err := provider.VOLESharesAt(root)
+	if err != nil {
+		return nil, err
+	}
+	return &VOLE{SkShare: bls12381.NewFr().Set(provider.SkShare()), A: shares.A, Delta0: shares.Delta0}, nil
+}
+
+// NextBBSTuple implements CorrelationSource. The tuple is tagged with the index of its root if the generator is tagged.
+func (c *CorrelationStream) NextBBSTuple() (*BBSPlusTuple, error) {
+	sequence, err := c.consume()
+	if err != nil {
+		return nil, err
+	}
+	index, err := c.ring.TupleRootIndex(sequence)
+	if err != nil {
+		return nil, err
+	}
+	return c.generator.GenBBSPlusTupleAt(c.ring, index)
+}
@@ -0,0 +1,141 @@
+package pcg
+
+import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func newTestCorrelationStreams(t *testing.T, start, end int) []*CorrelationStream {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	session, err := pcg.NewEvalSession(randPolys, ring.Prepared())
+	assert.Nil(t, err)
+
+	streams := make([]*CorrelationStream, len(seeds))
+	for i, seed := range seeds {
+		gen, err := session.EvalSeed(seed)
+		assert.Nil(t, err)
+		streams[i], err = NewCorrelationStream(gen, ring, start, end)
+		assert.Nil(t, err)
+	}
+	return streams
+}
+
+func TestCorrelationStream(t *testing.T) {
+	streams := newTestCorrelationStreams(t, 2, 5)
+
+	a, s, alpha := bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr()
+	for _, stream := range streams {
+		ole, err := stream.NextOLE()
+		assert.Nil(t, err)
+		a.Add(a, ole.A)
+		s.Add(s, ole.S)
+		alpha.Add(alpha, ole.Alpha)
+	}
+	as := bls12381.NewFr()
+	as.Mul(a, s)
+	assert.True(t, as.Equal(alpha))
+
+	sk, a, delta0 := bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr()
+	for _, stream := range streams {
+		vole, err := stream.NextVOLE()
+		assert.Nil(t, err)
+		sk.Add(sk, vole.SkShare)
+		a.Add(a, vole.A)
+		delta0.Add(delta0, vole.Delta0)
+	}
+	ska := bls12381.NewFr()
+	ska.Mul(sk, a)
+	assert.True(t, ska.Equal(delta0))
+
+	// The stream consumes the roots in the order of Ring.TupleRoot, i.e. the last tuple is at the root of sequence 4
+	for _, stream := range streams {
+		tuple, err := stream.NextBBSTuple()
+		assert.Nil(t, err)
+		assert.NotNil(t, tuple)
+		index, err := stream.ring.TupleRootIndex(4)
+		assert.Nil(t, err)
+		assert.Equal(t, index, tuple.Tag.RootIndex)
+		assert.NotEqual(t, 4, index)
+		assert.Equal(t, 0, stream.Remaining())
+
+		_, err = stream.NextOLE()
+		assert.True(t, errors.Is(err, ErrExhausted))
+		_, err = stream.NextVOLE()
+		assert.True(t, errors.Is(err, ErrExhausted))
+		_, err = stream.NextBBSTuple()
+		assert.True(t, errors.Is(err, ErrExhausted))
+	}
+}
+
+func TestCorrelationStreamConcurrent(t *testing.T) {
+	stream := newTestCorrelationStreams(t, 0, 16)[0]
+
+	var mu sync.Mutex
+	consumed := 0
+	wg := sync.WaitGroup{}
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				_, err := stream.NextOLE()
+				if errors.Is(err, ErrExhausted) {
+					return
+				}
+				assert.Nil(t, err)
+				mu.Lock()
+				consumed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 16, consumed)
+	assert.Equal(t, 0, stream.Remaining())
+}
+
+func TestNewCorrelationStreamInvalidRange(t *testing.T) {
+	pcg, err := NewPCG(128, 3, 2, 2, 2, 2)
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing() // 8 roots
+	assert.Nil(t, err)
+	gen := tuplegen.NewGenerator(nil)
+
+	_, err = NewCorrelationStream(gen, ring, 3, 2)
+	assert.NotNil(t, err)
+	_, err = NewCorrelationStream(gen, ring, 0, 9)
+	assert.NotNil(t, err)
+	stream, err := NewCorrelationStream(gen, ring, 8, 8)
+	assert.Nil(t, err)
+	_, err = stream.NextBBSTuple()
+	assert.True(t, errors.Is(err, ErrExhausted))
+}
+
+func TestOLEEqualsConstantTime(t *testing.T) {
+	one, two := bls12381.NewFr().One(), bls12381.NewFr()
+	two.Double(one)
+	ole := &OLE{A: one, S: two, Alpha: two}
+
+	assert.True(t, ole.EqualsConstantTime(&OLE{A: one, S: two, Alpha: two}))
+	assert.False(t, ole.EqualsConstantTime(&OLE{A: one, S: two, Alpha: one}))
+	assert.False(t, ole.EqualsConstantTime(&OLE{A: one, S: two}))
+	assert.False(t, ole.EqualsConstantTime(nil))
+
+	assert.Equal(t, 0, CompareOLEs(ole, &OLE{A: one, S: two, Alpha: two}))
+	assert.Equal(t, 1, CompareOLEs(ole, &OLE{A: one, S: one, Alpha: two}))
+	assert.Equal(t, -1, CompareOLEs(ole, &OLE{A: two, S: one, Alpha: one}))
+	assert.Equal(t, -1, CompareOLEs(nil, ole))
+	assert.Equal(t, 1, CompareOLEs(ole, nil))
+	assert.Equal(t, 0, CompareOLEs(nil, nil))
+}
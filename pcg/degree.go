@@ -0,0 +1,41 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+)
+
+// DegreeBudget holds the exclusive upper bounds of the degrees of the polynomials of Eval, which the ring arithmetic
+// relies on: the DSPF outputs of the VOLE and OLE correlations cover exactly 2^N and 2^(N+1) coefficients, and the
+// reduction of a PreparedDivisor of degree 2^N handles dividends of degree < 2^(N+1) (see PreparedDivisor.Reduce).
+// The expander asserts the budget after each multiplication, s.t. changes to the sampling or the rings that violate it
+// fail with a DegreeError instead of silently breaking the correlations.
+type DegreeBudget struct {
+	Sparse  int // Sparse bounds the degrees of the sparse polynomials u, v and k and of their VOLE expansions, i.e. 2^N.
+	Product int // Product bounds the degrees of the products u[r]*v[s] and of their OLE expansions, i.e. 2^(N+1).
+	Ring    int // Ring is the degree of the ring divisor, i.e. the bound of the degrees of the reduced polynomials.
+}
+
+// DegreeBudget returns the degree budget of the PCG.
+func (p *PCG) DegreeBudget() DegreeBudget {
+	return DegreeBudget{Sparse: 1 << p.N, Product: 1 << (p.N + 1), Ring: 1 << p.N}
+}
+
+// DegreeError is returned by the expander if a polynomial exceeds its degree budget.
+type DegreeError struct {
+	Polynomial string // Polynomial names the polynomial, e.g. "u[1]*v[0]".
+	Degree     int    // Degree is the degree of the polynomial.
+	Bound      int    // Bound is the exclusive upper bound of the budget.
+}
+
+func (e *DegreeError) Error() string {
+	return fmt.Sprintf("%s has degree %d, which exceeds the degree budget < %d", e.Polynomial, e.Degree, e.Bound)
+}
+
+// checkDegree returns a DegreeError if the degree of the polynomial is not below bound.
+func checkDegree(polynomial *poly.Polynomial, bound int, format string, args ...any) error {
+	if degree, _ := polynomial.Degree(); degree >= bound {
+		return &DegreeError{Polynomial: fmt.Sprintf(format, args...), Degree: degree, Bound: bound}
+	}
+	return nil
+}
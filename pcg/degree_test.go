@@ -0,0 +1,56 @@
+package pcg
+
+import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestDegreeBudget(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, DegreeBudget{Sparse: 64, Product: 128, Ring: 64}, pcg.DegreeBudget())
+
+	// The products of sparse polynomials of maximal degree stay within the budget
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	u, err := pcg.constructPolys(seeds[0].coefficients.aBeta, seeds[0].exponents.aOmega)
+	assert.Nil(t, err)
+	for _, polynomial := range u {
+		assert.Nil(t, checkDegree(polynomial, pcg.DegreeBudget().Sparse, "u"))
+	}
+	_, err = pcg.ExpandOLE(u, u, seeds[0].C, 0)
+	assert.Nil(t, err)
+}
+
+func TestDegreeBudgetViolation(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	u, err := pcg.constructPolys(seeds[0].coefficients.aBeta, seeds[0].exponents.aOmega)
+	assert.Nil(t, err)
+
+	// A sparse polynomial of degree 2^N exceeds the budget of the ring
+	tooLarge, err := poly.NewSparse([]*bls12381.Fr{bls12381.NewFr().One()}, []*big.Int{big.NewInt(64)})
+	assert.Nil(t, err)
+	invalid := []*poly.Polynomial{u[0], tooLarge}
+	var degreeErr *DegreeError
+	_, err = pcg.ExpandOLE(u, invalid, seeds[0].C, 0)
+	assert.True(t, errors.As(err, &degreeErr))
+	assert.Equal(t, DegreeError{Polynomial: "sparse polynomial 1", Degree: 64, Bound: 64}, *degreeErr)
+	_, _, err = pcg.ExpandOLESeparate(invalid, u, seeds[0].C, 0)
+	assert.True(t, errors.As(err, &degreeErr))
+	_, err = pcg.ExpandVOLE(invalid, seeds[0].ski, seeds[0].U, 0)
+	assert.True(t, errors.As(err, &degreeErr))
+
+	// The products are checked against the product budget
+	product, err := poly.NewSparse([]*bls12381.Fr{bls12381.NewFr().One()}, []*big.Int{big.NewInt(128)})
+	assert.Nil(t, err)
+	err = checkDegree(product, pcg.DegreeBudget().Product, "u[%d]*v[%d]", 1, 0)
+	assert.True(t, errors.As(err, &degreeErr))
+	assert.Equal(t, "u[1]*v[0] has degree 128, which exceeds the degree budget < 128", err.Error())
+}
@@ -0,0 +1,178 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"time"
+)
+
+// EvalOption configures the output of EvalCombined and EvalSeparate.
+type EvalOption func(*evalOptions)
+
+// evalOptions holds the configuration of an evaluation (see EvalOption).
+type evalOptions struct {
+	dense         *DenseShares         // dense receives the shares of EvalCombined as dense vectors. nil disables it.
+	denseSeparate *DenseSeparateShares // denseSeparate receives the shares of EvalSeparate as dense vectors. nil disables it.
+}
+
+// WithDenseShares makes EvalCombined write the shares to out as dense coefficient vectors. The shares are calculated
+// as vectors instead of polynomials, and the returned generator evaluates the vectors.
+func WithDenseShares(out *DenseShares) EvalOption {
+	return func(o *evalOptions) {
+		o.dense = out
+	}
+}
+
+// WithDenseSeparateShares makes EvalSeparate additionally write the shares to out as dense coefficient vectors.
+func WithDenseSeparateShares(out *DenseSeparateShares) EvalOption {
+	return func(o *evalOptions) {
+		o.denseSeparate = out
+	}
+}
+
+// newEvalOptions applies the options to an empty configuration.
+func newEvalOptions(opts []EvalOption) *evalOptions {
+	options := &evalOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// DenseShares holds the shares of a party in the n-out-of-n setting as coefficient vectors of length 2^N, e.g. to
+// feed them into an external NTT. The i-th element of a vector is the coefficient of x^i of the share polynomial.
+// The tuple generator evaluates the same vectors (see tuplegen.VectorShares), hence they must not be modified.
+type DenseShares struct {
+	SkShare *bls12381.Fr
+	A       []*bls12381.Fr
+	E       []*bls12381.Fr
+	S       []*bls12381.Fr
+	Alpha   []*bls12381.Fr
+	Delta0  []*bls12381.Fr // Delta0 is the share of a*sk, i.e. delta = delta0 + delta1
+	Delta1  []*bls12381.Fr // Delta1 is the share of a*e
+}
+
+// DenseSeparateShares holds the shares of a party in the tau-out-of-n setting as coefficient vectors of length 2^N
+// (see DenseShares). The cross terms are indexed by the counterparty and nil for the party itself and all
+// counterparties that were not evaluated.
+type DenseSeparateShares struct {
+	OwnIndex int
+	SkShare  *bls12381.Fr
+	Usk      []*bls12381.Fr // Usk is the local term of delta0, which is not yet weighted by the Lagrange coefficient (see tuplegen.ForwardDirection)
+	Uk       []*bls12381.Fr // Uk is the local term of alpha
+	Uv       []*bls12381.Fr // Uv is the local term of delta1
+	A        []*bls12381.Fr
+	E        []*bls12381.Fr
+	S        []*bls12381.Fr
+	Delta0   [][][]*bls12381.Fr // Delta0[j] holds the unweighted cross terms of delta0 with counterparty j in both directions (see ForwardDirection)
+	Alpha    [][]*bls12381.Fr
+	Delta1   [][]*bls12381.Fr
+}
+
+// PackFr packs the vector into a byte slice, where each element is encoded as 32 bytes in big-endian order.
+func PackFr(vector []*bls12381.Fr) []byte {
+	packed := make([]byte, 0, 32*len(vector))
+	for _, element := range vector {
+		packed = append(packed, element.ToBytes()...)
+	}
+	return packed
+}
+
+// denseGenerator calculates the final shares of evalCombined directly as dense vectors, writes them to out and
+// returns a generator over the vectors. With the domain of the ring, the vectors are the inverse transforms of the
+// final shares, s.t. the polynomial maps of the shares are never built.
+func (p *PCG) denseGenerator(seed *Seed, session *EvalSession, out *DenseShares, startTimeTotal time.Time, u, v, k, utilde []*poly.Polynomial, w, m [][]*poly.Polynomial) (*BBSPlusTupleGenerator, error) {
+	res := DenseShares{SkShare: seed.ski}
+	shares := []struct {
+		name   string
+		vector *[]*bls12381.Fr
+		eval   func() ([]*bls12381.Fr, error)
+	}{
+		{"ai", &res.A, func() ([]*bls12381.Fr, error) { return session.finalShareDense(u) }},
+		{"ei", &res.E, func() ([]*bls12381.Fr, error) { return session.finalShareDense(v) }},
+		{"si", &res.S, func() ([]*bls12381.Fr, error) { return session.finalShareDense(k) }},
+		{"delta0i", &res.Delta0, func() ([]*bls12381.Fr, error) { return session.finalShareDense(utilde) }},
+		{"alphai", &res.Alpha, func() ([]*bls12381.Fr, error) { return session.finalShare2DDense(w) }},
+		{"delta1i", &res.Delta1, func() ([]*bls12381.Fr, error) { return session.finalShare2DDense(m) }},
+	}
+	for _, share := range shares {
+		start := time.Now()
+		vector, err := share.eval()
+		if err != nil {
+			return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share %s: %w", share.name, err), PhaseFinalShare, seed.index)
+		}
+		*share.vector = vector
+		duration := time.Since(start)
+		p.logger.Debugf("Calculated dense final share for %s (in s): %v", share.name, duration.Seconds())
+		p.observePhase(seed.index, PhaseFinalShare, duration)
+	}
+	p.logger.Infof("Total time for EVAL (in s): %v", time.Since(startTimeTotal).Seconds())
+
+	provider, err := tuplegen.NewVectorShares(res.SkShare, res.A, res.E, res.S, res.Alpha, res.Delta0, res.Delta1)
+	if err != nil {
+		return nil, err
+	}
+	*out = res
+	return p.newGenerator(seed, provider)
+}
+
+// writeDenseSeparate writes the shares of evalSeparate to out.
+func (p *PCG) writeDenseSeparate(out *DenseSeparateShares, sk *bls12381.Fr, usk, uk, uv, a, e, s *poly.Polynomial, delta0 *tuplegen.PartyIndexed[[]*poly.Polynomial], alpha, delta1 *tuplegen.PartyIndexed[*poly.Polynomial]) error {
+	length := int(p.domain.Int64())
+	local, err := denseVectors(length, usk, uk, uv, a, e, s)
+	if err != nil {
+		return err
+	}
+	res := DenseSeparateShares{
+		OwnIndex: delta0.Own(),
+		SkShare:  sk,
+		Usk:      local[0],
+		Uk:       local[1],
+		Uv:       local[2],
+		A:        local[3],
+		E:        local[4],
+		S:        local[5],
+		Delta0:   make([][][]*bls12381.Fr, delta0.N()),
+		Alpha:    make([][]*bls12381.Fr, alpha.N()),
+		Delta1:   make([][]*bls12381.Fr, delta1.N()),
+	}
+	err = delta0.ForEachOther(func(j int, delta0J []*poly.Polynomial) error {
+		alphaJ, err := alpha.Get(j)
+		if err != nil {
+			return err
+		}
+		delta1J, err := delta1.Get(j)
+		if err != nil {
+			return err
+		}
+		if res.Delta0[j], err = denseVectors(length, delta0J...); err != nil {
+			return err
+		}
+		cross, err := denseVectors(length, alphaJ, delta1J)
+		if err != nil {
+			return err
+		}
+		res.Alpha[j], res.Delta1[j] = cross[0], cross[1]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*out = res
+	return nil
+}
+
+// denseVectors returns the coefficient vectors of the given length of the polynomials (see poly.Polynomial.Dense).
+func denseVectors(length int, polys ...*poly.Polynomial) ([][]*bls12381.Fr, error) {
+	vectors := make([][]*bls12381.Fr, len(polys))
+	for i, share := range polys {
+		vector, err := share.Dense(length)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert share to a dense vector: %w", err)
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
@@ -0,0 +1,98 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEvalCombinedDenseShares(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	var dense DenseShares
+	gen, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared(), WithDenseShares(&dense))
+	assert.Nil(t, err)
+	assert.True(t, dense.SkShare.Equal(seeds[0].ski))
+	for _, vector := range [][]*bls12381.Fr{dense.A, dense.E, dense.S, dense.Alpha, dense.Delta0, dense.Delta1} {
+		assert.Len(t, vector, 1<<6)
+	}
+
+	// The dense vectors are the coefficients of the share polynomials
+	root, err := ring.RootAt(7)
+	assert.Nil(t, err)
+	tuple, err := gen.GenBBSPlusTupleAt(ring, 7)
+	assert.Nil(t, err)
+	assert.True(t, poly.NewFromFr(dense.A).Evaluate(root).Equal(tuple.AShare))
+	assert.True(t, poly.NewFromFr(dense.Alpha).Evaluate(root).Equal(tuple.AlphaShare))
+	delta := poly.Add(poly.NewFromFr(dense.Delta0), poly.NewFromFr(dense.Delta1))
+	assert.True(t, delta.Evaluate(root).Equal(tuple.DeltaShare))
+
+	// The generator evaluates the dense vectors and derives the same tuples as without them
+	_, ok := gen.Provider().(*tuplegen.VectorShares)
+	assert.True(t, ok)
+	polyGen, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	expected, err := polyGen.GenBBSPlusTupleAt(ring, 7)
+	assert.Nil(t, err)
+	assert.True(t, expected.DeltaShare.Equal(tuple.DeltaShare))
+	assert.True(t, expected.SShare.Equal(tuple.SShare))
+
+	// Custom divisors convert the final share polynomials
+	div, err := NewPreparedDivisor(ring.Div)
+	assert.Nil(t, err)
+	var custom DenseShares
+	_, err = pcg.EvalCombined(seeds[0], randPolys, div, WithDenseShares(&custom))
+	assert.Nil(t, err)
+	for i := range dense.Alpha {
+		assert.True(t, dense.Alpha[i].Equal(custom.Alpha[i]))
+	}
+
+	packed := PackFr(dense.E)
+	assert.Len(t, packed, 32<<6)
+	assert.Equal(t, dense.E[1].ToBytes(), packed[32:64])
+
+	// The dense output of the other setting is rejected
+	_, err = pcg.EvalCombined(seeds[0], randPolys, ring.Prepared(), WithDenseSeparateShares(&DenseSeparateShares{}))
+	assert.NotNil(t, err)
+}
+
+func TestEvalSeparateDenseShares(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	var dense DenseSeparateShares
+	gen, err := pcg.EvalSeparateForSigners(seeds[1], randPolys, ring.Prepared(), []int{0, 1}, WithDenseSeparateShares(&dense))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, dense.OwnIndex)
+	assert.Len(t, dense.Uk, 1<<6)
+	assert.Len(t, dense.Alpha[0], 1<<6)
+	assert.Len(t, dense.Delta0[0], 2)
+	// Cross terms of the party itself and of counterparties outside the signer set are not evaluated
+	assert.Nil(t, dense.Alpha[1])
+	assert.Nil(t, dense.Delta1[2])
+	assert.Nil(t, dense.Delta0[2])
+
+	root, err := ring.RootAt(3)
+	assert.Nil(t, err)
+	tuple, err := gen.GenBBSPlusTupleAt(ring, 3, []int{0, 1})
+	assert.Nil(t, err)
+	assert.True(t, poly.NewFromFr(dense.S).Evaluate(root).Equal(tuple.SShare))
+
+	_, err = pcg.EvalSeparate(seeds[1], randPolys, ring.Prepared(), WithDenseShares(&DenseShares{}))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,174 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/artifact"
+	"strings"
+)
+
+// The descriptions summarize artifacts for debugging, e.g. to compare the artifacts of the parties when their tuples do
+// not match. They hold counts, domains, sizes and digests, but never secret values, s.t. they can be shared freely.
+
+// SeedDescription is a summary of a seed (see Seed.Describe).
+type SeedDescription struct {
+	Index        int             // Index is the index of the party.
+	SkShareIndex int             // SkShareIndex is the index of the Shamir evaluation point of the sk share.
+	Params       *SeedParameters // Params are the parameters the seed was generated for, nil for legacy seeds.
+	ParamsDigest [32]byte        // ParamsDigest identifies the parameters, zero for legacy seeds.
+	SeedHash     [32]byte        // SeedHash identifies the seed in the tags of its tuples.
+	Commitments  int             // Commitments is the amount of Feldman commitments to the sharing of sk.
+	C            int             // C is the amount of noise polynomials per vector.
+	T            int             // T is the amount of noise terms per polynomial.
+	VOLEKeys     KeysDescription // VOLEKeys summarizes the DSPF keys U of the VOLE correlation.
+	OLEKeys      KeysDescription // OLEKeys summarizes the DSPF keys C and V of the OLE correlations.
+	ReRandomized bool            // ReRandomized is set if the seed was re-randomized (see ReRandomizeSeed).
+	Signed       bool            // Signed is set if the seed holds a signature of the dealer.
+	Bytes        int             // Bytes is the size of the serialized seed (see Seed.Serialize).
+}
+
+// KeysDescription summarizes a set of DSPF key pairs of a seed.
+type KeysDescription struct {
+	Pairs   int // Pairs is the amount of key pairs.
+	DPFKeys int // DPFKeys is the amount of DPF keys of all keys of the pairs.
+	Domain  int // Domain is the bit length of the domain of the DPF keys, -1 if unknown or not uniform.
+	Bytes   int // Bytes is the size of all serialized keys of the pairs.
+}
+
+// Describe returns a summary of the seed (see SeedDescription).
+func (s *Seed) Describe() (*SeedDescription, error) {
+	data, err := s.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	description := &SeedDescription{
+		Index:        s.index,
+		SkShareIndex: s.skShareIndex,
+		Params:       s.Parameters(),
+		ParamsDigest: s.paramsDigest,
+		SeedHash:     s.hash(),
+		Commitments:  len(s.skCommitments),
+		C:            s.exponents.aOmega.Blocks(),
+		ReRandomized: s.reRandKey != nil,
+		Signed:       s.signature != nil,
+		Bytes:        len(data),
+	}
+	description.T = s.exponents.aOmega.Slots()
+
+	description.VOLEKeys.Domain, description.OLEKeys.Domain = -1, -1
+	for _, keys := range []struct {
+		matrix      *DSPFKeyMatrix
+		description *KeysDescription
+	}{{s.U, &description.VOLEKeys}, {s.C, &description.OLEKeys}, {s.V, &description.OLEKeys}} {
+		if keys.matrix == nil {
+			continue
+		}
+		for k := range keys.matrix.pairs {
+			if err := keys.description.add(&keys.matrix.pairs[k]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return description, nil
+}
+
+// add adds the keys of the pair to the description. Pairs of empty keys, i.e. the pairs of a party with itself, are
+// skipped.
+func (d *KeysDescription) add(pair *DSPFKeyPair) error {
+	used := false
+	for _, key := range []dspf.Key{pair.Key0, pair.Key1} {
+		keyDescription, err := key.Describe()
+		if err != nil {
+			return err
+		}
+		if keyDescription.DPFKeys == 0 {
+			continue
+		}
+		if d.DPFKeys == 0 {
+			d.Domain = keyDescription.Domain
+		} else if keyDescription.Domain != d.Domain {
+			d.Domain = -1
+		}
+		d.DPFKeys += keyDescription.DPFKeys
+		d.Bytes += keyDescription.Bytes
+		used = true
+	}
+	if used {
+		d.Pairs++
+	}
+	return nil
+}
+
+func (d *KeysDescription) String() string {
+	return fmt.Sprintf("%d pairs, %d DPF keys, domain 2^%d, %d bytes", d.Pairs, d.DPFKeys, d.Domain, d.Bytes)
+}
+
+func (d *SeedDescription) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "party: %d (sk share index %d)\n", d.Index, d.SkShareIndex)
+	if d.Params == nil {
+		b.WriteString("parameters: unknown (legacy seed)\n")
+	} else {
+		fmt.Fprintf(&b, "parameters: lambda=%d N=%d n=%d tau=%d c=%d t=%d regular noise=%t ring=%x\n", d.Params.Lambda, d.Params.N, d.Params.Parties, d.Params.Tau, d.Params.C, d.Params.T, d.Params.RegularNoise, d.Params.RingDigest[:8])
+	}
+	fmt.Fprintf(&b, "parameters digest: %x\n", d.ParamsDigest)
+	fmt.Fprintf(&b, "seed hash: %x\n", d.SeedHash)
+	fmt.Fprintf(&b, "commitments: %d\n", d.Commitments)
+	fmt.Fprintf(&b, "noise: c=%d polynomials of t=%d terms\n", d.C, d.T)
+	fmt.Fprintf(&b, "VOLE keys: %s\n", &d.VOLEKeys)
+	fmt.Fprintf(&b, "OLE keys: %s\n", &d.OLEKeys)
+	fmt.Fprintf(&b, "re-randomized: %t\nsigned: %t\nserialized size: %d bytes", d.ReRandomized, d.Signed, d.Bytes)
+	return b.String()
+}
+
+// Inspect describes the serialized artifact, i.e. a seed, tuple, ring or public parameters, for debugging. The kind of
+// the artifact is read from its header, hence artifacts of the legacy formats without header are not supported.
+// The description starts with the header and never contains secret values.
+func Inspect(data []byte) (string, error) {
+	kind, err := artifact.KindOf(data)
+	if err != nil {
+		return "", err
+	}
+	header, _, err := artifact.Decode(data, kind)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "kind: %s (format version %d)\n", header.Kind, header.Version)
+	fmt.Fprintf(&b, "conventions: %v\n", header.Conventions)
+	fmt.Fprintf(&b, "header parameters digest: %x\n", header.ParamsDigest)
+	switch kind {
+	case artifact.KindSeed:
+		seed := &Seed{}
+		if err := seed.Deserialize(data); err != nil {
+			return "", err
+		}
+		description, err := seed.Describe()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(description.String())
+	case artifact.KindTuple:
+		tuple := &BBSPlusTuple{}
+		if err := tuple.Deserialize(data); err != nil {
+			return "", err
+		}
+		b.WriteString(tuple.Describe().String())
+	case artifact.KindRing:
+		ring := &Ring{}
+		if err := ring.Deserialize(data); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "roots: %d (materialized: %t)\nring digest: %x", ring.Size(), ring.Roots != nil, ring.Div.Digest())
+	case artifact.KindPublicParameters:
+		pp := &PublicParameters{}
+		if err := pp.Deserialize(data); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "dealer key: %x", []byte(pp.DealerKey))
+	default:
+		return "", fmt.Errorf("unknown artifact kind %v", kind)
+	}
+	return b.String(), nil
+}
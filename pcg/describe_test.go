@@ -0,0 +1,72 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/artifact"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSeedDescribe(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, pp, err := pcg.TrustedSeedGenAuthenticated()
+	assert.Nil(t, err)
+
+	description, err := seeds[1].Describe()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, description.Index)
+	params, err := pcg.seedParameters()
+	assert.Nil(t, err)
+	assert.Equal(t, params, description.Params)
+	assert.Equal(t, pp.ParamsDigest, description.ParamsDigest)
+	assert.Equal(t, seeds[1].hash(), description.SeedHash)
+	assert.Equal(t, 2, description.C)
+	assert.Equal(t, 4, description.T)
+	assert.True(t, description.Signed)
+
+	// The seed holds the c VOLE and 2c^2 OLE key pairs of both directions with the counterparty
+	assert.Equal(t, 2*2, description.VOLEKeys.Pairs)
+	assert.Equal(t, 2*2*2*2, description.OLEKeys.Pairs)
+	assert.Equal(t, 6, description.VOLEKeys.Domain)
+	assert.Equal(t, 7, description.OLEKeys.Domain)
+	assert.Positive(t, description.VOLEKeys.Bytes)
+	assert.Positive(t, description.OLEKeys.DPFKeys)
+	data, err := seeds[1].Serialize()
+	assert.Nil(t, err)
+	assert.Equal(t, len(data), description.Bytes)
+
+	// The description never contains the sk share
+	assert.NotContains(t, description.String(), fmt.Sprintf("%x", seeds[1].ski.ToBytes()))
+}
+
+func TestInspect(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, pp, err := pcg.TrustedSeedGenAuthenticated()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	tuples, err := pcg.EvalCombinedAt(seeds[0], randPolys, ring.Prepared(), []int{3})
+	assert.Nil(t, err)
+
+	seedData, err := seeds[0].Serialize()
+	assert.Nil(t, err)
+	tupleData, err := tuples[0].Serialize()
+	assert.Nil(t, err)
+	ringData, err := ring.Serialize()
+	assert.Nil(t, err)
+	ppData, err := pp.Serialize()
+	assert.Nil(t, err)
+
+	for data, expected := range map[*[]byte]string{&seedData: "VOLE keys", &tupleData: "tag: root 3", &ringData: "roots: 64", &ppData: "dealer key"} {
+		description, err := Inspect(*data)
+		assert.Nil(t, err)
+		assert.Contains(t, description, expected)
+	}
+
+	_, err = Inspect([]byte("no artifact"))
+	assert.ErrorIs(t, err, artifact.ErrNoHeader)
+}
@@ -0,0 +1,66 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+)
+
+// DistributeEval makes the Eval of the PCG split the full evaluations of its DSPF keys by prefix into 2^depth chunks,
+// which are distributed across the workers, e.g. to evaluate large N on multiple machines. The merged evaluations are
+// expanded into the share polynomials as usual, i.e. the shares do not depend on the workers.
+// Each worker must evaluate chunks of the domains N and N+1, e.g. the worker of NewLocalWorker of a PCG with the same
+// parameters, which may be served to other machines via dspf.ServeWorker. The depth must be within [0, N].
+// Distributed evaluation is not supported with regular noise, as its DSPFs only evaluate the segments of the points.
+func (p *PCG) DistributeEval(workers []dspf.Worker, depth int) error {
+	if p.regularNoise {
+		return fmt.Errorf("distributed evaluation is not supported with regular noise")
+	}
+	if depth < 0 || depth > p.N {
+		return fmt.Errorf("depth %d is not within [0, N=%d]", depth, p.N)
+	}
+	dspfN, dspf2N, err := p.newDSPFs()
+	if err != nil {
+		return err
+	}
+	distributedN, err := dspf.NewDistributedDSPF(dspfN, workers, depth)
+	if err != nil {
+		return err
+	}
+	distributed2N, err := dspf.NewDistributedDSPF(dspf2N, workers, depth)
+	if err != nil {
+		return err
+	}
+
+	distributedN.SetLogger(p.logger)
+	distributed2N.SetLogger(p.logger)
+	p.dspfN = distributedN
+	p.dspf2N = distributed2N
+	return nil
+}
+
+// NewLocalWorker returns a worker that evaluates the chunks of the DSPF keys of the PCG in the current process (see
+// DistributeEval).
+func (p *PCG) NewLocalWorker() (*dspf.LocalWorker, error) {
+	dspfN, dspf2N, err := p.newDSPFs()
+	if err != nil {
+		return nil, err
+	}
+	return dspf.NewLocalWorker(dspfN, dspf2N)
+}
+
+// newDSPFs returns the (non-regular) DSPFs of the domains N and N+1 of the PCG (see NewPCG), which are constant time in the hardened mode.
+func (p *PCG) newDSPFs() (*dspf.DSPF, *dspf.DSPF, error) {
+	baseDpfDomain, err := p.newBaseDPF(p.N)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize base DPF with domain N: %w", err)
+	}
+	baseDpfDoubleDomain, err := p.newBaseDPF(p.N + 1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize base DPF with domain 2N: %w", err)
+	}
+	dspfN := dspf.NewDSPFFactory(baseDpfDomain)
+	dspf2N := dspf.NewDSPFFactory(baseDpfDoubleDomain)
+	dspfN.SetLogger(p.logger)
+	dspf2N.SetLogger(p.logger)
+	return dspfN, dspf2N, nil
+}
@@ -0,0 +1,50 @@
+package pcg
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestDistributeEval(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+	expected, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+
+	// A local worker and a remote worker served via net/rpc
+	local, err := pcg.NewLocalWorker()
+	assert.Nil(t, err)
+	served, err := pcg.NewLocalWorker()
+	assert.Nil(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	go dspf.ServeWorker(listener, served)
+	remote, err := dspf.DialWorker("tcp", listener.Addr().String())
+	assert.Nil(t, err)
+	defer remote.Close()
+
+	assert.Nil(t, pcg.DistributeEval([]dspf.Worker{local, remote}, 2))
+	actual, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	for _, i := range []int{0, 17, 63} {
+		expectedTuple, actualTuple := expected.GenBBSPlusTuple(ring.Roots[i]), actual.GenBBSPlusTuple(ring.Roots[i])
+		expectedTuple.Tag, actualTuple.Tag = nil, nil // The tags hold the time of the evaluation
+		assert.Equal(t, expectedTuple, actualTuple)
+	}
+
+	result, err := pcg.SimulateAllParties(seeds, randPolys, ring, 0)
+	assert.Nil(t, err)
+	assert.True(t, result.Correct())
+
+	assert.NotNil(t, pcg.DistributeEval([]dspf.Worker{local}, 7))
+	assert.NotNil(t, pcg.DistributeEval(nil, 1))
+}
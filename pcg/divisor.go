@@ -0,0 +1,211 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+)
+
+// sparseDivisorTerms is the maximal amount of terms of a divisor that is reduced by poly.Polynomial.Mod, which
+// subtracts the divisor term by term. Divisors with more terms are reduced via the Newton inverse series.
+const sparseDivisorTerms = 16
+
+// reduction is the strategy of PreparedDivisor.Reduce.
+type reduction int
+
+const (
+	reduceCyclotomic reduction = iota // reduceCyclotomic folds the coefficients of the dividend for div = x^m + 1.
+	reduceSparse                      // reduceSparse reduces via poly.Polynomial.Mod.
+	reduceNewton                      // reduceNewton divides via the precomputed inverse series of the reversed divisor.
+)
+
+// PreparedDivisor is a divisor of Eval along with the precomputations of its reduction, s.t. they are computed once
+// instead of per call. The divisor x^m + 1 of a ring (see Ring.Prepared) is reduced by folding its coefficients,
+// sparse divisors term by term and dense divisors via the Newton inverse series of the reversed divisor.
+// A PreparedDivisor is immutable and may be shared by concurrent evaluations.
+type PreparedDivisor struct {
+	div            *poly.Polynomial
+	degree         int
+	strategy       reduction
+	leadingInverse *bls12381.Fr   // leadingInverse is the inverse of the leading coefficient of div.
+	inverseSeries  []*bls12381.Fr // inverseSeries is the inverse of the reversed div modulo x^degree (reduceNewton only).
+	digest         [32]byte       // digest is the digest of div, which identifies the ring of seeds (see checkSeedParams).
+	ring           *Ring          // ring is the ring the divisor was prepared for, nil for custom divisors.
+}
+
+// NewPreparedDivisor prepares the reduction modulo the given divisor, which must be of positive degree.
+// The divisor must not be modified afterward.
+func NewPreparedDivisor(div *poly.Polynomial) (*PreparedDivisor, error) {
+	if div == nil || div.IsZero() {
+		return nil, poly.ErrDivisionByZero
+	}
+	degree, err := div.Degree()
+	if err != nil {
+		return nil, err
+	}
+	if degree == 0 {
+		return nil, fmt.Errorf("the divisor must be of positive degree")
+	}
+
+	d := &PreparedDivisor{div: div, degree: degree, leadingInverse: bls12381.NewFr(), digest: div.Digest()}
+	d.leadingInverse.Inverse(div.Coefficients[degree])
+	switch {
+	case isCyclotomicDivisor(div, degree):
+		d.strategy = reduceCyclotomic
+	case div.AmountOfCoefficients() <= sparseDivisorTerms:
+		d.strategy = reduceSparse
+	default:
+		d.strategy = reduceNewton
+		reversed, err := div.Dense(degree + 1)
+		if err != nil {
+			return nil, err
+		}
+		reverseFr(reversed)
+		if d.inverseSeries, err = inverseSeries(reversed, d.leadingInverse, degree); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// isCyclotomicDivisor reports whether div is x^degree + 1.
+func isCyclotomicDivisor(div *poly.Polynomial, degree int) bool {
+	one := bls12381.NewFr().One()
+	return div.AmountOfCoefficients() == 2 && div.Coefficients[degree].Equal(one) &&
+		div.Coefficients[0] != nil && div.Coefficients[0].Equal(one)
+}
+
+// Polynomial returns the divisor.
+func (d *PreparedDivisor) Polynomial() *poly.Polynomial {
+	return d.div
+}
+
+// Degree returns the degree of the divisor, i.e. the remainders have fewer coefficients.
+func (d *PreparedDivisor) Degree() int {
+	return d.degree
+}
+
+// Cyclotomic reports whether the divisor is x^m + 1, whose reduction folds the coefficients of the dividend.
+func (d *PreparedDivisor) Cyclotomic() bool {
+	return d.strategy == reduceCyclotomic
+}
+
+// Digest returns the digest of the divisor (see poly.Polynomial.Digest).
+func (d *PreparedDivisor) Digest() [32]byte {
+	return d.digest
+}
+
+// Ring returns the ring the divisor was prepared for, e.g. to access its roots, or nil for custom divisors.
+func (d *PreparedDivisor) Ring() *Ring {
+	return d.ring
+}
+
+// Reduce returns the remainder of p divided by the divisor. It equals p.Mod(div), but uses the precomputations.
+func (d *PreparedDivisor) Reduce(p *poly.Polynomial) (*poly.Polynomial, error) {
+	degree, err := p.Degree()
+	if err != nil {
+		return nil, err
+	}
+	if degree < d.degree {
+		return p.DeepCopy(), nil
+	}
+	switch d.strategy {
+	case reduceCyclotomic:
+		return d.fold(p), nil
+	case reduceNewton:
+		if degree-d.degree < len(d.inverseSeries) {
+			return d.divideNewton(p, degree)
+		}
+	}
+	return p.Mod(d.div) // sparse divisors and dividends beyond the precision of the inverse series
+}
+
+// fold reduces p modulo x^m + 1, i.e. the coefficient of x^e is added to x^(e mod m) with the sign (-1)^(e/m).
+func (d *PreparedDivisor) fold(p *poly.Polynomial) *poly.Polynomial {
+	values := make([]bls12381.Fr, d.degree)
+	p.ForEachTerm(func(exp int, coeff *bls12381.Fr) {
+		target := &values[exp%d.degree]
+		if (exp/d.degree)%2 == 0 {
+			target.Add(target, coeff)
+		} else {
+			target.Sub(target, coeff)
+		}
+	})
+	remainder := make([]*bls12381.Fr, d.degree)
+	for i := range values {
+		remainder[i] = &values[i]
+	}
+	return poly.NewFromFrOwned(remainder)
+}
+
+// divideNewton reduces p of the given degree via the quotient rev(q) = rev(p) * rev(div)^-1 mod x^(deg p - deg div + 1).
+func (d *PreparedDivisor) divideNewton(p *poly.Polynomial, degree int) (*poly.Polynomial, error) {
+	k := degree - d.degree + 1 // the amount of coefficients of the quotient
+	dividend, err := p.Dense(degree + 1)
+	if err != nil {
+		return nil, err
+	}
+	reversed := make([]*bls12381.Fr, k)
+	for i := range reversed {
+		reversed[i] = dividend[degree-i]
+	}
+	quotient, err := mulTruncated(reversed, d.inverseSeries[:k], k)
+	if err != nil {
+		return nil, err
+	}
+	reverseFr(quotient)
+
+	product, err := poly.Mul(d.div, poly.NewFromFrOwned(quotient))
+	if err != nil {
+		return nil, err
+	}
+	remainder := p.DeepCopy()
+	remainder.Sub(product)
+	return remainder, nil
+}
+
+// inverseSeries returns the inverse of the power series f modulo x^precision via Newton iteration, i.e.
+// g_2k = g_k * (2 - f * g_k) mod x^2k, given the inverse of f[0].
+func inverseSeries(f []*bls12381.Fr, constantInverse *bls12381.Fr, precision int) ([]*bls12381.Fr, error) {
+	g := []*bls12381.Fr{bls12381.NewFr().Set(constantInverse)}
+	two := bls12381.NewFr()
+	two.Double(bls12381.NewFr().One())
+	for len(g) < precision {
+		next := min(2*len(g), precision)
+		e, err := mulTruncated(f[:min(next, len(f))], g, next)
+		if err != nil {
+			return nil, err
+		}
+		for i := range e {
+			e[i].Neg(e[i])
+		}
+		e[0].Add(e[0], two)
+		if g, err = mulTruncated(g, e, next); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// mulTruncated returns the first length coefficients of the product of the dense polynomials a and b.
+func mulTruncated(a, b []*bls12381.Fr, length int) ([]*bls12381.Fr, error) {
+	product, err := poly.Mul(poly.NewFromFr(a), poly.NewFromFr(b))
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*bls12381.Fr, length)
+	for i := range values {
+		values[i] = bls12381.NewFr()
+		if coeff, ok := product.Coefficients[i]; ok {
+			values[i].Set(coeff)
+		}
+	}
+	return values, nil
+}
+
+// reverseFr reverses the slice in place.
+func reverseFr(values []*bls12381.Fr) {
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+}
@@ -0,0 +1,84 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestPreparedDivisorReduce(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	cyclotomic, err := poly.NewCyclotomicPolynomial(big.NewInt(64))
+	assert.Nil(t, err)
+	sparse, err := poly.NewSparse([]*bls12381.Fr{bls12381.NewFr().One(), bls12381.NewFr().One(), bls12381.NewFr().One()},
+		[]*big.Int{big.NewInt(32), big.NewInt(5), big.NewInt(0)})
+	assert.Nil(t, err)
+	dense, err := poly.NewRandomPolynomial(rng, 33) // 33 coefficients
+	assert.Nil(t, err)
+
+	for _, test := range []struct {
+		div        *poly.Polynomial
+		cyclotomic bool
+	}{{cyclotomic, true}, {sparse, false}, {dense, false}} {
+		prepared, err := NewPreparedDivisor(test.div)
+		assert.Nil(t, err)
+		assert.Equal(t, 32, prepared.Degree())
+		assert.Equal(t, test.cyclotomic, prepared.Cyclotomic())
+		assert.Equal(t, test.div.Digest(), prepared.Digest())
+		assert.Nil(t, prepared.Ring())
+
+		// Dividends below the degree, within the precision of the inverse series and beyond it
+		for _, degree := range []int{10, 32, 40, 62, 63, 100} {
+			p, err := poly.NewRandomPolynomial(rng, degree)
+			assert.Nil(t, err)
+			expected, err := p.Mod(test.div)
+			assert.Nil(t, err)
+			remainder, err := prepared.Reduce(p)
+			assert.Nil(t, err)
+			assert.True(t, expected.Equal(remainder), "remainders differ for degree %d", degree)
+		}
+	}
+}
+
+func TestNewPreparedDivisorInvalid(t *testing.T) {
+	_, err := NewPreparedDivisor(poly.NewEmpty())
+	assert.ErrorIs(t, err, poly.ErrDivisionByZero)
+	constant, err := poly.NewSparse([]*bls12381.Fr{bls12381.NewFr().One()}, []*big.Int{big.NewInt(0)})
+	assert.Nil(t, err)
+	_, err = NewPreparedDivisor(constant)
+	assert.NotNil(t, err)
+}
+
+func TestRingPrepared(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+	assert.True(t, ring.Prepared().Cyclotomic())
+	assert.Equal(t, ring, ring.Prepared().Ring())
+
+	data, err := ring.Serialize()
+	assert.Nil(t, err)
+	deserialized := &Ring{}
+	assert.Nil(t, deserialized.Deserialize(data))
+	assert.Equal(t, ring.Prepared().Digest(), deserialized.Prepared().Digest())
+
+	// A custom divisor of the same ring evaluates like the prepared divisor of the ring
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	custom, err := NewPreparedDivisor(ring.Div)
+	assert.Nil(t, err)
+	expected, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	generator, err := pcg.EvalCombined(seeds[0], randPolys, custom)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, generator)
+
+	_, err = pcg.EvalCombined(seeds[0], randPolys, nil)
+	assert.NotNil(t, err)
+}
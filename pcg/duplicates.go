@@ -0,0 +1,115 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"math/big"
+)
+
+// maxResampleAttempts bounds the attempts to resample an exponent vector until it is collision-free.
+const maxResampleAttempts = 1000
+
+// DuplicatePolicy defines how TrustedSeedGen handles duplicate special points of the OLE correlations, i.e. sums of
+// exponents omega_k + o_l that collide for different (k, l). The DSPFs of the PCG reject duplicate special points.
+type DuplicatePolicy int
+
+const (
+	// MergeDuplicates merges the duplicate special points of an OLE correlation into a single point holding the sum of
+	// their non-zero elements. Each merged point is replaced by a dummy point with a zero non-zero element, s.t. the
+	// amount of DPF keys does not reveal collisions. This is the default policy.
+	MergeDuplicates DuplicatePolicy = iota
+	// ResampleExponents resamples the exponents of the OLE correlations until all their sums are collision-free. Note
+	// that this conditions the noise on the absence of collisions and requires t^4 to be small compared to 2^N.
+	ResampleExponents
+)
+
+// String returns the name of the policy.
+func (d DuplicatePolicy) String() string {
+	switch d {
+	case MergeDuplicates:
+		return "MergeDuplicates"
+	case ResampleExponents:
+		return "ResampleExponents"
+	default:
+		return fmt.Sprintf("DuplicatePolicy(%d)", int(d))
+	}
+}
+
+// SetDuplicatePolicy sets how TrustedSeedGen handles duplicate special points of the OLE correlations.
+// The policy does not apply to regular noise, whose DSPFs assign each sum to the segment of its position in the outer
+// sum (see UseRegularNoise) and thereby add up duplicates by construction.
+func (p *PCG) SetDuplicatePolicy(policy DuplicatePolicy) error {
+	if policy != MergeDuplicates && policy != ResampleExponents {
+		return fmt.Errorf("unknown duplicate policy %v", policy)
+	}
+	p.duplicatePolicy = policy
+	return nil
+}
+
+// mergeDuplicatePoints merges duplicate special points into the first occurrence by adding up their non-zero elements.
+// Each merged point is replaced by a distinct dummy point within [0, bound) with a zero non-zero element, s.t. the
+// amount of points is unchanged and the sparse vector they represent is the same.
+func (p *PCG) mergeDuplicatePoints(points []*big.Int, values []*bls12381.Fr, bound *big.Int) ([]*big.Int, []*bls12381.Fr, error) {
+	if !hasDuplicates(points) {
+		return points, values, nil
+	}
+	if bound.Cmp(big.NewInt(int64(len(points)))) < 0 {
+		return nil, nil, fmt.Errorf("the domain is too small for %d distinct special points", len(points))
+	}
+
+	first := make(map[string]int, len(points))
+	merged := make([]*big.Int, 0, len(points))
+	mergedValues := make([]*bls12381.Fr, 0, len(points))
+	for k, point := range points {
+		if i, ok := first[point.String()]; ok {
+			mergedValues[i].Add(mergedValues[i], values[k])
+			continue
+		}
+		first[point.String()] = len(merged)
+		merged = append(merged, point)
+		mergedValues = append(mergedValues, bls12381.NewFr().Set(values[k]))
+	}
+	for len(merged) < len(points) {
+		dummy := p.rng.domain(domainDummyPoints).Int(bound)
+		if _, ok := first[dummy.String()]; ok {
+			continue
+		}
+		first[dummy.String()] = len(merged)
+		merged = append(merged, dummy)
+		mergedValues = append(mergedValues, bls12381.NewFr().Zero())
+	}
+	return merged, mergedValues, nil
+}
+
+// resampleCollisions resamples the exponent vectors o[j][s] until the sums with all omega[i][r] (i != j) are
+// collision-free, i.e. until the OLE correlations of omega and o have no duplicate special points.
+func (p *PCG) resampleCollisions(omega, o *ExponentMatrix) error {
+	for j := 0; j < o.Parties(); j++ {
+		for s := 0; s < o.Blocks(); s++ {
+			for attempt := 0; p.hasCollisions(omega, o.At(j, s), j); attempt++ {
+				if attempt == maxResampleAttempts {
+					return fmt.Errorf("failed to sample collision-free exponents within %d attempts", maxResampleAttempts)
+				}
+				o.Set(j, s, p.sampleExponentVector())
+			}
+		}
+	}
+	return nil
+}
+
+// hasCollisions returns whether the outer sum of the exponent vector of party j with any omega.At(i, r) (i != j)
+// holds duplicates.
+func (p *PCG) hasCollisions(omega *ExponentMatrix, vec []*big.Int, j int) bool {
+	arena := newOuterArena(p.t * len(vec))
+	for i := 0; i < omega.Parties(); i++ {
+		if i == j {
+			continue
+		}
+		for r := 0; r < omega.Blocks(); r++ {
+			if hasDuplicates(arena.outerSum(omega.At(i, r), vec)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestMergeDuplicatePoints(t *testing.T) {
+	pcg, err := NewPCG(128, 5, 2, 2, 2, 2)
+	assert.Nil(t, err)
+
+	one, two := bls12381.NewFr().One(), bls12381.NewFr().One()
+	two.Double(two)
+	points := []*big.Int{big.NewInt(3), big.NewInt(9), big.NewInt(3), big.NewInt(3)}
+	values := []*bls12381.Fr{one, two, two, one}
+	merged, mergedValues, err := pcg.mergeDuplicatePoints(points, values, big.NewInt(16))
+	assert.Nil(t, err)
+	assert.Len(t, merged, len(points))
+	assert.False(t, hasDuplicates(merged))
+
+	// The merged points represent the same sparse vector
+	vector := make(map[int64]*bls12381.Fr)
+	for k, point := range merged {
+		assert.True(t, point.Sign() >= 0 && point.Int64() < 16)
+		vector[point.Int64()] = mergedValues[k]
+	}
+	four := bls12381.NewFr().Set(two)
+	four.Double(four)
+	assert.True(t, vector[3].Equal(four))
+	assert.True(t, vector[9].Equal(two))
+	zeros := 0
+	for _, value := range vector {
+		if value.IsZero() {
+			zeros++
+		}
+	}
+	assert.Equal(t, 2, zeros)
+	assert.True(t, values[0].IsOne()) // the input is not modified
+
+	// Points without duplicates are kept
+	unique := []*big.Int{big.NewInt(1), big.NewInt(2)}
+	kept, _, err := pcg.mergeDuplicatePoints(unique, values[:2], big.NewInt(16))
+	assert.Nil(t, err)
+	assert.Equal(t, unique, kept)
+
+	_, _, err = pcg.mergeDuplicatePoints(points, values, big.NewInt(3))
+	assert.NotNil(t, err)
+}
+
+func TestDuplicatePolicies(t *testing.T) {
+	for _, policy := range []DuplicatePolicy{MergeDuplicates, ResampleExponents} {
+		// Small domains make collisions of the sums of exponents likely
+		pcg, err := NewPCG(128, 7, 2, 2, 2, 4)
+		assert.Nil(t, err)
+		assert.Nil(t, pcg.SetDuplicatePolicy(policy))
+		seeds, secrets, err := pcg.TrustedSeedGenAudited()
+		assert.Nil(t, err, policy.String())
+		if policy == ResampleExponents {
+			for j := 0; j < pcg.n; j++ {
+				for s := 0; s < pcg.c; s++ {
+					assert.False(t, pcg.hasCollisions(secrets.AOmega, secrets.SPhi.At(j, s), j))
+					assert.False(t, pcg.hasCollisions(secrets.AOmega, secrets.EEta.At(j, s), j))
+				}
+			}
+		}
+		assert.Nil(t, pcg.Audit(seeds[0], secrets), policy.String())
+
+		randPolys, err := pcg.PickRandomPolynomials()
+		assert.Nil(t, err)
+		ring, err := pcg.GetLazyRing()
+		assert.Nil(t, err)
+		result, err := pcg.SimulateAllParties(seeds, randPolys, ring, 0)
+		assert.Nil(t, err)
+		assert.True(t, result.Correct(), policy.String())
+	}
+
+	pcg, err := NewPCG(128, 8, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	assert.NotNil(t, pcg.SetDuplicatePolicy(DuplicatePolicy(2)))
+	assert.Equal(t, "DuplicatePolicy(2)", DuplicatePolicy(2).String())
+}
@@ -0,0 +1,85 @@
+package pcg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"math/big"
+)
+
+// epochDomainSeparator separates the derivation of the random polynomials of epochs from other uses of the PRF.
+const epochDomainSeparator = "pcg-bbs-plus/random-polynomials/epoch"
+
+// MaxEpochs returns the amount of epochs (batches) that can be derived from a single seed, i.e. c-1.
+func (p *PCG) MaxEpochs() uint64 {
+	return uint64(p.c - 1)
+}
+
+// PickRandomPolynomialsForEpoch derives the public random polynomials of the given epoch (batch index) of the setup
+// with the given digest (see Seed.SetupDigest). As for PickRandomPolynomials, the last polynomial is always 1.
+// Evaluating the same seed with the random polynomials of different epochs yields independent batches of 2^N tuples,
+// s.t. generating more tuples does not require a new seed generation.
+// The polynomials are derived deterministically via a PRF from the parameters of the PCG, the setup digest and the
+// epoch, hence all parties of a setup derive the same polynomials without interaction.
+//
+// Security caveats:
+//   - The polynomials are public. Keying them with the setup digest only ensures that distinct setups with the same
+//     parameters use distinct polynomials and that the polynomials of a setup are fixed only once the dealer committed
+//     to the sharing of sk.
+//   - Each batch reveals another linear combination of the same sparse polynomials. Given k batches of one seed, an
+//     adversary can eliminate k-1 of them, i.e. security relies on the module-LPN assumption with c-k+1 instead of c
+//     polynomials. Hence, at most MaxEpochs epochs are allowed and c and t must be chosen for the intended amount.
+//     In particular, for c = 2 only a single epoch is allowed.
+//   - An epoch must never be re-used for a different purpose, as re-using an epoch re-produces the same tuples.
+func (p *PCG) PickRandomPolynomialsForEpoch(setup [32]byte, epoch uint64) ([]*poly.Polynomial, error) {
+	if setup == ([32]byte{}) {
+		return nil, fmt.Errorf("the setup digest must not be zero")
+	}
+	if epoch >= p.MaxEpochs() {
+		return nil, fmt.Errorf("epoch %d exceeds the maximum amount of epochs %d for c = %d", epoch, p.MaxEpochs(), p.c)
+	}
+
+	groupOrder, _ := new(big.Int).SetString(poly.FrModulus, 16)
+	numElements, err := p.randomPolynomialLength()
+	if err != nil {
+		return nil, err
+	}
+	paramsDigest := p.paramsDigest()
+
+	polys := make([]*poly.Polynomial, p.c)
+	for r := 0; r < p.c-1; r++ {
+		// Derive a key for each polynomial and expand it to 64 bytes per coefficient.
+		// Reducing 512 bit modulo the group order yields a statistically close to uniform element.
+		stream := dpf.PRG(epochKey(paramsDigest, setup, epoch, r), 64*numElements)
+		coefficients := make([]*bls12381.Fr, numElements)
+		val := new(big.Int)
+		for i := range coefficients {
+			val.SetBytes(stream[64*i : 64*(i+1)])
+			val.Mod(val, groupOrder)
+			coefficients[i] = bls12381.NewFr().FromBytes(val.Bytes())
+		}
+		polys[r] = poly.NewFromFrOwned(coefficients)
+	}
+	// Set last polynomial to 1
+	one, err := poly.NewSparse([]*bls12381.Fr{bls12381.NewFr().One()}, []*big.Int{big.NewInt(0)}) // = 1
+	if err != nil {
+		return nil, err
+	}
+	polys[p.c-1] = one
+
+	return polys, nil
+}
+
+// epochKey returns the PRF key for the r-th random polynomial of the given epoch of the setup.
+func epochKey(paramsDigest, setup [32]byte, epoch uint64, r int) []byte {
+	h := sha256.New()
+	h.Write([]byte(epochDomainSeparator))
+	h.Write(paramsDigest[:])
+	h.Write(setup[:])
+	h.Write(binary.BigEndian.AppendUint64(nil, epoch))
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(r)))
+	return h.Sum(nil)
+}
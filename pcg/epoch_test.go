@@ -0,0 +1,82 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPickRandomPolynomialsForEpoch(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 3, 4) // Small lpn parameters for testing.
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), pcg.MaxEpochs())
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	setup, err := seeds[0].SetupDigest()
+	assert.Nil(t, err)
+
+	// All parties of a setup derive the same polynomials
+	other, err := NewPCG(128, 6, 2, 2, 3, 4)
+	assert.Nil(t, err)
+	otherSetup, err := seeds[1].SetupDigest()
+	assert.Nil(t, err)
+	assert.Equal(t, setup, otherSetup)
+	epoch0, err := pcg.PickRandomPolynomialsForEpoch(setup, 0)
+	assert.Nil(t, err)
+	assert.Len(t, epoch0, 3)
+	epoch0Other, err := other.PickRandomPolynomialsForEpoch(otherSetup, 0)
+	assert.Nil(t, err)
+	for r := range epoch0 {
+		assert.True(t, epoch0[r].Equal(epoch0Other[r]))
+	}
+
+	// Another setup with the same parameters derives other polynomials
+	otherSeeds, err := other.TrustedSeedGen()
+	assert.Nil(t, err)
+	otherSetup, err = otherSeeds[0].SetupDigest()
+	assert.Nil(t, err)
+	assert.NotEqual(t, setup, otherSetup)
+	epoch0Other, err = other.PickRandomPolynomialsForEpoch(otherSetup, 0)
+	assert.Nil(t, err)
+	assert.False(t, epoch0[0].Equal(epoch0Other[0]))
+	_, err = pcg.PickRandomPolynomialsForEpoch([32]byte{}, 0)
+	assert.NotNil(t, err)
+
+	epoch1, err := pcg.PickRandomPolynomialsForEpoch(setup, 1)
+	assert.Nil(t, err)
+	for r := 0; r < pcg.c-1; r++ {
+		assert.False(t, epoch0[r].Equal(epoch1[r]))
+	}
+	assert.True(t, epoch0[pcg.c-1].Equal(epoch1[pcg.c-1])) // = 1
+
+	_, err = pcg.PickRandomPolynomialsForEpoch(setup, pcg.MaxEpochs())
+	assert.NotNil(t, err)
+
+	// The correlations hold in each epoch, while the batches differ
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+	root := ring.Roots[11]
+	var aShares []*bls12381.Fr
+	for _, randPolys := range [][]*poly.Polynomial{epoch0, epoch1} {
+		eval0, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		eval1, err := pcg.EvalCombined(seeds[1], randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		tuple0 := eval0.GenBBSPlusTuple(root)
+		tuple1 := eval1.GenBBSPlusTuple(root)
+
+		a := bls12381.NewFr()
+		a.Add(tuple0.AShare, tuple1.AShare)
+		s := bls12381.NewFr()
+		s.Add(tuple0.SShare, tuple1.SShare)
+		alpha := bls12381.NewFr()
+		alpha.Add(tuple0.AlphaShare, tuple1.AlphaShare)
+		as := bls12381.NewFr()
+		as.Mul(a, s)
+		assert.True(t, alpha.Equal(as))
+		aShares = append(aShares, tuple0.AShare)
+	}
+	assert.False(t, aShares[0].Equal(aShares[1]))
+}
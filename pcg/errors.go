@@ -0,0 +1,117 @@
+package pcg
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// SpecialPointOutOfDomainError is returned if a special point to embed falls outside the domain of the DSPF.
+// Such a point would silently not be represented by the DSPF keys and thereby break the correlation.
+type SpecialPointOutOfDomainError struct {
+	Point *big.Int // Point is the offending special point
+	Bound *big.Int // Bound is the exclusive upper bound of the domain
+}
+
+func (e *SpecialPointOutOfDomainError) Error() string {
+	return fmt.Sprintf("special point %s is outside of the domain [0, %s)", e.Point, e.Bound)
+}
+
+// ParameterMismatchError is returned by Eval if the seed was generated for other parameters than the parameters of the
+// PCG instance or if the ring divisor differs from the divisor of the ring of the seed.
+type ParameterMismatchError struct {
+	Party     int    // Party is the index of the party holding the seed.
+	Parameter string // Parameter is the name of the first mismatching parameter, e.g. "N" or "ring".
+	Seed      any    // Seed is the value of the parameter in the seed.
+	PCG       any    // PCG is the value of the parameter in the PCG instance.
+}
+
+func (e *ParameterMismatchError) Error() string {
+	return fmt.Sprintf("seed of party %d was generated for %s=%v but the PCG uses %s=%v", e.Party, e.Parameter, e.Seed, e.Parameter, e.PCG)
+}
+
+// Phase identifies a phase of the Eval pipeline.
+type Phase int
+
+const (
+	PhaseVOLE       Phase = iota // PhaseVOLE is the expansion of the VOLE correlation sk*a (utilde).
+	PhaseOLE1                    // PhaseOLE1 is the expansion of the first OLE correlation a*s (w).
+	PhaseOLE2                    // PhaseOLE2 is the expansion of the second OLE correlation a*e (m).
+	PhaseFinalShare              // PhaseFinalShare is the evaluation of the final shares via the random polynomials.
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseVOLE:
+		return "VOLE"
+	case PhaseOLE1:
+		return "OLE1"
+	case PhaseOLE2:
+		return "OLE2"
+	case PhaseFinalShare:
+		return "FinalShare"
+	default:
+		return fmt.Sprintf("Phase(%d)", int(p))
+	}
+}
+
+// PhaseError is returned by Eval if a sub-evaluation fails. It identifies the failed sub-evaluation, s.t. it can be
+// retried without repeating the whole evaluation. Coordinates that do not apply to the failure are -1.
+type PhaseError struct {
+	Phase        Phase // Phase is the phase of the Eval pipeline that failed.
+	Party        int   // Party is the index of the evaluating party.
+	Counterparty int   // Counterparty is the index of the counterparty of the failed DSPF evaluation.
+	R, S         int   // R and S are the indices of the failed polynomial (S only applies to OLE correlations).
+	Err          error // Err is the underlying error.
+}
+
+func (e *PhaseError) Error() string {
+	coordinates := ""
+	if e.Counterparty >= 0 {
+		coordinates += fmt.Sprintf(", counterparty %d", e.Counterparty)
+	}
+	if e.R >= 0 {
+		coordinates += fmt.Sprintf(", r=%d", e.R)
+	}
+	if e.S >= 0 {
+		coordinates += fmt.Sprintf(", s=%d", e.S)
+	}
+	return fmt.Sprintf("eval phase %s failed for party %d%s: %v", e.Phase, e.Party, coordinates, e.Err)
+}
+
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+// coordinateError records the coordinates of a failed sub-evaluation deep inside the Eval pipeline.
+// It is transparent in error messages, as the coordinates are reported by the PhaseError it ends up in.
+type coordinateError struct {
+	counterparty, r, s int
+	err                error
+}
+
+func (e *coordinateError) Error() string {
+	return e.err.Error()
+}
+
+func (e *coordinateError) Unwrap() error {
+	return e.err
+}
+
+// newPhaseError returns a PhaseError for err, which picks up the coordinates recorded in err (if any).
+func newPhaseError(err error, phase Phase, party int) *PhaseError {
+	phaseErr := &PhaseError{Phase: phase, Party: party, Counterparty: -1, R: -1, S: -1, Err: err}
+	var coordinateErr *coordinateError
+	if errors.As(err, &coordinateErr) {
+		phaseErr.Counterparty = coordinateErr.counterparty
+		phaseErr.R = coordinateErr.r
+		phaseErr.S = coordinateErr.s
+	}
+	return phaseErr
+}
+
+// withCounterparty returns a PhaseError with the counterparty set.
+func (e *PhaseError) withCounterparty(counterparty int) *PhaseError {
+	e.Counterparty = counterparty
+	return e
+}
@@ -0,0 +1,84 @@
+package pcg
+
+import (
+	"errors"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPhaseErrorOfFailedDSPFEvaluation(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	// Break the DSPF key of the second OLE correlation between party 0 and party 2 at (r, s) = (1, 0)
+	seeds[0].V.AtOLE(0, 2, 1, 0).Key0.DPFKeys[0] = optreedpf.EmptyKey()
+
+	_, err = pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+	var phaseErr *PhaseError
+	assert.True(t, errors.As(err, &phaseErr))
+	assert.Equal(t, PhaseOLE2, phaseErr.Phase)
+	assert.Equal(t, 0, phaseErr.Party)
+	assert.Equal(t, 2, phaseErr.Counterparty)
+	assert.Equal(t, 1, phaseErr.R)
+	assert.Equal(t, 0, phaseErr.S)
+	assert.Contains(t, err.Error(), "eval phase OLE2 failed for party 0, counterparty 2, r=1, s=0")
+
+	_, err = pcg.EvalSeparate(seeds[0], randPolys, ring.Prepared())
+	assert.True(t, errors.As(err, &phaseErr))
+	assert.Equal(t, PhaseOLE2, phaseErr.Phase)
+	assert.Equal(t, 2, phaseErr.Counterparty)
+}
+
+func TestPhaseErrorOfFailedFinalShare(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	// A custom divisor is multiplied in coefficient form instead of in the evaluation domain of the ring
+	div, err := NewPreparedDivisor(ring.Div)
+	assert.Nil(t, err)
+	session, err := pcg.NewEvalSession(randPolys, div)
+	assert.Nil(t, err)
+
+	// Fail the first multiplication of the final shares
+	errInjected := errors.New("injected")
+	var failed atomic.Bool
+	mulPoly = func(p, q *poly.Polynomial) (*poly.Polynomial, error) {
+		if failed.CompareAndSwap(false, true) {
+			return nil, errInjected
+		}
+		return poly.Mul(p, q)
+	}
+	defer func() { mulPoly = poly.Mul }()
+
+	_, err = session.EvalSeed(seeds[1])
+	assert.ErrorIs(t, err, errInjected)
+	var phaseErr *PhaseError
+	assert.True(t, errors.As(err, &phaseErr))
+	assert.Equal(t, PhaseFinalShare, phaseErr.Phase)
+	assert.Equal(t, 1, phaseErr.Party)
+	assert.Equal(t, -1, phaseErr.Counterparty)
+	assert.True(t, phaseErr.R >= 0 && phaseErr.R < 2)
+	assert.Equal(t, -1, phaseErr.S)
+}
+
+func TestPhaseString(t *testing.T) {
+	assert.Equal(t, "VOLE", PhaseVOLE.String())
+	assert.Equal(t, "OLE1", PhaseOLE1.String())
+	assert.Equal(t, "OLE2", PhaseOLE2.String())
+	assert.Equal(t, "FinalShare", PhaseFinalShare.String())
+	assert.Equal(t, "Phase(7)", Phase(7).String())
+}
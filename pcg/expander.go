@@ -0,0 +1,274 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+)
+
+// The expander is the core of the PCG: it expands the compressed DSPF keys of a party into its shares of the
+// (V)OLE correlations over the polynomial ring. The methods below do not depend on the Seed struct, s.t. they can be
+// reused to build other correlations (e.g. OT extension) on top of the same DSPF key structure.
+//
+// All methods share the following preconditions:
+//   - index is the index of the evaluating party and must be within [0, n).
+//   - u and v hold exactly c polynomials (the party's t-sparse polynomials).
+//   - keys are the DSPF key matrices of all parties as generated by TrustedSeedGen, indexed by [i][j][r] (VOLE) or
+//     [i][j][r][s] (OLE), where the key pair at [i][j] embeds the correlation between party i and party j.
+//
+// The inputs are not modified. Failed DSPF evaluations report the counterparty and the indices r and s of the failed
+// polynomial to the PhaseError returned by Eval.
+
+// ExpandVOLE expands the VOLE correlation sk*u of party index for the n-out-of-n setting.
+// It returns c polynomials utilde[r] = u[r]*sk + sum_{j != index} (DSPF(keys[index][j][r]) + DSPF(keys[j][index][r])).
+// The resulting polynomials are of degree < 2^N and not yet reduced.
+func (p *PCG) ExpandVOLE(u []*poly.Polynomial, sk *bls12381.Fr, keys *DSPFKeyMatrix, index int) ([]*poly.Polynomial, error) {
+	if err := p.checkExpanderInput(index, keys, false, u); err != nil {
+		return nil, err
+	}
+
+	utilde := make([]*poly.Polynomial, p.c)
+	for r := 0; r < p.c; r++ {
+		ur := u[r].MulByConstantInto(poly.NewEmpty(), sk) // u[r] * sk[i], we need unmodified u[r] later on
+		for j := 0; j < p.n; j++ {
+			if index != j {
+				eval0, err := p.dspfN.FullEvalFastAggregated(keys.At(index, j, r).Key0)
+				if err != nil {
+					return nil, &coordinateError{counterparty: j, r: r, s: -1, err: err}
+				}
+				ur.Add(poly.NewFromFrOwned(eval0))
+
+				eval1, err := p.dspfN.FullEvalFastAggregated(keys.At(j, index, r).Key1)
+				if err != nil {
+					return nil, &coordinateError{counterparty: j, r: r, s: -1, err: err}
+				}
+				ur.Add(poly.NewFromFrOwned(eval1))
+			}
+		}
+		utilde[r] = ur
+	}
+	return utilde, nil
+}
+
+// ExpandOLE expands the OLE correlation u*v of party index for the n-out-of-n setting.
+// It returns c*c polynomials w[r][s] = u[r]*v[s] + sum_{j != index} (DSPF(keys[index][j][r][s]) + DSPF(keys[j][index][r][s])).
+// The resulting polynomials are of degree < 2^(N+1) and not yet reduced.
+func (p *PCG) ExpandOLE(u, v []*poly.Polynomial, keys *DSPFKeyMatrix, index int) ([][]*poly.Polynomial, error) {
+	if err := p.checkExpanderInput(index, keys, true, u, v); err != nil {
+		return nil, err
+	}
+
+	w := make([][]*poly.Polynomial, p.c)
+	for r := 0; r < p.c; r++ {
+		w[r] = make([]*poly.Polynomial, p.c)
+		for s := 0; s < p.c; s++ {
+			var err error
+			w[r][s], err = poly.Mul(u[r], v[s]) // u an r are t-sparse -> t*t complexity
+			if err != nil {
+				return nil, &coordinateError{counterparty: -1, r: r, s: s, err: err}
+			}
+			if err := checkDegree(w[r][s], p.DegreeBudget().Product, "u[%d]*v[%d]", r, s); err != nil {
+				return nil, &coordinateError{counterparty: -1, r: r, s: s, err: err}
+			}
+			for j := 0; j < p.n; j++ {
+				if index != j { // Ony cross terms
+					eval0, err := p.dspf2N.FullEvalFastAggregated(keys.AtOLE(index, j, r, s).Key0)
+					if err != nil {
+						return nil, &coordinateError{counterparty: j, r: r, s: s, err: err}
+					}
+					w[r][s].Add(poly.NewFromFrOwned(eval0)) // N
+
+					eval1, err := p.dspf2N.FullEvalFastAggregated(keys.AtOLE(j, index, r, s).Key1)
+					if err != nil {
+						return nil, &coordinateError{counterparty: j, r: r, s: s, err: err}
+					}
+					w[r][s].Add(poly.NewFromFrOwned(eval1)) // N
+				}
+			}
+		}
+	}
+	return w, nil
+}
+
+// ExpandVOLESeparate expands the pairwise VOLE cross terms of party index for the tau-out-of-n setting.
+// The output is structured as [j][direction][r], where j is the counter-parties index, direction is 0 for forward
+// (keys[index][j]) and 1 for backward (keys[j][index]) and where r is in c.
+// The local term u*sk is not included. The entry at [index] is nil.
+func (p *PCG) ExpandVOLESeparate(keys *DSPFKeyMatrix, index int) ([][][]*poly.Polynomial, error) {
+	utilde, err := p.expandVOLESeparate(keys, index, nil)
+	if err != nil {
+		return nil, err
+	}
+	return utilde.Slice(), nil
+}
+
+// expandVOLESeparate implements ExpandVOLESeparate, restricted to the counterparties j with counterparties[j] set.
+// If counterparties is nil, all counterparties are included.
+func (p *PCG) expandVOLESeparate(keys *DSPFKeyMatrix, index int, counterparties []bool) (*tuplegen.PartyIndexed[[][]*poly.Polynomial], error) {
+	if err := p.checkExpanderInput(index, keys, false); err != nil {
+		return nil, err
+	}
+
+	utilde := tuplegen.NewPartyIndexed[[][]*poly.Polynomial](index, p.n)
+	for j := 0; j < p.n; j++ {
+		if index != j && (counterparties == nil || counterparties[j]) {
+			utildeJ := make([][]*poly.Polynomial, 2) // 0 is forward, 1 is backward
+			utildeJ[forwardDirection] = make([]*poly.Polynomial, p.c)
+			utildeJ[backwardDirection] = make([]*poly.Polynomial, p.c)
+			for r := 0; r < p.c; r++ {
+				eval0, err := p.dspfN.FullEvalFastAggregated(keys.At(index, j, r).Key0)
+				if err != nil {
+					return nil, &coordinateError{counterparty: j, r: r, s: -1, err: err}
+				}
+				utildeJ[forwardDirection][r] = poly.NewFromFrOwned(eval0)
+
+				eval1, err := p.dspfN.FullEvalFastAggregated(keys.At(j, index, r).Key1)
+				if err != nil {
+					return nil, &coordinateError{counterparty: j, r: r, s: -1, err: err}
+				}
+				utildeJ[backwardDirection][r] = poly.NewFromFrOwned(eval1)
+			}
+			utilde.Set(j, utildeJ)
+		}
+	}
+	return utilde, nil
+}
+
+// ExpandVOLELocal returns the local term usk[r] = u[r]*sk of the VOLE correlation of a party for the tau-out-of-n
+// setting, where sk is the Shamir share of the party. Together with the cross terms of ExpandVOLESeparate, it yields the
+// share of a*sk of any signer set, once the terms are weighted by the Lagrange coefficients of the signer set: the
+// local and backward terms by the coefficient of the party and each forward term by the coefficient of the counterparty
+// (see tuplegen.ForwardDirection). The resulting polynomials are of degree < 2^N and not yet reduced.
+func (p *PCG) ExpandVOLELocal(u []*poly.Polynomial, sk *bls12381.Fr) ([]*poly.Polynomial, error) {
+	if len(u) != p.c {
+		return nil, fmt.Errorf("amount of polynomials is %d but is expected to be c=%d", len(u), p.c)
+	}
+	if sk == nil {
+		return nil, fmt.Errorf("sk share must not be nil")
+	}
+	usk := make([]*poly.Polynomial, p.c)
+	for r := 0; r < p.c; r++ {
+		usk[r] = u[r].MulByConstantInto(poly.NewEmpty(), sk) // We need unmodified u[r] later on
+	}
+	return usk, nil
+}
+
+// ExpandOLESeparate expands the pairwise OLE cross terms of party index for the tau-out-of-n setting.
+// The first output is structured as [j][r][s], where j is the counter-parties index and r and s are in c.
+// Each entry holds the sum of both directions DSPF(keys[index][j][r][s]) + DSPF(keys[j][index][r][s]).
+// The entry at [index] is nil. The second output holds the local products u[r]*v[s].
+func (p *PCG) ExpandOLESeparate(u, v []*poly.Polynomial, keys *DSPFKeyMatrix, index int) ([][][]*poly.Polynomial, [][]*poly.Polynomial, error) {
+	w, uv, err := p.expandOLESeparate(u, v, keys, index, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w.Slice(), uv, nil
+}
+
+// expandOLESeparate implements ExpandOLESeparate, restricted to the counterparties j with counterparties[j] set.
+// If counterparties is nil, all counterparties are included.
+func (p *PCG) expandOLESeparate(u, v []*poly.Polynomial, keys *DSPFKeyMatrix, index int, counterparties []bool) (*tuplegen.PartyIndexed[[][]*poly.Polynomial], [][]*poly.Polynomial, error) {
+	if err := p.checkExpanderInput(index, keys, true, u, v); err != nil {
+		return nil, nil, err
+	}
+
+	// The local products do not depend on the counterparty, hence they are computed once.
+	uv := make([][]*poly.Polynomial, p.c)
+	for r := 0; r < p.c; r++ {
+		uv[r] = make([]*poly.Polynomial, p.c)
+		for s := 0; s < p.c; s++ {
+			var err error
+			uv[r][s], err = poly.Mul(u[r], v[s])
+			if err != nil {
+				return nil, nil, &coordinateError{counterparty: -1, r: r, s: s, err: err}
+			}
+			if err := checkDegree(uv[r][s], p.DegreeBudget().Product, "u[%d]*v[%d]", r, s); err != nil {
+				return nil, nil, &coordinateError{counterparty: -1, r: r, s: s, err: err}
+			}
+		}
+	}
+
+	w := tuplegen.NewPartyIndexed[[][]*poly.Polynomial](index, p.n)
+	for j := 0; j < p.n; j++ {
+		if index != j && (counterparties == nil || counterparties[j]) { // Ony cross terms
+			wJ := make([][]*poly.Polynomial, p.c)
+			for r := 0; r < p.c; r++ {
+				wJ[r] = make([]*poly.Polynomial, p.c)
+				for s := 0; s < p.c; s++ {
+					eval0, err := p.dspf2N.FullEvalFastAggregated(keys.AtOLE(index, j, r, s).Key0)
+					if err != nil {
+						return nil, nil, &coordinateError{counterparty: j, r: r, s: s, err: err}
+					}
+					wJ[r][s] = poly.NewFromFrOwned(eval0)
+
+					eval1, err := p.dspf2N.FullEvalFastAggregated(keys.AtOLE(j, index, r, s).Key1)
+					if err != nil {
+						return nil, nil, &coordinateError{counterparty: j, r: r, s: s, err: err}
+					}
+					wJ[r][s].Add(poly.NewFromFrOwned(eval1))
+				}
+			}
+			w.Set(j, wJ)
+		}
+	}
+	return w, uv, nil
+}
+
+// counterparties returns a mask of the counterparties of the party with the given index within the signer set.
+func (p *PCG) counterparties(index int, signerSet []int) ([]bool, error) {
+	counterparties := make([]bool, p.n)
+	ownIndexInSignerSet := false
+	for _, signer := range signerSet {
+		if signer < 0 || signer >= p.n {
+			return nil, fmt.Errorf("signer index %d is out of range [0, %d)", signer, p.n)
+		}
+		if signer == index {
+			ownIndexInSignerSet = true
+			continue
+		}
+		counterparties[signer] = true
+	}
+	if !ownIndexInSignerSet {
+		return nil, fmt.Errorf("signer set must contain the own index %d", index)
+	}
+	if len(signerSet) < 2 {
+		return nil, fmt.Errorf("signer set must contain at least one counterparty")
+	}
+	return counterparties, nil
+}
+
+// checkExpanderInput validates the preconditions shared by all expander methods. keys must be an OLE key matrix if ole
+// is set and a VOLE key matrix otherwise.
+func (p *PCG) checkExpanderInput(index int, keys *DSPFKeyMatrix, ole bool, polys ...[]*poly.Polynomial) error {
+	if index < 0 || index >= p.n {
+		return fmt.Errorf("party index %d is out of range [0, %d)", index, p.n)
+	}
+	for _, ps := range polys {
+		if len(ps) != p.c {
+			return fmt.Errorf("amount of polynomials is %d but is expected to be c=%d", len(ps), p.c)
+		}
+		for r, polynomial := range ps {
+			if polynomial == nil {
+				return fmt.Errorf("polynomial %d must not be nil", r)
+			}
+			if err := checkDegree(polynomial, p.DegreeBudget().Sparse, "sparse polynomial %d", r); err != nil {
+				return err
+			}
+		}
+	}
+
+	if keys == nil {
+		return fmt.Errorf("keys must not be nil")
+	}
+	if keys.IsOLE() != ole {
+		return fmt.Errorf("keys hold the pairs of another correlation (OLE: %t)", keys.IsOLE())
+	}
+	if keys.N() != p.n {
+		return fmt.Errorf("keys must hold n=%d parties but holds %d", p.n, keys.N())
+	}
+	if keys.C() != p.c {
+		return fmt.Errorf("keys must hold c=%d key pairs per party but holds %d", p.c, keys.C())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExpandVOLECorrelation(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+
+	uSum := make([]*poly.Polynomial, pcg.c)
+	utildeSum := make([]*poly.Polynomial, pcg.c)
+	sk := bls12381.NewFr().Zero()
+	for _, seed := range seeds {
+		u, err := pcg.constructPolys(seed.coefficients.aBeta, seed.exponents.aOmega)
+		assert.Nil(t, err)
+		utilde, err := pcg.ExpandVOLE(u, seed.ski, seed.U, seed.index)
+		assert.Nil(t, err)
+		assert.Len(t, utilde, pcg.c)
+
+		sk.Add(sk, seed.ski)
+		for r := 0; r < pcg.c; r++ {
+			if uSum[r] == nil {
+				uSum[r] = poly.NewEmpty()
+				utildeSum[r] = poly.NewEmpty()
+			}
+			uSum[r].Add(u[r])
+			utildeSum[r].Add(utilde[r])
+		}
+	}
+
+	// Summing up all shares yields u*sk
+	for r := 0; r < pcg.c; r++ {
+		uSum[r].MulByConstant(sk)
+		assert.True(t, uSum[r].Equal(utildeSum[r]))
+	}
+}
+
+func TestExpanderInvalidInput(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	u, err := pcg.constructPolys(seeds[0].coefficients.aBeta, seeds[0].exponents.aOmega)
+	assert.Nil(t, err)
+
+	_, err = pcg.ExpandVOLE(u, seeds[0].ski, seeds[0].U, 2) // index out of range
+	assert.NotNil(t, err)
+	_, err = pcg.ExpandVOLE(u[:1], seeds[0].ski, seeds[0].U, 0) // less than c polynomials
+	assert.NotNil(t, err)
+	_, err = pcg.ExpandVOLESeparate(NewVOLEKeyMatrix(1, pcg.c), 0) // keys for less than n parties
+	assert.NotNil(t, err)
+	_, err = pcg.ExpandOLE(u, u, seeds[0].C, -1) // negative index
+	assert.NotNil(t, err)
+	_, _, err = pcg.ExpandOLESeparate(u, u, nil, 0) // no keys
+	assert.NotNil(t, err)
+	_, err = pcg.ExpandVOLESeparate(seeds[0].C, 0) // keys of an OLE correlation
+	assert.NotNil(t, err)
+}
+
+func TestExpandOLESeparateLocalProducts(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	u, err := pcg.constructPolys(seeds[1].coefficients.aBeta, seeds[1].exponents.aOmega)
+	assert.Nil(t, err)
+	v, err := pcg.constructPolys(seeds[1].coefficients.sEpsilon, seeds[1].exponents.sPhi)
+	assert.Nil(t, err)
+
+	// The local products are the same for every set of counterparties
+	for _, counterparties := range [][]bool{nil, {true, false, false}, {false, false, true}} {
+		w, uv, err := pcg.expandOLESeparate(u, v, seeds[1].C, 1, counterparties)
+		assert.Nil(t, err)
+		assert.False(t, w.Has(1))
+		for r := 0; r < pcg.c; r++ {
+			for s := 0; s < pcg.c; s++ {
+				expected, err := poly.Mul(u[r], v[s])
+				assert.Nil(t, err)
+				assert.True(t, expected.Equal(uv[r][s]))
+			}
+		}
+	}
+}
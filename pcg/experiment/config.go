@@ -0,0 +1,260 @@
+// Package experiment loads the configurations of experiments from JSON or YAML files, i.e. a sweep of PCG parameters
+// together with the ring, the DPF backend, the thread limit and the output paths, s.t. the CLI and the benchmarks of
+// pcg/bench run parameter sweeps declaratively and reproducibly.
+package experiment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// defaultLambda is the security parameter of points that do not set one.
+const defaultLambda = 128
+
+// Format is the file format of a configuration.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// RingType selects how the ring of an experiment is generated.
+type RingType string
+
+const (
+	RingFast RingType = "fast" // RingFast materializes the roots via GetRing(true). It is the default.
+	RingSlow RingType = "slow" // RingSlow materializes the roots via GetRing(false).
+	RingLazy RingType = "lazy" // RingLazy derives the roots on demand via GetLazyRing.
+)
+
+// EvalMode selects the evaluation of an experiment.
+type EvalMode string
+
+const (
+	EvalCombined EvalMode = "combined" // EvalCombined evaluates n-out-of-n shares via EvalCombined. It is the default.
+	EvalSeparate EvalMode = "separate" // EvalSeparate evaluates tau-out-of-n shares via EvalSeparate.
+)
+
+// Config is the configuration of an experiment.
+type Config struct {
+	Name    string    `json:"name" yaml:"name"`       // Name names the experiment, e.g. in the file names of its results.
+	Points  []Point   `json:"points" yaml:"points"`   // Points are the parameter sets of the sweep.
+	Ring    RingType  `json:"ring" yaml:"ring"`       // Ring selects the ring, RingFast if empty.
+	Eval    EvalMode  `json:"eval" yaml:"eval"`       // Eval selects the evaluation, EvalCombined if empty.
+	DPF     DPFConfig `json:"dpf" yaml:"dpf"`         // DPF configures the base DPFs of the PCG.
+	Threads int       `json:"threads" yaml:"threads"` // Threads limits GOMAXPROCS during the experiment, 0 keeps it.
+	Output  Output    `json:"output" yaml:"output"`   // Output holds the paths of the experiment.
+}
+
+// Point is a parameter set of the sweep of an experiment (see pcg.NewPCG).
+type Point struct {
+	Lambda       int  `json:"lambda" yaml:"lambda"` // Lambda is the security parameter, 128 if zero.
+	N            int  `json:"N" yaml:"N"`
+	Parties      int  `json:"n" yaml:"n"`
+	Tau          int  `json:"tau" yaml:"tau"`
+	C            int  `json:"c" yaml:"c"`
+	T            int  `json:"t" yaml:"t"`
+	RegularNoise bool `json:"regular_noise" yaml:"regular_noise"` // RegularNoise enables regular noise (see pcg.UseRegularNoise).
+}
+
+// DPFConfig configures the base DPFs of the PCG.
+type DPFConfig struct {
+	PRG      string `json:"prg" yaml:"prg"`           // PRG names the PRG backend (see dpf.PRGBackendByName), the default if empty.
+	Hardened bool   `json:"hardened" yaml:"hardened"` // Hardened enables the security-hardened mode (see pcg.UseHardenedMode).
+}
+
+// Output holds the paths of an experiment. Relative paths are relative to the configuration file (see Load).
+type Output struct {
+	Dir      string `json:"dir" yaml:"dir"`           // Dir is the directory the results are written to, none if empty.
+	Fixtures string `json:"fixtures" yaml:"fixtures"` // Fixtures is the directory of the fixtures (see cmd/pcgfixture), none if empty.
+}
+
+// Load reads the configuration from the file at path, whose format is given by its extension (.json, .yaml or .yml).
+func Load(path string) (*Config, error) {
+	var format Format
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = FormatJSON
+	case ".yaml", ".yml":
+		format = FormatYAML
+	default:
+		return nil, fmt.Errorf("unknown format of configuration %s, expected .json, .yaml or .yml", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration %s: %w", path, err)
+	}
+	config.Output.Dir = resolve(filepath.Dir(path), config.Output.Dir)
+	config.Output.Fixtures = resolve(filepath.Dir(path), config.Output.Fixtures)
+	return config, nil
+}
+
+// Parse parses and validates the configuration. Unknown fields are rejected, s.t. typos do not silently fall back to
+// defaults.
+func Parse(data []byte, format Format) (*Config, error) {
+	config := &Config{}
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(config); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON: %w", err)
+		}
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil {
+			return nil, fmt.Errorf("failed to decode YAML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	config.setDefaults()
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// setDefaults sets the defaults of the unset fields.
+func (c *Config) setDefaults() {
+	if c.Ring == "" {
+		c.Ring = RingFast
+	}
+	if c.Eval == "" {
+		c.Eval = EvalCombined
+	}
+	for i := range c.Points {
+		if c.Points[i].Lambda == 0 {
+			c.Points[i].Lambda = defaultLambda
+		}
+	}
+}
+
+// Validate checks the configuration, in particular the parameters of all points (see pcg.CheckParameters).
+func (c *Config) Validate() error {
+	if len(c.Points) == 0 {
+		return fmt.Errorf("the experiment holds no points")
+	}
+	for i, point := range c.Points {
+		if _, err := pcg.CheckParameters(point.Lambda, point.N, point.Parties, point.Tau, point.C, point.T); err != nil {
+			return fmt.Errorf("invalid point %d: %w", i, err)
+		}
+	}
+	switch c.Ring {
+	case RingFast, RingSlow, RingLazy:
+	default:
+		return fmt.Errorf("unknown ring %q, expected %q, %q or %q", c.Ring, RingFast, RingSlow, RingLazy)
+	}
+	switch c.Eval {
+	case EvalCombined, EvalSeparate:
+	default:
+		return fmt.Errorf("unknown evaluation %q, expected %q or %q", c.Eval, EvalCombined, EvalSeparate)
+	}
+	if _, err := dpf.PRGBackendByName(c.DPF.PRG); err != nil {
+		return fmt.Errorf("invalid PRG backend %q: %w", c.DPF.PRG, err)
+	}
+	if c.Threads < 0 {
+		return fmt.Errorf("threads must not be negative but is %d", c.Threads)
+	}
+	return nil
+}
+
+// String returns a name of the point, e.g. for sub-benchmarks and file names.
+func (p Point) String() string {
+	noise := ""
+	if p.RegularNoise {
+		noise = "_regular"
+	}
+	return fmt.Sprintf("l%d_N%d_n%d_tau%d_c%d_t%d%s", p.Lambda, p.N, p.Parties, p.Tau, p.C, p.T, noise)
+}
+
+// NewPCG returns the PCG of the point, configured as given by the experiment.
+func (c *Config) NewPCG(point Point) (*pcg.PCG, error) {
+	generator, err := pcg.NewPCG(point.Lambda, point.N, point.Parties, point.Tau, point.C, point.T)
+	if err != nil {
+		return nil, err
+	}
+	if point.RegularNoise {
+		if err := generator.UseRegularNoise(); err != nil {
+			return nil, err
+		}
+	}
+	prg, err := dpf.PRGBackendByName(c.DPF.PRG)
+	if err != nil {
+		return nil, err
+	}
+	if err := generator.UsePRGBackend(prg); err != nil {
+		return nil, err
+	}
+	if c.DPF.Hardened {
+		if err := generator.UseHardenedMode(); err != nil {
+			return nil, err
+		}
+	}
+	return generator, nil
+}
+
+// GetRing returns the ring of the PCG of the type given by the experiment.
+func (c *Config) GetRing(generator *pcg.PCG) (*pcg.Ring, error) {
+	switch c.Ring {
+	case RingSlow:
+		return generator.GetRing(false)
+	case RingLazy:
+		return generator.GetLazyRing()
+	default:
+		return generator.GetRing(true)
+	}
+}
+
+// FixturePath returns the path of the fixture of the PCG in the fixture directory, or "" if the experiment has none.
+func (c *Config) FixturePath(generator *pcg.PCG) string {
+	if c.Output.Fixtures == "" {
+		return ""
+	}
+	return filepath.Join(c.Output.Fixtures, generator.FixtureName())
+}
+
+// ResultPath returns the path of the result file with the given extension in the output directory, e.g. sweep.csv
+// for the experiment sweep, or "" if the experiment has no output directory.
+func (c *Config) ResultPath(ext string) string {
+	if c.Output.Dir == "" {
+		return ""
+	}
+	name := c.Name
+	if name == "" {
+		name = "experiment"
+	}
+	return filepath.Join(c.Output.Dir, name+ext)
+}
+
+// LimitThreads sets GOMAXPROCS to the thread limit of the experiment, if any, and returns the function restoring the
+// previous value.
+func (c *Config) LimitThreads() (restore func()) {
+	if c.Threads == 0 {
+		return func() {}
+	}
+	previous := runtime.GOMAXPROCS(c.Threads)
+	return func() { runtime.GOMAXPROCS(previous) }
+}
+
+// resolve returns path relative to dir, unless path is empty or absolute.
+func resolve(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,93 @@
+package experiment
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestLoadYAML(t *testing.T) {
+	config, err := Load(filepath.Join("testdata", "sweep.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "sweep", config.Name)
+	assert.Equal(t, RingLazy, config.Ring)
+	assert.Equal(t, EvalCombined, config.Eval)
+	assert.Equal(t, DPFConfig{PRG: dpf.PRGNameSHA512, Hardened: true}, config.DPF)
+	assert.Equal(t, 2, config.Threads)
+	assert.Equal(t, []Point{
+		{Lambda: 128, N: 6, Parties: 3, Tau: 3, C: 2, T: 4},
+		{Lambda: 128, N: 7, Parties: 3, Tau: 3, C: 2, T: 4, RegularNoise: true},
+	}, config.Points)
+
+	// Relative paths are relative to the configuration
+	assert.Equal(t, filepath.Join("testdata", "results"), config.Output.Dir)
+	assert.Equal(t, "/tmp/fixtures", config.Output.Fixtures)
+	assert.Equal(t, filepath.Join("testdata", "results", "sweep.csv"), config.ResultPath(".csv"))
+}
+
+func TestLoadJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sweep.json")
+	data := `{"name": "sweep", "points": [{"N": 6, "n": 3, "tau": 2, "c": 2, "t": 4}], "eval": "separate"}`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0o600))
+
+	config, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []Point{{Lambda: 128, N: 6, Parties: 3, Tau: 2, C: 2, T: 4}}, config.Points)
+	assert.Equal(t, RingFast, config.Ring)
+	assert.Equal(t, EvalSeparate, config.Eval)
+	assert.Equal(t, "", config.ResultPath(".csv"))
+	assert.Equal(t, "l128_N6_n3_tau2_c2_t4", config.Points[0].String())
+}
+
+func TestParseRejectsInvalidConfigs(t *testing.T) {
+	point := `"points": [{"N": 6, "n": 3, "tau": 3, "c": 2, "t": 4}]`
+	for name, data := range map[string]string{
+		"no points":     `{}`,
+		"unknown field": `{` + point + `, "treads": 2}`,
+		"invalid point": `{"points": [{"N": 6, "n": 3, "tau": 4, "c": 2, "t": 4}]}`,
+		"unknown ring":  `{` + point + `, "ring": "fastest"}`,
+		"unknown eval":  `{` + point + `, "eval": "both"}`,
+		"unknown prg":   `{` + point + `, "dpf": {"prg": "chacha"}}`,
+		"threads":       `{` + point + `, "threads": -1}`,
+	} {
+		_, err := Parse([]byte(data), FormatJSON)
+		assert.NotNil(t, err, name)
+	}
+	_, err := Parse([]byte("points: []\nring: fast\n"), FormatYAML)
+	assert.NotNil(t, err)
+	_, err = Load("sweep.toml")
+	assert.NotNil(t, err)
+}
+
+func TestNewPCG(t *testing.T) {
+	config, err := Load(filepath.Join("testdata", "sweep.yaml"))
+	assert.Nil(t, err)
+	for _, point := range config.Points {
+		generator, err := config.NewPCG(point)
+		assert.Nil(t, err)
+		assert.Equal(t, dpf.PRGNameSHA512, generator.PRGBackend().Name())
+		assert.True(t, generator.Hardened())
+		assert.Equal(t, point.RegularNoise, generator.RegularNoise())
+		assert.Equal(t, filepath.Join("/tmp/fixtures", generator.FixtureName()), config.FixturePath(generator))
+
+		ring, err := config.GetRing(generator)
+		assert.Nil(t, err)
+		assert.Nil(t, ring.Roots) // the lazy ring does not materialize its roots
+	}
+}
+
+func TestLimitThreads(t *testing.T) {
+	previous := runtime.GOMAXPROCS(0)
+	config := &Config{Threads: 1}
+	restore := config.LimitThreads()
+	assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+	restore()
+	assert.Equal(t, previous, runtime.GOMAXPROCS(0))
+
+	// No limit keeps GOMAXPROCS
+	(&Config{}).LimitThreads()()
+	assert.Equal(t, previous, runtime.GOMAXPROCS(0))
+}
@@ -0,0 +1,102 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"math/big"
+)
+
+// ExtendedRing is the ring F_q[x]/(x^(2^(N+1)) - 1) of the (unreduced) products of two elements of the base Ring.
+// Its 2^(N+1) roots are all 2^(N+1)th roots of unity. The odd powers of them are exactly the roots of the base ring,
+// hence x^(2^N) + 1 divides the divisor of the extended ring and reducing to the base ring is a ring homomorphism.
+type ExtendedRing struct {
+	Div  *poly.Polynomial // Div is x^(2^(N+1)) - 1
+	base *Ring
+	size int // size is the amount of roots 2^(N+1)
+}
+
+// GetExtendedRing returns the extended ring of the base ring returned by GetLazyRing.
+func (p *PCG) GetExtendedRing() (*ExtendedRing, error) {
+	base, err := p.GetLazyRing()
+	if err != nil {
+		return nil, err
+	}
+	return newExtendedRing(base)
+}
+
+// newExtendedRing returns the extended ring of the given base ring.
+func newExtendedRing(base *Ring) (*ExtendedRing, error) {
+	size := 2 * base.size
+	minusOne := bls12381.NewFr().One()
+	minusOne.Neg(minusOne)
+	div, err := poly.NewSparse([]*bls12381.Fr{bls12381.NewFr().One(), minusOne}, []*big.Int{big.NewInt(int64(size)), big.NewInt(0)})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExtendedRing{
+		Div:  div,
+		base: base,
+		size: size,
+	}, nil
+}
+
+// Base returns the base ring of the extended ring.
+func (r *ExtendedRing) Base() *Ring {
+	return r.base
+}
+
+// Size returns the amount of roots of the extended ring.
+func (r *ExtendedRing) Size() int {
+	return r.size
+}
+
+// RootAt returns the i-th root of the extended ring, i.e. the i-th power of the primitive 2^(N+1)th root of unity.
+// The root at 2i+1 equals the i-th root of the base ring.
+func (r *ExtendedRing) RootAt(i int) (*bls12381.Fr, error) {
+	if i < 0 || i >= r.size {
+		return nil, fmt.Errorf("root index %d is out of range [0, %d)", i, r.size)
+	}
+	root := bls12381.NewFr()
+	root.Exp(r.base.rootBase, big.NewInt(int64(i)))
+	return root, nil
+}
+
+// Reduce reduces the polynomial from the extended ring to the base ring, i.e. modulo x^(2^N) + 1.
+// In contrast to poly.Mod, this is done in a single pass over the coefficients, as x^(k*2^N + i) = (-1)^k * x^i.
+// The polynomial may be of arbitrary degree.
+func (r *ExtendedRing) Reduce(p *poly.Polynomial) *poly.Polynomial {
+	n := r.base.size
+	coefficients := make(map[int]*bls12381.Fr, len(p.Coefficients))
+	for exp, coeff := range p.Coefficients {
+		val := bls12381.NewFr().Set(coeff)
+		if (exp/n)%2 == 1 {
+			val.Neg(val)
+		}
+		if existing, ok := coefficients[exp%n]; ok {
+			existing.Add(existing, val)
+		} else {
+			coefficients[exp%n] = val
+		}
+	}
+	for exp, coeff := range coefficients {
+		if coeff.IsZero() {
+			delete(coefficients, exp)
+		}
+	}
+	return &poly.Polynomial{Coefficients: coefficients}
+}
+
+// ReduceEvaluations reduces the evaluations of a polynomial at all roots of the extended ring to its evaluations at the
+// roots of the base ring, i.e. the point-value form (see Ring.EvaluationDomain) of the reduced polynomial.
+func (r *ExtendedRing) ReduceEvaluations(values []*bls12381.Fr) ([]*bls12381.Fr, error) {
+	if len(values) != r.size {
+		return nil, fmt.Errorf("amount of evaluations %d does not match the size of the extended ring %d", len(values), r.size)
+	}
+	reduced := make([]*bls12381.Fr, r.base.size)
+	for i := range reduced {
+		reduced[i] = bls12381.NewFr().Set(values[2*i+1])
+	}
+	return reduced, nil
+}
@@ -0,0 +1,64 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestExtendedRing(t *testing.T) {
+	pcg, err := NewPCG(128, 5, 2, 2, 2, 4) // Small lpn parameters for testing.
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+	extended, err := pcg.GetExtendedRing()
+	assert.Nil(t, err)
+	assert.Equal(t, 2*ring.Size(), extended.Size())
+
+	// The roots of the extended ring are roots of its divisor and contain the roots of the base ring at odd indices
+	for i := 0; i < extended.Size(); i++ {
+		root, err := extended.RootAt(i)
+		assert.Nil(t, err)
+		assert.True(t, extended.Div.Evaluate(root).IsZero())
+		if i%2 == 1 {
+			assert.True(t, ring.Roots[i/2].Equal(root))
+		}
+	}
+	_, err = extended.RootAt(extended.Size())
+	assert.NotNil(t, err)
+
+	// The product of two ring elements lives in the extended ring without reduction
+	rng := rand.New(rand.NewSource(1))
+	a, err := poly.NewRandomPolynomial(rng, ring.Size())
+	assert.Nil(t, err)
+	b, err := poly.NewRandomPolynomial(rng, ring.Size())
+	assert.Nil(t, err)
+	product, err := poly.Mul(a, b)
+	assert.Nil(t, err)
+	degree, err := product.Degree()
+	assert.Nil(t, err)
+	assert.Less(t, degree, extended.Size())
+
+	// Reduce equals the reduction via Mod
+	expected, err := product.Mod(ring.Div)
+	assert.Nil(t, err)
+	reduced := extended.Reduce(product)
+	assert.True(t, expected.Equal(reduced))
+
+	// Reducing the evaluations equals evaluating the reduced polynomial at the roots of the base ring
+	values := make([]*bls12381.Fr, extended.Size())
+	for i := range values {
+		root, err := extended.RootAt(i)
+		assert.Nil(t, err)
+		values[i] = product.Evaluate(root)
+	}
+	reducedValues, err := extended.ReduceEvaluations(values)
+	assert.Nil(t, err)
+	for i, root := range ring.Roots {
+		assert.True(t, reduced.Evaluate(root).Equal(reducedValues[i]))
+	}
+	_, err = extended.ReduceEvaluations(values[1:])
+	assert.NotNil(t, err)
+}
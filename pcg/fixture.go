@@ -0,0 +1,191 @@
+package pcg
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"io"
+	"os"
+)
+
+// fixtureFormatVersion is the version of the file format of fixtures (see WriteFixture).
+const fixtureFormatVersion = 2
+
+// Fixture holds the inputs of an evaluation, i.e. the seeds of all parties, the public random polynomials and the
+// ring, s.t. benchmarks of large domains need not run TrustedSeedGen before each measurement.
+type Fixture struct {
+	Seeds []*Seed
+	Rand  []*poly.Polynomial
+	Ring  *Ring
+}
+
+// fixtureData is the gob format of a Fixture. The DSPF keys are shared by all seeds and hence only stored once.
+type fixtureData struct {
+	Version      int
+	ParamsDigest [32]byte
+	Seeds        []*seedData
+	Keys         *seedKeysData
+	Rand         []polynomialData
+	Ring         []byte
+}
+
+// polynomialData is the gob format of a polynomial in the fixture. In contrast to poly.Polynomial.Serialize, it
+// supports exponents beyond the range of int32.
+type polynomialData struct {
+	Exponents    []int
+	Coefficients [][]byte
+}
+
+// GenerateFixture generates the inputs of an evaluation as the benchmarks do, i.e. via TrustedSeedGen,
+// PickRandomPolynomials and GetRing(false).
+func (p *PCG) GenerateFixture() (*Fixture, error) {
+	seeds, err := p.TrustedSeedGen()
+	if err != nil {
+		return nil, err
+	}
+	rand, err := p.PickRandomPolynomials()
+	if err != nil {
+		return nil, err
+	}
+	ring, err := p.GetRing(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Fixture{Seeds: seeds, Rand: rand, Ring: ring}, nil
+}
+
+// FixtureName returns the file name of the fixture of the parameters of the PCG, e.g. to look fixtures up in a directory.
+func (p *PCG) FixtureName() string {
+	noise := ""
+	if p.regularNoise {
+		noise = "_regular"
+	}
+	if prg := p.PRGBackend().Name(); prg != dpf.PRGNameAES {
+		noise += "_" + prg // the DPF keys of the seeds only work with their PRG backend
+	}
+	return fmt.Sprintf("pcg_l%d_N%d_n%d_tau%d_c%d_t%d%s.fixture", p.lambda, p.N, p.n, p.tau, p.c, p.t, noise)
+}
+
+// WriteFixture writes the fixture to w. The seeds must stem from the same TrustedSeedGen, as their DSPF keys are only
+// written once.
+func (p *PCG) WriteFixture(w io.Writer, fixture *Fixture) error {
+	if fixture == nil || len(fixture.Seeds) == 0 || fixture.Ring == nil {
+		return fmt.Errorf("fixture must hold at least one seed and a ring")
+	}
+	first := fixture.Seeds[0]
+	data := fixtureData{
+		Version:      fixtureFormatVersion,
+		ParamsDigest: p.paramsDigest(),
+		Seeds:        make([]*seedData, len(fixture.Seeds)),
+		Rand:         make([]polynomialData, len(fixture.Rand)),
+	}
+	for i, seed := range fixture.Seeds {
+		if !sharesKeys(first, seed) {
+			return fmt.Errorf("seed %d does not share the DSPF keys of the first seed", i)
+		}
+		party, err := seed.partyData()
+		if err != nil {
+			return err
+		}
+		data.Seeds[i] = party
+	}
+	keys, err := first.keysData()
+	if err != nil {
+		return err
+	}
+	data.Keys = keys
+	for i, r := range fixture.Rand {
+		r.ForEachTerm(func(exp int, coeff *bls12381.Fr) {
+			data.Rand[i].Exponents = append(data.Rand[i].Exponents, exp)
+			data.Rand[i].Coefficients = append(data.Rand[i].Coefficients, coeff.ToBytes())
+		})
+	}
+	if data.Ring, err = fixture.Ring.Serialize(); err != nil {
+		return err
+	}
+
+	buffered := bufio.NewWriter(w)
+	if err := gob.NewEncoder(buffered).Encode(&data); err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	return buffered.Flush()
+}
+
+// ReadFixture reads a fixture written by WriteFixture. It returns an error if the fixture was written for other
+// parameters than the parameters of the PCG.
+func (p *PCG) ReadFixture(r io.Reader) (*Fixture, error) {
+	var data fixtureData
+	if err := gob.NewDecoder(bufio.NewReader(r)).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture: %w", err)
+	}
+	if data.Version != fixtureFormatVersion {
+		return nil, fmt.Errorf("unsupported fixture format version %d", data.Version)
+	}
+	if data.ParamsDigest != p.paramsDigest() {
+		return nil, fmt.Errorf("fixture was generated for different PCG parameters")
+	}
+	if data.Keys == nil || len(data.Seeds) == 0 {
+		return nil, fmt.Errorf("fixture holds no seeds")
+	}
+
+	fixture := &Fixture{Seeds: make([]*Seed, len(data.Seeds)), Rand: make([]*poly.Polynomial, len(data.Rand)), Ring: &Ring{}}
+	for i, party := range data.Seeds {
+		seed, err := seedFromData(party, data.Keys)
+		if err != nil {
+			return nil, fmt.Errorf("fixture holds an invalid seed: %w", err)
+		}
+		seed.paramsDigest = data.ParamsDigest
+		if i > 0 { // Share the keys of the first seed, as seeds of TrustedSeedGen do
+			seed.U, seed.C, seed.V = fixture.Seeds[0].U, fixture.Seeds[0].C, fixture.Seeds[0].V
+		}
+		fixture.Seeds[i] = seed
+	}
+	for i, r := range data.Rand {
+		if len(r.Exponents) != len(r.Coefficients) {
+			return nil, fmt.Errorf("fixture holds an invalid random polynomial")
+		}
+		fixture.Rand[i] = poly.NewEmpty()
+		for k, exp := range r.Exponents {
+			coefficient, err := dpf.FrFromBytesCanonical(r.Coefficients[k])
+			if exp < 0 || err != nil {
+				return nil, fmt.Errorf("fixture holds an invalid random polynomial")
+			}
+			fixture.Rand[i].Coefficients[exp] = coefficient
+		}
+	}
+	if err := fixture.Ring.Deserialize(data.Ring); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+// SaveFixture writes the fixture to the file at path (see WriteFixture).
+func (p *PCG) SaveFixture(path string, fixture *Fixture) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := p.WriteFixture(file, fixture); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadFixture reads the fixture from the file at path (see ReadFixture).
+func (p *PCG) LoadFixture(path string) (*Fixture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return p.ReadFixture(file)
+}
+
+// sharesKeys returns whether both seeds hold the same DSPF key tables, as the seeds of a single TrustedSeedGen do.
+func sharesKeys(a, b *Seed) bool {
+	return a.U == b.U && a.C == b.C && a.V == b.V
+}
@@ -0,0 +1,66 @@
+package pcg
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixtureRoundTrip(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	fixture, err := pcg.GenerateFixture()
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), pcg.FixtureName())
+	assert.Nil(t, pcg.SaveFixture(path, fixture))
+	loaded, err := pcg.LoadFixture(path)
+	assert.Nil(t, err)
+
+	assert.Len(t, loaded.Seeds, len(fixture.Seeds))
+	for i := range fixture.Seeds {
+		expected, err := fixture.Seeds[i].Digest()
+		assert.Nil(t, err)
+		actual, err := loaded.Seeds[i].Digest()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, actual)
+		assert.True(t, sharesKeys(loaded.Seeds[0], loaded.Seeds[i]))
+	}
+	assert.Len(t, loaded.Rand, len(fixture.Rand))
+	for i := range fixture.Rand {
+		assert.True(t, fixture.Rand[i].Equal(loaded.Rand[i]))
+	}
+	assert.Equal(t, fixture.Ring.Roots, loaded.Ring.Roots)
+	assert.True(t, fixture.Ring.Div.Equal(loaded.Ring.Div))
+
+	// The loaded fixture evaluates to the same tuples
+	expected, err := pcg.EvalCombined(fixture.Seeds[1], fixture.Rand, fixture.Ring.Prepared())
+	assert.Nil(t, err)
+	actual, err := pcg.EvalCombined(loaded.Seeds[1], loaded.Rand, loaded.Ring.Prepared())
+	assert.Nil(t, err)
+	expectedTuple, actualTuple := expected.GenBBSPlusTuple(fixture.Ring.Roots[5]), actual.GenBBSPlusTuple(loaded.Ring.Roots[5])
+	expectedTuple.Tag, actualTuple.Tag = nil, nil // The tags hold the time of the evaluation
+	assert.Equal(t, expectedTuple, actualTuple)
+}
+
+func TestFixtureRejectsOtherParameters(t *testing.T) {
+	pcg, err := NewPCG(128, 5, 2, 2, 2, 2)
+	assert.Nil(t, err)
+	fixture, err := pcg.GenerateFixture()
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	assert.Nil(t, pcg.WriteFixture(&buf, fixture))
+
+	other, err := NewPCG(128, 5, 2, 2, 2, 3)
+	assert.Nil(t, err)
+	assert.NotEqual(t, pcg.FixtureName(), other.FixtureName())
+	_, err = other.ReadFixture(bytes.NewReader(buf.Bytes()))
+	assert.NotNil(t, err)
+
+	// Seeds of different seed generations cannot be combined
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	fixture.Seeds[1] = seeds[1]
+	assert.NotNil(t, pcg.WriteFixture(&buf, fixture))
+}
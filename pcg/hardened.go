@@ -0,0 +1,49 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+)
+
+// UseHardenedMode switches the PCG to the security-hardened mode, in which the base DPFs compute and combine their
+// results in constant time (see optreedpf.OpTreeDPF.SetConstantTime), i.e. Eval does not branch on the control bits
+// of the DPF keys. The tuples and signature shares are recombined in constant time regardless of the mode (see
+// tuplegen.CombineSignatureShares). The seeds and outputs are unchanged by the mode.
+// It must be called before DistributeEval, while regular noise may be enabled before or after.
+func (p *PCG) UseHardenedMode() error {
+	if p.regularNoise {
+		p.hardened = true
+		return p.UseRegularNoise()
+	}
+	if _, ok := p.dspfN.(*dspf.DSPF); !ok {
+		return fmt.Errorf("the hardened mode must be enabled before the evaluation is distributed")
+	}
+	p.hardened = true
+	dspfN, dspf2N, err := p.newDSPFs()
+	if err != nil {
+		return err
+	}
+	p.dspfN = dspfN
+	p.dspf2N = dspf2N
+	return nil
+}
+
+// Hardened returns whether the PCG is in the security-hardened mode (see UseHardenedMode).
+func (p *PCG) Hardened() bool {
+	return p.hardened
+}
+
+// newBaseDPF returns a base DPF with the given domain, which is constant time in the hardened mode and uses the PRG
+// backend of the PCG.
+func (p *PCG) newBaseDPF(domain int) (*optreedpf.OpTreeDPF, error) {
+	baseDpf, err := optreedpf.InitFactory(p.lambda, domain)
+	if err != nil {
+		return nil, err
+	}
+	baseDpf.SetConstantTime(p.hardened)
+	if p.prg != nil {
+		baseDpf.SetPRG(p.prg)
+	}
+	return baseDpf, nil
+}
@@ -0,0 +1,56 @@
+package pcg
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUseHardenedMode(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+	expected, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+
+	// The hardened mode does not change the outputs
+	assert.False(t, pcg.Hardened())
+	assert.Nil(t, pcg.UseHardenedMode())
+	assert.True(t, pcg.Hardened())
+	hardened, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, hardened)
+}
+
+func TestUseHardenedModeRegularNoise(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	assert.Nil(t, pcg.UseRegularNoise())
+	assert.Nil(t, pcg.UseHardenedMode())
+	assert.True(t, pcg.RegularNoise())
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	result, err := pcg.SimulateAllParties(seeds, randPolys, ring, 0)
+	assert.Nil(t, err)
+	assert.True(t, result.Correct())
+}
+
+func TestUseHardenedModeAfterDistributeEval(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	worker, err := pcg.NewLocalWorker()
+	assert.Nil(t, err)
+	assert.Nil(t, pcg.DistributeEval([]dspf.Worker{worker}, 1))
+	assert.NotNil(t, pcg.UseHardenedMode())
+	assert.False(t, pcg.Hardened())
+}
@@ -0,0 +1,40 @@
+package pcg
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/metrics"
+	"time"
+)
+
+// PhaseObserver receives the duration of each phase of Eval for the evaluating party, e.g. to compare configurations
+// in benchmarks. PhaseFinalShare is reported once per final share polynomial.
+// If seeds are evaluated concurrently (e.g. via SimulateAllParties), the observer is called concurrently as well.
+type PhaseObserver func(party int, phase Phase, duration time.Duration)
+
+// SetPhaseObserver sets the observer of the phases of Eval. A nil observer disables the observation.
+func (p *PCG) SetPhaseObserver(observer PhaseObserver) {
+	p.phaseObserver = observer
+}
+
+// SetMetrics sets the metrics the PCG reports to, e.g. a metrics.Registry served by a server component: the amount
+// of generated seeds, the durations of the phases of Eval and, via the generators returned by Eval, the amount of
+// generated tuples. A nil metrics disables the reporting.
+func (p *PCG) SetMetrics(m metrics.Metrics) {
+	p.metrics = m
+}
+
+// observePhase reports the duration of a phase to the phase observer and the metrics, if any.
+func (p *PCG) observePhase(party int, phase Phase, duration time.Duration) {
+	if p.phaseObserver != nil {
+		p.phaseObserver(party, phase, duration)
+	}
+	if p.metrics != nil {
+		p.metrics.Observe(metrics.EvalPhaseSeconds, duration.Seconds(), metrics.Label{Name: "phase", Value: phase.String()})
+	}
+}
+
+// countSeeds reports the amount of generated seeds to the metrics, if any.
+func (p *PCG) countSeeds(seeds int) {
+	if p.metrics != nil {
+		p.metrics.Add(metrics.SeedsGenerated, float64(seeds))
+	}
+}
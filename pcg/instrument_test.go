@@ -0,0 +1,37 @@
+package pcg
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/metrics"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPCGMetrics(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	registry := metrics.NewRegistry()
+	pcg.SetMetrics(registry)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	seedCount, _ := registry.Value(metrics.SeedsGenerated)
+	assert.Equal(t, 2.0, seedCount)
+
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	generator, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	for _, phase := range []Phase{PhaseVOLE, PhaseOLE1, PhaseOLE2, PhaseFinalShare} {
+		_, ok := registry.Value(metrics.EvalPhaseSeconds, metrics.Label{Name: "phase", Value: phase.String()})
+		assert.True(t, ok, "phase %v", phase)
+	}
+
+	_, err = generator.GenBBSPlusTuplesAt(ring, []int{0, 1, 2})
+	assert.Nil(t, err)
+	_, err = generator.GenBBSPlusTupleAt(ring, 3)
+	assert.Nil(t, err)
+	tuples, _ := registry.Value(metrics.TuplesGenerated, metrics.Label{Name: "result", Value: "ok"})
+	assert.Equal(t, 4.0, tuples)
+}
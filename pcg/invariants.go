@@ -0,0 +1,139 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+)
+
+// The invariant checks assert the internal consistency of the seeds of Gen and the shares of Eval, e.g. that the sk
+// shares reconstruct the committed sk. They are only compiled in with the pcgdebug build tag (go test -tags pcgdebug),
+// as they cost about as much as the checked stage itself, and serve as a high-assurance mode for CI and research
+// validation. A violated invariant is a bug of the PCG and returned as an InvariantError.
+
+// InvariantError is returned by Gen and Eval if an internal invariant is violated in the pcgdebug mode.
+type InvariantError struct {
+	Stage     string // Stage is the stage the invariant was checked in, e.g. "TrustedSeedGen".
+	Invariant string // Invariant describes the violated invariant.
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("invariant violated in %s: %s", e.Stage, e.Invariant)
+}
+
+// checkSeedGenInvariants checks the seeds of trustedSeedGen against the secrets of the dealer, i.e. the shapes of all
+// seeds and key matrices, the sk shares against their commitments, and that any tau shares reconstruct the committed sk.
+func (p *PCG) checkSeedGenInvariants(seeds []*Seed, secrets *DealerSecrets) error {
+	if !invariantChecks {
+		return nil
+	}
+	violation := func(format string, args ...any) error {
+		return &InvariantError{Stage: "TrustedSeedGen", Invariant: fmt.Sprintf(format, args...)}
+	}
+	if len(seeds) != p.n {
+		return violation("got %d seeds for n=%d parties", len(seeds), p.n)
+	}
+	scheme := p.SharingScheme()
+	for i, seed := range seeds {
+		if seed == nil || seed.ski == nil || seed.U == nil || seed.C == nil || seed.V == nil {
+			return violation("seed %d has nil fields", i)
+		}
+		if seed.index != i {
+			return violation("seed %d has index %d", i, seed.index)
+		}
+		if !seed.ski.Equal(secrets.SkShares[seed.skShareIndex]) {
+			return violation("seed %d does not hold its sk share", i)
+		}
+		if err := scheme.Verify(seed.ski, seed.skShareIndex, seed.skCommitments); err != nil {
+			return violation("sk share of seed %d: %v", i, err)
+		}
+		for name, vectors := range map[string]int{"aOmega": seed.exponents.aOmega.Blocks(), "eEta": seed.exponents.eEta.Blocks(), "sPhi": seed.exponents.sPhi.Blocks(),
+			"aBeta": seed.coefficients.aBeta.Blocks(), "eGamma": seed.coefficients.eGamma.Blocks(), "sEpsilon": seed.coefficients.sEpsilon.Blocks()} {
+			if vectors != p.c {
+				return violation("seed %d holds %d vectors of %s but c=%d", i, vectors, name, p.c)
+			}
+		}
+		for name, matrix := range map[string]*DSPFKeyMatrix{"U": seed.U, "C": seed.C, "V": seed.V} {
+			if err := p.checkKeyMatrix(matrix); err != nil {
+				return violation("key matrix %s of seed %d: %v", name, i, err)
+			}
+		}
+	}
+
+	// Only a sharing with a share per party can be reconstructed from any signer set
+	if len(secrets.SkShares) != p.n {
+		return nil
+	}
+	committed, err := scheme.PublicKey(seeds[0].skCommitments)
+	if err != nil {
+		return violation("%v", err)
+	}
+	first, last := make([]int, p.tau), make([]int, p.tau)
+	for k := range first {
+		first[k], last[k] = k, p.n-p.tau+k
+	}
+	for _, signers := range [][]int{first, last} {
+		shares := make([]*bls12381.Fr, len(signers))
+		for k, signer := range signers {
+			shares[k] = secrets.SkShares[signer]
+		}
+		sk, err := scheme.Recombine(shares, signers)
+		if err != nil {
+			return violation("failed to reconstruct sk from signers %v: %v", signers, err)
+		}
+		pk := bls12381.NewG1().MulScalar(new(bls12381.PointG1), bls12381.NewG1().One(), sk)
+		if !bls12381.NewG1().Equal(pk, committed) {
+			return violation("the sk reconstructed from signers %v does not match the commitment", signers)
+		}
+	}
+	return nil
+}
+
+// checkKeyMatrix checks that all key pairs between distinct parties hold t DPF keys each and the diagonal is empty.
+func (p *PCG) checkKeyMatrix(matrix *DSPFKeyMatrix) error {
+	if matrix.N() != p.n || matrix.C() != p.c {
+		return fmt.Errorf("the matrix is of %d parties and c=%d", matrix.N(), matrix.C())
+	}
+	for i := 0; i < p.n; i++ {
+		for j := 0; j < p.n; j++ {
+			for _, pair := range matrix.Block(i, j) {
+				keys := p.t
+				if matrix.IsOLE() {
+					keys = p.t * p.t
+				}
+				if i == j {
+					keys = 0
+				}
+				if len(pair.Key0.DPFKeys) != keys || len(pair.Key1.DPFKeys) != keys {
+					return fmt.Errorf("the pair [%d][%d] holds %d and %d DPF keys instead of %d", i, j, len(pair.Key0.DPFKeys), len(pair.Key1.DPFKeys), keys)
+				}
+				for _, dspfKey := range []dspf.Key{pair.Key0, pair.Key1} {
+					for _, key := range dspfKey.DPFKeys {
+						if key == nil {
+							return fmt.Errorf("the pair [%d][%d] holds a nil DPF key", i, j)
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkShareInvariants checks that the final shares of Eval of the given party are set and reduced by the divisor
+// of the ring, i.e. of a degree below 2^N.
+func (p *PCG) checkShareInvariants(stage string, party int, shares map[string]*poly.Polynomial) error {
+	if !invariantChecks {
+		return nil
+	}
+	for name, share := range shares {
+		if share == nil {
+			return &InvariantError{Stage: stage, Invariant: fmt.Sprintf("the share %s of party %d is nil", name, party)}
+		}
+		if degree, err := share.Degree(); err == nil && degree >= 1<<p.N {
+			return &InvariantError{Stage: stage, Invariant: fmt.Sprintf("the share %s of party %d has degree %d, but the ring has 2^%d roots", name, party, degree, p.N)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build !pcgdebug
+
+package pcg
+
+// invariantChecks disables the invariant checks of Gen and Eval (see invariants.go). Build with the pcgdebug tag to
+// enable them.
+const invariantChecks = false
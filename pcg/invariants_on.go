@@ -0,0 +1,7 @@
+//go:build pcgdebug
+
+package pcg
+
+// invariantChecks enables the invariant checks of Gen and Eval (see invariants.go), as the PCG is built with the
+// pcgdebug tag.
+const invariantChecks = true
@@ -0,0 +1,56 @@
+package pcg
+
+import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckKeyMatrix(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	for _, matrix := range []*DSPFKeyMatrix{seeds[0].U, seeds[0].C, seeds[0].V} {
+		assert.Nil(t, pcg.checkKeyMatrix(matrix))
+	}
+
+	truncated := seeds[0].C.Clone()
+	truncated.AtOLE(0, 1, 0, 0).Key1.DPFKeys = truncated.AtOLE(0, 1, 0, 0).Key1.DPFKeys[1:]
+	assert.NotNil(t, pcg.checkKeyMatrix(truncated))
+	other, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	assert.NotNil(t, other.checkKeyMatrix(seeds[0].U))
+}
+
+func TestInvariantChecks(t *testing.T) {
+	if !invariantChecks {
+		t.Skip("the invariant checks require the pcgdebug build tag")
+	}
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, secrets, err := pcg.TrustedSeedGenAudited()
+	assert.Nil(t, err)
+	assert.Nil(t, pcg.checkSeedGenInvariants(seeds, secrets))
+
+	// A seed, whose share does not match the commitments, violates the invariants
+	var invariantErr *InvariantError
+	tampered := *seeds[1]
+	tampered.ski = bls12381.NewFr().One()
+	err = pcg.checkSeedGenInvariants([]*Seed{seeds[0], &tampered, seeds[2]}, secrets)
+	assert.True(t, errors.As(err, &invariantErr))
+	assert.Equal(t, "TrustedSeedGen", invariantErr.Stage)
+	assert.NotNil(t, pcg.checkSeedGenInvariants(seeds[:2], secrets))
+
+	// Shares of Eval must be set and reduced
+	reduced := poly.NewEmpty()
+	reduced.Coefficients[1<<6-1] = bls12381.NewFr().One()
+	assert.Nil(t, pcg.checkShareInvariants("EvalCombined", 0, map[string]*poly.Polynomial{"ai": reduced}))
+	unreduced := poly.NewEmpty()
+	unreduced.Coefficients[1<<6] = bls12381.NewFr().One()
+	err = pcg.checkShareInvariants("EvalCombined", 0, map[string]*poly.Polynomial{"ai": unreduced})
+	assert.True(t, errors.As(err, &invariantErr))
+	assert.NotNil(t, pcg.checkShareInvariants("EvalCombined", 0, map[string]*poly.Polynomial{"ai": nil}))
+}
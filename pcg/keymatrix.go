@@ -0,0 +1,90 @@
+package pcg
+
+import "fmt"
+
+// DSPFKeyMatrix holds the DSPF key pairs of a correlation between all pairs of parties, i.e. the pairs [i][j][r] of
+// the VOLE or [i][j][r][s] of an OLE correlation, where the pair at [i][j] embeds the correlation between party i and
+// party j. The pairs are stored by value in a single slab in the order of their indices, s.t. the pairs of a party are
+// contiguous and generating or iterating the keys does not chase pointers across many small allocations.
+// The pairs of the diagonal [i][i] hold no keys.
+type DSPFKeyMatrix struct {
+	n, c  int
+	ole   bool
+	pairs []DSPFKeyPair
+}
+
+// NewVOLEKeyMatrix returns a matrix of n*n*c empty key pairs indexed by [i][j][r].
+func NewVOLEKeyMatrix(n, c int) *DSPFKeyMatrix {
+	return &DSPFKeyMatrix{n: n, c: c, pairs: make([]DSPFKeyPair, n*n*c)}
+}
+
+// NewOLEKeyMatrix returns a matrix of n*n*c*c empty key pairs indexed by [i][j][r][s].
+func NewOLEKeyMatrix(n, c int) *DSPFKeyMatrix {
+	return &DSPFKeyMatrix{n: n, c: c, ole: true, pairs: make([]DSPFKeyPair, n*n*c*c)}
+}
+
+// N returns the amount of parties.
+func (m *DSPFKeyMatrix) N() int {
+	return m.n
+}
+
+// C returns the amount of noise polynomials per party.
+func (m *DSPFKeyMatrix) C() int {
+	return m.c
+}
+
+// IsOLE returns whether the matrix holds the pairs of an OLE correlation, i.e. is indexed by [i][j][r][s].
+func (m *DSPFKeyMatrix) IsOLE() bool {
+	return m.ole
+}
+
+// At returns the pair [i][j][r] of a VOLE matrix. The pair is part of the matrix, i.e. modifying it modifies the matrix.
+// It panics if an index is out of range or if the matrix is an OLE matrix.
+func (m *DSPFKeyMatrix) At(i, j, r int) *DSPFKeyPair {
+	if m.ole {
+		panic("At called on an OLE key matrix")
+	}
+	return &m.Block(i, j)[m.checkIndex(r)]
+}
+
+// AtOLE returns the pair [i][j][r][s] of an OLE matrix. The pair is part of the matrix, i.e. modifying it modifies the
+// matrix. It panics if an index is out of range or if the matrix is a VOLE matrix.
+func (m *DSPFKeyMatrix) AtOLE(i, j, r, s int) *DSPFKeyPair {
+	if !m.ole {
+		panic("AtOLE called on a VOLE key matrix")
+	}
+	return &m.Block(i, j)[m.checkIndex(r)*m.c+m.checkIndex(s)]
+}
+
+// Block returns the pairs [i][j] of the matrix in the order of r (and s), i.e. c pairs of a VOLE and c*c pairs of an
+// OLE matrix. The block is part of the matrix. It panics if an index is out of range.
+func (m *DSPFKeyMatrix) Block(i, j int) []DSPFKeyPair {
+	if i < 0 || i >= m.n || j < 0 || j >= m.n {
+		panic(fmt.Sprintf("party index [%d][%d] out of range [0, %d)", i, j, m.n))
+	}
+	size := m.blockSize()
+	offset := (i*m.n + j) * size
+	return m.pairs[offset : offset+size : offset+size]
+}
+
+// Clone returns a copy of the matrix, which shares the DSPF keys but not the slab of the pairs with m.
+func (m *DSPFKeyMatrix) Clone() *DSPFKeyMatrix {
+	clone := *m
+	clone.pairs = append([]DSPFKeyPair(nil), m.pairs...)
+	return &clone
+}
+
+// blockSize returns the amount of pairs per pair of parties.
+func (m *DSPFKeyMatrix) blockSize() int {
+	if m.ole {
+		return m.c * m.c
+	}
+	return m.c
+}
+
+func (m *DSPFKeyMatrix) checkIndex(r int) int {
+	if r < 0 || r >= m.c {
+		panic(fmt.Sprintf("polynomial index %d out of range [0, %d)", r, m.c))
+	}
+	return r
+}
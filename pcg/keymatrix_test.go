@@ -0,0 +1,63 @@
+package pcg
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDSPFKeyMatrix(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+
+	vole, ole := seeds[0].U, seeds[0].C
+	assert.Equal(t, 3, vole.N())
+	assert.Equal(t, 2, vole.C())
+	assert.False(t, vole.IsOLE())
+	assert.True(t, ole.IsOLE())
+
+	// The blocks are views into the slab in the order of the indices
+	assert.Equal(t, vole.At(1, 2, 1), &vole.Block(1, 2)[1])
+	assert.Equal(t, ole.AtOLE(2, 0, 1, 0), &ole.Block(2, 0)[1*2+0])
+	assert.Len(t, ole.Block(0, 1), 4)
+	assert.NotEmpty(t, vole.At(0, 1, 0).Key0.DPFKeys)
+	assert.Empty(t, vole.At(1, 1, 0).Key0.DPFKeys) // the diagonal holds no keys
+
+	// A clone shares the keys, but not the pairs
+	clone := ole.Clone()
+	assert.Equal(t, ole.AtOLE(0, 1, 1, 1), clone.AtOLE(0, 1, 1, 1))
+	*clone.AtOLE(0, 1, 1, 1) = DSPFKeyPair{}
+	assert.NotEmpty(t, ole.AtOLE(0, 1, 1, 1).Key0.DPFKeys)
+
+	assert.Panics(t, func() { vole.At(3, 0, 0) })
+	assert.Panics(t, func() { vole.At(0, 1, 2) })
+	assert.Panics(t, func() { vole.AtOLE(0, 1, 0, 0) })
+	assert.Panics(t, func() { ole.At(0, 1, 0) })
+}
+
+func TestDSPFKeyMatrixSerializedSize(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+
+	// The flat layout is smaller than the nested layout of the format version 2
+	flat, err := seeds[0].keysData()
+	assert.Nil(t, err)
+	nested, err := nestedKeysData(seeds[0])
+	assert.Nil(t, err)
+	var flatBuf, nestedBuf bytes.Buffer
+	assert.Nil(t, gob.NewEncoder(&flatBuf).Encode(flat))
+	assert.Nil(t, gob.NewEncoder(&nestedBuf).Encode(nested))
+	assert.Less(t, flatBuf.Len(), nestedBuf.Len())
+
+	// Invalid amounts of keys are rejected
+	flat.U.Keys = flat.U.Keys[1:]
+	_, err = flat.U.matrix()
+	assert.NotNil(t, err)
+	_, err = (&keyMatrixData{N: -1}).matrix()
+	assert.NotNil(t, err)
+}
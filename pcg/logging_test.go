@@ -0,0 +1,59 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingLogger records all messages for testing.
+type recordingLogger struct {
+	sync.Mutex
+	debug, info []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	l.debug = append(l.debug, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	l.info = append(l.info, fmt.Sprintf(format, args...))
+}
+
+func TestPCGLogger(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4) // Small lpn parameters for testing.
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+
+	// The default logger discards all messages
+	_, err = pcg.EvalSeparate(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+
+	logger := &recordingLogger{}
+	pcg.SetLogger(logger)
+	generator, err := pcg.EvalSeparate(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	assert.NotEmpty(t, logger.debug)
+	assert.Len(t, logger.info, 1)
+	assert.True(t, strings.HasPrefix(logger.info[0], "Total time for EVAL"))
+
+	// The generator inherits the logger
+	debugMessages := len(logger.debug)
+	assert.Nil(t, generator.GenBBSPlusTuple(ring.Roots[0], []int{1, 2}))
+	assert.Len(t, logger.debug, debugMessages+1)
+	assert.Contains(t, logger.debug[debugMessages], "rejected signer set")
+
+	pcg.SetLogger(nil)
+	assert.NotNil(t, pcg.logger)
+}
@@ -0,0 +1,242 @@
+package pcg
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"math/big"
+)
+
+// vectorMatrix holds the t-vectors of the sparse noise polynomials of all parties, indexed by [party][block][slot]:
+// block r < c of party i holds the t slots of the r-th polynomial of party i. All blocks hold the same amount of slots.
+type vectorMatrix[T any] struct {
+	parties, blocks, slots int
+	values                 [][][]T
+}
+
+// newVectorMatrix validates the dimensions of the values and returns their matrix. isNil reports nil elements.
+func newVectorMatrix[T any](values [][][]T, isNil func(T) bool) (vectorMatrix[T], error) {
+	if len(values) == 0 {
+		return vectorMatrix[T]{}, fmt.Errorf("matrix holds no parties")
+	}
+	m := vectorMatrix[T]{parties: len(values), blocks: len(values[0]), values: values}
+	if m.blocks > 0 {
+		m.slots = len(values[0][0])
+	}
+	for i, party := range values {
+		if len(party) != m.blocks {
+			return vectorMatrix[T]{}, fmt.Errorf("party %d holds %d blocks but %d are expected", i, len(party), m.blocks)
+		}
+		for r, block := range party {
+			if len(block) != m.slots {
+				return vectorMatrix[T]{}, fmt.Errorf("block [%d][%d] holds %d slots but %d are expected", i, r, len(block), m.slots)
+			}
+			for k, value := range block {
+				if isNil(value) {
+					return vectorMatrix[T]{}, fmt.Errorf("slot [%d][%d][%d] must not be nil", i, r, k)
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// Parties returns the amount of parties n.
+func (m *vectorMatrix[T]) Parties() int {
+	return m.parties
+}
+
+// Blocks returns the amount of polynomials c per party.
+func (m *vectorMatrix[T]) Blocks() int {
+	return m.blocks
+}
+
+// Slots returns the amount of non-zero entries t per polynomial.
+func (m *vectorMatrix[T]) Slots() int {
+	return m.slots
+}
+
+// At returns the t-vector of block r of party i. The vector is part of the matrix. It panics if an index is out of
+// range.
+func (m *vectorMatrix[T]) At(i, r int) []T {
+	m.checkIndex(i, r)
+	return m.values[i][r]
+}
+
+// Set replaces the t-vector of block r of party i. It panics if an index is out of range or the vector does not hold
+// t slots.
+func (m *vectorMatrix[T]) Set(i, r int, vector []T) {
+	m.checkIndex(i, r)
+	if len(vector) != m.slots {
+		panic(fmt.Sprintf("vector holds %d slots but %d are expected", len(vector), m.slots))
+	}
+	m.values[i][r] = vector
+}
+
+// party returns the matrix of party i alone, which shares the vectors with m.
+func (m *vectorMatrix[T]) party(i int) vectorMatrix[T] {
+	m.checkIndex(i, 0)
+	return vectorMatrix[T]{parties: 1, blocks: m.blocks, slots: m.slots, values: m.values[i : i+1 : i+1]}
+}
+
+// checkIndex panics if party i or block r is out of range. Block 0 is accepted for matrices without blocks.
+func (m *vectorMatrix[T]) checkIndex(i, r int) {
+	if i < 0 || i >= m.parties {
+		panic(fmt.Sprintf("party index %d out of range [0, %d)", i, m.parties))
+	}
+	if r < 0 || r >= max(m.blocks, 1) {
+		panic(fmt.Sprintf("block index %d out of range [0, %d)", r, m.blocks))
+	}
+}
+
+// ExponentMatrix holds the exponents of the t-sparse noise polynomials, e.g. At(i, r) are the exponents of u_r of
+// party i. A seed holds the matrix of its own party only (see Party).
+type ExponentMatrix struct {
+	vectorMatrix[*big.Int]
+}
+
+// NewExponentMatrix returns the matrix of the exponents indexed by [party][block][slot]. It errs if the parties hold
+// different amounts of blocks, the blocks hold different amounts of slots or an exponent is nil.
+func NewExponentMatrix(values [][][]*big.Int) (*ExponentMatrix, error) {
+	m, err := newVectorMatrix(values, func(v *big.Int) bool { return v == nil })
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent matrix: %w", err)
+	}
+	return &ExponentMatrix{m}, nil
+}
+
+// Party returns the matrix of party i alone, which shares the vectors with m. It panics if i is out of range.
+func (m *ExponentMatrix) Party(i int) *ExponentMatrix {
+	return &ExponentMatrix{m.party(i)}
+}
+
+// vectors returns the vectors of the first party, i.e. of the party of a seed's matrix, or nil if m is nil.
+func (m *ExponentMatrix) vectors() [][]*big.Int {
+	if m == nil {
+		return nil
+	}
+	return m.values[0]
+}
+
+// Equal returns whether both matrices hold the same exponents.
+func (m *ExponentMatrix) Equal(o *ExponentMatrix) bool {
+	return equalVectorMatrices(&m.vectorMatrix, &o.vectorMatrix, func(a, b *big.Int) bool { return a.Cmp(b) == 0 })
+}
+
+// Serialize serializes the matrix.
+func (m *ExponentMatrix) Serialize() ([]byte, error) {
+	return encodeMatrix(m.values)
+}
+
+// Deserialize deserializes a matrix serialized via Serialize and sets the matrix the function is being called on.
+func (m *ExponentMatrix) Deserialize(data []byte) error {
+	var values [][][]*big.Int
+	if err := decodeMatrix(data, &values); err != nil {
+		return err
+	}
+	decoded, err := NewExponentMatrix(values)
+	if err != nil {
+		return err
+	}
+	*m = *decoded
+	return nil
+}
+
+// CoefficientMatrix holds the non-zero coefficients of the t-sparse noise polynomials, e.g. At(i, r) are the
+// coefficients of u_r of party i. A seed holds the matrix of its own party only (see Party).
+type CoefficientMatrix struct {
+	vectorMatrix[*bls12381.Fr]
+}
+
+// NewCoefficientMatrix returns the matrix of the coefficients indexed by [party][block][slot]. It errs if the parties
+// hold different amounts of blocks, the blocks hold different amounts of slots or a coefficient is nil.
+func NewCoefficientMatrix(values [][][]*bls12381.Fr) (*CoefficientMatrix, error) {
+	m, err := newVectorMatrix(values, func(v *bls12381.Fr) bool { return v == nil })
+	if err != nil {
+		return nil, fmt.Errorf("invalid coefficient matrix: %w", err)
+	}
+	return &CoefficientMatrix{m}, nil
+}
+
+// Party returns the matrix of party i alone, which shares the vectors with m. It panics if i is out of range.
+func (m *CoefficientMatrix) Party(i int) *CoefficientMatrix {
+	return &CoefficientMatrix{m.party(i)}
+}
+
+// vectors returns the vectors of the first party, i.e. of the party of a seed's matrix, or nil if m is nil.
+func (m *CoefficientMatrix) vectors() [][]*bls12381.Fr {
+	if m == nil {
+		return nil
+	}
+	return m.values[0]
+}
+
+// Equal returns whether both matrices hold the same coefficients.
+func (m *CoefficientMatrix) Equal(o *CoefficientMatrix) bool {
+	return equalVectorMatrices(&m.vectorMatrix, &o.vectorMatrix, func(a, b *bls12381.Fr) bool { return a.Equal(b) })
+}
+
+// Serialize serializes the matrix. The coefficients are encoded via ToBytes.
+func (m *CoefficientMatrix) Serialize() ([]byte, error) {
+	encoded := make([][][][]byte, m.parties)
+	for i, party := range m.values {
+		encoded[i] = frMatrixToBytes(party)
+	}
+	return encodeMatrix(encoded)
+}
+
+// Deserialize deserializes a matrix serialized via Serialize and sets the matrix the function is being called on.
+func (m *CoefficientMatrix) Deserialize(data []byte) error {
+	var encoded [][][][]byte
+	if err := decodeMatrix(data, &encoded); err != nil {
+		return err
+	}
+	values := make([][][]*bls12381.Fr, len(encoded))
+	for i, party := range encoded {
+		var err error
+		if values[i], err = frMatrixFromBytes(party); err != nil {
+			return err
+		}
+	}
+	decoded, err := NewCoefficientMatrix(values)
+	if err != nil {
+		return err
+	}
+	*m = *decoded
+	return nil
+}
+
+// equalVectorMatrices returns whether both matrices are of the same dimensions and hold equal elements.
+func equalVectorMatrices[T any](a, b *vectorMatrix[T], equal func(T, T) bool) bool {
+	if a.parties != b.parties || a.blocks != b.blocks || a.slots != b.slots {
+		return false
+	}
+	for i := range a.values {
+		for r := range a.values[i] {
+			for k := range a.values[i][r] {
+				if !equal(a.values[i][r][k], b.values[i][r][k]) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// encodeMatrix gob encodes the values of a matrix.
+func encodeMatrix(values any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, fmt.Errorf("failed to encode matrix: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeMatrix gob decodes the values of a matrix encoded via encodeMatrix.
+func decodeMatrix(data []byte, values any) error {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(values); err != nil {
+		return fmt.Errorf("failed to decode matrix: %w", err)
+	}
+	return nil
+}
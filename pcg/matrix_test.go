@@ -0,0 +1,94 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestNewExponentMatrixValidatesDimensions(t *testing.T) {
+	m, err := NewExponentMatrix([][][]*big.Int{{{big.NewInt(1), big.NewInt(2)}}, {{big.NewInt(3), big.NewInt(4)}}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, m.Parties())
+	assert.Equal(t, 1, m.Blocks())
+	assert.Equal(t, 2, m.Slots())
+	assert.Equal(t, int64(3), m.At(1, 0)[0].Int64())
+
+	for _, values := range [][][][]*big.Int{
+		nil,
+		{{{big.NewInt(1)}}, {}}, // a party without blocks
+		{{{big.NewInt(1)}, {big.NewInt(2), big.NewInt(3)}}}, // blocks of different lengths
+		{{{big.NewInt(1), nil}}},                            // a nil exponent
+	} {
+		_, err := NewExponentMatrix(values)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestMatrixAccessors(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	exponents := pcg.sampleExponents()
+	assert.Equal(t, []int{3, 2, 4}, []int{exponents.Parties(), exponents.Blocks(), exponents.Slots()})
+
+	// The matrix of a party shares its vectors with the matrix of all parties
+	party := exponents.Party(2)
+	assert.Equal(t, 1, party.Parties())
+	vector := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	party.Set(0, 1, vector)
+	assert.Equal(t, vector, exponents.At(2, 1))
+
+	assert.Panics(t, func() { exponents.At(3, 0) })
+	assert.Panics(t, func() { exponents.At(0, 2) })
+	assert.Panics(t, func() { exponents.Set(0, 0, vector[:3]) })
+	assert.Panics(t, func() { exponents.Party(-1) })
+}
+
+func TestMatrixSerialization(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+
+	exponents := pcg.sampleExponents()
+	data, err := exponents.Serialize()
+	assert.Nil(t, err)
+	decodedExponents := new(ExponentMatrix)
+	assert.Nil(t, decodedExponents.Deserialize(data))
+	assert.True(t, exponents.Equal(decodedExponents))
+	assert.False(t, exponents.Equal(pcg.sampleExponents()))
+
+	coefficients := pcg.sampleCoefficients()
+	data, err = coefficients.Serialize()
+	assert.Nil(t, err)
+	decodedCoefficients := new(CoefficientMatrix)
+	assert.Nil(t, decodedCoefficients.Deserialize(data))
+	assert.True(t, coefficients.Equal(decodedCoefficients))
+	assert.False(t, coefficients.Party(0).Equal(coefficients))
+
+	assert.NotNil(t, decodedCoefficients.Deserialize(data[:len(data)/2]))
+}
+
+func TestConstructPolysRequiresSeedMatrices(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	exponents, coefficients := pcg.sampleExponents(), pcg.sampleCoefficients()
+
+	// The matrices of all parties are rejected, as constructPolys derives the polynomials of a single party
+	_, err = pcg.constructPolys(coefficients, exponents)
+	assert.NotNil(t, err)
+
+	polys, err := pcg.constructPolys(coefficients.Party(1), exponents.Party(1))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(polys))
+	for r, p := range polys {
+		for k, exponent := range exponents.At(1, r) {
+			assert.True(t, p.Coefficients[int(exponent.Int64())].Equal(coefficients.At(1, r)[k]))
+		}
+	}
+
+	// Matrices of other parameters are rejected
+	wrong, err := NewCoefficientMatrix([][][]*bls12381.Fr{{coefficients.At(0, 0)}})
+	assert.Nil(t, err)
+	_, err = pcg.constructPolys(wrong, exponents.Party(0))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,191 @@
+package pcg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"math"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// MaxSupportedN is the largest domain N the PCG evaluates, as the products of the random polynomials with the
+// polynomials of Eval require an FFT of size 2^(N+1).
+const MaxSupportedN = poly.MaxFFTOrder - 1
+
+// The memory estimates are linear in the domain 2^N. The factors were measured as the peak heap of the stages for
+// N in [12, 14] and c in [2, 4] on amd64, incl. the garbage the stages leave to the collector.
+const (
+	bytesPerCoefficient      = 200  // bytesPerCoefficient is the size of a coefficient of a dense polynomial (map entry and field element)
+	bytesPerRoot             = 128  // bytesPerRoot is the size of a materialized root of GetRing
+	bytesPerPointCoefficient = 1024 // bytesPerPointCoefficient is the size per coefficient of EvalCombinedAt (full DSPF evaluations)
+)
+
+// MemoryEstimate is the estimated peak heap memory of the stages of the PCG in bytes (see EstimateMemory).
+type MemoryEstimate struct {
+	N           int   // N is the domain of the estimated stages.
+	Ring        int64 // Ring is the size of the materialized roots of GetRing. The lazy ring holds no roots.
+	RandomPolys int64 // RandomPolys is the size of the public random polynomials of PickRandomPolynomials.
+	Eval        int64 // Eval is the peak of EvalCombined or EvalSeparate, depending on tau, incl. the random polynomials.
+	EvalAt      int64 // EvalAt is the peak of EvalCombinedAt, which holds the outputs of a single DSPF key at a time.
+	Limit       int64 // Limit is the memory limit the stages are checked against, 0 if there is none (see SetMemoryLimit).
+}
+
+// String returns a summary of the estimate.
+func (e *MemoryEstimate) String() string {
+	limit := "none"
+	if e.Limit > 0 {
+		limit = formatBytes(e.Limit)
+	}
+	return fmt.Sprintf("N=%d: ring %s, random polynomials %s, eval %s, eval at roots %s (limit %s)",
+		e.N, formatBytes(e.Ring), formatBytes(e.RandomPolys), formatBytes(e.Eval), formatBytes(e.EvalAt), limit)
+}
+
+// EstimateMemory returns the estimated peak memory of the stages of the PCG, s.t. integrators can choose N before
+// running them. The stages refuse to run if their estimate exceeds the memory limit (see SetMemoryLimit).
+func (p *PCG) EstimateMemory() *MemoryEstimate {
+	return p.estimateMemory(p.N)
+}
+
+// estimateMemory returns the estimate for the parameters of the PCG, but the given N.
+func (p *PCG) estimateMemory(N int) *MemoryEstimate {
+	domain := math.Ldexp(1, N)
+	c, n := float64(p.c), float64(p.n)
+
+	// Eval holds the expanded correlations (c² OLE polynomials of 2^(N+1) coefficients), the outer product of the random
+	// polynomials and the final shares at once. The separate evaluation additionally holds the cross terms of each
+	// counterparty, i.e. four final shares.
+	units := 9*c*c + 28
+	if p.tau != p.n {
+		units += 8 * (n - 1)
+	}
+	randomPolys := (c - 1) * domain * bytesPerCoefficient
+	return &MemoryEstimate{
+		N:           N,
+		Ring:        saturatingInt64(domain * bytesPerRoot),
+		RandomPolys: saturatingInt64(randomPolys),
+		Eval:        saturatingInt64(units*domain*bytesPerCoefficient + randomPolys),
+		EvalAt:      saturatingInt64(domain*bytesPerPointCoefficient + randomPolys),
+		Limit:       p.memoryLimit,
+	}
+}
+
+// SetMemoryLimit sets the memory in bytes the stages of the PCG may use. A stage whose estimate exceeds the limit
+// returns a MemoryError instead of running out of memory midway. A limit of 0 disables the checks.
+// The limit defaults to DetectMemoryLimit.
+func (p *PCG) SetMemoryLimit(limit int64) {
+	p.memoryLimit = max(limit, 0)
+}
+
+// MemoryLimit returns the memory limit of the PCG, 0 if there is none (see SetMemoryLimit).
+func (p *PCG) MemoryLimit() int64 {
+	return p.memoryLimit
+}
+
+// MemoryError is returned by a stage of the PCG if its estimated memory exceeds the memory limit.
+type MemoryError struct {
+	Stage    string // Stage is the name of the refused stage, e.g. "EvalCombined".
+	N        int    // N is the domain of the PCG.
+	Required int64  // Required is the estimated peak memory of the stage in bytes.
+	Limit    int64  // Limit is the memory limit in bytes.
+	MaxN     int    // MaxN is the largest N, for which the stage fits into the limit, 0 if none does.
+	Hint     string // Hint suggests an alternative, which needs less memory.
+}
+
+func (e *MemoryError) Error() string {
+	fits := "no N fits into the limit"
+	if e.MaxN > 0 {
+		fits = fmt.Sprintf("the largest N that fits is %d", e.MaxN)
+	}
+	message := fmt.Sprintf("%s for N=%d needs about %s, but the memory limit is %s; %s", e.Stage, e.N, formatBytes(e.Required), formatBytes(e.Limit), fits)
+	if e.Hint != "" {
+		message += "; " + e.Hint
+	}
+	return message + " (see PCG.SetMemoryLimit)"
+}
+
+// checkMemory returns a MemoryError if the estimate of the stage, selected by size, exceeds the memory limit.
+func (p *PCG) checkMemory(stage string, size func(*MemoryEstimate) int64, hint string) error {
+	if p.memoryLimit == 0 {
+		return nil
+	}
+	required := size(p.estimateMemory(p.N))
+	if required <= p.memoryLimit {
+		return nil
+	}
+	maxN := 0
+	for N := p.N - 1; N >= 1; N-- {
+		if size(p.estimateMemory(N)) <= p.memoryLimit {
+			maxN = N
+			break
+		}
+	}
+	return &MemoryError{Stage: stage, N: p.N, Required: required, Limit: p.memoryLimit, MaxN: maxN, Hint: hint}
+}
+
+// DetectMemoryLimit returns the memory available to the process in bytes, i.e. the soft memory limit of the Go runtime
+// (GOMEMLIMIT) if set, and otherwise the memory limit of the cgroup or the physical memory on Linux. It returns 0 if
+// the memory is unknown, e.g. on other platforms.
+func DetectMemoryLimit() int64 {
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		return limit
+	}
+	limit := int64(0)
+	for _, path := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		if data, err := os.ReadFile(path); err == nil {
+			if value, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err == nil && value > 0 {
+				limit = value // "max" means unlimited and fails to parse
+				break
+			}
+		}
+	}
+	if total := physicalMemory(); total > 0 && (limit == 0 || total < limit) {
+		limit = total
+	}
+	return limit
+}
+
+// physicalMemory returns the total memory of /proc/meminfo in bytes, 0 if unknown.
+func physicalMemory() int64 {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kiB, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kiB * 1024
+		}
+	}
+	return 0
+}
+
+// formatBytes formats the size in binary units, e.g. 1.5 GiB.
+func formatBytes(size int64) string {
+	value, units := float64(size), []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// saturatingInt64 converts the size to an int64, saturating at math.MaxInt64.
+func saturatingInt64(size float64) int64 {
+	if size >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(size)
+}
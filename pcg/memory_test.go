@@ -0,0 +1,100 @@
+package pcg
+
+import (
+	"errors"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEstimateMemory(t *testing.T) {
+	pcg, err := NewPCG(128, 20, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	pcg.SetMemoryLimit(1 << 30)
+	estimate := pcg.EstimateMemory()
+	assert.Equal(t, 20, estimate.N)
+	assert.Equal(t, int64(1<<30), estimate.Limit)
+	assert.True(t, estimate.Ring > 0 && estimate.RandomPolys > 0)
+	assert.True(t, estimate.EvalAt < estimate.Eval)
+	assert.Contains(t, estimate.String(), "limit 1.0 GiB")
+
+	// The estimates double with the domain and grow with c and the separate evaluation
+	smaller := pcg.estimateMemory(19)
+	assert.Equal(t, 2*smaller.Ring, estimate.Ring)
+	assert.InDelta(t, 2*smaller.Eval, estimate.Eval, 1)
+	wider, err := NewPCG(128, 20, 2, 2, 4, 4)
+	assert.Nil(t, err)
+	assert.True(t, wider.EstimateMemory().Eval > estimate.Eval)
+	separate, err := NewPCG(128, 20, 3, 2, 2, 4)
+	assert.Nil(t, err)
+	assert.True(t, separate.EstimateMemory().Eval > estimate.Eval)
+
+	// The largest supported domain does not overflow
+	largest, err := NewPCG(128, MaxSupportedN, 2, 2, 4, 4)
+	assert.Nil(t, err)
+	assert.True(t, largest.EstimateMemory().Eval > wider.EstimateMemory().Eval)
+}
+
+func TestMemoryLimit(t *testing.T) {
+	pcg, err := NewPCG(128, 10, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, DetectMemoryLimit(), pcg.MemoryLimit())
+
+	// A limit below the estimates refuses the stages with an actionable error
+	pcg.SetMemoryLimit(pcg.estimateMemory(7).Eval)
+	_, err = pcg.GetRing(false)
+	assert.Nil(t, err)
+	rand, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	_, err = pcg.NewEvalSession(rand, ring.Prepared())
+	var memoryErr *MemoryError
+	assert.True(t, errors.As(err, &memoryErr))
+	assert.Equal(t, "Eval", memoryErr.Stage)
+	assert.Equal(t, 7, memoryErr.MaxN)
+	assert.Contains(t, err.Error(), "the largest N that fits is 7")
+	assert.Contains(t, err.Error(), "EvalCombinedAt")
+
+	pcg.SetMemoryLimit(1)
+	_, err = pcg.GetRing(false)
+	assert.True(t, errors.As(err, &memoryErr))
+	assert.Equal(t, 0, memoryErr.MaxN)
+	assert.Contains(t, err.Error(), "GetLazyRing")
+	_, err = pcg.PickRandomPolynomials()
+	assert.True(t, errors.As(err, &memoryErr))
+	_, err = pcg.EvalCombinedAt(nil, rand, ring.Prepared(), []int{0})
+	assert.True(t, errors.As(err, &memoryErr))
+
+	// A limit of 0 disables the checks
+	pcg.SetMemoryLimit(0)
+	_, err = pcg.NewEvalSession(rand, ring.Prepared())
+	assert.Nil(t, err)
+}
+
+func TestMaxSupportedN(t *testing.T) {
+	assert.Equal(t, 25, MaxSupportedN)
+	assert.Equal(t, MaxSupportedN+1, poly.MaxFFTOrder)
+
+	// N = MaxSupportedN passes the FFT bound and is only refused by the memory limit
+	pcg, err := NewPCG(128, MaxSupportedN, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	pcg.SetMemoryLimit(1 << 20)
+	_, err = pcg.PickRandomPolynomials()
+	var memoryErr *MemoryError
+	assert.True(t, errors.As(err, &memoryErr))
+	_, err = pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	pcg, err = NewPCG(128, MaxSupportedN+1, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	_, err = pcg.PickRandomPolynomials()
+	assert.NotNil(t, err)
+	assert.False(t, errors.As(err, &memoryErr))
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 GiB", formatBytes(2<<30))
+}
@@ -0,0 +1,127 @@
+package pcg
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// The Merkle tree follows RFC 6962: leaves and inner nodes are hashed with distinct prefixes and a tree over n leaves
+// is split at the largest power of two smaller than n, s.t. no leaf is duplicated.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// MerkleProof is the inclusion proof of the seed of a party in the Merkle root published by the dealer.
+type MerkleProof struct {
+	Index     int        // Index is the index of the leaf, i.e. the index of the party.
+	LeafCount int        // LeafCount is the amount of leaves, i.e. the amount of parties.
+	Siblings  [][32]byte // Siblings are the hashes of the sibling nodes from the leaf to the root.
+}
+
+// MerkleCommitSeeds commits to the seeds of all parties via a Merkle tree over their digests (see Seed.Digest).
+// The dealer publishes the root and hands each party its seed together with its proof, s.t. a party can later prove
+// which seed it was given via VerifySeedInclusion. proofs[i] is the proof of seeds[i].
+func MerkleCommitSeeds(seeds []*Seed) ([32]byte, []*MerkleProof, error) {
+	if len(seeds) == 0 {
+		return [32]byte{}, nil, fmt.Errorf("at least one seed is required")
+	}
+
+	leaves := make([][32]byte, len(seeds))
+	for i, seed := range seeds {
+		seedHash, err := seed.Digest()
+		if err != nil {
+			return [32]byte{}, nil, fmt.Errorf("failed to hash seed %d: %w", i, err)
+		}
+		leaves[i] = merkleLeaf(seedHash)
+	}
+
+	proofs := make([]*MerkleProof, len(seeds))
+	for i := range leaves {
+		proofs[i] = &MerkleProof{
+			Index:     i,
+			LeafCount: len(leaves),
+			Siblings:  merklePath(i, leaves),
+		}
+	}
+	return merkleRoot(leaves), proofs, nil
+}
+
+// VerifySeedInclusion verifies that the seed with the given digest (see Seed.Digest) is included in the Merkle root
+// at the index of the proof.
+func VerifySeedInclusion(root [32]byte, proof *MerkleProof, seedHash [32]byte) error {
+	if proof == nil || proof.Index < 0 || proof.Index >= proof.LeafCount {
+		return fmt.Errorf("invalid inclusion proof")
+	}
+
+	// Verification of an audit path as specified in RFC 9162, section 2.1.3.2
+	fn, sn := proof.Index, proof.LeafCount-1
+	node := merkleLeaf(seedHash)
+	for _, sibling := range proof.Siblings {
+		if sn == 0 {
+			return fmt.Errorf("inclusion proof holds too many siblings")
+		}
+		if fn%2 == 1 || fn == sn {
+			node = merkleNode(sibling, node)
+			for fn%2 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			node = merkleNode(node, sibling)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return fmt.Errorf("inclusion proof holds too few siblings")
+	}
+	if node != root {
+		return fmt.Errorf("seed is not included in the root at index %d", proof.Index)
+	}
+	return nil
+}
+
+// merkleRoot returns the root of the Merkle tree over the given leaf hashes.
+func merkleRoot(leaves [][32]byte) [32]byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	k := merkleSplit(len(leaves))
+	return merkleNode(merkleRoot(leaves[:k]), merkleRoot(leaves[k:]))
+}
+
+// merklePath returns the siblings of the leaf with the given index from the leaf to the root.
+func merklePath(index int, leaves [][32]byte) [][32]byte {
+	if len(leaves) == 1 {
+		return nil
+	}
+	k := merkleSplit(len(leaves))
+	if index < k {
+		return append(merklePath(index, leaves[:k]), merkleRoot(leaves[k:]))
+	}
+	return append(merklePath(index-k, leaves[k:]), merkleRoot(leaves[:k]))
+}
+
+// merkleSplit returns the largest power of two smaller than n > 1.
+func merkleSplit(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// merkleLeaf returns the leaf hash of a seed digest.
+func merkleLeaf(seedHash [32]byte) [32]byte {
+	return sha256.Sum256(append([]byte{merkleLeafPrefix}, seedHash[:]...))
+}
+
+// merkleNode returns the hash of the inner node with the given children.
+func merkleNode(left, right [32]byte) [32]byte {
+	buf := make([]byte, 0, 1+2*sha256.Size)
+	buf = append(buf, merkleNodePrefix)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
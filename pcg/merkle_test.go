@@ -0,0 +1,66 @@
+package pcg
+
+import (
+	"crypto/sha256"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMerkleCommitSeeds(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+
+	root, proofs, err := MerkleCommitSeeds(seeds)
+	assert.Nil(t, err)
+	assert.Len(t, proofs, len(seeds))
+
+	for i, seed := range seeds {
+		seedHash, err := seed.Digest()
+		assert.Nil(t, err)
+		assert.Nil(t, VerifySeedInclusion(root, proofs[i], seedHash))
+
+		// A seed does not verify at the index of another party
+		other := proofs[(i+1)%len(seeds)]
+		assert.NotNil(t, VerifySeedInclusion(root, other, seedHash))
+	}
+
+	// A modified seed is not included
+	modified := *seeds[0]
+	modified.skShareIndex++
+	modifiedHash, err := modified.Digest()
+	assert.Nil(t, err)
+	assert.NotNil(t, VerifySeedInclusion(root, proofs[0], modifiedHash))
+
+	_, _, err = MerkleCommitSeeds(nil)
+	assert.NotNil(t, err)
+}
+
+func TestMerkleProofsForAllSizes(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		seedHashes := make([][32]byte, n)
+		leaves := make([][32]byte, n)
+		for i := range seedHashes {
+			seedHashes[i] = sha256.Sum256([]byte{byte(i)})
+			leaves[i] = merkleLeaf(seedHashes[i])
+		}
+		root := merkleRoot(leaves)
+
+		for i := range leaves {
+			proof := &MerkleProof{Index: i, LeafCount: n, Siblings: merklePath(i, leaves)}
+			assert.Nil(t, VerifySeedInclusion(root, proof, seedHashes[i]), "n=%d, i=%d", n, i)
+
+			// Truncated and extended proofs are rejected
+			if len(proof.Siblings) > 0 {
+				truncated := &MerkleProof{Index: i, LeafCount: n, Siblings: proof.Siblings[1:]}
+				assert.NotNil(t, VerifySeedInclusion(root, truncated, seedHashes[i]))
+			}
+			extended := &MerkleProof{Index: i, LeafCount: n, Siblings: append(proof.Siblings, root)}
+			assert.NotNil(t, VerifySeedInclusion(root, extended, seedHashes[i]))
+		}
+	}
+
+	assert.NotNil(t, VerifySeedInclusion([32]byte{}, nil, [32]byte{}))
+	assert.NotNil(t, VerifySeedInclusion([32]byte{}, &MerkleProof{Index: 2, LeafCount: 2}, [32]byte{}))
+}
@@ -0,0 +1,198 @@
+// Package metrics defines the small metrics interface the PCG, the tuple generators and the tuple store report to,
+// and a Registry implementing it, which serves the metrics in the Prometheus text format via HTTP. Library users that
+// do not serve metrics leave the metrics unset and need no metrics library at all.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the metrics reported by the components of the PCG.
+const (
+	SeedsGenerated   = "pcg_seeds_generated_total"       // SeedsGenerated counts the seeds generated by TrustedSeedGen.
+	EvalPhaseSeconds = "pcg_eval_phase_duration_seconds" // EvalPhaseSeconds sums the durations of the phases of Eval, labeled by phase.
+	TuplesGenerated  = "pcg_tuples_generated_total"      // TuplesGenerated counts the tuples of the generators, labeled by result.
+	StoreAvailable   = "pcg_tuple_store_available"       // StoreAvailable is the amount of available tuples of a store.
+	StoreReserved    = "pcg_tuple_store_reserved"        // StoreReserved is the amount of reserved tuples of a store, i.e. of running sessions.
+)
+
+// Label is a label of a metric, e.g. the phase of a duration.
+type Label struct {
+	Name, Value string
+}
+
+// Metrics receives the metrics of the components. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Add adds delta to the counter with the given name and labels.
+	Add(name string, delta float64, labels ...Label)
+	// Set sets the gauge with the given name and labels.
+	Set(name string, value float64, labels ...Label)
+	// Observe adds an observation, e.g. a duration in seconds, to the summary with the given name and labels.
+	Observe(name string, value float64, labels ...Label)
+}
+
+// Nop is a Metrics that discards all metrics. It is the default of all components.
+type Nop struct{}
+
+// Add discards the metric.
+func (Nop) Add(string, float64, ...Label) {}
+
+// Set discards the metric.
+func (Nop) Set(string, float64, ...Label) {}
+
+// Observe discards the metric.
+func (Nop) Observe(string, float64, ...Label) {}
+
+// OrNop returns m, or Nop if m is nil.
+func OrNop(m Metrics) Metrics {
+	if m == nil {
+		return Nop{}
+	}
+	return m
+}
+
+// metricType is the type of a metric in the Prometheus text format.
+type metricType string
+
+const (
+	counterType metricType = "counter"
+	gaugeType   metricType = "gauge"
+	summaryType metricType = "summary"
+)
+
+// series is a metric with a fixed set of labels.
+type series struct {
+	labels string // labels is the encoding of the labels in the Prometheus text format, e.g. {phase="VOLE"}.
+	value  float64
+	count  uint64 // count is the amount of observations of a summary.
+}
+
+// family holds all series of a metric.
+type family struct {
+	typ    metricType
+	series map[string]*series
+}
+
+// Registry is a Metrics holding the metrics in memory. It serves them in the Prometheus text format (see Handler).
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Add implements Metrics.
+func (r *Registry) Add(name string, delta float64, labels ...Label) {
+	r.update(name, counterType, labels, func(s *series) { s.value += delta })
+}
+
+// Set implements Metrics.
+func (r *Registry) Set(name string, value float64, labels ...Label) {
+	r.update(name, gaugeType, labels, func(s *series) { s.value = value })
+}
+
+// Observe implements Metrics. The summaries only hold the sum and count of the observations.
+func (r *Registry) Observe(name string, value float64, labels ...Label) {
+	r.update(name, summaryType, labels, func(s *series) {
+		s.value += value
+		s.count++
+	})
+}
+
+// update applies fn to the series of the labels of the metric. Metrics reported with another type are ignored, as the
+// type of a metric must not change.
+func (r *Registry) update(name string, typ metricType, labels []Label, fn func(s *series)) {
+	encoded := encodeLabels(labels)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{typ: typ, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+	if f.typ != typ {
+		return
+	}
+	s, ok := f.series[encoded]
+	if !ok {
+		s = &series{labels: encoded}
+		f.series[encoded] = s
+	}
+	fn(s)
+}
+
+// Value returns the value of the counter or gauge, or the sum of the summary, with the given name and labels, and
+// whether it was reported.
+func (r *Registry) Value(name string, labels ...Label) (float64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if s, ok := f.series[encodeLabels(labels)]; ok {
+			return s.value, true
+		}
+	}
+	return 0, false
+}
+
+// WriteText writes all metrics in the Prometheus text format, sorted by name and labels.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.typ)
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := f.series[key]
+			if f.typ == summaryType {
+				fmt.Fprintf(&b, "%s_sum%s %v\n", name, s.labels, s.value)
+				fmt.Fprintf(&b, "%s_count%s %d\n", name, s.labels, s.count)
+				continue
+			}
+			fmt.Fprintf(&b, "%s%s %v\n", name, s.labels, s.value)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler returns the HTTP handler serving the metrics in the Prometheus text format, e.g. at /metrics of a server.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteText(w) // the client is gone if writing fails
+	})
+}
+
+// encodeLabels encodes the labels sorted by name in the Prometheus text format.
+func encodeLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	sorted := append([]Label(nil), labels...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	parts := make([]string, len(sorted))
+	for i, label := range sorted {
+		parts[i] = fmt.Sprintf("%s=%q", label.Name, label.Value)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	r := NewRegistry()
+	r.Add(TuplesGenerated, 2, Label{Name: "result", Value: "ok"})
+	r.Add(TuplesGenerated, 3, Label{Name: "result", Value: "ok"})
+	r.Add(TuplesGenerated, 1, Label{Name: "result", Value: "failed"})
+	r.Set(StoreAvailable, 10)
+	r.Set(StoreAvailable, 7)
+	r.Observe(EvalPhaseSeconds, 1.5, Label{Name: "phase", Value: "VOLE"})
+	r.Observe(EvalPhaseSeconds, 0.5, Label{Name: "phase", Value: "VOLE"})
+	r.Set(TuplesGenerated, 100) // another type is ignored
+
+	var b strings.Builder
+	assert.Nil(t, r.WriteText(&b))
+	expected := `# TYPE pcg_eval_phase_duration_seconds summary
+pcg_eval_phase_duration_seconds_sum{phase="VOLE"} 2
+pcg_eval_phase_duration_seconds_count{phase="VOLE"} 2
+# TYPE pcg_tuple_store_available gauge
+pcg_tuple_store_available 7
+# TYPE pcg_tuples_generated_total counter
+pcg_tuples_generated_total{result="failed"} 1
+pcg_tuples_generated_total{result="ok"} 5
+`
+	assert.Equal(t, expected, b.String())
+
+	value, ok := r.Value(TuplesGenerated, Label{Name: "result", Value: "ok"})
+	assert.True(t, ok)
+	assert.Equal(t, 5.0, value)
+	_, ok = r.Value(SeedsGenerated)
+	assert.False(t, ok)
+}
+
+func TestRegistryLabelOrder(t *testing.T) {
+	r := NewRegistry()
+	r.Add("requests", 1, Label{Name: "b", Value: "2"}, Label{Name: "a", Value: "1"})
+	r.Add("requests", 1, Label{Name: "a", Value: "1"}, Label{Name: "b", Value: "2"})
+	value, ok := r.Value("requests", Label{Name: "a", Value: "1"}, Label{Name: "b", Value: "2"})
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, value)
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	r := NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Add(SeedsGenerated, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	value, _ := r.Value(SeedsGenerated)
+	assert.Equal(t, 800.0, value)
+}
+
+func TestRegistryHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Add(SeedsGenerated, 3)
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL + "/metrics")
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, response.Header.Get("Content-Type"), "text/plain")
+	assert.Equal(t, "# TYPE pcg_seeds_generated_total counter\npcg_seeds_generated_total 3\n", string(body))
+}
+
+func TestOrNop(t *testing.T) {
+	assert.Equal(t, Nop{}, OrNop(nil))
+	r := NewRegistry()
+	assert.Equal(t, r, OrNop(r))
+}
@@ -0,0 +1,84 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"math/big"
+	"math/bits"
+)
+
+// UseRegularNoise switches the PCG to regular noise, i.e. the exponents of each sparse polynomial hold exactly one
+// exponent per segment [k*2^N/t, (k+1)*2^N/t) of the domain. The DPFs of the DSPFs then only cover the segment of
+// their special point, which cuts the work of the full evaluations by roughly a factor t.
+//
+// Note that the security of the PCG then rests on the Module-LPN assumption with regular noise instead of the
+// (standard) Module-LPN assumption, and the noise entropy reported by SecurityLevel decreases accordingly.
+// Regular noise requires t to be a power of two of at most 2^(N-1). Seeds must be evaluated by a PCG with the same
+// noise distribution, which is why it is bound to the parameter digest.
+func (p *PCG) UseRegularNoise() error {
+	if p.t&(p.t-1) != 0 || p.t > 1<<(p.N-1) {
+		return fmt.Errorf("regular noise requires t to be a power of two of at most 2^(N-1)=%d but t is %d", 1<<(p.N-1), p.t)
+	}
+	segmentDomain := p.N - bits.TrailingZeros(uint(p.t)) // log2(2^N/t)
+	segmentLength := 1 << segmentDomain
+
+	// The special points of VOLE correlations are the exponents, i.e. the k-th point is within the k-th segment.
+	voleOffsets := make([]int, p.t)
+	for k := range voleOffsets {
+		voleOffsets[k] = k * segmentLength
+	}
+	// The special points of OLE correlations are the sums of the k-th and l-th exponent (see outerSumBigInt), which are
+	// within [(k+l)*2^N/t, (k+l+2)*2^N/t), i.e. a segment of twice the length.
+	oleOffsets := make([]int, 0, p.t*p.t)
+	for k := 0; k < p.t; k++ {
+		for l := 0; l < p.t; l++ {
+			oleOffsets = append(oleOffsets, (k+l)*segmentLength)
+		}
+	}
+
+	baseDpfDomain, err := p.newBaseDPF(segmentDomain)
+	if err != nil {
+		return fmt.Errorf("failed to initialize base DPF with the domain of a segment: %w", err)
+	}
+	baseDpfDoubleDomain, err := p.newBaseDPF(segmentDomain + 1)
+	if err != nil {
+		return fmt.Errorf("failed to initialize base DPF with the domain of a double segment: %w", err)
+	}
+	dspfN, err := dspf.NewSegmentDSPF(baseDpfDomain, p.N, segmentDomain, voleOffsets)
+	if err != nil {
+		return err
+	}
+	dspf2N, err := dspf.NewSegmentDSPF(baseDpfDoubleDomain, p.N+1, segmentDomain+1, oleOffsets)
+	if err != nil {
+		return err
+	}
+
+	dspfN.SetLogger(p.logger)
+	dspf2N.SetLogger(p.logger)
+	p.dspfN = dspfN
+	p.dspf2N = dspf2N
+	p.regularNoise = true
+	return nil
+}
+
+// RegularNoise returns whether the PCG uses regular noise (see UseRegularNoise).
+func (p *PCG) RegularNoise() bool {
+	return p.regularNoise
+}
+
+// sampleRegularExponents samples t exponents, where the k-th exponent is uniform within the k-th segment of the
+// domain. The exponents are thereby unique and sorted.
+func (p *PCG) sampleRegularExponents() []*big.Int {
+	segmentLength := new(big.Int).Div(p.domain, big.NewInt(int64(p.t)))
+	vec := make([]*big.Int, p.t)
+	for k := range vec {
+		vec[k] = p.rng.domain(domainExponents).Int(segmentLength)
+		vec[k].Add(vec[k], new(big.Int).Mul(segmentLength, big.NewInt(int64(k))))
+	}
+	return vec
+}
+
+// regularNoiseEntropy returns log2 of the amount of possible regular noise positions, i.e. t*log2(2^N/t).
+func regularNoiseEntropy(N, t int) int {
+	return t * (N - bits.TrailingZeros(uint(t)))
+}
@@ -0,0 +1,58 @@
+package pcg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestRegularNoiseEvalCombined(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	digest := pcg.paramsDigest()
+	level := pcg.SecurityLevel()
+	assert.Nil(t, pcg.UseRegularNoise())
+	assert.True(t, pcg.RegularNoise())
+	assert.NotEqual(t, digest, pcg.paramsDigest())
+	assert.Equal(t, 4*4, pcg.SecurityLevel().NoiseEntropy) // t*log2(2^N/t)
+	assert.Less(t, pcg.SecurityLevel().NoiseEntropy, level.NoiseEntropy)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	result, err := pcg.SimulateAllParties(seeds, randPolys, ring, 0)
+	assert.Nil(t, err)
+	assert.True(t, result.Correct())
+}
+
+func TestSampleRegularExponents(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 8)
+	assert.Nil(t, err)
+	assert.Nil(t, pcg.UseRegularNoise())
+
+	segmentLength := int64(64 / 8)
+	exponents := pcg.sampleExponents()
+	for i := 0; i < exponents.Parties(); i++ {
+		for r := 0; r < exponents.Blocks(); r++ {
+			vec := exponents.At(i, r)
+			assert.Equal(t, 8, len(vec))
+			for k, exp := range vec {
+				assert.Equal(t, int64(k), new(big.Int).Div(exp, big.NewInt(segmentLength)).Int64())
+			}
+		}
+	}
+}
+
+func TestUseRegularNoiseInvalidT(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 6)
+	assert.Nil(t, err)
+	assert.NotNil(t, pcg.UseRegularNoise()) // not a power of two
+	assert.False(t, pcg.RegularNoise())
+
+	_, err = NewPCG(128, 6, 2, 2, 2, 64)
+	assert.NotNil(t, err) // segments of a single point are rejected as t exceeds 2^N/4
+}
@@ -0,0 +1,52 @@
+package pcg
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"sync"
+	"sync/atomic"
+)
+
+// mulPoly multiplies two polynomials. It is a variable s.t. tests can inject failing multiplications.
+var mulPoly = poly.Mul
+
+// parallelFor calls fn(i) for all i in [0, n) on at most limit goroutines.
+// After the first error no further calls are started. parallelFor returns once all started calls returned,
+// i.e. no goroutine outlives it, and reports the error of the lowest index among the failed calls.
+// fn must only write to memory owned by index i.
+func parallelFor(n, limit int, fn func(i int) error) error {
+	if limit > n {
+		limit = n
+	}
+	if limit < 1 {
+		limit = 1
+	}
+
+	var next atomic.Int64
+	var failed atomic.Bool
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for w := 0; w < limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if err := fn(i); err != nil {
+					errs[i] = err
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
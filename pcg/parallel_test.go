@@ -0,0 +1,160 @@
+package pcg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelFor(t *testing.T) {
+	results := make([]int, 100)
+	err := parallelFor(len(results), 4, func(i int) error {
+		results[i] = i * i
+		return nil
+	})
+	assert.Nil(t, err)
+	for i, res := range results {
+		assert.Equal(t, i*i, res)
+	}
+
+	// The error of the lowest failed index is reported and no calls are started after a failure
+	var calls atomic.Int64
+	err = parallelFor(1000, 4, func(i int) error {
+		calls.Add(1)
+		if i >= 10 {
+			return fmt.Errorf("failed at %d", i)
+		}
+		return nil
+	})
+	assert.NotNil(t, err)
+	assert.Less(t, calls.Load(), int64(1000))
+
+	err = parallelFor(10, 1, func(i int) error {
+		if i == 3 || i == 7 {
+			return fmt.Errorf("failed at %d", i)
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "failed at 3")
+
+	assert.Nil(t, parallelFor(0, 4, func(i int) error { return errors.New("never called") }))
+}
+
+func TestFailingMultiplications(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 3, 4)
+	assert.Nil(t, err)
+	rand, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	w := [][]*poly.Polynomial{rand, rand, rand}
+	oprand, err := outerProductPoly(rand, rand)
+	assert.Nil(t, err)
+
+	// Inject a multiplication that fails on every second call
+	errInjected := errors.New("injected")
+	var calls atomic.Int64
+	mulPoly = func(p, q *poly.Polynomial) (*poly.Polynomial, error) {
+		if calls.Add(1)%2 == 0 {
+			return nil, errInjected
+		}
+		return poly.Mul(p, q)
+	}
+	defer func() { mulPoly = poly.Mul }()
+
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		_, err = outerProductPoly(rand, rand)
+		assert.ErrorIs(t, err, errInjected)
+		_, err = pcg.evalFinalShare(rand, rand, ring.Prepared())
+		assert.ErrorIs(t, err, errInjected)
+		_, err = pcg.evalFinalShare2D(w, oprand, ring.Prepared())
+		assert.ErrorIs(t, err, errInjected)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines) // no worker outlives a failed call
+
+	// Without failures, the results are complete
+	mulPoly = poly.Mul
+	oprand, err = outerProductPoly(rand, rand)
+	assert.Nil(t, err)
+	assert.Len(t, oprand, len(rand)*len(rand))
+	for i, product := range oprand {
+		expected, err := poly.Mul(rand[i/len(rand)], rand[i%len(rand)])
+		assert.Nil(t, err)
+		assert.True(t, expected.Equal(product))
+	}
+}
+
+func TestEvalFinalShareEdgeCases(t *testing.T) {
+	for _, c := range []int{1, 8} {
+		pcg, err := NewPCG(128, 6, 2, 2, c, 4)
+		assert.Nil(t, err)
+		rand, err := pcg.PickRandomPolynomials()
+		assert.Nil(t, err)
+		ring, err := pcg.GetLazyRing()
+		assert.Nil(t, err)
+		oprand, err := outerProductPoly(rand, rand)
+		assert.Nil(t, err)
+		w := make([][]*poly.Polynomial, c)
+		for r := range w {
+			w[r] = rand
+		}
+
+		// Sequential reference of the inner products
+		expected := poly.NewEmpty()
+		expected2D := poly.NewEmpty()
+		for r := 0; r < c; r++ {
+			product, err := poly.Mul(rand[r], rand[r])
+			assert.Nil(t, err)
+			expected.Add(product)
+			for s := 0; s < c; s++ {
+				product, err = poly.Mul(oprand[r*c+s], w[r][s])
+				assert.Nil(t, err)
+				expected2D.Add(product)
+			}
+		}
+		expected, err = expected.Mod(ring.Div)
+		assert.Nil(t, err)
+		expected2D, err = expected2D.Mod(ring.Div)
+		assert.Nil(t, err)
+
+		share, err := pcg.evalFinalShare(rand, rand, ring.Prepared())
+		assert.Nil(t, err)
+		assert.True(t, expected.Equal(share), "evalFinalShare differs for c = %d", c)
+		share2D, err := pcg.evalFinalShare2D(w, oprand, ring.Prepared())
+		assert.Nil(t, err)
+		assert.True(t, expected2D.Equal(share2D), "evalFinalShare2D differs for c = %d", c)
+	}
+}
+
+func TestEvalFinalShareErrorAttribution(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 8, 4)
+	assert.Nil(t, err)
+	rand, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	// Fail the multiplications of all polynomials from index 5 on, which is reported regardless of the scheduling
+	errInjected := errors.New("injected")
+	mulPoly = func(p, q *poly.Polynomial) (*poly.Polynomial, error) {
+		for r := 5; r < len(rand); r++ {
+			if p == rand[r] {
+				return nil, errInjected
+			}
+		}
+		return poly.Mul(p, q)
+	}
+	defer func() { mulPoly = poly.Mul }()
+
+	for i := 0; i < 10; i++ {
+		_, err = pcg.evalFinalShare(rand, rand, ring.Prepared())
+		var coordinateErr *coordinateError
+		assert.ErrorAs(t, err, &coordinateErr)
+		assert.Equal(t, 5, coordinateErr.r)
+	}
+}
@@ -0,0 +1,119 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"math"
+	"math/big"
+)
+
+// FieldSecurityLevel is the security level of BLS12-381 in bits.
+// All values of the PCG live in its scalar field Fr, regardless of lambda, hence a larger lambda does not increase the
+// security of the PCG beyond this level.
+const FieldSecurityLevel = 128
+
+// maxDomainBitLength bounds N, as the PCG evaluates polynomials with 2^(N+1) coefficients.
+const maxDomainBitLength = 32
+
+// tSafetyFactor bounds t to 2^N / tSafetyFactor, s.t. the noise stays sparse and the sampling of t unique exponents
+// (see sampleTUniqueExponents) rejects few samples.
+const tSafetyFactor = 4
+
+// SecurityLevel describes the security of a parameter set in bits.
+type SecurityLevel struct {
+	Lambda       int // Lambda is the requested security parameter, i.e. the seed length of the DPFs.
+	Field        int // Field is the security level of the field the PCG operates in.
+	NoiseEntropy int // NoiseEntropy is log2 of the amount of possible noise positions, i.e. log2(binomial(2^N, t)) or t*log2(2^N/t) for regular noise.
+	Effective    int // Effective is the minimum of the above and thereby an upper bound on the security of the PCG.
+}
+
+// CheckParameters validates the combination of the PCG parameters (see NewPCG) and returns their security level.
+// It returns an error for combinations that are invalid or nonsensical. A lambda of 192 or 256 is accepted, but the
+// field caps the Effective level at FieldSecurityLevel. t must be at most 2^N / tSafetyFactor, and c*t^2 must not
+// exceed the domain 2^(N+1) of the OLE DSPFs, whose t^2 special points per product of noise polynomials must stay
+// sparse. Note that the NoiseEntropy of small domains may be
+// below lambda, which is accepted for testing. Check Effective before deploying a parameter set.
+func CheckParameters(lambda, N, n, tau, c, t int) (*SecurityLevel, error) {
+	if lambda != 128 && lambda != 192 && lambda != 256 {
+		return nil, fmt.Errorf("lambda must be 128, 192, or 256 but is %d", lambda)
+	}
+	if N < 1 || N > maxDomainBitLength {
+		return nil, fmt.Errorf("N must be within [1, %d] but is %d", maxDomainBitLength, N)
+	}
+	if n < 2 {
+		return nil, fmt.Errorf("n must be at least 2 but is %d", n)
+	}
+	if tau < 1 || tau > n {
+		return nil, fmt.Errorf("tau must be within [1, n=%d] but is %d", n, tau)
+	}
+	if c < 1 {
+		return nil, fmt.Errorf("c must be at least 1 but is %d", c)
+	}
+	if t < 1 || int64(t) > int64(1)<<N {
+		return nil, fmt.Errorf("t must be within [1, 2^N=%d] but is %d", int64(1)<<N, t)
+	}
+	if maxT := (int64(1) << N) / tSafetyFactor; int64(t) > maxT {
+		return nil, fmt.Errorf("t must be at most 2^N/%d=%d for sparse noise but is %d", tSafetyFactor, maxT, t)
+	}
+	if points := int64(c) * int64(t) * int64(t); points > int64(1)<<(N+1) {
+		return nil, fmt.Errorf("c*t^2=%d exceeds the domain 2^(N+1)=%d of the OLE DSPFs", points, int64(1)<<(N+1))
+	}
+
+	noiseEntropy := log2Binomial(int64(1)<<N, t)
+	return &SecurityLevel{
+		Lambda:       lambda,
+		Field:        FieldSecurityLevel,
+		NoiseEntropy: noiseEntropy,
+		Effective:    min(lambda, FieldSecurityLevel, noiseEntropy),
+	}, nil
+}
+
+// SecurityLevel returns the security level of the parameters of the PCG.
+func (p *PCG) SecurityLevel() *SecurityLevel {
+	level, _ := CheckParameters(p.lambda, p.N, p.n, p.tau, p.c, p.t) // the parameters are validated by NewPCG
+	if p.regularNoise {
+		level.NoiseEntropy = regularNoiseEntropy(p.N, p.t)
+		level.Effective = min(level.Lambda, level.Field, level.NoiseEntropy)
+	}
+	return level
+}
+
+// SeedParameters are the parameters of the PCG a seed was generated for. Eval checks them against the parameters of
+// the PCG instance, s.t. a seed evaluated with other parameters, e.g. another N, fails instead of producing garbage.
+type SeedParameters struct {
+	Lambda       int
+	N            int
+	Parties      int // Parties is the amount of parties n.
+	Tau          int
+	C            int
+	T            int
+	RegularNoise bool     // RegularNoise is set if the seed embeds regular noise (see UseRegularNoise).
+	RingDigest   [32]byte // RingDigest is the digest of the divisor of the ring, i.e. x^(2^N) + 1.
+}
+
+// seedParameters returns the parameters of the PCG, which are embedded into its seeds.
+func (p *PCG) seedParameters() (*SeedParameters, error) {
+	div, err := poly.NewCyclotomicPolynomial(new(big.Int).Lsh(big.NewInt(1), uint(p.N+1)))
+	if err != nil {
+		return nil, err
+	}
+	return &SeedParameters{
+		Lambda:       p.lambda,
+		N:            p.N,
+		Parties:      p.n,
+		Tau:          p.tau,
+		C:            p.c,
+		T:            p.t,
+		RegularNoise: p.regularNoise,
+		RingDigest:   div.Digest(),
+	}, nil
+}
+
+// log2Binomial returns floor(log2(binomial(n, k))).
+// n is an int64, s.t. n = 2^N does not overflow on 32-bit platforms.
+func log2Binomial(n int64, k int) int {
+	lgN, _ := math.Lgamma(float64(n) + 1)
+	lgK, _ := math.Lgamma(float64(k) + 1)
+	lgNK, _ := math.Lgamma(float64(n-int64(k)) + 1)
+	return int(math.Floor((lgN - lgK - lgNK) / math.Ln2))
+}
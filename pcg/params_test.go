@@ -0,0 +1,107 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestCheckParameters(t *testing.T) {
+	testCases := []struct {
+		lambda, N, n, tau, c, t int
+		valid                   bool
+	}{
+		{128, 6, 2, 2, 2, 4, true},
+		{128, 20, 10, 5, 4, 16, true},
+		{128, 6, 2, 2, 1, 2, true},
+		{192, 20, 3, 2, 4, 16, true},  // lambda exceeds the field, which caps the effective level
+		{256, 10, 3, 2, 4, 16, true},  // lambda exceeds the field, which caps the effective level
+		{100, 10, 3, 2, 4, 16, false}, // unsupported lambda
+		{128, 0, 2, 2, 2, 4, false},
+		{128, 33, 2, 2, 2, 4, false},
+		{128, 6, 1, 1, 2, 4, false},
+		{128, 6, 2, 3, 2, 4, false},
+		{128, 6, 2, 0, 2, 4, false},
+		{128, 6, 2, 2, 0, 4, false},
+		{128, 2, 2, 2, 2, 5, false}, // t exceeds 2^N
+		{128, 4, 2, 2, 1, 4, true},
+		{128, 4, 2, 2, 1, 5, false},  // t exceeds 2^N/4
+		{128, 6, 2, 2, 2, 64, false}, // t exceeds 2^N/4
+		{128, 6, 2, 2, 8, 4, true},
+		{128, 6, 2, 2, 9, 4, false}, // c*t^2 exceeds 2^(N+1)
+		{128, 6, 2, 2, 2, 9, false}, // c*t^2 exceeds 2^(N+1)
+	}
+	for _, tc := range testCases {
+		level, err := CheckParameters(tc.lambda, tc.N, tc.n, tc.tau, tc.c, tc.t)
+		_, errPCG := NewPCG(tc.lambda, tc.N, tc.n, tc.tau, tc.c, tc.t)
+		if tc.valid {
+			assert.Nil(t, err, "%+v", tc)
+			assert.Nil(t, errPCG, "%+v", tc)
+			assert.LessOrEqual(t, level.Effective, tc.lambda)
+			assert.LessOrEqual(t, level.Effective, FieldSecurityLevel)
+		} else {
+			assert.NotNil(t, err, "%+v", tc)
+			assert.NotNil(t, errPCG, "%+v", tc)
+		}
+	}
+}
+
+func TestSecurityLevel(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	level := pcg.SecurityLevel()
+	assert.Equal(t, 128, level.Lambda)
+	assert.Equal(t, 19, level.NoiseEntropy) // log2(binomial(64, 4)) = log2(635376)
+	assert.Equal(t, 19, level.Effective)
+
+	pcg, err = NewPCG(128, 32, 2, 2, 4, 64)
+	assert.Nil(t, err)
+	level = pcg.SecurityLevel()
+	assert.Greater(t, level.NoiseEntropy, 128)
+	assert.Equal(t, 128, level.Effective)
+
+	// A lambda beyond the field is accepted, but the effective level is capped by the field
+	pcg, err = NewPCG(256, 32, 2, 2, 4, 64)
+	assert.Nil(t, err)
+	level = pcg.SecurityLevel()
+	assert.Equal(t, 256, level.Lambda)
+	assert.Equal(t, FieldSecurityLevel, level.Effective)
+}
+
+// TestDSPFInteropAcrossLambda checks that the DSPFs at every lambda of the DPF reproduce the sums of point functions
+// over the domains N and N+1 the PCG embeds its correlations in.
+func TestDSPFInteropAcrossLambda(t *testing.T) {
+	N := 6
+	for _, lambda := range []int{128, 192, 256} {
+		for _, domain := range []int{N, N + 1} {
+			base, err := optreedpf.InitFactory(lambda, domain)
+			assert.Nil(t, err)
+			d := dspf.NewDSPFFactory(base)
+
+			points := []*big.Int{big.NewInt(0), big.NewInt(17), big.NewInt(1<<domain - 1)}
+			values := []*big.Int{big.NewInt(1), big.NewInt(2), new(big.Int).Set(base.BetaMax)}
+			key0, key1, err := d.Gen(points, values)
+			assert.Nil(t, err)
+
+			res0, err := d.FullEvalFastAggregated(key0)
+			assert.Nil(t, err)
+			res1, err := d.FullEvalFastAggregated(key1)
+			assert.Nil(t, err)
+			assert.Equal(t, 1<<domain, len(res0))
+			for i := range res0 {
+				sum := bls12381.NewFr()
+				sum.Add(res0[i], res1[i])
+				expected := big.NewInt(0)
+				for k, point := range points {
+					if int(point.Int64()) == i {
+						expected = values[k]
+					}
+				}
+				assert.True(t, sum.Equal(bls12381.NewFr().FromBytes(expected.Bytes())), "lambda=%d, domain=%d, x=%d", lambda, domain, i)
+			}
+		}
+	}
+}
@@ -3,34 +3,51 @@ package pcg
 import (
 	"fmt"
 	bls12381 "github.com/kilic/bls12-381"
-	"log"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/metrics"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
 	"math"
 	"math/big"
-	"math/rand"
-	"pcg-bbs-plus/dpf"
-	"pcg-bbs-plus/dpf/optreedpf"
-	"pcg-bbs-plus/dspf"
-	"pcg-bbs-plus/pcg/poly"
 	"time"
 )
 
 type PCG struct {
-	lambda int        // lambda is the security parameter used to determine the output length of the underlying PRandomG
-	N      int        // N is the domain of the PCG. For given N, the PCG is able to generate up to 2^N BBS+ tuples.
-	n      int        // n is the number of parties participating in this PCG
-	tau    int        // tau is the threshold for the signature scheme (tau-out-of-n setting)
-	c      int        // c is the first security parameter of the Module-LPN assumption
-	t      int        // t is the second security parameter of the Module-LPN assumption
-	dspfN  *dspf.DSPF // dpfN is the Distributed Sum of Point Function used to construct the PCG with domain N
-	dspf2N *dspf.DSPF // dpf2N is the Distributed Sum of Point Function used to construct the PCG with domain 2N
-	rng    *rand.Rand // rng is the random number generator used to sample the PCG seeds
+	lambda int            // lambda is the security parameter used to determine the output length of the underlying PRandomG
+	N      int            // N is the domain of the PCG. For given N, the PCG is able to generate up to 2^N BBS+ tuples.
+	n      int            // n is the number of parties participating in this PCG
+	tau    int            // tau is the threshold for the signature scheme (tau-out-of-n setting)
+	c      int            // c is the first security parameter of the Module-LPN assumption
+	t      int            // t is the second security parameter of the Module-LPN assumption
+	dspfN  dspf.Scheme    // dpfN is the Distributed Sum of Point Function used to construct the PCG with domain N
+	dspf2N dspf.Scheme    // dpf2N is the Distributed Sum of Point Function used to construct the PCG with domain 2N
+	rng    *randomness    // rng derives the randomness of the seeds and random polynomials from a master seed (see Reseed)
+	domain *big.Int       // domain is the bound 2^N of all exponents; products of exponents are bound by 2*domain
+	logger logging.Logger // logger receives the log messages of the PCG. It defaults to a no-op logger.
+
+	regularNoise    bool            // regularNoise is set if the noise positions are regular (see UseRegularNoise)
+	hardened        bool            // hardened is set if the PCG is in the security-hardened mode (see UseHardenedMode)
+	duplicatePolicy DuplicatePolicy // duplicatePolicy defines how duplicate special points are handled (see SetDuplicatePolicy)
+	phaseObserver   PhaseObserver   // phaseObserver receives the durations of the phases of Eval. nil disables it.
+	memoryLimit     int64           // memoryLimit bounds the estimated memory of the stages in bytes, 0 disables it (see SetMemoryLimit)
+	metrics         metrics.Metrics // metrics receives the counts of seeds and tuples and the durations of the phases of Eval. nil disables it.
+	prg             dpf.PRGBackend  // prg is the PRG backend of the base DPFs, nil for the default (see UsePRGBackend)
 }
 
 // NewPCG creates a new BBS+ PCG with the given parameters.
-// It uses OptreeDPF as the underlying DPF.
+// It uses OptreeDPF as the underlying DPF. The parameters are validated via CheckParameters.
 func NewPCG(lambda, N, n, tau, c, t int) (*PCG, error) {
-	seed, _ := bytesToInt64(dpf.RandomSeed(8))
-	rng := rand.New(rand.NewSource(seed))
+	if _, err := CheckParameters(lambda, N, n, tau, c, t); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	master, err := sampleMasterSeed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed the randomness: %w", err)
+	}
 
 	baseDpfDomain, err := optreedpf.InitFactory(lambda, N)
 	if err != nil {
@@ -41,10 +58,6 @@ func NewPCG(lambda, N, n, tau, c, t int) (*PCG, error) {
 		return nil, fmt.Errorf("failed to initialize base DPF with domain 2N: %w", err)
 	}
 
-	if tau > n {
-		return nil, fmt.Errorf("tau must be smaller or equal to n")
-	}
-
 	return &PCG{
 		lambda: lambda,
 		N:      N,
@@ -54,14 +67,78 @@ func NewPCG(lambda, N, n, tau, c, t int) (*PCG, error) {
 		t:      t,
 		dspfN:  dspf.NewDSPFFactory(baseDpfDomain),
 		dspf2N: dspf.NewDSPFFactory(baseDpfDoubleDomain),
-		rng:    rng,
+		rng:    newRandomness(master),
+		domain: new(big.Int).Lsh(big.NewInt(1), uint(N)),
+		logger: logging.NopLogger{},
+
+		memoryLimit: DetectMemoryLimit(),
 	}, nil
 }
 
+// SetLogger sets the logger of the PCG and its DSPFs. The tuple generators returned by the PCG inherit the logger.
+// A nil logger discards all messages.
+func (p *PCG) SetLogger(logger logging.Logger) {
+	p.logger = logging.OrNop(logger)
+	p.dspfN.SetLogger(p.logger)
+	p.dspf2N.SetLogger(p.logger)
+}
+
 // Define the ring we are working with.
 // The cyclotomic polynomial defined here is F(x)= x^((2^(N+1))/2) + 1
 // s.t. we can calculate N roots of unity r s.t. F(r) = 0
 func (p *PCG) GetRing(fast bool) (*Ring, error) {
+	ring, err := p.GetLazyRing()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkMemory("GetRing", func(e *MemoryEstimate) int64 { return e.Ring }, "GetLazyRing derives the roots on demand"); err != nil {
+		return nil, err
+	}
+
+	groupOrder := big.NewInt(0)
+	groupOrder.SetString(poly.FrModulus, 16) // BLS12-381 group order
+	powerIteratorBase := ring.rootBase.ToBig()
+	twoPowNDouble := 2 * int64(ring.size) // 2^(N+1)
+
+	// Generate roots
+	roots := make([]*bls12381.Fr, ring.size)
+	pos := 0
+
+	// We differentiate between fast and slow for benchmarking purposes
+	if fast {
+		// Pre-compute the square of powerIteratorBase to use for multiplication in each step
+		powerIteratorBaseSquared := new(big.Int).Mul(powerIteratorBase, powerIteratorBase)
+		powerIteratorBaseSquared.Mod(powerIteratorBaseSquared, groupOrder)
+
+		// Initialize val with the first exponentiation outside the loop
+		val := new(big.Int).Set(powerIteratorBase) // Assuming i=1 as the first relevant root for simplicity
+
+		for i := int64(1); i < twoPowNDouble; i += 2 { // Start from i=1 and skip every second root
+			// For the first iteration, val is already set. For subsequent iterations, multiply by powerIteratorBaseSquared
+			if i > 1 {
+				val = val.Mul(val, powerIteratorBaseSquared).Mod(val, groupOrder)
+			}
+
+			roots[pos] = bls12381.NewFr().FromBytes(val.Bytes())
+			pos++
+		}
+	} else {
+		for i := int64(0); i < twoPowNDouble; i++ {
+			if math.Mod(float64(i), 2) == 1 { // only every second root
+				val := new(big.Int).Exp(powerIteratorBase, big.NewInt(i), groupOrder) // Start from i=0 for the first root
+				roots[pos] = bls12381.NewFr().FromBytes(val.Bytes())
+				pos++
+			}
+		}
+	}
+
+	ring.Roots = roots
+	return ring, nil
+}
+
+// GetLazyRing returns the same ring as GetRing but does not materialize its roots.
+// Roots are computed on demand via Ring.RootAt, which avoids storing all 2^N roots for large N.
+func (p *PCG) GetLazyRing() (*Ring, error) {
 	// Define the Ring we work in
 	smallFactorThreshold := big.NewInt(1000)
 	groupOrderFactorization := multiplicativeGroupOrderFactorizationBLS12381()
@@ -91,6 +168,10 @@ func (p *PCG) GetRing(fast bool) (*Ring, error) {
 	exp.Div(exp, smoothOrder)
 	multiplicativeSmoothGroupGenerator := new(big.Int).Exp(primitiveRootOfUnity, exp, groupOrder)
 
+	size, err := domainSize(p.N)
+	if err != nil {
+		return nil, err
+	}
 	twoPowN := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(p.N)), nil) // 2^N
 	twoPowNDouble := new(big.Int).Mul(twoPowN, big.NewInt(2))               // 2^(N+1)
 
@@ -102,58 +183,78 @@ func (p *PCG) GetRing(fast bool) (*Ring, error) {
 	smoothOrderDivN := new(big.Int).Div(smoothOrder, twoPowNDouble)
 	powerIteratorBase := new(big.Int).Exp(multiplicativeSmoothGroupGenerator, smoothOrderDivN, groupOrder)
 
-	// Generate roots
-	roots := make([]*bls12381.Fr, twoPowN.Int64())
-	pos := 0
-
-	// We differentiate between fast and slow for benchmarking purposes
-	if fast {
-		// Pre-compute the square of powerIteratorBase to use for multiplication in each step
-		powerIteratorBaseSquared := new(big.Int).Mul(powerIteratorBase, powerIteratorBase)
-		powerIteratorBaseSquared.Mod(powerIteratorBaseSquared, groupOrder)
-
-		// Initialize val with the first exponentiation outside the loop
-		val := new(big.Int).Set(powerIteratorBase) // Assuming i=1 as the first relevant root for simplicity
-
-		for i := 1; i < int(twoPowNDouble.Int64()); i += 2 { // Start from i=1 and skip every second root
-			// For the first iteration, val is already set. For subsequent iterations, multiply by powerIteratorBaseSquared
-			if i > 1 {
-				val = val.Mul(val, powerIteratorBaseSquared).Mod(val, groupOrder)
-			}
-
-			roots[pos] = bls12381.NewFr().FromBytes(val.Bytes())
-			pos++
-		}
-	} else {
-		for i := 0; i < int(twoPowNDouble.Int64()); i++ {
-			if math.Mod(float64(i), 2) == 1 { // only every second root
-				val := new(big.Int).Exp(powerIteratorBase, big.NewInt(int64(i)), groupOrder) // Start from i=0 for the first root
-				roots[pos] = bls12381.NewFr().FromBytes(val.Bytes())
-				pos++
-			}
-		}
-	}
-
 	// div = x^((2^(N+1))/2) + 1
 	div, err := poly.NewCyclotomicPolynomial(twoPowNDouble)
 	if err != nil {
 		return nil, err // Handle error appropriately
 	}
 
-	return &Ring{div, roots}, nil
+	ring := &Ring{
+		Div:      div,
+		rootBase: bls12381.NewFr().FromBytes(powerIteratorBase.Bytes()),
+		size:     size,
+	}
+	if err := ring.prepare(); err != nil {
+		return nil, err
+	}
+	return ring, nil
 }
 
 // TrustedSeedGen generates a seed for each party via a central dealer.
 // The goal is to realize a distributed generation.
 func (p *PCG) TrustedSeedGen() ([]*Seed, error) {
+	seeds, _, err := p.trustedSeedGen(nil, nil)
+	return seeds, err
+}
+
+// TrustedSeedGenWithKeyShares works like TrustedSeedGen, but embeds the given sharing of sk instead of sharing a fresh
+// sk, e.g. the result of a DKG among the parties (see sharing.NewContribution and sharing.CombineContributions), s.t.
+// the dealer does not choose sk. skShares[i] is the share of party i, which must be consistent with the commitments
+// to the sharing of the scheme of the PCG (see SharingScheme), i.e. the DKG must produce an additive sharing in the
+// n-out-of-n setting. Note that the dealer still learns the shares, as it embeds the VOLE correlations sk_j*a_i, hence it
+// has to erase them after the seed generation.
+func (p *PCG) TrustedSeedGenWithKeyShares(skShares []*bls12381.Fr, skCommitments []*bls12381.PointG1) ([]*Seed, error) {
+	if len(skShares) != p.n {
+		return nil, fmt.Errorf("got %d sk shares but n=%d are expected", len(skShares), p.n)
+	}
+	scheme := p.SharingScheme()
+	for i, share := range skShares {
+		if err := scheme.Verify(share, i, skCommitments); err != nil {
+			return nil, fmt.Errorf("invalid sk share of party %d: %w", i, err)
+		}
+	}
+	seeds, _, err := p.trustedSeedGen(skShares, skCommitments)
+	return seeds, err
+}
+
+// trustedSeedGen generates the seeds of TrustedSeedGen and returns them with the secrets of the dealer.
+// If skShares is nil, the dealer shares a fresh sk. Otherwise, skShares[i] is the share of party i.
+func (p *PCG) trustedSeedGen(skShares []*bls12381.Fr, skCommitments []*bls12381.PointG1) ([]*Seed, *DealerSecrets, error) {
 	// Notation of the variables analogue to the notation from the formal definition of PCG
 	// 1. Generate key shares for each party
-	_, skShares := getShamirSharedRandomElement(p.rng, 2, 2) // for testing, we always use 2 out of 2, as we do not interpolate the key shares
+	// The dealer commits to the sharing, s.t. each party can verify its share via Seed.VerifyShare.
+	if skShares == nil {
+		var err error
+		// The dealer shares sk with the scheme of the PCG, s.t. the tuples of any signer set the scheme admits recombine
+		// sk: all n parties add up their shares, while any tau parties of a Shamir sharing interpolate sk
+		_, skShares, skCommitments, err = p.SharingScheme().Share(p.rng.domain(domainSkSharing), nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("step 1: failed to share sk: %w", err)
+		}
+	}
 
 	// 2a. Initialize aOmega, eEta, and sPhi by sampling at random from N
 	aOmega := p.sampleExponents() // a
 	eEta := p.sampleExponents()   // e
 	sPhi := p.sampleExponents()   // s
+	if p.duplicatePolicy == ResampleExponents && !p.regularNoise {
+		if err := p.resampleCollisions(aOmega, sPhi); err != nil {
+			return nil, nil, fmt.Errorf("step 2: %w", err)
+		}
+		if err := p.resampleCollisions(aOmega, eEta); err != nil {
+			return nil, nil, fmt.Errorf("step 2: %w", err)
+		}
+	}
 
 	// 2b. Initialize aBeta, eGamma and sEpsilon by sampling at random from F_q (via bls12381.Fr)
 	aBeta := p.sampleCoefficients()    // a
@@ -163,65 +264,95 @@ func (p *PCG) TrustedSeedGen() ([]*Seed, error) {
 	// 3. Embed first part of delta (delta0) correlation (sk*a)
 	U, err := p.embedVOLECorrelations(aOmega, aBeta, skShares)
 	if err != nil {
-		return nil, fmt.Errorf("step 3: failed to generate DSPF keys for first part of delta VOLE correlation (sk * a): %w", err)
+		return nil, nil, fmt.Errorf("step 3: failed to generate DSPF keys for first part of delta VOLE correlation (sk * a): %w", err)
 	}
 
 	// 4a. Embed alpha correlation (a*s)
 	C, err := p.embedOLECorrelations(aOmega, sPhi, aBeta, sEpsilon)
 	if err != nil {
-		return nil, fmt.Errorf("step 4: failed to generate DSPF keys for alpha OLE correlation (a * s): %w", err)
+		return nil, nil, fmt.Errorf("step 4: failed to generate DSPF keys for alpha OLE correlation (a * s): %w", err)
 	}
 
 	// 4b. Embed second part of delta (delta1) correlation (a*e)
 	V, err := p.embedOLECorrelations(aOmega, eEta, aBeta, eGamma)
 	if err != nil {
-		return nil, fmt.Errorf("step 4: failed to generate DSPF keys for second part of delta OLE correlation (a * e): %w", err)
+		return nil, nil, fmt.Errorf("step 4: failed to generate DSPF keys for second part of delta OLE correlation (a * e): %w", err)
 	}
 
 	// 5. Generate seed for each party
 	seeds := make([]*Seed, p.n)
+	paramsDigest := p.paramsDigest()
+	params, err := p.seedParameters()
+	if err != nil {
+		return nil, nil, fmt.Errorf("step 5: %w", err)
+	}
 	for i := 0; i < p.n; i++ {
-		keyIndex := i
-		if i > 1 {
-			keyIndex = 1 // We set the key index for all parties > 1 to 1, as we do not interpolate the key shares (only for testing as this has no performance impact on Eval)
-		}
+		keyIndex := p.skShareIndex(i, len(skShares))
 		seeds[i] = &Seed{
-			index: i,
-			ski:   skShares[keyIndex],
+			index:         i,
+			ski:           skShares[keyIndex],
+			skShareIndex:  keyIndex,
+			skCommitments: skCommitments,
 			exponents: seedExponents{
-				aOmega: aOmega[i],
-				eEta:   eEta[i],
-				sPhi:   sPhi[i],
+				aOmega: aOmega.Party(i),
+				eEta:   eEta.Party(i),
+				sPhi:   sPhi.Party(i),
 			},
 			coefficients: seedCoefficients{
-				aBeta:    aBeta[i],
-				eGamma:   eGamma[i],
-				sEpsilon: sEpsilon[i],
+				aBeta:    aBeta.Party(i),
+				eGamma:   eGamma.Party(i),
+				sEpsilon: sEpsilon.Party(i),
 			},
-			U: U,
-			C: C,
-			V: V,
+			U:            U,
+			C:            C,
+			V:            V,
+			paramsDigest: paramsDigest,
+			params:       params,
 		}
 	}
 
-	return seeds, nil
+	secrets := &DealerSecrets{
+		SkShares: skShares,
+		AOmega:   aOmega,
+		EEta:     eEta,
+		SPhi:     sPhi,
+		ABeta:    aBeta,
+		EGamma:   eGamma,
+		SEpsilon: sEpsilon,
+	}
+	if err := p.checkSeedGenInvariants(seeds, secrets); err != nil {
+		return nil, nil, err
+	}
+	p.countSeeds(len(seeds))
+	return seeds, secrets, nil
 }
 
 // EvalCombined evaluates the PCG for an n-out-of-n setting.
 // This setting has a better performance than the tau-out-of-n setting (EvalSeparate).
-func (p *PCG) EvalCombined(seed *Seed, rand []*poly.Polynomial, div *poly.Polynomial) (*BBSPlusTupleGenerator, error) {
+func (p *PCG) EvalCombined(seed *Seed, rand []*poly.Polynomial, div *PreparedDivisor, opts ...EvalOption) (*BBSPlusTupleGenerator, error) {
 	if p.tau != p.n {
 		return nil, fmt.Errorf("EvalCombined can only be used for an n-out-of-n setting")
 	}
 
-	startTimeTotal := time.Now()
-	if len(rand) != p.c {
-		return nil, fmt.Errorf("rand must hold c=%d polynomials but contains %d", p.c, len(rand))
+	session, err := p.NewEvalSession(rand, div)
+	if err != nil {
+		return nil, err
+	}
+	return session.EvalSeed(seed, opts...)
+}
+
+// evalCombined evaluates the PCG for an n-out-of-n setting with the precomputations of the session.
+func (p *PCG) evalCombined(seed *Seed, session *EvalSession, options *evalOptions) (*BBSPlusTupleGenerator, error) {
+	if options.denseSeparate != nil {
+		return nil, fmt.Errorf("WithDenseSeparateShares can only be used for the tau-out-of-n setting")
 	}
-	one, _ := poly.NewSparse([]*bls12381.Fr{bls12381.NewFr().FromBytes(big.NewInt(1).Bytes())}, []*big.Int{big.NewInt(0)}) // = 1
-	if !rand[p.c-1].Equal(one) {
-		return nil, fmt.Errorf("rand must be a slice of polynomials with polynomial of the the last index rand[c-1] equal to 1")
+	if err := p.checkSeedParams(seed, session.div); err != nil {
+		return nil, err
+	}
+	if err := checkDenseReRandomization(seed, options); err != nil {
+		return nil, err
 	}
+	startTimeTotal := time.Now()
 
 	startGenPolys := time.Now()
 	u, err := p.constructPolys(seed.coefficients.aBeta, seed.exponents.aOmega)
@@ -238,116 +369,155 @@ func (p *PCG) EvalCombined(seed *Seed, rand []*poly.Polynomial, div *poly.Polyno
 	}
 	endGenPolys := time.Now()
 	duration := endGenPolys.Sub(startGenPolys)
-	log.Println("Generated polynomials (in s): ", duration.Seconds())
+	p.logger.Debugf("Generated polynomials (in s): %v", duration.Seconds())
 
 	// 2. Process VOLE (u) with seed / delta0 = ask
 	startVole := time.Now()
-	utilde, err := p.evalVOLEwithSeed(u, seed.ski, seed.U, seed.index, div)
+	utilde, err := p.ExpandVOLE(u, seed.ski, seed.U, seed.index)
 	if err != nil {
-		return nil, fmt.Errorf("step 2: failed to evaluate VOLE (utilde): %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 2: failed to evaluate VOLE (utilde): %w", err), PhaseVOLE, seed.index)
 	}
 	endVole := time.Now()
 	duration = endVole.Sub(startVole)
-	log.Println("Processed VOLE (in s): ", duration.Seconds())
+	p.logger.Debugf("Processed VOLE (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseVOLE, duration)
 
 	// 3. Process first OLE correlation (u, k) with seed / alpha = as
 	startOle := time.Now()
-	w, err := p.evalOLEwithSeed(u, k, seed.C, seed.index, div)
+	w, err := p.ExpandOLE(u, k, seed.C, seed.index)
 	if err != nil {
-		return nil, fmt.Errorf("step 3: failed to evaluate OLE (w): %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 3: failed to evaluate OLE (w): %w", err), PhaseOLE1, seed.index)
 	}
 	endOle := time.Now()
 	duration = endOle.Sub(startOle)
-	log.Println("Processed #1 OLE (in s): ", duration.Seconds())
+	p.logger.Debugf("Processed #1 OLE (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseOLE1, duration)
 
 	// 4. Process second OLE correlation (u, v) with seed /  delta1 = ae
 	startOle2 := time.Now()
-	m, err := p.evalOLEwithSeed(u, v, seed.V, seed.index, div)
+	m, err := p.ExpandOLE(u, v, seed.V, seed.index)
 	if err != nil {
-		return nil, fmt.Errorf("step 4: failed to evaluate OLE (m): %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 4: failed to evaluate OLE (m): %w", err), PhaseOLE2, seed.index)
 	}
 	endOle2 := time.Now()
 	duration = endOle2.Sub(startOle2)
-	log.Println("Processed #2 OLE (in s): ", duration.Seconds())
+	p.logger.Debugf("Processed #2 OLE (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseOLE2, duration)
 
 	// 5. Calculate final shares
+	if options.dense != nil {
+		return p.denseGenerator(seed, session, options.dense, startTimeTotal, u, v, k, utilde, w, m)
+	}
 	startFinalShareAi := time.Now()
-	ai, err := p.evalFinalShare(u, rand, div)
+	ai, err := session.finalShare(u)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share ai: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share ai: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareAi := time.Now()
 	duration = endFinalShareAi.Sub(startFinalShareAi)
-	log.Println("Calculated final share polynomials for ai (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for ai (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareEi := time.Now()
-	ei, err := p.evalFinalShare(v, rand, div)
+	ei, err := session.finalShare(v)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share ei: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share ei: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareEi := time.Now()
 	duration = endFinalShareEi.Sub(startFinalShareEi)
-	log.Println("Calculated final share polynomials for ei (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for ei (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareSi := time.Now()
-	si, err := p.evalFinalShare(k, rand, div)
+	si, err := session.finalShare(k)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share ki: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share ki: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareSi := time.Now()
 	duration = endFinalShareSi.Sub(startFinalShareSi)
-	log.Println("Calculated final share polynomials for si (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for si (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareVOLE := time.Now()
-	delta0i, err := p.evalFinalShare(utilde, rand, div)
+	delta0i, err := session.finalShare(utilde)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share delta0i: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share delta0i: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareVOLE := time.Now()
 	duration = endFinalShareVOLE.Sub(startFinalShareVOLE)
-	log.Println("Calculated final share polynomials for VOLE (delta0i) (in s): ", duration.Seconds())
-
-	oprand, err := outerProductPoly(rand, rand)
-	if err != nil {
-		return nil, err
-	}
+	p.logger.Debugf("Calculated final share polynomials for VOLE (delta0i) (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareOLE := time.Now()
-	alphai, err := p.evalFinalShare2D(w, oprand, div)
+	alphai, err := session.finalShare2D(w)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share alphai: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share alphai: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareOLE := time.Now()
 	duration = endFinalShareOLE.Sub(startFinalShareOLE)
-	log.Println("Calculated final share polynomials for #1 OLE (alphai) (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for #1 OLE (alphai) (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareOLE2 := time.Now()
-	delta1i, err := p.evalFinalShare2D(m, oprand, div)
+	delta1i, err := session.finalShare2D(m)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share delta1i: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share delta1i: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareOLE2 := time.Now()
 	duration = endFinalShareOLE2.Sub(startFinalShareOLE2)
-	log.Println("Calculated final share polynomials for #2 OLE (delta1i) (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for #2 OLE (delta1i) (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	endTimeTotal := time.Now()
 	duration = endTimeTotal.Sub(startTimeTotal)
-	log.Println("Total time for EVAL (in s): ", duration.Seconds())
+	p.logger.Infof("Total time for EVAL (in s): %v", duration.Seconds())
+	shares := map[string]*poly.Polynomial{"ai": ai, "ei": ei, "si": si, "alphai": alphai, "delta0i": delta0i, "delta1i": delta1i}
+	if err := p.checkShareInvariants("EvalCombined", seed.index, shares); err != nil {
+		return nil, err
+	}
 
-	return NewBBSPlusTupleGenerator(seed.ski, ai, ei, si, alphai, delta0i, delta1i), nil
+	return p.newGenerator(seed, tuplegen.NewPolyShares(seed.ski, ai, ei, si, alphai, delta0i, delta1i))
 }
 
 // EvalSeparate evaluates the PCG for a tau-out-of-n setting.
 // This setting has a worse performance than the n-out-of-n setting (EvalCombined).
-func (p *PCG) EvalSeparate(seed *Seed, rand []*poly.Polynomial, div *poly.Polynomial) (*SeparateBBSPlusTupleGenerator, error) {
-	startTimeTotal := time.Now()
+func (p *PCG) EvalSeparate(seed *Seed, rand []*poly.Polynomial, div *PreparedDivisor, opts ...EvalOption) (*SeparateBBSPlusTupleGenerator, error) {
+	session, err := p.NewEvalSession(rand, div)
+	if err != nil {
+		return nil, err
+	}
+	return session.EvalSeedSeparate(seed, opts...)
+}
 
-	if len(rand) != p.c {
-		return nil, fmt.Errorf("rand must hold c=%d polynomials but contains %d", p.c, len(rand))
+// EvalSeparateForSigners evaluates the PCG for a tau-out-of-n setting, restricted to the given signer set.
+// Only the cross terms with co-signers are evaluated, which skips the DSPF evaluations of all other counterparties.
+// The resulting generator can only derive tuples for subsets of signerSet. signerSet must contain the seed's index.
+func (p *PCG) EvalSeparateForSigners(seed *Seed, rand []*poly.Polynomial, div *PreparedDivisor, signerSet []int, opts ...EvalOption) (*SeparateBBSPlusTupleGenerator, error) {
+	session, err := p.NewEvalSession(rand, div)
+	if err != nil {
+		return nil, err
 	}
-	one, _ := poly.NewSparse([]*bls12381.Fr{bls12381.NewFr().FromBytes(big.NewInt(1).Bytes())}, []*big.Int{big.NewInt(0)}) // = 1
-	if !rand[p.c-1].Equal(one) {
-		return nil, fmt.Errorf("rand must be a slice of polynomials with polynomial of the the last index rand[c-1] equal to 1")
+	return session.EvalSeedSeparateForSigners(seed, signerSet, opts...)
+}
+
+// evalSeparate evaluates the PCG for a tau-out-of-n setting with the precomputations of the session.
+// If counterparties is nil, the cross terms with all counterparties are evaluated.
+func (p *PCG) evalSeparate(seed *Seed, session *EvalSession, counterparties []bool, options *evalOptions) (*SeparateBBSPlusTupleGenerator, error) {
+	if options.dense != nil {
+		return nil, fmt.Errorf("WithDenseShares can only be used for the n-out-of-n setting")
+	}
+	if err := p.checkSeedParams(seed, session.div); err != nil {
+		return nil, err
+	}
+	if err := checkDenseReRandomization(seed, options); err != nil {
+		return nil, err
+	}
+	startTimeTotal := time.Now()
+	if counterparties == nil {
+		counterparties = make([]bool, p.n)
+		for j := range counterparties {
+			counterparties[j] = j != seed.index
+		}
 	}
 
 	startGenPolys := time.Now()
@@ -365,155 +535,182 @@ func (p *PCG) EvalSeparate(seed *Seed, rand []*poly.Polynomial, div *poly.Polyno
 	}
 	endGenPolys := time.Now()
 	duration := endGenPolys.Sub(startGenPolys)
-	log.Println("Generated polynomials (in s): ", duration.Seconds())
+	p.logger.Debugf("Generated polynomials (in s): %v", duration.Seconds())
 
 	// 2. Process VOLE (u) with seed / delta0 = ask
 	startVole := time.Now()
-	utilde, err := p.evalVOLEwithSeedSeparate(seed.U, seed.index) // utilde[seedIndex] is nil!
+	utilde, err := p.expandVOLESeparate(seed.U, seed.index, counterparties)
 	if err != nil {
-		return nil, fmt.Errorf("step 2: failed to evaluate VOLE (utilde): %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 2: failed to evaluate VOLE (utilde): %w", err), PhaseVOLE, seed.index)
 	}
-	usk := make([]*poly.Polynomial, p.c)
-	for r := 0; r < p.c; r++ {
-		usk[r] = u[r].DeepCopy()
-		usk[r].MulByConstant(seed.ski)
+	usk, err := p.ExpandVOLELocal(u, seed.ski)
+	if err != nil {
+		return nil, newPhaseError(fmt.Errorf("step 2: failed to evaluate VOLE (usk): %w", err), PhaseVOLE, seed.index)
 	}
 	endVole := time.Now()
 	duration = endVole.Sub(startVole)
-	log.Println("Processed VOLE (in s): ", duration.Seconds())
+	p.logger.Debugf("Processed VOLE (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseVOLE, duration)
 
 	// 3. Process first OLE correlation (u, k) with seed / alpha = as
 	startOle := time.Now()
-	w, uk, err := p.evalOLEwithSeedSeparate(u, k, seed.C, seed.index) // w[seedIndex] is nil!
+	w, uk, err := p.expandOLESeparate(u, k, seed.C, seed.index, counterparties)
 	if err != nil {
-		return nil, fmt.Errorf("step 3: failed to evaluate OLE (w): %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 3: failed to evaluate OLE (w): %w", err), PhaseOLE1, seed.index)
 	}
 	endOle := time.Now()
 	duration = endOle.Sub(startOle)
-	log.Println("Processed #1 OLE (in s): ", duration.Seconds())
+	p.logger.Debugf("Processed #1 OLE (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseOLE1, duration)
 
 	// 4. Process second OLE correlation (u, v) with seed /  delta1 = ae
 	startOle2 := time.Now()
-	m, uv, err := p.evalOLEwithSeedSeparate(u, v, seed.V, seed.index) // m[seedIndex] is nil!
+	m, uv, err := p.expandOLESeparate(u, v, seed.V, seed.index, counterparties)
 	if err != nil {
-		return nil, fmt.Errorf("step 4: failed to evaluate OLE (m): %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 4: failed to evaluate OLE (m): %w", err), PhaseOLE2, seed.index)
 	}
 	endOle2 := time.Now()
 	duration = endOle2.Sub(startOle2)
-	log.Println("Processed #2 OLE (in s): ", duration.Seconds())
+	p.logger.Debugf("Processed #2 OLE (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseOLE2, duration)
 
 	// 5. Calculate final shares
 	startFinalShareAi := time.Now()
-	ai, err := p.evalFinalShare(u, rand, div)
+	ai, err := session.finalShare(u)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share ai: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share ai: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareAi := time.Now()
 	duration = endFinalShareAi.Sub(startFinalShareAi)
-	log.Println("Calculated final share polynomials for ai (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for ai (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareEi := time.Now()
-	ei, err := p.evalFinalShare(v, rand, div)
+	ei, err := session.finalShare(v)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share ei: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share ei: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareEi := time.Now()
 	duration = endFinalShareEi.Sub(startFinalShareEi)
-	log.Println("Calculated final share polynomials for ei (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for ei (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareSi := time.Now()
-	si, err := p.evalFinalShare(k, rand, div)
+	si, err := session.finalShare(k)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share ki: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share ki: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareSi := time.Now()
 	duration = endFinalShareSi.Sub(startFinalShareSi)
-	log.Println("Calculated final share polynomials for si (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for si (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareVOLE := time.Now()
-	delta0i := make([][]*poly.Polynomial, p.n) // delta0i[seedIndex] is nil!
-	for j := 0; j < p.n; j++ {
-		if j != seed.index { // only for counterparties
-			delta0i[j] = make([]*poly.Polynomial, 2)
-			forwardShareJ, err := p.evalFinalShare(utilde[j][forwardDirection], rand, div)
+	delta0i, err := tuplegen.MapPartyIndexed(utilde, func(j int, utildeJ [][]*poly.Polynomial) ([]*poly.Polynomial, error) {
+		delta0iJ := make([]*poly.Polynomial, 2)
+		for _, direction := range []int{forwardDirection, backwardDirection} {
+			shareJ, err := session.finalShare(utildeJ[direction])
 			if err != nil {
-				return nil, fmt.Errorf("step 5: failed to evaluate final share delta0i: %w", err)
+				return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share delta0i: %w", err), PhaseFinalShare, seed.index).withCounterparty(j)
 			}
-			delta0i[j][forwardDirection] = poly.NewEmpty()
-			delta0i[j][forwardDirection].Set(forwardShareJ)
-
-			backwardShareJ, err := p.evalFinalShare(utilde[j][backwardDirection], rand, div)
-			if err != nil {
-				return nil, fmt.Errorf("step 5: failed to evaluate final share delta0i: %w", err)
-			}
-			delta0i[j][backwardDirection] = poly.NewEmpty()
-			delta0i[j][backwardDirection].Set(backwardShareJ)
+			delta0iJ[direction] = poly.NewEmpty()
+			delta0iJ[direction].Set(shareJ)
 		}
+		return delta0iJ, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	uskEval, err := p.evalFinalShare(usk, rand, div) // Eval usk (we count this to delta0i)
+	uskEval, err := session.finalShare(usk) // Eval usk (we count this to delta0i)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share usk: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share usk: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareVOLE := time.Now()
 	duration = endFinalShareVOLE.Sub(startFinalShareVOLE)
-	log.Println("Calculated final share polynomials for VOLE (delta0i) (in s): ", duration.Seconds())
-
-	oprand, err := outerProductPoly(rand, rand)
-	if err != nil {
-		return nil, err
-	}
+	p.logger.Debugf("Calculated final share polynomials for VOLE (delta0i) (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareOLE := time.Now()
-	alphai := make([]*poly.Polynomial, p.n) // alphai[seedIndex] is nil!
-	for j := 0; j < p.n; j++ {
-		if j != seed.index { // only for counterparties
-			alphai[j], err = p.evalFinalShare2D(w[j], oprand, div)
-			if err != nil {
-				return nil, fmt.Errorf("step 5: failed to evaluate final share alphai: %w", err)
-			}
+	alphai, err := tuplegen.MapPartyIndexed(w, func(j int, wJ [][]*poly.Polynomial) (*poly.Polynomial, error) {
+		alphaiJ, err := session.finalShare2D(wJ)
+		if err != nil {
+			return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share alphai: %w", err), PhaseFinalShare, seed.index).withCounterparty(j)
 		}
+		return alphaiJ, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	ukEval, err := p.evalFinalShare2D(uk, oprand, div) // Eval uk (we count this to alphai)
+	ukEval, err := session.finalShare2D(uk) // Eval uk (we count this to alphai)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share uk: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share uk: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareOLE := time.Now()
 	duration = endFinalShareOLE.Sub(startFinalShareOLE)
-	log.Println("Calculated final share polynomials for #1 OLE (alphai) (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for #1 OLE (alphai) (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	startFinalShareOLE2 := time.Now()
-	delta1i := make([]*poly.Polynomial, p.n) // delta1i[seedIndex] is nil!
-	for j := 0; j < p.n; j++ {
-		if j != seed.index { // only for counterparties
-			delta1i[j], err = p.evalFinalShare2D(m[j], oprand, div)
-			if err != nil {
-				return nil, fmt.Errorf("step 5: failed to evaluate final share delta1i: %w", err)
-			}
+	delta1i, err := tuplegen.MapPartyIndexed(m, func(j int, mJ [][]*poly.Polynomial) (*poly.Polynomial, error) {
+		delta1iJ, err := session.finalShare2D(mJ)
+		if err != nil {
+			return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share delta1i: %w", err), PhaseFinalShare, seed.index).withCounterparty(j)
 		}
+		return delta1iJ, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	uvEval, err := p.evalFinalShare2D(uv, oprand, div) // Eval uv (we count this to delta1i)
+	uvEval, err := session.finalShare2D(uv) // Eval uv (we count this to delta1i)
 	if err != nil {
-		return nil, fmt.Errorf("step 5: failed to evaluate final share uv: %w", err)
+		return nil, newPhaseError(fmt.Errorf("step 5: failed to evaluate final share uv: %w", err), PhaseFinalShare, seed.index)
 	}
 	endFinalShareOLE2 := time.Now()
 	duration = endFinalShareOLE2.Sub(startFinalShareOLE2)
-	log.Println("Calculated final share polynomials for #2 OLE (delta1i) (in s): ", duration.Seconds())
+	p.logger.Debugf("Calculated final share polynomials for #2 OLE (delta1i) (in s): %v", duration.Seconds())
+	p.observePhase(seed.index, PhaseFinalShare, duration)
 
 	endTimeTotal := time.Now()
 	duration = endTimeTotal.Sub(startTimeTotal)
-	log.Println("Total time for EVAL (in s): ", duration.Seconds())
+	p.logger.Infof("Total time for EVAL (in s): %v", duration.Seconds())
+	shares := map[string]*poly.Polynomial{"ai": ai, "ei": ei, "si": si, "usk": uskEval, "uk": ukEval, "uv": uvEval}
+	if err := p.checkShareInvariants("EvalSeparate", seed.index, shares); err != nil {
+		return nil, err
+	}
+
+	if options.denseSeparate != nil {
+		if err := p.writeDenseSeparate(options.denseSeparate, seed.ski, uskEval, ukEval, uvEval, ai, ei, si, delta0i, alphai, delta1i); err != nil {
+			return nil, err
+		}
+	}
 
-	return NewSeparateBBSPlusTupleGenerator(uskEval, ukEval, uvEval, seed.ski, ai, ei, si, delta0i, alphai, delta1i), nil
+	generator, err := NewSeparateBBSPlusTupleGenerator(uskEval, ukEval, uvEval, seed.ski, ai, ei, si, delta0i, alphai, delta1i)
+	if err != nil {
+		return nil, err
+	}
+	generator.SetTag(p.newTupleTag(seed))
+	generator.SetLogger(p.logger)
+	generator.SetMetrics(p.metrics)
+	generator.SetSharingScheme(p.SharingScheme())
+	reRand, err := seed.reRandomization(p.n)
+	if err != nil {
+		return nil, err
+	}
+	generator.SetReRandomization(reRand)
+	return generator, nil
 }
 
 // PickRandomPolynomials picks c random polynomials of degree N. The last polynomial is not random and always 1.
 // This function is intended to be used to generate the random polynomials for calling EvalCombined.
 func (p *PCG) PickRandomPolynomials() ([]*poly.Polynomial, error) {
-	numElements := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(p.N)), nil)
+	numElements, err := p.randomPolynomialLength()
+	if err != nil {
+		return nil, err
+	}
 
 	polys := make([]*poly.Polynomial, p.c)
 	for i := 0; i < p.c-1; i++ {
-		nPoly, err := poly.NewRandomPolynomial(p.rng, int(numElements.Int64()))
+		nPoly, err := poly.NewRandomPolynomial(p.rng.domain(domainRandomPolynomials), numElements)
 		if err != nil {
 			return nil, err
 		}
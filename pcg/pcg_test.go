@@ -23,11 +23,11 @@ func TestPCGCombinedEnd2End(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, ring)
 
-	eval0, err := pcg.EvalCombined(seeds[0], randPolys, ring.Div)
+	eval0, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
 	assert.Nil(t, err)
 	assert.NotNil(t, eval0)
 
-	eval1, err := pcg.EvalCombined(seeds[1], randPolys, ring.Div)
+	eval1, err := pcg.EvalCombined(seeds[1], randPolys, ring.Prepared())
 	assert.Nil(t, err)
 	assert.NotNil(t, eval1)
 
@@ -93,11 +93,11 @@ func TestPCGSeparateEnd2End(t *testing.T) {
 
 	signerSet := []int{0, 2} // Assume 2-of-3 with signer 0 and 2
 
-	eval0, err := pcg.EvalSeparate(seeds[signerSet[0]], randPolys, ring.Div)
+	eval0, err := pcg.EvalSeparate(seeds[signerSet[0]], randPolys, ring.Prepared())
 	assert.Nil(t, err)
 	assert.NotNil(t, eval0)
 
-	eval1, err := pcg.EvalSeparate(seeds[signerSet[1]], randPolys, ring.Div)
+	eval1, err := pcg.EvalSeparate(seeds[signerSet[1]], randPolys, ring.Prepared())
 	assert.Nil(t, err)
 	assert.NotNil(t, eval1)
 
@@ -107,12 +107,13 @@ func TestPCGSeparateEnd2End(t *testing.T) {
 	tuple1 := eval1.GenBBSPlusTuple(root, signerSet)
 	assert.NotNil(t, tuple1)
 
+	// The sk shares of the tuples are the Lagrange-weighted shares of the shared sk
 	sk := bls12381.NewFr()
 	sk.Add(tuple0.SkShare, tuple1.SkShare)
-
-	seedSk := bls12381.NewFr()
-	seedSk.Add(seeds[0].ski, seeds[1].ski)
-	assert.Equal(t, 0, sk.Cmp(seedSk))
+	pk, err := seeds[0].SharedPublicKey()
+	assert.Nil(t, err)
+	g1 := bls12381.NewG1()
+	assert.True(t, g1.Equal(pk, g1.MulScalar(g1.New(), g1.One(), sk)))
 
 	a := bls12381.NewFr() // Sum up a0 and a1
 	a.Add(tuple0.AShare, tuple1.AShare)
@@ -145,8 +146,145 @@ func TestPCGSeparateEnd2End(t *testing.T) {
 	assert.Equal(t, 0, alpha.Cmp(as))
 }
 
+func TestPCGSeparateAnySignerSet(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4) // Small parameters for testing.
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	pk, err := seeds[0].SharedPublicKey()
+	assert.Nil(t, err)
+
+	generators := make([]*SeparateBBSPlusTupleGenerator, 3)
+	for i, seed := range seeds {
+		generators[i], err = pcg.EvalSeparate(seed, randPolys, ring.Prepared())
+		assert.Nil(t, err)
+	}
+
+	// Each signer set of at least tau parties reconstructs sk and the correlations
+	g1 := bls12381.NewG1()
+	for _, signerSet := range [][]int{{0, 1}, {0, 2}, {1, 2}, {0, 1, 2}} {
+		sk, a, e, s, alpha, delta := bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr()
+		for _, signer := range signerSet {
+			tuple, err := generators[signer].GenBBSPlusTupleAt(ring, 9, signerSet)
+			assert.Nil(t, err)
+			sk.Add(sk, tuple.SkShare)
+			a.Add(a, tuple.AShare)
+			e.Add(e, tuple.EShare)
+			s.Add(s, tuple.SShare)
+			alpha.Add(alpha, tuple.AlphaShare)
+			delta.Add(delta, tuple.DeltaShare)
+		}
+		assert.True(t, g1.Equal(pk, g1.MulScalar(g1.New(), g1.One(), sk)), "sk of signer set %v", signerSet)
+
+		as := bls12381.NewFr()
+		as.Mul(a, s)
+		assert.True(t, alpha.Equal(as), "alpha of signer set %v", signerSet)
+		skPe, askPae := bls12381.NewFr(), bls12381.NewFr()
+		skPe.Add(sk, e)
+		askPae.Mul(a, skPe)
+		assert.True(t, delta.Equal(askPae), "delta of signer set %v", signerSet)
+	}
+}
+
+func TestPCGSeparateForSigners(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4) // Small parameters for testing.
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	signerSet := []int{0, 2}
+	for _, signer := range signerSet {
+		full, err := pcg.EvalSeparate(seeds[signer], randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		restricted, err := pcg.EvalSeparateForSigners(seeds[signer], randPolys, ring.Prepared(), signerSet)
+		assert.Nil(t, err)
+
+		// Restricting the evaluation to the signer set does not change the tuples of the signer set
+		tupleFull, err := full.GenBBSPlusTupleAt(ring, 5, signerSet)
+		assert.Nil(t, err)
+		tupleRestricted, err := restricted.GenBBSPlusTupleAt(ring, 5, signerSet)
+		assert.Nil(t, err)
+		assert.Nil(t, tupleFull.Tag.CheckCompatible(tupleRestricted.Tag))
+		tupleFull.Tag, tupleRestricted.Tag = nil, nil // the timestamps differ
+		assert.Equal(t, tupleFull, tupleRestricted)
+
+		// Signer sets including non-evaluated counterparties are rejected
+		tuple, err := restricted.GenBBSPlusTupleAt(ring, 5, []int{0, 1, 2})
+		assert.NotNil(t, err)
+		assert.Nil(t, tuple)
+	}
+
+	_, err = pcg.EvalSeparateForSigners(seeds[1], randPolys, ring.Prepared(), signerSet) // Own index not in signer set
+	assert.NotNil(t, err)
+	_, err = pcg.EvalSeparateForSigners(seeds[0], randPolys, ring.Prepared(), []int{0}) // No counterparties
+	assert.NotNil(t, err)
+	_, err = pcg.EvalSeparateForSigners(seeds[0], randPolys, ring.Prepared(), []int{0, 3}) // Out of range
+	assert.NotNil(t, err)
+}
+
+func TestPCGSeparateInvariantToN(t *testing.T) {
+	for _, n := range []int{2, 3, 4} {
+		pcg, err := NewPCG(128, 6, n, 2, 2, 4) // Small lpn parameters for testing.
+		assert.Nil(t, err)
+		seeds, err := pcg.TrustedSeedGen()
+		assert.Nil(t, err)
+		randPolys, err := pcg.PickRandomPolynomials()
+		assert.Nil(t, err)
+		ring, err := pcg.GetRing(true)
+		assert.Nil(t, err)
+
+		signerSet := []int{0, n - 1}
+		eval0, err := pcg.EvalSeparate(seeds[signerSet[0]], randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		eval1, err := pcg.EvalSeparate(seeds[signerSet[1]], randPolys, ring.Prepared())
+		assert.Nil(t, err)
+
+		for _, i := range []int{0, 7, ring.Size() - 1} {
+			tuple0 := eval0.GenBBSPlusTuple(ring.Roots[i], signerSet)
+			tuple1 := eval1.GenBBSPlusTuple(ring.Roots[i], signerSet)
+			assert.NotNil(t, tuple0)
+			assert.NotNil(t, tuple1)
+
+			sk := bls12381.NewFr()
+			sk.Add(tuple0.SkShare, tuple1.SkShare)
+			a := bls12381.NewFr()
+			a.Add(tuple0.AShare, tuple1.AShare)
+			e := bls12381.NewFr()
+			e.Add(tuple0.EShare, tuple1.EShare)
+			s := bls12381.NewFr()
+			s.Add(tuple0.SShare, tuple1.SShare)
+
+			// alpha = a*s
+			alpha := bls12381.NewFr()
+			alpha.Add(tuple0.AlphaShare, tuple1.AlphaShare)
+			as := bls12381.NewFr()
+			as.Mul(a, s)
+			assert.True(t, alpha.Equal(as), "alpha does not reconstruct for n = %d", n)
+
+			// delta = a*(sk + e)
+			delta := bls12381.NewFr()
+			delta.Add(tuple0.DeltaShare, tuple1.DeltaShare)
+			skPe := bls12381.NewFr()
+			skPe.Add(sk, e)
+			askPae := bls12381.NewFr()
+			askPae.Mul(a, skPe)
+			assert.True(t, delta.Equal(askPae), "delta does not reconstruct for n = %d", n)
+		}
+	}
+}
+
 func TestRootsOfUnity(t *testing.T) {
 	pcg, err := NewPCG(128, 10, 2, 2, 2, 4) // Small lpn parameters for testing.
+	assert.Nil(t, err)
 
 	ring, err := pcg.GetRing(false)
 	assert.Nil(t, err)
@@ -171,6 +309,64 @@ func TestRootsOfUnity(t *testing.T) {
 	}
 }
 
+func TestLazyRing(t *testing.T) {
+	pcg, err := NewPCG(128, 8, 2, 2, 2, 4) // Small lpn parameters for testing.
+	assert.Nil(t, err)
+
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+	lazyRing, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	assert.Nil(t, lazyRing.Roots)
+	assert.True(t, ring.Div.Equal(lazyRing.Div))
+	assert.Equal(t, len(ring.Roots), lazyRing.Size())
+
+	// Roots computed on demand match the materialized roots
+	for i := 0; i < lazyRing.Size(); i++ {
+		root, err := lazyRing.RootAt(i)
+		assert.Nil(t, err)
+		assert.True(t, ring.Roots[i].Equal(root))
+	}
+
+	// Reverse lookup
+	for _, i := range []int{0, 1, 57, lazyRing.Size() - 1} {
+		index, err := lazyRing.IndexOf(ring.Roots[i])
+		assert.Nil(t, err)
+		assert.Equal(t, i, index)
+		index, err = ring.IndexOf(ring.Roots[i])
+		assert.Nil(t, err)
+		assert.Equal(t, i, index)
+	}
+	_, err = lazyRing.IndexOf(bls12381.NewFr().One())
+	assert.NotNil(t, err)
+
+	// The tuples in sequence use each root exactly once
+	used := make(map[int]bool, lazyRing.Size())
+	for i := 0; i < lazyRing.Size(); i++ {
+		index, err := lazyRing.TupleRootIndex(i)
+		assert.Nil(t, err)
+		used[index] = true
+		root, err := lazyRing.TupleRoot(i)
+		assert.Nil(t, err)
+		assert.True(t, ring.Roots[index].Equal(root))
+	}
+	assert.Len(t, used, lazyRing.Size())
+	_, err = lazyRing.TupleRoot(lazyRing.Size())
+	assert.NotNil(t, err)
+
+	// The evaluation domain of the ring is indexed like its roots
+	domain, err := lazyRing.EvaluationDomain()
+	assert.Nil(t, err)
+	for _, i := range []int{0, 3, lazyRing.Size() - 1} {
+		assert.True(t, ring.Roots[i].Equal(domain.Root(i)))
+	}
+
+	_, err = lazyRing.RootAt(lazyRing.Size())
+	assert.NotNil(t, err)
+	_, err = lazyRing.RootAt(-1)
+	assert.NotNil(t, err)
+}
+
 func BenchmarkRootOfUnityGen15(b *testing.B) {
 	benchmarkRootOfUnityGen(b, 15)
 }
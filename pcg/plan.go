@@ -0,0 +1,87 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"time"
+)
+
+// SeedGenPlan describes the DSPF keys generated by TrustedSeedGen for the parameters of a PCG (see PlanSeedGen).
+// A DSPF key pair with t special points consists of t DPF key pairs.
+type SeedGenPlan struct {
+	VOLEKeyPairs int64 // VOLEKeyPairs is the amount of DSPF key pairs of the VOLE correlation U with domain N, i.e. n(n-1)c
+	OLEKeyPairs  int64 // OLEKeyPairs is the amount of DSPF key pairs of the OLE correlations C and V with domain N+1, i.e. 2n(n-1)c²
+	DPFKeyPairs  int64 // DPFKeyPairs is the amount of DPF key pairs of all DSPF keys, i.e. n(n-1)c*t + 2n(n-1)c²*t²
+
+	VOLEKeyPairBytes int   // VOLEKeyPairBytes is the (approximate) serialized size of both keys of a VOLE DSPF key pair
+	OLEKeyPairBytes  int   // OLEKeyPairBytes is the (approximate) serialized size of both keys of an OLE DSPF key pair
+	TotalBytes       int64 // TotalBytes is the serialized size of all DSPF keys
+	PartyBytes       int64 // PartyBytes is the serialized size of the DSPF keys a single party evaluates, i.e. one key of each pair it is part of
+
+	Calibration       time.Duration // Calibration is the time it took to generate the calibration keys
+	EstimatedDuration time.Duration // EstimatedDuration is the estimated time to generate all DSPF keys on this machine
+}
+
+// String returns a summary of the plan.
+func (s *SeedGenPlan) String() string {
+	return fmt.Sprintf("DSPF key pairs: %d (VOLE) + %d (OLE), DPF key pairs: %d, total: %d bytes, per party: %d bytes, estimated time: %v",
+		s.VOLEKeyPairs, s.OLEKeyPairs, s.DPFKeyPairs, s.TotalBytes, s.PartyBytes, s.EstimatedDuration)
+}
+
+// PlanSeedGen returns the amount and size of the DSPF keys TrustedSeedGen generates for the parameters of the PCG,
+// s.t. integrators can choose (N, n, c, t) before running it. The estimated duration is extrapolated from the
+// generation of a single VOLE and a single OLE DSPF key pair, which also yield the key sizes. The calibration
+// therefore generates t + t² DPF key pairs, which is negligible compared to the generation of all keys unless n and c are small.
+// The sizes are approximate, as the sizes of keys vary slightly with the encoding lengths of their values.
+// Note that the time to sample the exponents and coefficients is not included in the estimation.
+func (p *PCG) PlanSeedGen() (*SeedGenPlan, error) {
+	n, c, t := int64(p.n), int64(p.c), int64(p.t)
+	plan := &SeedGenPlan{
+		VOLEKeyPairs: n * (n - 1) * c,
+		OLEKeyPairs:  2 * n * (n - 1) * c * c,
+	}
+	plan.DPFKeyPairs = plan.VOLEKeyPairs*t + plan.OLEKeyPairs*t*t
+
+	exponents := p.sampleExponents()
+	coefficients := p.sampleCoefficients()
+
+	start := time.Now()
+	vole, err := p.embedVOLECorrelation(exponents.At(0, 0), coefficients.At(0, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the VOLE calibration key: %w", err)
+	}
+	voleDuration := time.Since(start)
+
+	startOLE := time.Now()
+	ole, err := p.embedOLECorrelation(newOuterArena(p.t*p.t), exponents.At(0, 0), exponents.At(1, 0), coefficients.At(0, 0), coefficients.At(1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the OLE calibration key: %w", err)
+	}
+	oleDuration := time.Since(startOLE)
+	plan.Calibration = time.Since(start)
+
+	if plan.VOLEKeyPairBytes, err = vole.serializedSize(); err != nil {
+		return nil, err
+	}
+	if plan.OLEKeyPairBytes, err = ole.serializedSize(); err != nil {
+		return nil, err
+	}
+	plan.TotalBytes = plan.VOLEKeyPairs*int64(plan.VOLEKeyPairBytes) + plan.OLEKeyPairs*int64(plan.OLEKeyPairBytes)
+	// A party evaluates one key of each pair it shares with a counterparty, i.e. of 2/n of all pairs
+	plan.PartyBytes = 2 * plan.TotalBytes / n
+	plan.EstimatedDuration = time.Duration(plan.VOLEKeyPairs)*voleDuration + time.Duration(plan.OLEKeyPairs)*oleDuration
+	return plan, nil
+}
+
+// serializedSize returns the size of the serializations of both keys of the pair.
+func (k *DSPFKeyPair) serializedSize() (int, error) {
+	size := 0
+	for _, key := range []*dspf.Key{&k.Key0, &k.Key1} {
+		data, err := key.SerializeKeys()
+		if err != nil {
+			return 0, fmt.Errorf("failed to serialize DSPF key: %w", err)
+		}
+		size += len(data)
+	}
+	return size, nil
+}
@@ -0,0 +1,46 @@
+package pcg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPlanSeedGen(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+
+	plan, err := pcg.PlanSeedGen()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3*2*2), plan.VOLEKeyPairs)
+	assert.Equal(t, int64(2*3*2*2*2), plan.OLEKeyPairs)
+	assert.Equal(t, int64(12*4+48*16), plan.DPFKeyPairs)
+	assert.Greater(t, plan.Calibration, time.Duration(0))
+	assert.Greater(t, plan.EstimatedDuration, plan.Calibration)
+
+	// The planned sizes match the keys of TrustedSeedGen up to the varying encoding lengths of the key material
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	vole, err := seeds[0].U.At(0, 1, 0).serializedSize()
+	assert.Nil(t, err)
+	assert.InEpsilon(t, plan.VOLEKeyPairBytes, vole, 0.02)
+	ole, err := seeds[0].C.AtOLE(1, 2, 1, 0).serializedSize()
+	assert.Nil(t, err)
+	assert.InEpsilon(t, plan.OLEKeyPairBytes, ole, 0.02)
+	assert.Equal(t, plan.VOLEKeyPairs*int64(plan.VOLEKeyPairBytes)+plan.OLEKeyPairs*int64(plan.OLEKeyPairBytes), plan.TotalBytes)
+	assert.Equal(t, plan.TotalBytes*2/3, plan.PartyBytes)
+}
+
+func TestPlanSeedGenRegularNoise(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	assert.Nil(t, pcg.UseRegularNoise())
+
+	plan, err := pcg.PlanSeedGen()
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	ole, err := seeds[0].V.AtOLE(0, 1, 1, 1).serializedSize()
+	assert.Nil(t, err)
+	assert.InEpsilon(t, plan.OLEKeyPairBytes, ole, 0.02)
+}
@@ -0,0 +1,267 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"runtime"
+	"time"
+)
+
+// EvalCombinedAt evaluates the PCG for an n-out-of-n setting at the roots of the ring of div with the given indices
+// only and returns the tuples in the order of the indices. It yields the same tuples as the generator of EvalCombined,
+// but evaluates the sparse polynomials and the DSPF outputs at each root directly instead of constructing, multiplying
+// and reducing polynomials of degree 2^N. This suits parties that only need few tuples: the cost grows with the
+// amount of roots, while the products of EvalCombined and their reduction are skipped entirely.
+// Note that the DSPF outputs are pseudorandom coefficient vectors, hence they are still fully evaluated, but each
+// vector is only held until it is evaluated at the roots. div must be prepared by a ring (see Ring.Prepared).
+func (p *PCG) EvalCombinedAt(seed *Seed, rand []*poly.Polynomial, div *PreparedDivisor, indices []int) ([]*BBSPlusTuple, error) {
+	if p.tau != p.n {
+		return nil, fmt.Errorf("EvalCombinedAt can only be used for an n-out-of-n setting")
+	}
+	if err := p.checkRandomPolynomials(rand); err != nil {
+		return nil, err
+	}
+	if err := p.checkMemory("EvalCombinedAt", func(e *MemoryEstimate) int64 { return e.EvalAt }, "a smaller N or c reduces the memory"); err != nil {
+		return nil, err
+	}
+	return p.evalCombinedAt(seed, rand, div, indices)
+}
+
+// EvalSeedAt evaluates the seed for an n-out-of-n setting at the roots with the given indices (see EvalCombinedAt).
+func (s *EvalSession) EvalSeedAt(seed *Seed, indices []int) ([]*BBSPlusTuple, error) {
+	if s.pcg.tau != s.pcg.n {
+		return nil, fmt.Errorf("EvalSeedAt can only be used for an n-out-of-n setting")
+	}
+	return s.pcg.evalCombinedAt(seed, s.rand, s.div, indices)
+}
+
+// EvalAtRoot evaluates the PCG for an n-out-of-n setting at the root with the given index only and returns the tuple
+// share of the party (see EvalCombinedAt). The sparse polynomials are evaluated at the root directly, while the DSPF
+// outputs are evaluated in full, as the shares of a party are dense pseudorandom vectors. It serves as a fast
+// correctness check of the seeds (see CheckRootCorrelation) and as the basis of deriving tuples on demand.
+func (p *PCG) EvalAtRoot(seed *Seed, rootIndex int, rand []*poly.Polynomial, div *PreparedDivisor) (*BBSPlusTuple, error) {
+	tuples, err := p.EvalCombinedAt(seed, rand, div, []int{rootIndex})
+	if err != nil {
+		return nil, err
+	}
+	return tuples[0], nil
+}
+
+// CheckRootCorrelation reconstructs the tuple of the shares of all parties at the root with the given index, e.g.
+// returned by EvalAtRoot, and checks its correlations.
+func CheckRootCorrelation(shares []*BBSPlusTuple, rootIndex int) (CorrelationCheck, error) {
+	for i, share := range shares {
+		if share != nil && share.Tag != nil && share.Tag.RootIndex != rootIndex {
+			return CorrelationCheck{}, fmt.Errorf("share %d belongs to root %d instead of root %d", i, share.Tag.RootIndex, rootIndex)
+		}
+	}
+	tuple, err := ReconstructTuple(shares)
+	if err != nil {
+		return CorrelationCheck{}, err
+	}
+	return checkCorrelation(tuple, rootIndex), nil
+}
+
+// evalCombinedAt implements EvalCombinedAt for validated random polynomials.
+func (p *PCG) evalCombinedAt(seed *Seed, rand []*poly.Polynomial, div *PreparedDivisor, indices []int) ([]*BBSPlusTuple, error) {
+	if div == nil {
+		return nil, fmt.Errorf("the divisor must not be nil")
+	}
+	if err := p.checkSeedParams(seed, div); err != nil {
+		return nil, err
+	}
+	ring := div.Ring()
+	if ring == nil {
+		return nil, fmt.Errorf("the divisor must be prepared by a ring to provide the roots (see Ring.Prepared)")
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no root indices given")
+	}
+	roots := make([]*bls12381.Fr, len(indices))
+	for k, index := range indices {
+		root, err := ring.RootAt(index)
+		if err != nil {
+			return nil, err
+		}
+		roots[k] = root
+	}
+	startTimeTotal := time.Now()
+
+	// 1. Evaluate the random and sparse polynomials at the roots
+	u, err := p.constructPolys(seed.coefficients.aBeta, seed.exponents.aOmega)
+	if err != nil {
+		return nil, fmt.Errorf("step 1: failed to generate polynomials for u from aBeta and aOmega: %w", err)
+	}
+	v, err := p.constructPolys(seed.coefficients.eGamma, seed.exponents.eEta)
+	if err != nil {
+		return nil, fmt.Errorf("step 1: failed to generate polynomials for v from eGamma and eEta: %w", err)
+	}
+	k, err := p.constructPolys(seed.coefficients.sEpsilon, seed.exponents.sPhi)
+	if err != nil {
+		return nil, fmt.Errorf("step 1: failed to generate polynomials for k from sEpsilon and sPhi: %w", err)
+	}
+	randAt, uAt, vAt, kAt := evalPolysAt(rand, roots), evalPolysAt(u, roots), evalPolysAt(v, roots), evalPolysAt(k, roots)
+
+	// 2. Process VOLE (u) with seed / delta0 = ask
+	if err := p.checkExpanderInput(seed.index, seed.U, false); err != nil {
+		return nil, newPhaseError(fmt.Errorf("step 2: failed to evaluate VOLE (utilde): %w", err), PhaseVOLE, seed.index)
+	}
+	utildeAt := make([][]*bls12381.Fr, p.c)
+	for r := 0; r < p.c; r++ {
+		utildeAt[r] = scalarMulFr(seed.ski, uAt[r])
+		for j := 0; j < p.n; j++ {
+			if j == seed.index {
+				continue
+			}
+			err := p.addDSPFAt(p.dspfN, utildeAt[r], roots, seed.U.At(seed.index, j, r).Key0, seed.U.At(j, seed.index, r).Key1)
+			if err != nil {
+				return nil, newPhaseError(fmt.Errorf("step 2: failed to evaluate VOLE (utilde): %w", &coordinateError{counterparty: j, r: r, s: -1, err: err}), PhaseVOLE, seed.index)
+			}
+		}
+	}
+
+	// 3. and 4. Process the OLE correlations (u, k) / alpha = as and (u, v) / delta1 = ae
+	wAt, err := p.evalOLEAt(uAt, kAt, seed.C, seed.index, roots)
+	if err != nil {
+		return nil, newPhaseError(fmt.Errorf("step 3: failed to evaluate OLE (w): %w", err), PhaseOLE1, seed.index)
+	}
+	mAt, err := p.evalOLEAt(uAt, vAt, seed.V, seed.index, roots)
+	if err != nil {
+		return nil, newPhaseError(fmt.Errorf("step 4: failed to evaluate OLE (m): %w", err), PhaseOLE2, seed.index)
+	}
+
+	// 5. Calculate the final shares as the inner products with the random polynomials at each root
+	provider := &pointShares{skShare: seed.ski, shares: make(map[string]*tuplegen.Shares, len(roots))}
+	for i, root := range roots {
+		shares := &tuplegen.Shares{
+			A:     innerProductAt(randAt, uAt, i),
+			E:     innerProductAt(randAt, vAt, i),
+			S:     innerProductAt(randAt, kAt, i),
+			Alpha: innerProductAt2D(randAt, wAt, i),
+		}
+		delta0 := innerProductAt(randAt, utildeAt, i)
+		shares.Delta = innerProductAt2D(randAt, mAt, i)
+		shares.Delta.Add(shares.Delta, delta0)
+		provider.shares[string(root.ToBytes())] = shares
+	}
+	p.logger.Infof("Total time for EVAL at %d roots (in s): %v", len(roots), time.Since(startTimeTotal).Seconds())
+
+	generator, err := p.newGenerator(seed, provider)
+	if err != nil {
+		return nil, err
+	}
+	tuples := make([]*BBSPlusTuple, len(indices))
+	for i, index := range indices {
+		if tuples[i], err = generator.GenBBSPlusTupleAt(ring, index); err != nil {
+			return nil, err
+		}
+	}
+	return tuples, nil
+}
+
+// evalOLEAt returns the values w[r][s][k] = u[r][k]*v[s][k] + sum_{j != index} (DSPF(keys[index][j][r][s]) +
+// DSPF(keys[j][index][r][s])) at the k-th root, i.e. the values of the polynomials of ExpandOLE at the roots.
+func (p *PCG) evalOLEAt(u, v [][]*bls12381.Fr, keys *DSPFKeyMatrix, index int, roots []*bls12381.Fr) ([][][]*bls12381.Fr, error) {
+	if err := p.checkExpanderInput(index, keys, true); err != nil {
+		return nil, err
+	}
+	w := make([][][]*bls12381.Fr, p.c)
+	for r := 0; r < p.c; r++ {
+		w[r] = make([][]*bls12381.Fr, p.c)
+		for s := 0; s < p.c; s++ {
+			w[r][s] = make([]*bls12381.Fr, len(roots))
+			for i := range roots {
+				w[r][s][i] = bls12381.NewFr()
+				w[r][s][i].Mul(u[r][i], v[s][i])
+			}
+			for j := 0; j < p.n; j++ {
+				if j == index {
+					continue
+				}
+				if err := p.addDSPFAt(p.dspf2N, w[r][s], roots, keys.AtOLE(index, j, r, s).Key0, keys.AtOLE(j, index, r, s).Key1); err != nil {
+					return nil, &coordinateError{counterparty: j, r: r, s: s, err: err}
+				}
+			}
+		}
+	}
+	return w, nil
+}
+
+// addDSPFAt adds the full evaluations of the DSPF keys, i.e. their coefficient vectors, evaluated at the roots to
+// values. As the roots are roots of the divisor, this equals the evaluation of the reduced polynomials.
+func (p *PCG) addDSPFAt(scheme dspf.Scheme, values, roots []*bls12381.Fr, keys ...dspf.Key) error {
+	for _, key := range keys {
+		coefficients, err := scheme.FullEvalFastAggregated(key)
+		if err != nil {
+			return err
+		}
+		evaluations := make([]*bls12381.Fr, len(roots))
+		_ = parallelFor(len(roots), runtime.NumCPU(), func(i int) error {
+			evaluations[i] = poly.EvaluateDense(coefficients, roots[i])
+			return nil
+		})
+		for i := range values {
+			values[i].Add(values[i], evaluations[i])
+		}
+	}
+	return nil
+}
+
+// evalPolysAt returns the values [r][k] of the polynomials polys[r] at the k-th root.
+func evalPolysAt(polys []*poly.Polynomial, roots []*bls12381.Fr) [][]*bls12381.Fr {
+	values := make([][]*bls12381.Fr, len(polys))
+	for r := range polys {
+		values[r] = make([]*bls12381.Fr, len(roots))
+	}
+	_ = parallelFor(len(polys)*len(roots), runtime.NumCPU(), func(i int) error {
+		r, k := i/len(roots), i%len(roots)
+		values[r][k] = polys[r].Evaluate(roots[k])
+		return nil
+	})
+	return values
+}
+
+// innerProductAt returns sum_r weights[r][k] * values[r][k] at the k-th root.
+func innerProductAt(weights, values [][]*bls12381.Fr, k int) *bls12381.Fr {
+	result, tmp := bls12381.NewFr(), bls12381.NewFr()
+	for r := range weights {
+		tmp.Mul(weights[r][k], values[r][k])
+		result.Add(result, tmp)
+	}
+	return result
+}
+
+// innerProductAt2D returns sum_{r,s} weights[r][k] * weights[s][k] * values[r][s][k] at the k-th root, i.e. the inner
+// product with the outer product of the weights.
+func innerProductAt2D(weights [][]*bls12381.Fr, values [][][]*bls12381.Fr, k int) *bls12381.Fr {
+	result, tmp := bls12381.NewFr(), bls12381.NewFr()
+	for r := range weights {
+		for s := range weights {
+			tmp.Mul(weights[r][k], weights[s][k])
+			tmp.Mul(tmp, values[r][s][k])
+			result.Add(result, tmp)
+		}
+	}
+	return result
+}
+
+// pointShares is a tuplegen.ShareProvider holding the shares of EvalCombinedAt at the evaluated roots only.
+type pointShares struct {
+	skShare *bls12381.Fr
+	shares  map[string]*tuplegen.Shares // shares maps the encoding of each evaluated root to its shares
+}
+
+func (p *pointShares) SkShare() *bls12381.Fr {
+	return p.skShare
+}
+
+func (p *pointShares) SharesAt(root *bls12381.Fr) (*tuplegen.Shares, error) {
+	shares, ok := p.shares[string(root.ToBytes())]
+	if !ok {
+		return nil, fmt.Errorf("the shares were not evaluated at the given root")
+	}
+	return shares, nil
+}
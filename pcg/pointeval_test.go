@@ -0,0 +1,108 @@
+package pcg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEvalCombinedAtMatchesEvalCombined(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	session, err := pcg.NewEvalSession(randPolys, ring.Prepared())
+	assert.Nil(t, err)
+
+	indices := []int{0, 5, 63, 5}
+	for _, seed := range seeds {
+		tuples, err := pcg.EvalCombinedAt(seed, randPolys, ring.Prepared(), indices)
+		assert.Nil(t, err)
+		sessionTuples, err := session.EvalSeedAt(seed, indices)
+		assert.Nil(t, err)
+		gen, err := pcg.EvalCombined(seed, randPolys, ring.Prepared())
+		assert.Nil(t, err)
+
+		assert.Len(t, tuples, len(indices))
+		for k, index := range indices {
+			expected, err := gen.GenBBSPlusTupleAt(ring, index)
+			assert.Nil(t, err)
+			for _, tuple := range []*BBSPlusTuple{tuples[k], sessionTuples[k]} {
+				assert.True(t, expected.SkShare.Equal(tuple.SkShare))
+				assert.True(t, expected.AShare.Equal(tuple.AShare), "root %d", index)
+				assert.True(t, expected.EShare.Equal(tuple.EShare), "root %d", index)
+				assert.True(t, expected.SShare.Equal(tuple.SShare), "root %d", index)
+				assert.True(t, expected.AlphaShare.Equal(tuple.AlphaShare), "root %d", index)
+				assert.True(t, expected.DeltaShare.Equal(tuple.DeltaShare), "root %d", index)
+				assert.Equal(t, index, tuple.Tag.RootIndex)
+				assert.Nil(t, expected.Tag.CheckCompatible(tuple.Tag))
+			}
+		}
+	}
+}
+
+func TestEvalCombinedAtInvalidInput(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	_, err = pcg.EvalCombinedAt(seeds[0], randPolys, ring.Prepared(), nil) // no roots
+	assert.NotNil(t, err)
+	_, err = pcg.EvalCombinedAt(seeds[0], randPolys, ring.Prepared(), []int{ring.Size()}) // root out of range
+	assert.NotNil(t, err)
+	_, err = pcg.EvalCombinedAt(seeds[0], randPolys[:1], ring.Prepared(), []int{0}) // invalid rand
+	assert.NotNil(t, err)
+
+	custom, err := NewPreparedDivisor(ring.Div) // custom divisors provide no roots
+	assert.Nil(t, err)
+	_, err = pcg.EvalCombinedAt(seeds[0], randPolys, custom, []int{0})
+	assert.NotNil(t, err)
+
+	// EvalCombinedAt is restricted to the n-out-of-n setting
+	separatePcg, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+	_, err = separatePcg.EvalCombinedAt(seeds[0], randPolys, ring.Prepared(), []int{0})
+	assert.NotNil(t, err)
+}
+
+func TestEvalAtRoot(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	shares := make([]*BBSPlusTuple, len(seeds))
+	for i, seed := range seeds {
+		shares[i], err = pcg.EvalAtRoot(seed, 17, randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		expected, err := pcg.EvalCombinedAt(seed, randPolys, ring.Prepared(), []int{17})
+		assert.Nil(t, err)
+		assert.True(t, expected[0].AShare.Equal(shares[i].AShare))
+		assert.True(t, expected[0].DeltaShare.Equal(shares[i].DeltaShare))
+	}
+	check, err := CheckRootCorrelation(shares, 17)
+	assert.Nil(t, err)
+	assert.Equal(t, CorrelationCheck{RootIndex: 17, Alpha: true, Delta: true}, check)
+
+	_, err = CheckRootCorrelation(shares, 18) // shares of another root
+	assert.NotNil(t, err)
+	check, err = CheckRootCorrelation(shares[:2], 17) // missing share
+	assert.Nil(t, err)
+	assert.False(t, check.Alpha && check.Delta)
+
+	_, err = pcg.EvalAtRoot(seeds[0], ring.Size(), randPolys, ring.Prepared())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,60 @@
+package poly
+
+// DegreeOfZero is the degree Degree reports for the zero polynomial.
+const DegreeOfZero = -1
+
+// Degree returns the degree of the polynomial, i.e. its highest exponent.
+// The zero polynomial has no terms and hence reports DegreeOfZero. The error is always nil.
+// The degree is cached by Mul and Mod and kept up to date by Add and Sub, s.t. it is returned in O(1). Otherwise, it
+// is computed by a scan over the coefficients. Degree itself never writes the cache, s.t. it is safe to call
+// concurrently on a polynomial that is not modified.
+func (p *Polynomial) Degree() (int, error) {
+	if p.degreeCached {
+		return p.degree, nil
+	}
+	deg, found := maxKey(p.Coefficients)
+	if !found {
+		return DegreeOfZero, nil
+	}
+	return deg, nil
+}
+
+// InvalidateCaches invalidates the cached digest and degree of the polynomial (see Digest and Degree).
+// It must be called if Coefficients are modified directly.
+func (p *Polynomial) InvalidateCaches() {
+	p.InvalidateDigest()
+	p.degreeCached = false
+}
+
+// cacheDegree computes the degree of the polynomial and caches it.
+func (p *Polynomial) cacheDegree() {
+	deg, found := maxKey(p.Coefficients)
+	if !found {
+		deg = DegreeOfZero
+	}
+	p.degree, p.degreeCached = deg, true
+}
+
+// termAdded updates the cached degree after a term with the given exponent was added.
+func (p *Polynomial) termAdded(exp int) {
+	if p.degreeCached && exp > p.degree {
+		p.degree = exp
+	}
+}
+
+// termRemoved updates the cached degree after the term with the given exponent was removed.
+// If the leading term was removed, the next lower exponent is searched downwards, as long as this is cheaper than a
+// scan over all coefficients. For reductions, which remove the leading term in each step, the search is amortized
+// over the steps.
+func (p *Polynomial) termRemoved(exp int) {
+	if !p.degreeCached || exp != p.degree {
+		return
+	}
+	for deg, steps := exp-1, 0; deg >= 0 && steps < len(p.Coefficients); deg, steps = deg-1, steps+1 {
+		if _, ok := p.Coefficients[deg]; ok {
+			p.degree = deg
+			return
+		}
+	}
+	p.cacheDegree()
+}
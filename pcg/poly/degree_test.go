@@ -0,0 +1,102 @@
+package poly
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// assertDegree asserts that the (cached) degree of p equals the degree computed by a scan.
+func assertDegree(t *testing.T, expected int, p *Polynomial) {
+	deg, err := p.Degree()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, deg)
+	if expected == DegreeOfZero {
+		assert.True(t, p.IsZero())
+		return
+	}
+	scanned, _ := maxKey(p.Coefficients)
+	assert.Equal(t, scanned, deg)
+}
+
+func TestDegreeCache(t *testing.T) {
+	p := NewFromFr(randomFrSlice(10))
+	assert.False(t, p.degreeCached)
+	assertDegree(t, 9, p)
+	assert.False(t, p.degreeCached) // Degree never writes the cache
+
+	// Mul caches the degree
+	assert.Nil(t, p.Mul(NewFromFr(randomFrSlice(5))))
+	assert.True(t, p.degreeCached)
+	assertDegree(t, 13, p)
+
+	// Add and Sub keep it up to date
+	x20, err := NewSparse([]*bls12381.Fr{bls12381.NewFr().One()}, []*big.Int{big.NewInt(20)})
+	assert.Nil(t, err)
+	p.Add(x20)
+	assertDegree(t, 20, p)
+	p.Sub(x20)
+	assertDegree(t, 13, p)
+
+	leading := NewEmpty()
+	leading.Coefficients[13] = bls12381.NewFr().Set(p.Coefficients[13])
+	leading.Coefficients[12] = bls12381.NewFr().Set(p.Coefficients[12])
+	p.Sub(leading)
+	assert.True(t, p.degreeCached)
+	assertDegree(t, 11, p)
+
+	// Copies share the cached degree
+	q := p.DeepCopy()
+	assert.True(t, q.degreeCached)
+	assertDegree(t, 11, q)
+	r := NewEmpty()
+	r.Set(p)
+	assertDegree(t, 11, r)
+
+	// Cancelling all terms yields an empty polynomial
+	p.Sub(q)
+	assertDegree(t, DegreeOfZero, p)
+	p.Add(x20)
+	assertDegree(t, 20, p)
+
+	// Direct modifications require an explicit invalidation
+	q.Coefficients[30] = bls12381.NewFr().One()
+	q.InvalidateCaches()
+	assert.False(t, q.degreeCached)
+	assertDegree(t, 30, q)
+}
+
+func TestDegreeCacheSparse(t *testing.T) {
+	// Removing the leading term of a sparse polynomial falls back to a scan instead of searching the gap
+	p, err := NewSparse([]*bls12381.Fr{bls12381.NewFr().One(), bls12381.NewFr().One()}, []*big.Int{big.NewInt(3), big.NewInt(1 << 20)})
+	assert.Nil(t, err)
+	p.cacheDegree()
+	leading, err := NewSparse([]*bls12381.Fr{bls12381.NewFr().One()}, []*big.Int{big.NewInt(1 << 20)})
+	assert.Nil(t, err)
+	p.Sub(leading)
+	assertDegree(t, 3, p)
+}
+
+func TestModKeepsDegreeCache(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	a, err := NewRandomPolynomial(rng, 300)
+	assert.Nil(t, err)
+	b, err := NewRandomPolynomial(rng, 40)
+	assert.Nil(t, err)
+
+	remainder, err := a.Mod(b)
+	assert.Nil(t, err)
+	assert.True(t, remainder.degreeCached)
+	deg, err := remainder.Degree()
+	assert.Nil(t, err)
+	scanned, _ := maxKey(remainder.Coefficients)
+	assert.Equal(t, scanned, deg)
+	assert.Less(t, deg, 40)
+
+	// Reducing the remainder again does not change it
+	again, err := remainder.Mod(b)
+	assert.Nil(t, err)
+	assert.True(t, again.Equal(remainder))
+}
@@ -0,0 +1,36 @@
+package poly
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// Digest returns the SHA-256 digest of the canonical serialization of the polynomial, i.e. its terms in ascending
+// order of their exponents, each encoded as the exponent (8 bytes, big-endian) followed by the coefficient (32 bytes).
+// Equal polynomials have equal digests, s.t. the digest can be used to compare polynomials or as a map key.
+// The digest is cached. The cache is invalidated by all methods modifying the polynomial. If Coefficients are modified
+// directly (or via a polynomial sharing them, see Set), InvalidateCaches must be called.
+func (p *Polynomial) Digest() [32]byte {
+	if p.digest != nil {
+		return *p.digest
+	}
+
+	h := sha256.New()
+	var expBytes [8]byte
+	p.ForEachTerm(func(exp int, coeff *bls12381.Fr) {
+		binary.BigEndian.PutUint64(expBytes[:], uint64(exp))
+		h.Write(expBytes[:])
+		h.Write(coeff.ToBytes())
+	})
+
+	digest := new([32]byte)
+	copy(digest[:], h.Sum(nil))
+	p.digest = digest
+	return *digest
+}
+
+// InvalidateDigest invalidates the cached digest of the polynomial (see Digest).
+func (p *Polynomial) InvalidateDigest() {
+	p.digest = nil
+}
@@ -0,0 +1,50 @@
+package poly
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDigest(t *testing.T) {
+	p := NewFromFr(randomFrSlice(100))
+	q := p.DeepCopy()
+	assert.Equal(t, p.Digest(), q.Digest())
+
+	// The digest does not depend on the construction of the polynomial
+	r := NewEmpty()
+	r.Add(p)
+	assert.Equal(t, p.Digest(), r.Digest())
+
+	// Modifications invalidate the cached digest
+	digest := p.Digest()
+	p.Add(NewFromFr([]*bls12381.Fr{bls12381.NewFr().One()}))
+	assert.NotEqual(t, digest, p.Digest())
+	assert.False(t, p.Equal(q))
+	p.Sub(NewFromFr([]*bls12381.Fr{bls12381.NewFr().One()}))
+	assert.Equal(t, digest, p.Digest())
+	assert.True(t, p.Equal(q))
+
+	two := bls12381.NewFr().One()
+	two.Add(two, two)
+	p.MulByConstant(two)
+	assert.NotEqual(t, digest, p.Digest())
+	assert.NoError(t, p.Mul(NewFromFr([]*bls12381.Fr{bls12381.NewFr().Zero(), bls12381.NewFr().One()})))
+	assert.NotEqual(t, digest, p.Digest())
+	degree, err := p.Degree()
+	assert.NoError(t, err)
+	assert.Equal(t, 100, degree)
+
+	// Direct modifications require an explicit invalidation
+	q.Digest()
+	for _, coeff := range q.Coefficients {
+		coeff.Add(coeff, two)
+		break
+	}
+	q.InvalidateDigest()
+	assert.NotEqual(t, digest, q.Digest())
+
+	// The digest can be used as a map key
+	cache := map[[32]byte]*Polynomial{r.Digest(): r}
+	assert.Contains(t, cache, r.DeepCopy().Digest())
+}
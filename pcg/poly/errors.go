@@ -0,0 +1,59 @@
+package poly
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// MaxDegree bounds the exponents of polynomials. It covers the products of two polynomials over the largest domain
+// 2^32 of the PCG, while rejecting exponents that overflowed or were truncated by a conversion.
+// On platforms with 32-bit integers, exponents are further bounded by math.MaxInt (see maxExponent).
+const MaxDegree int64 = 1<<34 - 1
+
+// maxExponent is the largest exponent a polynomial can hold on this platform.
+var maxExponent = min(MaxDegree, int64(math.MaxInt))
+
+// ErrDuplicateExponent is returned by NewSparse if an exponent is given more than once.
+var ErrDuplicateExponent = errors.New("exponents must be unique")
+
+// ErrDivisionByZero is returned by Mod if the divisor is the zero polynomial.
+var ErrDivisionByZero = errors.New("division by the zero polynomial")
+
+// ExponentOutOfRangeError is returned if an exponent is negative or exceeds MaxDegree. Polynomials are not Laurent
+// polynomials, i.e. a negative exponent would silently break all arithmetic on the polynomial.
+type ExponentOutOfRangeError struct {
+	Exponent *big.Int // Exponent is the offending exponent
+	Max      int64    // Max is the inclusive upper bound of exponents
+}
+
+func (e *ExponentOutOfRangeError) Error() string {
+	return fmt.Sprintf("exponent %s is outside of [0, %d]", e.Exponent, e.Max)
+}
+
+// checkExponent returns an ExponentOutOfRangeError if the exponent is not within [0, maxExponent].
+func checkExponent(exp *big.Int) error {
+	if exp == nil {
+		return errors.New("exponent must not be nil")
+	}
+	if exp.Sign() < 0 || !exp.IsInt64() || exp.Int64() > maxExponent {
+		return &ExponentOutOfRangeError{Exponent: new(big.Int).Set(exp), Max: maxExponent}
+	}
+	return nil
+}
+
+// Validate checks that all exponents of the polynomial are within [0, MaxDegree] and all coefficients are non-nil.
+// Polynomials created by the constructors of this package are always valid, but polynomials whose Coefficients are
+// modified directly should be validated before they are used in arithmetic.
+func (p *Polynomial) Validate() error {
+	for exp, coeff := range p.Coefficients {
+		if err := checkExponent(big.NewInt(int64(exp))); err != nil {
+			return err
+		}
+		if coeff == nil {
+			return fmt.Errorf("coefficient of x^%d must not be nil", exp)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package poly
+
+import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// exponentSample is a random set of exponents for property tests, mixing valid exponents with negative and too large
+// ones as well as duplicates.
+type exponentSample []*big.Int
+
+func (exponentSample) Generate(rng *rand.Rand, size int) reflect.Value {
+	sample := make(exponentSample, rng.Intn(size+1))
+	for i := range sample {
+		switch rng.Intn(8) {
+		case 0:
+			sample[i] = big.NewInt(-1 - rng.Int63n(1<<40))
+		case 1:
+			sample[i] = new(big.Int).Add(big.NewInt(MaxDegree), big.NewInt(1+rng.Int63n(1<<40)))
+		case 2:
+			sample[i] = new(big.Int).Lsh(big.NewInt(1), 64+uint(rng.Intn(64)))
+		case 3:
+			sample[i] = big.NewInt(MaxDegree)
+		default:
+			sample[i] = big.NewInt(rng.Int63n(int64(size + 1)))
+		}
+	}
+	return reflect.ValueOf(sample)
+}
+
+// validExponents returns whether all exponents are within [0, MaxDegree] and unique.
+func validExponents(exponents []*big.Int) bool {
+	seen := make(map[int64]bool, len(exponents))
+	for _, exp := range exponents {
+		if exp.Sign() < 0 || !exp.IsInt64() || exp.Int64() > maxExponent || seen[exp.Int64()] {
+			return false
+		}
+		seen[exp.Int64()] = true
+	}
+	return true
+}
+
+func TestNewSparseRejectsOutOfRange(t *testing.T) {
+	for _, exp := range []*big.Int{big.NewInt(-1), big.NewInt(MaxDegree + 1), new(big.Int).Lsh(big.NewInt(1), 64)} {
+		_, err := NewSparse([]*bls12381.Fr{bls12381.NewFr().One()}, []*big.Int{exp})
+		var rangeErr *ExponentOutOfRangeError
+		assert.True(t, errors.As(err, &rangeErr))
+		assert.Equal(t, 0, rangeErr.Exponent.Cmp(exp))
+		assert.Equal(t, maxExponent, rangeErr.Max)
+	}
+
+	_, err := NewSparse(randomFrSlice(2), []*big.Int{big.NewInt(3), big.NewInt(3)})
+	assert.ErrorIs(t, err, ErrDuplicateExponent)
+	_, err = NewSparse(randomFrSlice(1), []*big.Int{nil})
+	assert.NotNil(t, err)
+
+	p, err := NewSparse([]*bls12381.Fr{bls12381.NewFr().One()}, []*big.Int{big.NewInt(MaxDegree)})
+	assert.Nil(t, err)
+	assert.Nil(t, p.Validate())
+}
+
+func TestNewSparseProperty(t *testing.T) {
+	property := func(exponents exponentSample) bool {
+		p, err := NewSparse(randomFrSlice(len(exponents)), exponents)
+		if !validExponents(exponents) {
+			return p == nil && err != nil
+		}
+		return err == nil && len(p.Coefficients) == len(exponents) && p.Validate() == nil
+	}
+	assert.Nil(t, quick.Check(property, &quick.Config{MaxCount: 500}))
+}
+
+func TestValidate(t *testing.T) {
+	p := NewFromFr(randomFrSlice(10))
+	assert.Nil(t, p.Validate())
+
+	p.Coefficients[-1] = bls12381.NewFr().One()
+	var rangeErr *ExponentOutOfRangeError
+	assert.True(t, errors.As(p.Validate(), &rangeErr))
+	delete(p.Coefficients, -1)
+
+	p.Coefficients[3] = nil
+	assert.NotNil(t, p.Validate())
+}
+
+func TestSubSelfProperty(t *testing.T) {
+	// Subtracting a polynomial from itself removes all terms, i.e. yields a polynomial equal to NewEmpty
+	property := func(seed int64, sparse bool) bool {
+		rng := rand.New(rand.NewSource(seed))
+		p := NewFromFr(randomFrSlice(1 + rng.Intn(64)))
+		if sparse {
+			exponents := []*big.Int{big.NewInt(rng.Int63n(MaxDegree)), big.NewInt(0)}
+			if exponents[0].Sign() == 0 {
+				exponents = exponents[:1]
+			}
+			p, _ = NewSparse(randomFrSlice(len(exponents)), exponents)
+		}
+		p.Sub(p.DeepCopy())
+		deg, err := p.Degree()
+		return p.Equal(NewEmpty()) && len(p.Coefficients) == 0 && deg == DegreeOfZero && err == nil
+	}
+	assert.Nil(t, quick.Check(property, nil))
+}
+
+func TestAddSubProperty(t *testing.T) {
+	// (p + q) - q = p and (p - q) + q = p for polynomials of arbitrary degrees
+	property := func(seed int64) bool {
+		rng := rand.New(rand.NewSource(seed))
+		p := NewFromFr(randomFrSlice(rng.Intn(32)))
+		q := NewFromFr(randomFrSlice(rng.Intn(32)))
+		sum := p.DeepCopy()
+		sum.Add(q)
+		sum.Sub(q)
+		diff := p.DeepCopy()
+		diff.Sub(q)
+		diff.Add(q)
+		return sum.Equal(p) && diff.Equal(p) && sum.Validate() == nil
+	}
+	assert.Nil(t, quick.Check(property, nil))
+}
@@ -2,8 +2,9 @@ package poly
 
 import (
 	"fmt"
-	"math"
+	bls12381 "github.com/kilic/bls12-381"
 	"math/big"
+	"runtime"
 	"sync"
 )
 
@@ -33,243 +34,270 @@ const frN18thRootOfUnity = "6655958292649360769328239839605671205036555777397685
 const frN19thRootOfUnity = "51555063808423308049511962493800938964626677430689792843329428329414086596084"
 const frN20thRootOfUnity = "40761091479171164124770217649282394772014553669504867428009848071682288518237"
 const frN21thRootOfUnity = "14361536881434323440496415919546682220756906901284133287236631717500087413776"
+const frN22thRootOfUnity = "4859563557044021881916617240989566298388494151979623102977292742331120628579"
+const frN23thRootOfUnity = "52167942466760591552294394977846462646742207006759917080697723404762651336366"
+const frN24thRootOfUnity = "18596002123094854211120822350746157678791770803088570110573239418060655130524"
+const frN25thRootOfUnity = "734830308204920577628633053915970695663549910788964686411700880930222744862"
+const frN26thRootOfUnity = "4541622677469846713471916119560591929733417256448031920623614406126544048514"
 
-// FFT is a struct that holds the modulus and root of unity to perform FFT with these parameters.
-// The FFT code was partly taken over from https://github.com/OlegJakushkin/deepblockchains/blob/81407c2359d6680d25b507b9f4b98b42eb164978/stark/primefield.go
+// FFT multiplies and transforms polynomials over Fr via the number theoretic transform of the size of the order of its
+// root of unity. The transform runs natively on Fr elements; the big.Int methods convert their inputs and outputs.
+// The twiddle factors of each root of unity are computed once and shared by all FFTs over it (see fftPlan).
 type FFT struct {
 	modulus     *big.Int
 	rootOfUnity *big.Int
 	n           int // n is the maximum number of coefficients of the polynomial given for multiplication.
 }
 
+// NewFFT creates an FFT over the given root of unity, whose order must be a power of two. It is kept for compatibility
+// with the former big.Int based FFT, hence the modulus must be FrModulus. The FFT transforms vectors of up to the
+// order of the root of unity, but does not bound the polynomials of MulPolysFFT (see NewBLS12381FFT).
 func NewFFT(modulus *big.Int, rootOfUnity *big.Int) (*FFT, error) {
 	if modulus == nil || rootOfUnity == nil {
-		panic("modulus or rootOfUnity cannot be nil")
+		return nil, fmt.Errorf("modulus or rootOfUnity cannot be nil")
 	}
-	return &FFT{modulus, rootOfUnity, -1}, nil
+	if modulus.Cmp(frModulus()) != 0 {
+		return nil, fmt.Errorf("the FFT only supports the scalar field of BLS12-381")
+	}
+	if _, err := planFor(rootOfUnity); err != nil {
+		return nil, err
+	}
+	return &FFT{modulus: modulus, rootOfUnity: rootOfUnity, n: -1}, nil
 }
 
 // NewBLS12381FFT creates a new FFT struct with the modulus and root of unity for BLS12-381.
 // 2**n is the maximum number of coefficients of the polynomial for multiplication.
 func NewBLS12381FFT(n int) (*FFT, error) {
-	modulus := new(big.Int)
-	modulus.SetString(FrModulus, 16)
-
 	// we need to choose n+1, s.t. all multiplications of polynomials of degree n can be represented.
 	n = n + 1
 
 	// Choosing the appropriate root of unity for the +given n is important for the FFT performance.
-	rootOfUnity := big.NewInt(0)
-	switch {
-	case n >= 1 && n <= 8: // For polynomials of degree < 2**8, naive multiplication is generally faster.
-		rootOfUnity.SetString(frN8thRootOfUnity, 10)
-	case n == 9:
-		rootOfUnity.SetString(frN9thRootOfUnity, 10)
-	case n == 10:
-		rootOfUnity.SetString(frN10thRootOfUnity, 10)
-	case n == 11:
-		rootOfUnity.SetString(frN11thRootOfUnity, 10)
-	case n == 12:
-		rootOfUnity.SetString(frN12thRootOfUnity, 10)
-	case n == 13:
-		rootOfUnity.SetString(frN13thRootOfUnity, 10)
-	case n == 14:
-		rootOfUnity.SetString(frN14thRootOfUnity, 10)
-	case n == 15:
-		rootOfUnity.SetString(frN15thRootOfUnity, 10)
-	case n == 16:
-		rootOfUnity.SetString(frN16thRootOfUnity, 10)
-	case n == 17:
-		rootOfUnity.SetString(frN17thRootOfUnity, 10)
-	case n == 18:
-		rootOfUnity.SetString(frN18thRootOfUnity, 10)
-	case n == 19:
-		rootOfUnity.SetString(frN19thRootOfUnity, 10)
-	case n == 20:
-		rootOfUnity.SetString(frN20thRootOfUnity, 10)
-	case n == 21:
-		rootOfUnity.SetString(frN21thRootOfUnity, 10)
-	default:
-		return nil, fmt.Errorf("n must be between 1 and 21 (inclusive)")
+	if n < 1 || n > maxRootOfUnityOrder {
+		return nil, fmt.Errorf("n must be between 1 and %d (inclusive)", maxRootOfUnityOrder)
+	}
+	order := max(n, minRootOfUnityOrder) // For polynomials of degree < 2**8, naive multiplication is generally faster.
+	rootOfUnity, err := checkedRootOfUnity(order)
+	if err != nil {
+		return nil, err
 	}
 
-	return &FFT{modulus, rootOfUnity, n}, nil
+	return &FFT{modulus: frModulus(), rootOfUnity: rootOfUnity, n: n}, nil
 }
 
+// MulPolysFFT multiplies the polynomials given by their coefficients.
 func (f *FFT) MulPolysFFT(a []*big.Int, b []*big.Int) ([]*big.Int, error) {
-	maxLen := int(math.Pow(2, float64(f.n)))
-	if len(a) > maxLen || len(b) > maxLen {
-		panic("polynomial too large")
+	product, err := f.MulPolysFFTFr(bigsToFr(a), bigsToFr(b))
+	if err != nil {
+		return nil, err
 	}
+	return frsToBig(product), nil
+}
 
-	x1 := f.fft(a, false)
-	x2 := f.fft(b, false)
-	c := make([]*big.Int, len(x1))
-	t := new(big.Int)
-	for i, v1 := range x1 {
-		t.Mul(v1, x2[i])
-		c[i] = new(big.Int).Mod(t, f.modulus)
+// MulPolysFFTFr multiplies the polynomials given by their coefficients like MulPolysFFT, but on Fr elements.
+func (f *FFT) MulPolysFFTFr(a []*bls12381.Fr, b []*bls12381.Fr) ([]*bls12381.Fr, error) {
+	if f.n >= 0 && (len(a) > 1<<f.n || len(b) > 1<<f.n) {
+		return nil, fmt.Errorf("polynomials of %d and %d coefficients are too large for an FFT of 2^%d coefficients", len(a), len(b), f.n)
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return nil, nil
+	}
+	plan, err := planFor(f.rootOfUnity)
+	if err != nil {
+		return nil, err
+	}
+	if len(a)+len(b)-1 > plan.size {
+		return nil, fmt.Errorf("the product of %d coefficients exceeds the FFT size %d", len(a)+len(b)-1, plan.size)
 	}
 
-	inv := f.fft(c, true)
+	x1, x2 := plan.load(a), plan.load(b)
+	plan.transform(x1, false)
+	plan.transform(x2, false)
+	for i := range x1 {
+		x1[i].Mul(&x1[i], &x2[i])
+	}
+	plan.transform(x1, true)
 
-	result := make([]*big.Int, len(a)+len(b)-1)
+	result := make([]*bls12381.Fr, len(a)+len(b)-1)
 	for i := range result {
-		result[i] = big.NewInt(0)
-		if inv[i] != nil {
-			result[i].Set(inv[i])
-		}
+		result[i] = &x1[i]
 	}
-
 	return result, nil
 }
 
 // ForwardFFT converts a slice of polynomial coefficients (as *big.Int) into its point-value form using FFT.
 func (f *FFT) ForwardFFT(coeffs []*big.Int) ([]*big.Int, error) {
-	if len(coeffs) > int(math.Pow(2, float64(f.n))) {
+	if f.n >= 0 && len(coeffs) > 1<<f.n {
 		return nil, fmt.Errorf("polynomial too large for FFT parameters")
 	}
-	pointValues := f.fft(coeffs, false)
-	return pointValues, nil
+	return f.transformBig(coeffs, false)
 }
 
 // InverseFFT converts a slice of point-values (as *big.Int) back into polynomial coefficients using the inverse FFT.
 func (f *FFT) InverseFFT(pointValues []*big.Int) ([]*big.Int, error) {
-	if len(pointValues) > int(math.Pow(2, float64(f.n))) {
+	if f.n >= 0 && len(pointValues) > 1<<f.n {
 		return nil, fmt.Errorf("point-value form too large for FFT parameters")
 	}
-	coeffs := f.fft(pointValues, true)
-	return coeffs, nil
+	return f.transformBig(pointValues, true)
 }
 
+// fft returns the (inverse) transform of vals, padded with zeros to the size of the FFT.
 func (f *FFT) fft(vals []*big.Int, inv bool) []*big.Int {
-	// Build up roots of unity
-	rootz := make([]*big.Int, 2)
-	rootz[0] = new(big.Int).Set(ONE)
-	rootz[1] = f.rootOfUnity
+	transformed, err := f.transformBig(vals, inv)
+	if err != nil {
+		panic(err) // the root of unity was validated by the constructor
+	}
+	return transformed
+}
 
-	i := 1
-	for rootz[i].Cmp(ONE) != 0 {
-		t := new(big.Int).Mul(rootz[i], f.rootOfUnity)
-		rootz = append(rootz, t.Mod(t, f.modulus))
-		i = i + 1
+// transformBig returns the (inverse) transform of vals, padded with zeros to the size of the FFT.
+func (f *FFT) transformBig(vals []*big.Int, inv bool) ([]*big.Int, error) {
+	plan, err := planFor(f.rootOfUnity)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) > plan.size {
+		return nil, fmt.Errorf("%d values exceed the FFT size %d", len(vals), plan.size)
 	}
+	values := plan.load(bigsToFr(vals))
+	plan.transform(values, inv)
+	result := make([]*big.Int, len(values))
+	for i := range values {
+		result[i] = values[i].ToBig()
+	}
+	return result, nil
+}
 
-	// Fill in vals with zeroes if needed
-	if len(rootz) > len(vals)+1 {
-		extrazeros := make([]*big.Int, len(rootz)-len(vals)-1)
-		for i := 0; i < len(extrazeros); i++ {
-			extrazeros[i] = new(big.Int)
-		}
-		vals = append(vals, extrazeros...)
+// maxCachedPlanOrder bounds the plans kept in the cache to sizes of up to 2^maxCachedPlanOrder, whose twiddle
+// factors take 2^(maxCachedPlanOrder-1) field elements, i.e. 16 MiB. Larger plans are rebuilt per transform.
+const maxCachedPlanOrder = 20
+
+// fftPlans caches the plans of the roots of unity by their decimal representation.
+var fftPlans sync.Map
+
+// fftPlan holds the precomputed twiddle factors of the transforms of a fixed size over a root of unity.
+type fftPlan struct {
+	size     int
+	twiddles []bls12381.Fr // twiddles[i] = root^i for i in [0, size/2)
+	sizeInv  bls12381.Fr   // sizeInv is the inverse of size, which scales the inverse transform
+}
+
+// planFor returns the plan of the root of unity, whose order must be a power of two of at most 2^frTwoAdicity.
+func planFor(rootOfUnity *big.Int) (*fftPlan, error) {
+	key := rootOfUnity.String()
+	if plan, ok := fftPlans.Load(key); ok {
+		return plan.(*fftPlan), nil
 	}
 
-	if inv {
-		// Inverse FFT
-		t := new(big.Int).Sub(f.modulus, TWO)
-		invlen := new(big.Int).Exp(big.NewInt(int64(len(vals))), t, f.modulus)
-		irootz := make([]*big.Int, 0)
-		for i := len(rootz) - 1; i > 0; i-- {
-			irootz = append(irootz, rootz[i])
+	root := bls12381.NewFr().FromBytes(rootOfUnity.Bytes())
+	one := bls12381.NewFr().One()
+	order, power := 0, bls12381.NewFr().Set(root)
+	for !power.Equal(one) {
+		if order == frTwoAdicity {
+			return nil, fmt.Errorf("the root of unity is not of an order 2^k with k <= %d", frTwoAdicity)
 		}
+		power.Square(power)
+		order++
+	}
 
-		res := f._fft(vals, irootz)
+	plan := &fftPlan{size: 1 << order, twiddles: make([]bls12381.Fr, max(1<<order/2, 1))}
+	plan.twiddles[0].One()
+	for i := 1; i < len(plan.twiddles); i++ {
+		plan.twiddles[i].Mul(&plan.twiddles[i-1], root)
+	}
+	plan.sizeInv.Inverse(bls12381.NewFr().FromBytes(big.NewInt(int64(plan.size)).Bytes()))
+	if order <= maxCachedPlanOrder {
+		fftPlans.Store(key, plan)
+	}
+	return plan, nil
+}
 
-		o := make([]*big.Int, len(res))
-		q := new(big.Int)
-		for i, x := range res {
-			q.Mul(x, invlen)
-			o[i] = new(big.Int).Mod(q, f.modulus)
+// load returns a copy of the values padded with zeros to the size of the plan. nil values are zero.
+func (p *fftPlan) load(values []*bls12381.Fr) []bls12381.Fr {
+	loaded := make([]bls12381.Fr, p.size)
+	for i, value := range values {
+		if value != nil {
+			loaded[i].Set(value)
 		}
-		return o
-	} else {
-		// Regular FFT
-		res := f._fft(vals, rootz[0:len(rootz)-1])
-		return res
 	}
+	return loaded
 }
 
-func (f *FFT) _fft(vals []*big.Int, rootsOfUnity []*big.Int) []*big.Int {
-	if len(vals) <= 1 {
-		return vals
-	}
+// parallelFFTThreshold is the size from which the butterflies of each stage are computed in parallel.
+const parallelFFTThreshold = 1 << 12
 
-	rootsOfUnity2 := len(rootsOfUnity) / 2
-	root2 := make([]*big.Int, rootsOfUnity2)
-	valsDiv2 := len(vals) / 2
-	for i := 0; i < rootsOfUnity2; i++ {
-		root2[i] = rootsOfUnity[i*2]
+// transform computes the (inverse) transform of the values in place via the iterative radix-2 Cooley-Tukey algorithm.
+// The inverse transform is the forward transform with reversed outputs, scaled by 1/size.
+func (p *fftPlan) transform(values []bls12381.Fr, inv bool) {
+	bitReverse(values)
+	workers := 1
+	if p.size >= parallelFFTThreshold {
+		workers = runtime.NumCPU()
 	}
-	o := make([]*big.Int, len(vals))
-
-	var L []*big.Int
-	var R []*big.Int
-	if len(vals) >= 1024 {
+	for length := 2; length <= p.size; length <<= 1 {
+		half, step := length/2, p.size/length
+		butterflies := p.size / 2
+		chunk := (butterflies + workers - 1) / workers
 		var wg sync.WaitGroup
-		y_times_root := make([]*big.Int, valsDiv2)
-		wg.Add(1)
-		go func() {
-			lvals := make([]*big.Int, valsDiv2)
-			for i := 0; i < valsDiv2; i++ {
-				lvals[i] = vals[i*2]
-			}
-			L = f._fft(lvals, root2)
-			wg.Done()
-		}()
-		wg.Add(1)
-		go func() {
-			rvals := make([]*big.Int, valsDiv2)
-			for i := 0; i < valsDiv2; i++ {
-				rvals[i] = vals[i*2+1]
-			}
-			R = f._fft(rvals, root2)
-			for i, rval := range R {
-				y_times_root[i] = new(big.Int).Mul(rval, rootsOfUnity[i])
-			}
-			wg.Done()
-		}()
-		wg.Wait()
-
-		wg.Add(1)
-		go func() {
-			t := new(big.Int)
-			for i, x := range L {
-				t.Add(x, y_times_root[i])
-				o[i] = new(big.Int).Mod(t, f.modulus)
-			}
-			wg.Done()
-		}()
-		wg.Add(1)
-		go func() {
-			t := new(big.Int)
-			for i, x := range L {
-				t.Sub(x, y_times_root[i])
-				o[i+len(L)] = new(big.Int).Mod(t, f.modulus)
-			}
-			wg.Done()
-		}()
+		for start := 0; start < butterflies; start += chunk {
+			end := min(start+chunk, butterflies)
+			wg.Add(1)
+			go func(start, end int) {
+				defer wg.Done()
+				var t, u bls12381.Fr
+				for k := start; k < end; k++ {
+					block, j := k/half, k%half
+					lower := block*length + j
+					upper := lower + half
+					t.Mul(&values[upper], &p.twiddles[j*step])
+					u.Set(&values[lower])
+					values[lower].Add(&u, &t)
+					values[upper].Sub(&u, &t)
+				}
+			}(start, end)
+		}
 		wg.Wait()
-	} else {
-		lvals := make([]*big.Int, valsDiv2)
-		for i := 0; i < valsDiv2; i++ {
-			lvals[i] = vals[i*2]
+	}
+	if inv {
+		for i, j := 1, p.size-1; i < j; i, j = i+1, j-1 {
+			values[i], values[j] = values[j], values[i]
+		}
+		for i := range values {
+			values[i].Mul(&values[i], &p.sizeInv)
 		}
-		L = f._fft(lvals, root2)
+	}
+}
 
-		rvals := make([]*big.Int, valsDiv2)
-		for i := 0; i < valsDiv2; i++ {
-			rvals[i] = vals[i*2+1]
+// bitReverse permutes the values, whose amount is a power of two, by the bit reversal of their indices.
+func bitReverse(values []bls12381.Fr) {
+	for i, j := 1, 0; i < len(values); i++ {
+		bit := len(values) >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			values[i], values[j] = values[j], values[i]
 		}
-		R = f._fft(rvals, root2)
+	}
+}
 
-		y_times_root := new(big.Int)
-		t1 := new(big.Int)
-		t2 := new(big.Int)
-		for i, x := range L {
-			y_times_root.Mul(R[i], rootsOfUnity[i])
-			o[i] = new(big.Int).Mod(t1.Add(x, y_times_root), f.modulus)
-			o[i+len(L)] = new(big.Int).Mod(t2.Sub(x, y_times_root), f.modulus)
+// bigsToFr converts the values to field elements. nil values are zero.
+func bigsToFr(values []*big.Int) []*bls12381.Fr {
+	converted := make([]*bls12381.Fr, len(values))
+	for i, value := range values {
+		converted[i] = bls12381.NewFr()
+		if value != nil {
+			converted[i].FromBytes(value.Bytes())
 		}
 	}
-	return o
+	return converted
+}
+
+// frsToBig converts the field elements to big.Int.
+func frsToBig(values []*bls12381.Fr) []*big.Int {
+	converted := make([]*big.Int, len(values))
+	for i, value := range values {
+		converted[i] = value.ToBig()
+	}
+	return converted
 }
@@ -0,0 +1,97 @@
+package poly
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestFFTRoundTrip(t *testing.T) {
+	for _, n := range []int{7, 9, 12} { // n >= 7, as the size of the FFT is at least 2^minRootOfUnityOrder
+		fft, err := NewBLS12381FFT(n)
+		assert.Nil(t, err)
+
+		coeffs := polyAsCoefficientsBigInt(NewFromFr(randomFrSlice(1 << n)))
+		points, err := fft.ForwardFFT(coeffs)
+		assert.Nil(t, err)
+		recovered, err := fft.InverseFFT(points)
+		assert.Nil(t, err)
+		for i := range coeffs {
+			assert.Equal(t, 0, coeffs[i].Cmp(recovered[i]), "coefficient %d for n=%d", i, n)
+		}
+	}
+}
+
+func TestFFTForwardEvaluatesAtRootPowers(t *testing.T) {
+	fft, err := NewBLS12381FFT(7) // FFT of size 2^8
+	assert.Nil(t, err)
+	p := NewFromFr(randomFrSlice(200))
+	points, err := fft.ForwardFFT(polyAsCoefficientsBigInt(p))
+	assert.Nil(t, err)
+	assert.Equal(t, 256, len(points))
+
+	root := bls12381.NewFr().FromBytes(fft.rootOfUnity.Bytes())
+	x := bls12381.NewFr().One()
+	for i := range points {
+		assert.Equal(t, 0, p.Evaluate(x).ToBig().Cmp(points[i]), "point %d", i)
+		x.Mul(x, root)
+	}
+}
+
+func TestMulPolysFFTFrMatchesNaive(t *testing.T) {
+	// 2^13 coefficients exceed parallelFFTThreshold, s.t. the parallel butterflies are covered
+	for _, size := range []int{1, 3, 300, 1 << 12} {
+		a, b := NewFromFr(randomFrSlice(size)), NewFromFr(randomFrSlice(size/2+1))
+		expected := a.DeepCopy()
+		assert.Nil(t, expected.mulNaive(b))
+
+		actual := a.DeepCopy()
+		assert.Nil(t, actual.mulFFT(b))
+		assert.True(t, expected.Equal(actual), "size %d", size)
+	}
+}
+
+func TestMulPolysFFTFrTooLarge(t *testing.T) {
+	fft, err := NewBLS12381FFT(2)
+	assert.Nil(t, err)
+	_, err = fft.MulPolysFFTFr(randomFrSlice(9), randomFrSlice(1))
+	assert.NotNil(t, err)
+}
+
+func TestNewFFTCompatibility(t *testing.T) {
+	root, err := GenerateRootOfUnity(10)
+	assert.Nil(t, err)
+	legacy, err := NewFFT(frModulus(), root)
+	assert.Nil(t, err)
+	current, err := NewBLS12381FFT(9)
+	assert.Nil(t, err)
+
+	a := polyAsCoefficientsBigInt(NewFromFr(randomFrSlice(400)))
+	b := polyAsCoefficientsBigInt(NewFromFr(randomFrSlice(500)))
+	expected, err := current.MulPolysFFT(a, b)
+	assert.Nil(t, err)
+	actual, err := legacy.MulPolysFFT(a, b)
+	assert.Nil(t, err)
+	for i := range expected {
+		assert.Equal(t, 0, expected[i].Cmp(actual[i]), "coefficient %d", i)
+	}
+
+	_, err = NewFFT(nil, root)
+	assert.NotNil(t, err)
+	_, err = NewFFT(big.NewInt(97), root)
+	assert.NotNil(t, err)
+	_, err = NewFFT(frModulus(), big.NewInt(7)) // 7 is a generator of Fr*, hence of an order that is no power of two
+	assert.NotNil(t, err)
+}
+
+func TestFFTPlanIsCached(t *testing.T) {
+	root, err := GenerateRootOfUnity(9)
+	assert.Nil(t, err)
+	first, err := planFor(root)
+	assert.Nil(t, err)
+	second, err := planFor(new(big.Int).Set(root))
+	assert.Nil(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 512, first.size)
+}
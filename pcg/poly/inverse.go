@@ -0,0 +1,39 @@
+package poly
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// BatchInverse inverts all elements with a single field inversion (Montgomery's trick), i.e. in 3(n-1)
+// multiplications and one inversion instead of n inversions. The elements are not modified.
+// It returns an error if an element is zero.
+func BatchInverse(elements []*bls12381.Fr) ([]*bls12381.Fr, error) {
+	n := len(elements)
+	inverses := make([]*bls12381.Fr, n)
+	if n == 0 {
+		return inverses, nil
+	}
+
+	// products[i] = elements[0] * ... * elements[i]
+	products := make([]*bls12381.Fr, n)
+	for i, element := range elements {
+		if element.IsZero() {
+			return nil, fmt.Errorf("element %d is zero and has no inverse", i)
+		}
+		products[i] = bls12381.NewFr().Set(element)
+		if i > 0 {
+			products[i].Mul(products[i-1], element)
+		}
+	}
+
+	inv := bls12381.NewFr()
+	inv.Inverse(products[n-1])
+	for i := n - 1; i > 0; i-- {
+		inverses[i] = bls12381.NewFr()
+		inverses[i].Mul(inv, products[i-1])
+		inv.Mul(inv, elements[i])
+	}
+	inverses[0] = inv
+	return inverses, nil
+}
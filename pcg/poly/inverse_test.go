@@ -0,0 +1,27 @@
+package poly
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBatchInverse(t *testing.T) {
+	elements := randomFrSlice(10)
+	elements = append(elements, bls12381.NewFr().One())
+
+	inverses, err := BatchInverse(elements)
+	assert.Nil(t, err)
+	for i := range elements {
+		expected := bls12381.NewFr()
+		expected.Inverse(elements[i])
+		assert.True(t, expected.Equal(inverses[i]))
+	}
+
+	inverses, err = BatchInverse(nil)
+	assert.Nil(t, err)
+	assert.Len(t, inverses, 0)
+
+	_, err = BatchInverse([]*bls12381.Fr{bls12381.NewFr().One(), bls12381.NewFr().Zero()})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,231 @@
+package poly
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"math/big"
+)
+
+// EvaluationDomain is the set of the n roots omega^(2i+1), i in [0, n), of the cyclotomic polynomial x^n + 1,
+// where omega is a primitive 2n-th root of unity.
+// Polynomials in the ring F_q[x]/(x^n + 1) are uniquely determined by their evaluations over this domain.
+type EvaluationDomain struct {
+	size     int
+	omega    *bls12381.Fr // omega is the primitive 2n-th root of unity, the i-th root of the domain is omega^(2i+1)
+	omegaInv *bls12381.Fr
+	fft      *FFT // fft is the FFT of size n over the n-th root of unity omega^2
+}
+
+// NewEvaluationDomain creates the evaluation domain of x^size + 1 for the primitive 2*size-th root of unity omega.
+func NewEvaluationDomain(omega *bls12381.Fr, size int) (*EvaluationDomain, error) {
+	if size <= 0 || size&(size-1) != 0 {
+		return nil, fmt.Errorf("size must be a power of two")
+	}
+	check := bls12381.NewFr()
+	check.Exp(omega, big.NewInt(int64(size)))
+	minusOne := bls12381.NewFr()
+	minusOne.Neg(bls12381.NewFr().One())
+	if !check.Equal(minusOne) {
+		return nil, fmt.Errorf("omega must be a primitive %d-th root of unity", 2*size)
+	}
+
+	modulus := new(big.Int)
+	modulus.SetString(FrModulus, 16)
+	psi := bls12381.NewFr()
+	psi.Square(omega)
+	fft, err := NewFFT(modulus, psi.ToBig())
+	if err != nil {
+		return nil, err
+	}
+
+	omegaInv := bls12381.NewFr()
+	omegaInv.Inverse(omega)
+
+	return &EvaluationDomain{
+		size:     size,
+		omega:    bls12381.NewFr().Set(omega),
+		omegaInv: omegaInv,
+		fft:      fft,
+	}, nil
+}
+
+// Size returns the amount of roots of the domain.
+func (d *EvaluationDomain) Size() int {
+	return d.size
+}
+
+// Root returns the i-th root omega^(2i+1) of the domain.
+func (d *EvaluationDomain) Root(i int) *bls12381.Fr {
+	root := bls12381.NewFr()
+	root.Exp(d.omega, big.NewInt(int64(2*i+1)))
+	return root
+}
+
+// NTTPolynomial is a polynomial of F_q[x]/(x^n + 1) in point-value form over an EvaluationDomain.
+// Mul, Add and Sub are pointwise in O(n). The coefficient form is only computed when requested and then cached.
+type NTTPolynomial struct {
+	domain       *EvaluationDomain
+	values       []*bls12381.Fr // values[i] is the evaluation at the i-th root of the domain
+	coefficients *Polynomial    // coefficients caches the coefficient form. nil if not computed yet or outdated.
+}
+
+// NewNTTPolynomial converts the polynomial into point-value form over the domain.
+// The polynomial is reduced modulo x^n + 1 beforehand, i.e. it may be of arbitrary degree.
+func (d *EvaluationDomain) NewNTTPolynomial(p *Polynomial) *NTTPolynomial {
+	reduced := d.reduce(p)
+
+	// Evaluating p at omega^(2i+1) equals evaluating p(omega*x) at the n-th roots of unity omega^(2i).
+	twisted := make([]*big.Int, d.size)
+	omegaPow := bls12381.NewFr().One()
+	tmp := bls12381.NewFr()
+	for k := 0; k < d.size; k++ {
+		if coeff, ok := reduced.Coefficients[k]; ok {
+			tmp.Mul(coeff, omegaPow)
+			twisted[k] = tmp.ToBig()
+		} else {
+			twisted[k] = big.NewInt(0)
+		}
+		omegaPow.Mul(omegaPow, d.omega)
+	}
+
+	values := make([]*bls12381.Fr, d.size)
+	for i, val := range d.transform(twisted, false) {
+		values[i] = bls12381.NewFr().FromBytes(val.Bytes())
+	}
+
+	return &NTTPolynomial{
+		domain:       d,
+		values:       values,
+		coefficients: reduced,
+	}
+}
+
+// Domain returns the evaluation domain of the polynomial.
+func (p *NTTPolynomial) Domain() *EvaluationDomain {
+	return p.domain
+}
+
+// ValueAt returns the evaluation of the polynomial at the i-th root of the domain in O(1).
+func (p *NTTPolynomial) ValueAt(i int) (*bls12381.Fr, error) {
+	if i < 0 || i >= p.domain.size {
+		return nil, fmt.Errorf("root index %d is out of range [0, %d)", i, p.domain.size)
+	}
+	return bls12381.NewFr().Set(p.values[i]), nil
+}
+
+// Coefficients returns the coefficient form of the polynomial, reduced modulo x^n + 1.
+// The conversion is only done once until the polynomial is modified.
+func (p *NTTPolynomial) Coefficients() *Polynomial {
+	if p.coefficients == nil {
+		p.coefficients = NewFromFrOwned(p.Dense())
+	}
+	return p.coefficients.DeepCopy()
+}
+
+// Dense returns the coefficient vector of length n of the polynomial, reduced modulo x^n + 1, like Coefficients, but
+// without building the coefficient map. The vector is not shared with the polynomial.
+func (p *NTTPolynomial) Dense() []*bls12381.Fr {
+	vals := make([]*big.Int, p.domain.size)
+	for i, val := range p.values {
+		vals[i] = val.ToBig()
+	}
+
+	coefficients := make([]*bls12381.Fr, p.domain.size)
+	omegaInvPow := bls12381.NewFr().One()
+	for k, val := range p.domain.transform(vals, true) {
+		coefficients[k] = bls12381.NewFr().FromBytes(val.Bytes())
+		coefficients[k].Mul(coefficients[k], omegaInvPow) // Undo the twist by omega^k
+		omegaInvPow.Mul(omegaInvPow, p.domain.omegaInv)
+	}
+	return coefficients
+}
+
+// Add adds q to p pointwise.
+func (p *NTTPolynomial) Add(q *NTTPolynomial) error {
+	if err := p.checkDomain(q); err != nil {
+		return err
+	}
+	for i := range p.values {
+		p.values[i].Add(p.values[i], q.values[i])
+	}
+	p.coefficients = nil
+	return nil
+}
+
+// Sub subtracts q from p pointwise.
+func (p *NTTPolynomial) Sub(q *NTTPolynomial) error {
+	if err := p.checkDomain(q); err != nil {
+		return err
+	}
+	for i := range p.values {
+		p.values[i].Sub(p.values[i], q.values[i])
+	}
+	p.coefficients = nil
+	return nil
+}
+
+// Mul multiplies p with q pointwise, which equals the product of both polynomials modulo x^n + 1.
+func (p *NTTPolynomial) Mul(q *NTTPolynomial) error {
+	if err := p.checkDomain(q); err != nil {
+		return err
+	}
+	for i := range p.values {
+		p.values[i].Mul(p.values[i], q.values[i])
+	}
+	p.coefficients = nil
+	return nil
+}
+
+// MulByConstant multiplies p with a constant.
+func (p *NTTPolynomial) MulByConstant(constant *bls12381.Fr) {
+	for i := range p.values {
+		p.values[i].Mul(p.values[i], constant)
+	}
+	p.coefficients = nil
+}
+
+// DeepCopy returns a deep copy of the polynomial.
+func (p *NTTPolynomial) DeepCopy() *NTTPolynomial {
+	values := make([]*bls12381.Fr, len(p.values))
+	for i, val := range p.values {
+		values[i] = bls12381.NewFr().Set(val)
+	}
+	var coefficients *Polynomial
+	if p.coefficients != nil {
+		coefficients = p.coefficients.DeepCopy()
+	}
+	return &NTTPolynomial{
+		domain:       p.domain,
+		values:       values,
+		coefficients: coefficients,
+	}
+}
+
+// checkDomain checks that both polynomials are given over the same domain.
+func (p *NTTPolynomial) checkDomain(q *NTTPolynomial) error {
+	if p.domain.size != q.domain.size || !p.domain.omega.Equal(q.domain.omega) {
+		return fmt.Errorf("polynomials are not given over the same evaluation domain")
+	}
+	return nil
+}
+
+// reduce returns p modulo x^n + 1, i.e. x^(k*n + r) is mapped to (-1)^k * x^r.
+func (d *EvaluationDomain) reduce(p *Polynomial) *Polynomial {
+	reduced := NewEmpty()
+	for exp, coeff := range p.Coefficients {
+		val := bls12381.NewFr().Set(coeff)
+		if (exp/d.size)%2 == 1 {
+			val.Neg(val)
+		}
+		reduced.addCoefficient(exp%d.size, val)
+	}
+	return reduced
+}
+
+// transform computes the (inverse) FFT of size n over omega^2.
+func (d *EvaluationDomain) transform(vals []*big.Int, inv bool) []*big.Int {
+	if d.size == 1 {
+		return vals
+	}
+	return d.fft.fft(vals, inv)
+}
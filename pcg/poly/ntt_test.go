@@ -0,0 +1,99 @@
+package poly
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func testEvaluationDomain(t *testing.T) *EvaluationDomain {
+	omega, ok := new(big.Int).SetString(frN9thRootOfUnity, 10) // primitive 512th root of unity
+	assert.True(t, ok)
+	domain, err := NewEvaluationDomain(bls12381.NewFr().FromBytes(omega.Bytes()), 256)
+	assert.Nil(t, err)
+	return domain
+}
+
+func TestNTTPolynomialEvaluations(t *testing.T) {
+	domain := testEvaluationDomain(t)
+	p := NewFromFr(randomFrSlice(200))
+	nttP := domain.NewNTTPolynomial(p)
+
+	for _, i := range []int{0, 1, 100, 255} {
+		val, err := nttP.ValueAt(i)
+		assert.Nil(t, err)
+		assert.True(t, p.Evaluate(domain.Root(i)).Equal(val))
+	}
+	_, err := nttP.ValueAt(256)
+	assert.NotNil(t, err)
+
+	// Roots are roots of x^256 + 1
+	div, err := NewCyclotomicPolynomial(big.NewInt(512))
+	assert.Nil(t, err)
+	assert.True(t, div.Evaluate(domain.Root(7)).IsZero())
+}
+
+func TestNTTPolynomialMul(t *testing.T) {
+	domain := testEvaluationDomain(t)
+	div, err := NewCyclotomicPolynomial(big.NewInt(512))
+	assert.Nil(t, err)
+
+	a := NewFromFr(randomFrSlice(256))
+	b := randomSparsePoly(16, 300) // Degree above the domain size is reduced first
+
+	expected, err := Mul(a, b)
+	assert.Nil(t, err)
+	expected, err = expected.Mod(div)
+	assert.Nil(t, err)
+
+	nttA := domain.NewNTTPolynomial(a)
+	err = nttA.Mul(domain.NewNTTPolynomial(b))
+	assert.Nil(t, err)
+	assert.True(t, expected.Equal(nttA.Coefficients()))
+
+	// The dense vector holds the same coefficients without the coefficient map
+	dense := nttA.Dense()
+	assert.Len(t, dense, 256)
+	assert.True(t, expected.Equal(NewFromFr(dense)))
+}
+
+func TestNTTPolynomialAddSub(t *testing.T) {
+	domain := testEvaluationDomain(t)
+	a := NewFromFr(randomFrSlice(256))
+	b := NewFromFr(randomFrSlice(256))
+
+	nttA := domain.NewNTTPolynomial(a)
+	assert.True(t, a.Equal(nttA.Coefficients()))
+
+	sum := nttA.DeepCopy()
+	err := sum.Add(domain.NewNTTPolynomial(b))
+	assert.Nil(t, err)
+	assert.True(t, Add(a, b).Equal(sum.Coefficients()))
+
+	err = sum.Sub(domain.NewNTTPolynomial(b))
+	assert.Nil(t, err)
+	assert.True(t, a.Equal(sum.Coefficients()))
+
+	two := bls12381.NewFr().FromBytes(big.NewInt(2).Bytes())
+	sum.MulByConstant(two)
+	a.MulByConstant(two)
+	assert.True(t, a.Equal(sum.Coefficients()))
+}
+
+func TestNewEvaluationDomainInvalid(t *testing.T) {
+	_, err := NewEvaluationDomain(bls12381.NewFr().One(), 256)
+	assert.NotNil(t, err)
+	_, err = NewEvaluationDomain(bls12381.NewFr().One(), 100)
+	assert.NotNil(t, err)
+
+	// Domains of different roots are not compatible
+	domain := testEvaluationDomain(t)
+	omega, _ := new(big.Int).SetString(frN9thRootOfUnity, 10)
+	omega3 := bls12381.NewFr()
+	omega3.Exp(bls12381.NewFr().FromBytes(omega.Bytes()), big.NewInt(3))
+	other, err := NewEvaluationDomain(omega3, 256)
+	assert.Nil(t, err)
+	p := NewFromFr(randomFrSlice(10))
+	assert.NotNil(t, domain.NewNTTPolynomial(p).Mul(other.NewNTTPolynomial(p)))
+}
@@ -0,0 +1,193 @@
+package poly
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"runtime"
+	"sync"
+)
+
+// EvaluationPoint is a point x together with the powers of x the evaluation of polynomials up to a degree bound
+// needs, i.e. the powers x^(2^i) of the sparse evaluation and the powers x^(k*chunkSize) combining the chunks of the
+// parallel evaluation. Evaluating several polynomials at the same point via EvaluateAt computes the powers only once,
+// e.g. the six polynomials of a tuple at a root. An EvaluationPoint is immutable and hence safe for concurrent use.
+type EvaluationPoint struct {
+	x           *bls12381.Fr
+	maxDegree   int
+	squares     []*bls12381.Fr // squares[i] = x^(2^i) for all 2^i <= maxDegree
+	chunkSize   int
+	chunkPowers []*bls12381.Fr // chunkPowers[k] = x^(k*chunkSize) for each chunk of the parallel evaluation
+}
+
+// NewEvaluationPoint precomputes the powers of x for polynomials of degree up to maxDegree.
+func NewEvaluationPoint(x *bls12381.Fr, maxDegree int) *EvaluationPoint {
+	maxDegree = max(maxDegree, 0)
+	chunks := runtime.NumCPU()
+	chunkSize := maxDegree/chunks + 1 // s.t. the chunks cover maxDegree+1 coefficients
+	return &EvaluationPoint{
+		x:           bls12381.NewFr().Set(x),
+		maxDegree:   maxDegree,
+		squares:     powersOfTwo(x, maxDegree),
+		chunkSize:   chunkSize,
+		chunkPowers: precomputeXPowers(x, chunkSize, chunks),
+	}
+}
+
+// X returns the point.
+func (e *EvaluationPoint) X() *bls12381.Fr {
+	return e.x
+}
+
+// MaxDegree returns the degree bound of the polynomials the powers were precomputed for.
+func (e *EvaluationPoint) MaxDegree() int {
+	return e.maxDegree
+}
+
+// EvaluateAt evaluates the polynomial at the point like Evaluate, but reuses the precomputed powers of the point.
+// Polynomials of a degree above the degree bound of the point are evaluated via Evaluate.
+func (p *Polynomial) EvaluateAt(point *EvaluationPoint) *bls12381.Fr {
+	numCoefficients := len(p.Coefficients)
+	if numCoefficients == 0 {
+		return bls12381.NewFr().Zero()
+	}
+	degree, _ := p.Degree()
+	if degree > point.maxDegree {
+		return p.Evaluate(point.x)
+	}
+	if numCoefficients*log2(nextPowerOf2(degree+1)) < degree+1 {
+		return p.evaluateSparseWith(point.squares)
+	}
+	if numCoefficients < currentThresholds().ParallelEvaluation {
+		return p.evaluateSequential(point.x)
+	}
+	return p.evaluateChunks(point)
+}
+
+// EvaluateAtPoints evaluates the polynomial at all points and returns the values in the order of the points. Dense
+// polynomials are evaluated by a single pass over their coefficients for all points, which saves looking up each
+// coefficient once per point. If the points do not share the same degree bound of at least the degree of the
+// polynomial, they are evaluated one by one via EvaluateAt.
+func (p *Polynomial) EvaluateAtPoints(points []*EvaluationPoint) []*bls12381.Fr {
+	values := make([]*bls12381.Fr, len(points))
+	numCoefficients := len(p.Coefficients)
+	degree, _ := p.Degree()
+	dense := numCoefficients > 0 && numCoefficients*log2(nextPowerOf2(degree+1)) >= degree+1
+	if len(points) < 2 || !dense || !sameDegreeBound(points, degree) {
+		for i, point := range points {
+			values[i] = p.EvaluateAt(point)
+		}
+		return values
+	}
+	if numCoefficients < currentThresholds().ParallelEvaluation {
+		return evaluateChunkAtPoints(p, points, 0, degree+1)
+	}
+	return evaluateChunksAtPoints(points, degree, func(start, end int) []*bls12381.Fr {
+		return evaluateChunkAtPoints(p, points, start, end)
+	})
+}
+
+// EvaluateDenseAtPoints evaluates the polynomial with the given coefficient vector at all points like
+// EvaluateAtPoints, but without a coefficient map, e.g. for the dense shares of the PCG. nil coefficients are zero.
+func EvaluateDenseAtPoints(coefficients []*bls12381.Fr, points []*EvaluationPoint) []*bls12381.Fr {
+	degree := len(coefficients) - 1
+	if len(coefficients) < currentThresholds().ParallelEvaluation || len(points) == 0 || !sameDegreeBound(points, degree) {
+		return evaluateDenseChunkAtPoints(coefficients, points, 0, len(coefficients))
+	}
+	return evaluateChunksAtPoints(points, degree, func(start, end int) []*bls12381.Fr {
+		return evaluateDenseChunkAtPoints(coefficients, points, start, end)
+	})
+}
+
+// evaluateChunksAtPoints evaluates the chunks of the coefficients up to the given degree at all points in parallel
+// via evaluateChunk and combines them with the precomputed chunk powers of the points, which share their degree bound.
+func evaluateChunksAtPoints(points []*EvaluationPoint, degree int, evaluateChunk func(start, end int) []*bls12381.Fr) []*bls12381.Fr {
+	chunkSize := points[0].chunkSize
+	partials := make([][]*bls12381.Fr, len(points[0].chunkPowers))
+	var wg sync.WaitGroup
+	for c := range partials {
+		start := c * chunkSize
+		end := min(start+chunkSize, degree+1)
+		if start >= end {
+			break // the remaining chunks exceed the degree
+		}
+
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			partials[c] = evaluateChunk(start, end)
+		}(c)
+	}
+	wg.Wait()
+
+	values := make([]*bls12381.Fr, len(points))
+	tmp := bls12381.NewFr()
+	for i, point := range points {
+		values[i] = bls12381.NewFr().Zero()
+		for c, partial := range partials {
+			if partial == nil {
+				break
+			}
+			tmp.Mul(partial[i], point.chunkPowers[c])
+			values[i].Add(values[i], tmp)
+		}
+	}
+	return values
+}
+
+// evaluateChunkAtPoints evaluates the coefficients [start, end) of the polynomial at all points via Horner's method,
+// looking up each coefficient once for all points.
+func evaluateChunkAtPoints(p *Polynomial, points []*EvaluationPoint, start, end int) []*bls12381.Fr {
+	results := make([]*bls12381.Fr, len(points))
+	for i := range results {
+		results[i] = bls12381.NewFr().Zero()
+	}
+	for exp := end - 1; exp >= start; exp-- {
+		coeff, ok := p.Coefficients[exp]
+		for i, point := range points {
+			results[i].Mul(results[i], point.x)
+			if ok {
+				results[i].Add(results[i], coeff)
+			}
+		}
+	}
+	return results
+}
+
+// evaluateDenseChunkAtPoints evaluates the coefficients [start, end) of the vector at all points via Horner's method
+// (see evaluateChunkAtPoints).
+func evaluateDenseChunkAtPoints(coefficients []*bls12381.Fr, points []*EvaluationPoint, start, end int) []*bls12381.Fr {
+	results := make([]*bls12381.Fr, len(points))
+	for i := range results {
+		results[i] = bls12381.NewFr().Zero()
+	}
+	for exp := end - 1; exp >= start; exp-- {
+		coeff := coefficients[exp]
+		for i, point := range points {
+			results[i].Mul(results[i], point.x)
+			if coeff != nil {
+				results[i].Add(results[i], coeff)
+			}
+		}
+	}
+	return results
+}
+
+// sameDegreeBound returns whether all points share the same degree bound of at least degree.
+func sameDegreeBound(points []*EvaluationPoint, degree int) bool {
+	for _, point := range points {
+		if point.maxDegree != points[0].maxDegree || point.maxDegree < degree {
+			return false
+		}
+	}
+	return true
+}
+
+// powersOfTwo returns the powers x^(2^i) for all 2^i <= degree, but at least x.
+func powersOfTwo(x *bls12381.Fr, degree int) []*bls12381.Fr {
+	squares := []*bls12381.Fr{bls12381.NewFr().Set(x)}
+	for i := 1; 1<<i <= degree; i++ {
+		square := bls12381.NewFr()
+		square.Square(squares[i-1])
+		squares = append(squares, square)
+	}
+	return squares
+}
@@ -0,0 +1,127 @@
+package poly
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestEvaluateAt(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	x, err := bls12381.NewFr().Rand(rng)
+	assert.Nil(t, err)
+	point := NewEvaluationPoint(x, 4095)
+	assert.Equal(t, 4095, point.MaxDegree())
+	assert.True(t, x.Equal(point.X()))
+
+	// Dense polynomials with gaps, i.e. of a degree above their amount of coefficients
+	withGaps := NewFromFr(randomFrSlice(4096))
+	for exp := 1000; exp < 1500; exp++ {
+		delete(withGaps.Coefficients, exp)
+	}
+	withGaps.InvalidateCaches()
+
+	for _, p := range []*Polynomial{
+		NewFromFr(randomFrSlice(4096)), // parallel
+		withGaps,                       // parallel
+		NewFromFr(randomFrSlice(300)),  // sequential
+		randomSparsePoly(16, 4000),     // sparse
+		randomSparsePoly(16, 1<<20),    // above the degree bound
+		NewEmpty(),
+	} {
+		expected := p.evaluateNaive(x)
+		assert.True(t, expected.Equal(p.EvaluateAt(point)))
+		assert.True(t, expected.Equal(p.Evaluate(x)))
+	}
+	assert.True(t, withGaps.evaluateSequential(x).Equal(withGaps.evaluateParallel(x)))
+}
+
+func TestEvaluateAtPoints(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	points := make([]*EvaluationPoint, 5)
+	for i := range points {
+		x, err := bls12381.NewFr().Rand(rng)
+		assert.Nil(t, err)
+		points[i] = NewEvaluationPoint(x, 4095)
+	}
+	other, err := bls12381.NewFr().Rand(rng)
+	assert.Nil(t, err)
+
+	for _, p := range []*Polynomial{
+		NewFromFr(randomFrSlice(4096)), // parallel
+		NewFromFr(randomFrSlice(300)),  // sequential
+		randomSparsePoly(16, 4000),     // sparse
+	} {
+		for _, batch := range [][]*EvaluationPoint{
+			points,
+			append(points[:2:2], NewEvaluationPoint(other, 300)), // differing degree bounds
+			points[:1],
+			nil,
+		} {
+			values := p.EvaluateAtPoints(batch)
+			assert.Len(t, values, len(batch))
+			for i, point := range batch {
+				assert.True(t, p.evaluateNaive(point.X()).Equal(values[i]))
+			}
+		}
+	}
+}
+
+func TestEvaluateDenseAtPoints(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	points := make([]*EvaluationPoint, 3)
+	for i := range points {
+		x, err := bls12381.NewFr().Rand(rng)
+		assert.Nil(t, err)
+		points[i] = NewEvaluationPoint(x, 4095)
+	}
+	other, err := bls12381.NewFr().Rand(rng)
+	assert.Nil(t, err)
+
+	withGaps := randomFrSlice(4096)
+	for exp := 1000; exp < 1500; exp++ {
+		withGaps[exp] = nil
+	}
+	for _, coefficients := range [][]*bls12381.Fr{
+		randomFrSlice(4096), // parallel
+		withGaps,            // parallel with nil coefficients
+		randomFrSlice(300),  // sequential
+		nil,
+	} {
+		p := NewEmpty()
+		for exp, coeff := range coefficients {
+			if coeff != nil {
+				p.Coefficients[exp] = coeff
+			}
+		}
+		for _, batch := range [][]*EvaluationPoint{points, append(points[:1:1], NewEvaluationPoint(other, 300)), nil} {
+			values := EvaluateDenseAtPoints(coefficients, batch)
+			assert.Len(t, values, len(batch))
+			for i, point := range batch {
+				assert.True(t, p.evaluateNaive(point.X()).Equal(values[i]))
+			}
+		}
+	}
+}
+
+func BenchmarkEvaluateAtPoints(b *testing.B) {
+	p := NewFromFr(randomFrSlice(1 << 14))
+	points := make([]*EvaluationPoint, 16)
+	for i, x := range randomFrSlice(len(points)) {
+		points[i] = NewEvaluationPoint(x, 1<<14-1)
+	}
+
+	b.Run("EvaluateAt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, point := range points {
+				p.EvaluateAt(point)
+			}
+		}
+	})
+	b.Run("EvaluateAtPoints", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p.EvaluateAtPoints(points)
+		}
+	})
+}
@@ -5,32 +5,45 @@ import (
 	"encoding/binary"
 	"fmt"
 	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"io"
 	"math"
 	"math/big"
-	"math/rand"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 )
 
 // Polynomial represents a polynomial in the form of a map: exponent -> coefficient.
+// Only non-zero coefficients are stored, hence the zero polynomial holds no coefficients. Its canonical form is
+// NewEmpty(), but the zero value &Polynomial{} is a valid zero polynomial as well.
 type Polynomial struct {
 	Coefficients map[int]*bls12381.Fr // Coefficients of the polynomial in the form of a map: exponent -> coefficient
+	digest       *[32]byte            // digest caches the result of Digest. nil if not computed or invalidated.
+	degree       int                  // degree caches the result of Degree. It is only valid if degreeCached is set.
+	degreeCached bool                 // degreeCached reports whether degree is valid.
 }
 
 // Serialize returns the byte representation of the polynomial.
 func (p *Polynomial) Serialize() ([]byte, error) {
 	var buffer bytes.Buffer
 
-	for exponent, coefficient := range p.Coefficients {
-		// Write the exponent
-		err := binary.Write(&buffer, binary.BigEndian, int32(exponent))
+	// Terms are written in ascending order of the exponents, s.t. the serialization is deterministic
+	var err error
+	p.ForEachTerm(func(exponent int, coefficient *bls12381.Fr) {
 		if err != nil {
-			return nil, err
+			return
 		}
+		// Write the exponent
+		err = binary.Write(&buffer, binary.BigEndian, int32(exponent))
 
 		// Write the coefficient
 		coeffBytes := coefficient.ToBytes()
 		buffer.Write(coeffBytes[:])
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return buffer.Bytes(), nil
@@ -51,12 +64,14 @@ func (p *Polynomial) Deserialize(data []byte) error {
 
 		// Read the coefficient
 		coeffBytes := make([]byte, 32) // size of bls12381.Fr in bytes is 32
-		_, err = buffer.Read(coeffBytes)
+		n, err := buffer.Read(coeffBytes)
 		if err != nil {
 			return err
 		}
-		coefficient := bls12381.NewFr()
-		coefficient.FromBytes(coeffBytes)
+		coefficient, err := dpf.FrFromBytesCanonical(coeffBytes[:n])
+		if err != nil {
+			return fmt.Errorf("invalid coefficient of exponent %d: %w", exponent, err)
+		}
 
 		newPolynomial.Coefficients[int(exponent)] = coefficient
 	}
@@ -65,7 +80,7 @@ func (p *Polynomial) Deserialize(data []byte) error {
 	return nil
 }
 
-// NewEmpty returns a new empty polynomial.
+// NewEmpty returns a new zero polynomial.
 func NewEmpty() *Polynomial {
 	return &Polynomial{
 		Coefficients: make(map[int]*bls12381.Fr),
@@ -73,10 +88,8 @@ func NewEmpty() *Polynomial {
 }
 
 // NewFromSerialization takes a serialized polynomial and deserializes it to return a new Polynomial.
+// The zero polynomial is serialized to an empty byte slice.
 func NewFromSerialization(data []byte) (*Polynomial, error) {
-	if len(data) == 0 {
-		return nil, fmt.Errorf("byte slice is empty")
-	}
 	newPoly := NewEmpty()
 	err := newPoly.Deserialize(data)
 	if err != nil {
@@ -85,22 +98,90 @@ func NewFromSerialization(data []byte) (*Polynomial, error) {
 	return newPoly, nil
 }
 
+// parallelConstructionThreshold is the amount of values from which NewFromFr copies them in parallel.
+const parallelConstructionThreshold = 1 << 12
+
 // NewFromFr converts slice of *bls12381.Fr to Polynomial representation.
-// The index of the element will be its exponent.
+// The index of the element will be its exponent. The values are copied, in parallel for large slices.
 func NewFromFr(values []*bls12381.Fr) *Polynomial {
-	coefficients := make(map[int]*bls12381.Fr)
-	for i, v := range values {
+	workers := 1
+	if len(values) >= parallelConstructionThreshold {
+		workers = runtime.NumCPU()
+	}
+	return NewFromFrParallel(values, workers)
+}
+
+// NewFromFrParallel works like NewFromFr, but copies the values in chunks by the given amount of workers.
+// The copies are allocated at once and set directly instead of via their byte representation. The map of the
+// polynomial is filled sequentially afterwards, as maps do not support concurrent writes.
+func NewFromFrParallel(values []*bls12381.Fr, workers int) *Polynomial {
+	copies := make([]bls12381.Fr, len(values))
+	copyChunk := func(start, end int) {
+		for i := start; i < end; i++ {
+			copies[i].Set(values[i])
+		}
+	}
+	if workers <= 1 {
+		copyChunk(0, len(values))
+	} else {
+		chunkSize := (len(values) + workers - 1) / workers
+		var wg sync.WaitGroup
+		for start := 0; start < len(values); start += chunkSize {
+			wg.Add(1)
+			go func(start, end int) {
+				defer wg.Done()
+				copyChunk(start, end)
+			}(start, min(start+chunkSize, len(values)))
+		}
+		wg.Wait()
+	}
+
+	coefficients := make(map[int]*bls12381.Fr, len(values))
+	for i := range copies {
 		// Ensure that only non-zero Coefficients are stored for efficiency.
+		if !copies[i].IsZero() {
+			coefficients[i] = &copies[i]
+		}
+	}
+	return &Polynomial{Coefficients: coefficients}
+}
+
+// NewFromFrOwned works like NewFromFr, but adopts the non-zero values instead of copying them. The caller must own the
+// values, e.g. a fresh DSPF evaluation, and must neither modify nor reuse them as long as the polynomial is used.
+func NewFromFrOwned(values []*bls12381.Fr) *Polynomial {
+	coefficients := make(map[int]*bls12381.Fr, len(values))
+	for i, v := range values {
 		if !v.IsZero() {
-			coefficients[i] = bls12381.NewFr()
-			val := bls12381.NewFr().FromBytes(v.ToBytes()) // DeepCopy coefficient
-			coefficients[i].Set(val)
+			coefficients[i] = v
 		}
 	}
+	return &Polynomial{Coefficients: coefficients}
+}
 
-	return &Polynomial{
-		Coefficients: coefficients,
+// Dense returns the coefficients of the polynomial as a slice of the given length, i.e. the inverse of NewFromFr.
+// The non-zero elements are shared with the polynomial (zero-copy), hence the slice must not be modified as long as
+// the polynomial is used. The zero elements are allocated at once. It returns an error if the degree of the
+// polynomial is not below length.
+func (p *Polynomial) Dense(length int) ([]*bls12381.Fr, error) {
+	if deg, _ := p.Degree(); deg >= length {
+		return nil, fmt.Errorf("polynomial of degree %d does not fit into %d coefficients", deg, length)
 	}
+	values := make([]*bls12381.Fr, length)
+	missing := length
+	for exp, coeff := range p.Coefficients {
+		if exp >= 0 && exp < length {
+			values[exp] = coeff
+			missing--
+		}
+	}
+	zeros := make([]bls12381.Fr, missing)
+	for i := range values {
+		if values[i] == nil {
+			values[i] = &zeros[0]
+			zeros = zeros[1:]
+		}
+	}
+	return values, nil
 }
 
 // NewFromBig converts slice of *big.Int to Polynomial representation.
@@ -113,18 +194,25 @@ func NewFromBig(values []*big.Int) *Polynomial {
 		val := bls12381.NewFr().FromBytes(value.Bytes())
 		rValues[i].Set(val)
 	}
-	return NewFromFr(rValues)
+	return NewFromFrOwned(rValues)
 }
 
 // NewSparse creates a new sparse polynomial with the given Coefficients and their exponents.
 // The index of the coefficient will determine the respective exponent in the exponents slice.
 // E.g. Coefficients = [1, 2, 3], exponents = [0, 1, 2] -> 1*x^0 + 2*x^1 + 3*x^2
+// It returns an ExponentOutOfRangeError if an exponent is negative or exceeds MaxDegree and ErrDuplicateExponent if an
+// exponent is given more than once.
 func NewSparse(coefficients []*bls12381.Fr, exponents []*big.Int) (*Polynomial, error) {
 	if len(coefficients) != len(exponents) {
 		return nil, fmt.Errorf("length of Coefficients and exponents must be equal")
 	}
+	for _, exp := range exponents {
+		if err := checkExponent(exp); err != nil {
+			return nil, err
+		}
+	}
 	if hasDuplicates(exponents) {
-		return nil, fmt.Errorf("exponents must be unique")
+		return nil, ErrDuplicateExponent
 	}
 
 	p := &Polynomial{
@@ -145,8 +233,8 @@ func NewSparse(coefficients []*bls12381.Fr, exponents []*big.Int) (*Polynomial,
 }
 
 // NewRandomPolynomial creates a random polynomial of the given degree.
-// Every coefficient is a random element in Fr, hence the polynomial is most likely not sparse.
-func NewRandomPolynomial(rng *rand.Rand, degree int) (*Polynomial, error) {
+// Every coefficient is a random element in Fr read from rng, hence the polynomial is most likely not sparse.
+func NewRandomPolynomial(rng io.Reader, degree int) (*Polynomial, error) {
 	coefficients := make([]*bls12381.Fr, degree)
 	for i := 0; i < degree; i++ {
 		randElement, err := bls12381.NewFr().Rand(rng)
@@ -156,7 +244,7 @@ func NewRandomPolynomial(rng *rand.Rand, degree int) (*Polynomial, error) {
 		coefficients[i] = bls12381.NewFr()
 		coefficients[i].Set(randElement)
 	}
-	return NewFromFr(coefficients), nil
+	return NewFromFrOwned(coefficients), nil
 }
 
 // NewCyclotomicPolynomial creates a cyclotomic polynomial of the given degree.
@@ -179,21 +267,15 @@ func NewCyclotomicPolynomial(degree *big.Int) (*Polynomial, error) {
 	return poly, nil
 }
 
-// Degree returns the degree of the polynomial.
-// If the polynomial is empty, it returns an error.
-func (p *Polynomial) Degree() (int, error) {
-	deg, found := maxKey(p.Coefficients)
-	if !found {
-		return -1, fmt.Errorf("polynomial is empty")
-	}
-	return deg, nil
-}
-
 // Equal checks if two polynomials are equal.
+// If the digests of both polynomials are cached, only the digests are compared.
 func (p *Polynomial) Equal(q *Polynomial) bool {
 	if len(p.Coefficients) != len(q.Coefficients) { // Quick check
 		return false
 	}
+	if p.digest != nil && q.digest != nil {
+		return *p.digest == *q.digest
+	}
 
 	for exp, coeff := range p.Coefficients {
 		if val, ok := q.Coefficients[exp]; !ok || !val.Equal(coeff) {
@@ -214,6 +296,8 @@ func (p *Polynomial) DeepCopy() *Polynomial {
 		val := bls12381.NewFr().FromBytes(coeff.ToBytes())
 		newPoly.Coefficients[exp] = bls12381.NewFr().Set(val)
 	}
+	newPoly.digest = p.digest // the cached digest is never modified, only replaced
+	newPoly.degree, newPoly.degreeCached = p.degree, p.degreeCached
 
 	return newPoly
 }
@@ -222,6 +306,25 @@ func (p *Polynomial) DeepCopy() *Polynomial {
 // It is not a copy, so be careful when using this function.
 func (p *Polynomial) Set(q *Polynomial) {
 	p.Coefficients = q.Coefficients
+	p.digest = q.digest
+	p.degree, p.degreeCached = q.degree, q.degreeCached
+}
+
+// IsZero reports whether the polynomial is the zero polynomial.
+func (p *Polynomial) IsZero() bool {
+	for _, coeff := range p.Coefficients {
+		if !coeff.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// initCoefficients allocates the coefficients of the zero value &Polynomial{}, s.t. terms can be added to it.
+func (p *Polynomial) initCoefficients() {
+	if p.Coefficients == nil {
+		p.Coefficients = make(map[int]*bls12381.Fr)
+	}
 }
 
 // AmountOfCoefficients returns the number of Coefficients of the polynomial.
@@ -229,28 +332,53 @@ func (p *Polynomial) AmountOfCoefficients() int {
 	return len(p.Coefficients)
 }
 
-// String returns the string representation of the polynomial.
+// SortedExponents returns the exponents of the coefficients of the polynomial in ascending order.
+func (p *Polynomial) SortedExponents() []int {
+	exponents := make([]int, 0, len(p.Coefficients))
+	for exp := range p.Coefficients {
+		exponents = append(exponents, exp)
+	}
+	sort.Ints(exponents)
+	return exponents
+}
+
+// ForEachTerm calls fn for each term of the polynomial in ascending order of the exponents.
+// fn must not modify the polynomial.
+func (p *Polynomial) ForEachTerm(fn func(exp int, coeff *bls12381.Fr)) {
+	for _, exp := range p.SortedExponents() {
+		fn(exp, p.Coefficients[exp])
+	}
+}
+
+// String returns the string representation of the polynomial, starting with the term of the highest exponent.
 func (p *Polynomial) String() string {
-	degree, _ := p.Degree()
-	str := ""
-	for i := degree; i >= 0; i-- {
-		if val, ok := p.Coefficients[i]; ok {
-			str += fmt.Sprintf("%s*x^%d + ", val.ToBig().String(), i)
-		}
+	exponents := p.SortedExponents()
+	if len(exponents) == 0 {
+		return "0"
 	}
-	return str[:len(str)-3] // Remove trailing " + "
+	terms := make([]string, len(exponents))
+	for i, exp := range exponents {
+		terms[len(exponents)-1-i] = fmt.Sprintf("%s*x^%d", p.Coefficients[exp].ToBig().String(), exp)
+	}
+	return strings.Join(terms, " + ")
 }
 
 // Add adds two polynomials and stores the result in the polynomial the function is being called on.
+// Terms that cancel out are removed, i.e. the result may be the empty polynomial (see Sub).
+// Add relies on valid exponents, i.e. polynomials with directly modified Coefficients should be checked via Validate.
 func (p *Polynomial) Add(q *Polynomial) {
+	p.InvalidateDigest()
+	p.initCoefficients()
 	for exp, coeff := range q.Coefficients {
 		if val, ok := p.Coefficients[exp]; ok {
 			val.Add(val, coeff)
 			if val.IsZero() {
 				delete(p.Coefficients, exp)
+				p.termRemoved(exp)
 			}
 		} else {
 			p.Coefficients[exp] = bls12381.NewFr().FromBytes(coeff.ToBytes())
+			p.termAdded(exp)
 		}
 	}
 	return
@@ -263,6 +391,7 @@ func (p *Polynomial) SparseBigAdd(b []*big.Int) error {
 		return fmt.Errorf("length of b must be equal to the number of Coefficients of the polynomial")
 	}
 
+	p.InvalidateDigest()
 	i := 0
 	for _, coeff := range p.Coefficients {
 		valFr := bls12381.NewFr().FromBytes(coeff.ToBytes())
@@ -273,32 +402,137 @@ func (p *Polynomial) SparseBigAdd(b []*big.Int) error {
 }
 
 // Sub subtracts two polynomials and stores the result in the polynomial the function is being called on.
+// Terms that cancel out are removed, s.t. only non-zero coefficients are stored. Hence, subtracting a polynomial from
+// itself yields the zero polynomial, which is Equal to NewEmpty() and has the degree DegreeOfZero.
 func (p *Polynomial) Sub(q *Polynomial) {
+	p.InvalidateDigest()
+	p.initCoefficients()
 	for exp, coeff := range q.Coefficients {
 		if val, ok := p.Coefficients[exp]; ok {
 			val.Sub(val, coeff)
 			if val.IsZero() {
 				delete(p.Coefficients, exp)
+				p.termRemoved(exp)
 			}
 		} else {
 			p.Coefficients[exp] = bls12381.NewFr().FromBytes(coeff.ToBytes()) // DeepCopy coefficient
 			p.Coefficients[exp].Neg(p.Coefficients[exp])
+			p.termAdded(exp)
 		}
 	}
 }
 
-// MulByConstant multiplies the polynomial by a constant.
+// parallelScalingThreshold is the amount of coefficients from which MulByConstant and MulByConstantInto scale them
+// in parallel chunks.
+const parallelScalingThreshold = 1 << 12
+
+// MulByConstant multiplies the polynomial by a constant. Multiplying by one leaves the polynomial untouched and
+// multiplying by zero clears it. The coefficients of dense polynomials are scaled in parallel.
 func (p *Polynomial) MulByConstant(constant *bls12381.Fr) {
+	if constant.IsOne() {
+		return
+	}
+	p.InvalidateDigest()
+	if constant.IsZero() {
+		p.Coefficients = make(map[int]*bls12381.Fr)
+		p.cacheDegree()
+		return
+	}
+	coefficients := make([]*bls12381.Fr, 0, len(p.Coefficients))
 	for _, coeff := range p.Coefficients {
-		coeff.Mul(coeff, constant)
+		coefficients = append(coefficients, coeff)
 	}
+	scaleFr(coefficients, coefficients, constant)
+}
+
+// MulByConstantInto sets dst to the product of the polynomial and the constant and returns dst, which may be p. The
+// coefficients of dst are reused for the exponents both polynomials hold, s.t. scaling polynomials of the same support
+// into a preallocated destination, e.g. per r of the VOLE expansion, allocates no coefficients.
+func (p *Polynomial) MulByConstantInto(dst *Polynomial, constant *bls12381.Fr) *Polynomial {
+	if dst == p {
+		p.MulByConstant(constant)
+		return dst
+	}
+	dst.InvalidateDigest()
+	if dst.Coefficients == nil {
+		dst.Coefficients = make(map[int]*bls12381.Fr, len(p.Coefficients))
+	}
+	if constant.IsZero() {
+		clear(dst.Coefficients)
+		dst.cacheDegree()
+		return dst
+	}
+	for exp := range dst.Coefficients {
+		if _, ok := p.Coefficients[exp]; !ok {
+			delete(dst.Coefficients, exp)
+		}
+	}
+
+	src := make([]*bls12381.Fr, 0, len(p.Coefficients))
+	out := make([]*bls12381.Fr, 0, len(p.Coefficients))
+	var fresh []bls12381.Fr // fresh holds the coefficients of the exponents dst does not hold yet
+	for exp, coeff := range p.Coefficients {
+		target, ok := dst.Coefficients[exp]
+		if !ok {
+			if fresh == nil {
+				fresh = make([]bls12381.Fr, len(p.Coefficients)-len(dst.Coefficients))
+			}
+			target = &fresh[0]
+			fresh = fresh[1:]
+			dst.Coefficients[exp] = target
+		}
+		src = append(src, coeff)
+		out = append(out, target)
+	}
+	if constant.IsOne() {
+		for i := range src {
+			out[i].Set(src[i])
+		}
+	} else {
+		scaleFr(out, src, constant)
+	}
+	dst.degree, dst.degreeCached = p.degree, p.degreeCached
+	return dst
+}
+
+// scaleFr sets dst[i] = src[i] * constant, in parallel chunks for at least parallelScalingThreshold elements.
+func scaleFr(dst, src []*bls12381.Fr, constant *bls12381.Fr) {
+	scale := func(start, end int) {
+		for i := start; i < end; i++ {
+			dst[i].Mul(src[i], constant)
+		}
+	}
+	if len(src) < parallelScalingThreshold {
+		scale(0, len(src))
+		return
+	}
+	workers := runtime.NumCPU()
+	chunkSize := (len(src) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(src); start += chunkSize {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			scale(start, end)
+		}(start, min(start+chunkSize, len(src)))
+	}
+	wg.Wait()
 }
 
 // Mul multiplies two polynomials and stores the result in the polynomial the function is being called on.
 // The function will choose the most efficient method of multiplication depending on the structure of the polynomials.
+// The crossovers between the methods are given by the thresholds of the process (see Thresholds).
+// If either polynomial is zero, the result is the zero polynomial.
 func (p *Polynomial) Mul(q *Polynomial) error {
+	p.InvalidateDigest()
+	if p.IsZero() || q.IsZero() {
+		p.Coefficients = make(map[int]*bls12381.Fr)
+		p.cacheDegree()
+		return nil
+	}
+	thresholds := currentThresholds()
 	maxComplexity := len(p.Coefficients) * len(q.Coefficients)
-	if maxComplexity < 1024 {
+	if maxComplexity < thresholds.NaiveMul {
 		return p.mulNaive(q)
 	}
 
@@ -314,9 +548,23 @@ func (p *Polynomial) Mul(q *Polynomial) error {
 
 	// Calculate the size for FFT, which is the next power of 2 greater than degP + degQ
 	nFFT := nextPowerOf2(degP + degQ + 1)
+	fftComplexity := int(thresholds.FFTWeight * float64(nFFT*log2(nFFT)))
+
+	// Sparse polynomials of high degree (e.g. the outer sums of t-sparse polynomials) consist of few dense segments
+	// separated by large gaps. Multiplying segment-wise avoids a single FFT over the whole (mostly empty) degree range.
+	if 2*len(p.Coefficients) < degP+1 || 2*len(q.Coefficients) < degQ+1 {
+		segmentsP := p.segments(segmentGapThreshold)
+		segmentsQ := q.segments(segmentGapThreshold)
+		if len(segmentsP) > 1 || len(segmentsQ) > 1 {
+			segmentedComplexity := segmentedMulComplexity(segmentsP, segmentsQ)
+			if segmentedComplexity < fftComplexity && segmentedComplexity < maxComplexity {
+				return p.mulSegmentedFFT(segmentsP, segmentsQ)
+			}
+		}
+	}
 
-	// Compare the product of non-zero coefficients with nFFT * log2(nFFT)
-	if maxComplexity > nFFT*log2(nFFT) {
+	// Compare the product of non-zero coefficients with the weighted nFFT * log2(nFFT) (see Thresholds)
+	if maxComplexity > fftComplexity {
 		return p.mulFFT(q)
 	} else {
 		return p.mulNaive(q)
@@ -359,18 +607,36 @@ func (p *Polynomial) GetCoefficient(i int) (*bls12381.Fr, error) {
 }
 
 // Evaluate decides whether to evaluate the polynomial sequentially or in parallel based on the number of coefficients.
-// Both methods use Horner's method.
+// Both methods use Horner's method. Sparse polynomials, for which Horner's method would iterate over mostly
+// non-existing coefficients, are evaluated via evaluateSparse instead.
 func (p *Polynomial) Evaluate(x *bls12381.Fr) *bls12381.Fr {
 	numCoefficients := len(p.Coefficients)
 	if numCoefficients == 0 {
 		return bls12381.NewFr().Zero()
 	}
-	if numCoefficients < 1024 {
+	degree, _ := maxKey(p.Coefficients)
+	if numCoefficients*log2(nextPowerOf2(degree+1)) < degree+1 {
+		return p.evaluateSparse(x)
+	}
+	if numCoefficients < currentThresholds().ParallelEvaluation {
 		return p.evaluateSequential(x)
 	}
 	return p.evaluateParallel(x)
 }
 
+// EvaluateDense evaluates the polynomial with the given dense coefficient vector at x via Horner's method, i.e. without
+// constructing the polynomial.
+func EvaluateDense(coefficients []*bls12381.Fr, x *bls12381.Fr) *bls12381.Fr {
+	result := bls12381.NewFr().Zero()
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		if coefficients[i] != nil {
+			result.Add(result, coefficients[i])
+		}
+	}
+	return result
+}
+
 // evaluateNaive evaluates the polynomial at a given value of x with naive method.
 // only used for benchmarking.
 func (p *Polynomial) evaluateNaive(x *bls12381.Fr) *bls12381.Fr {
@@ -403,28 +669,63 @@ func (p *Polynomial) evaluateSequential(x *bls12381.Fr) *bls12381.Fr {
 	return result
 }
 
+// evaluateSparse evaluates the polynomial at a given value of x as the sum of coeff*x^exp.
+// The powers of x are computed incrementally in ascending order of the exponents, where each step multiplies the
+// shared powers x^(2^i) given by the binary representation of the gap to the previous exponent.
+// This takes O(t*log(degree)) multiplications for a t-sparse polynomial instead of O(degree).
+func (p *Polynomial) evaluateSparse(x *bls12381.Fr) *bls12381.Fr {
+	degree, _ := maxKey(p.Coefficients)
+	return p.evaluateSparseWith(powersOfTwo(x, degree))
+}
+
+// evaluateSparseWith works like evaluateSparse, but with the given powers x^(2^i) up to at least the degree.
+func (p *Polynomial) evaluateSparseWith(squares []*bls12381.Fr) *bls12381.Fr {
+	exponents := p.SortedExponents()
+
+	result := bls12381.NewFr().Zero()
+	xPow := bls12381.NewFr().One() // x^prev
+	prev := 0
+	tmp := bls12381.NewFr()
+	for _, exp := range exponents {
+		for gap, i := exp-prev, 0; gap > 0; gap, i = gap>>1, i+1 {
+			if gap&1 == 1 {
+				xPow.Mul(xPow, squares[i])
+			}
+		}
+		prev = exp
+
+		tmp.Mul(xPow, p.Coefficients[exp])
+		result.Add(result, tmp)
+	}
+
+	return result
+}
+
 // evaluateParallel evaluates the polynomial at a given value of x in parallel.
 func (p *Polynomial) evaluateParallel(x *bls12381.Fr) *bls12381.Fr {
-	numCoefficients := len(p.Coefficients)
+	degree, _ := maxKey(p.Coefficients)
+	return p.evaluateChunks(NewEvaluationPoint(x, degree))
+}
 
-	numCores := runtime.NumCPU()
-	chunkSize := (numCoefficients + numCores - 1) / numCores
+// evaluateChunks evaluates the polynomial in parallel, where each goroutine evaluates a chunk of the coefficients via
+// Horner's method and the chunks are combined with the powers of the point. The degree must not exceed the degree
+// bound of the point.
+func (p *Polynomial) evaluateChunks(point *EvaluationPoint) *bls12381.Fr {
+	degree, _ := maxKey(p.Coefficients)
 
 	var wg sync.WaitGroup
-	results := make([]*bls12381.Fr, numCores)
-	xPowers := precomputeXPowers(x, chunkSize, numCores) // TODO: Optimization Idea: We could cache this for multiple evaluations...
-
-	for i := 0; i < numCores; i++ {
-		start := i * chunkSize
-		end := start + chunkSize
-		if end > numCoefficients {
-			end = numCoefficients
+	results := make([]*bls12381.Fr, len(point.chunkPowers))
+	for i := range results {
+		start := i * point.chunkSize
+		end := min(start+point.chunkSize, degree+1)
+		if start >= end {
+			break // the remaining chunks exceed the degree
 		}
 
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			results[i] = parallelEvaluateChunk(p, x, start, end)
+			results[i] = parallelEvaluateChunk(p, point.x, start, end)
 		}(i)
 	}
 
@@ -432,20 +733,89 @@ func (p *Polynomial) evaluateParallel(x *bls12381.Fr) *bls12381.Fr {
 
 	// Combine results
 	finalResult := bls12381.NewFr().Zero()
-	for i := 0; i < numCores; i++ {
-		temp := bls12381.NewFr()
-		temp.Mul(results[i], xPowers[i])
+	temp := bls12381.NewFr()
+	for i, result := range results {
+		if result == nil {
+			break
+		}
+		temp.Mul(result, point.chunkPowers[i])
 		finalResult.Add(finalResult, temp)
 	}
 
 	return finalResult
 }
 
+// sparseDivisorThreshold is the maximal amount of terms of a divisor, for which Mod uses modSparse.
+const sparseDivisorThreshold = 16
+
 // Mod returns the remainder of the polynomial divided by another polynomial.
+// Divisors with few terms (e.g. x^m + 1) are reduced via modSparse, all others via modNaive.
+// The remainder of the zero polynomial is zero, and dividing by the zero polynomial returns ErrDivisionByZero.
 func (p *Polynomial) Mod(divisor *Polynomial) (*Polynomial, error) {
+	if divisor.IsZero() {
+		return nil, ErrDivisionByZero
+	}
+	if len(divisor.Coefficients) <= sparseDivisorThreshold {
+		return p.modSparse(divisor)
+	}
 	return p.modNaive(divisor)
 }
 
+// modSparse returns the remainder of the polynomial divided by another polynomial.
+// Each reduction step subtracts the shifted and scaled divisor directly from the remainder, s.t. no intermediate
+// polynomials are allocated. A step costs O(terms of the divisor), which suits sparse divisors.
+func (p *Polynomial) modSparse(divisor *Polynomial) (*Polynomial, error) {
+	divisorDegree, err := divisor.Degree()
+	if err != nil {
+		return nil, err
+	}
+	currentRemDeg, err := p.Degree()
+	if err != nil {
+		return nil, err
+	}
+	// Quick check if the degree of the divisor is greater than the dividend
+	if divisorDegree > currentRemDeg {
+		return p.DeepCopy(), nil
+	}
+
+	inv := bls12381.NewFr()
+	inv.Inverse(divisor.Coefficients[divisorDegree])
+	lowerTerms := make(map[int]*bls12381.Fr, len(divisor.Coefficients)-1) // the terms of the divisor except the leading term
+	for exp, coeff := range divisor.Coefficients {
+		if exp != divisorDegree && !coeff.IsZero() {
+			lowerTerms[exp] = coeff
+		}
+	}
+
+	remainder := p.DeepCopy()
+	remainder.cacheDegree() // the degree of the remainder is queried after each reduction step
+	remainder.InvalidateDigest()
+	product := bls12381.NewFr()
+	for currentRemDeg >= divisorDegree {
+		shift := currentRemDeg - divisorDegree
+		leadingTermCoefficient := bls12381.NewFr()
+		leadingTermCoefficient.Mul(remainder.Coefficients[currentRemDeg], inv)
+
+		// The leading term cancels out by construction
+		delete(remainder.Coefficients, currentRemDeg)
+		remainder.termRemoved(currentRemDeg)
+		for exp, coeff := range lowerTerms {
+			if leadingTermCoefficient.IsZero() {
+				break
+			}
+			product.Mul(leadingTermCoefficient, coeff)
+			remainder.subTerm(exp+shift, product)
+		}
+
+		currentRemDeg, err = remainder.Degree() // DegreeOfZero if the remainder is zero, which ends the reduction
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return remainder, nil
+}
+
 // modNaive returns the remainder of the polynomial divided by another polynomial.
 // This is the naive method of modulo using polynomial division.
 func (p *Polynomial) modNaive(divisor *Polynomial) (*Polynomial, error) {
@@ -462,12 +832,12 @@ func (p *Polynomial) modNaive(divisor *Polynomial) (*Polynomial, error) {
 		return p.DeepCopy(), nil
 	}
 
+	inv := bls12381.NewFr() // the inverse of the leading coefficient of the divisor is invariant
+	inv.Inverse(divisor.Coefficients[divisorDegree])
 	remainder := p.DeepCopy()
+	remainder.cacheDegree() // the degree of the remainder is queried after each reduction step
 	for currentRemDeg >= divisorDegree {
 		leadingTermExponent := currentRemDeg - divisorDegree
-
-		inv := bls12381.NewFr()
-		inv.Inverse(divisor.Coefficients[divisorDegree])
 		leadingTermCoefficient := bls12381.NewFr()
 		leadingTermCoefficient.Mul(remainder.Coefficients[currentRemDeg], inv)
 
@@ -496,7 +866,7 @@ func (p *Polynomial) isCyclotomic() bool {
 	if err != nil {
 		return false
 	}
-	if degree == 0 {
+	if degree <= 0 { // constants and the zero polynomial
 		return false
 	}
 
@@ -542,45 +912,205 @@ func (p *Polynomial) mulNaive(q *Polynomial) error {
 			}
 		}
 	}
+	for exp, coeff := range resultCoeffs { // Terms may cancel out
+		if coeff.IsZero() {
+			delete(resultCoeffs, exp)
+		}
+	}
 	p.Coefficients = resultCoeffs
+	p.cacheDegree()
 	return nil
 }
 
 // mulFFT multiplies two polynomials using the FFT  in O(nlogn).
 // note that this can be faster for polynomials with a very large number of Coefficients.
 func (p *Polynomial) mulFFT(q *Polynomial) error {
-	coeffsP := polyAsCoefficientsBigInt(p)
-	coeffsQ := polyAsCoefficientsBigInt(q)
-	coeffsP, coeffsQ = extendSliceWithZeros(coeffsP, coeffsQ)
+	degreeP, _ := p.Degree()
+	degreeQ, _ := q.Degree()
+	coeffsP, err := p.Dense(degreeP + 1)
+	if err != nil {
+		return err
+	}
+	coeffsQ, err := q.Dense(degreeQ + 1)
+	if err != nil {
+		return err
+	}
 
-	n := math.Ceil(math.Log2(float64(len(coeffsP))))
-	fft, err := NewBLS12381FFT(int(n))
+	fft, err := NewBLS12381FFT(log2(nextPowerOf2(max(len(coeffsP), len(coeffsQ)))))
 	if err != nil {
 		return err
 	}
-	resultBig, err := fft.MulPolysFFT(coeffsP, coeffsQ)
+	product, err := fft.MulPolysFFTFr(coeffsP, coeffsQ)
 	if err != nil {
 		return err
 	}
 
-	p.Coefficients = NewFromBig(resultBig).Coefficients
+	p.Coefficients = NewFromFrOwned(product).Coefficients
+	p.cacheDegree()
+	return nil
+}
+
+// mulSegmentedFFT multiplies two polynomials given by their dense segments.
+// Each pair of segments is multiplied either naively or via FFT (whatever is cheaper) and the partial products are
+// added at the offset given by the start of both segments.
+func (p *Polynomial) mulSegmentedFFT(segmentsP, segmentsQ []polySegment) error {
+	result := NewEmpty()
+	for _, sp := range segmentsP {
+		for _, sq := range segmentsQ {
+			offset := sp.start + sq.start
+			if sp.amountNonZero*sq.amountNonZero <= sp.fftComplexity(sq) {
+				for i, coeffP := range sp.coefficients {
+					if coeffP.Sign() == 0 {
+						continue
+					}
+					for j, coeffQ := range sq.coefficients {
+						if coeffQ.Sign() == 0 {
+							continue
+						}
+						product := bls12381.NewFr().FromBytes(coeffP.Bytes())
+						product.Mul(product, bls12381.NewFr().FromBytes(coeffQ.Bytes()))
+						result.addCoefficient(offset+i+j, product)
+					}
+				}
+				continue
+			}
+
+			product, err := mulDenseFFT(sp.coefficients, sq.coefficients)
+			if err != nil {
+				return err
+			}
+			for k, coeff := range product {
+				if coeff.Sign() != 0 {
+					result.addCoefficient(offset+k, bls12381.NewFr().FromBytes(coeff.Bytes()))
+				}
+			}
+		}
+	}
+
+	p.Coefficients = result.Coefficients
+	p.cacheDegree()
 	return nil
 }
 
+// mulDenseFFT multiplies two polynomials given as dense coefficient slices via FFT.
+func mulDenseFFT(coeffsP, coeffsQ []*big.Int) ([]*big.Int, error) {
+	coeffsP, coeffsQ = extendSliceWithZeros(coeffsP, coeffsQ)
+
+	n := math.Ceil(math.Log2(float64(len(coeffsP))))
+	fft, err := NewBLS12381FFT(int(n))
+	if err != nil {
+		return nil, err
+	}
+	return fft.MulPolysFFT(coeffsP, coeffsQ)
+}
+
+// addCoefficient adds coeff to the coefficient of x^exp.
+func (p *Polynomial) addCoefficient(exp int, coeff *bls12381.Fr) {
+	p.InvalidateDigest()
+	p.initCoefficients()
+	if val, ok := p.Coefficients[exp]; ok {
+		val.Add(val, coeff)
+		if val.IsZero() {
+			delete(p.Coefficients, exp)
+			p.termRemoved(exp)
+		}
+	} else {
+		p.Coefficients[exp] = coeff
+		p.termAdded(exp)
+	}
+}
+
+// subTerm subtracts coeff*x^exp from the polynomial. Coefficients that become zero are removed.
+// coeff is copied, s.t. the caller may reuse it.
+func (p *Polynomial) subTerm(exp int, coeff *bls12381.Fr) {
+	p.initCoefficients()
+	if val, ok := p.Coefficients[exp]; ok {
+		val.Sub(val, coeff)
+		if val.IsZero() {
+			delete(p.Coefficients, exp)
+			p.termRemoved(exp)
+		}
+	} else {
+		val = bls12381.NewFr()
+		val.Neg(coeff)
+		p.Coefficients[exp] = val
+		p.termAdded(exp)
+	}
+}
+
+// segmentGapThreshold is the minimal amount of consecutive zero coefficients that splits a polynomial into segments.
+// For polynomials of degree < 2**8, naive multiplication is generally faster, so smaller gaps are not worth splitting.
+const segmentGapThreshold = 256
+
+// polySegment is a dense part of a sparse polynomial.
+type polySegment struct {
+	start         int        // start is the exponent of the first coefficient of the segment
+	coefficients  []*big.Int // coefficients holds the dense coefficients of the segment, the index being the exponent relative to start
+	amountNonZero int        // amountNonZero is the number of non-zero coefficients in the segment
+}
+
+// fftComplexity estimates the cost of multiplying two segments via FFT.
+func (s polySegment) fftComplexity(q polySegment) int {
+	nFFT := nextPowerOf2(len(s.coefficients) + len(q.coefficients) - 1)
+	return nFFT * log2(nFFT)
+}
+
+// segments splits the polynomial into dense segments, s.t. consecutive segments are separated by at least gap zero coefficients.
+func (p *Polynomial) segments(gap int) []polySegment {
+	exponents := p.SortedExponents()
+
+	segments := make([]polySegment, 0)
+	for i := 0; i < len(exponents); {
+		j := i + 1
+		for j < len(exponents) && exponents[j]-exponents[j-1] <= gap {
+			j++
+		}
+
+		start := exponents[i]
+		coefficients := make([]*big.Int, exponents[j-1]-start+1)
+		for k := range coefficients {
+			coefficients[k] = big.NewInt(0)
+		}
+		for _, exp := range exponents[i:j] {
+			coefficients[exp-start] = p.Coefficients[exp].ToBig()
+		}
+		segments = append(segments, polySegment{start, coefficients, j - i})
+		i = j
+	}
+	return segments
+}
+
+// segmentedMulComplexity estimates the cost of multiplying two polynomials segment-wise.
+func segmentedMulComplexity(segmentsP, segmentsQ []polySegment) int {
+	complexity := 0
+	for _, sp := range segmentsP {
+		for _, sq := range segmentsQ {
+			naive := sp.amountNonZero * sq.amountNonZero
+			fft := sp.fftComplexity(sq)
+			if naive < fft {
+				complexity += naive
+			} else {
+				complexity += fft
+			}
+		}
+	}
+	return complexity
+}
+
 // polyAsCoefficientsBigInt returns the Coefficients of the polynomial in the form of a slice.
 // The index of the element will be its exponent.
 // Zero Coefficients are also represented s.t. the full polynomial is represented by the slice.
 func polyAsCoefficientsBigInt(p *Polynomial) []*big.Int {
 	degree, _ := p.Degree()
 	coefficients := make([]*big.Int, degree+1)
-	for i := 0; i < degree+1; i++ {
-		val, ok := p.Coefficients[i]
-		if ok {
-			coefficients[i] = val.ToBig()
-		} else {
-			coefficients[i] = big.NewInt(0)
+	next := 0 // next exponent without coefficient
+	p.ForEachTerm(func(exp int, coeff *bls12381.Fr) {
+		for ; next < exp; next++ {
+			coefficients[next] = big.NewInt(0)
 		}
-	}
+		coefficients[exp] = coeff.ToBig()
+		next = exp + 1
+	})
 
 	return coefficients
 }
@@ -16,6 +16,34 @@ func TestNewPoly(t *testing.T) {
 	assert.Equal(t, len(slice), len(poly.Coefficients))
 }
 
+func TestNewFromFrParallel(t *testing.T) {
+	slice := randomFrSlice(parallelConstructionThreshold + 3)
+	slice[5] = bls12381.NewFr().Zero()
+	expected := NewFromFrParallel(slice, 1)
+	assert.Equal(t, len(slice)-1, len(expected.Coefficients))
+	for _, workers := range []int{0, 3, 8, len(slice) + 1} {
+		assert.True(t, expected.Equal(NewFromFrParallel(slice, workers)))
+	}
+	assert.True(t, expected.Equal(NewFromFr(slice)))
+
+	// The values are copied
+	poly := NewFromFr(slice)
+	slice[0].Double(slice[0])
+	assert.False(t, poly.Coefficients[0].Equal(slice[0]))
+}
+
+func TestNewFromFrOwned(t *testing.T) {
+	slice := randomFrSlice(100)
+	slice[7] = bls12381.NewFr().Zero()
+	poly := NewFromFrOwned(slice)
+	assert.True(t, poly.Equal(NewFromFr(slice)))
+
+	// The values are adopted
+	assert.True(t, poly.Coefficients[3] == slice[3])
+	_, ok := poly.Coefficients[7]
+	assert.False(t, ok)
+}
+
 func TestSerialize(t *testing.T) {
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 	poly, err := NewRandomPolynomial(rng, 512)
@@ -36,6 +64,51 @@ func TestSerialize(t *testing.T) {
 	assert.NotNil(t, fromBytes)
 	assert.True(t, poly.Equal(fromBytes))
 
+	// The serialization is deterministic
+	for i := 0; i < 5; i++ {
+		again, err := fromBytes.DeepCopy().Serialize()
+		assert.Nil(t, err)
+		assert.Equal(t, serializedBytes, again)
+	}
+}
+
+func TestDeserializeRejectsNonCanonical(t *testing.T) {
+	poly := NewFromFr([]*bls12381.Fr{bls12381.NewFr().One()})
+	serialized, err := poly.Serialize()
+	assert.Nil(t, err)
+
+	// The coefficient q must not decode to the non-canonical limbs of q
+	q, _ := new(big.Int).SetString(FrModulus, 16)
+	nonCanonical := append([]byte(nil), serialized...)
+	q.FillBytes(nonCanonical[len(nonCanonical)-32:])
+	assert.NotNil(t, NewEmpty().Deserialize(nonCanonical))
+
+	// A truncated coefficient
+	assert.NotNil(t, NewEmpty().Deserialize(serialized[:len(serialized)-1]))
+}
+
+func TestSortedIteration(t *testing.T) {
+	exponents := []*big.Int{big.NewInt(700), big.NewInt(3), big.NewInt(42), big.NewInt(0)}
+	poly, err := NewSparse(randomFrSlice(4), exponents)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 3, 42, 700}, poly.SortedExponents())
+
+	var visited []int
+	poly.ForEachTerm(func(exp int, coeff *bls12381.Fr) {
+		visited = append(visited, exp)
+		assert.True(t, poly.Coefficients[exp].Equal(coeff))
+	})
+	assert.Equal(t, []int{0, 3, 42, 700}, visited)
+
+	assert.Empty(t, NewEmpty().SortedExponents())
+	assert.Equal(t, "0", NewEmpty().String())
+}
+
+func TestString(t *testing.T) {
+	one := bls12381.NewFr().One()
+	poly, err := NewSparse([]*bls12381.Fr{one, one, one}, []*big.Int{big.NewInt(0), big.NewInt(5), big.NewInt(2)})
+	assert.Nil(t, err)
+	assert.Equal(t, "1*x^5 + 1*x^2 + 1*x^0", poly.String())
 }
 
 func TestNewSparsePoly(t *testing.T) {
@@ -91,6 +164,60 @@ func TestDegree(t *testing.T) {
 	assert.Equal(t, n-1, deg)
 }
 
+func TestZeroPolynomial(t *testing.T) {
+	p := NewFromFr(randomFrSlice(64))
+	x := randomFrSlice(1)[0]
+	for _, zero := range []*Polynomial{NewEmpty(), {}, NewFromFr(make([]*bls12381.Fr, 0))} {
+		assert.True(t, zero.IsZero())
+		deg, err := zero.Degree()
+		assert.Nil(t, err)
+		assert.Equal(t, DegreeOfZero, deg)
+		assert.True(t, zero.Evaluate(x).IsZero())
+		assert.True(t, zero.Equal(NewEmpty()))
+
+		product, err := Mul(p, zero)
+		assert.Nil(t, err)
+		assert.True(t, product.IsZero())
+		product, err = Mul(zero, p)
+		assert.Nil(t, err)
+		assert.True(t, product.IsZero())
+
+		remainder, err := zero.Mod(p)
+		assert.Nil(t, err)
+		assert.True(t, remainder.IsZero())
+		_, err = p.Mod(zero)
+		assert.ErrorIs(t, err, ErrDivisionByZero)
+
+		data, err := zero.Serialize()
+		assert.Nil(t, err)
+		deserialized, err := NewFromSerialization(data)
+		assert.Nil(t, err)
+		assert.True(t, deserialized.IsZero())
+	}
+
+	// The zero value can be used as a zero polynomial
+	var sum Polynomial
+	sum.Add(p)
+	assert.True(t, sum.Equal(p))
+	var diff Polynomial
+	diff.Sub(p)
+	diff.Add(p)
+	assert.True(t, diff.IsZero())
+
+	// Terms that cancel out in a product are removed, e.g. (x+1)(x-1) = x^2-1
+	one := bls12381.NewFr().One()
+	minusOne := bls12381.NewFr()
+	minusOne.Neg(one)
+	product, err := Mul(NewFromFr([]*bls12381.Fr{one, one}), NewFromFr([]*bls12381.Fr{minusOne, one}))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, product.AmountOfCoefficients())
+
+	// A divisor that divides the dividend yields a zero remainder
+	remainder, err := product.modNaive(NewFromFr([]*bls12381.Fr{one, one}))
+	assert.Nil(t, err)
+	assert.True(t, remainder.IsZero())
+}
+
 func TestAddPolys(t *testing.T) {
 	n := 512
 	slice1 := randomFrSlice(n)
@@ -239,6 +366,35 @@ func TestEvaluateLarge(t *testing.T) {
 
 	resultd := poly.evaluateParallel(x)
 	assert.True(t, resulta.Equal(resultd))
+
+	coefficients := randomFrSlice(2048)
+	coefficients[7] = bls12381.NewFr().Zero()
+	expected := NewFromFr(coefficients).evaluateSequential(x)
+	coefficients[7] = nil // nil coefficients are zero
+	assert.True(t, expected.Equal(EvaluateDense(coefficients, x)))
+	assert.True(t, EvaluateDense(nil, x).IsZero())
+}
+
+func TestEvaluateSparse(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	x, err := bls12381.NewFr().Rand(rng)
+	assert.Nil(t, err)
+
+	// t-sparse polynomial of high degree
+	poly := randomSparsePoly(16, 1<<20)
+	expected := poly.evaluateNaive(x)
+	assert.True(t, expected.Equal(poly.evaluateSparse(x)))
+	assert.True(t, expected.Equal(poly.Evaluate(x)))
+
+	// Sparse evaluation also holds for dense polynomials and constants
+	dense := NewFromFr(randomFrSlice(300))
+	assert.True(t, dense.evaluateSequential(x).Equal(dense.evaluateSparse(x)))
+	constant := NewFromFr(randomFrSlice(1))
+	assert.True(t, constant.Coefficients[0].Equal(constant.evaluateSparse(x)))
+
+	// Sparse polynomials with many coefficients
+	poly = randomSparsePoly(2048, 1<<18)
+	assert.True(t, poly.evaluateNaive(x).Equal(poly.Evaluate(x)))
 }
 
 func TestSeparateMul(t *testing.T) {
@@ -337,6 +493,46 @@ func TestMulPolySparseEqual(t *testing.T) {
 	assert.True(t, acopy1.Equal(acopy2))
 }
 
+func TestMulPolySegmentedEqual(t *testing.T) {
+	polyA := randomClusteredPoly(4, 128, 1<<16)
+	polyB := randomClusteredPoly(3, 200, 1<<15)
+
+	expected := polyA.DeepCopy()
+	err := expected.mulNaive(polyB)
+	assert.Nil(t, err)
+
+	segmentsA := polyA.segments(segmentGapThreshold)
+	segmentsB := polyB.segments(segmentGapThreshold)
+	assert.Equal(t, 4, len(segmentsA))
+	assert.Equal(t, 3, len(segmentsB))
+
+	result := polyA.DeepCopy()
+	err = result.mulSegmentedFFT(segmentsA, segmentsB)
+	assert.Nil(t, err)
+	assert.True(t, expected.Equal(result))
+
+	// The dispatcher should pick the segmented multiplication for this shape and yield the same result.
+	result, err = Mul(polyA, polyB)
+	assert.Nil(t, err)
+	assert.True(t, expected.Equal(result))
+}
+
+func TestSegments(t *testing.T) {
+	exponents := []*big.Int{big.NewInt(0), big.NewInt(3), big.NewInt(1000), big.NewInt(1001), big.NewInt(5000)}
+	p, err := NewSparse(randomFrSlice(len(exponents)), exponents)
+	assert.Nil(t, err)
+
+	segments := p.segments(segmentGapThreshold)
+	assert.Equal(t, 3, len(segments))
+	assert.Equal(t, 0, segments[0].start)
+	assert.Equal(t, 4, len(segments[0].coefficients))
+	assert.Equal(t, 2, segments[0].amountNonZero)
+	assert.Equal(t, 1000, segments[1].start)
+	assert.Equal(t, 2, len(segments[1].coefficients))
+	assert.Equal(t, 5000, segments[2].start)
+	assert.Equal(t, 1, segments[2].amountNonZero)
+}
+
 func TestNewRandomPolynomial(t *testing.T) {
 	l := 1024
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -373,6 +569,71 @@ func TestMulPolyByConstant(t *testing.T) {
 	assert.True(t, expectedPoly.Equal(poly))
 }
 
+func TestMulPolyByConstantSpecialCases(t *testing.T) {
+	slice := randomFrSlice(64)
+	poly := NewFromFr(slice)
+
+	poly.MulByConstant(bls12381.NewFr().One())
+	assert.True(t, NewFromFr(slice).Equal(poly))
+
+	poly.MulByConstant(bls12381.NewFr().Zero())
+	assert.Equal(t, 0, len(poly.Coefficients))
+	degree, _ := poly.Degree()
+	assert.Equal(t, DegreeOfZero, degree)
+}
+
+func TestMulPolyByConstantParallel(t *testing.T) {
+	n := parallelScalingThreshold + 5
+	slice := randomFrSlice(n)
+	poly := NewFromFr(slice)
+
+	constant := bls12381.NewFr()
+	constant.FromBytes(big.NewInt(42).Bytes())
+
+	expected := make([]*bls12381.Fr, n)
+	for i := 0; i < n; i++ {
+		expected[i] = bls12381.NewFr()
+		expected[i].Mul(slice[i], constant)
+	}
+
+	poly.MulByConstant(constant)
+	assert.True(t, NewFromFr(expected).Equal(poly))
+}
+
+func TestMulPolyByConstantInto(t *testing.T) {
+	slice := randomFrSlice(128)
+	poly := NewFromFr(slice)
+	original := poly.DeepCopy()
+
+	constant := bls12381.NewFr()
+	constant.FromBytes(big.NewInt(7).Bytes())
+	expected := poly.DeepCopy()
+	expected.MulByConstant(constant)
+
+	// A fresh destination
+	dst := poly.MulByConstantInto(NewEmpty(), constant)
+	assert.True(t, expected.Equal(dst))
+	assert.True(t, original.Equal(poly))
+
+	// A destination of another support reuses the coefficients of shared exponents and drops the others
+	dst = NewFromFr(randomFrSlice(256))
+	reused := dst.Coefficients[3]
+	assert.Same(t, dst, poly.MulByConstantInto(dst, constant))
+	assert.True(t, expected.Equal(dst))
+	assert.Same(t, reused, dst.Coefficients[3])
+	degree, _ := dst.Degree()
+	assert.Equal(t, 127, degree)
+
+	// One copies and zero clears
+	assert.True(t, original.Equal(poly.MulByConstantInto(dst, bls12381.NewFr().One())))
+	assert.Equal(t, 0, len(poly.MulByConstantInto(dst, bls12381.NewFr().Zero()).Coefficients))
+	assert.True(t, original.Equal(poly))
+
+	// The polynomial itself as destination
+	assert.Same(t, poly, poly.MulByConstantInto(poly, constant))
+	assert.True(t, expected.Equal(poly))
+}
+
 func TestMod(t *testing.T) {
 	// Test polynomial a: 2x^2 + 2x + 1
 	aValues := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(2)}
@@ -411,6 +672,14 @@ func TestModLarge(t *testing.T) {
 	assert.True(t, deg < degB)
 }
 
+func BenchmarkNewFromFrN16(b *testing.B) {
+	slice := randomFrSlice(1 << 16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewFromFr(slice)
+	}
+}
+
 func BenchmarkMulNaiveN10(b *testing.B) { benchmarkMulNaive(b, 1024) }
 func BenchmarkMulNaiveN11(b *testing.B) { benchmarkMulNaive(b, 2048) }
 func BenchmarkMulNaiveN12(b *testing.B) { benchmarkMulNaive(b, 4096) }
@@ -459,6 +728,12 @@ func BenchmarkEvaluateHornerSeqN18(b *testing.B) { benchmarkEvaluationHornerSeq(
 func BenchmarkEvaluateHornerSeqN19(b *testing.B) { benchmarkEvaluationHornerSeq(b, 524288) }
 func BenchmarkEvaluateHornerSeqN20(b *testing.B) { benchmarkEvaluationHornerSeq(b, 1048576) }
 
+func BenchmarkEvaluateSparseD1048576T16(b *testing.B)   { benchmarkEvaluationSparse(b, 1048576, 16) }
+func BenchmarkEvaluateSparseD1048576T1024(b *testing.B) { benchmarkEvaluationSparse(b, 1048576, 1024) }
+func BenchmarkEvaluateHornerSeqD1048576T16(b *testing.B) {
+	benchmarkEvaluationHornerSeqSparse(b, 1048576, 16)
+}
+
 func BenchmarkEvaluateHornerParN10(b *testing.B) { benchmarkEvaluationHornerParallel(b, 1024) }
 func BenchmarkEvaluateHornerParN11(b *testing.B) { benchmarkEvaluationHornerParallel(b, 2048) }
 func BenchmarkEvaluateHornerParN12(b *testing.B) { benchmarkEvaluationHornerParallel(b, 4096) }
@@ -495,6 +770,9 @@ func BenchmarkMulSparseFFTD32768T2048(t *testing.B) { benchmarkMulSparseFFT(t, 3
 func BenchmarkMulSparseFFTD32768T2560(t *testing.B) { benchmarkMulSparseFFT(t, 32768, 2560) }
 func BenchmarkMulSparseFFTD32768T3072(t *testing.B) { benchmarkMulSparseFFT(t, 32768, 3072) }
 
+func BenchmarkMulClusteredNaiveC8W512(b *testing.B)     { benchmarkMulClustered(b, 8, 512, false) }
+func BenchmarkMulClusteredSegmentedC8W512(b *testing.B) { benchmarkMulClustered(b, 8, 512, true) }
+
 func BenchmarkMulSparseNaiveD262144T16(t *testing.B)   { benchmarkMulSparseNaive(t, 262144, 16) }
 func BenchmarkMulSparseNaiveD262144T128(t *testing.B)  { benchmarkMulSparseNaive(t, 262144, 128) }
 func BenchmarkMulSparseNaiveD262144T256(t *testing.B)  { benchmarkMulSparseNaive(t, 262144, 256) }
@@ -619,6 +897,36 @@ func benchmarkEvaluationHornerSeq(b *testing.B, n int) {
 	}
 }
 
+func benchmarkEvaluationSparse(b *testing.B, degree, sparseness int) {
+	poly1 := randomSparsePoly(sparseness, degree)
+
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	point, err := bls12381.NewFr().Rand(rng)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		poly1.evaluateSparse(point)
+	}
+}
+
+func benchmarkEvaluationHornerSeqSparse(b *testing.B, degree, sparseness int) {
+	poly1 := randomSparsePoly(sparseness, degree)
+
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	point, err := bls12381.NewFr().Rand(rng)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		poly1.evaluateSequential(point)
+	}
+}
+
 func benchmarkEvaluationNaive(b *testing.B, n int) {
 	slice1 := randomFrSlice(n)
 	poly1 := NewFromFr(slice1)
@@ -681,3 +989,121 @@ func randomSparsePoly(sparseness, maxDegree int) *Polynomial {
 	poly, _ := NewSparse(coefficients, exponents)
 	return poly
 }
+
+func benchmarkMulClustered(b *testing.B, clusters, width int, segmented bool) {
+	poly1 := randomClusteredPoly(clusters, width, 1<<18)
+	poly2 := randomClusteredPoly(clusters, width, 1<<18)
+	segments1 := poly1.segments(segmentGapThreshold)
+	segments2 := poly2.segments(segmentGapThreshold)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		p := poly1.DeepCopy()
+		b.StartTimer()
+		var err error
+		if segmented {
+			err = p.mulSegmentedFFT(segments1, segments2)
+		} else {
+			err = p.mulNaive(poly2)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// randomClusteredPoly generates a polynomial of dense clusters of the given width spread evenly over [0, maxDegree].
+func randomClusteredPoly(clusters, width, maxDegree int) *Polynomial {
+	p := NewEmpty()
+	coefficients := randomFrSlice(clusters * width)
+	for c := 0; c < clusters; c++ {
+		start := c * (maxDegree / clusters)
+		for i := 0; i < width; i++ {
+			p.Coefficients[start+i] = coefficients[c*width+i]
+		}
+	}
+	return p
+}
+
+func TestModSparseMatchesModNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	dividend, err := NewRandomPolynomial(rng, 600)
+	assert.Nil(t, err)
+
+	cyclotomic, err := NewCyclotomicPolynomial(big.NewInt(128))
+	assert.Nil(t, err)
+	sparse, err := NewSparse(randomFrSlice(4), []*big.Int{big.NewInt(0), big.NewInt(7), big.NewInt(50), big.NewInt(201)})
+	assert.Nil(t, err)
+	dense, err := NewRandomPolynomial(rng, 8)
+	assert.Nil(t, err)
+
+	for _, divisor := range []*Polynomial{cyclotomic, sparse, dense} {
+		expected, err := dividend.modNaive(divisor)
+		assert.Nil(t, err)
+		remainder, err := dividend.modSparse(divisor)
+		assert.Nil(t, err)
+		assert.True(t, expected.Equal(remainder))
+		exponents := expected.SortedExponents()
+		assertDegree(t, exponents[len(exponents)-1], remainder)
+	}
+
+	// Multiples of the divisor reduce to zero
+	multiple, err := Mul(sparse, dividend)
+	assert.Nil(t, err)
+	remainder, err := multiple.Mod(sparse)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, remainder.AmountOfCoefficients())
+}
+
+func BenchmarkModNaiveCyclotomic(b *testing.B)  { benchmarkMod(b, false) }
+func BenchmarkModSparseCyclotomic(b *testing.B) { benchmarkMod(b, true) }
+
+func benchmarkMod(b *testing.B, sparse bool) {
+	rng := rand.New(rand.NewSource(42))
+	dividend, err := NewRandomPolynomial(rng, 2*4096)
+	if err != nil {
+		b.Fatal(err)
+	}
+	divisor, err := NewCyclotomicPolynomial(big.NewInt(4096))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if sparse {
+			_, err = dividend.modSparse(divisor)
+		} else {
+			_, err = dividend.modNaive(divisor)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDense(t *testing.T) {
+	values := randomFrSlice(8)
+	values[3] = bls12381.NewFr().Zero()
+	p := NewFromFr(values)
+
+	dense, err := p.Dense(16)
+	assert.Nil(t, err)
+	assert.Len(t, dense, 16)
+	for i, v := range dense {
+		if i < 8 {
+			assert.True(t, v.Equal(values[i]))
+		} else {
+			assert.True(t, v.IsZero())
+		}
+	}
+	// Non-zero elements are shared with the polynomial
+	assert.Same(t, p.Coefficients[0], dense[0])
+	assert.True(t, NewFromFr(dense).Equal(p))
+
+	_, err = p.Dense(7)
+	assert.NotNil(t, err)
+	empty, err := NewEmpty().Dense(4)
+	assert.Nil(t, err)
+	assert.Len(t, empty, 4)
+}
@@ -0,0 +1,145 @@
+package poly
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// The FFT of BLS12-381 supports domains of size 2^minRootOfUnityOrder up to 2^maxRootOfUnityOrder.
+const (
+	minRootOfUnityOrder = 8
+	maxRootOfUnityOrder = 26
+)
+
+// MaxFFTOrder bounds the products computed via FFT to 2^MaxFFTOrder coefficients.
+const MaxFFTOrder = maxRootOfUnityOrder
+
+// frTwoAdicity is the largest s, s.t. 2^s divides FrModulus - 1.
+const frTwoAdicity = 32
+
+// frRootsOfUnity maps n to the hardcoded primitive 2^n-th root of unity of Fr.
+var frRootsOfUnity = map[int]string{
+	8:  frN8thRootOfUnity,
+	9:  frN9thRootOfUnity,
+	10: frN10thRootOfUnity,
+	11: frN11thRootOfUnity,
+	12: frN12thRootOfUnity,
+	13: frN13thRootOfUnity,
+	14: frN14thRootOfUnity,
+	15: frN15thRootOfUnity,
+	16: frN16thRootOfUnity,
+	17: frN17thRootOfUnity,
+	18: frN18thRootOfUnity,
+	19: frN19thRootOfUnity,
+	20: frN20thRootOfUnity,
+	21: frN21thRootOfUnity,
+	22: frN22thRootOfUnity,
+	23: frN23thRootOfUnity,
+	24: frN24thRootOfUnity,
+	25: frN25thRootOfUnity,
+	26: frN26thRootOfUnity,
+}
+
+// rootChecks holds the result of the self-test of each hardcoded root of unity, s.t. it runs once per FFT size.
+var rootChecks [maxRootOfUnityOrder + 1]struct {
+	once sync.Once
+	root *big.Int
+	err  error
+}
+
+// checkedRootOfUnity returns the hardcoded primitive 2^n-th root of unity, which is verified on first use.
+// A typo in a constant would otherwise yield silently wrong products for polynomials of a specific size only.
+func checkedRootOfUnity(n int) (*big.Int, error) {
+	if _, ok := frRootsOfUnity[n]; !ok {
+		return nil, fmt.Errorf("no root of unity of order 2^%d available", n)
+	}
+	check := &rootChecks[n]
+	check.once.Do(func() {
+		check.root, check.err = parseRootOfUnity(n)
+	})
+	if check.err != nil {
+		return nil, check.err
+	}
+	return new(big.Int).Set(check.root), nil
+}
+
+// parseRootOfUnity parses and verifies the hardcoded primitive 2^n-th root of unity.
+func parseRootOfUnity(n int) (*big.Int, error) {
+	root, ok := new(big.Int).SetString(frRootsOfUnity[n], 10)
+	if !ok {
+		return nil, fmt.Errorf("root of unity of order 2^%d is not a decimal number", n)
+	}
+	if err := VerifyRootOfUnity(root, frModulus(), n); err != nil {
+		return nil, fmt.Errorf("hardcoded root of unity of order 2^%d is invalid: %w", n, err)
+	}
+	return root, nil
+}
+
+// VerifyConstants verifies that all hardcoded roots of unity of the FFT are primitive roots of their order.
+func VerifyConstants() error {
+	for n := minRootOfUnityOrder; n <= maxRootOfUnityOrder; n++ {
+		if _, err := parseRootOfUnity(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyRootOfUnity verifies that root is a primitive 2^n-th root of unity modulo the (prime) modulus,
+// i.e. that root^(2^n) = 1 and root^(2^(n-1)) = -1.
+func VerifyRootOfUnity(root, modulus *big.Int, n int) error {
+	if n < 1 {
+		return fmt.Errorf("n must be at least 1 but is %d", n)
+	}
+	if root.Sign() <= 0 || root.Cmp(modulus) >= 0 {
+		return fmt.Errorf("root of unity must be within [1, modulus)")
+	}
+	half := new(big.Int).Exp(root, new(big.Int).Lsh(ONE, uint(n-1)), modulus)
+	minusOne := new(big.Int).Sub(modulus, ONE)
+	if half.Cmp(minusOne) != 0 {
+		return fmt.Errorf("root^(2^%d) != -1", n-1)
+	}
+	// Squaring -1 yields 1, hence root^(2^n) = 1 follows. We still check it explicitly for clarity.
+	if new(big.Int).Exp(half, TWO, modulus).Cmp(ONE) != 0 {
+		return fmt.Errorf("root^(2^%d) != 1", n)
+	}
+	return nil
+}
+
+// GenerateRootOfUnity computes a primitive 2^n-th root of unity of Fr as g^((q-1)/2^n), where g is
+// FrPrimitiveRootOfUnity and q is FrModulus. The result is valid, but need not equal the hardcoded constant.
+func GenerateRootOfUnity(n int) (*big.Int, error) {
+	if n < 1 || n > frTwoAdicity {
+		return nil, fmt.Errorf("n must be between 1 and %d (inclusive)", frTwoAdicity)
+	}
+	modulus := frModulus()
+	generator, _ := new(big.Int).SetString(FrPrimitiveRootOfUnity, 10)
+	exp := new(big.Int).Rsh(new(big.Int).Sub(modulus, ONE), uint(n))
+	root := new(big.Int).Exp(generator, exp, modulus)
+	if err := VerifyRootOfUnity(root, modulus, n); err != nil {
+		return nil, fmt.Errorf("generated root of unity of order 2^%d is invalid: %w", n, err)
+	}
+	return root, nil
+}
+
+// GenerateRootOfUnityTable recomputes the table of roots of unity from FrPrimitiveRootOfUnity and returns it as
+// Go constant declarations, which may replace the hardcoded constants.
+func GenerateRootOfUnityTable() (string, error) {
+	var sb strings.Builder
+	for n := minRootOfUnityOrder; n <= maxRootOfUnityOrder; n++ {
+		root, err := GenerateRootOfUnity(n)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "const frN%dthRootOfUnity = \"%s\"\n", n, root.String())
+	}
+	return sb.String(), nil
+}
+
+// frModulus returns FrModulus as big.Int.
+func frModulus() *big.Int {
+	modulus, _ := new(big.Int).SetString(FrModulus, 16)
+	return modulus
+}
@@ -0,0 +1,76 @@
+package poly
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyConstants(t *testing.T) {
+	assert.Nil(t, VerifyConstants())
+	for n := minRootOfUnityOrder; n <= maxRootOfUnityOrder; n++ {
+		root, err := checkedRootOfUnity(n)
+		assert.Nil(t, err)
+		assert.Equal(t, frRootsOfUnity[n], root.String())
+	}
+	_, err := checkedRootOfUnity(maxRootOfUnityOrder + 1)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyRootOfUnity(t *testing.T) {
+	modulus := frModulus()
+	root, _ := new(big.Int).SetString(frN10thRootOfUnity, 10)
+	assert.Nil(t, VerifyRootOfUnity(root, modulus, 10))
+
+	// A typo in the constant is detected
+	typo := new(big.Int).Add(root, ONE)
+	assert.NotNil(t, VerifyRootOfUnity(typo, modulus, 10))
+
+	// A root of a smaller order is not primitive
+	square := new(big.Int).Exp(root, TWO, modulus)
+	assert.NotNil(t, VerifyRootOfUnity(square, modulus, 10))
+	assert.Nil(t, VerifyRootOfUnity(square, modulus, 9))
+
+	// A root of a larger order does not satisfy root^(2^n) = 1
+	assert.NotNil(t, VerifyRootOfUnity(root, modulus, 9))
+
+	assert.NotNil(t, VerifyRootOfUnity(big.NewInt(0), modulus, 10))
+	assert.NotNil(t, VerifyRootOfUnity(modulus, modulus, 10))
+	assert.NotNil(t, VerifyRootOfUnity(root, modulus, 0))
+}
+
+func TestGenerateRootOfUnity(t *testing.T) {
+	for n := 1; n <= frTwoAdicity; n++ {
+		root, err := GenerateRootOfUnity(n)
+		assert.Nil(t, err)
+		assert.Nil(t, VerifyRootOfUnity(root, frModulus(), n))
+	}
+	_, err := GenerateRootOfUnity(0)
+	assert.NotNil(t, err)
+	_, err = GenerateRootOfUnity(frTwoAdicity + 1)
+	assert.NotNil(t, err)
+
+	table, err := GenerateRootOfUnityTable()
+	assert.Nil(t, err)
+	assert.Contains(t, table, "const frN8thRootOfUnity = ")
+	assert.Contains(t, table, "const frN26thRootOfUnity = ")
+}
+
+// TestGeneratedRootsMatchFFT checks that random products via the hardcoded and the generated roots of unity coincide.
+func TestGeneratedRootsMatchFFT(t *testing.T) {
+	for n := minRootOfUnityOrder; n <= 10; n++ {
+		hardcoded, err := NewBLS12381FFT(n - 1)
+		assert.Nil(t, err)
+		root, err := GenerateRootOfUnity(n)
+		assert.Nil(t, err)
+		generated := &FFT{modulus: frModulus(), rootOfUnity: root, n: n}
+
+		a := polyAsCoefficientsBigInt(NewFromFr(randomFrSlice(1 << (n - 1))))
+		b := polyAsCoefficientsBigInt(NewFromFr(randomFrSlice(1 << (n - 1))))
+		expected, err := hardcoded.MulPolysFFT(a, b)
+		assert.Nil(t, err)
+		actual, err := generated.MulPolysFFT(a, b)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, actual)
+	}
+}
@@ -0,0 +1,213 @@
+package poly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Thresholds holds the crossovers at which the polynomial arithmetic switches between its algorithms. The defaults
+// (see DefaultThresholds) were tuned for a single machine, Calibrate measures them on the host instead.
+type Thresholds struct {
+	// NaiveMul is the product of the amounts of coefficients below which Mul always multiplies naively.
+	NaiveMul int `json:"naive_mul"`
+	// FFTWeight weights the complexity nFFT*log2(nFFT) of an FFT multiplication, when Mul compares it with the
+	// complexity of the naive multiplication, i.e. the product of the amounts of coefficients.
+	FFTWeight float64 `json:"fft_weight"`
+	// ParallelEvaluation is the amount of coefficients from which Evaluate evaluates a dense polynomial in parallel.
+	ParallelEvaluation int `json:"parallel_evaluation"`
+	// CPUs is the amount of CPUs of the host the thresholds were calibrated on, 0 for the defaults.
+	CPUs int `json:"cpus"`
+}
+
+// DefaultThresholds returns the thresholds used unless others are set (see SetThresholds or Calibrate).
+func DefaultThresholds() Thresholds {
+	return Thresholds{NaiveMul: 1024, FFTWeight: 1, ParallelEvaluation: 1024}
+}
+
+// thresholds holds the thresholds of the process, nil for the defaults.
+var thresholds atomic.Pointer[Thresholds]
+
+// CurrentThresholds returns the thresholds currently used by the polynomial arithmetic.
+func CurrentThresholds() Thresholds {
+	return *currentThresholds()
+}
+
+// SetThresholds sets the thresholds of the process. The thresholds apply to all subsequent operations, including
+// operations of other goroutines.
+func SetThresholds(t Thresholds) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	thresholds.Store(&t)
+	return nil
+}
+
+// ResetThresholds restores the default thresholds.
+func ResetThresholds() {
+	thresholds.Store(nil)
+}
+
+func currentThresholds() *Thresholds {
+	if t := thresholds.Load(); t != nil {
+		return t
+	}
+	defaults := DefaultThresholds()
+	return &defaults
+}
+
+func (t Thresholds) validate() error {
+	if t.NaiveMul < 0 || t.ParallelEvaluation < 0 {
+		return fmt.Errorf("thresholds must not be negative")
+	}
+	if !(t.FFTWeight > 0) {
+		return fmt.Errorf("the FFT weight must be positive")
+	}
+	return nil
+}
+
+// Sizes measured by Calibrate. They stay small, s.t. the calibration takes at most a few seconds.
+var (
+	calibrationMulSizes  = []int{8, 16, 32, 64, 128, 256}
+	calibrationEvalSizes = []int{1 << 8, 1 << 9, 1 << 10, 1 << 11, 1 << 12, 1 << 13, 1 << 14}
+)
+
+// calibrationRuns is the amount of runs per measurement, of which the median is taken.
+const calibrationRuns = 5
+
+// Calibrate measures the crossovers of the algorithms on the host and sets them as thresholds of the process (see
+// SetThresholds). Crossovers beyond the measured sizes fall back to the largest measured size.
+func Calibrate() (Thresholds, error) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	t := Thresholds{CPUs: runtime.NumCPU()}
+
+	// Naive vs. FFT multiplication of dense polynomials
+	t.NaiveMul = -1
+	for _, size := range calibrationMulSizes {
+		p, err := NewRandomPolynomial(rng, size)
+		if err != nil {
+			return Thresholds{}, err
+		}
+		q, err := NewRandomPolynomial(rng, size)
+		if err != nil {
+			return Thresholds{}, err
+		}
+		naive, err := measure(func() error { return p.DeepCopy().mulNaive(q) })
+		if err != nil {
+			return Thresholds{}, err
+		}
+		fft, err := measure(func() error { return p.DeepCopy().mulFFT(q) })
+		if err != nil {
+			return Thresholds{}, err
+		}
+
+		// The weight is the ratio of the costs per unit of complexity, measured at the largest size
+		nFFT := nextPowerOf2(2*size - 1)
+		t.FFTWeight = (float64(fft) / float64(nFFT*log2(nFFT))) / (float64(naive) / float64(size*size))
+		if t.NaiveMul < 0 && fft < naive {
+			t.NaiveMul = size * size
+		}
+	}
+	if t.NaiveMul < 0 {
+		largest := calibrationMulSizes[len(calibrationMulSizes)-1]
+		t.NaiveMul = largest * largest
+	}
+
+	// Sequential vs. parallel evaluation of dense polynomials
+	t.ParallelEvaluation = -1
+	for _, size := range calibrationEvalSizes {
+		p, err := NewRandomPolynomial(rng, size)
+		if err != nil {
+			return Thresholds{}, err
+		}
+		x := p.Coefficients[0]
+		sequential, err := measure(func() error { p.evaluateSequential(x); return nil })
+		if err != nil {
+			return Thresholds{}, err
+		}
+		parallel, err := measure(func() error { p.evaluateParallel(x); return nil })
+		if err != nil {
+			return Thresholds{}, err
+		}
+		if parallel < sequential {
+			t.ParallelEvaluation = size
+			break
+		}
+	}
+	if t.ParallelEvaluation < 0 {
+		t.ParallelEvaluation = calibrationEvalSizes[len(calibrationEvalSizes)-1]
+	}
+
+	if err := SetThresholds(t); err != nil {
+		return Thresholds{}, err
+	}
+	return t, nil
+}
+
+// LoadOrCalibrate sets the thresholds persisted at path, if they were calibrated on a host with the same amount of
+// CPUs. Otherwise, it calibrates the thresholds (see Calibrate) and persists them at path.
+// If the thresholds can neither be loaded nor calibrated, the current thresholds are kept and an error is returned.
+func LoadOrCalibrate(path string) (Thresholds, error) {
+	t, err := LoadThresholds(path)
+	if err == nil && t.CPUs == runtime.NumCPU() {
+		if err := SetThresholds(t); err != nil {
+			return Thresholds{}, err
+		}
+		return t, nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Thresholds{}, err
+	}
+
+	t, err = Calibrate()
+	if err != nil {
+		return Thresholds{}, err
+	}
+	return t, SaveThresholds(path, t)
+}
+
+// LoadThresholds reads thresholds persisted by SaveThresholds. It does not set them.
+func LoadThresholds(path string) (Thresholds, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Thresholds{}, err
+	}
+	var t Thresholds
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Thresholds{}, fmt.Errorf("failed to decode the thresholds at %s: %w", path, err)
+	}
+	if err := t.validate(); err != nil {
+		return Thresholds{}, fmt.Errorf("invalid thresholds at %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// SaveThresholds persists the thresholds at path as JSON.
+func SaveThresholds(path string, t Thresholds) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// measure returns the median duration of calibrationRuns runs of fn.
+func measure(fn func() error) (time.Duration, error) {
+	durations := make([]time.Duration, calibrationRuns)
+	for i := range durations {
+		start := time.Now()
+		if err := fn(); err != nil {
+			return 0, err
+		}
+		durations[i] = time.Since(start)
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return max(durations[calibrationRuns/2], 1), nil
+}
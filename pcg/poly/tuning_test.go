@@ -0,0 +1,68 @@
+package poly
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestThresholdsDefaults(t *testing.T) {
+	ResetThresholds()
+	assert.Equal(t, DefaultThresholds(), CurrentThresholds())
+
+	assert.NotNil(t, SetThresholds(Thresholds{NaiveMul: -1, FFTWeight: 1}))
+	assert.NotNil(t, SetThresholds(Thresholds{NaiveMul: 1024}))
+	assert.Equal(t, DefaultThresholds(), CurrentThresholds())
+}
+
+func TestMulIndependentOfThresholds(t *testing.T) {
+	defer ResetThresholds()
+	rng := rand.New(rand.NewSource(1))
+	p, err := NewRandomPolynomial(rng, 100)
+	assert.Nil(t, err)
+	q, err := NewRandomPolynomial(rng, 70)
+	assert.Nil(t, err)
+	x := p.Coefficients[1]
+
+	expected, err := Mul(p, q)
+	assert.Nil(t, err)
+	for _, thresholds := range []Thresholds{
+		{NaiveMul: 0, FFTWeight: 1e-6, ParallelEvaluation: 0},            // always FFT, always parallel
+		{NaiveMul: 1 << 30, FFTWeight: 1e6, ParallelEvaluation: 1 << 30}, // always naive, always sequential
+	} {
+		assert.Nil(t, SetThresholds(thresholds))
+		product, err := Mul(p, q)
+		assert.Nil(t, err)
+		assert.True(t, expected.Equal(product))
+		assert.True(t, expected.Evaluate(x).Equal(product.Evaluate(x)))
+	}
+}
+
+func TestLoadOrCalibrate(t *testing.T) {
+	defer ResetThresholds()
+	path := filepath.Join(t.TempDir(), "thresholds.json")
+
+	calibrated, err := LoadOrCalibrate(path)
+	assert.Nil(t, err)
+	assert.Equal(t, calibrated, CurrentThresholds())
+	assert.Equal(t, runtime.NumCPU(), calibrated.CPUs)
+	assert.True(t, calibrated.FFTWeight > 0)
+
+	// The persisted thresholds are loaded instead of calibrated again
+	persisted := Thresholds{NaiveMul: 7, FFTWeight: 2, ParallelEvaluation: 9, CPUs: runtime.NumCPU()}
+	assert.Nil(t, SaveThresholds(path, persisted))
+	ResetThresholds()
+	loaded, err := LoadOrCalibrate(path)
+	assert.Nil(t, err)
+	assert.Equal(t, persisted, loaded)
+	assert.Equal(t, persisted, CurrentThresholds())
+
+	// Invalid files keep the current thresholds
+	assert.Nil(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = LoadOrCalibrate(path)
+	assert.NotNil(t, err)
+	assert.Equal(t, persisted, CurrentThresholds())
+}
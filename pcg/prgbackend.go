@@ -0,0 +1,37 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+)
+
+// UsePRGBackend switches the base DPFs of the PCG to the given PRG backend, e.g. to compare the backends in
+// experiments. A nil backend selects the default dpf.AESPRG. As DPF keys do not record their backend, seeds must be
+// evaluated by a PCG using the same backend as the PCG that generated them.
+// It must be called before DistributeEval, while regular noise and the hardened mode may be enabled before or after.
+func (p *PCG) UsePRGBackend(prg dpf.PRGBackend) error {
+	if p.regularNoise {
+		p.prg = prg
+		return p.UseRegularNoise()
+	}
+	if _, ok := p.dspfN.(*dspf.DSPF); !ok {
+		return fmt.Errorf("the PRG backend must be set before the evaluation is distributed")
+	}
+	p.prg = prg
+	dspfN, dspf2N, err := p.newDSPFs()
+	if err != nil {
+		return err
+	}
+	p.dspfN = dspfN
+	p.dspf2N = dspf2N
+	return nil
+}
+
+// PRGBackend returns the PRG backend of the base DPFs of the PCG (see UsePRGBackend).
+func (p *PCG) PRGBackend() dpf.PRGBackend {
+	if p.prg == nil {
+		return dpf.AESPRG{}
+	}
+	return p.prg
+}
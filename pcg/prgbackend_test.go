@@ -0,0 +1,62 @@
+package pcg
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUsePRGBackend(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, dpf.PRGNameAES, pcg.PRGBackend().Name())
+	name := pcg.FixtureName()
+	assert.Nil(t, pcg.UsePRGBackend(dpf.HashPRG{}))
+	assert.Equal(t, dpf.PRGNameSHA512, pcg.PRGBackend().Name())
+	assert.NotEqual(t, name, pcg.FixtureName())
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	result, err := pcg.SimulateAllParties(seeds, randPolys, ring, 0)
+	assert.Nil(t, err)
+	assert.True(t, result.Correct())
+
+	// The hardened mode keeps the backend
+	assert.Nil(t, pcg.UseHardenedMode())
+	result, err = pcg.SimulateAllParties(seeds, randPolys, ring, 0)
+	assert.Nil(t, err)
+	assert.True(t, result.Correct())
+}
+
+func TestUsePRGBackendRegularNoise(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	assert.Nil(t, pcg.UseRegularNoise())
+	assert.Nil(t, pcg.UsePRGBackend(dpf.HashPRG{}))
+	assert.True(t, pcg.RegularNoise())
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	result, err := pcg.SimulateAllParties(seeds, randPolys, ring, 0)
+	assert.Nil(t, err)
+	assert.True(t, result.Correct())
+}
+
+func TestUsePRGBackendAfterDistributeEval(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	worker, err := pcg.NewLocalWorker()
+	assert.Nil(t, err)
+	assert.Nil(t, pcg.DistributeEval([]dspf.Worker{worker}, 1))
+	assert.NotNil(t, pcg.UsePRGBackend(dpf.HashPRG{}))
+	assert.Equal(t, dpf.PRGNameAES, pcg.PRGBackend().Name())
+}
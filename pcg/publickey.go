@@ -0,0 +1,61 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/sharing"
+)
+
+// PublicKeyShare returns the public key share w_i = g2^ski of the party holding the seed.
+func (s *Seed) PublicKeyShare() *bls12381.PointG2 {
+	g2 := bls12381.NewG2()
+	pk := g2.New()
+	g2.MulScalar(pk, g2.One(), s.ski)
+	return pk
+}
+
+// AggregatePublicKey returns the BBS+ public key w = g2^sk for the n-out-of-n setting from the public key shares
+// of all parties. As EvalCombined treats sk as the sum of all shares ski, w is the sum of all w_i.
+func AggregatePublicKey(pkShares []*bls12381.PointG2) (*bls12381.PointG2, error) {
+	if len(pkShares) == 0 {
+		return nil, fmt.Errorf("no public key shares given")
+	}
+
+	g2 := bls12381.NewG2()
+	pk := g2.Zero()
+	for i, pkShare := range pkShares {
+		if pkShare == nil {
+			return nil, fmt.Errorf("public key share %d must not be nil", i)
+		}
+		g2.Add(pk, pk, pkShare)
+	}
+	return pk, nil
+}
+
+// AggregatePublicKeyThreshold returns the BBS+ public key w = g2^sk for the tau-out-of-n setting from the public key
+// shares of the parties with the given (shamir share) indices by interpolating in the exponent.
+func AggregatePublicKeyThreshold(pkShares []*bls12381.PointG2, indices []int) (*bls12381.PointG2, error) {
+	if len(pkShares) == 0 {
+		return nil, fmt.Errorf("no public key shares given")
+	}
+	if len(pkShares) != len(indices) {
+		return nil, fmt.Errorf("amount of public key shares is %d but amount of indices is %d", len(pkShares), len(indices))
+	}
+
+	lambdas, err := sharing.LagrangeCoefficientsAtZero(indices)
+	if err != nil {
+		return nil, err
+	}
+
+	g2 := bls12381.NewG2()
+	pk := g2.Zero()
+	tmp := g2.New()
+	for i, pkShare := range pkShares {
+		if pkShare == nil {
+			return nil, fmt.Errorf("public key share %d must not be nil", i)
+		}
+		g2.MulScalar(tmp, pkShare, lambdas[i])
+		g2.Add(pk, pk, tmp)
+	}
+	return pk, nil
+}
@@ -0,0 +1,66 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/sharing"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestAggregatePublicKey(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+
+	sk := bls12381.NewFr()
+	pkShares := make([]*bls12381.PointG2, len(seeds))
+	for i, seed := range seeds {
+		sk.Add(sk, seed.ski)
+		pkShares[i] = seed.PublicKeyShare()
+	}
+
+	pk, err := AggregatePublicKey(pkShares)
+	assert.Nil(t, err)
+
+	g2 := bls12381.NewG2()
+	expected := g2.New()
+	g2.MulScalar(expected, g2.One(), sk)
+	assert.True(t, g2.Equal(expected, pk))
+
+	_, err = AggregatePublicKey(nil)
+	assert.NotNil(t, err)
+}
+
+func TestAggregatePublicKeyThreshold(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	secret, shares, err := sharing.Share(rng, nil, 3, 5)
+	assert.Nil(t, err)
+
+	g2 := bls12381.NewG2()
+	expected := g2.New()
+	g2.MulScalar(expected, g2.One(), secret)
+
+	for _, signerSet := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 2, 3, 4}} {
+		pkShares := make([]*bls12381.PointG2, len(signerSet))
+		for i, signer := range signerSet {
+			pkShares[i] = (&Seed{ski: shares[signer]}).PublicKeyShare()
+		}
+		pk, err := AggregatePublicKeyThreshold(pkShares, signerSet)
+		assert.Nil(t, err)
+		assert.True(t, g2.Equal(expected, pk))
+	}
+
+	// Less than t shares do not reconstruct the key
+	pkShares := []*bls12381.PointG2{(&Seed{ski: shares[0]}).PublicKeyShare(), (&Seed{ski: shares[1]}).PublicKeyShare()}
+	pk, err := AggregatePublicKeyThreshold(pkShares, []int{0, 1})
+	assert.Nil(t, err)
+	assert.False(t, g2.Equal(expected, pk))
+
+	_, err = AggregatePublicKeyThreshold(pkShares, []int{0, 0})
+	assert.NotNil(t, err)
+	_, err = AggregatePublicKeyThreshold(pkShares, []int{0})
+	assert.NotNil(t, err)
+}
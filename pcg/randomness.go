@@ -0,0 +1,185 @@
+package pcg
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"sync"
+)
+
+// MasterSeedSize is the size of the master seed of the randomness of the PCG (see Reseed).
+const MasterSeedSize = 32
+
+// Domains of the randomness of the PCG. Each domain is an independent stream derived from the master seed, s.t. the
+// randomness of a component is reproducible given the master seed, regardless of the other components.
+const (
+	domainSkSharing         = "sk sharing"
+	domainExponents         = "exponents"
+	domainCoefficients      = "coefficients"
+	domainDummyPoints       = "dummy points"
+	domainRandomPolynomials = "random polynomials"
+	domainRingRoots         = "ring roots"
+)
+
+// entropySource is the source of the master seeds of NewPCG and ReseedFromEntropy.
+var entropySource io.Reader = crand.Reader
+
+// randomness derives the labeled domains of the randomness of the PCG from a master seed. The stream of each domain is
+// AES-256-CTR keyed by HMAC-SHA256(master seed, label).
+type randomness struct {
+	mu      sync.Mutex               // mu guards master and domains
+	master  [MasterSeedSize]byte     // master is the master seed
+	domains map[string]*domainStream // domains holds the streams of the domains, which are created on first use
+}
+
+// newRandomness returns the randomness of the given master seed.
+func newRandomness(master [MasterSeedSize]byte) *randomness {
+	return &randomness{master: master, domains: make(map[string]*domainStream)}
+}
+
+// domain returns the stream of the domain with the given label. Successive calls continue the same stream.
+// The stream is safe for concurrent use, but the order in which concurrent samplers consume it is not deterministic.
+func (r *randomness) domain(label string) *domainStream {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stream, ok := r.domains[label]; ok {
+		return stream
+	}
+
+	mac := hmac.New(sha256.New, r.master[:])
+	mac.Write([]byte(label))
+	block, _ := aes.NewCipher(mac.Sum(nil)) // the key is 32 bytes long, hence this does not fail
+	stream := &domainStream{rng: rand.New(&streamSource{stream: cipher.NewCTR(block, make([]byte, aes.BlockSize))})}
+	r.domains[label] = stream
+	return stream
+}
+
+// domainStream is the stream of a domain. Each read is serialized by mu, as rand.Rand is not safe for concurrent use.
+type domainStream struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// Read fills b with the next bytes of the stream. It never returns an error.
+func (s *domainStream) Read(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.Read(b)
+}
+
+// Int returns a uniform random integer in [0, max) (see big.Int.Rand).
+func (s *domainStream) Int(max *big.Int) *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return new(big.Int).Rand(s.rng, max)
+}
+
+// streamSource is a rand.Source64 over the key stream of a stream cipher.
+type streamSource struct {
+	stream cipher.Stream
+	buf    [8]byte
+}
+
+func (s *streamSource) Uint64() uint64 {
+	s.buf = [8]byte{}
+	s.stream.XORKeyStream(s.buf[:], s.buf[:])
+	return binary.BigEndian.Uint64(s.buf[:])
+}
+
+func (s *streamSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Seed is not supported, as the source is keyed via the master seed (see PCG.Reseed).
+func (s *streamSource) Seed(int64) {
+	panic("streamSource cannot be seeded")
+}
+
+// Reseed replaces the master seed of the randomness of the PCG, e.g. to reproduce the seeds of TrustedSeedGen and the
+// random polynomials of PickRandomPolynomials. The master seed must hold at least MasterSeedSize bytes of entropy and
+// is hashed via SHA-256. The DPF keys are sampled from crypto/rand regardless of the master seed.
+func (p *PCG) Reseed(masterSeed []byte) error {
+	if len(masterSeed) < MasterSeedSize {
+		return fmt.Errorf("master seed must be at least %d bytes long but is %d bytes long", MasterSeedSize, len(masterSeed))
+	}
+	p.rng = newRandomness(sha256.Sum256(masterSeed))
+	return nil
+}
+
+// ReseedFromEntropy replaces the master seed of the randomness of the PCG by a fresh one from crypto/rand, which must
+// pass the health tests of CheckEntropy.
+func (p *PCG) ReseedFromEntropy() error {
+	master, err := sampleMasterSeed()
+	if err != nil {
+		return err
+	}
+	p.rng = newRandomness(master)
+	return nil
+}
+
+// CheckEntropy runs the health tests of SP 800-90B (repetition count and adaptive proportion test) on a sample of the
+// entropy source of the master seeds, i.e. crypto/rand. It detects a broken source, e.g. one returning constant data.
+func (p *PCG) CheckEntropy() error {
+	return checkEntropy(entropySource)
+}
+
+// sampleMasterSeed samples a master seed from the entropy source after checking its health.
+func sampleMasterSeed() ([MasterSeedSize]byte, error) {
+	var master [MasterSeedSize]byte
+	if err := checkEntropy(entropySource); err != nil {
+		return master, err
+	}
+	if _, err := io.ReadFull(entropySource, master[:]); err != nil {
+		return master, fmt.Errorf("failed to read the master seed: %w", err)
+	}
+	return master, nil
+}
+
+// Parameters of the health tests of checkEntropy for a source claiming full entropy (8 bits per byte) and a false
+// positive probability of 2^-40 (see SP 800-90B, section 4.4).
+const (
+	entropySampleSize = 1024 // entropySampleSize is the amount of bytes tested
+	repetitionCutoff  = 6    // repetitionCutoff is 1 + ceil(40/8)
+	proportionWindow  = 512  // proportionWindow is the window size of the adaptive proportion test
+	proportionCutoff  = 13   // proportionCutoff is the cutoff of the adaptive proportion test for the window size
+)
+
+// checkEntropy runs the repetition count and adaptive proportion test on a sample of the source.
+func checkEntropy(source io.Reader) error {
+	sample := make([]byte, entropySampleSize)
+	if _, err := io.ReadFull(source, sample); err != nil {
+		return fmt.Errorf("failed to read from the entropy source: %w", err)
+	}
+
+	repetitions := 1
+	for k := 1; k < len(sample); k++ {
+		if sample[k] != sample[k-1] {
+			repetitions = 1
+			continue
+		}
+		repetitions++
+		if repetitions >= repetitionCutoff {
+			return fmt.Errorf("entropy source failed the repetition count test at byte %d", k)
+		}
+	}
+
+	for start := 0; start+proportionWindow <= len(sample); start += proportionWindow {
+		count := 0
+		for _, b := range sample[start : start+proportionWindow] {
+			if b == sample[start] {
+				count++
+			}
+		}
+		if count >= proportionCutoff {
+			return fmt.Errorf("entropy source failed the adaptive proportion test at byte %d", start)
+		}
+	}
+	return nil
+}
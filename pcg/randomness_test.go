@@ -0,0 +1,93 @@
+package pcg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestReseed(t *testing.T) {
+	master := bytes.Repeat([]byte{7}, MasterSeedSize)
+	pcgA, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	pcgB, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	assert.Nil(t, pcgA.Reseed(master))
+	assert.Nil(t, pcgB.Reseed(master))
+
+	// The domains are independent, i.e. the exponents do not depend on the preceding sampling of coefficients
+	pcgB.sampleCoefficients()
+	expA, expB := pcgA.sampleExponents(), pcgB.sampleExponents()
+	assert.True(t, expA.Party(1).Equal(expB.Party(1)))
+	coeffA := pcgA.sampleCoefficients()
+	assert.False(t, coeffA.Party(0).Equal(pcgB.sampleCoefficients().Party(0)))
+
+	randA, err := pcgA.PickRandomPolynomials()
+	assert.Nil(t, err)
+	randB, err := pcgB.PickRandomPolynomials()
+	assert.Nil(t, err)
+	for i := range randA {
+		assert.True(t, randA[i].Equal(randB[i]))
+	}
+
+	// Reseeding restarts the domains
+	assert.Nil(t, pcgB.Reseed(master))
+	assert.True(t, coeffA.Party(0).Equal(pcgB.sampleCoefficients().Party(0)))
+
+	assert.NotNil(t, pcgA.Reseed(master[:MasterSeedSize-1]))
+	assert.Nil(t, pcgA.ReseedFromEntropy())
+	assert.False(t, expA.Party(1).Equal(pcgA.sampleExponents().Party(1)))
+}
+
+func TestDomainConcurrentSampling(t *testing.T) {
+	master := bytes.Repeat([]byte{7}, MasterSeedSize)
+	sequential := newRandomness(sha256.Sum256(master))
+	concurrent := newRandomness(sha256.Sum256(master))
+	bound := big.NewInt(1000003)
+
+	// Concurrent samplers consume the same stream as a sequential one, only the assignment of the samples differs
+	const workers, samples = 8, 64
+	expected := make([]int64, workers*samples)
+	for k := range expected {
+		expected[k] = sequential.domain(domainRingRoots).Int(bound).Int64()
+	}
+	actual := make([]int64, workers*samples)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for k := 0; k < samples; k++ {
+				actual[w*samples+k] = concurrent.domain(domainRingRoots).Int(bound).Int64()
+			}
+		}(w)
+	}
+	wg.Wait()
+	slices.Sort(expected)
+	slices.Sort(actual)
+	assert.Equal(t, expected, actual)
+}
+
+func TestCheckEntropy(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	assert.Nil(t, pcg.CheckEntropy())
+
+	assert.NotNil(t, checkEntropy(bytes.NewReader(make([]byte, entropySampleSize))))
+	assert.NotNil(t, checkEntropy(bytes.NewReader(make([]byte, 10))))
+
+	// A source without repetitions, but with a biased distribution
+	biased := make([]byte, entropySampleSize)
+	for k := range biased {
+		if k%2 == 0 {
+			biased[k] = 1
+		} else {
+			biased[k] = byte(k)
+		}
+	}
+	assert.NotNil(t, checkEntropy(bytes.NewReader(biased)))
+}
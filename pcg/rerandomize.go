@@ -0,0 +1,90 @@
+package pcg
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+)
+
+// minReRandomizationKeyLength is the minimal length of the keys of ReRandomizeSeed in bytes.
+const minReRandomizationKeyLength = 32
+
+// reRandomizationKeyDST is the domain separation tag of the derivation of the re-randomization key of a seed.
+const reRandomizationKeyDST = "pcg-bbs-plus/rerandomize/key/v1"
+
+// reRandomizationCommitmentDST is the domain separation tag of the commitment to the re-randomization key of a seed in
+// its hash (see Seed.hash).
+const reRandomizationCommitmentDST = "pcg-bbs-plus/rerandomize/commitment/v1"
+
+// ReRandomizeSeed derives a new seed from the given seed, whose shares are re-randomized by fresh pseudorandom sharings
+// of zero of every component of the tuples, i.e. of sk, a, e, s, alpha and delta (see tuplegen.ReRandomization).
+// All parties have to re-randomize their seeds with the same key, which they agree on jointly and must never publish.
+// The shares of the new seed are unlinkable to the shares of the old seed for anyone not knowing the key, while the
+// shares of all parties still sum to valid tuples. As the DSPF keys are reused, no new seed generation is required.
+//
+// Re-randomizing a re-randomized seed derives the key of the new seed from both keys. The key is not covered by the
+// signature of the dealer (see VerifySeed), but by the seed hash in the tags of the tuples, s.t. the tuples of the old
+// and the new seed are not combined. The dense shares (see WithDenseShares) cannot be re-randomized, as the sharings
+// of zero are derived per root. The given seed is not modified and shares its DSPF keys with the returned seed.
+func ReRandomizeSeed(seed *Seed, key []byte) (*Seed, error) {
+	if seed == nil {
+		return nil, fmt.Errorf("seed must not be nil")
+	}
+	if len(key) < minReRandomizationKeyLength {
+		return nil, fmt.Errorf("re-randomization key must have at least %d bytes but has %d", minReRandomizationKeyLength, len(key))
+	}
+
+	h := sha256.New()
+	h.Write([]byte(reRandomizationKeyDST))
+	if seed.reRandKey != nil {
+		h.Write(seed.reRandKey[:])
+	}
+	h.Write(key)
+	var derived [32]byte
+	copy(derived[:], h.Sum(nil))
+
+	reRandomized := *seed
+	reRandomized.reRandKey = &derived
+	return &reRandomized, nil
+}
+
+// reRandomization returns the re-randomization of the shares of the seed among n parties, nil if the seed was not
+// re-randomized.
+func (s *Seed) reRandomization(n int) (*tuplegen.ReRandomization, error) {
+	if s.reRandKey == nil {
+		return nil, nil
+	}
+	return tuplegen.NewReRandomization(*s.reRandKey, s.index, n)
+}
+
+// reRandomizationCommitment returns the commitment to the re-randomization key of the seed, nil if the seed was not
+// re-randomized.
+func (s *Seed) reRandomizationCommitment() []byte {
+	if s.reRandKey == nil {
+		return nil
+	}
+	commitment := sha256.Sum256(append([]byte(reRandomizationCommitmentDST), s.reRandKey[:]...))
+	return commitment[:]
+}
+
+// checkDenseReRandomization returns an error if the shares of a re-randomized seed are requested as dense vectors.
+func checkDenseReRandomization(seed *Seed, options *evalOptions) error {
+	if seed.reRandKey != nil && (options.dense != nil || options.denseSeparate != nil) {
+		return fmt.Errorf("the dense shares of a re-randomized seed cannot be re-randomized")
+	}
+	return nil
+}
+
+// newGenerator returns the generator of the seed over the shares of the provider, which re-randomizes the shares if
+// the seed was re-randomized (see ReRandomizeSeed).
+func (p *PCG) newGenerator(seed *Seed, provider tuplegen.ShareProvider) (*BBSPlusTupleGenerator, error) {
+	reRand, err := seed.reRandomization(p.n)
+	if err != nil {
+		return nil, err
+	}
+	generator := tuplegen.NewGenerator(reRand.Shares(provider))
+	generator.SetTag(p.newTupleTag(seed))
+	generator.SetLogger(p.logger)
+	generator.SetMetrics(p.metrics)
+	return generator, nil
+}
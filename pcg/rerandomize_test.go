@@ -0,0 +1,177 @@
+package pcg
+
+import (
+	"crypto/rand"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func randomReRandomizationKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	assert.Nil(t, err)
+	return key
+}
+
+func TestReRandomizeSeedCombined(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	generators, err := tuplegen.NewGenerators(2)
+	assert.Nil(t, err)
+
+	key := randomReRandomizationKey(t)
+	oldTuples := make([]*BBSPlusTuple, len(seeds))
+	newTuples := make([]*BBSPlusTuple, len(seeds))
+	voles := make([]*tuplegen.VOLEShares, len(seeds))
+	for i, seed := range seeds {
+		reSeed, err := ReRandomizeSeed(seed, key)
+		assert.Nil(t, err)
+		assert.Nil(t, seed.reRandKey) // The original seed is not modified
+
+		gen, err := pcg.EvalCombined(seed, randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		reGen, err := pcg.EvalCombined(reSeed, randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		reGen.SetGenerators(generators)
+
+		oldTuples[i], err = gen.GenBBSPlusTupleAt(ring, 5)
+		assert.Nil(t, err)
+		newTuples[i], err = reGen.GenBBSPlusTupleAt(ring, 5)
+		assert.Nil(t, err)
+		root, err := ring.RootAt(5)
+		assert.Nil(t, err)
+		voles[i], err = reGen.Provider().(tuplegen.VOLEProvider).VOLESharesAt(root)
+		assert.Nil(t, err)
+
+		// The shares of the re-randomized seed differ from the old ones, and so do the tags
+		for _, shares := range [][2]*bls12381.Fr{
+			{oldTuples[i].SkShare, newTuples[i].SkShare},
+			{oldTuples[i].AShare, newTuples[i].AShare},
+			{oldTuples[i].EShare, newTuples[i].EShare},
+			{oldTuples[i].SShare, newTuples[i].SShare},
+			{oldTuples[i].AlphaShare, newTuples[i].AlphaShare},
+			{oldTuples[i].DeltaShare, newTuples[i].DeltaShare},
+		} {
+			assert.False(t, shares[0].Equal(shares[1]))
+		}
+		assert.NotNil(t, oldTuples[i].Tag.CheckCompatible(newTuples[i].Tag))
+	}
+
+	// The re-randomized tuples still satisfy the correlations and reconstruct the same tuple
+	check, err := CheckRootCorrelation(newTuples, 5)
+	assert.Nil(t, err)
+	assert.True(t, check.Alpha)
+	assert.True(t, check.Delta)
+	oldTuple, err := ReconstructTuple(oldTuples)
+	assert.Nil(t, err)
+	newTuple, err := ReconstructTuple(newTuples)
+	assert.Nil(t, err)
+	assert.True(t, oldTuple.SkShare.Equal(newTuple.SkShare))
+	assert.True(t, oldTuple.AShare.Equal(newTuple.AShare))
+
+	// The VOLE correlation sk*a holds as well
+	a, delta0 := bls12381.NewFr(), bls12381.NewFr()
+	for _, vole := range voles {
+		a.Add(a, vole.A)
+		delta0.Add(delta0, vole.Delta0)
+	}
+	a.Mul(a, newTuple.SkShare)
+	assert.True(t, a.Equal(delta0))
+
+	// The signature of the re-randomized tuples verifies under the shared key
+	messages := []*bls12381.Fr{bls12381.NewFr().One(), bls12381.NewFr().Zero()}
+	shares := make([]*tuplegen.SignatureShare, len(newTuples))
+	for i, tuple := range newTuples {
+		shares[i], err = tuple.Sign(messages)
+		assert.Nil(t, err)
+	}
+	signature, err := tuplegen.CombineSignatureShares(shares)
+	assert.Nil(t, err)
+	g2 := bls12381.NewG2()
+	pk := g2.New()
+	g2.MulScalar(pk, g2.One(), newTuple.SkShare)
+	assert.Nil(t, generators.Verify(pk, messages, signature))
+}
+
+func TestReRandomizeSeedSeparate(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	key := randomReRandomizationKey(t)
+	for _, signerSet := range [][]int{{0, 2}, {0, 1, 2}} {
+		tuples := make([]*BBSPlusTuple, len(signerSet))
+		for k, signer := range signerSet {
+			reSeed, err := ReRandomizeSeed(seeds[signer], key)
+			assert.Nil(t, err)
+			gen, err := pcg.EvalSeparate(seeds[signer], randPolys, ring.Prepared())
+			assert.Nil(t, err)
+			reGen, err := pcg.EvalSeparate(reSeed, randPolys, ring.Prepared())
+			assert.Nil(t, err)
+
+			old, err := gen.GenBBSPlusTupleAt(ring, 3, signerSet)
+			assert.Nil(t, err)
+			tuples[k], err = reGen.GenBBSPlusTupleAt(ring, 3, signerSet)
+			assert.Nil(t, err)
+			assert.False(t, old.AShare.Equal(tuples[k].AShare))
+			assert.False(t, old.DeltaShare.Equal(tuples[k].DeltaShare))
+		}
+		check, err := CheckRootCorrelation(tuples, 3)
+		assert.Nil(t, err)
+		assert.True(t, check.Alpha)
+		assert.True(t, check.Delta)
+	}
+}
+
+func TestReRandomizeSeedKey(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+
+	_, err = ReRandomizeSeed(nil, randomReRandomizationKey(t))
+	assert.NotNil(t, err)
+	_, err = ReRandomizeSeed(seeds[0], make([]byte, minReRandomizationKeyLength-1))
+	assert.NotNil(t, err)
+
+	// Re-randomizing twice derives a new key from both keys
+	key := randomReRandomizationKey(t)
+	once, err := ReRandomizeSeed(seeds[0], key)
+	assert.Nil(t, err)
+	twice, err := ReRandomizeSeed(once, key)
+	assert.Nil(t, err)
+	assert.NotEqual(t, *once.reRandKey, *twice.reRandKey)
+	assert.NotEqual(t, seeds[0].hash(), once.hash())
+	assert.NotEqual(t, once.hash(), twice.hash())
+
+	// The key is serialized with the seed
+	data, err := once.Serialize()
+	assert.Nil(t, err)
+	var read Seed
+	assert.Nil(t, read.Deserialize(data))
+	assert.Equal(t, *once.reRandKey, *read.reRandKey)
+	description, err := read.Describe()
+	assert.Nil(t, err)
+	assert.True(t, description.ReRandomized)
+
+	// The dense shares cannot be re-randomized
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	_, err = pcg.EvalCombined(once, randPolys, ring.Prepared(), WithDenseShares(&DenseShares{}))
+	assert.NotNil(t, err)
+}
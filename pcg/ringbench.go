@@ -0,0 +1,123 @@
+package pcg
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"strings"
+	"time"
+)
+
+// ringBenchTuples is the amount of tuples derived from the materialized roots per run of CompareRings.
+const ringBenchTuples = 16
+
+// RingBenchParams are the parameters of a single point of the parameter sweep of CompareRings.
+// The PCG is evaluated in the n-out-of-n setting, i.e. tau = n.
+type RingBenchParams struct {
+	N, n, c, t int
+}
+
+// NewRingBenchParams returns the parameters of a sweep point.
+func NewRingBenchParams(N, n, c, t int) RingBenchParams {
+	return RingBenchParams{N: N, n: n, c: c, t: t}
+}
+
+// RingTimings are the timings of a single end-to-end run of CompareRings.
+type RingTimings struct {
+	Ring   time.Duration           // Ring is the time to generate the ring, i.e. to materialize its roots
+	Phases map[Phase]time.Duration // Phases holds the durations of the phases of EvalCombined (see PhaseObserver)
+	Derive time.Duration           // Derive is the time to derive ringBenchTuples tuples from the materialized roots
+	Total  time.Duration           // Total is the time of the whole run
+}
+
+// RingComparison compares the end-to-end timings of the rings of GetRing(true) and GetRing(false) for a sweep point.
+type RingComparison struct {
+	Params RingBenchParams
+	Fast   RingTimings // Fast are the timings with GetRing(true)
+	Slow   RingTimings // Slow are the timings with GetRing(false)
+}
+
+// PhaseDelta returns how much longer the given phase took with GetRing(false) than with GetRing(true).
+func (c *RingComparison) PhaseDelta(phase Phase) time.Duration {
+	return c.Slow.Phases[phase] - c.Fast.Phases[phase]
+}
+
+// String returns a report of the timings of both rings and their deltas.
+func (c *RingComparison) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "N: %d, n: %d, c: %d, t: %d\n", c.Params.N, c.Params.n, c.Params.c, c.Params.t)
+	fmt.Fprintf(&b, "%-12s %14s %14s %14s\n", "step", "fast", "slow", "delta")
+	row := func(name string, fast, slow time.Duration) {
+		fmt.Fprintf(&b, "%-12s %14v %14v %14v\n", name, fast, slow, slow-fast)
+	}
+	row("Ring", c.Fast.Ring, c.Slow.Ring)
+	for _, phase := range []Phase{PhaseVOLE, PhaseOLE1, PhaseOLE2, PhaseFinalShare} {
+		row(phase.String(), c.Fast.Phases[phase], c.Slow.Phases[phase])
+	}
+	row("Derive", c.Fast.Derive, c.Slow.Derive)
+	row("Total", c.Fast.Total, c.Slow.Total)
+	return b.String()
+}
+
+// CompareRings runs the full pipeline (ring generation, EvalCombined of the first party and tuple derivation) once
+// with the ring of GetRing(true) and once with the ring of GetRing(false) for each point of the parameter sweep.
+// Both rings share the divisor x^(2^N) + 1 and only differ in how their roots are computed, hence the deltas of the
+// Eval phases are expected to be within noise, while the ring generation dominates the difference.
+func CompareRings(lambda int, sweep []RingBenchParams) ([]*RingComparison, error) {
+	comparisons := make([]*RingComparison, 0, len(sweep))
+	for _, params := range sweep {
+		pcg, err := NewPCG(lambda, params.N, params.n, params.n, params.c, params.t)
+		if err != nil {
+			return nil, err
+		}
+		seeds, err := pcg.TrustedSeedGen()
+		if err != nil {
+			return nil, err
+		}
+		rand, err := pcg.PickRandomPolynomials()
+		if err != nil {
+			return nil, err
+		}
+
+		comparison := &RingComparison{Params: params}
+		if comparison.Fast, err = pcg.ringBenchRun(seeds[0], rand, true); err != nil {
+			return nil, err
+		}
+		if comparison.Slow, err = pcg.ringBenchRun(seeds[0], rand, false); err != nil {
+			return nil, err
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons, nil
+}
+
+// ringBenchRun runs the pipeline of CompareRings for the given seed with the ring of GetRing(fast).
+func (p *PCG) ringBenchRun(seed *Seed, rand []*poly.Polynomial, fast bool) (RingTimings, error) {
+	timings := RingTimings{Phases: make(map[Phase]time.Duration)}
+	previous := p.phaseObserver
+	p.SetPhaseObserver(func(party int, phase Phase, duration time.Duration) {
+		timings.Phases[phase] += duration
+	})
+	defer p.SetPhaseObserver(previous)
+
+	start := time.Now()
+	ring, err := p.GetRing(fast)
+	if err != nil {
+		return RingTimings{}, err
+	}
+	timings.Ring = time.Since(start)
+
+	generator, err := p.EvalCombined(seed, rand, ring.Prepared())
+	if err != nil {
+		return RingTimings{}, err
+	}
+
+	startDerive := time.Now()
+	for i := 0; i < min(ringBenchTuples, len(ring.Roots)); i++ {
+		if generator.GenBBSPlusTuple(ring.Roots[i]) == nil {
+			return RingTimings{}, fmt.Errorf("failed to derive tuple %d", i)
+		}
+	}
+	timings.Derive = time.Since(startDerive)
+	timings.Total = time.Since(start)
+	return timings, nil
+}
@@ -0,0 +1,56 @@
+package pcg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCompareRings(t *testing.T) {
+	comparisons, err := CompareRings(128, []RingBenchParams{NewRingBenchParams(5, 2, 2, 4), NewRingBenchParams(6, 2, 2, 4)})
+	assert.Nil(t, err)
+	assert.Len(t, comparisons, 2)
+	for _, comparison := range comparisons {
+		for _, timings := range []RingTimings{comparison.Fast, comparison.Slow} {
+			for _, phase := range []Phase{PhaseVOLE, PhaseOLE1, PhaseOLE2, PhaseFinalShare} {
+				assert.Greater(t, timings.Phases[phase], time.Duration(0))
+			}
+			assert.Greater(t, timings.Ring, time.Duration(0))
+			assert.GreaterOrEqual(t, timings.Total, timings.Ring+timings.Derive)
+		}
+		assert.Equal(t, comparison.Slow.Phases[PhaseVOLE]-comparison.Fast.Phases[PhaseVOLE], comparison.PhaseDelta(PhaseVOLE))
+		assert.Contains(t, comparison.String(), "FinalShare")
+	}
+
+	_, err = CompareRings(128, []RingBenchParams{NewRingBenchParams(6, 1, 2, 4)})
+	assert.NotNil(t, err)
+}
+
+func TestPhaseObserver(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	calls := make(map[Phase]int)
+	pcg.SetPhaseObserver(func(party int, phase Phase, duration time.Duration) {
+		assert.Equal(t, 1, party)
+		calls[phase]++
+	})
+	_, err = pcg.EvalCombined(seeds[1], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	assert.Equal(t, map[Phase]int{PhaseVOLE: 1, PhaseOLE1: 1, PhaseOLE2: 1, PhaseFinalShare: 6}, calls)
+
+	calls = make(map[Phase]int)
+	_, err = pcg.EvalSeparate(seeds[1], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	assert.Equal(t, map[Phase]int{PhaseVOLE: 1, PhaseOLE1: 1, PhaseOLE2: 1, PhaseFinalShare: 6}, calls)
+
+	pcg.SetPhaseObserver(nil)
+	_, err = pcg.EvalCombined(seeds[1], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+}
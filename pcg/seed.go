@@ -1,22 +1,28 @@
 package pcg
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/artifact"
 	"math/big"
-	"pcg-bbs-plus/dspf"
 )
 
+// seedExponents holds the exponent matrices of the party of a seed, i.e. of a single party (see ExponentMatrix.Party).
 type seedExponents struct {
-	aOmega [][]*big.Int // Exponents for a_i
-	eEta   [][]*big.Int // Exponents for e_i
-	sPhi   [][]*big.Int // Exponents for s_i
+	aOmega *ExponentMatrix // Exponents for a_i
+	eEta   *ExponentMatrix // Exponents for e_i
+	sPhi   *ExponentMatrix // Exponents for s_i
 }
 
+// seedCoefficients holds the coefficient matrices of the party of a seed, i.e. of a single party.
 type seedCoefficients struct {
-	aBeta    [][]*bls12381.Fr // Coefficients for a_i
-	eGamma   [][]*bls12381.Fr // Coefficients for e_i
-	sEpsilon [][]*bls12381.Fr // Coefficients for s_i
+	aBeta    *CoefficientMatrix // Coefficients for a_i
+	eGamma   *CoefficientMatrix // Coefficients for e_i
+	sEpsilon *CoefficientMatrix // Coefficients for s_i
 }
 
 type DSPFKeyPair struct {
@@ -27,21 +33,470 @@ type DSPFKeyPair struct {
 // Seed is the seed generated by the Gen function of the PCG.
 // It allows to derive ECDSA tuples from the EvalAll function of the PCG.
 type Seed struct {
-	index        int
-	ski          *bls12381.Fr
-	exponents    seedExponents
-	coefficients seedCoefficients
-	U            [][][]*DSPFKeyPair   // U[i][j][r]
-	C            [][][][]*DSPFKeyPair // C[i][j][r][s]
-	V            [][][][]*DSPFKeyPair // V[i][j][r][s]
+	index         int
+	ski           *bls12381.Fr
+	skShareIndex  int                 // skShareIndex is the index of the shamir evaluation point of ski
+	skCommitments []*bls12381.PointG1 // skCommitments are the commitments of the dealer to the sharing of sk (see PCG.SharingScheme)
+	exponents     seedExponents
+	coefficients  seedCoefficients
+	U             *DSPFKeyMatrix  // U[i][j][r]
+	C             *DSPFKeyMatrix  // C[i][j][r][s]
+	V             *DSPFKeyMatrix  // V[i][j][r][s]
+	reRandKey     *[32]byte       // reRandKey is the key of the sharings of zero that re-randomize the shares (see ReRandomizeSeed). nil if not re-randomized.
+	signature     []byte          // signature is the signature of the dealer on the seed (see TrustedSeedGenAuthenticated). nil if unsigned.
+	paramsDigest  [32]byte        // paramsDigest identifies the parameters of the PCG the seed was generated for. It is zero for seeds of the legacy format.
+	params        *SeedParameters // params are the parameters of the PCG the seed was generated for. nil for seeds of the legacy formats.
+}
+
+// VerifyShare verifies the sk share of the seed against the commitments of the dealer to the sharing of sk.
+func (s *Seed) VerifyShare() error {
+	return s.sharingScheme().Verify(s.ski, s.skShareIndex, s.skCommitments)
+}
+
+// SharedPublicKey returns g1^sk for the shared sk, as committed to by the dealer.
+// Parties that verified their shares via VerifyShare hold consistent shares of the secret key of this public key.
+func (s *Seed) SharedPublicKey() (*bls12381.PointG1, error) {
+	if len(s.skCommitments) == 0 {
+		return nil, fmt.Errorf("seed holds no commitments")
+	}
+	return s.sharingScheme().PublicKey(s.skCommitments)
+}
+
+// Parameters returns the parameters of the PCG the seed was generated for. It returns nil for seeds of the legacy
+// formats, which do not record their parameters.
+func (s *Seed) Parameters() *SeedParameters {
+	if s.params == nil {
+		return nil
+	}
+	params := *s.params
+	return &params
+}
+
+// checkSeedParams returns a ParameterMismatchError if the seed was generated for other parameters than the parameters
+// of the PCG or for another ring than the ring of the given divisor. Seeds of the legacy formats do not record (all)
+// their parameters and only the recorded parameters are checked.
+func (p *PCG) checkSeedParams(seed *Seed, div *PreparedDivisor) error {
+	if seed.params != nil {
+		params, err := p.seedParameters()
+		if err != nil {
+			return err
+		}
+		for _, param := range []struct {
+			name      string
+			seed, pcg any
+		}{
+			{"lambda", seed.params.Lambda, params.Lambda},
+			{"N", seed.params.N, params.N},
+			{"n", seed.params.Parties, params.Parties},
+			{"tau", seed.params.Tau, params.Tau},
+			{"c", seed.params.C, params.C},
+			{"t", seed.params.T, params.T},
+			{"regular noise", seed.params.RegularNoise, params.RegularNoise},
+		} {
+			if param.seed != param.pcg {
+				return &ParameterMismatchError{Party: seed.index, Parameter: param.name, Seed: param.seed, PCG: param.pcg}
+			}
+		}
+		if divDigest := div.Digest(); seed.params.RingDigest != divDigest {
+			return &ParameterMismatchError{Party: seed.index, Parameter: "ring", Seed: fmt.Sprintf("%x", seed.params.RingDigest[:4]), PCG: fmt.Sprintf("%x", divDigest[:4])}
+		}
+	}
+	if digest := p.paramsDigest(); seed.paramsDigest != ([32]byte{}) && seed.paramsDigest != digest {
+		return &ParameterMismatchError{Party: seed.index, Parameter: "digest", Seed: fmt.Sprintf("%x", seed.paramsDigest[:4]), PCG: fmt.Sprintf("%x", digest[:4])}
+	}
+	return nil
+}
+
+// legacySeedFormatVersion is the version of the legacy serialization of seeds, which has no artifact header but starts
+// with the gob encoded version.
+const legacySeedFormatVersion = 1
+
+// seedData is the gob format of the party specific parts of a Seed. Field elements are encoded via ToBytes and the
+// commitments via G1.ToBytes.
+type seedData struct {
+	Index         int
+	SkShareIndex  int
+	Ski           []byte
+	SkCommitments [][]byte
+	AOmega        [][]*big.Int
+	EEta          [][]*big.Int
+	SPhi          [][]*big.Int
+	ABeta         [][][]byte
+	EGamma        [][][]byte
+	SEpsilon      [][][]byte
+	Scale         []byte // Scale is the re-randomization factor of a removed public re-randomization. Seeds holding one are rejected.
+	ReRandKey     []byte // ReRandKey is the key of the re-randomization of the shares (see ReRandomizeSeed). nil if not re-randomized.
+	Signature     []byte
+	Params        *SeedParameters // Params are the parameters of the PCG. Seeds of the legacy formats hold none.
+}
+
+// seedKeysData is the gob format of the DSPF keys U, C and V of a Seed, which are shared by the seeds of all parties.
+type seedKeysData struct {
+	U *keyMatrixData
+	C *keyMatrixData
+	V *keyMatrixData
+}
+
+// keyMatrixData is the gob format of a DSPFKeyMatrix. Keys holds the serialized Key0 and Key1 of each pair in the
+// order of the matrix, except for the pairs of the diagonal [i][i], which hold no keys.
+type keyMatrixData struct {
+	N    int
+	C    int
+	OLE  bool
+	Keys [][]byte
+}
+
+// nestedSeedKeysData is the gob format of the DSPF keys of the format version 2 and of the legacy format, which
+// nest the key pairs by their indices.
+type nestedSeedKeysData struct {
+	U [][][]keyPairData
+	C [][][][]keyPairData
+	V [][][][]keyPairData
+}
+
+// keyPairData is the gob format of a DSPFKeyPair in nestedSeedKeysData.
+type keyPairData struct {
+	Present bool
+	Key0    []byte
+	Key1    []byte
 }
 
+// flatKeysFormatVersion is the first format version of seeds that store the DSPF keys as keyMatrixData.
+const flatKeysFormatVersion = 3
+
+// Serialize serializes the seed, including the DSPF keys of all parties and the signature of the dealer, s.t. the
+// deserialized seed can still be verified via VerifySeed. The seed is prefixed by an artifact header, which records the
+// parameters of the PCG and the conventions the seed was generated under.
 func (s *Seed) Serialize() ([]byte, error) {
-	return nil, fmt.Errorf("not implemented")
+	party, err := s.partyData()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.keysData()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(artifact.NewHeader(artifact.KindSeed, s.paramsDigest).Encode())
+	encoder := gob.NewEncoder(buf)
+	for _, v := range []any{party, keys} {
+		if err := encoder.Encode(v); err != nil {
+			return nil, fmt.Errorf("failed to encode seed: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
 }
 
+// Deserialize deserializes a seed serialized via Serialize and sets the seed the function is being called on.
+// It returns an artifact.IncompatibleError if the seed was serialized by a revision with another format version or
+// other conventions. Seeds of the legacy format without header are accepted, but Eval cannot check their parameters.
 func (s *Seed) Deserialize(data []byte) error {
-	return fmt.Errorf("not implemented")
+	if !artifact.HasHeader(data) {
+		return s.deserializeLegacy(data)
+	}
+	header, body, err := artifact.Decode(data, artifact.KindSeed)
+	if err != nil {
+		return err
+	}
+	seed, err := decodeSeed(gob.NewDecoder(bytes.NewReader(body)), header.Version)
+	if err != nil {
+		return err
+	}
+	seed.paramsDigest = header.ParamsDigest
+	*s = *seed
+	return nil
+}
+
+// deserializeLegacy deserializes a seed of the legacy format, which starts with the gob encoded version.
+func (s *Seed) deserializeLegacy(data []byte) error {
+	decoder := gob.NewDecoder(bytes.NewReader(data))
+	var version int
+	if err := decoder.Decode(&version); err != nil {
+		return fmt.Errorf("failed to decode seed: %w", err)
+	}
+	if version != legacySeedFormatVersion {
+		return fmt.Errorf("unsupported seed format version %d", version)
+	}
+	seed, err := decodeSeed(decoder, legacySeedFormatVersion)
+	if err != nil {
+		return err
+	}
+	*s = *seed
+	return nil
+}
+
+// decodeSeed decodes the party specific parts and the DSPF keys of a seed of the given format version.
+func decodeSeed(decoder *gob.Decoder, version uint16) (*Seed, error) {
+	var party seedData
+	if err := decoder.Decode(&party); err != nil {
+		return nil, fmt.Errorf("failed to decode seed: %w", err)
+	}
+	keys := &seedKeysData{}
+	if version < flatKeysFormatVersion {
+		var nested nestedSeedKeysData
+		if err := decoder.Decode(&nested); err != nil {
+			return nil, fmt.Errorf("failed to decode seed keys: %w", err)
+		}
+		var err error
+		if keys, err = nested.flatten(); err != nil {
+			return nil, err
+		}
+	} else if err := decoder.Decode(keys); err != nil {
+		return nil, fmt.Errorf("failed to decode seed keys: %w", err)
+	}
+	return seedFromData(&party, keys)
+}
+
+// partyData returns the party specific parts of the seed in their gob format.
+func (s *Seed) partyData() (*seedData, error) {
+	if s.ski == nil {
+		return nil, fmt.Errorf("seed holds no sk share")
+	}
+	g1 := bls12381.NewG1()
+	data := &seedData{
+		Index:         s.index,
+		SkShareIndex:  s.skShareIndex,
+		Ski:           s.ski.ToBytes(),
+		SkCommitments: make([][]byte, len(s.skCommitments)),
+		AOmega:        s.exponents.aOmega.vectors(),
+		EEta:          s.exponents.eEta.vectors(),
+		SPhi:          s.exponents.sPhi.vectors(),
+		ABeta:         frMatrixToBytes(s.coefficients.aBeta.vectors()),
+		EGamma:        frMatrixToBytes(s.coefficients.eGamma.vectors()),
+		SEpsilon:      frMatrixToBytes(s.coefficients.sEpsilon.vectors()),
+		Signature:     s.signature,
+		Params:        s.params,
+	}
+	for i, commitment := range s.skCommitments {
+		data.SkCommitments[i] = g1.ToBytes(commitment)
+	}
+	if s.reRandKey != nil {
+		data.ReRandKey = s.reRandKey[:]
+	}
+	return data, nil
+}
+
+// keysData returns the DSPF keys of the seed in their gob format.
+func (s *Seed) keysData() (*seedKeysData, error) {
+	if s.U == nil || s.C == nil || s.V == nil {
+		return nil, fmt.Errorf("seed holds no DSPF keys")
+	}
+	keys := &seedKeysData{}
+	for _, k := range []struct {
+		data   **keyMatrixData
+		matrix *DSPFKeyMatrix
+	}{{&keys.U, s.U}, {&keys.C, s.C}, {&keys.V, s.V}} {
+		m := k.matrix
+		data := &keyMatrixData{N: m.n, C: m.c, OLE: m.ole, Keys: make([][]byte, 0, 2*(len(m.pairs)-m.n*m.blockSize()))}
+		for i := 0; i < m.n; i++ {
+			for j := 0; j < m.n; j++ {
+				if i == j {
+					continue
+				}
+				for _, pair := range m.Block(i, j) {
+					for _, key := range []dspf.Key{pair.Key0, pair.Key1} {
+						serialized, err := key.SerializeKeys()
+						if err != nil {
+							return nil, fmt.Errorf("failed to serialize DSPF keys: %w", err)
+						}
+						data.Keys = append(data.Keys, serialized)
+					}
+				}
+			}
+		}
+		*k.data = data
+	}
+	return keys, nil
+}
+
+// matrix reconstructs the key matrix from its gob format.
+func (data *keyMatrixData) matrix() (*DSPFKeyMatrix, error) {
+	if data == nil || data.N < 0 || data.C < 0 {
+		return nil, fmt.Errorf("seed holds an invalid DSPF key matrix")
+	}
+	m := NewVOLEKeyMatrix(data.N, data.C)
+	if data.OLE {
+		m = NewOLEKeyMatrix(data.N, data.C)
+	}
+	if len(data.Keys) != 2*(len(m.pairs)-m.n*m.blockSize()) {
+		return nil, fmt.Errorf("seed holds %d DSPF keys, but its key matrix requires %d", len(data.Keys), 2*(len(m.pairs)-m.n*m.blockSize()))
+	}
+	keys := data.Keys
+	for i := 0; i < m.n; i++ {
+		for j := 0; j < m.n; j++ {
+			if i == j {
+				continue
+			}
+			block := m.Block(i, j)
+			for k := range block {
+				if err := block[k].Key0.DeserializeKeys(keys[0]); err != nil {
+					return nil, fmt.Errorf("failed to deserialize DSPF keys: %w", err)
+				}
+				if err := block[k].Key1.DeserializeKeys(keys[1]); err != nil {
+					return nil, fmt.Errorf("failed to deserialize DSPF keys: %w", err)
+				}
+				keys = keys[2:]
+			}
+		}
+	}
+	return m, nil
+}
+
+// flatten converts the nested gob format of the keys into seedKeysData. The nested slices must be regular, i.e. hold
+// n*n*c (VOLE) or n*n*c*c (OLE) pairs. The pairs of the diagonal are dropped.
+func (nested *nestedSeedKeysData) flatten() (*seedKeysData, error) {
+	n, c := len(nested.U), 0
+	if n > 0 && len(nested.U[0]) > 0 {
+		c = len(nested.U[0][0])
+	}
+	invalid := fmt.Errorf("seed holds DSPF keys of an irregular shape")
+	keys := &seedKeysData{U: &keyMatrixData{N: n, C: c}}
+	for i := range nested.U {
+		if len(nested.U[i]) != n {
+			return nil, invalid
+		}
+		for j := range nested.U[i] {
+			if len(nested.U[i][j]) != c {
+				return nil, invalid
+			}
+			for _, pair := range nested.U[i][j] {
+				if i != j {
+					keys.U.Keys = append(keys.U.Keys, pair.Key0, pair.Key1)
+				}
+			}
+		}
+	}
+	for _, k := range []struct {
+		data   **keyMatrixData
+		nested [][][][]keyPairData
+	}{{&keys.C, nested.C}, {&keys.V, nested.V}} {
+		data := &keyMatrixData{N: n, C: c, OLE: true}
+		if len(k.nested) != n {
+			return nil, fmt.Errorf("seed holds the DSPF keys of inconsistent amounts of parties")
+		}
+		for i := range k.nested {
+			if len(k.nested[i]) != n {
+				return nil, invalid
+			}
+			for j := range k.nested[i] {
+				if len(k.nested[i][j]) != c {
+					return nil, invalid
+				}
+				for r := range k.nested[i][j] {
+					if len(k.nested[i][j][r]) != c {
+						return nil, invalid
+					}
+					for _, pair := range k.nested[i][j][r] {
+						if i != j {
+							data.Keys = append(data.Keys, pair.Key0, pair.Key1)
+						}
+					}
+				}
+			}
+		}
+		*k.data = data
+	}
+	return keys, nil
+}
+
+// seedFromData reconstructs a seed from its gob format and checks that its parts are consistent.
+func seedFromData(party *seedData, keys *seedKeysData) (*Seed, error) {
+	if keys.U == nil || keys.C == nil || keys.V == nil || keys.U.OLE || !keys.C.OLE || !keys.V.OLE {
+		return nil, fmt.Errorf("seed holds no valid DSPF keys")
+	}
+	n := keys.U.N
+	if keys.C.N != n || keys.V.N != n || keys.C.C != keys.U.C || keys.V.C != keys.U.C {
+		return nil, fmt.Errorf("seed holds the DSPF keys of inconsistent amounts of parties")
+	}
+	if party.Index < 0 || party.Index >= n {
+		return nil, fmt.Errorf("seed index %d is not within [0, n=%d)", party.Index, n)
+	}
+	ski, err := dpf.FrFromBytesCanonical(party.Ski)
+	if err != nil {
+		return nil, fmt.Errorf("seed holds an invalid sk share: %w", err)
+	}
+
+	seed := &Seed{
+		index:        party.Index,
+		ski:          ski,
+		skShareIndex: party.SkShareIndex,
+		signature:    party.Signature,
+		params:       party.Params,
+	}
+	for _, e := range []struct {
+		matrix  **ExponentMatrix
+		vectors [][]*big.Int
+	}{{&seed.exponents.aOmega, party.AOmega}, {&seed.exponents.eEta, party.EEta}, {&seed.exponents.sPhi, party.SPhi}} {
+		if *e.matrix, err = NewExponentMatrix([][][]*big.Int{e.vectors}); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range []struct {
+		matrix  **CoefficientMatrix
+		encoded [][][]byte
+	}{{&seed.coefficients.aBeta, party.ABeta}, {&seed.coefficients.eGamma, party.EGamma}, {&seed.coefficients.sEpsilon, party.SEpsilon}} {
+		vectors, err := frMatrixFromBytes(c.encoded)
+		if err != nil {
+			return nil, err
+		}
+		if *c.matrix, err = NewCoefficientMatrix([][][]*bls12381.Fr{vectors}); err != nil {
+			return nil, err
+		}
+	}
+	if party.Scale != nil {
+		return nil, fmt.Errorf("seed was re-randomized with a public scalar, which is no longer supported as it provides no unlinkability")
+	}
+	if party.ReRandKey != nil {
+		if len(party.ReRandKey) != 32 {
+			return nil, fmt.Errorf("seed holds a re-randomization key of %d instead of 32 bytes", len(party.ReRandKey))
+		}
+		seed.reRandKey = (*[32]byte)(party.ReRandKey)
+	}
+	g1 := bls12381.NewG1()
+	seed.skCommitments = make([]*bls12381.PointG1, len(party.SkCommitments))
+	for i, commitment := range party.SkCommitments {
+		if seed.skCommitments[i], err = g1.FromBytes(commitment); err != nil {
+			return nil, fmt.Errorf("seed holds an invalid commitment: %w", err)
+		}
+	}
+
+	if seed.U, err = keys.U.matrix(); err != nil {
+		return nil, err
+	}
+	if seed.C, err = keys.C.matrix(); err != nil {
+		return nil, err
+	}
+	if seed.V, err = keys.V.matrix(); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+// frMatrixToBytes encodes each element of the matrix via ToBytes.
+func frMatrixToBytes(matrix [][]*bls12381.Fr) [][][]byte {
+	res := make([][][]byte, len(matrix))
+	for i, row := range matrix {
+		res[i] = make([][]byte, len(row))
+		for j, element := range row {
+			res[i][j] = element.ToBytes()
+		}
+	}
+	return res
+}
+
+// frMatrixFromBytes decodes the matrix encoded by frMatrixToBytes.
+func frMatrixFromBytes(matrix [][][]byte) ([][]*bls12381.Fr, error) {
+	res := make([][]*bls12381.Fr, len(matrix))
+	for i, row := range matrix {
+		res[i] = make([]*bls12381.Fr, len(row))
+		for j, element := range row {
+			var err error
+			if res[i][j], err = dpf.FrFromBytesCanonical(element); err != nil {
+				return nil, fmt.Errorf("seed holds an invalid coefficient: %w", err)
+			}
+		}
+	}
+	return res, nil
 }
 
 type oleSeed struct {
@@ -0,0 +1,216 @@
+package pcg
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/artifact"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSeedSerialize(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	seeds, pp, err := pcg.TrustedSeedGenAuthenticated()
+	assert.Nil(t, err)
+	for _, seed := range seeds {
+		data, err := seed.Serialize()
+		assert.Nil(t, err)
+		deserialized := &Seed{}
+		assert.Nil(t, deserialized.Deserialize(data))
+
+		// The deserialized seed covers the same content, including the signature
+		expected, err := seed.Digest()
+		assert.Nil(t, err)
+		actual, err := deserialized.Digest()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, actual)
+		assert.Nil(t, pcg.VerifySeed(deserialized, pp))
+		assert.Nil(t, deserialized.VerifyShare())
+		assert.Equal(t, seed.hash(), deserialized.hash())
+	}
+
+	rand, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(false)
+	assert.Nil(t, err)
+	data, err := seeds[0].Serialize()
+	assert.Nil(t, err)
+	deserialized := &Seed{}
+	assert.Nil(t, deserialized.Deserialize(data))
+	expected, err := pcg.EvalSeparate(seeds[0], rand, ring.Prepared())
+	assert.Nil(t, err)
+	actual, err := pcg.EvalSeparate(deserialized, rand, ring.Prepared())
+	assert.Nil(t, err)
+	signers := []int{0, 1, 2}
+	expectedTuple, actualTuple := expected.GenBBSPlusTuple(ring.Roots[3], signers), actual.GenBBSPlusTuple(ring.Roots[3], signers)
+	assert.Nil(t, expectedTuple.Tag.CheckCompatible(actualTuple.Tag))
+	expectedTuple.Tag, actualTuple.Tag = nil, nil // The tags hold the time of the evaluation
+	assert.Equal(t, expectedTuple, actualTuple)
+}
+
+func TestSeedDeserializeInvalid(t *testing.T) {
+	pcg, err := NewPCG(128, 5, 2, 2, 2, 2)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	data, err := seeds[0].Serialize()
+	assert.Nil(t, err)
+
+	seed := &Seed{}
+	assert.NotNil(t, seed.Deserialize(nil))
+	assert.NotNil(t, seed.Deserialize(data[:len(data)/2]))
+	assert.NotNil(t, seed.Deserialize([]byte("not a seed")))
+
+	// An invalid index is rejected and the seed is not modified
+	invalid := *seeds[0]
+	invalid.index = 5
+	data, err = invalid.Serialize()
+	assert.Nil(t, err)
+	assert.NotNil(t, seed.Deserialize(data))
+	assert.Nil(t, seed.ski)
+
+	// Seeds re-randomized with a public scalar are rejected
+	party, err := seeds[0].partyData()
+	assert.Nil(t, err)
+	keys, err := seeds[0].keysData()
+	assert.Nil(t, err)
+	party.Scale = []byte{2}
+	_, err = seedFromData(party, keys)
+	assert.ErrorContains(t, err, "re-randomized")
+}
+
+func TestSeedCompatibility(t *testing.T) {
+	pcg, err := NewPCG(128, 5, 2, 2, 2, 2)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	rand, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	// Seeds of the legacy format are accepted
+	party, err := seeds[0].partyData()
+	assert.Nil(t, err)
+	keys, err := nestedKeysData(seeds[0])
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+	for _, v := range []any{legacySeedFormatVersion, party, keys} {
+		assert.Nil(t, encoder.Encode(v))
+	}
+	legacy := &Seed{}
+	assert.Nil(t, legacy.Deserialize(buf.Bytes()))
+	_, err = pcg.EvalCombined(legacy, rand, ring.Prepared())
+	assert.Nil(t, err)
+
+	// Seeds of the format version 2 with nested keys are accepted and keep their digest
+	header := artifact.NewHeader(artifact.KindSeed, seeds[0].paramsDigest)
+	header.Version = 2
+	buf = *bytes.NewBuffer(header.Encode())
+	encoder = gob.NewEncoder(&buf)
+	for _, v := range []any{party, keys} {
+		assert.Nil(t, encoder.Encode(v))
+	}
+	nested := &Seed{}
+	assert.Nil(t, nested.Deserialize(buf.Bytes()))
+	digest, err := nested.Digest()
+	assert.Nil(t, err)
+	expected, err := seeds[0].Digest()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, digest)
+	_, err = pcg.EvalCombined(nested, rand, ring.Prepared())
+	assert.Nil(t, err)
+
+	// Seeds of other conventions are rejected
+	data, err := seeds[0].Serialize()
+	assert.Nil(t, err)
+	data[8+int(artifact.ConventionExponentSampling)]++ // the revisions of the conventions follow the magic, kind, version and their amount
+	var incompatible *artifact.IncompatibleError
+	assert.True(t, errors.As((&Seed{}).Deserialize(data), &incompatible))
+
+	// Seeds of other parameters are rejected by Eval
+	other, err := NewPCG(128, 5, 2, 2, 2, 2)
+	assert.Nil(t, err)
+	assert.Nil(t, other.UseRegularNoise())
+	_, err = other.EvalCombined(seeds[0], rand, ring.Prepared())
+	assert.NotNil(t, err)
+}
+
+func TestSeedParameterMismatch(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	params := seeds[0].Parameters()
+	assert.Equal(t, 6, params.N)
+	assert.Equal(t, 2, params.Parties)
+
+	// The parameters survive the serialization
+	data, err := seeds[0].Serialize()
+	assert.Nil(t, err)
+	deserialized := &Seed{}
+	assert.Nil(t, deserialized.Deserialize(data))
+	assert.Equal(t, params, deserialized.Parameters())
+
+	// A PCG with another N rejects the seed
+	other, err := NewPCG(128, 7, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	otherRing, err := other.GetLazyRing()
+	assert.Nil(t, err)
+	randPolys, err := other.PickRandomPolynomials()
+	assert.Nil(t, err)
+	_, err = other.EvalCombined(seeds[0], randPolys, otherRing.Prepared())
+	var mismatch *ParameterMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "N", mismatch.Parameter)
+	assert.Equal(t, 6, mismatch.Seed)
+	assert.Equal(t, 7, mismatch.PCG)
+
+	// The PCG of the seed rejects the divisor of another ring
+	randPolys, err = pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	_, err = pcg.EvalSeparate(seeds[1], randPolys, otherRing.Prepared())
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "ring", mismatch.Parameter)
+	assert.Equal(t, 1, mismatch.Party)
+}
+
+// nestedKeysData returns the DSPF keys of the seed in the nested gob format of the format version 2.
+func nestedKeysData(s *Seed) (*nestedSeedKeysData, error) {
+	encodePair := func(pair *DSPFKeyPair) (keyPairData, error) {
+		key0, err := pair.Key0.SerializeKeys()
+		if err != nil {
+			return keyPairData{}, err
+		}
+		key1, err := pair.Key1.SerializeKeys()
+		return keyPairData{Present: true, Key0: key0, Key1: key1}, err
+	}
+
+	n, c := s.U.N(), s.U.C()
+	keys := &nestedSeedKeysData{U: make([][][]keyPairData, n), C: make([][][][]keyPairData, n), V: make([][][][]keyPairData, n)}
+	var err error
+	for i := 0; i < n; i++ {
+		keys.U[i], keys.C[i], keys.V[i] = make([][]keyPairData, n), make([][][]keyPairData, n), make([][][]keyPairData, n)
+		for j := 0; j < n; j++ {
+			keys.U[i][j], keys.C[i][j], keys.V[i][j] = make([]keyPairData, c), make([][]keyPairData, c), make([][]keyPairData, c)
+			for r := 0; r < c; r++ {
+				if keys.U[i][j][r], err = encodePair(s.U.At(i, j, r)); err != nil {
+					return nil, err
+				}
+				keys.C[i][j][r], keys.V[i][j][r] = make([]keyPairData, c), make([]keyPairData, c)
+				for k := 0; k < c; k++ {
+					if keys.C[i][j][r][k], err = encodePair(s.C.AtOLE(i, j, r, k)); err != nil {
+						return nil, err
+					}
+					if keys.V[i][j][r][k], err = encodePair(s.V.AtOLE(i, j, r, k)); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	return keys, nil
+}
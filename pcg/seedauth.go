@@ -0,0 +1,202 @@
+package pcg
+
+import (
+	"crypto/ed25519"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dspf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/artifact"
+	"hash"
+	"math/big"
+)
+
+// seedAuthDomainSeparator separates the digest signed by the dealer from other uses of the hash function.
+const seedAuthDomainSeparator = "pcg-bbs-plus/seed-auth/v1"
+
+// PublicParameters holds the public output of the dealer, which the parties need to authenticate their seeds.
+type PublicParameters struct {
+	DealerKey    ed25519.PublicKey // DealerKey is the public part of the ephemeral signing key of the dealer.
+	ParamsDigest [32]byte          // ParamsDigest binds the seeds to the parameters of the PCG.
+}
+
+// Serialize serializes the public parameters, prefixed by an artifact header that records the parameters of the PCG.
+func (pp *PublicParameters) Serialize() ([]byte, error) {
+	if len(pp.DealerKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public parameters hold no valid dealer key")
+	}
+	return append(artifact.NewHeader(artifact.KindPublicParameters, pp.ParamsDigest).Encode(), pp.DealerKey...), nil
+}
+
+// Deserialize deserializes public parameters serialized via Serialize and sets the public parameters the function is
+// being called on. It returns an artifact.IncompatibleError if they were serialized by an incompatible revision.
+func (pp *PublicParameters) Deserialize(data []byte) error {
+	header, body, err := artifact.Decode(data, artifact.KindPublicParameters)
+	if err != nil {
+		return err
+	}
+	if len(body) != ed25519.PublicKeySize {
+		return fmt.Errorf("public parameters hold no valid dealer key")
+	}
+	pp.DealerKey = append(ed25519.PublicKey(nil), body...)
+	pp.ParamsDigest = header.ParamsDigest
+	return nil
+}
+
+// TrustedSeedGenAuthenticated works like TrustedSeedGen, but the dealer additionally signs the seed of each party
+// with an ephemeral key. The private part of the key is discarded after signing and the public part is returned
+// as part of the PublicParameters, s.t. each party can check its seed via VerifySeed before evaluating it.
+func (p *PCG) TrustedSeedGenAuthenticated() ([]*Seed, *PublicParameters, error) {
+	seeds, err := p.TrustedSeedGen()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(cryptorand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate the signing key of the dealer: %w", err)
+	}
+	pp := &PublicParameters{DealerKey: publicKey, ParamsDigest: p.paramsDigest()}
+
+	// All parties share the same DSPF keys, hence they are only hashed once.
+	keysDigest, err := seeds[0].keysDigest()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to hash the DSPF keys: %w", err)
+	}
+	for _, seed := range seeds {
+		seed.signature = ed25519.Sign(privateKey, seed.authDigest(pp.ParamsDigest, keysDigest))
+	}
+	return seeds, pp, nil
+}
+
+// VerifySeed checks the signature of the dealer on the seed against the public parameters.
+// It returns an error if the seed is unsigned, was modified or was generated for other parameters.
+// Re-randomized seeds (see ReRandomizeSeed) remain valid, as the re-randomization key is not signed.
+func (p *PCG) VerifySeed(seed *Seed, pp *PublicParameters) error {
+	if pp == nil || len(pp.DealerKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("public parameters hold no valid dealer key")
+	}
+	if pp.ParamsDigest != p.paramsDigest() {
+		return fmt.Errorf("public parameters were generated for different PCG parameters")
+	}
+	if seed.signature == nil {
+		return fmt.Errorf("seed of party %d is not signed", seed.index)
+	}
+
+	keysDigest, err := seed.keysDigest()
+	if err != nil {
+		return fmt.Errorf("failed to hash the DSPF keys: %w", err)
+	}
+	if !ed25519.Verify(pp.DealerKey, seed.authDigest(pp.ParamsDigest, keysDigest), seed.signature) {
+		return fmt.Errorf("invalid dealer signature on seed of party %d", seed.index)
+	}
+	return nil
+}
+
+// Digest returns the SHA-256 digest of the seed, which covers the party specific parts and the DSPF keys.
+// The re-randomization key is not covered (see ReRandomizeSeed).
+func (s *Seed) Digest() ([32]byte, error) {
+	keysDigest, err := s.keysDigest()
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("failed to hash the DSPF keys: %w", err)
+	}
+	return s.contentDigest(keysDigest), nil
+}
+
+// authDigest returns the digest the dealer signs for the seed.
+// It binds the digest of the seed (see Digest) to the parameters.
+func (s *Seed) authDigest(paramsDigest, keysDigest [32]byte) []byte {
+	contentDigest := s.contentDigest(keysDigest)
+	h := sha256.New()
+	h.Write([]byte(seedAuthDomainSeparator))
+	h.Write(paramsDigest[:])
+	h.Write(contentDigest[:])
+	return h.Sum(nil)
+}
+
+// contentDigest returns the digest of the party specific parts of the seed and the digest of the DSPF keys.
+func (s *Seed) contentDigest(keysDigest [32]byte) [32]byte {
+	h := sha256.New()
+	writeInt(h, s.index)
+	writeInt(h, s.skShareIndex)
+	h.Write(s.ski.ToBytes())
+
+	g1 := bls12381.NewG1()
+	writeInt(h, len(s.skCommitments))
+	for _, commitment := range s.skCommitments {
+		h.Write(g1.ToBytes(commitment))
+	}
+
+	for _, exponents := range [][][]*big.Int{s.exponents.aOmega.vectors(), s.exponents.eEta.vectors(), s.exponents.sPhi.vectors()} {
+		writeInt(h, len(exponents))
+		for _, row := range exponents {
+			writeInt(h, len(row))
+			for _, exponent := range row {
+				writeBytes(h, exponent.Bytes())
+			}
+		}
+	}
+	for _, coefficients := range [][][]*bls12381.Fr{s.coefficients.aBeta.vectors(), s.coefficients.eGamma.vectors(), s.coefficients.sEpsilon.vectors()} {
+		writeInt(h, len(coefficients))
+		for _, row := range coefficients {
+			writeInt(h, len(row))
+			for _, coefficient := range row {
+				h.Write(coefficient.ToBytes())
+			}
+		}
+	}
+
+	h.Write(keysDigest[:])
+	var digest [32]byte
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
+
+// keysDigest returns the SHA-256 digest of the serialized DSPF keys U, C and V of the seed.
+func (s *Seed) keysDigest() ([32]byte, error) {
+	if s.U == nil || s.C == nil || s.V == nil {
+		return [32]byte{}, fmt.Errorf("seed holds no DSPF keys")
+	}
+	h := sha256.New()
+	// The keys are written with the lengths of their former nested layout, s.t. the digests of seeds are unchanged
+	for _, keys := range []*DSPFKeyMatrix{s.U, s.C, s.V} {
+		writeInt(h, keys.N())
+		for i := 0; i < keys.N(); i++ {
+			writeInt(h, keys.N())
+			for j := 0; j < keys.N(); j++ {
+				writeInt(h, keys.C())
+				block := keys.Block(i, j)
+				for k := range block {
+					if keys.IsOLE() && k%keys.C() == 0 {
+						writeInt(h, keys.C())
+					}
+					writeInt(h, 1)
+					for _, key := range []dspf.Key{block[k].Key0, block[k].Key1} {
+						data, err := key.SerializeKeys()
+						if err != nil {
+							return [32]byte{}, err
+						}
+						writeBytes(h, data)
+					}
+				}
+			}
+		}
+	}
+
+	var digest [32]byte
+	copy(digest[:], h.Sum(nil))
+	return digest, nil
+}
+
+// writeInt writes the integer as 8 byte big endian to the hash.
+func writeInt(h hash.Hash, i int) {
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+}
+
+// writeBytes writes the length prefixed data to the hash.
+func writeBytes(h hash.Hash, data []byte) {
+	writeInt(h, len(data))
+	h.Write(data)
+}
@@ -0,0 +1,80 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf/optreedpf"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVerifySeed(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+
+	seeds, pp, err := pcg.TrustedSeedGenAuthenticated()
+	assert.Nil(t, err)
+	for _, seed := range seeds {
+		assert.Nil(t, pcg.VerifySeed(seed, pp))
+	}
+
+	// Seeds of other parties are not interchangeable
+	swapped := *seeds[1]
+	swapped.signature = seeds[0].signature
+	assert.NotNil(t, pcg.VerifySeed(&swapped, pp))
+
+	// Unsigned seeds are rejected
+	unsigned, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	assert.NotNil(t, pcg.VerifySeed(unsigned[0], pp))
+
+	// Public parameters of other PCG parameters are rejected
+	other, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+	assert.NotNil(t, other.VerifySeed(seeds[0], pp))
+	assert.NotNil(t, pcg.VerifySeed(seeds[0], nil))
+}
+
+func TestPublicParametersSerialize(t *testing.T) {
+	pcg, err := NewPCG(128, 5, 2, 2, 2, 2)
+	assert.Nil(t, err)
+	seeds, pp, err := pcg.TrustedSeedGenAuthenticated()
+	assert.Nil(t, err)
+
+	data, err := pp.Serialize()
+	assert.Nil(t, err)
+	deserialized := &PublicParameters{}
+	assert.Nil(t, deserialized.Deserialize(data))
+	assert.Equal(t, pp, deserialized)
+	assert.Nil(t, pcg.VerifySeed(seeds[0], deserialized))
+
+	assert.NotNil(t, deserialized.Deserialize(data[:len(data)-1]))
+	seedData, err := seeds[0].Serialize()
+	assert.Nil(t, err)
+	assert.NotNil(t, deserialized.Deserialize(seedData)) // the header identifies the kind of the artifact
+}
+
+func TestVerifySeedDetectsTampering(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+
+	seeds, pp, err := pcg.TrustedSeedGenAuthenticated()
+	assert.Nil(t, err)
+
+	// Tamper with the sk share
+	tampered := *seeds[0]
+	tampered.ski = bls12381.NewFr().Set(seeds[0].ski)
+	tampered.ski.Add(tampered.ski, bls12381.NewFr().One())
+	assert.NotNil(t, pcg.VerifySeed(&tampered, pp))
+
+	// Tamper with a correction word of a DPF key
+	dpfKey := seeds[0].U.At(0, 1, 0).Key0.DPFKeys[0].(*optreedpf.Key)
+	cw := dpfKey.CW[0]
+	original := cw.Tl
+	cw.Tl = !original
+	dpfKey.CW[0] = cw
+	assert.NotNil(t, pcg.VerifySeed(seeds[0], pp))
+
+	cw.Tl = original
+	dpfKey.CW[0] = cw
+	assert.Nil(t, pcg.VerifySeed(seeds[0], pp))
+}
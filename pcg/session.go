@@ -0,0 +1,228 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"math/big"
+	"runtime"
+	"time"
+)
+
+// EvalSession holds the precomputations of Eval that only depend on the public random polynomials and the ring,
+// s.t. they are shared by the evaluations of multiple seeds. This is useful for benchmarks and simulations that
+// evaluate the seeds of all parties sequentially, while a single party simply uses EvalCombined or EvalSeparate.
+// For the divisor of a ring (see Ring.Prepared), the session holds the evaluation domain of the ring along with rand
+// and its outer product in point-value form, s.t. the final shares only transform the polynomials of the seed and
+// multiply pointwise. For custom divisors, it holds the outer product of rand in coefficient form instead.
+// A session must not be used with seeds of another PCG.
+type EvalSession struct {
+	pcg       *PCG
+	rand      []*poly.Polynomial     // rand are the public random polynomials, where rand[c-1] = 1
+	div       *PreparedDivisor       // div is the prepared divisor of the ring
+	ring      *Ring                  // ring is the ring of div, nil for custom divisors
+	domain    *poly.EvaluationDomain // domain is the evaluation domain of ring, nil if the final shares are multiplied via oprand
+	randNTT   []*poly.NTTPolynomial  // randNTT is rand in point-value form over domain
+	oprandNTT []*poly.NTTPolynomial  // oprandNTT is the outer product of rand with itself in point-value form over domain
+	oprand    []*poly.Polynomial     // oprand is the outer product of rand with itself, nil if domain is set
+}
+
+// NewEvalSession validates the random polynomials and precomputes their transforms or outer product for the given
+// ring divisor.
+func (p *PCG) NewEvalSession(rand []*poly.Polynomial, div *PreparedDivisor) (*EvalSession, error) {
+	if div == nil {
+		return nil, fmt.Errorf("the divisor must not be nil")
+	}
+	if err := p.checkRandomPolynomials(rand); err != nil {
+		return nil, err
+	}
+	if err := p.checkMemory("Eval", func(e *MemoryEstimate) int64 { return e.Eval }, "EvalCombinedAt derives few tuples without the products of Eval"); err != nil {
+		return nil, err
+	}
+
+	session := &EvalSession{pcg: p, rand: rand, div: div, ring: div.Ring()}
+	start := time.Now()
+	if session.ring != nil && div.Cyclotomic() {
+		if err := session.prepareDomain(); err != nil {
+			return nil, err
+		}
+		p.logger.Debugf("Precomputed transforms of rand (in s): %v", time.Since(start).Seconds())
+		return session, nil
+	}
+
+	oprand, err := outerProductPoly(rand, rand)
+	if err != nil {
+		return nil, err
+	}
+	session.oprand = oprand
+	p.logger.Debugf("Precomputed outer product of rand (in s): %v", time.Since(start).Seconds())
+	return session, nil
+}
+
+// prepareDomain precomputes the evaluation domain of the ring and the point-value forms of rand and its outer product.
+// The outer product is computed pointwise, i.e. without any multiplication of polynomials.
+func (s *EvalSession) prepareDomain() error {
+	domain, err := s.ring.EvaluationDomain()
+	if err != nil {
+		return err
+	}
+	c := len(s.rand)
+	randNTT := make([]*poly.NTTPolynomial, c)
+	_ = parallelFor(c, runtime.NumCPU(), func(r int) error {
+		randNTT[r] = domain.NewNTTPolynomial(s.rand[r])
+		return nil
+	})
+	oprandNTT := make([]*poly.NTTPolynomial, c*c)
+	for i := range oprandNTT {
+		oprandNTT[i] = randNTT[i/c].DeepCopy()
+		if err := oprandNTT[i].Mul(randNTT[i%c]); err != nil {
+			return err
+		}
+	}
+	s.domain, s.randNTT, s.oprandNTT = domain, randNTT, oprandNTT
+	return nil
+}
+
+// finalShare evaluates the inner product of u with rand modulo the divisor (see evalFinalShare). With the domain of
+// the ring, each polynomial of u is transformed once and multiplied pointwise with the transform of rand, and the sum
+// is transformed back once.
+func (s *EvalSession) finalShare(u []*poly.Polynomial) (*poly.Polynomial, error) {
+	if s.domain == nil {
+		return s.pcg.evalFinalShare(u, s.rand, s.div)
+	}
+	sum, err := s.finalShareNTT(u)
+	if err != nil {
+		return nil, err
+	}
+	return sum.Coefficients(), nil
+}
+
+// finalShareDense evaluates the final share like finalShare, but returns it as a dense coefficient vector of length
+// 2^N. With the domain of the ring, the vector is the inverse transform of the sum, s.t. no polynomial map is built.
+func (s *EvalSession) finalShareDense(u []*poly.Polynomial) ([]*bls12381.Fr, error) {
+	if s.domain == nil {
+		share, err := s.pcg.evalFinalShare(u, s.rand, s.div)
+		if err != nil {
+			return nil, err
+		}
+		return share.Dense(int(s.pcg.domain.Int64()))
+	}
+	sum, err := s.finalShareNTT(u)
+	if err != nil {
+		return nil, err
+	}
+	return sum.Dense(), nil
+}
+
+// finalShareNTT returns the inner product of u with rand in point-value form over the domain of the ring.
+func (s *EvalSession) finalShareNTT(u []*poly.Polynomial) (*poly.NTTPolynomial, error) {
+	products := make([]*poly.NTTPolynomial, s.pcg.c)
+	err := parallelFor(len(products), runtime.NumCPU(), func(r int) error {
+		products[r] = s.domain.NewNTTPolynomial(u[r])
+		if err := products[r].Mul(s.randNTT[r]); err != nil {
+			return &coordinateError{counterparty: -1, r: r, s: -1, err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sumNTT(products)
+}
+
+// finalShare2D evaluates the inner product of w with the outer product of rand modulo the divisor
+// (see evalFinalShare2D). With the domain of the ring, it works like finalShare.
+func (s *EvalSession) finalShare2D(w [][]*poly.Polynomial) (*poly.Polynomial, error) {
+	if s.domain == nil {
+		return s.pcg.evalFinalShare2D(w, s.oprand, s.div)
+	}
+	sum, err := s.finalShare2DNTT(w)
+	if err != nil {
+		return nil, err
+	}
+	return sum.Coefficients(), nil
+}
+
+// finalShare2DDense evaluates the final share like finalShare2D, but returns it as a dense coefficient vector of
+// length 2^N (see finalShareDense).
+func (s *EvalSession) finalShare2DDense(w [][]*poly.Polynomial) ([]*bls12381.Fr, error) {
+	if s.domain == nil {
+		share, err := s.pcg.evalFinalShare2D(w, s.oprand, s.div)
+		if err != nil {
+			return nil, err
+		}
+		return share.Dense(int(s.pcg.domain.Int64()))
+	}
+	sum, err := s.finalShare2DNTT(w)
+	if err != nil {
+		return nil, err
+	}
+	return sum.Dense(), nil
+}
+
+// finalShare2DNTT returns the inner product of w with the outer product of rand in point-value form over the domain
+// of the ring.
+func (s *EvalSession) finalShare2DNTT(w [][]*poly.Polynomial) (*poly.NTTPolynomial, error) {
+	c := s.pcg.c
+	products := make([]*poly.NTTPolynomial, c*c)
+	err := parallelFor(len(products), runtime.NumCPU(), func(i int) error {
+		products[i] = s.domain.NewNTTPolynomial(w[i/c][i%c])
+		if i == len(products)-1 {
+			return nil // The last entry of the outer product is 1
+		}
+		if err := products[i].Mul(s.oprandNTT[i]); err != nil {
+			return &coordinateError{counterparty: -1, r: i / c, s: i % c, err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sumNTT(products)
+}
+
+// sumNTT sums the polynomials in index order. The first polynomial is modified and returned.
+func sumNTT(products []*poly.NTTPolynomial) (*poly.NTTPolynomial, error) {
+	sum := products[0]
+	for _, product := range products[1:] {
+		if err := sum.Add(product); err != nil {
+			return nil, err
+		}
+	}
+	return sum, nil
+}
+
+// checkRandomPolynomials checks that rand holds c polynomials, the last of which is 1.
+func (p *PCG) checkRandomPolynomials(rand []*poly.Polynomial) error {
+	if len(rand) != p.c {
+		return fmt.Errorf("rand must hold c=%d polynomials but contains %d", p.c, len(rand))
+	}
+	one, _ := poly.NewSparse([]*bls12381.Fr{bls12381.NewFr().FromBytes(big.NewInt(1).Bytes())}, []*big.Int{big.NewInt(0)}) // = 1
+	if !rand[p.c-1].Equal(one) {
+		return fmt.Errorf("rand must be a slice of polynomials with polynomial of the the last index rand[c-1] equal to 1")
+	}
+	return nil
+}
+
+// EvalSeed evaluates the seed for an n-out-of-n setting (see EvalCombined).
+func (s *EvalSession) EvalSeed(seed *Seed, opts ...EvalOption) (*BBSPlusTupleGenerator, error) {
+	if s.pcg.tau != s.pcg.n {
+		return nil, fmt.Errorf("EvalSeed can only be used for an n-out-of-n setting")
+	}
+	return s.pcg.evalCombined(seed, s, newEvalOptions(opts))
+}
+
+// EvalSeedSeparate evaluates the seed for a tau-out-of-n setting (see EvalSeparate).
+func (s *EvalSession) EvalSeedSeparate(seed *Seed, opts ...EvalOption) (*SeparateBBSPlusTupleGenerator, error) {
+	return s.pcg.evalSeparate(seed, s, nil, newEvalOptions(opts))
+}
+
+// EvalSeedSeparateForSigners evaluates the seed for a tau-out-of-n setting, restricted to the given signer set
+// (see EvalSeparateForSigners).
+func (s *EvalSession) EvalSeedSeparateForSigners(seed *Seed, signerSet []int, opts ...EvalOption) (*SeparateBBSPlusTupleGenerator, error) {
+	counterparties, err := s.pcg.counterparties(seed.index, signerSet)
+	if err != nil {
+		return nil, err
+	}
+	return s.pcg.evalSeparate(seed, s, counterparties, newEvalOptions(opts))
+}
@@ -0,0 +1,150 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEvalSessionMatchesEvalCombined(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	session, err := pcg.NewEvalSession(randPolys, ring.Prepared())
+	assert.Nil(t, err)
+
+	a := bls12381.NewFr()
+	s := bls12381.NewFr()
+	alpha := bls12381.NewFr()
+	for _, seed := range seeds {
+		gen, err := session.EvalSeed(seed)
+		assert.Nil(t, err)
+		expectedGen, err := pcg.EvalCombined(seed, randPolys, ring.Prepared())
+		assert.Nil(t, err)
+
+		tuple, err := gen.GenBBSPlusTupleAt(ring, 5)
+		assert.Nil(t, err)
+		expected, err := expectedGen.GenBBSPlusTupleAt(ring, 5)
+		assert.Nil(t, err)
+		assert.True(t, tuple.AShare.Equal(expected.AShare))
+		assert.True(t, tuple.AlphaShare.Equal(expected.AlphaShare))
+		assert.True(t, tuple.DeltaShare.Equal(expected.DeltaShare))
+
+		a.Add(a, tuple.AShare)
+		s.Add(s, tuple.SShare)
+		alpha.Add(alpha, tuple.AlphaShare)
+	}
+
+	as := bls12381.NewFr()
+	as.Mul(a, s)
+	assert.True(t, as.Equal(alpha))
+
+	// EvalSeed is restricted to the n-out-of-n setting
+	separatePcg, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+	separateSession, err := separatePcg.NewEvalSession(randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	_, err = separateSession.EvalSeed(seeds[0])
+	assert.NotNil(t, err)
+}
+
+func TestNewEvalSessionInvalidRand(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+
+	_, err = pcg.NewEvalSession(randPolys[:1], ring.Prepared())
+	assert.NotNil(t, err)
+	_, err = pcg.NewEvalSession([]*poly.Polynomial{randPolys[0], randPolys[0]}, ring.Prepared())
+	assert.NotNil(t, err)
+}
+
+func TestEvalSessionDomainMatchesCustomDivisor(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 3, 4)
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	div, err := NewPreparedDivisor(ring.Div)
+	assert.Nil(t, err)
+
+	// The session of the ring multiplies in its evaluation domain, the one of the custom divisor in coefficient form
+	session, err := pcg.NewEvalSession(randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	assert.NotNil(t, session.domain)
+	assert.Nil(t, session.oprand)
+	customSession, err := pcg.NewEvalSession(randPolys, div)
+	assert.Nil(t, err)
+	assert.Nil(t, customSession.domain)
+
+	signerSet := []int{0, 2}
+	gen, err := session.EvalSeedSeparate(seeds[0])
+	assert.Nil(t, err)
+	expectedGen, err := customSession.EvalSeedSeparate(seeds[0])
+	assert.Nil(t, err)
+	for _, index := range []int{0, 5, ring.Size() - 1} {
+		tuple, err := gen.GenBBSPlusTupleAt(ring, index, signerSet)
+		assert.Nil(t, err)
+		expected, err := expectedGen.GenBBSPlusTupleAt(ring, index, signerSet)
+		assert.Nil(t, err)
+		assert.True(t, tuple.AShare.Equal(expected.AShare))
+		assert.True(t, tuple.EShare.Equal(expected.EShare))
+		assert.True(t, tuple.SShare.Equal(expected.SShare))
+		assert.True(t, tuple.AlphaShare.Equal(expected.AlphaShare))
+		assert.True(t, tuple.DeltaShare.Equal(expected.DeltaShare))
+	}
+}
+
+func BenchmarkFinalShares(b *testing.B) {
+	for _, N := range []int{10, 12, 14} {
+		pcg, err := NewPCG(128, N, 2, 2, 4, 16)
+		assert.Nil(b, err)
+		randPolys, err := pcg.PickRandomPolynomials()
+		assert.Nil(b, err)
+		ring, err := pcg.GetLazyRing()
+		assert.Nil(b, err)
+		w := make([][]*poly.Polynomial, pcg.c)
+		for r := range w {
+			w[r] = randPolys
+		}
+
+		// Without a session, each evaluation computes the outer product and multiplies in coefficient form
+		b.Run(fmt.Sprintf("N=%d/WithoutSession", N), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				oprand, err := outerProductPoly(randPolys, randPolys)
+				assert.Nil(b, err)
+				_, err = pcg.evalFinalShare(randPolys, randPolys, ring.Prepared())
+				assert.Nil(b, err)
+				_, err = pcg.evalFinalShare2D(w, oprand, ring.Prepared())
+				assert.Nil(b, err)
+			}
+		})
+		b.Run(fmt.Sprintf("N=%d/WithSession", N), func(b *testing.B) {
+			session, err := pcg.NewEvalSession(randPolys, ring.Prepared())
+			assert.Nil(b, err)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err = session.finalShare(randPolys)
+				assert.Nil(b, err)
+				_, err = session.finalShare2D(w)
+				assert.Nil(b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package sharing
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"io"
+)
+
+// Contribution is the contribution of a party to the distributed key generation (DKG) of a t-out-of-n sharing of a
+// random secret, i.e. a Feldman sharing of a random value. The party sends Shares[j] privately to party j and
+// broadcasts the Commitments. The shared secret is the sum of the values of all contributions, hence it is unknown to
+// any party (and any dealer) unless all contributors collude.
+// Note that this is the plain Feldman DKG (Pedersen) without complaint round, i.e. parties abort on invalid shares,
+// and the last contributor can bias the public key.
+type Contribution struct {
+	Shares      []*bls12381.Fr      // Shares[j] is the share for the party with index j.
+	Commitments []*bls12381.PointG1 // Commitments are the Feldman commitments to the contributed sharing.
+}
+
+// NewContribution samples the contribution of a party to the DKG of a t-out-of-n sharing.
+func NewContribution(rng io.Reader, t, n int) (*Contribution, error) {
+	_, shares, commitments, err := ShareWithCommitments(rng, nil, t, n)
+	if err != nil {
+		return nil, err
+	}
+	return &Contribution{Shares: shares, Commitments: commitments}, nil
+}
+
+// CombineContributions verifies the shares the party with the given index received from all contributors against
+// their commitments, i.e. shares[k] and commitments[k] stem from the k-th contributor. It returns the share of the
+// party of the combined sharing and the commitments to the combined sharing, whose first entry is g1^sk.
+func CombineContributions(index int, shares []*bls12381.Fr, commitments [][]*bls12381.PointG1) (*bls12381.Fr, []*bls12381.PointG1, error) {
+	if len(shares) == 0 {
+		return nil, nil, fmt.Errorf("no contributions given")
+	}
+	if len(shares) != len(commitments) {
+		return nil, nil, fmt.Errorf("got %d shares but %d commitments", len(shares), len(commitments))
+	}
+
+	share := bls12381.NewFr()
+	combined := commitments[0]
+	for k := range shares {
+		if err := VerifyShare(shares[k], index, commitments[k]); err != nil {
+			return nil, nil, fmt.Errorf("contribution %d: %w", k, err)
+		}
+		share.Add(share, shares[k])
+		if k > 0 {
+			var err error
+			if combined, err = CombineCommitments(combined, commitments[k]); err != nil {
+				return nil, nil, fmt.Errorf("contribution %d: %w", k, err)
+			}
+		}
+	}
+	return share, combined, nil
+}
@@ -0,0 +1,47 @@
+package sharing
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestDKG(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	contributions := make([]*Contribution, 4)
+	for k := range contributions {
+		var err error
+		contributions[k], err = NewContribution(rng, 3, 4)
+		assert.Nil(t, err)
+	}
+
+	shares := make([]*bls12381.Fr, 4)
+	var combined []*bls12381.PointG1
+	for i := range shares {
+		received := make([]*bls12381.Fr, len(contributions))
+		commitments := make([][]*bls12381.PointG1, len(contributions))
+		for k, contribution := range contributions {
+			received[k], commitments[k] = contribution.Shares[i], contribution.Commitments
+		}
+		var err error
+		shares[i], combined, err = CombineContributions(i, received, commitments)
+		assert.Nil(t, err)
+		assert.Nil(t, VerifyShare(shares[i], i, combined))
+	}
+
+	// The secret is shared 3-out-of-4 and its public key is the first combined commitment
+	secret, err := Reconstruct(shares[1:], []int{1, 2, 3})
+	assert.Nil(t, err)
+	g1 := bls12381.NewG1()
+	pk := g1.New()
+	g1.MulScalar(pk, g1.One(), secret)
+	assert.True(t, g1.Equal(pk, combined[0]))
+
+	// A contributor sending an inconsistent share is detected
+	received := []*bls12381.Fr{contributions[0].Shares[0], bls12381.NewFr().One()}
+	_, _, err = CombineContributions(0, received, [][]*bls12381.PointG1{contributions[0].Commitments, contributions[1].Commitments})
+	assert.NotNil(t, err)
+	_, _, err = CombineContributions(0, received, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,70 @@
+package sharing
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// commit returns the Feldman commitments C_k = g1^(coefficient_k) to the coefficients of a sharing polynomial.
+func commit(coefficients []*bls12381.Fr) []*bls12381.PointG1 {
+	g1 := bls12381.NewG1()
+	commitments := make([]*bls12381.PointG1, len(coefficients))
+	for k, coefficient := range coefficients {
+		commitments[k] = g1.New()
+		g1.MulScalar(commitments[k], g1.One(), coefficient)
+	}
+	return commitments
+}
+
+// VerifyShare checks the share of the party with the given index against the Feldman commitments of the dealer,
+// i.e. it checks that g1^share = prod_k C_k^(x^k) where x is the evaluation point of the party.
+func VerifyShare(share *bls12381.Fr, index int, commitments []*bls12381.PointG1) error {
+	if share == nil {
+		return fmt.Errorf("share must not be nil")
+	}
+	if len(commitments) == 0 {
+		return fmt.Errorf("no commitments given")
+	}
+	if index < 0 {
+		return fmt.Errorf("party index %d must not be negative", index)
+	}
+
+	g1 := bls12381.NewG1()
+	x := EvaluationPoint(index)
+	xPow := bls12381.NewFr().One()
+	expected := g1.Zero()
+	tmp := g1.New()
+	for _, commitment := range commitments {
+		if commitment == nil {
+			return fmt.Errorf("commitment must not be nil")
+		}
+		g1.MulScalar(tmp, commitment, xPow)
+		g1.Add(expected, expected, tmp)
+		xPow.Mul(xPow, x)
+	}
+
+	actual := g1.New()
+	g1.MulScalar(actual, g1.One(), share)
+	if !g1.Equal(expected, actual) {
+		return fmt.Errorf("share of party %d is inconsistent with the commitments", index)
+	}
+	return nil
+}
+
+// CombineCommitments returns the commitments to the sum of the sharings committed to by a and b, e.g. to update the
+// commitments of a sharing with the commitments to the sharing of zero of a refresh (see Refresh).
+func CombineCommitments(a, b []*bls12381.PointG1) ([]*bls12381.PointG1, error) {
+	if len(a) != len(b) {
+		return nil, fmt.Errorf("commitments of sharings with different thresholds %d and %d", len(a), len(b))
+	}
+	g1 := bls12381.NewG1()
+	combined := make([]*bls12381.PointG1, len(a))
+	for k := range a {
+		if a[k] == nil || b[k] == nil {
+			return nil, fmt.Errorf("commitment must not be nil")
+		}
+		combined[k] = g1.New()
+		g1.Add(combined[k], a[k], b[k])
+	}
+	return combined, nil
+}
@@ -0,0 +1,57 @@
+package sharing
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestFeldmanVerifyShare(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	secret, shares, commitments, err := ShareWithCommitments(rng, nil, 3, 5)
+	assert.Nil(t, err)
+	assert.Len(t, commitments, 3)
+
+	for i, share := range shares {
+		assert.Nil(t, VerifyShare(share, i, commitments))
+	}
+
+	// A share does not verify for another party
+	assert.NotNil(t, VerifyShare(shares[0], 1, commitments))
+
+	// A tampered share does not verify
+	tampered := bls12381.NewFr()
+	tampered.Add(shares[2], bls12381.NewFr().One())
+	assert.NotNil(t, VerifyShare(tampered, 2, commitments))
+
+	// The first commitment is the public key of the secret
+	g1 := bls12381.NewG1()
+	pk := g1.New()
+	g1.MulScalar(pk, g1.One(), secret)
+	assert.True(t, g1.Equal(pk, commitments[0]))
+
+	assert.NotNil(t, VerifyShare(shares[0], 0, nil))
+	assert.NotNil(t, VerifyShare(nil, 0, commitments))
+	assert.NotNil(t, VerifyShare(shares[0], -1, commitments))
+}
+
+func TestCombineCommitments(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	_, shares, commitments, err := ShareWithCommitments(rng, nil, 3, 5)
+	assert.Nil(t, err)
+
+	refreshed, zeroCommitments, err := Refresh(rng, shares, 3)
+	assert.Nil(t, err)
+	combined, err := CombineCommitments(commitments, zeroCommitments)
+	assert.Nil(t, err)
+	for i, share := range refreshed {
+		assert.Nil(t, VerifyShare(share, i, combined))
+		assert.NotNil(t, VerifyShare(share, i, commitments))
+	}
+	// The public key of the secret is unchanged
+	assert.True(t, bls12381.NewG1().Equal(commitments[0], combined[0]))
+
+	_, err = CombineCommitments(commitments, zeroCommitments[:2])
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,148 @@
+package sharing
+
+import (
+	"container/list"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// lagrangeCacheSize is the maximal amount of signer sets whose lagrange coefficients are cached.
+const lagrangeCacheSize = 1024
+
+// lagrangeCache caches the lagrange coefficients at zero of the most recently used signer sets.
+// The coefficients only depend on the signer set, which typically stays the same for many tuple derivations.
+var lagrangeCache = newCoefficientCache(lagrangeCacheSize)
+
+// coefficientCache is a least recently used cache of coefficients by key, which holds at most size entries.
+type coefficientCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List               // order holds the entries from the most to the least recently used
+	entries map[string]*list.Element // entries maps each key to its element in order
+}
+
+// coefficientCacheEntry is an element of the order of a coefficientCache.
+type coefficientCacheEntry struct {
+	key          string
+	coefficients []*bls12381.Fr
+}
+
+// newCoefficientCache returns an empty cache that holds at most size entries.
+func newCoefficientCache(size int) *coefficientCache {
+	return &coefficientCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// get returns the cached coefficients of the key and marks them as most recently used.
+func (c *coefficientCache) get(key string) ([]*bls12381.Fr, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*coefficientCacheEntry).coefficients, true
+}
+
+// put caches the coefficients of the key and evicts the least recently used entry if the cache is full.
+func (c *coefficientCache) put(key string, coefficients []*bls12381.Fr) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*coefficientCacheEntry).coefficients = coefficients
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&coefficientCacheEntry{key: key, coefficients: coefficients})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*coefficientCacheEntry).key)
+	}
+}
+
+// len returns the amount of cached entries.
+func (c *coefficientCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// LagrangeCoefficientsAtZero returns the lagrange coefficients of all parties of the signer set (given by their
+// shamir share indices) for interpolating the sharing polynomial at zero. The i-th coefficient belongs to indices[i].
+// The numerators share prefix and suffix products and all denominators are inverted with a single batched inversion.
+// Results of the most recently used signer sets are cached (see lagrangeCacheSize). The returned coefficients must not be modified.
+func LagrangeCoefficientsAtZero(indices []int) ([]*bls12381.Fr, error) {
+	key := lagrangeCacheKey(indices)
+	if cached, ok := lagrangeCache.get(key); ok {
+		return cached, nil
+	}
+
+	n := len(indices)
+	if n == 0 {
+		return nil, fmt.Errorf("signer set must not be empty")
+	}
+	xs := make([]*bls12381.Fr, n)
+	for i, index := range indices {
+		if index < 0 {
+			return nil, fmt.Errorf("party index %d must not be negative", index)
+		}
+		xs[i] = EvaluationPoint(index)
+	}
+
+	// Numerators prod_{j != i} x_j via prefix and suffix products
+	prefix := make([]*bls12381.Fr, n+1)
+	suffix := make([]*bls12381.Fr, n+1)
+	prefix[0] = bls12381.NewFr().One()
+	suffix[n] = bls12381.NewFr().One()
+	for i := 0; i < n; i++ {
+		prefix[i+1] = bls12381.NewFr()
+		prefix[i+1].Mul(prefix[i], xs[i])
+		suffix[n-i-1] = bls12381.NewFr()
+		suffix[n-i-1].Mul(suffix[n-i], xs[n-i-1])
+	}
+
+	// Denominators prod_{j != i} (x_j - x_i)
+	denominators := make([]*bls12381.Fr, n)
+	diff := bls12381.NewFr()
+	for i := 0; i < n; i++ {
+		denominators[i] = bls12381.NewFr().One()
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+			diff.Sub(xs[j], xs[i])
+			if diff.IsZero() {
+				return nil, fmt.Errorf("duplicate index %d", indices[i])
+			}
+			denominators[i].Mul(denominators[i], diff)
+		}
+	}
+	inverses, err := poly.BatchInverse(denominators)
+	if err != nil {
+		return nil, err
+	}
+
+	coefficients := make([]*bls12381.Fr, n)
+	for i := 0; i < n; i++ {
+		coefficients[i] = bls12381.NewFr()
+		coefficients[i].Mul(prefix[i], suffix[i+1])
+		coefficients[i].Mul(coefficients[i], inverses[i])
+	}
+
+	lagrangeCache.put(key, coefficients)
+	return coefficients, nil
+}
+
+// lagrangeCacheKey returns the cache key of the signer set.
+func lagrangeCacheKey(indices []int) string {
+	parts := make([]string, len(indices))
+	for i, index := range indices {
+		parts[i] = strconv.Itoa(index)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,63 @@
+package sharing
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestLagrangeCoefficientsAtZero(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	secret, shares, err := Share(rng, nil, 4, 7)
+	assert.Nil(t, err)
+
+	for _, signerSet := range [][]int{{0, 1, 2, 3}, {6, 3, 1, 0}, {0, 1, 2, 3, 4, 5, 6}} {
+		lambdas, err := LagrangeCoefficientsAtZero(signerSet)
+		assert.Nil(t, err)
+		assert.Len(t, lambdas, len(signerSet))
+
+		interpolated := bls12381.NewFr().Zero()
+		for i, signer := range signerSet {
+			tmp := bls12381.NewFr()
+			tmp.Mul(lambdas[i], shares[signer])
+			interpolated.Add(interpolated, tmp)
+		}
+		assert.True(t, secret.Equal(interpolated))
+
+		// Subsequent calls are served from the cache
+		cached, err := LagrangeCoefficientsAtZero(signerSet)
+		assert.Nil(t, err)
+		assert.Same(t, lambdas[0], cached[0])
+	}
+
+	_, err = LagrangeCoefficientsAtZero([]int{1, 2, 1})
+	assert.NotNil(t, err)
+	_, err = LagrangeCoefficientsAtZero([]int{})
+	assert.NotNil(t, err)
+}
+
+func TestCoefficientCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newCoefficientCache(2)
+	one := []*bls12381.Fr{bls12381.NewFr().One()}
+	cache.put("a", one)
+	cache.put("b", one)
+	_, ok := cache.get("a") // b is now the least recently used entry
+	assert.True(t, ok)
+	cache.put("c", one)
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	for _, key := range []string{"a", "c"} {
+		cached, ok := cache.get(key)
+		assert.True(t, ok)
+		assert.Same(t, one[0], cached[0])
+	}
+
+	// The cache of LagrangeCoefficientsAtZero stays bounded for many signer sets
+	for i := 0; i < lagrangeCacheSize+10; i++ {
+		_, err := LagrangeCoefficientsAtZero([]int{i, i + 1})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, lagrangeCacheSize, lagrangeCache.len())
+}
@@ -0,0 +1,199 @@
+package sharing
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"io"
+)
+
+// Scheme is a secret sharing of the sk term of the PCG among n parties. The PCG selects the scheme by its parameters
+// (see SchemeFor), s.t. the seed generation, the verification of the shares and the recombination of the tuples of a
+// signer set agree on how the shares combine to sk.
+type Scheme interface {
+	// Share shares the secret among the n parties. If secret is nil, a random secret is shared. It returns the secret,
+	// the shares of the n parties and the commitments to the sharing, against which each party can check its share.
+	Share(rng io.Reader, secret *bls12381.Fr) (*bls12381.Fr, []*bls12381.Fr, []*bls12381.PointG1, error)
+	// Verify checks the share of the party with the given index against the commitments to the sharing.
+	Verify(share *bls12381.Fr, index int, commitments []*bls12381.PointG1) error
+	// PublicKey returns g1^secret for the secret committed to by the commitments.
+	PublicKey(commitments []*bls12381.PointG1) (*bls12381.PointG1, error)
+	// Recombine returns the secret of the shares of the parties with the given indices.
+	Recombine(shares []*bls12381.Fr, indices []int) (*bls12381.Fr, error)
+	// LocalWeights returns the weights of the parties of the signer set, s.t. the secret is the sum of their weighted
+	// shares. The i-th weight belongs to signerSet[i]. The returned weights must not be modified.
+	LocalWeights(signerSet []int) ([]*bls12381.Fr, error)
+}
+
+// SchemeFor returns the scheme of a t-out-of-n sharing: Additive if t = n, as all parties have to participate anyway
+// and the shares simply add up to the secret, and Shamir otherwise.
+func SchemeFor(t, n int) Scheme {
+	if t == n {
+		return Additive{N: n}
+	}
+	return Shamir{T: t, N: n}
+}
+
+// Shamir is the t-out-of-n Shamir sharing with Feldman commitments (see ShareWithCommitments), whose secret any signer
+// set of at least t parties interpolates via lagrange coefficients.
+type Shamir struct {
+	T, N int
+}
+
+// Share implements Scheme.
+func (s Shamir) Share(rng io.Reader, secret *bls12381.Fr) (*bls12381.Fr, []*bls12381.Fr, []*bls12381.PointG1, error) {
+	return ShareWithCommitments(rng, secret, s.T, s.N)
+}
+
+// Verify implements Scheme (see VerifyShare).
+func (s Shamir) Verify(share *bls12381.Fr, index int, commitments []*bls12381.PointG1) error {
+	return VerifyShare(share, index, commitments)
+}
+
+// PublicKey implements Scheme. It returns C_0, the commitment to the constant coefficient.
+func (s Shamir) PublicKey(commitments []*bls12381.PointG1) (*bls12381.PointG1, error) {
+	if len(commitments) == 0 || commitments[0] == nil {
+		return nil, fmt.Errorf("no commitments given")
+	}
+	return bls12381.NewG1().New().Set(commitments[0]), nil
+}
+
+// Recombine implements Scheme.
+func (s Shamir) Recombine(shares []*bls12381.Fr, indices []int) (*bls12381.Fr, error) {
+	return recombine(s, shares, indices)
+}
+
+// LocalWeights implements Scheme. The weights are the lagrange coefficients at zero of the signer set, which must
+// hold at least t parties.
+func (s Shamir) LocalWeights(signerSet []int) ([]*bls12381.Fr, error) {
+	if len(signerSet) < s.T {
+		return nil, fmt.Errorf("signer set of %d parties cannot recombine a %d-out-of-%d sharing", len(signerSet), s.T, s.N)
+	}
+	if err := checkSignerSet(signerSet, s.N); err != nil {
+		return nil, err
+	}
+	return LagrangeCoefficientsAtZero(signerSet)
+}
+
+// Additive is the n-out-of-n additive sharing, whose secret is the sum of the shares of all parties. Its commitments
+// are the commitments g1^share to the shares of the parties, hence g1^secret is their sum.
+type Additive struct {
+	N int
+}
+
+// Share implements Scheme.
+func (a Additive) Share(rng io.Reader, secret *bls12381.Fr) (*bls12381.Fr, []*bls12381.Fr, []*bls12381.PointG1, error) {
+	if a.N < 1 {
+		return nil, nil, nil, fmt.Errorf("amount of parties must be positive but is %d", a.N)
+	}
+	shares := make([]*bls12381.Fr, a.N)
+	sum := bls12381.NewFr()
+	for i := range shares {
+		share, err := bls12381.NewFr().Rand(rng)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to sample share: %w", err)
+		}
+		shares[i] = share
+		sum.Add(sum, share)
+	}
+	if secret != nil {
+		// Shift the last share, s.t. the shares add up to the secret
+		diff := bls12381.NewFr()
+		diff.Sub(secret, sum)
+		shares[a.N-1].Add(shares[a.N-1], diff)
+		sum.Set(secret)
+	}
+	return sum, shares, commit(shares), nil
+}
+
+// Verify implements Scheme. It checks that g1^share is the commitment to the share of the party.
+func (a Additive) Verify(share *bls12381.Fr, index int, commitments []*bls12381.PointG1) error {
+	if share == nil {
+		return fmt.Errorf("share must not be nil")
+	}
+	if len(commitments) != a.N {
+		return fmt.Errorf("got %d commitments but n=%d are expected", len(commitments), a.N)
+	}
+	if index < 0 || index >= a.N {
+		return fmt.Errorf("party index %d is out of range [0, %d)", index, a.N)
+	}
+	if commitments[index] == nil {
+		return fmt.Errorf("commitment must not be nil")
+	}
+	g1 := bls12381.NewG1()
+	if !g1.Equal(commitments[index], g1.MulScalar(g1.New(), g1.One(), share)) {
+		return fmt.Errorf("share of party %d is inconsistent with the commitments", index)
+	}
+	return nil
+}
+
+// PublicKey implements Scheme. It returns the sum of the commitments to the shares.
+func (a Additive) PublicKey(commitments []*bls12381.PointG1) (*bls12381.PointG1, error) {
+	if len(commitments) != a.N {
+		return nil, fmt.Errorf("got %d commitments but n=%d are expected", len(commitments), a.N)
+	}
+	g1 := bls12381.NewG1()
+	pk := g1.Zero()
+	for _, commitment := range commitments {
+		if commitment == nil {
+			return nil, fmt.Errorf("commitment must not be nil")
+		}
+		g1.Add(pk, pk, commitment)
+	}
+	return pk, nil
+}
+
+// Recombine implements Scheme.
+func (a Additive) Recombine(shares []*bls12381.Fr, indices []int) (*bls12381.Fr, error) {
+	return recombine(a, shares, indices)
+}
+
+// LocalWeights implements Scheme. All n parties must participate, each with weight one.
+func (a Additive) LocalWeights(signerSet []int) ([]*bls12381.Fr, error) {
+	if len(signerSet) != a.N {
+		return nil, fmt.Errorf("signer set of %d parties cannot recombine an additive sharing among %d parties", len(signerSet), a.N)
+	}
+	if err := checkSignerSet(signerSet, a.N); err != nil {
+		return nil, err
+	}
+	weights := make([]*bls12381.Fr, len(signerSet))
+	for i := range weights {
+		weights[i] = bls12381.NewFr().One()
+	}
+	return weights, nil
+}
+
+// recombine returns the sum of the shares weighted by the local weights of the scheme.
+func recombine(scheme Scheme, shares []*bls12381.Fr, indices []int) (*bls12381.Fr, error) {
+	if len(shares) != len(indices) {
+		return nil, fmt.Errorf("got %d shares but %d indices", len(shares), len(indices))
+	}
+	weights, err := scheme.LocalWeights(indices)
+	if err != nil {
+		return nil, err
+	}
+	secret := bls12381.NewFr()
+	tmp := bls12381.NewFr()
+	for i, share := range shares {
+		if share == nil {
+			return nil, fmt.Errorf("share of party %d must not be nil", indices[i])
+		}
+		tmp.Mul(weights[i], share)
+		secret.Add(secret, tmp)
+	}
+	return secret, nil
+}
+
+// checkSignerSet checks that the signer set holds distinct parties in [0, n).
+func checkSignerSet(signerSet []int, n int) error {
+	seen := make(map[int]bool, len(signerSet))
+	for _, signer := range signerSet {
+		if signer < 0 || signer >= n {
+			return fmt.Errorf("party index %d is out of range [0, %d)", signer, n)
+		}
+		if seen[signer] {
+			return fmt.Errorf("duplicate index %d", signer)
+		}
+		seen[signer] = true
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package sharing
+
+import (
+	"crypto/rand"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSchemeFor(t *testing.T) {
+	assert.Equal(t, Additive{N: 3}, SchemeFor(3, 3))
+	assert.Equal(t, Shamir{T: 2, N: 3}, SchemeFor(2, 3))
+}
+
+func TestSchemesRecombine(t *testing.T) {
+	g1 := bls12381.NewG1()
+	for _, scheme := range []Scheme{Additive{N: 4}, Shamir{T: 3, N: 4}} {
+		secret, err := bls12381.NewFr().Rand(rand.Reader)
+		assert.Nil(t, err)
+		shared, shares, commitments, err := scheme.Share(rand.Reader, secret)
+		assert.Nil(t, err)
+		assert.True(t, shared.Equal(secret))
+
+		for i, share := range shares {
+			assert.Nil(t, scheme.Verify(share, i, commitments), "%T: share %d", scheme, i)
+		}
+		assert.NotNil(t, scheme.Verify(bls12381.NewFr().One(), 1, commitments))
+
+		pk, err := scheme.PublicKey(commitments)
+		assert.Nil(t, err)
+		assert.True(t, g1.Equal(pk, g1.MulScalar(g1.New(), g1.One(), secret)), "%T", scheme)
+
+		recombined, err := scheme.Recombine([]*bls12381.Fr{shares[3], shares[1], shares[2], shares[0]}, []int{3, 1, 2, 0})
+		assert.Nil(t, err)
+		assert.True(t, recombined.Equal(secret), "%T", scheme)
+	}
+}
+
+func TestSchemesRandomSecret(t *testing.T) {
+	for _, scheme := range []Scheme{Additive{N: 3}, Shamir{T: 2, N: 3}} {
+		secret, shares, _, err := scheme.Share(rand.Reader, nil)
+		assert.Nil(t, err)
+		recombined, err := scheme.Recombine(shares, []int{0, 1, 2})
+		assert.Nil(t, err)
+		assert.True(t, recombined.Equal(secret), "%T", scheme)
+	}
+}
+
+func TestSchemesLocalWeights(t *testing.T) {
+	weights, err := Additive{N: 3}.LocalWeights([]int{2, 0, 1})
+	assert.Nil(t, err)
+	for _, weight := range weights {
+		assert.True(t, weight.IsOne())
+	}
+	_, err = Additive{N: 3}.LocalWeights([]int{0, 1}) // all parties must participate
+	assert.NotNil(t, err)
+	_, err = Additive{N: 3}.LocalWeights([]int{0, 1, 1})
+	assert.NotNil(t, err)
+
+	weights, err = Shamir{T: 2, N: 3}.LocalWeights([]int{0, 2})
+	assert.Nil(t, err)
+	lambdas, err := LagrangeCoefficientsAtZero([]int{0, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, lambdas, weights)
+	_, err = Shamir{T: 2, N: 3}.LocalWeights([]int{1})
+	assert.NotNil(t, err)
+	_, err = Shamir{T: 2, N: 3}.LocalWeights([]int{0, 3})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,140 @@
+// Package sharing implements Shamir secret sharings over the scalar field of BLS12-381, i.e. the sharing of the
+// secret key of the PCG. It provides Feldman commitments to verify shares, the reconstruction via lagrange
+// coefficients and the proactive refresh of sharings, e.g. for long-lived signing keys.
+// The party with index i holds the evaluation of the sharing polynomial at i+1 (see EvaluationPoint).
+package sharing
+
+import (
+	"encoding/binary"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"io"
+)
+
+// Share generates a t-out-of-n sharing of the secret. If secret is nil, a random secret is shared.
+// It returns the secret and the shares of the n parties.
+func Share(rng io.Reader, secret *bls12381.Fr, t, n int) (*bls12381.Fr, []*bls12381.Fr, error) {
+	coefficients, err := sampleCoefficients(rng, secret, t, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return coefficients[0], evalShares(coefficients, n), nil
+}
+
+// ShareWithCommitments works like Share, but additionally returns the Feldman commitments C_k = g1^(coefficient_k)
+// to the coefficients of the sharing polynomial, against which each party can check its share via VerifyShare.
+// C_0 = g1^secret is the public key of the shared secret.
+func ShareWithCommitments(rng io.Reader, secret *bls12381.Fr, t, n int) (*bls12381.Fr, []*bls12381.Fr, []*bls12381.PointG1, error) {
+	coefficients, err := sampleCoefficients(rng, secret, t, n)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return coefficients[0], evalShares(coefficients, n), commit(coefficients), nil
+}
+
+// Reconstruct interpolates the secret from the shares of the parties with the given indices.
+// At least t shares of a t-out-of-n sharing are required, otherwise the result is not the secret.
+func Reconstruct(shares []*bls12381.Fr, indices []int) (*bls12381.Fr, error) {
+	if len(shares) != len(indices) {
+		return nil, fmt.Errorf("got %d shares but %d indices", len(shares), len(indices))
+	}
+	lambdas, err := LagrangeCoefficientsAtZero(indices)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := bls12381.NewFr()
+	tmp := bls12381.NewFr()
+	for i, share := range shares {
+		if share == nil {
+			return nil, fmt.Errorf("share of party %d must not be nil", indices[i])
+		}
+		tmp.Mul(lambdas[i], share)
+		secret.Add(secret, tmp)
+	}
+	return secret, nil
+}
+
+// Refresh proactively re-shares the t-out-of-n sharing given by the shares of all n parties, i.e. it adds a fresh
+// sharing of zero to the shares. The refreshed shares share the same secret, while shares of different refresh
+// periods cannot be combined. It returns the refreshed shares and the Feldman commitments to the sharing of zero,
+// which update the commitments of the sharing via CombineCommitments.
+// In a distributed refresh, each party instead sends a sharing of zero (see ZeroSharing) to the others and adds up
+// the received shares.
+func Refresh(rng io.Reader, shares []*bls12381.Fr, t int) ([]*bls12381.Fr, []*bls12381.PointG1, error) {
+	zeroShares, commitments, err := ZeroSharing(rng, t, len(shares))
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshed := make([]*bls12381.Fr, len(shares))
+	for i, share := range shares {
+		if share == nil {
+			return nil, nil, fmt.Errorf("share of party %d must not be nil", i)
+		}
+		refreshed[i] = bls12381.NewFr()
+		refreshed[i].Add(share, zeroShares[i])
+	}
+	return refreshed, commitments, nil
+}
+
+// ZeroSharing generates a t-out-of-n sharing of zero with its Feldman commitments (see Refresh).
+func ZeroSharing(rng io.Reader, t, n int) ([]*bls12381.Fr, []*bls12381.PointG1, error) {
+	_, shares, commitments, err := ShareWithCommitments(rng, bls12381.NewFr().Zero(), t, n)
+	return shares, commitments, err
+}
+
+// EvaluationPoint returns the point at which the sharing polynomial is evaluated for the party with the given index.
+func EvaluationPoint(index int) *bls12381.Fr {
+	return uint64ToFr(uint64(index + 1))
+}
+
+// sampleCoefficients samples the t coefficients of a random sharing polynomial with the given secret as its first
+// coefficient. If secret is nil, the first coefficient is random as well.
+func sampleCoefficients(rng io.Reader, secret *bls12381.Fr, t, n int) ([]*bls12381.Fr, error) {
+	if t < 1 || t > n {
+		return nil, fmt.Errorf("threshold t must be within [1, n=%d] but is %d", n, t)
+	}
+	coefficients := make([]*bls12381.Fr, t)
+	for i := 0; i < t; i++ {
+		coefficient, err := bls12381.NewFr().Rand(rng)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sample coefficient: %w", err)
+		}
+		coefficients[i] = coefficient
+	}
+	if secret != nil {
+		coefficients[0].Set(secret)
+	}
+	return coefficients, nil
+}
+
+// evalShares evaluates the sharing polynomial given by its coefficients at the points of the n parties.
+func evalShares(coefficients []*bls12381.Fr, n int) []*bls12381.Fr {
+	shares := make([]*bls12381.Fr, n)
+	for i := 0; i < n; i++ {
+		share := bls12381.NewFr()
+		share.Set(coefficients[0]) // Share initialized with the secret
+
+		incrExponentiation := bls12381.NewFr().One()
+		x := EvaluationPoint(i)
+		for j := 1; j < len(coefficients); j++ {
+			incrExponentiation.Mul(incrExponentiation, x)
+			tmp := bls12381.NewFr().Set(coefficients[j])
+			tmp.Mul(tmp, incrExponentiation)
+			share.Add(share, tmp)
+		}
+
+		shares[i] = share
+	}
+	return shares
+}
+
+// uint64ToFr converts an uint64 into a bls12381.Fr.
+// This function is taken from the threshold-bbs-plus-signatures repository.
+func uint64ToFr(val uint64) *bls12381.Fr {
+	fr := bls12381.NewFr()
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, val)
+	fr.FromBytes(buf)
+	return fr
+}
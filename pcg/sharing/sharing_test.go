@@ -0,0 +1,80 @@
+package sharing
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestShareAndReconstruct(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	secret, err := bls12381.NewFr().Rand(rng)
+	assert.Nil(t, err)
+
+	shared, shares, err := Share(rng, secret, 3, 5)
+	assert.Nil(t, err)
+	assert.True(t, secret.Equal(shared))
+	assert.Len(t, shares, 5)
+
+	for _, indices := range [][]int{{0, 1, 2}, {4, 2, 1}, {0, 1, 2, 3, 4}} {
+		subset := make([]*bls12381.Fr, len(indices))
+		for i, index := range indices {
+			subset[i] = shares[index]
+		}
+		reconstructed, err := Reconstruct(subset, indices)
+		assert.Nil(t, err)
+		assert.True(t, secret.Equal(reconstructed))
+	}
+
+	// Less than t shares do not reconstruct the secret
+	reconstructed, err := Reconstruct(shares[:2], []int{0, 1})
+	assert.Nil(t, err)
+	assert.False(t, secret.Equal(reconstructed))
+
+	_, err = Reconstruct(shares[:2], []int{0})
+	assert.NotNil(t, err)
+	_, _, err = Share(rng, nil, 6, 5)
+	assert.NotNil(t, err)
+	_, _, err = Share(rng, nil, 0, 5)
+	assert.NotNil(t, err)
+}
+
+func TestRefresh(t *testing.T) {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	secret, shares, err := Share(rng, nil, 2, 4)
+	assert.Nil(t, err)
+
+	refreshed, _, err := Refresh(rng, shares, 2)
+	assert.Nil(t, err)
+	for i := range shares {
+		assert.False(t, shares[i].Equal(refreshed[i]))
+	}
+	reconstructed, err := Reconstruct([]*bls12381.Fr{refreshed[3], refreshed[1]}, []int{3, 1})
+	assert.Nil(t, err)
+	assert.True(t, secret.Equal(reconstructed))
+
+	// Shares of different refresh periods do not reconstruct the secret
+	mixed, err := Reconstruct([]*bls12381.Fr{shares[0], refreshed[1]}, []int{0, 1})
+	assert.Nil(t, err)
+	assert.False(t, secret.Equal(mixed))
+
+	// A distributed refresh, where each party contributes a sharing of zero, preserves the secret as well
+	distributed := make([]*bls12381.Fr, len(shares))
+	for i := range distributed {
+		distributed[i] = bls12381.NewFr().Set(shares[i])
+	}
+	for range shares {
+		zeroShares, _, err := ZeroSharing(rng, 2, len(shares))
+		assert.Nil(t, err)
+		for i := range distributed {
+			distributed[i].Add(distributed[i], zeroShares[i])
+		}
+	}
+	reconstructed, err = Reconstruct(distributed[:2], []int{0, 1})
+	assert.Nil(t, err)
+	assert.True(t, secret.Equal(reconstructed))
+
+	_, _, err = Refresh(rng, []*bls12381.Fr{shares[0], nil}, 2)
+	assert.NotNil(t, err)
+}
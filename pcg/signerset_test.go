@@ -0,0 +1,62 @@
+package pcg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPrecomputeSignerSets(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4) // Small lpn parameters for testing.
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+
+	generator, err := pcg.EvalSeparate(seeds[2], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+
+	batches, err := generator.PrecomputeAllSignerSets(2)
+	assert.Nil(t, err)
+	assert.Len(t, batches, 2)
+	assert.Contains(t, batches, "0,2")
+	assert.Contains(t, batches, "1,2")
+
+	// The tuples of a batch equal the tuples derived for the signer set on demand
+	batch := batches["0,2"]
+	assert.Equal(t, []int{0, 2}, batch.SignerSet)
+	for _, i := range []int{0, 13, ring.Size() - 1} {
+		expected, err := generator.GenBBSPlusTupleAt(ring, i, []int{2, 0})
+		assert.Nil(t, err)
+		tuple, err := batch.GenBBSPlusTupleAt(ring, i)
+		assert.Nil(t, err)
+		assert.Nil(t, expected.Tag.CheckCompatible(tuple.Tag))
+		expected.Tag, tuple.Tag = nil, nil // the timestamps differ
+		assert.Equal(t, expected, tuple)
+	}
+
+	all, err := generator.PrecomputeAllSignerSets(3)
+	assert.Nil(t, err)
+	assert.Len(t, all, 1)
+	assert.Contains(t, all, "0,1,2")
+
+	// Invalid signer sets
+	_, err = generator.PrecomputeSignerSet([]int{0, 1}) // own index missing
+	assert.NotNil(t, err)
+	_, err = generator.PrecomputeSignerSet([]int{0, 2, 2}) // duplicate signer
+	assert.NotNil(t, err)
+	_, err = generator.PrecomputeSignerSets([][]int{{0, 2}, {2, 3}}) // out of range
+	assert.NotNil(t, err)
+	_, err = generator.PrecomputeAllSignerSets(4)
+	assert.NotNil(t, err)
+
+	// Generators restricted to a signer set can only precompute this set
+	restricted, err := pcg.EvalSeparateForSigners(seeds[2], randPolys, ring.Prepared(), []int{1, 2})
+	assert.Nil(t, err)
+	_, err = restricted.PrecomputeAllSignerSets(2)
+	assert.NotNil(t, err)
+	_, err = restricted.PrecomputeSignerSet([]int{1, 2})
+	assert.Nil(t, err)
+}
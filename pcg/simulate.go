@@ -0,0 +1,161 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"math/big"
+	"runtime"
+	"time"
+)
+
+// memorySampleInterval is the interval in which SimulateAllParties samples the heap allocation.
+const memorySampleInterval = 10 * time.Millisecond
+
+// SimulationResult holds the evaluations of all parties of a simulation (see SimulateAllParties).
+type SimulationResult struct {
+	Generators         []*BBSPlusTupleGenerator         // Generators holds the generator of each party in an n-out-of-n setting, nil otherwise
+	SeparateGenerators []*SeparateBBSPlusTupleGenerator // SeparateGenerators holds the generator of each party in a tau-out-of-n setting, nil otherwise
+	SignerSet          []int                            // SignerSet is the signer set the correlations were checked for, i.e. the first tau parties
+	Checks             []CorrelationCheck               // Checks holds the correlation checks of the reconstructed tuples
+	Duration           time.Duration                    // Duration is the wall time of the evaluations of all parties
+	PeakHeapBytes      uint64                           // PeakHeapBytes is the peak heap allocation sampled during the evaluations
+}
+
+// CorrelationCheck is the result of checking the correlations of the tuple reconstructed at a root.
+type CorrelationCheck struct {
+	RootIndex int  // RootIndex is the index of the root the tuple was reconstructed at
+	Alpha     bool // Alpha reports whether alpha = a*s holds
+	Delta     bool // Delta reports whether delta = a*(sk+e) holds
+}
+
+// Correct returns whether all correlation checks of the simulation succeeded.
+func (r *SimulationResult) Correct() bool {
+	for _, check := range r.Checks {
+		if !check.Alpha || !check.Delta {
+			return false
+		}
+	}
+	return true
+}
+
+// SimulateAllParties evaluates the seeds of all parties on a single machine with at most parallelism concurrent
+// evaluations (runtime.NumCPU() if parallelism < 1), e.g. for experiments. The evaluations share their
+// precomputations via an EvalSession. Afterward, the tuples of the signer set are reconstructed at the first,
+// middle and last root of the ring and at a root sampled from the ring roots domain of the randomness of the PCG
+// (see Reseed), and their correlations are checked.
+// Note that the evaluations of all parties are held in memory at once.
+func (p *PCG) SimulateAllParties(seeds []*Seed, rand []*poly.Polynomial, ring *Ring, parallelism int) (*SimulationResult, error) {
+	if len(seeds) != p.n {
+		return nil, fmt.Errorf("expected the seeds of all n=%d parties but got %d", p.n, len(seeds))
+	}
+	for i, seed := range seeds {
+		if seed == nil || seed.index != i {
+			return nil, fmt.Errorf("seeds[%d] is not the seed of party %d", i, i)
+		}
+	}
+	if parallelism < 1 {
+		parallelism = runtime.NumCPU()
+	}
+
+	session, err := p.NewEvalSession(rand, ring.Prepared())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SimulationResult{SignerSet: make([]int, p.tau)}
+	for i := range result.SignerSet {
+		result.SignerSet[i] = i
+	}
+	combined := p.tau == p.n
+	if combined {
+		result.Generators = make([]*BBSPlusTupleGenerator, p.n)
+	} else {
+		result.SeparateGenerators = make([]*SeparateBBSPlusTupleGenerator, p.n)
+	}
+
+	stop := make(chan struct{})
+	peak := make(chan uint64)
+	go sampleHeap(stop, peak)
+
+	start := time.Now()
+	err = parallelFor(p.n, parallelism, func(i int) error {
+		if combined {
+			generator, err := session.EvalSeed(seeds[i])
+			result.Generators[i] = generator
+			return err
+		}
+		generator, err := session.EvalSeedSeparate(seeds[i])
+		result.SeparateGenerators[i] = generator
+		return err
+	})
+	result.Duration = time.Since(start)
+	close(stop)
+	result.PeakHeapBytes = <-peak
+	if err != nil {
+		return nil, err
+	}
+	p.logger.Infof("Simulated Eval of %d parties (in s): %v, peak heap: %d bytes", p.n, result.Duration.Seconds(), result.PeakHeapBytes)
+
+	checked := make(map[int]bool)
+	for _, index := range []int{0, ring.Size() / 2, ring.Size() - 1, p.sampleRootIndex(ring)} {
+		if checked[index] {
+			continue
+		}
+		checked[index] = true
+		check, err := result.check(ring, index)
+		if err != nil {
+			return nil, err
+		}
+		result.Checks = append(result.Checks, check)
+	}
+	return result, nil
+}
+
+// sampleRootIndex samples the index of a root of the ring from the ring roots domain.
+func (p *PCG) sampleRootIndex(ring *Ring) int {
+	return int(p.rng.domain(domainRingRoots).Int(big.NewInt(int64(ring.Size()))).Int64())
+}
+
+// check reconstructs the tuple of the signer set at the root with the given index and checks its correlations.
+func (r *SimulationResult) check(ring *Ring, index int) (CorrelationCheck, error) {
+	tuple, err := r.ReconstructTupleAt(ring, index)
+	if err != nil {
+		return CorrelationCheck{}, err
+	}
+	return checkCorrelation(tuple, index), nil
+}
+
+// checkCorrelation checks the correlations of the reconstructed tuple at the root with the given index.
+func checkCorrelation(tuple *BBSPlusTuple, index int) CorrelationCheck {
+	as := bls12381.NewFr()
+	as.Mul(tuple.AShare, tuple.SShare)
+	skPe := bls12381.NewFr()
+	skPe.Add(tuple.SkShare, tuple.EShare)
+	aSkPe := bls12381.NewFr()
+	aSkPe.Mul(tuple.AShare, skPe)
+	return CorrelationCheck{RootIndex: index, Alpha: as.Equal(tuple.AlphaShare), Delta: aSkPe.Equal(tuple.DeltaShare)}
+}
+
+// sampleHeap samples the heap allocation until stop is closed and then sends the peak to peak.
+func sampleHeap(stop <-chan struct{}, peak chan<- uint64) {
+	var stats runtime.MemStats
+	var highest uint64
+	sample := func() {
+		runtime.ReadMemStats(&stats)
+		highest = max(highest, stats.HeapAlloc)
+	}
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+	sample()
+	for {
+		select {
+		case <-ticker.C:
+			sample()
+		case <-stop:
+			sample()
+			peak <- highest
+			return
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package pcg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSimulateAllParties(t *testing.T) {
+	for _, tau := range []int{2, 3} {
+		pcg, err := NewPCG(128, 6, 3, tau, 2, 4)
+		assert.Nil(t, err)
+		seeds, err := pcg.TrustedSeedGen()
+		assert.Nil(t, err)
+		randPolys, err := pcg.PickRandomPolynomials()
+		assert.Nil(t, err)
+		ring, err := pcg.GetLazyRing()
+		assert.Nil(t, err)
+
+		result, err := pcg.SimulateAllParties(seeds, randPolys, ring, 2)
+		assert.Nil(t, err)
+		assert.True(t, result.Correct(), "correlations do not hold for tau = %d", tau)
+		assert.GreaterOrEqual(t, len(result.Checks), 3) // the sampled root may coincide with a fixed one
+		assert.LessOrEqual(t, len(result.Checks), 4)
+		assert.Equal(t, tau, len(result.SignerSet))
+		assert.Greater(t, result.PeakHeapBytes, uint64(0))
+		if tau == 3 {
+			assert.Equal(t, 3, len(result.Generators))
+			assert.Nil(t, result.SeparateGenerators)
+		} else {
+			assert.Equal(t, 3, len(result.SeparateGenerators))
+			assert.Nil(t, result.Generators)
+		}
+
+		// All seeds are required in order
+		_, err = pcg.SimulateAllParties(seeds[:2], randPolys, ring, 2)
+		assert.NotNil(t, err)
+		_, err = pcg.SimulateAllParties([]*Seed{seeds[1], seeds[0], seeds[2]}, randPolys, ring, 2)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestSimulationResultCorrect(t *testing.T) {
+	result := &SimulationResult{Checks: []CorrelationCheck{{RootIndex: 0, Alpha: true, Delta: true}}}
+	assert.True(t, result.Correct())
+	result.Checks = append(result.Checks, CorrelationCheck{RootIndex: 1, Alpha: true, Delta: false})
+	assert.False(t, result.Correct())
+}
@@ -3,9 +3,9 @@ package pcg
 import (
 	"fmt"
 	bls12381 "github.com/kilic/bls12-381"
-	"log"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/sharing"
 	"math/big"
-	"pcg-bbs-plus/pcg/poly"
 	"time"
 )
 
@@ -25,17 +25,15 @@ func (p *PCG) genSingleOlePCG() ([]*oleSeed, error) {
 			V[i][j] = new(DSPFKeyPair)
 		}
 	}
+	arena := newOuterArena(p.t * p.t)
 	for r := 0; r < p.c; r++ {
 		for s := 0; s < p.c; s++ {
-			specialPoints := outerSumBigInt(aOmega[0][r], aOmega[1][s])
-			nonZeroElements := outerProductFr(aBeta[0][r], aBeta[1][s])
-
-			key1, key2, err := p.dspf2N.Gen(specialPoints, frSliceToBigIntSlice(nonZeroElements))
+			keys, err := p.embedOLECorrelation(arena, aOmega.At(0, r), aOmega.At(1, s), aBeta.At(0, r), aBeta.At(1, s))
 			if err != nil {
 				return nil, err
 			}
 
-			V[r][s] = &DSPFKeyPair{key1, key2}
+			V[r][s] = keys
 		}
 	}
 
@@ -44,10 +42,10 @@ func (p *PCG) genSingleOlePCG() ([]*oleSeed, error) {
 		seeds[i] = &oleSeed{
 			index: i,
 			exponents: seedExponents{
-				aOmega: aOmega[i],
+				aOmega: aOmega.Party(i),
 			},
 			coefficients: seedCoefficients{
-				aBeta: aBeta[i],
+				aBeta: aBeta.Party(i),
 			},
 			V: V,
 		}
@@ -57,7 +55,7 @@ func (p *PCG) genSingleOlePCG() ([]*oleSeed, error) {
 
 // evalSingleOle evaluates a single OLE seed.
 // This is intended for benchmarking purposes and can only be used with two parties (p.n=2).
-func (p *PCG) evalSingleOle(seed *oleSeed, rand []*poly.Polynomial, div *poly.Polynomial) (*poly.Polynomial, *poly.Polynomial, error) {
+func (p *PCG) evalSingleOle(seed *oleSeed, rand []*poly.Polynomial, div *PreparedDivisor) (*poly.Polynomial, *poly.Polynomial, error) {
 	startTimerSetup := time.Now()
 	if p.n != 2 {
 		return nil, nil, fmt.Errorf("evalSingleOle can only be used with two parties")
@@ -76,7 +74,7 @@ func (p *PCG) evalSingleOle(seed *oleSeed, rand []*poly.Polynomial, div *poly.Po
 		return nil, nil, fmt.Errorf("step 1: failed to generate polynomials for u from aBeta and aOmega: %w", err)
 	}
 	endTimerSetup := time.Now()
-	log.Println("Time for setup (in s): ", endTimerSetup.Sub(startTimerSetup).Seconds())
+	p.logger.Debugf("Time for setup (in s): %v", endTimerSetup.Sub(startTimerSetup).Seconds())
 
 	startTimerFullEval := time.Now()
 	w := make([][]*poly.Polynomial, p.c)
@@ -94,11 +92,11 @@ func (p *PCG) evalSingleOle(seed *oleSeed, rand []*poly.Polynomial, div *poly.Po
 				return nil, nil, err
 			}
 			w[i][j] = new(poly.Polynomial)
-			w[i][j].Set(poly.NewFromFr(eval0))
+			w[i][j].Set(poly.NewFromFrOwned(eval0))
 		}
 	}
 	endTimerFullEval := time.Now()
-	log.Println("Time for full eval (in s): ", endTimerFullEval.Sub(startTimerFullEval).Seconds())
+	p.logger.Debugf("Time for full eval (in s): %v", endTimerFullEval.Sub(startTimerFullEval).Seconds())
 
 	startTimerRingElement := time.Now()
 	// Evaluate the polynomials
@@ -115,7 +113,7 @@ func (p *PCG) evalSingleOle(seed *oleSeed, rand []*poly.Polynomial, div *poly.Po
 		return nil, nil, err
 	}
 	endTimerRingElement := time.Now()
-	log.Println("Time for ring element (in s): ", endTimerRingElement.Sub(startTimerRingElement).Seconds())
+	p.logger.Debugf("Time for ring element (in s): %v", endTimerRingElement.Sub(startTimerRingElement).Seconds())
 
 	return ei, wi, nil
 }
@@ -129,15 +127,18 @@ func (p *PCG) genSingleVolePCG() ([]*voleSeed, error) {
 	aOmega := p.sampleExponents()   // we only use aOmega[0]
 	aBeta := p.sampleCoefficients() // we only use aBeta[0]
 
-	_, skShares := getShamirSharedRandomElement(p.rng, 2, 2) // we only use skShares[1]
+	_, skShares, err := sharing.Share(p.rng.domain(domainSkSharing), nil, 2, 2) // we only use skShares[1]
+	if err != nil {
+		return nil, err
+	}
 
 	V := make([]*DSPFKeyPair, p.c)
 	for i := range V {
 		V[i] = new(DSPFKeyPair)
 	}
 	for r := 0; r < p.c; r++ {
-		specialPoints := aOmega[0][r]
-		nonZeroElements := scalarMulFr(skShares[1], aBeta[0][r])
+		specialPoints := aOmega.At(0, r)
+		nonZeroElements := scalarMulFr(skShares[1], aBeta.At(0, r))
 
 		key1, key2, err := p.dspfN.Gen(specialPoints, frSliceToBigIntSlice(nonZeroElements))
 		if err != nil {
@@ -153,10 +154,10 @@ func (p *PCG) genSingleVolePCG() ([]*voleSeed, error) {
 			index:    i,
 			constant: skShares[1],
 			exponents: seedExponents{
-				aOmega: aOmega[i],
+				aOmega: aOmega.Party(i),
 			},
 			coefficients: seedCoefficients{
-				aBeta: aBeta[i],
+				aBeta: aBeta.Party(i),
 			},
 			V: V,
 		}
@@ -166,7 +167,7 @@ func (p *PCG) genSingleVolePCG() ([]*voleSeed, error) {
 
 // evalSingleVole evaluates a single VOLE seed.
 // This is intended for benchmarking purposes and can only be used with two parties (p.n=2).
-func (p *PCG) evalSingleVole(seed *voleSeed, rand []*poly.Polynomial, div *poly.Polynomial) (*poly.Polynomial, *poly.Polynomial, error) {
+func (p *PCG) evalSingleVole(seed *voleSeed, rand []*poly.Polynomial, div *PreparedDivisor) (*poly.Polynomial, *poly.Polynomial, error) {
 	startTimerSetup := time.Now()
 	if p.n != 2 {
 		return nil, nil, fmt.Errorf("evalSingleVole can only be used with two parties")
@@ -185,7 +186,7 @@ func (p *PCG) evalSingleVole(seed *voleSeed, rand []*poly.Polynomial, div *poly.
 		return nil, nil, fmt.Errorf("step 1: failed to generate polynomials for u from aBeta and aOmega: %w", err)
 	}
 	endTimerSetup := time.Now()
-	log.Println("Time for setup (in s): ", endTimerSetup.Sub(startTimerSetup).Seconds())
+	p.logger.Debugf("Time for setup (in s): %v", endTimerSetup.Sub(startTimerSetup).Seconds())
 
 	startTimerFullEval := time.Now()
 	w := make([]*poly.Polynomial, p.c)
@@ -202,10 +203,10 @@ func (p *PCG) evalSingleVole(seed *voleSeed, rand []*poly.Polynomial, div *poly.
 			return nil, nil, err
 		}
 		w[i] = new(poly.Polynomial)
-		w[i].Set(poly.NewFromFr(eval0))
+		w[i].Set(poly.NewFromFrOwned(eval0))
 	}
 	endTimerFullEval := time.Now()
-	log.Println("Time for full eval (in s): ", endTimerFullEval.Sub(startTimerFullEval).Seconds())
+	p.logger.Debugf("Time for full eval (in s): %v", endTimerFullEval.Sub(startTimerFullEval).Seconds())
 
 	startTimerRingElement := time.Now()
 	// Evaluate the polynomials
@@ -218,7 +219,7 @@ func (p *PCG) evalSingleVole(seed *voleSeed, rand []*poly.Polynomial, div *poly.
 		return nil, nil, err
 	}
 	endTimerRingElement := time.Now()
-	log.Println("Time for ring element (in s): ", endTimerRingElement.Sub(startTimerRingElement).Seconds())
+	p.logger.Debugf("Time for ring element (in s): %v", endTimerRingElement.Sub(startTimerRingElement).Seconds())
 
 	if seed.index == 0 {
 		return ei, wi, nil
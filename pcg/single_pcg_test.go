@@ -23,9 +23,9 @@ func TestSingleOLE(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, ring)
 
-	x0, z0, err := pcg.evalSingleOle(seeds[0], randPolys, ring.Div)
-	x1, z1, err := pcg.evalSingleOle(seeds[1], randPolys, ring.Div)
-
+	x0, z0, err := pcg.evalSingleOle(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	x1, z1, err := pcg.evalSingleOle(seeds[1], randPolys, ring.Prepared())
 	assert.Nil(t, err)
 	assert.NotNil(t, x0)
 	assert.NotNil(t, z0)
@@ -70,9 +70,9 @@ func TestSingleVOLE(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, ring)
 
-	x0, z0, err := pcg.evalSingleVole(seeds[0], randPolys, ring.Div)
-	x1, z1, err := pcg.evalSingleVole(seeds[1], randPolys, ring.Div) // x1 contains constant
-
+	x0, z0, err := pcg.evalSingleVole(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	x1, z1, err := pcg.evalSingleVole(seeds[1], randPolys, ring.Prepared()) // x1 contains constant
 	assert.Nil(t, err)
 	assert.NotNil(t, x0)
 	assert.NotNil(t, z0)
@@ -223,7 +223,7 @@ func benchmarkSingleOLEpcgEvaluation(b *testing.B, N, c, t int) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _, err := pcg.evalSingleOle(seeds[0], randPolys, ring.Div)
+		_, _, err := pcg.evalSingleOle(seeds[0], randPolys, ring.Prepared())
 		assert.Nil(b, err)
 	}
 }
@@ -257,7 +257,7 @@ func benchmarkSingleVOLEpcgEvaluation(b *testing.B, N, c, t int) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _, err := pcg.evalSingleVole(seeds[0], randPolys, ring.Div)
+		_, _, err := pcg.evalSingleVole(seeds[0], randPolys, ring.Prepared())
 		assert.Nil(b, err)
 	}
 }
@@ -0,0 +1,189 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"math"
+	"math/big"
+)
+
+// DefaultUniformityBuckets is the default amount of buckets of the chi-square test of CheckUniformity.
+const DefaultUniformityBuckets = 16
+
+// minExpectedBucketCount is the minimum expected count per bucket, below which the chi-square test is unreliable.
+const minExpectedBucketCount = 5
+
+// uniformityQuantile is the quantile of the standard normal distribution of the significance level 0.001 of the
+// chi-square test, i.e. a correct expansion fails the test of a vector with probability about 0.1%.
+const uniformityQuantile = 3.09
+
+// ReconstructTuple reconstructs the tuple of the given shares of all signers at the same root, i.e. each component
+// of the returned tuple is the sum of the shares of that component.
+func ReconstructTuple(shares []*BBSPlusTuple) (*BBSPlusTuple, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares given")
+	}
+	sk, a, e, s := bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr()
+	alpha, delta := bls12381.NewFr(), bls12381.NewFr()
+	for i, share := range shares {
+		if share == nil {
+			return nil, fmt.Errorf("share %d must not be nil", i)
+		}
+		sk.Add(sk, share.SkShare)
+		a.Add(a, share.AShare)
+		e.Add(e, share.EShare)
+		s.Add(s, share.SShare)
+		alpha.Add(alpha, share.AlphaShare)
+		delta.Add(delta, share.DeltaShare)
+	}
+	return NewBBSPlusTuple(sk, a, e, s, alpha, delta), nil
+}
+
+// TupleVectors holds the reconstructed values a, e and s of the tuples at a set of roots.
+type TupleVectors struct {
+	RootIndices []int          // RootIndices are the indices of the roots the tuples were reconstructed at
+	A           []*bls12381.Fr // A[k] is a at the root with index RootIndices[k]
+	E           []*bls12381.Fr // E[k] is e at the root with index RootIndices[k]
+	S           []*bls12381.Fr // S[k] is s at the root with index RootIndices[k]
+}
+
+// ReconstructTupleAt reconstructs the tuple of the signer set at the root with the given index.
+func (r *SimulationResult) ReconstructTupleAt(ring *Ring, index int) (*BBSPlusTuple, error) {
+	shares := make([]*BBSPlusTuple, len(r.SignerSet))
+	for k, signer := range r.SignerSet {
+		var tuple *BBSPlusTuple
+		var err error
+		if r.Generators != nil {
+			tuple, err = r.Generators[signer].GenBBSPlusTupleAt(ring, index)
+		} else {
+			tuple, err = r.SeparateGenerators[signer].GenBBSPlusTupleAt(ring, index, r.SignerSet)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tuple == nil {
+			return nil, fmt.Errorf("party %d generated no tuple at root %d", signer, index)
+		}
+		shares[k] = tuple
+	}
+	return ReconstructTuple(shares)
+}
+
+// ReconstructVectors reconstructs the values a, e and s of the tuples of the signer set at the roots with the given
+// indices.
+func (r *SimulationResult) ReconstructVectors(ring *Ring, indices []int) (*TupleVectors, error) {
+	vectors := &TupleVectors{
+		RootIndices: indices,
+		A:           make([]*bls12381.Fr, len(indices)),
+		E:           make([]*bls12381.Fr, len(indices)),
+		S:           make([]*bls12381.Fr, len(indices)),
+	}
+	for k, index := range indices {
+		tuple, err := r.ReconstructTupleAt(ring, index)
+		if err != nil {
+			return nil, fmt.Errorf("root %d: %w", index, err)
+		}
+		vectors.A[k], vectors.E[k], vectors.S[k] = tuple.AShare, tuple.EShare, tuple.SShare
+	}
+	return vectors, nil
+}
+
+// UniformityCheck is the result of the uniformity checks of a vector of field elements (see CheckUniformity).
+type UniformityCheck struct {
+	Name      string  // Name identifies the vector, e.g. "a"
+	Samples   int     // Samples is the length of the vector
+	Buckets   int     // Buckets is the amount of buckets of the chi-square test
+	ChiSquare float64 // ChiSquare is the chi-square statistic of the bucket counts
+	Critical  float64 // Critical is the critical value of the chi-square statistic
+	Zeros     int     // Zeros is the amount of zero elements, which are negligibly likely for uniform elements
+}
+
+// Passed returns whether the vector passed the chi-square test and holds no zero elements.
+func (c *UniformityCheck) Passed() bool {
+	return c.Zeros == 0 && c.ChiSquare <= c.Critical
+}
+
+// CheckUniformity checks whether the field elements are uniformly distributed. It runs a chi-square test over the
+// given amount of buckets, where an element falls into the bucket of its residue modulo the amount of buckets, and
+// counts the zero elements. Each bucket must be expected to hold at least 5 elements.
+func CheckUniformity(name string, values []*bls12381.Fr, buckets int) (*UniformityCheck, error) {
+	if buckets < 2 {
+		return nil, fmt.Errorf("at least 2 buckets are required but got %d", buckets)
+	}
+	if len(values) < minExpectedBucketCount*buckets {
+		return nil, fmt.Errorf("at least %d values are required for %d buckets but got %d", minExpectedBucketCount*buckets, buckets, len(values))
+	}
+
+	counts := make([]int, buckets)
+	modulus := big.NewInt(int64(buckets))
+	residue := new(big.Int)
+	check := &UniformityCheck{Name: name, Samples: len(values), Buckets: buckets}
+	for _, value := range values {
+		if value.IsZero() {
+			check.Zeros++
+		}
+		counts[residue.Mod(value.ToBig(), modulus).Int64()]++
+	}
+
+	expected := float64(len(values)) / float64(buckets)
+	for _, count := range counts {
+		diff := float64(count) - expected
+		check.ChiSquare += diff * diff / expected
+	}
+	check.Critical = chiSquareCritical(buckets - 1)
+	return check, nil
+}
+
+// chiSquareCritical approximates the critical value of the chi-square distribution with the given degrees of freedom
+// at the significance level of uniformityQuantile via the Wilson-Hilferty transformation.
+func chiSquareCritical(df int) float64 {
+	v := 2 / (9 * float64(df))
+	return float64(df) * math.Pow(1-v+uniformityQuantile*math.Sqrt(v), 3)
+}
+
+// StatisticsReport holds the results of the statistical tests of a simulation (see SimulationResult.StatisticalTests).
+type StatisticsReport struct {
+	Vectors    *TupleVectors     // Vectors are the reconstructed vectors the tests ran on
+	Uniformity []UniformityCheck // Uniformity holds the uniformity checks of a, e and s
+}
+
+// Passed returns whether all statistical tests passed.
+func (r *StatisticsReport) Passed() bool {
+	for k := range r.Uniformity {
+		if !r.Uniformity[k].Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// StatisticalTests reconstructs the vectors a, e and s of the signer set at the given amount of evenly spaced roots
+// (all roots if roots exceeds the ring size) and checks their uniformity (see CheckUniformity). This catches bugs of
+// the expansion that the correlation checks miss, e.g. a vector that is constant or zero at some roots.
+func (r *SimulationResult) StatisticalTests(ring *Ring, roots, buckets int) (*StatisticsReport, error) {
+	if roots < 1 {
+		return nil, fmt.Errorf("at least one root is required but got %d", roots)
+	}
+	roots = min(roots, ring.Size())
+	indices := make([]int, roots)
+	for k := range indices {
+		indices[k] = k * ring.Size() / roots
+	}
+	vectors, err := r.ReconstructVectors(ring, indices)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StatisticsReport{Vectors: vectors}
+	for _, vector := range []struct {
+		name   string
+		values []*bls12381.Fr
+	}{{"a", vectors.A}, {"e", vectors.E}, {"s", vectors.S}} {
+		check, err := CheckUniformity(vector.name, vector.values, buckets)
+		if err != nil {
+			return nil, err
+		}
+		report.Uniformity = append(report.Uniformity, *check)
+	}
+	return report, nil
+}
@@ -0,0 +1,77 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStatisticalTests(t *testing.T) {
+	for _, tau := range []int{2, 3} {
+		pcg, err := NewPCG(128, 7, 3, tau, 2, 4)
+		assert.Nil(t, err)
+		seeds, err := pcg.TrustedSeedGen()
+		assert.Nil(t, err)
+		randPolys, err := pcg.PickRandomPolynomials()
+		assert.Nil(t, err)
+		ring, err := pcg.GetLazyRing()
+		assert.Nil(t, err)
+		result, err := pcg.SimulateAllParties(seeds, randPolys, ring, 0)
+		assert.Nil(t, err)
+
+		report, err := result.StatisticalTests(ring, ring.Size(), 8)
+		assert.Nil(t, err)
+		assert.Equal(t, ring.Size(), len(report.Vectors.A))
+		assert.Equal(t, 3, len(report.Uniformity))
+		// The test fails for a correct expansion with probability about 0.1% per vector
+		assert.True(t, report.Passed(), "%+v", report.Uniformity)
+
+		// The reconstructed vectors match the reconstructed tuples
+		tuple, err := result.ReconstructTupleAt(ring, report.Vectors.RootIndices[7])
+		assert.Nil(t, err)
+		assert.True(t, tuple.EShare.Equal(report.Vectors.E[7]))
+
+		// Too few roots for the buckets
+		_, err = result.StatisticalTests(ring, 16, 8)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCheckUniformity(t *testing.T) {
+	values := make([]*bls12381.Fr, 160)
+	for k := range values {
+		values[k] = bls12381.NewFr().One()
+	}
+	check, err := CheckUniformity("constant", values, DefaultUniformityBuckets)
+	assert.Nil(t, err)
+	assert.False(t, check.Passed())
+	assert.Equal(t, 0, check.Zeros)
+
+	// A single zero fails the check
+	for k := range values {
+		values[k] = bls12381.NewFr().FromBytes([]byte{byte(k)})
+	}
+	check, err = CheckUniformity("counter", values, DefaultUniformityBuckets)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, check.Zeros)
+	assert.InDelta(t, 0, check.ChiSquare, 1e-9)
+	assert.False(t, check.Passed())
+
+	_, err = CheckUniformity("one bucket", values, 1)
+	assert.NotNil(t, err)
+}
+
+func TestReconstructTuple(t *testing.T) {
+	one := bls12381.NewFr().One()
+	share := NewBBSPlusTuple(one, one, one, one, one, one)
+	tuple, err := ReconstructTuple([]*BBSPlusTuple{share, share})
+	assert.Nil(t, err)
+	two := bls12381.NewFr()
+	two.Double(one)
+	assert.True(t, tuple.DeltaShare.Equal(two))
+
+	_, err = ReconstructTuple(nil)
+	assert.NotNil(t, err)
+	_, err = ReconstructTuple([]*BBSPlusTuple{share, nil})
+	assert.NotNil(t, err)
+}
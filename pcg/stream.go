@@ -0,0 +1,22 @@
+package pcg
+
+import (
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/stream"
+)
+
+// WriteSeeds writes the seeds to w, one chunk per seed (see Seed.Serialize), e.g. to ship the seeds of all parties
+// with a compressing stream.Writer.
+func WriteSeeds(w *stream.Writer, seeds []*Seed) error {
+	return stream.WriteAll(w, seeds)
+}
+
+// ReadSeeds reads the remaining seeds of a stream written by WriteSeeds.
+func ReadSeeds(r *stream.Reader) ([]*Seed, error) {
+	return stream.ReadAll(r, func(data []byte) (*Seed, error) {
+		seed := &Seed{}
+		if err := seed.Deserialize(data); err != nil {
+			return nil, err
+		}
+		return seed, nil
+	})
+}
@@ -0,0 +1,386 @@
+// Package stream implements the chunked serialization of the artifacts of the PCG, e.g. the seeds of all parties, share
+// polynomials or tuple batches, with optional transparent compression. Each artifact is written as a chunk, which is
+// compressed on its own, s.t. readers decode one artifact at a time without holding the whole stream in memory. Writers
+// and readers count the raw and the compressed bytes (see Stats), s.t. communication numbers include both figures.
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// formatVersion is the version of the stream format written by NewWriter.
+const formatVersion = 1
+
+// DefaultMaxChunkSize bounds the raw size of the chunks a Reader accepts, unless set otherwise (see SetMaxChunkSize).
+const DefaultMaxChunkSize = 1 << 30
+
+// magic prefixes every stream. Like the artifact header, it starts with a zero byte, hence it is no gob stream.
+var magic = []byte{0, 'P', 'C', 'S'}
+
+// ErrNoStream is returned by NewReader if the data does not start with the magic of a stream.
+var ErrNoStream = errors.New("the data is no chunked stream")
+
+// Compressor compresses the chunks of a stream. The ID of the compressor is recorded in the stream, s.t. readers
+// decompress with the same compressor (see Register). Further algorithms, e.g. zstd or snappy, are plugged in by
+// registering a Compressor wrapping them.
+type Compressor interface {
+	// ID identifies the compressor in streams. The IDs below 16 are reserved for the compressors of this package.
+	ID() uint8
+	// Name names the compressor in messages.
+	Name() string
+	// Compress returns the compression of src.
+	Compress(src []byte) ([]byte, error)
+	// Decompress returns the decompression of src, which must be exactly size bytes.
+	Decompress(src []byte, size int) ([]byte, error)
+}
+
+// None stores the chunks uncompressed. It is the compressor of streams without compression.
+type None struct{}
+
+// ID implements Compressor.
+func (None) ID() uint8 { return 0 }
+
+// Name implements Compressor.
+func (None) Name() string { return "none" }
+
+// Compress implements Compressor.
+func (None) Compress(src []byte) ([]byte, error) { return src, nil }
+
+// Decompress implements Compressor.
+func (None) Decompress(src []byte, size int) ([]byte, error) {
+	if len(src) != size {
+		return nil, fmt.Errorf("chunk holds %d bytes but %d are expected", len(src), size)
+	}
+	return src, nil
+}
+
+// Flate compresses the chunks via DEFLATE (see compress/flate), which needs no dependencies beyond the standard library.
+type Flate struct {
+	Level int // Level is the compression level of compress/flate, flate.DefaultCompression if zero.
+}
+
+// ID implements Compressor.
+func (Flate) ID() uint8 { return 1 }
+
+// Name implements Compressor.
+func (Flate) Name() string { return "flate" }
+
+// Compress implements Compressor.
+func (f Flate) Compress(src []byte) ([]byte, error) {
+	level := f.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress implements Compressor.
+func (Flate) Decompress(src []byte, size int) ([]byte, error) {
+	// One byte beyond size is read, s.t. chunks that decompress to more than size bytes are detected.
+	data, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(src)), int64(size)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress chunk: %w", err)
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("chunk decompresses to %d bytes but %d are expected", len(data), size)
+	}
+	return data, nil
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[uint8]Compressor{0: None{}, 1: Flate{}}
+)
+
+// Register registers the compressor, s.t. readers decompress the streams of its ID. It errs if the ID is taken.
+func Register(c Compressor) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registered, ok := registry[c.ID()]; ok {
+		return fmt.Errorf("compressor ID %d is taken by %s", c.ID(), registered.Name())
+	}
+	registry[c.ID()] = c
+	return nil
+}
+
+// Lookup returns the registered compressor of the ID.
+func Lookup(id uint8) (Compressor, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[id]
+	return c, ok
+}
+
+// Stats are the sizes of the chunks written or read so far.
+type Stats struct {
+	Chunks          int64 // Chunks is the amount of chunks, i.e. of artifacts.
+	RawBytes        int64 // RawBytes is the size of the chunks before compression.
+	CompressedBytes int64 // CompressedBytes is the size of the stream, i.e. of the compressed chunks incl. header and framing.
+}
+
+// Ratio returns CompressedBytes / RawBytes, 0 if no bytes were written.
+func (s Stats) Ratio() float64 {
+	if s.RawBytes == 0 {
+		return 0
+	}
+	return float64(s.CompressedBytes) / float64(s.RawBytes)
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d chunks, %d raw bytes, %d compressed bytes (%.1f%%)", s.Chunks, s.RawBytes, s.CompressedBytes, 100*s.Ratio())
+}
+
+// Writer writes a stream of chunks. Close must be called after the last chunk, as readers detect truncated streams by
+// the missing end marker.
+type Writer struct {
+	w          *bufio.Writer
+	compressor Compressor
+	stats      Stats
+	closed     bool
+}
+
+// NewWriter writes the header of a stream with the given compressor to w and returns its Writer. A nil compressor
+// stores the chunks uncompressed. The compressor must be registered (see Register), s.t. the stream can be read.
+func NewWriter(w io.Writer, compressor Compressor) (*Writer, error) {
+	if compressor == nil {
+		compressor = None{}
+	}
+	if registered, ok := Lookup(compressor.ID()); !ok || registered.Name() != compressor.Name() {
+		return nil, fmt.Errorf("compressor %s is not registered", compressor.Name())
+	}
+	sw := &Writer{w: bufio.NewWriter(w), compressor: compressor}
+	header := append(append([]byte{}, magic...), formatVersion, compressor.ID())
+	if err := sw.write(header); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+// WriteChunk compresses the data and writes it as the next chunk.
+func (w *Writer) WriteChunk(data []byte) error {
+	if w.closed {
+		return errors.New("the stream is closed")
+	}
+	compressed, err := w.compressor.Compress(data)
+	if err != nil {
+		return fmt.Errorf("failed to compress chunk: %w", err)
+	}
+	// The raw size is shifted by one, s.t. zero marks the end of the stream and empty chunks are possible.
+	frame := binary.AppendUvarint(nil, uint64(len(data))+1)
+	frame = binary.AppendUvarint(frame, uint64(len(compressed)))
+	if err := w.write(frame); err != nil {
+		return err
+	}
+	if err := w.write(compressed); err != nil {
+		return err
+	}
+	w.stats.Chunks++
+	w.stats.RawBytes += int64(len(data))
+	return nil
+}
+
+// Close writes the end marker and flushes the stream. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err := w.write([]byte{0}); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// Stats returns the sizes of the chunks written so far.
+func (w *Writer) Stats() Stats {
+	return w.stats
+}
+
+// write writes data to the stream and counts it.
+func (w *Writer) write(data []byte) error {
+	n, err := w.w.Write(data)
+	w.stats.CompressedBytes += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write stream: %w", err)
+	}
+	return nil
+}
+
+// Reader reads a stream of chunks written by a Writer.
+type Reader struct {
+	r            *bufio.Reader
+	compressor   Compressor
+	maxChunkSize int
+	stats        Stats
+	done         bool
+}
+
+// NewReader reads the header of the stream in r and returns its Reader. It returns ErrNoStream if r does not start with
+// a stream and an error if the compressor of the stream is not registered.
+func NewReader(r io.Reader) (*Reader, error) {
+	sr := &Reader{r: bufio.NewReader(r), maxChunkSize: DefaultMaxChunkSize}
+	header := make([]byte, len(magic)+2)
+	if err := sr.read(header); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:len(magic)], magic) {
+		return nil, ErrNoStream
+	}
+	if version := header[len(magic)]; version != formatVersion {
+		return nil, fmt.Errorf("unsupported stream format version %d", version)
+	}
+	compressor, ok := Lookup(header[len(magic)+1])
+	if !ok {
+		return nil, fmt.Errorf("unknown compressor ID %d", header[len(magic)+1])
+	}
+	sr.compressor = compressor
+	return sr, nil
+}
+
+// Compressor returns the compressor of the stream.
+func (r *Reader) Compressor() Compressor {
+	return r.compressor
+}
+
+// SetMaxChunkSize bounds the raw size of the chunks, s.t. malformed streams cannot exhaust the memory.
+func (r *Reader) SetMaxChunkSize(size int) {
+	r.maxChunkSize = size
+}
+
+// Next returns the next chunk. It returns io.EOF after the last chunk and io.ErrUnexpectedEOF if the stream ends
+// without end marker.
+func (r *Reader) Next() ([]byte, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	size, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		r.done = true
+		return nil, io.EOF
+	}
+	size--
+	compressedSize, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	// Compressed chunks may exceed their raw size slightly, e.g. for incompressible data.
+	if size > uint64(r.maxChunkSize) || compressedSize > size+size/8+1024 {
+		return nil, fmt.Errorf("chunk of %d bytes (%d compressed) exceeds the maximum size of %d bytes", size, compressedSize, r.maxChunkSize)
+	}
+	compressed := make([]byte, compressedSize)
+	if err := r.read(compressed); err != nil {
+		return nil, err
+	}
+	data, err := r.compressor.Decompress(compressed, int(size))
+	if err != nil {
+		return nil, err
+	}
+	r.stats.Chunks++
+	r.stats.RawBytes += int64(size)
+	return data, nil
+}
+
+// Stats returns the sizes of the chunks read so far.
+func (r *Reader) Stats() Stats {
+	return r.stats
+}
+
+// read reads exactly len(data) bytes of the stream and counts them.
+func (r *Reader) read(data []byte) error {
+	n, err := io.ReadFull(r.r, data)
+	r.stats.CompressedBytes += int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil
+}
+
+// readUvarint reads a uvarint of the stream and counts it.
+func (r *Reader) readUvarint() (uint64, error) {
+	counter := &countingByteReader{r: r.r}
+	v, err := binary.ReadUvarint(counter)
+	r.stats.CompressedBytes += counter.n
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stream: %w", err)
+	}
+	return v, nil
+}
+
+// countingByteReader counts the bytes read from r.
+type countingByteReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingByteReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// Serializer is implemented by the artifacts written to streams, e.g. seeds, polynomials and tuples.
+type Serializer interface {
+	Serialize() ([]byte, error)
+}
+
+// WriteAll serializes the items and writes each as a chunk.
+func WriteAll[T Serializer](w *Writer, items []T) error {
+	for i, item := range items {
+		data, err := item.Serialize()
+		if err != nil {
+			return fmt.Errorf("failed to serialize item %d: %w", i, err)
+		}
+		if err := w.WriteChunk(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadAll reads the remaining chunks of the stream and decodes each via decode.
+func ReadAll[T any](r *Reader, decode func(data []byte) (T, error)) ([]T, error) {
+	var items []T
+	for {
+		data, err := r.Next()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		item, err := decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode item %d: %w", len(items), err)
+		}
+		items = append(items, item)
+	}
+}
@@ -0,0 +1,113 @@
+package stream
+
+import (
+	"bytes"
+	"crypto/rand"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+// testChunks returns chunks of compressible, random and no data.
+func testChunks(t *testing.T) [][]byte {
+	random := make([]byte, 1000)
+	_, err := rand.Read(random)
+	assert.Nil(t, err)
+	return [][]byte{bytes.Repeat([]byte{0, 0, 0, 1}, 1000), random, {}}
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	for _, compressor := range []Compressor{nil, None{}, Flate{}, Flate{Level: 9}} {
+		chunks := testChunks(t)
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, compressor)
+		assert.Nil(t, err)
+		for _, chunk := range chunks {
+			assert.Nil(t, w.WriteChunk(chunk))
+		}
+		assert.Nil(t, w.Close())
+		written := w.Stats()
+		assert.Equal(t, int64(3), written.Chunks)
+		assert.Equal(t, int64(5000), written.RawBytes)
+		assert.Equal(t, int64(buf.Len()), written.CompressedBytes)
+
+		r, err := NewReader(&buf)
+		assert.Nil(t, err)
+		for _, chunk := range chunks {
+			data, err := r.Next()
+			assert.Nil(t, err)
+			assert.Equal(t, len(chunk), len(data))
+			assert.True(t, bytes.Equal(chunk, data))
+		}
+		_, err = r.Next()
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, written, r.Stats())
+	}
+}
+
+func TestStreamCompression(t *testing.T) {
+	chunk := bytes.Repeat([]byte{0, 0, 0, 1}, 1000)
+	sizes := make(map[string]int64)
+	for _, compressor := range []Compressor{None{}, Flate{}} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, compressor)
+		assert.Nil(t, err)
+		assert.Nil(t, w.WriteChunk(chunk))
+		assert.Nil(t, w.Close())
+		sizes[compressor.Name()] = w.Stats().CompressedBytes
+	}
+	assert.Greater(t, sizes["none"], int64(len(chunk)))
+	assert.Less(t, sizes["flate"], int64(len(chunk))/10)
+	assert.Equal(t, "1 chunks, 10 raw bytes, 5 compressed bytes (50.0%)", Stats{Chunks: 1, RawBytes: 10, CompressedBytes: 5}.String())
+	assert.Equal(t, 0.0, Stats{}.Ratio())
+}
+
+func TestStreamInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Flate{})
+	assert.Nil(t, err)
+	assert.Nil(t, w.WriteChunk(bytes.Repeat([]byte{1}, 100)))
+	assert.Nil(t, w.Close())
+	assert.NotNil(t, w.WriteChunk([]byte{1}))
+	data := buf.Bytes()
+
+	// Truncated streams, incl. streams without end marker, fail
+	for _, end := range []int{3, 6, 8, len(data) - 2, len(data) - 1} {
+		r, err := NewReader(bytes.NewReader(data[:end]))
+		for err == nil {
+			_, err = r.Next()
+		}
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "truncated at %d", end)
+	}
+
+	_, err = NewReader(bytes.NewReader([]byte("not a stream")))
+	assert.Equal(t, ErrNoStream, err)
+	unknown := append([]byte{}, data...)
+	unknown[len(magic)+1] = 200
+	_, err = NewReader(bytes.NewReader(unknown))
+	assert.ErrorContains(t, err, "unknown compressor")
+
+	r, err := NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	r.SetMaxChunkSize(99)
+	_, err = r.Next()
+	assert.ErrorContains(t, err, "maximum size")
+}
+
+// fakeCompressor claims the ID of Flate.
+type fakeCompressor struct {
+	None
+}
+
+func (fakeCompressor) ID() uint8 { return 1 }
+
+func (fakeCompressor) Name() string { return "fake" }
+
+func TestRegister(t *testing.T) {
+	assert.ErrorContains(t, Register(fakeCompressor{}), "taken by flate")
+	_, err := NewWriter(io.Discard, fakeCompressor{})
+	assert.ErrorContains(t, err, "not registered")
+	c, ok := Lookup(Flate{}.ID())
+	assert.True(t, ok)
+	assert.Equal(t, "flate", c.Name())
+}
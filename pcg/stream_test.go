@@ -0,0 +1,89 @@
+package pcg
+
+import (
+	"bytes"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/stream"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// writeStream writes a stream via write with the given compressor and returns it along with its statistics.
+func writeStream(t *testing.T, compressor stream.Compressor, write func(w *stream.Writer) error) (*bytes.Buffer, stream.Stats) {
+	var buf bytes.Buffer
+	w, err := stream.NewWriter(&buf, compressor)
+	assert.Nil(t, err)
+	assert.Nil(t, write(w))
+	assert.Nil(t, w.Close())
+	assert.Equal(t, int64(buf.Len()), w.Stats().CompressedBytes)
+	return &buf, w.Stats()
+}
+
+func TestStreamSeedsSharesAndTuples(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+	generator, err := pcg.EvalCombined(seeds[0], randPolys, ring.Prepared())
+	assert.Nil(t, err)
+	shares, ok := generator.Provider().(*tuplegen.PolyShares)
+	assert.True(t, ok)
+	tuples, err := generator.GenBBSPlusTuplesAt(ring, []int{0, 1, 2})
+	assert.Nil(t, err)
+	generators, err := tuplegen.NewGenerators(2)
+	assert.Nil(t, err)
+	tuples[1].PrecomputeBase(generators)
+
+	for _, compressor := range []stream.Compressor{stream.None{}, stream.Flate{}} {
+		buf, stats := writeStream(t, compressor, func(w *stream.Writer) error { return WriteSeeds(w, seeds) })
+		assert.Equal(t, int64(len(seeds)), stats.Chunks)
+		r, err := stream.NewReader(buf)
+		assert.Nil(t, err)
+		read, err := ReadSeeds(r)
+		assert.Nil(t, err)
+		assert.Len(t, read, len(seeds))
+		for i := range seeds {
+			assert.Equal(t, seeds[i].hash(), read[i].hash())
+		}
+		assert.Equal(t, stats, r.Stats())
+
+		buf, stats = writeStream(t, compressor, func(w *stream.Writer) error { return tuplegen.WritePolyShares(w, shares) })
+		assert.Equal(t, int64(7), stats.Chunks)
+		r, err = stream.NewReader(buf)
+		assert.Nil(t, err)
+		readShares, err := tuplegen.ReadPolyShares(r)
+		assert.Nil(t, err)
+		assert.True(t, shares.SkShare().Equal(readShares.SkShare()))
+		restored := tuplegen.NewGenerator(readShares)
+		for i, tuple := range tuples {
+			restoredTuple, err := restored.GenBBSPlusTupleAt(ring, i)
+			assert.Nil(t, err)
+			assert.True(t, tuple.EqualsConstantTime(restoredTuple), "tuple %d", i)
+		}
+
+		buf, stats = writeStream(t, compressor, func(w *stream.Writer) error { return tuplegen.WriteTuples(w, tuples) })
+		assert.Equal(t, int64(len(tuples)), stats.Chunks)
+		r, err = stream.NewReader(buf)
+		assert.Nil(t, err)
+		readTuples, err := tuplegen.ReadTuples(r)
+		assert.Nil(t, err)
+		assert.Len(t, readTuples, len(tuples))
+		for i := range tuples {
+			assert.True(t, tuples[i].EqualsConstantTime(readTuples[i]), "tuple %d", i)
+			assert.Equal(t, tuples[i].Tag.RootIndex, readTuples[i].Tag.RootIndex)
+			assert.Equal(t, tuples[i].Base != nil, readTuples[i].Base != nil)
+		}
+		assert.True(t, bls12381.NewG1().Equal(tuples[1].Base.Point, readTuples[1].Base.Point))
+	}
+
+	// The raw sizes do not depend on the compressor, only the compressed sizes do
+	_, uncompressed := writeStream(t, stream.None{}, func(w *stream.Writer) error { return WriteSeeds(w, seeds) })
+	_, compressed := writeStream(t, stream.Flate{}, func(w *stream.Writer) error { return WriteSeeds(w, seeds) })
+	assert.Equal(t, uncompressed.RawBytes, compressed.RawBytes)
+	assert.Less(t, compressed.CompressedBytes, uncompressed.CompressedBytes)
+}
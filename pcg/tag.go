@@ -0,0 +1,53 @@
+package pcg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// newTupleTag returns the tag template for the tuples derived from the given seed, i.e. without root index and timestamp.
+func (p *PCG) newTupleTag(seed *Seed) *TupleTag {
+	return &TupleTag{
+		RootIndex:    -1,
+		SeedHash:     seed.hash(),
+		ParamsDigest: p.paramsDigest(),
+	}
+}
+
+// paramsDigest returns the SHA-256 digest of the parameters of the PCG.
+func (p *PCG) paramsDigest() [32]byte {
+	buf := make([]byte, 0, 6*8)
+	for _, param := range []int{p.lambda, p.N, p.n, p.tau, p.c, p.t} {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(param))
+	}
+	if p.regularNoise { // appended only for regular noise, s.t. the digests of existing parameters are unchanged
+		buf = append(buf, 1)
+	}
+	return sha256.Sum256(buf)
+}
+
+// SetupDigest returns the digest of the public parts of the seed that are shared by all parties of its setup (see hash),
+// e.g. to derive the random polynomials of the setup (see PickRandomPolynomialsForEpoch). It fails for seeds without
+// commitments of the dealer, e.g. of the legacy formats, as their digest would not identify the setup.
+func (s *Seed) SetupDigest() ([32]byte, error) {
+	if len(s.skCommitments) == 0 {
+		return [32]byte{}, fmt.Errorf("the seed holds no commitments to identify its setup")
+	}
+	return s.hash(), nil
+}
+
+// hash returns the SHA-256 hash of the public parts of the seed that are shared by all parties,
+// i.e. the commitments of the dealer and the commitment to the re-randomization key.
+func (s *Seed) hash() [32]byte {
+	h := sha256.New()
+	g1 := bls12381.NewG1()
+	for _, commitment := range s.skCommitments {
+		h.Write(g1.ToBytes(commitment))
+	}
+	h.Write(s.reRandomizationCommitment())
+	var digest [32]byte
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
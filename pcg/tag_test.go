@@ -0,0 +1,50 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTupleTag(t *testing.T) {
+	pcg, err := NewPCG(128, 5, 2, 2, 2, 4) // Small lpn parameters for testing.
+	assert.Nil(t, err)
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+
+	// Tags of the parties of the same run are compatible
+	tag0 := pcg.newTupleTag(seeds[0])
+	tag1 := pcg.newTupleTag(seeds[1])
+	assert.Nil(t, tag0.CheckCompatible(tag1))
+	atRoot1, atRoot2 := *tag0, *tag1
+	atRoot1.RootIndex, atRoot2.RootIndex = 1, 2
+	assert.NotNil(t, atRoot1.CheckCompatible(&atRoot2))
+
+	// Other seed generations and parameters are detected
+	otherSeeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	assert.NotNil(t, tag0.CheckCompatible(pcg.newTupleTag(otherSeeds[0])))
+	otherPcg, err := NewPCG(128, 6, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	assert.NotNil(t, tag0.CheckCompatible(otherPcg.newTupleTag(seeds[0])))
+
+	// Generators populate the tag of the tuples
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	one := poly.NewFromFr([]*bls12381.Fr{bls12381.NewFr().One()})
+	generator := NewBBSPlusTupleGenerator(seeds[0].ski, one, one, one, one, one, one)
+	tuple, err := generator.GenBBSPlusTupleAt(ring, 3)
+	assert.Nil(t, err)
+	assert.Nil(t, tuple.Tag)
+
+	generator.SetTag(tag0)
+	tuple, err = generator.GenBBSPlusTupleAt(ring, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, tuple.Tag.RootIndex)
+	assert.False(t, tuple.Tag.Timestamp.IsZero())
+	assert.Equal(t, -1, tag0.RootIndex) // the template is not modified
+	root, err := ring.RootAt(3)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, generator.GenBBSPlusTuple(root).Tag.RootIndex)
+}
@@ -1,8 +0,0 @@
-package pcg_test
-
-import (
-	"testing"
-)
-
-func TestTupleSerialization(t *testing.T) {
-}
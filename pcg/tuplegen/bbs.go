@@ -0,0 +1,165 @@
+package tuplegen
+
+import (
+	"encoding/binary"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+)
+
+// generatorsDomainSeparator is the domain separation tag of the hash to curve deriving the generators h_0, ..., h_L.
+const generatorsDomainSeparator = "pcg-bbs-plus/bbs/generators/v1"
+
+// Generators are the public generators of BBS+ signatures over L messages: g1, the generator h_0 of the blinding
+// value s and the generators h_1, ..., h_L of the messages. They are derived deterministically by hashing to G1,
+// s.t. all parties agree on them without interaction and the generators of L messages are a prefix of those of L+1.
+type Generators struct {
+	G1 *bls12381.PointG1
+	H0 *bls12381.PointG1
+	H  []*bls12381.PointG1 // H[l] is the generator h_(l+1) of the l-th message
+}
+
+// NewGenerators returns the generators of BBS+ signatures over the given amount of messages.
+func NewGenerators(messageCount int) (*Generators, error) {
+	if messageCount < 1 {
+		return nil, fmt.Errorf("message count must be positive but is %d", messageCount)
+	}
+	g1 := bls12381.NewG1()
+	points := make([]*bls12381.PointG1, messageCount+1)
+	for i := range points {
+		point, err := g1.HashToCurve(binary.BigEndian.AppendUint32(nil, uint32(i)), []byte(generatorsDomainSeparator))
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive generator h_%d: %w", i, err)
+		}
+		points[i] = point
+	}
+	return &Generators{G1: g1.One(), H0: points[0], H: points[1:]}, nil
+}
+
+// MessageCount returns the amount of messages L the generators are meant for.
+func (g *Generators) MessageCount() int {
+	return len(g.H)
+}
+
+// commitment returns the commitment g1 * h0^s * h_1^m_1 * ... * h_L^m_L to the messages.
+func (g *Generators) commitment(messages []*bls12381.Fr, s *bls12381.Fr) (*bls12381.PointG1, error) {
+	if len(messages) != g.MessageCount() {
+		return nil, fmt.Errorf("amount of messages is %d but the generators are meant for %d messages", len(messages), g.MessageCount())
+	}
+	g1 := bls12381.NewG1()
+	commitment := g1.New().Set(g.G1)
+	tmp := g1.New()
+	g1.MulScalar(tmp, g.H0, s)
+	g1.Add(commitment, commitment, tmp)
+	for l, message := range messages {
+		g1.MulScalar(tmp, g.H[l], message)
+		g1.Add(commitment, commitment, tmp)
+	}
+	return commitment, nil
+}
+
+// CommitmentBase is a party's precomputed message-independent share g1^a_i * h0^alpha_i of the BBS+ commitment of a
+// tuple. Summed over all parties, it yields (g1 * h0^s)^a, hence signing only requires the message-dependent part.
+type CommitmentBase struct {
+	Generators *Generators       // Generators are the generators the base was computed for.
+	Point      *bls12381.PointG1 // Point is g1^a_i * h0^alpha_i.
+}
+
+// PrecomputeBase precomputes the commitment base of the tuple for the given generators. The base is serialized with
+// the tuple and is recomputed if the tuple is blinded.
+func (t *BBSPlusTuple) PrecomputeBase(generators *Generators) {
+	g1 := bls12381.NewG1()
+	point, tmp := g1.New(), g1.New()
+	g1.MulScalar(point, generators.G1, t.AShare)
+	g1.MulScalar(tmp, generators.H0, t.AlphaShare)
+	g1.Add(point, point, tmp)
+	t.Base = &CommitmentBase{Generators: generators, Point: point}
+}
+
+// SignatureShare is a party's share of a BBS+ signature on a set of messages.
+type SignatureShare struct {
+	A     *bls12381.PointG1 // A is the share (g1 * h0^s * h_1^m_1 * ... * h_L^m_L)^a_i, using alpha_i for h0^s.
+	Delta *bls12381.Fr
+	E     *bls12381.Fr
+	S     *bls12381.Fr
+}
+
+// Sign returns the party's share of the BBS+ signature on the given messages. The tuple must hold a commitment base
+// (see PrecomputeBase and BBSPlusTupleGenerator.SetGenerators) for the amount of messages. Each tuple must be used
+// for a single signature only.
+func (t *BBSPlusTuple) Sign(messages []*bls12381.Fr) (*SignatureShare, error) {
+	if t.Base == nil {
+		return nil, fmt.Errorf("the tuple holds no commitment base")
+	}
+	generators := t.Base.Generators
+	if len(messages) != generators.MessageCount() {
+		return nil, fmt.Errorf("amount of messages is %d but the commitment base is meant for %d messages", len(messages), generators.MessageCount())
+	}
+
+	g1 := bls12381.NewG1()
+	a := g1.New().Set(t.Base.Point)
+	tmp := g1.New()
+	exponent := bls12381.NewFr()
+	for l, message := range messages {
+		exponent.Mul(message, t.AShare)
+		g1.MulScalar(tmp, generators.H[l], exponent)
+		g1.Add(a, a, tmp)
+	}
+	return &SignatureShare{
+		A:     a,
+		Delta: bls12381.NewFr().Set(t.DeltaShare),
+		E:     bls12381.NewFr().Set(t.EShare),
+		S:     bls12381.NewFr().Set(t.SShare),
+	}, nil
+}
+
+// Signature is a BBS+ signature (A, e, s) with A = (g1 * h0^s * h_1^m_1 * ... * h_L^m_L)^(1/(sk+e)).
+type Signature struct {
+	A *bls12381.PointG1
+	E *bls12381.Fr
+	S *bls12381.Fr
+}
+
+// CombineSignatureShares combines the signature shares of all signers into a BBS+ signature, i.e. A is the sum of the
+// A shares raised to the inverse of delta = a*(sk+e). The field elements are recombined and inverted in constant time
+// (see dpf.ConstantTimeAddFr), whereas the group operations of bls12381 are not constant time.
+func CombineSignatureShares(shares []*SignatureShare) (*Signature, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no signature shares given")
+	}
+	g1 := bls12381.NewG1()
+	a := g1.Zero()
+	delta, e, s := bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr()
+	for i, share := range shares {
+		if share == nil {
+			return nil, fmt.Errorf("signature share %d must not be nil", i)
+		}
+		g1.Add(a, a, share.A)
+		dpf.ConstantTimeAddFr(delta, delta, share.Delta)
+		dpf.ConstantTimeAddFr(e, e, share.E)
+		dpf.ConstantTimeAddFr(s, s, share.S)
+	}
+	if dpf.ConstantTimeIsZeroFr(delta) == 1 {
+		return nil, fmt.Errorf("the combined delta is zero")
+	}
+	dpf.ConstantTimeInverseFr(delta, delta)
+	g1.MulScalar(a, a, delta)
+	return &Signature{A: a, E: e, S: s}, nil
+}
+
+// Verify checks the signature on the messages under the public key w = g2^sk, i.e. e(A, w * g2^e) = e(C, g2) for the
+// commitment C = g1 * h0^s * h_1^m_1 * ... * h_L^m_L.
+func (g *Generators) Verify(pk *bls12381.PointG2, messages []*bls12381.Fr, signature *Signature) error {
+	commitment, err := g.commitment(messages, signature.S)
+	if err != nil {
+		return err
+	}
+	g2 := bls12381.NewG2()
+	wE := g2.New()
+	g2.MulScalar(wE, g2.One(), signature.E)
+	g2.Add(wE, wE, pk)
+	if !bls12381.NewEngine().AddPair(signature.A, wE).AddPairInv(commitment, g2.One()).Check() {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
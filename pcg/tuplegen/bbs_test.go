@@ -0,0 +1,115 @@
+package tuplegen_test
+
+import (
+	"crypto/rand"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func randomFr(t *testing.T) *bls12381.Fr {
+	fr, err := bls12381.NewFr().Rand(rand.Reader)
+	assert.Nil(t, err)
+	return fr
+}
+
+// additiveShares splits the value into n random additive shares.
+func additiveShares(t *testing.T, value *bls12381.Fr, n int) []*bls12381.Fr {
+	shares := make([]*bls12381.Fr, n)
+	last := bls12381.NewFr().Set(value)
+	for i := 0; i < n-1; i++ {
+		shares[i] = randomFr(t)
+		last.Sub(last, shares[i])
+	}
+	shares[n-1] = last
+	return shares
+}
+
+// correlatedTuples returns the tuples of n parties for a random secret key, along with the public key.
+func correlatedTuples(t *testing.T, n int) ([]*tuplegen.BBSPlusTuple, *bls12381.PointG2) {
+	sk, a, e, s := randomFr(t), randomFr(t), randomFr(t), randomFr(t)
+	alpha, delta := bls12381.NewFr(), bls12381.NewFr()
+	alpha.Mul(a, s)
+	delta.Add(sk, e)
+	delta.Mul(delta, a)
+
+	skShares, aShares, eShares := additiveShares(t, sk, n), additiveShares(t, a, n), additiveShares(t, e, n)
+	sShares, alphaShares, deltaShares := additiveShares(t, s, n), additiveShares(t, alpha, n), additiveShares(t, delta, n)
+	tuples := make([]*tuplegen.BBSPlusTuple, n)
+	for i := range tuples {
+		tuples[i] = tuplegen.NewBBSPlusTuple(skShares[i], aShares[i], eShares[i], sShares[i], alphaShares[i], deltaShares[i])
+	}
+
+	g2 := bls12381.NewG2()
+	pk := g2.New()
+	g2.MulScalar(pk, g2.One(), sk)
+	return tuples, pk
+}
+
+func sign(t *testing.T, tuples []*tuplegen.BBSPlusTuple, messages []*bls12381.Fr) *tuplegen.Signature {
+	shares := make([]*tuplegen.SignatureShare, len(tuples))
+	for i, tuple := range tuples {
+		share, err := tuple.Sign(messages)
+		assert.Nil(t, err)
+		shares[i] = share
+	}
+	signature, err := tuplegen.CombineSignatureShares(shares)
+	assert.Nil(t, err)
+	return signature
+}
+
+func TestSign(t *testing.T) {
+	generators, err := tuplegen.NewGenerators(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, generators.MessageCount())
+
+	tuples, pk := correlatedTuples(t, 3)
+	for _, tuple := range tuples {
+		tuple.PrecomputeBase(generators)
+	}
+	messages := []*bls12381.Fr{randomFr(t), randomFr(t), randomFr(t)}
+	signature := sign(t, tuples, messages)
+	assert.Nil(t, generators.Verify(pk, messages, signature))
+
+	// The signature does not verify for other messages
+	other := []*bls12381.Fr{messages[0], messages[1], randomFr(t)}
+	assert.NotNil(t, generators.Verify(pk, other, signature))
+
+	// Blinding recomputes the commitment base
+	prfKeys, err := tuplegen.NewZeroSharingKeys(rand.Reader, len(tuples))
+	assert.Nil(t, err)
+	for i, tuple := range tuples {
+		assert.Nil(t, tuple.Blind([]byte("session"), prfKeys[i]))
+	}
+	assert.Nil(t, generators.Verify(pk, messages, sign(t, tuples, messages)))
+}
+
+func TestSignMessageCount(t *testing.T) {
+	tuples, _ := correlatedTuples(t, 2)
+	_, err := tuples[0].Sign([]*bls12381.Fr{randomFr(t)})
+	assert.NotNil(t, err) // no commitment base
+
+	generators, err := tuplegen.NewGenerators(2)
+	assert.Nil(t, err)
+	tuples[0].PrecomputeBase(generators)
+	_, err = tuples[0].Sign([]*bls12381.Fr{randomFr(t)})
+	assert.NotNil(t, err)
+
+	_, err = tuplegen.NewGenerators(0)
+	assert.NotNil(t, err)
+}
+
+func TestNewGeneratorsPrefix(t *testing.T) {
+	small, err := tuplegen.NewGenerators(2)
+	assert.Nil(t, err)
+	large, err := tuplegen.NewGenerators(5)
+	assert.Nil(t, err)
+
+	g1 := bls12381.NewG1()
+	assert.True(t, g1.Equal(small.H0, large.H0))
+	for l := range small.H {
+		assert.True(t, g1.Equal(small.H[l], large.H[l]))
+	}
+	assert.False(t, g1.Equal(large.H0, large.H[0]))
+}
@@ -0,0 +1,127 @@
+package tuplegen
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"io"
+)
+
+// Domain separators of the zero shares added to the components of a tuple.
+const (
+	blindA byte = iota + 1
+	blindE
+	blindS
+	blindAlpha
+	blindDelta
+)
+
+// ZeroSharingKey holds the PRF keys a party shares pairwise with the other parties. The PRF outputs of each pair cancel
+// out, s.t. the parties derive a fresh sharing of zero for each session without interaction.
+type ZeroSharingKey struct {
+	Index int              // Index is the index of the party holding the key
+	Keys  map[int][32]byte // Keys[j] is the PRF key shared with party j
+}
+
+// NewZeroSharingKeys samples the pairwise PRF keys of n parties, e.g. alongside the seeds by a trusted dealer.
+// The i-th key is meant for the party with index i.
+func NewZeroSharingKeys(rng io.Reader, n int) ([]*ZeroSharingKey, error) {
+	if n < 2 {
+		return nil, fmt.Errorf("zero sharing requires at least two parties but n is %d", n)
+	}
+	keys := make([]*ZeroSharingKey, n)
+	for i := range keys {
+		keys[i] = &ZeroSharingKey{Index: i, Keys: make(map[int][32]byte, n-1)}
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			var key [32]byte
+			if _, err := io.ReadFull(rng, key[:]); err != nil {
+				return nil, fmt.Errorf("failed to sample PRF key: %w", err)
+			}
+			keys[i].Keys[j] = key
+			keys[j].Keys[i] = key
+		}
+	}
+	return keys, nil
+}
+
+// ForSigners returns the key restricted to the co-signers of the signer set, s.t. the zero shares of the signer set
+// sum up to zero in the tau-out-of-n setting.
+func (k *ZeroSharingKey) ForSigners(signerSet []int) (*ZeroSharingKey, error) {
+	restricted := &ZeroSharingKey{Index: k.Index, Keys: make(map[int][32]byte, len(signerSet))}
+	for _, signer := range signerSet {
+		if signer == k.Index {
+			continue
+		}
+		key, ok := k.Keys[signer]
+		if !ok {
+			return nil, fmt.Errorf("no PRF key is shared with signer %d", signer)
+		}
+		restricted.Keys[signer] = key
+	}
+	return restricted, nil
+}
+
+// Blind re-randomizes the shares of the tuple by adding a fresh sharing of zero derived from the session ID, s.t. the
+// tuple cannot be linked to its precomputation batch via protocol transcripts. All parties using the tuple have to
+// blind it with the same session ID, which must be unique per tuple, and keys restricted to the signer set
+// (see ZeroSharingKey.ForSigners). The reconstructed values, and hence the BBS+ relations, do not change.
+// The share of the secret key is not blinded, as it is reused for all tuples (and shamir shared for tau-out-of-n).
+// A precomputed commitment base and share commitments are recomputed for the blinded shares.
+func (t *BBSPlusTuple) Blind(sessionID []byte, prfKey *ZeroSharingKey) error {
+	if prfKey == nil {
+		return fmt.Errorf("PRF key must not be nil")
+	}
+	for _, c := range []struct {
+		share     *bls12381.Fr
+		separator byte
+	}{
+		{t.AShare, blindA},
+		{t.EShare, blindE},
+		{t.SShare, blindS},
+		{t.AlphaShare, blindAlpha},
+		{t.DeltaShare, blindDelta},
+	} {
+		dpf.ConstantTimeAddFr(c.share, c.share, prfKey.zeroShare(sessionID, c.separator))
+	}
+	if t.Base != nil {
+		t.PrecomputeBase(t.Base.Generators)
+	}
+	if t.Commitments != nil && t.Commitments.key != nil {
+		t.Commitments, _ = t.CommitShares(t.Commitments.params, t.Commitments.key)
+	} else {
+		t.Commitments = nil // commitments without a key cannot be recomputed
+	}
+	return nil
+}
+
+// zeroShare returns the share of zero of the party for the given session and component.
+// The party adds the PRF outputs shared with parties of a higher index and subtracts the others.
+func (k *ZeroSharingKey) zeroShare(sessionID []byte, separator byte) *bls12381.Fr {
+	share := bls12381.NewFr()
+	for j, key := range k.Keys {
+		output := prf(key, sessionID, separator)
+		if j > k.Index {
+			dpf.ConstantTimeAddFr(share, share, output)
+		} else {
+			dpf.ConstantTimeSubFr(share, share, output)
+		}
+	}
+	return share
+}
+
+// prf evaluates HMAC-SHA256 keyed by key on the session ID and separator. Two blocks are reduced to a field element,
+// s.t. the output is statistically close to uniform.
+func prf(key [32]byte, sessionID []byte, separator byte) *bls12381.Fr {
+	wide := make([]byte, 0, 2*sha256.Size)
+	for block := byte(0); block < 2; block++ {
+		mac := hmac.New(sha256.New, key[:])
+		mac.Write([]byte{separator, block})
+		mac.Write(sessionID)
+		wide = mac.Sum(wide)
+	}
+	return bls12381.NewFr().FromBytes(wide)
+}
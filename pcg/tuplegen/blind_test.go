@@ -0,0 +1,76 @@
+package tuplegen_test
+
+import (
+	"crypto/rand"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func randomTuple(t *testing.T) *tuplegen.BBSPlusTuple {
+	shares := make([]*bls12381.Fr, 6)
+	for i := range shares {
+		share, err := bls12381.NewFr().Rand(rand.Reader)
+		assert.Nil(t, err)
+		shares[i] = share
+	}
+	return tuplegen.NewBBSPlusTuple(shares[0], shares[1], shares[2], shares[3], shares[4], shares[5])
+}
+
+// sumTuples returns the sums of the A, E, S, Alpha and Delta shares of the tuples.
+func sumTuples(tuples []*tuplegen.BBSPlusTuple) []*bls12381.Fr {
+	sums := []*bls12381.Fr{bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr()}
+	for _, tuple := range tuples {
+		for i, share := range []*bls12381.Fr{tuple.AShare, tuple.EShare, tuple.SShare, tuple.AlphaShare, tuple.DeltaShare} {
+			sums[i].Add(sums[i], share)
+		}
+	}
+	return sums
+}
+
+func TestTupleBlind(t *testing.T) {
+	n := 4
+	keys, err := tuplegen.NewZeroSharingKeys(rand.Reader, n)
+	assert.Nil(t, err)
+
+	signerSet := []int{0, 2, 3}
+	tuples := make([]*tuplegen.BBSPlusTuple, len(signerSet))
+	originals := make([]*tuplegen.BBSPlusTuple, len(signerSet))
+	for i := range tuples {
+		tuples[i] = randomTuple(t)
+		originals[i] = tuplegen.NewBBSPlusTuple(tuples[i].SkShare, tuples[i].AShare, tuples[i].EShare, tuples[i].SShare, tuples[i].AlphaShare, tuples[i].DeltaShare)
+	}
+	expected := sumTuples(tuples)
+
+	for i, signer := range signerSet {
+		key, err := keys[signer].ForSigners(signerSet)
+		assert.Nil(t, err)
+		assert.Nil(t, tuples[i].Blind([]byte("session-1"), key))
+	}
+
+	// The shares are re-randomized, but the reconstructed values are unchanged
+	assert.Equal(t, expected, sumTuples(tuples))
+	for i := range tuples {
+		assert.True(t, tuples[i].SkShare.Equal(originals[i].SkShare))
+		assert.False(t, tuples[i].AShare.Equal(originals[i].AShare))
+		assert.False(t, tuples[i].DeltaShare.Equal(originals[i].DeltaShare))
+	}
+
+	// Blinding is deterministic in the session ID
+	again := tuplegen.NewBBSPlusTuple(originals[0].SkShare, originals[0].AShare, originals[0].EShare, originals[0].SShare, originals[0].AlphaShare, originals[0].DeltaShare)
+	key, err := keys[0].ForSigners(signerSet)
+	assert.Nil(t, err)
+	assert.Nil(t, again.Blind([]byte("session-1"), key))
+	assert.True(t, again.AShare.Equal(tuples[0].AShare))
+	other := tuplegen.NewBBSPlusTuple(originals[0].SkShare, originals[0].AShare, originals[0].EShare, originals[0].SShare, originals[0].AlphaShare, originals[0].DeltaShare)
+	assert.Nil(t, other.Blind([]byte("session-2"), key))
+	assert.False(t, other.AShare.Equal(tuples[0].AShare))
+
+	// Keys must cover the signer set
+	_, err = keys[0].ForSigners([]int{0, 4})
+	assert.NotNil(t, err)
+	assert.NotNil(t, tuples[0].Blind([]byte("session-1"), nil))
+	_, err = tuplegen.NewZeroSharingKeys(rand.Reader, 1)
+	assert.NotNil(t, err)
+}
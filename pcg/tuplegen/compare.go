@@ -0,0 +1,106 @@
+package tuplegen
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"sort"
+)
+
+// The comparisons of tuples only depend on the presence (non-nil) of their shares, which is public, but not on the
+// values of the shares. The tag and the commitment base of a tuple are metadata and hence not compared.
+
+// EqualsConstantTime returns whether the shares of the tuples are equal. The shares are compared in constant time, s.t.
+// the comparison does not leak the position of the first differing share. A nil share only equals a nil share.
+func (t *BBSPlusTuple) EqualsConstantTime(other *BBSPlusTuple) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+	return EqualSharesConstantTime(t.shares(), other.shares())
+}
+
+// CompareTuples returns -1, 0 or 1 if the shares of a are less than, equal to or greater than those of b in the
+// canonical order, i.e. lexicographically by SkShare, AShare, EShare, SShare, AlphaShare and DeltaShare, where nil
+// tuples and shares precede all others. The shares are compared in constant time.
+func CompareTuples(a, b *BBSPlusTuple) int {
+	if a == nil || b == nil {
+		return compareNil(a == nil, b == nil)
+	}
+	return CompareSharesConstantTime(a.shares(), b.shares())
+}
+
+// SortTuples sorts the tuples in place in the canonical order of CompareTuples.
+func SortTuples(tuples []*BBSPlusTuple) {
+	sort.SliceStable(tuples, func(i, j int) bool {
+		return CompareTuples(tuples[i], tuples[j]) < 0
+	})
+}
+
+// DeduplicateTuples returns the tuples with distinct shares in the canonical order of CompareTuples, keeping the first
+// occurrence of each. The given slice is not modified.
+func DeduplicateTuples(tuples []*BBSPlusTuple) []*BBSPlusTuple {
+	sorted := make([]*BBSPlusTuple, len(tuples))
+	copy(sorted, tuples)
+	SortTuples(sorted)
+
+	unique := sorted[:0]
+	for k, tuple := range sorted {
+		if k == 0 || !tuple.EqualsConstantTime(unique[len(unique)-1]) {
+			unique = append(unique, tuple)
+		}
+	}
+	return unique
+}
+
+// EqualSharesConstantTime returns whether the slices hold equal shares at each position. The shares are compared in
+// constant time. A nil share only equals a nil share.
+func EqualSharesConstantTime(a, b []*bls12381.Fr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	equal := 1
+	for k := range a {
+		if a[k] == nil || b[k] == nil {
+			equal &= boolToInt(a[k] == nil && b[k] == nil)
+			continue
+		}
+		equal &= dpf.ConstantTimeEqualFr(a[k], b[k])
+	}
+	return equal == 1
+}
+
+// CompareSharesConstantTime compares the slices of shares of the same length lexicographically (see CompareTuples).
+// The result is combined without branching on the values, s.t. the comparison does not leak the position of the first
+// differing share.
+func CompareSharesConstantTime(a, b []*bls12381.Fr) int {
+	result := 0
+	for k := 0; k < min(len(a), len(b)); k++ {
+		var cmp int
+		if a[k] == nil || b[k] == nil {
+			cmp = compareNil(a[k] == nil, b[k] == nil)
+		} else {
+			cmp = dpf.ConstantTimeCompareFr(a[k], b[k])
+		}
+		result += (1 - result*result) * cmp // only the first non-zero comparison is kept
+	}
+	if result == 0 {
+		result = compareNil(len(a) < len(b), len(b) < len(a)) // shorter slices precede their extensions
+	}
+	return result
+}
+
+// shares returns the shares of the tuple in the canonical order.
+func (t *BBSPlusTuple) shares() []*bls12381.Fr {
+	return []*bls12381.Fr{t.SkShare, t.AShare, t.EShare, t.SShare, t.AlphaShare, t.DeltaShare}
+}
+
+// compareNil orders missing values before present ones.
+func compareNil(aNil, bNil bool) int {
+	return boolToInt(bNil) - boolToInt(aNil)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
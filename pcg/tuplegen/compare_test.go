@@ -0,0 +1,73 @@
+package tuplegen_test
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func frOf(v int64) *bls12381.Fr {
+	return bls12381.NewFr().FromBytes(big.NewInt(v).Bytes())
+}
+
+func tupleOf(values ...int64) *tuplegen.BBSPlusTuple {
+	return tuplegen.NewBBSPlusTuple(frOf(values[0]), frOf(values[1]), frOf(values[2]), frOf(values[3]), frOf(values[4]), frOf(values[5]))
+}
+
+func TestTupleEqualsConstantTime(t *testing.T) {
+	tuple := tupleOf(1, 2, 3, 4, 5, 6)
+	assert.True(t, tuple.EqualsConstantTime(tupleOf(1, 2, 3, 4, 5, 6)))
+	assert.False(t, tuple.EqualsConstantTime(tupleOf(1, 2, 3, 4, 5, 7)))
+	assert.False(t, tuple.EqualsConstantTime(tupleOf(0, 2, 3, 4, 5, 6)))
+
+	// Tags are metadata and not compared
+	tagged := tupleOf(1, 2, 3, 4, 5, 6)
+	tagged.Tag = &tuplegen.TupleTag{RootIndex: 3}
+	assert.True(t, tuple.EqualsConstantTime(tagged))
+
+	// nil tuples and shares only equal nil
+	partial := tupleOf(1, 2, 3, 4, 5, 6)
+	partial.DeltaShare = nil
+	assert.False(t, tuple.EqualsConstantTime(partial))
+	assert.False(t, partial.EqualsConstantTime(tuple))
+	assert.True(t, partial.EqualsConstantTime(partial))
+	assert.False(t, tuple.EqualsConstantTime(nil))
+	assert.True(t, (*tuplegen.BBSPlusTuple)(nil).EqualsConstantTime(nil))
+}
+
+func TestCompareTuples(t *testing.T) {
+	minusOne := bls12381.NewFr()
+	minusOne.Neg(bls12381.NewFr().One())
+	large := tupleOf(1, 2, 3, 4, 5, 6)
+	large.AShare = minusOne
+
+	// The order is lexicographic by share and numeric within a share
+	assert.Equal(t, 0, tuplegen.CompareTuples(tupleOf(1, 2, 3, 4, 5, 6), tupleOf(1, 2, 3, 4, 5, 6)))
+	assert.Equal(t, -1, tuplegen.CompareTuples(tupleOf(1, 2, 3, 4, 5, 6), tupleOf(1, 2, 3, 4, 5, 7)))
+	assert.Equal(t, 1, tuplegen.CompareTuples(tupleOf(2, 0, 0, 0, 0, 0), tupleOf(1, 9, 9, 9, 9, 9)))
+	assert.Equal(t, 1, tuplegen.CompareTuples(large, tupleOf(1, 3, 0, 0, 0, 0)))
+	assert.Equal(t, -1, tuplegen.CompareTuples(nil, tupleOf(0, 0, 0, 0, 0, 0)))
+	assert.Equal(t, 1, tuplegen.CompareTuples(tupleOf(0, 0, 0, 0, 0, 0), nil))
+
+	tuples := []*tuplegen.BBSPlusTuple{tupleOf(3, 0, 0, 0, 0, 0), tupleOf(1, 2, 0, 0, 0, 0), nil, tupleOf(1, 1, 0, 0, 0, 0)}
+	tuplegen.SortTuples(tuples)
+	assert.Nil(t, tuples[0])
+	for k := 1; k+1 < len(tuples); k++ {
+		assert.Equal(t, -1, tuplegen.CompareTuples(tuples[k], tuples[k+1]))
+	}
+}
+
+func TestDeduplicateTuples(t *testing.T) {
+	first := tupleOf(1, 2, 3, 4, 5, 6)
+	first.Tag = &tuplegen.TupleTag{RootIndex: 1}
+	tuples := []*tuplegen.BBSPlusTuple{tupleOf(2, 0, 0, 0, 0, 0), first, tupleOf(1, 2, 3, 4, 5, 6), tupleOf(2, 0, 0, 0, 0, 0)}
+
+	unique := tuplegen.DeduplicateTuples(tuples)
+	assert.Len(t, unique, 2)
+	assert.Same(t, first, unique[0]) // the first occurrence is kept
+	assert.True(t, unique[1].EqualsConstantTime(tupleOf(2, 0, 0, 0, 0, 0)))
+	assert.Same(t, first, tuples[1]) // the input is not modified
+	assert.Empty(t, tuplegen.DeduplicateTuples(nil))
+}
@@ -0,0 +1,353 @@
+package tuplegen
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/logging"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/sharing"
+	"time"
+)
+
+// BBSPlusTupleGenerator derives pre-computed BBS+ signatures from the shares of a ShareProvider.
+// It is used for the n-out-of-n scheme.
+type BBSPlusTupleGenerator struct {
+	provider   ShareProvider
+	tag        *TupleTag       // tag is the template of the tags of the generated tuples. nil means untagged.
+	generators *Generators     // generators are the BBS+ generators of the commitment bases. nil means no precomputation.
+	committer  *shareCommitter // committer commits to the shares of the generated tuples. nil means no commitments.
+	logger     logging.Logger  // logger receives the log messages of the generator. It defaults to a no-op logger.
+	stats      *statsCounter   // stats aggregates the statistics of the generated tuples (see Stats).
+}
+
+// NewBBSPlusTupleGenerator returns a new BBSPlusTupleGenerator for an n-out-of-n scheme over the given polynomials.
+func NewBBSPlusTupleGenerator(SkShare *bls12381.Fr, APoly, EPoly, SPoly, AlphaPoly, Delta0Poly, Delta1Poly *poly.Polynomial) *BBSPlusTupleGenerator {
+	return NewGenerator(NewPolyShares(SkShare, APoly, EPoly, SPoly, AlphaPoly, Delta0Poly, Delta1Poly))
+}
+
+// NewGenerator returns a new BBSPlusTupleGenerator for an n-out-of-n scheme over the shares of the given provider.
+func NewGenerator(provider ShareProvider) *BBSPlusTupleGenerator {
+	stats := &statsCounter{}
+	recordStatsOf(provider, stats)
+	return &BBSPlusTupleGenerator{
+		provider: provider,
+		logger:   logging.NopLogger{},
+		stats:    stats,
+	}
+}
+
+// Provider returns the share provider of the generator.
+func (t *BBSPlusTupleGenerator) Provider() ShareProvider {
+	return t.provider
+}
+
+// SetLogger sets the logger of the generator. A nil logger discards all messages.
+func (t *BBSPlusTupleGenerator) SetLogger(logger logging.Logger) {
+	t.logger = logging.OrNop(logger)
+}
+
+// SetTag sets the template of the tags of the generated tuples. A nil tag disables tagging.
+func (t *BBSPlusTupleGenerator) SetTag(tag *TupleTag) {
+	t.tag = tag
+}
+
+// SetGenerators sets the BBS+ generators for the message count of the signatures, s.t. the commitment base of each
+// generated tuple is precomputed (see BBSPlusTuple.PrecomputeBase). Nil generators disable the precomputation.
+func (t *BBSPlusTupleGenerator) SetGenerators(generators *Generators) {
+	t.generators = generators
+}
+
+// SetShareCommitments sets the Pedersen parameters and the commitment key of the party, s.t. each generated tuple
+// carries the commitments to its shares (see BBSPlusTuple.CommitShares). A nil key disables the commitments.
+func (t *BBSPlusTupleGenerator) SetShareCommitments(params *PedersenParams, key *CommitmentKey) {
+	t.committer = newShareCommitter(params, key)
+}
+
+// GenBBSPlusTuple returns a BBSPlusTuple from a BBSPlusTupleGenerator for a given root.
+// If the generator is tagged, the tuple is tagged with an unknown root index (see GenBBSPlusTupleAt).
+// It returns nil if the provider fails to provide the shares.
+func (t *BBSPlusTupleGenerator) GenBBSPlusTuple(root *bls12381.Fr) *BBSPlusTuple {
+	tuple, err := t.genBBSPlusTuple(root, -1)
+	if err != nil {
+		t.logger.Debugf("failed to generate tuple: %v", err)
+		return nil
+	}
+	return tuple
+}
+
+// genBBSPlusTuple returns the BBSPlusTuple for the given root, tagged with the given root index.
+func (t *BBSPlusTupleGenerator) genBBSPlusTuple(root *bls12381.Fr, index int) (tuple *BBSPlusTuple, err error) {
+	start := time.Now()
+	defer func() { t.stats.recordTuple(start, err, tuple == nil) }()
+	shares, err := t.provider.SharesAt(root)
+	if err != nil {
+		return nil, err
+	}
+	return finalize(t.provider.SkShare(), shares, t.tag, t.generators, t.committer, index), nil
+}
+
+// GenBBSPlusTupleAt returns the BBSPlusTuple with the given index, i.e. the tuple for the index-th root of the ring.
+func (t *BBSPlusTupleGenerator) GenBBSPlusTupleAt(ring RootSource, index int) (*BBSPlusTuple, error) {
+	root, err := ring.RootAt(index)
+	if err != nil {
+		return nil, err
+	}
+	return t.genBBSPlusTuple(root, index)
+}
+
+// GenBBSPlusTuples returns the BBSPlusTuples for the given roots in their order. If the provider is a
+// BatchShareProvider, the shares of all roots are derived at once, which amortizes the evaluation of the polynomials
+// over the roots. If the generator is tagged, the tuples are tagged with an unknown root index (see
+// GenBBSPlusTuplesAt).
+func (t *BBSPlusTupleGenerator) GenBBSPlusTuples(roots []*bls12381.Fr) ([]*BBSPlusTuple, error) {
+	indices := make([]int, len(roots))
+	for i := range indices {
+		indices[i] = -1
+	}
+	return t.genBBSPlusTuples(roots, indices)
+}
+
+// GenBBSPlusTuplesAt returns the BBSPlusTuples with the given indices in their order (see GenBBSPlusTuples).
+func (t *BBSPlusTupleGenerator) GenBBSPlusTuplesAt(ring RootSource, indices []int) ([]*BBSPlusTuple, error) {
+	roots, err := rootsAt(ring, indices)
+	if err != nil {
+		return nil, err
+	}
+	return t.genBBSPlusTuples(roots, indices)
+}
+
+// genBBSPlusTuples returns the BBSPlusTuples for the given roots, tagged with the given root indices.
+func (t *BBSPlusTupleGenerator) genBBSPlusTuples(roots []*bls12381.Fr, indices []int) (tuples []*BBSPlusTuple, err error) {
+	start := time.Now()
+	defer func() { t.stats.recordTuples(start, len(roots), err) }()
+	shares, err := sharesAtRoots(t.provider, roots)
+	if err != nil {
+		return nil, err
+	}
+	tuples = make([]*BBSPlusTuple, len(roots))
+	for i := range tuples {
+		tuples[i] = finalize(t.provider.SkShare(), shares[i], t.tag, t.generators, t.committer, indices[i])
+	}
+	return tuples, nil
+}
+
+// SeparateBBSPlusTupleGenerator derives pre-computed BBS+ signatures from the shares of a SeparateShareProvider.
+// It is used for the tau-out-of-n scheme.
+type SeparateBBSPlusTupleGenerator struct {
+	provider   SeparateShareProvider
+	tag        *TupleTag        // tag is the template of the tags of the generated tuples. nil means untagged.
+	generators *Generators      // generators are the BBS+ generators of the commitment bases. nil means no precomputation.
+	committer  *shareCommitter  // committer commits to the shares of the generated tuples. nil means no commitments.
+	logger     logging.Logger   // logger receives the log messages of the generator. It defaults to a no-op logger.
+	stats      *statsCounter    // stats aggregates the statistics of the generated tuples (see Stats).
+	scheme     sharing.Scheme   // scheme weights the shares of the signer sets. nil means the lagrange coefficients.
+	reRand     *ReRandomization // reRand re-randomizes the shares of the signer sets. nil disables it.
+}
+
+// NewSeparateBBSPlusTupleGenerator returns a new NewSeparateBBSPlusTupleGenerator for an tau-out-of-n scheme over the
+// given polynomials (see NewSeparatePolyShares). The own index is the own index of the cross terms.
+func NewSeparateBBSPlusTupleGenerator(usk, uk, uv *poly.Polynomial, SkShare *bls12381.Fr, APoly, EPoly, SPoly *poly.Polynomial, Delta0Poly *PartyIndexed[[]*poly.Polynomial], AlphaPoly, Delta1Poly *PartyIndexed[*poly.Polynomial]) (*SeparateBBSPlusTupleGenerator, error) {
+	shares, err := NewSeparatePolyShares(usk, uk, uv, SkShare, APoly, EPoly, SPoly, Delta0Poly, AlphaPoly, Delta1Poly)
+	if err != nil {
+		return nil, err
+	}
+	return NewSeparateGenerator(shares), nil
+}
+
+// NewSeparateGenerator returns a new SeparateBBSPlusTupleGenerator for a tau-out-of-n scheme over the shares of the
+// given provider.
+func NewSeparateGenerator(provider SeparateShareProvider) *SeparateBBSPlusTupleGenerator {
+	stats := &statsCounter{}
+	recordStatsOf(provider, stats)
+	return &SeparateBBSPlusTupleGenerator{
+		provider: provider,
+		logger:   logging.NopLogger{},
+		stats:    stats,
+	}
+}
+
+// SetLogger sets the logger of the generator. A nil logger discards all messages.
+func (t *SeparateBBSPlusTupleGenerator) SetLogger(logger logging.Logger) {
+	t.logger = logging.OrNop(logger)
+}
+
+// SetTag sets the template of the tags of the generated tuples. A nil tag disables tagging.
+func (t *SeparateBBSPlusTupleGenerator) SetTag(tag *TupleTag) {
+	t.tag = tag
+}
+
+// SetGenerators sets the BBS+ generators for the message count of the signatures, s.t. the commitment base of each
+// generated tuple is precomputed (see BBSPlusTuple.PrecomputeBase). Nil generators disable the precomputation.
+func (t *SeparateBBSPlusTupleGenerator) SetGenerators(generators *Generators) {
+	t.generators = generators
+}
+
+// SetSharingScheme sets the scheme the sk term is shared with, whose local weights recombine the shares of a signer
+// set (see sharing.Scheme.LocalWeights). A nil scheme weights the shares by the lagrange coefficients of the signer
+// set, i.e. assumes a Shamir sharing.
+func (t *SeparateBBSPlusTupleGenerator) SetSharingScheme(scheme sharing.Scheme) {
+	t.scheme = scheme
+}
+
+// SetReRandomization sets the re-randomization of the shares of the signer sets (see ReRandomization). Its own index
+// must be the signer index of the party. A nil re-randomization disables it.
+func (t *SeparateBBSPlusTupleGenerator) SetReRandomization(r *ReRandomization) {
+	t.reRand = r
+}
+
+// SetShareCommitments sets the Pedersen parameters and the commitment key of the party, s.t. each generated tuple
+// carries the commitments to its shares (see BBSPlusTuple.CommitShares). A nil key disables the commitments.
+func (t *SeparateBBSPlusTupleGenerator) SetShareCommitments(params *PedersenParams, key *CommitmentKey) {
+	t.committer = newShareCommitter(params, key)
+}
+
+// GenBBSPlusTuple returns a BBSPlusTuple from a SeparateBBSPlusTupleGenerator for a given root.
+// signerSet is the set of signers that are participating. It must contain ownIndex.
+// If the generator is tagged, the tuple is tagged with an unknown root index (see GenBBSPlusTupleAt).
+// It returns nil for invalid signer sets or if the provider fails to provide the shares.
+func (t *SeparateBBSPlusTupleGenerator) GenBBSPlusTuple(root *bls12381.Fr, signerSet []int) *BBSPlusTuple {
+	tuple, err := t.genBBSPlusTuple(root, -1, signerSet)
+	if err != nil {
+		t.logger.Debugf("failed to generate tuple: %v", err)
+		return nil
+	}
+	return tuple
+}
+
+// genBBSPlusTuple returns the BBSPlusTuple for the given root and signer set, tagged with the given root index.
+// It returns an error for invalid signer sets.
+func (t *SeparateBBSPlusTupleGenerator) genBBSPlusTuple(root *bls12381.Fr, index int, signerSet []int) (tuple *BBSPlusTuple, err error) {
+	start := time.Now()
+	defer func() { t.stats.recordTuple(start, err, tuple == nil) }()
+	provider, err := t.signerSetProvider(signerSet)
+	if err != nil {
+		return nil, fmt.Errorf("rejected signer set %v: %w", signerSet, err)
+	}
+	shares, err := provider.SharesAt(root)
+	if err != nil {
+		return nil, err
+	}
+	return finalize(provider.SkShare(), shares, t.tag, t.generators, t.committer, index), nil
+}
+
+// GenBBSPlusTupleAt returns the BBSPlusTuple with the given index, i.e. the tuple for the index-th root of the ring.
+// signerSet is the set of signers that are participating. It must contain ownIndex, otherwise an error is returned.
+func (t *SeparateBBSPlusTupleGenerator) GenBBSPlusTupleAt(ring RootSource, index int, signerSet []int) (*BBSPlusTuple, error) {
+	root, err := ring.RootAt(index)
+	if err != nil {
+		return nil, err
+	}
+	return t.genBBSPlusTuple(root, index, signerSet)
+}
+
+// GenBBSPlusTuples returns the BBSPlusTuples for the given roots and signer set in their order (see
+// BBSPlusTupleGenerator.GenBBSPlusTuples). signerSet is the set of signers that are participating. It must contain
+// ownIndex, otherwise an error is returned.
+func (t *SeparateBBSPlusTupleGenerator) GenBBSPlusTuples(roots []*bls12381.Fr, signerSet []int) (tuples []*BBSPlusTuple, err error) {
+	start := time.Now()
+	defer func() { t.stats.recordTuples(start, len(roots), err) }()
+	provider, err := t.signerSetProvider(signerSet)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := sharesAtRoots(provider, roots)
+	if err != nil {
+		return nil, err
+	}
+	tuples = make([]*BBSPlusTuple, len(roots))
+	for i := range tuples {
+		tuples[i] = finalize(provider.SkShare(), shares[i], t.tag, t.generators, t.committer, -1)
+	}
+	return tuples, nil
+}
+
+// signerSetProvider validates the signer set and returns the provider of the combined shares of the signer set.
+func (t *SeparateBBSPlusTupleGenerator) signerSetProvider(signerSet []int) (ShareProvider, error) {
+	ownIndex := t.provider.OwnIndex()
+
+	// Check if ownIndex is in signerSet
+	ownIndexInSignerSet := false
+	for _, signer := range signerSet {
+		if signer == ownIndex {
+			ownIndexInSignerSet = true
+			break
+		}
+	}
+	if !ownIndexInSignerSet {
+		return nil, fmt.Errorf("signer set must contain the own index %d", ownIndex)
+	}
+
+	// Check if the shares of all co-signers are available (see PCG.EvalSeparateForSigners)
+	seen := make(map[int]bool, len(signerSet))
+	for _, signer := range signerSet {
+		if seen[signer] {
+			return nil, fmt.Errorf("signer %d is contained multiple times in the signer set", signer)
+		}
+		seen[signer] = true
+		if signer != ownIndex && !t.provider.HasCrossShares(signer) {
+			return nil, fmt.Errorf("shares of signer %d were not evaluated", signer)
+		}
+	}
+
+	weights, err := t.localWeights(signerSet)
+	if err != nil {
+		return nil, err
+	}
+	if aggregator, ok := t.provider.(SignerSetAggregator); ok {
+		provider, err := aggregator.AggregateSignerSet(signerSet, weights)
+		if err != nil {
+			return nil, err
+		}
+		return t.reRand.forSigners(provider, signerSet), nil
+	}
+	return t.reRand.forSigners(newSignerSetShares(t.provider, signerSet, weights), signerSet), nil
+}
+
+// localWeights returns the weights of the shares of the parties of the signer set (see SetSharingScheme).
+func (t *SeparateBBSPlusTupleGenerator) localWeights(signerSet []int) ([]*bls12381.Fr, error) {
+	if t.scheme == nil {
+		return sharing.LagrangeCoefficientsAtZero(signerSet)
+	}
+	return t.scheme.LocalWeights(signerSet)
+}
+
+// finalize returns the tuple of the given shares, tagged with the given root index if tag is not nil, with the
+// commitment base for the generators if they are not nil and with the share commitments if committer is not nil.
+func finalize(skShare *bls12381.Fr, shares *Shares, tag *TupleTag, generators *Generators, committer *shareCommitter, index int) *BBSPlusTuple {
+	tuple := NewBBSPlusTuple(skShare, shares.A, shares.E, shares.S, shares.Alpha, shares.Delta)
+	if tag != nil {
+		tuple.Tag = tag.forRoot(index)
+	}
+	if generators != nil {
+		tuple.PrecomputeBase(generators)
+	}
+	committer.commit(tuple)
+	return tuple
+}
+
+// sharesAtRoots returns the shares of the provider at the given roots, at once if it is a BatchShareProvider.
+func sharesAtRoots(provider ShareProvider, roots []*bls12381.Fr) ([]*Shares, error) {
+	if batch, ok := provider.(BatchShareProvider); ok {
+		return batch.SharesAtRoots(roots)
+	}
+	shares := make([]*Shares, len(roots))
+	for i, root := range roots {
+		var err error
+		if shares[i], err = provider.SharesAt(root); err != nil {
+			return nil, err
+		}
+	}
+	return shares, nil
+}
+
+// rootsAt returns the roots of the ring with the given indices.
+func rootsAt(ring RootSource, indices []int) ([]*bls12381.Fr, error) {
+	roots := make([]*bls12381.Fr, len(indices))
+	for i, index := range indices {
+		var err error
+		if roots[i], err = ring.RootAt(index); err != nil {
+			return nil, err
+		}
+	}
+	return roots, nil
+}
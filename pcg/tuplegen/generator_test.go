@@ -0,0 +1,210 @@
+package tuplegen
+
+import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/sharing"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+// constantShares is a SeparateShareProvider that is not backed by polynomials, i.e. it provides the same shares for
+// all roots. The cross terms with party j are (j, 10*j, 100*j, 1000*j).
+type constantShares struct {
+	ownIndex int
+	n        int
+	missing  int // missing is the party whose cross terms are not available.
+}
+
+func frOf(i uint64) *bls12381.Fr {
+	return bls12381.NewFr().FromBytes(new(big.Int).SetUint64(i).Bytes())
+}
+
+func (c *constantShares) SkShare() *bls12381.Fr { return frOf(7) }
+func (c *constantShares) OwnIndex() int         { return c.ownIndex }
+func (c *constantShares) Parties() int          { return c.n }
+func (c *constantShares) HasCrossShares(j int) bool {
+	return j >= 0 && j < c.n && j != c.ownIndex && j != c.missing
+}
+func (c *constantShares) LocalSharesAt(*bls12381.Fr) (*LocalShares, error) {
+	return &LocalShares{A: frOf(1), E: frOf(2), S: frOf(3), Alpha: frOf(4), Delta0: frOf(5), Delta1: frOf(6)}, nil
+}
+func (c *constantShares) CrossSharesAt(_ *bls12381.Fr, j int) (*CrossShares, error) {
+	if !c.HasCrossShares(j) {
+		return nil, errors.New("cross shares not available")
+	}
+	return &CrossShares{Alpha: frOf(uint64(j)), Delta0Forward: frOf(uint64(10 * j)), Delta0Backward: frOf(uint64(100 * j)), Delta1: frOf(uint64(1000 * j))}, nil
+}
+
+// expectedShares returns the sk and delta shares of party 1 for the signer set {0, 1, 2} of constantShares.
+func expectedShares(t *testing.T) (*bls12381.Fr, *bls12381.Fr) {
+	lambdas, err := sharing.LagrangeCoefficientsAtZero([]int{0, 1, 2})
+	assert.Nil(t, err)
+	skShare := bls12381.NewFr()
+	skShare.Mul(lambdas[1], frOf(7))
+
+	// l_1*(5 + 100*0 + 100*2) + l_0*10*0 + l_2*10*2 + 6 + 1000*0 + 1000*2
+	delta, tmp := bls12381.NewFr(), bls12381.NewFr()
+	delta.Mul(lambdas[1], frOf(5+200))
+	tmp.Mul(lambdas[2], frOf(20))
+	delta.Add(delta, tmp)
+	delta.Add(delta, frOf(6+2000))
+	return skShare, delta
+}
+
+// indexRing is a RootSource whose i-th root is i.
+type indexRing struct{}
+
+func (indexRing) RootAt(i int) (*bls12381.Fr, error) {
+	if i < 0 {
+		return nil, errors.New("index out of range")
+	}
+	return frOf(uint64(i)), nil
+}
+
+func TestSeparateGeneratorWithoutAggregator(t *testing.T) {
+	generator := NewSeparateGenerator(&constantShares{ownIndex: 1, n: 4, missing: 3})
+
+	tuple, err := generator.GenBBSPlusTupleAt(indexRing{}, 5, []int{0, 1, 2})
+	assert.Nil(t, err)
+	skShare, delta := expectedShares(t)
+	assert.Equal(t, skShare, tuple.SkShare)
+	assert.Equal(t, frOf(1), tuple.AShare)
+	assert.Equal(t, frOf(2), tuple.EShare)
+	assert.Equal(t, frOf(3), tuple.SShare)
+	assert.Equal(t, frOf(4+0+2), tuple.AlphaShare)
+	assert.Equal(t, delta, tuple.DeltaShare)
+	assert.Nil(t, tuple.Tag)
+
+	// Invalid signer sets yield an error, while the legacy GenBBSPlusTuple returns no tuple
+	for _, signerSet := range [][]int{{0, 2}, {0, 1, 3}, {1, 1, 2}} {
+		tuple, err = generator.GenBBSPlusTupleAt(indexRing{}, 5, signerSet)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "rejected signer set")
+		assert.Nil(t, tuple)
+		assert.Nil(t, generator.GenBBSPlusTuple(frOf(5), signerSet))
+	}
+	_, err = generator.GenBBSPlusTupleAt(indexRing{}, -1, []int{0, 1})
+	assert.NotNil(t, err)
+
+	// Batches of the fallback provider derive the same tuples
+	batch, err := generator.PrecomputeSignerSet([]int{2, 1, 0})
+	assert.Nil(t, err)
+	assert.Equal(t, "0,1,2", batch.Label)
+	tuple, err = batch.GenBBSPlusTupleAt(indexRing{}, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, frOf(4+0+2), tuple.AlphaShare)
+	assert.Equal(t, delta, tuple.DeltaShare)
+
+	// Batches require the cross terms of all co-signers
+	_, err = generator.PrecomputeAllSignerSets(2)
+	assert.NotNil(t, err)
+}
+
+func TestGeneratorTag(t *testing.T) {
+	shares := &constantShares{ownIndex: 0, n: 2, missing: -1}
+	generator := NewSeparateGenerator(shares)
+	generator.SetTag(&TupleTag{RootIndex: -1, SeedHash: [32]byte{1}})
+
+	tuple, err := generator.GenBBSPlusTupleAt(indexRing{}, 3, []int{0, 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, tuple.Tag.RootIndex)
+	assert.Equal(t, [32]byte{1}, tuple.Tag.SeedHash)
+	assert.Equal(t, -1, generator.GenBBSPlusTuple(frOf(3), []int{0, 1}).Tag.RootIndex)
+}
+
+func TestGeneratorGenerators(t *testing.T) {
+	shares := &constantShares{ownIndex: 0, n: 2, missing: -1}
+	generator := NewSeparateGenerator(shares)
+	assert.Nil(t, generator.GenBBSPlusTuple(frOf(3), []int{0, 1}).Base)
+
+	generators, err := NewGenerators(2)
+	assert.Nil(t, err)
+	generator.SetGenerators(generators)
+	tuple := generator.GenBBSPlusTuple(frOf(3), []int{0, 1})
+	assert.Equal(t, generators, tuple.Base.Generators)
+
+	batch, err := generator.PrecomputeSignerSet([]int{0, 1})
+	assert.Nil(t, err)
+	batched, err := batch.GenBBSPlusTupleAt(indexRing{}, 3)
+	assert.Nil(t, err)
+	assert.True(t, bls12381.NewG1().Equal(tuple.Base.Point, batched.Base.Point))
+}
+
+func TestGenBBSPlusTuples(t *testing.T) {
+	generator := NewBBSPlusTupleGenerator(frOf(1), indexPoly(2), indexPoly(3), indexPoly(4), indexPoly(5), indexPoly(6), indexPoly(7))
+	generator.SetTag(&TupleTag{RootIndex: -1})
+	indices := []int{3, 0, 3, 17}
+
+	tuples, err := generator.GenBBSPlusTuplesAt(indexRing{}, indices)
+	assert.Nil(t, err)
+	assert.Len(t, tuples, len(indices))
+	for k, index := range indices {
+		expected, err := generator.GenBBSPlusTupleAt(indexRing{}, index)
+		assert.Nil(t, err)
+		assert.True(t, expected.EqualsConstantTime(tuples[k]))
+		assert.Equal(t, index, tuples[k].Tag.RootIndex)
+	}
+	_, err = generator.GenBBSPlusTuplesAt(indexRing{}, []int{1, -1})
+	assert.NotNil(t, err)
+
+	roots := []*bls12381.Fr{frOf(3), frOf(5)}
+	untagged, err := generator.GenBBSPlusTuples(roots)
+	assert.Nil(t, err)
+	assert.True(t, tuples[0].EqualsConstantTime(untagged[0]))
+	assert.Equal(t, -1, untagged[1].Tag.RootIndex)
+
+	// Providers without batch support derive the shares root by root
+	separate := NewSeparateGenerator(&constantShares{ownIndex: 1, n: 4, missing: 3})
+	batch, err := separate.GenBBSPlusTuples(roots, []int{0, 1, 2})
+	assert.Nil(t, err)
+	for k, root := range roots {
+		assert.True(t, separate.GenBBSPlusTuple(root, []int{0, 1, 2}).EqualsConstantTime(batch[k]))
+	}
+	_, err = separate.GenBBSPlusTuples(roots, []int{0, 3})
+	assert.NotNil(t, err)
+}
+
+// indexPoly returns a dense polynomial of degree 2^10-1 with the coefficients seed*(i+1).
+func indexPoly(seed uint64) *poly.Polynomial {
+	coefficients := make([]*bls12381.Fr, 1<<10)
+	for i := range coefficients {
+		coefficients[i] = frOf(seed * uint64(i+1))
+	}
+	return poly.NewFromFr(coefficients)
+}
+
+func TestSeparateGeneratorOwnIndexOfPartialSignerSet(t *testing.T) {
+	// Party 2 of 4 evaluated the cross terms with signer 3 only, s.t. counterparties 0 and 1 hold no value as well
+	delta0 := NewPartyIndexed[[]*poly.Polynomial](2, 4)
+	delta0.Set(3, []*poly.Polynomial{constantPoly(8), constantPoly(9)})
+	alpha, delta1 := NewPartyIndexed[*poly.Polynomial](2, 4), NewPartyIndexed[*poly.Polynomial](2, 4)
+	alpha.Set(3, constantPoly(10))
+	delta1.Set(3, constantPoly(11))
+	shares, err := NewSeparatePolyShares(constantPoly(1), constantPoly(2), constantPoly(3), frOf(4), constantPoly(5), constantPoly(6), constantPoly(7), delta0, alpha, delta1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, shares.OwnIndex())
+	assert.Equal(t, 4, shares.Parties())
+	assert.False(t, shares.HasCrossShares(0))
+	assert.False(t, shares.HasCrossShares(2))
+	assert.True(t, shares.HasCrossShares(3))
+
+	generator := NewSeparateGenerator(shares)
+	tuple, err := generator.GenBBSPlusTupleAt(indexRing{}, 5, []int{2, 3})
+	assert.Nil(t, err)
+	assert.NotNil(t, tuple)
+	for _, signerSet := range [][]int{{0, 3}, {0, 2}, {1, 2, 3}} {
+		_, err = generator.GenBBSPlusTupleAt(indexRing{}, 5, signerSet)
+		assert.NotNil(t, err)
+	}
+	_, err = shares.CrossSharesAt(frOf(5), 0)
+	assert.NotNil(t, err)
+
+	// The cross terms must belong to the same party and counterparties
+	_, err = NewSeparatePolyShares(constantPoly(1), constantPoly(2), constantPoly(3), frOf(4), constantPoly(5), constantPoly(6), constantPoly(7), delta0, NewPartyIndexed[*poly.Polynomial](1, 4), delta1)
+	assert.NotNil(t, err)
+	_, err = NewSeparatePolyShares(constantPoly(1), constantPoly(2), constantPoly(3), frOf(4), constantPoly(5), constantPoly(6), constantPoly(7), delta0, NewPartyIndexed[*poly.Polynomial](2, 4), delta1)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,62 @@
+package tuplegen
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Tuples are consumed in the order of their sequence numbers 0, 1, 2, ..., while the PCG derives the tuple with root
+// index i at the i-th root of its ring (see RootSource). TupleRootIndex fixes the mapping between both: the tuple with
+// sequence number i is derived at the root whose index is the bit reversal of i, i.e. the order in which the
+// iterative FFT lays out its inputs. As the bit reversal is an involution on [0, size), it is a permutation, hence each
+// root is used by exactly one sequence number and all roots are used once the sequence numbers are exhausted.
+// Consecutive tuples are spread over the roots, s.t. a prefix of the sequence does not depend on a block of adjacent
+// roots. The mapping is part of the protocol: all parties must derive the tuple of a sequence number at the same root.
+
+// TupleRootSource is a RootSource of a fixed amount of roots, e.g. the ring of the PCG, whose roots can be addressed
+// by tuple sequence numbers (see TupleRootIndex).
+type TupleRootSource interface {
+	RootSource
+	Size() int
+}
+
+// TupleRootIndex returns the index of the root of the tuple with the given sequence number among size roots, i.e. the
+// bit reversal of sequence (see above). size must be a power of two.
+func TupleRootIndex(sequence, size int) (int, error) {
+	if size <= 0 || size&(size-1) != 0 {
+		return 0, fmt.Errorf("the amount of roots must be a power of two but is %d", size)
+	}
+	if sequence < 0 || sequence >= size {
+		return 0, fmt.Errorf("tuple sequence number %d is out of range [0, %d)", sequence, size)
+	}
+	if size == 1 {
+		return 0, nil
+	}
+	return int(bits.Reverse64(uint64(sequence)) >> (64 - bits.TrailingZeros64(uint64(size)))), nil
+}
+
+// TupleSequence returns the sequence number of the tuple at the root with the given index among size roots, i.e. the
+// inverse of TupleRootIndex. As the bit reversal is an involution, both coincide.
+func TupleSequence(rootIndex, size int) (int, error) {
+	return TupleRootIndex(rootIndex, size)
+}
+
+// GenBBSPlusTupleInSequence returns the BBSPlusTuple with the given sequence number, i.e. the tuple at the root
+// TupleRootIndex(sequence, ring.Size()). The tuple is tagged with its root index.
+func (t *BBSPlusTupleGenerator) GenBBSPlusTupleInSequence(ring TupleRootSource, sequence int) (*BBSPlusTuple, error) {
+	index, err := TupleRootIndex(sequence, ring.Size())
+	if err != nil {
+		return nil, err
+	}
+	return t.GenBBSPlusTupleAt(ring, index)
+}
+
+// GenBBSPlusTupleInSequence returns the BBSPlusTuple of the signer set with the given sequence number (see
+// BBSPlusTupleGenerator.GenBBSPlusTupleInSequence).
+func (t *SeparateBBSPlusTupleGenerator) GenBBSPlusTupleInSequence(ring TupleRootSource, sequence int, signerSet []int) (*BBSPlusTuple, error) {
+	index, err := TupleRootIndex(sequence, ring.Size())
+	if err != nil {
+		return nil, err
+	}
+	return t.GenBBSPlusTupleAt(ring, index, signerSet)
+}
@@ -0,0 +1,59 @@
+package tuplegen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTupleRootIndexIsPermutation(t *testing.T) {
+	for _, size := range []int{1, 2, 8, 1 << 10} {
+		used := make([]bool, size)
+		for sequence := 0; sequence < size; sequence++ {
+			index, err := TupleRootIndex(sequence, size)
+			assert.Nil(t, err)
+			assert.False(t, used[index], "root %d is used twice for size %d", index, size)
+			used[index] = true
+
+			back, err := TupleSequence(index, size)
+			assert.Nil(t, err)
+			assert.Equal(t, sequence, back)
+		}
+	}
+
+	// The mapping is fixed by the protocol
+	for sequence, index := range []int{0, 4, 2, 6, 1, 5, 3, 7} {
+		actual, err := TupleRootIndex(sequence, 8)
+		assert.Nil(t, err)
+		assert.Equal(t, index, actual)
+	}
+
+	for _, invalid := range [][2]int{{-1, 8}, {8, 8}, {0, 0}, {0, 6}} {
+		_, err := TupleRootIndex(invalid[0], invalid[1])
+		assert.NotNil(t, err, "sequence %d, size %d", invalid[0], invalid[1])
+	}
+}
+
+// sizedIndexRing is an indexRing of size roots.
+type sizedIndexRing struct {
+	indexRing
+	size int
+}
+
+func (r sizedIndexRing) Size() int { return r.size }
+
+func TestGenBBSPlusTupleInSequence(t *testing.T) {
+	ring := sizedIndexRing{size: 8}
+	generator := NewSeparateGenerator(&constantShares{ownIndex: 0, n: 2, missing: -1})
+	generator.SetTag(&TupleTag{RootIndex: -1})
+	tuple, err := generator.GenBBSPlusTupleInSequence(ring, 1, []int{0, 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, tuple.Tag.RootIndex)
+	_, err = generator.GenBBSPlusTupleInSequence(ring, 8, []int{0, 1})
+	assert.NotNil(t, err)
+
+	batch, err := generator.PrecomputeSignerSet([]int{0, 1})
+	assert.Nil(t, err)
+	tuple, err = batch.GenBBSPlusTupleInSequence(ring, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, tuple.Tag.RootIndex)
+}
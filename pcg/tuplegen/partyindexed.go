@@ -0,0 +1,101 @@
+package tuplegen
+
+import "fmt"
+
+// PartyIndexed holds a value per counterparty of the party with the own index among n parties, e.g. the cross terms
+// of the separate evaluation. In contrast to a plain slice, the own index and non-participating counterparties hold no
+// value, and accessing them is an error rather than a silent nil.
+type PartyIndexed[T any] struct {
+	own    int
+	values []T
+	set    []bool
+}
+
+// NewPartyIndexed returns an empty PartyIndexed of the party with the own index among n parties.
+// It panics if own is not in [0, n).
+func NewPartyIndexed[T any](own, n int) *PartyIndexed[T] {
+	if own < 0 || own >= n {
+		panic(fmt.Sprintf("own index %d is out of range [0, %d)", own, n))
+	}
+	return &PartyIndexed[T]{own: own, values: make([]T, n), set: make([]bool, n)}
+}
+
+// Own returns the own index, which never holds a value.
+func (p *PartyIndexed[T]) Own() int {
+	return p.own
+}
+
+// N returns the amount of parties.
+func (p *PartyIndexed[T]) N() int {
+	return len(p.values)
+}
+
+// Set sets the value of counterparty j. It panics if j is the own index or out of range.
+func (p *PartyIndexed[T]) Set(j int, value T) {
+	if j == p.own {
+		panic(fmt.Sprintf("cannot set a value for the own index %d", j))
+	}
+	if j < 0 || j >= len(p.values) {
+		panic(fmt.Sprintf("counterparty index %d is out of range [0, %d)", j, len(p.values)))
+	}
+	p.values[j], p.set[j] = value, true
+}
+
+// Has returns whether counterparty j holds a value.
+func (p *PartyIndexed[T]) Has(j int) bool {
+	return j >= 0 && j < len(p.set) && p.set[j]
+}
+
+// Get returns the value of counterparty j. It errs if j is the own index, out of range or holds no value.
+func (p *PartyIndexed[T]) Get(j int) (T, error) {
+	var zero T
+	switch {
+	case j == p.own:
+		return zero, fmt.Errorf("index %d is the own index", j)
+	case j < 0 || j >= len(p.values):
+		return zero, fmt.Errorf("counterparty index %d is out of range [0, %d)", j, len(p.values))
+	case !p.set[j]:
+		return zero, fmt.Errorf("counterparty %d holds no value", j)
+	}
+	return p.values[j], nil
+}
+
+// ForEachOther calls fn for each counterparty holding a value in ascending order and stops at the first error, which
+// it returns as is.
+func (p *PartyIndexed[T]) ForEachOther(fn func(j int, value T) error) error {
+	for j, ok := range p.set {
+		if !ok {
+			continue
+		}
+		if err := fn(j, p.values[j]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Slice returns the values as a slice indexed by party, which holds the zero value at the own index and at the
+// counterparties without value.
+func (p *PartyIndexed[T]) Slice() []T {
+	values := make([]T, len(p.values))
+	copy(values, p.values)
+	return values
+}
+
+// MapPartyIndexed returns the PartyIndexed holding fn(j, value) for each counterparty j of p holding a value. It stops
+// at the first error of fn, which it returns as is.
+func MapPartyIndexed[T, U any](p *PartyIndexed[T], fn func(j int, value T) (U, error)) (*PartyIndexed[U], error) {
+	mapped := NewPartyIndexed[U](p.own, len(p.values))
+	err := p.ForEachOther(func(j int, value T) error {
+		result, err := fn(j, value)
+		if err != nil {
+			return err
+		}
+		mapped.Set(j, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapped, nil
+}
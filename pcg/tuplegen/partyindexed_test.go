@@ -0,0 +1,68 @@
+package tuplegen
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPartyIndexed(t *testing.T) {
+	values := NewPartyIndexed[int](1, 4)
+	assert.Equal(t, 1, values.Own())
+	assert.Equal(t, 4, values.N())
+	values.Set(3, 30)
+	values.Set(0, 0)
+
+	// ForEachOther visits the counterparties holding a value in ascending order
+	var visited []int
+	assert.Nil(t, values.ForEachOther(func(j int, value int) error {
+		assert.Equal(t, 10*j, value)
+		visited = append(visited, j)
+		return nil
+	}))
+	assert.Equal(t, []int{0, 3}, visited)
+
+	value, err := values.Get(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 30, value)
+	assert.True(t, values.Has(0))
+	assert.False(t, values.Has(1))
+	assert.False(t, values.Has(2))
+	assert.Equal(t, []int{0, 0, 0, 30}, values.Slice())
+
+	// The own index, missing counterparties and indices out of range hold no value
+	for _, j := range []int{1, 2, -1, 4} {
+		_, err = values.Get(j)
+		assert.NotNil(t, err, "index %d", j)
+	}
+	assert.Panics(t, func() { values.Set(1, 0) })
+	assert.Panics(t, func() { values.Set(4, 0) })
+	assert.Panics(t, func() { NewPartyIndexed[int](4, 4) })
+}
+
+func TestPartyIndexedErrors(t *testing.T) {
+	values := NewPartyIndexed[int](0, 3)
+	values.Set(1, 1)
+	values.Set(2, 2)
+
+	// ForEachOther and MapPartyIndexed stop at the first error
+	calls := 0
+	err := values.ForEachOther(func(j int, value int) error {
+		calls++
+		return fmt.Errorf("counterparty %d", j)
+	})
+	assert.EqualError(t, err, "counterparty 1")
+	assert.Equal(t, 1, calls)
+
+	_, err = MapPartyIndexed(values, func(j int, value int) (string, error) {
+		return "", fmt.Errorf("counterparty %d", j)
+	})
+	assert.EqualError(t, err, "counterparty 1")
+
+	mapped, err := MapPartyIndexed(values, func(j int, value int) (string, error) {
+		return fmt.Sprint(value * j), nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, mapped.Own())
+	assert.Equal(t, []string{"", "1", "4"}, mapped.Slice())
+}
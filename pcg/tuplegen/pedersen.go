@@ -0,0 +1,201 @@
+package tuplegen
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"io"
+)
+
+// pedersenDomainSeparator is the domain separation tag of the hash to curve deriving the generator h of the
+// Pedersen commitments. It differs from the tag of the BBS+ generators, s.t. the discrete logarithms of h to the BBS+
+// generators are unknown.
+const pedersenDomainSeparator = "pcg-bbs-plus/pedersen/h/v1"
+
+// Domain separators of the blinding factors of the commitments to the components of a tuple.
+const (
+	commitA byte = iota + 1
+	commitE
+	commitS
+)
+
+// PedersenParams are the public generators g and h of Pedersen commitments g^v * h^r over G1. h is derived by hashing
+// to G1, s.t. all parties agree on it without interaction and nobody knows its discrete logarithm to g.
+type PedersenParams struct {
+	G *bls12381.PointG1
+	H *bls12381.PointG1
+}
+
+// NewPedersenParams returns the Pedersen parameters with g the generator of G1.
+func NewPedersenParams() (*PedersenParams, error) {
+	g1 := bls12381.NewG1()
+	h, err := g1.HashToCurve([]byte("h"), []byte(pedersenDomainSeparator))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive the generator h: %w", err)
+	}
+	return &PedersenParams{G: g1.One(), H: h}, nil
+}
+
+// Commit returns the commitment g^value * h^blinding.
+func (p *PedersenParams) Commit(value, blinding *bls12381.Fr) *bls12381.PointG1 {
+	g1 := bls12381.NewG1()
+	commitment, tmp := g1.New(), g1.New()
+	g1.MulScalar(commitment, p.G, value)
+	g1.MulScalar(tmp, p.H, blinding)
+	return g1.Add(commitment, commitment, tmp)
+}
+
+// VerifyOpening returns an error if the commitment does not open to the value with the blinding factor.
+func (p *PedersenParams) VerifyOpening(commitment *bls12381.PointG1, value, blinding *bls12381.Fr) error {
+	if !bls12381.NewG1().Equal(commitment, p.Commit(value, blinding)) {
+		return fmt.Errorf("the commitment does not open to the value")
+	}
+	return nil
+}
+
+// CommitmentKey is the secret PRF key a party derives the blinding factors of its share commitments from. The
+// blinding factors of a tuple only depend on its shares, s.t. the party can reopen the commitments of any tuple it
+// holds without storing them.
+type CommitmentKey [32]byte
+
+// NewCommitmentKey samples a commitment key from rng, e.g. crypto/rand.
+func NewCommitmentKey(rng io.Reader) (*CommitmentKey, error) {
+	var key CommitmentKey
+	if _, err := io.ReadFull(rng, key[:]); err != nil {
+		return nil, fmt.Errorf("failed to sample the commitment key: %w", err)
+	}
+	return &key, nil
+}
+
+// Openings returns the blinding factors of the commitments to the shares of the tuple (see BBSPlusTuple.CommitShares).
+func (k *CommitmentKey) Openings(t *BBSPlusTuple) *ShareOpenings {
+	// The shares of distinct tuples differ with overwhelming probability, hence so do their blinding factors
+	context := bytes.Join([][]byte{t.AShare.ToBytes(), t.EShare.ToBytes(), t.SShare.ToBytes()}, nil)
+	return &ShareOpenings{
+		A: k.blindingFactor(context, commitA),
+		E: k.blindingFactor(context, commitE),
+		S: k.blindingFactor(context, commitS),
+	}
+}
+
+// blindingFactor evaluates the PRF keyed by the commitment key on the context and separator (see prf).
+func (k *CommitmentKey) blindingFactor(context []byte, separator byte) *bls12381.Fr {
+	mac := hmac.New(sha256.New, k[:])
+	mac.Write(context)
+	return prf([32]byte(mac.Sum(nil)), nil, separator)
+}
+
+// ShareOpenings are the blinding factors of the commitments to the AShare, EShare and SShare of a tuple. They are as
+// secret as the shares themselves.
+type ShareOpenings struct {
+	A, E, S *bls12381.Fr
+}
+
+// ShareCommitments are Pedersen commitments to the AShare, EShare and SShare of a party's tuple. They can be
+// published alongside the tuple, s.t. higher-level protocols can prove statements about the shares, e.g. the
+// correctness of a partial signature, without revealing them. As the commitments are additively homomorphic, the
+// product of the commitments of all parties commits to the reconstructed values (see CombineShareCommitments).
+type ShareCommitments struct {
+	A, E, S *bls12381.PointG1
+
+	params *PedersenParams // params are the parameters the commitments were computed with.
+	key    *CommitmentKey  // key derives the blinding factors, s.t. the commitments can be recomputed by Blind.
+}
+
+// CommitShares returns the Pedersen commitments to the AShare, EShare and SShare of the tuple and their blinding
+// factors derived from the commitment key of the party.
+func (t *BBSPlusTuple) CommitShares(params *PedersenParams, key *CommitmentKey) (*ShareCommitments, *ShareOpenings) {
+	openings := key.Openings(t)
+	return &ShareCommitments{
+		A:      params.Commit(t.AShare, openings.A),
+		E:      params.Commit(t.EShare, openings.E),
+		S:      params.Commit(t.SShare, openings.S),
+		params: params,
+		key:    key,
+	}, openings
+}
+
+// VerifyOpenings returns an error if the commitments do not open to the shares of the tuple with the openings.
+func (c *ShareCommitments) VerifyOpenings(params *PedersenParams, t *BBSPlusTuple, openings *ShareOpenings) error {
+	for _, component := range []struct {
+		name       string
+		commitment *bls12381.PointG1
+		share      *bls12381.Fr
+		blinding   *bls12381.Fr
+	}{
+		{"AShare", c.A, t.AShare, openings.A},
+		{"EShare", c.E, t.EShare, openings.E},
+		{"SShare", c.S, t.SShare, openings.S},
+	} {
+		if err := params.VerifyOpening(component.commitment, component.share, component.blinding); err != nil {
+			return fmt.Errorf("%s: %w", component.name, err)
+		}
+	}
+	return nil
+}
+
+// Serialize returns the compressed encodings of the commitments A, E and S.
+func (c *ShareCommitments) Serialize() []byte {
+	g1 := bls12381.NewG1()
+	return bytes.Join([][]byte{g1.ToCompressed(c.A), g1.ToCompressed(c.E), g1.ToCompressed(c.S)}, nil)
+}
+
+// DeserializeShareCommitments decodes commitments encoded by ShareCommitments.Serialize.
+func DeserializeShareCommitments(data []byte) (*ShareCommitments, error) {
+	const pointSize = 48
+	if len(data) != 3*pointSize {
+		return nil, fmt.Errorf("serialized share commitments are %d bytes long but must be %d bytes long", len(data), 3*pointSize)
+	}
+	g1 := bls12381.NewG1()
+	points := make([]*bls12381.PointG1, 3)
+	for i := range points {
+		point, err := g1.FromCompressed(data[i*pointSize : (i+1)*pointSize])
+		if err != nil {
+			return nil, fmt.Errorf("invalid commitment %d: %w", i, err)
+		}
+		points[i] = point
+	}
+	return &ShareCommitments{A: points[0], E: points[1], S: points[2]}, nil
+}
+
+// CombineShareCommitments returns the products of the commitments of all parties, which commit to the reconstructed
+// values a, e and s with the sums of the blinding factors of the parties.
+func CombineShareCommitments(commitments []*ShareCommitments) (*ShareCommitments, error) {
+	if len(commitments) == 0 {
+		return nil, fmt.Errorf("no share commitments to combine")
+	}
+	g1 := bls12381.NewG1()
+	combined := &ShareCommitments{A: g1.Zero(), E: g1.Zero(), S: g1.Zero()}
+	for i, c := range commitments {
+		if c == nil {
+			return nil, fmt.Errorf("share commitments %d must not be nil", i)
+		}
+		g1.Add(combined.A, combined.A, c.A)
+		g1.Add(combined.E, combined.E, c.E)
+		g1.Add(combined.S, combined.S, c.S)
+	}
+	return combined, nil
+}
+
+// shareCommitter holds the parameters and key a generator commits to the shares of its tuples with.
+type shareCommitter struct {
+	params *PedersenParams
+	key    *CommitmentKey
+}
+
+// commit sets the share commitments of the tuple if the committer is not nil.
+func (c *shareCommitter) commit(t *BBSPlusTuple) {
+	if c != nil {
+		t.Commitments, _ = t.CommitShares(c.params, c.key)
+	}
+}
+
+// newShareCommitter returns the committer of the parameters and key, nil if either is nil.
+func newShareCommitter(params *PedersenParams, key *CommitmentKey) *shareCommitter {
+	if params == nil || key == nil {
+		return nil
+	}
+	return &shareCommitter{params: params, key: key}
+}
@@ -0,0 +1,87 @@
+package tuplegen_test
+
+import (
+	"crypto/rand"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestShareCommitments(t *testing.T) {
+	params, err := tuplegen.NewPedersenParams()
+	assert.Nil(t, err)
+	key, err := tuplegen.NewCommitmentKey(rand.Reader)
+	assert.Nil(t, err)
+
+	tuple := randomTuple(t)
+	commitments, openings := tuple.CommitShares(params, key)
+	assert.Nil(t, commitments.VerifyOpenings(params, tuple, openings))
+	assert.Equal(t, openings, key.Openings(tuple)) // the openings are rederived from the key
+
+	// The commitments hide the shares, i.e. another key yields other commitments, and bind them
+	other, err := tuplegen.NewCommitmentKey(rand.Reader)
+	assert.Nil(t, err)
+	otherCommitments, _ := tuple.CommitShares(params, other)
+	assert.False(t, bls12381.NewG1().Equal(commitments.A, otherCommitments.A))
+	assert.NotNil(t, commitments.VerifyOpenings(params, randomTuple(t), openings))
+
+	deserialized, err := tuplegen.DeserializeShareCommitments(commitments.Serialize())
+	assert.Nil(t, err)
+	assert.Nil(t, deserialized.VerifyOpenings(params, tuple, openings))
+	_, err = tuplegen.DeserializeShareCommitments(commitments.Serialize()[1:])
+	assert.NotNil(t, err)
+}
+
+func TestCombineShareCommitments(t *testing.T) {
+	params, err := tuplegen.NewPedersenParams()
+	assert.Nil(t, err)
+
+	// The combined commitments of all parties open to the reconstructed values with the summed blinding factors
+	tuples := []*tuplegen.BBSPlusTuple{randomTuple(t), randomTuple(t), randomTuple(t)}
+	commitments := make([]*tuplegen.ShareCommitments, len(tuples))
+	blinding := []*bls12381.Fr{bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr()}
+	for i, tuple := range tuples {
+		key, err := tuplegen.NewCommitmentKey(rand.Reader)
+		assert.Nil(t, err)
+		var openings *tuplegen.ShareOpenings
+		commitments[i], openings = tuple.CommitShares(params, key)
+		for k, factor := range []*bls12381.Fr{openings.A, openings.E, openings.S} {
+			blinding[k].Add(blinding[k], factor)
+		}
+	}
+	combined, err := tuplegen.CombineShareCommitments(commitments)
+	assert.Nil(t, err)
+	sums := sumTuples(tuples)
+	reconstructed := tuplegen.NewBBSPlusTuple(bls12381.NewFr(), sums[0], sums[1], sums[2], sums[3], sums[4])
+	assert.Nil(t, combined.VerifyOpenings(params, reconstructed, &tuplegen.ShareOpenings{A: blinding[0], E: blinding[1], S: blinding[2]}))
+
+	_, err = tuplegen.CombineShareCommitments(nil)
+	assert.NotNil(t, err)
+}
+
+func TestGeneratorShareCommitments(t *testing.T) {
+	params, err := tuplegen.NewPedersenParams()
+	assert.Nil(t, err)
+	key, err := tuplegen.NewCommitmentKey(rand.Reader)
+	assert.Nil(t, err)
+	polys := make([]*poly.Polynomial, 6)
+	for i := range polys {
+		polys[i] = poly.NewFromFr([]*bls12381.Fr{randomFr(t), randomFr(t)})
+	}
+	generator := tuplegen.NewBBSPlusTupleGenerator(randomFr(t), polys[0], polys[1], polys[2], polys[3], polys[4], polys[5])
+	generator.SetShareCommitments(params, key)
+	tuple := generator.GenBBSPlusTuple(randomFr(t))
+	assert.NotNil(t, tuple.Commitments)
+	assert.Nil(t, tuple.Commitments.VerifyOpenings(params, tuple, key.Openings(tuple)))
+
+	// Blinding the tuple recomputes its commitments
+	zeroKeys, err := tuplegen.NewZeroSharingKeys(rand.Reader, 2)
+	assert.Nil(t, err)
+	assert.Nil(t, tuple.Blind([]byte("session"), zeroKeys[0]))
+	assert.Nil(t, tuple.Commitments.VerifyOpenings(params, tuple, key.Openings(tuple)))
+
+	generator.SetShareCommitments(params, nil)
+	assert.Nil(t, generator.GenBBSPlusTuple(randomFr(t)).Commitments)
+}
@@ -0,0 +1,264 @@
+package tuplegen
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+)
+
+// PolyShares is a ShareProvider holding the shares as polynomials, whose evaluation at a root yields the shares for
+// that root. It is provided by the PCG for the n-out-of-n setting.
+type PolyShares struct {
+	skShare    *bls12381.Fr
+	aPoly      *poly.Polynomial
+	ePoly      *poly.Polynomial
+	sPoly      *poly.Polynomial
+	alphaPoly  *poly.Polynomial
+	delta0Poly *poly.Polynomial
+	delta1Poly *poly.Polynomial
+	deltaPoly  *poly.Polynomial
+	maxDegree  int           // maxDegree is the maximal degree of the polynomials, for which the roots are prepared
+	stats      *statsCounter // stats records the evaluations in the statistics of the generator, nil if none
+}
+
+// NewPolyShares returns a new PolyShares.
+func NewPolyShares(skShare *bls12381.Fr, aPoly, ePoly, sPoly, alphaPoly, delta0Poly, delta1Poly *poly.Polynomial) *PolyShares {
+	shares := &PolyShares{
+		skShare:    skShare,
+		aPoly:      aPoly,
+		ePoly:      ePoly,
+		sPoly:      sPoly,
+		alphaPoly:  alphaPoly,
+		delta0Poly: delta0Poly, // Store delta0Poly and delta1Poly separately for testing purposes.
+		delta1Poly: delta1Poly,
+		deltaPoly:  poly.Add(delta0Poly, delta1Poly),
+	}
+	shares.maxDegree = maxDegree(aPoly, ePoly, sPoly, alphaPoly, delta0Poly, shares.deltaPoly)
+	return shares
+}
+
+// SkShare returns the share of the secret key.
+func (p *PolyShares) SkShare() *bls12381.Fr {
+	return p.skShare
+}
+
+// SharesAt evaluates the polynomials at the given root. The powers of the root are computed once for all polynomials.
+func (p *PolyShares) SharesAt(root *bls12381.Fr) (*Shares, error) {
+	shares, err := p.SharesAtRoots([]*bls12381.Fr{root})
+	if err != nil {
+		return nil, err
+	}
+	return shares[0], nil
+}
+
+// SharesAtRoots evaluates the polynomials at all given roots in a single pass over the coefficients of each
+// polynomial (see poly.Polynomial.EvaluateAtPoints).
+func (p *PolyShares) SharesAtRoots(roots []*bls12381.Fr) ([]*Shares, error) {
+	points := p.points(roots)
+	a := p.stats.evaluate(ShareA, p.aPoly, points)
+	e := p.stats.evaluate(ShareE, p.ePoly, points)
+	s := p.stats.evaluate(ShareS, p.sPoly, points)
+	alpha := p.stats.evaluate(ShareAlpha, p.alphaPoly, points)
+	delta := p.stats.evaluate(ShareDelta, p.deltaPoly, points)
+
+	shares := make([]*Shares, len(roots))
+	for i := range shares {
+		shares[i] = &Shares{A: a[i], E: e[i], S: s[i], Alpha: alpha[i], Delta: delta[i]}
+	}
+	return shares, nil
+}
+
+// VOLESharesAt evaluates the polynomials of the VOLE correlation at the given root.
+func (p *PolyShares) VOLESharesAt(root *bls12381.Fr) (*VOLEShares, error) {
+	points := p.points([]*bls12381.Fr{root})
+	return &VOLEShares{
+		A:      p.stats.evaluate(ShareA, p.aPoly, points)[0],
+		Delta0: p.stats.evaluate(ShareDelta, p.delta0Poly, points)[0],
+	}, nil
+}
+
+// points prepares the roots for the evaluation of the polynomials.
+func (p *PolyShares) points(roots []*bls12381.Fr) []*poly.EvaluationPoint {
+	points := make([]*poly.EvaluationPoint, len(roots))
+	for i, root := range roots {
+		points[i] = poly.NewEvaluationPoint(root, p.maxDegree)
+	}
+	return points
+}
+
+func (p *PolyShares) recordStats(stats *statsCounter) {
+	p.stats = stats
+}
+
+// SeparatePolyShares is a SeparateShareProvider holding the shares as polynomials. It is provided by the PCG for the
+// tau-out-of-n setting, where only the cross terms of the evaluated counterparties hold a value.
+type SeparatePolyShares struct {
+	usk        *poly.Polynomial
+	uk         *poly.Polynomial
+	uv         *poly.Polynomial
+	skShare    *bls12381.Fr
+	aPoly      *poly.Polynomial
+	ePoly      *poly.Polynomial
+	sPoly      *poly.Polynomial
+	alphaPoly  *PartyIndexed[*poly.Polynomial]
+	delta0Poly *PartyIndexed[[]*poly.Polynomial] // delta0Poly holds the shares of both directions per counterparty (see ForwardDirection)
+	delta1Poly *PartyIndexed[*poly.Polynomial]
+	maxDegree  int           // maxDegree is the maximal degree of the local terms, for which the roots are prepared
+	stats      *statsCounter // stats records the evaluations in the statistics of the generator, nil if none
+}
+
+// NewSeparatePolyShares returns a new SeparatePolyShares for the party, whose signer index is the own index of the
+// cross terms. usk, uk and uv are the local terms of delta0, alpha and delta1, while delta0Poly, alphaPoly and
+// delta1Poly hold the cross terms with each evaluated counterparty. usk = u*sk_i and skShare = sk_i are not yet
+// weighted by the Lagrange coefficient of the party, as it depends on the signer set (see ForwardDirection).
+// It returns an error if the cross terms belong to different parties or counterparties.
+func NewSeparatePolyShares(usk, uk, uv *poly.Polynomial, skShare *bls12381.Fr, aPoly, ePoly, sPoly *poly.Polynomial, delta0Poly *PartyIndexed[[]*poly.Polynomial], alphaPoly, delta1Poly *PartyIndexed[*poly.Polynomial]) (*SeparatePolyShares, error) {
+	own, n := delta1Poly.Own(), delta1Poly.N()
+	if alphaPoly.Own() != own || delta0Poly.Own() != own || alphaPoly.N() != n || delta0Poly.N() != n {
+		return nil, fmt.Errorf("cross terms belong to different parties")
+	}
+	for j := 0; j < n; j++ {
+		if alphaPoly.Has(j) != delta1Poly.Has(j) || delta0Poly.Has(j) != delta1Poly.Has(j) {
+			return nil, fmt.Errorf("cross terms with counterparty %d are incomplete", j)
+		}
+		if delta0J, err := delta0Poly.Get(j); err == nil && len(delta0J) != 2 {
+			return nil, fmt.Errorf("cross terms of delta0 with counterparty %d hold %d instead of 2 directions", j, len(delta0J))
+		}
+	}
+	shares := &SeparatePolyShares{
+		usk:        usk,
+		uk:         uk,
+		uv:         uv,
+		skShare:    skShare,
+		aPoly:      aPoly,
+		ePoly:      ePoly,
+		sPoly:      sPoly,
+		alphaPoly:  alphaPoly,
+		delta0Poly: delta0Poly,
+		delta1Poly: delta1Poly,
+	}
+	shares.maxDegree = maxDegree(usk, uk, uv, aPoly, ePoly, sPoly)
+	return shares, nil
+}
+
+// SkShare returns the share of the secret key.
+func (p *SeparatePolyShares) SkShare() *bls12381.Fr {
+	return p.skShare
+}
+
+// OwnIndex returns the signer index of the party.
+func (p *SeparatePolyShares) OwnIndex() int {
+	return p.delta1Poly.Own()
+}
+
+// Parties returns the amount of parties n.
+func (p *SeparatePolyShares) Parties() int {
+	return p.delta1Poly.N()
+}
+
+// HasCrossShares returns whether the cross terms with party j were evaluated.
+func (p *SeparatePolyShares) HasCrossShares(j int) bool {
+	return p.delta1Poly.Has(j)
+}
+
+// LocalSharesAt evaluates the local terms at the given root.
+func (p *SeparatePolyShares) LocalSharesAt(root *bls12381.Fr) (*LocalShares, error) {
+	points := []*poly.EvaluationPoint{poly.NewEvaluationPoint(root, p.maxDegree)}
+	return &LocalShares{
+		A:      p.stats.evaluate(ShareA, p.aPoly, points)[0],
+		E:      p.stats.evaluate(ShareE, p.ePoly, points)[0],
+		S:      p.stats.evaluate(ShareS, p.sPoly, points)[0],
+		Alpha:  p.stats.evaluate(ShareAlpha, p.uk, points)[0],
+		Delta0: p.stats.evaluate(ShareDelta, p.usk, points)[0],
+		Delta1: p.stats.evaluate(ShareDelta, p.uv, points)[0],
+	}, nil
+}
+
+// CrossSharesAt evaluates the cross terms with party j at the given root.
+func (p *SeparatePolyShares) CrossSharesAt(root *bls12381.Fr, j int) (*CrossShares, error) {
+	alphaJ, delta0J, delta1J, err := p.crossTerms(j)
+	if err != nil {
+		return nil, err
+	}
+	points := []*poly.EvaluationPoint{poly.NewEvaluationPoint(root, p.maxDegree)}
+	return &CrossShares{
+		Alpha:          p.stats.evaluate(ShareAlpha, alphaJ, points)[0],
+		Delta0Forward:  p.stats.evaluate(ShareDelta, delta0J[ForwardDirection], points)[0],
+		Delta0Backward: p.stats.evaluate(ShareDelta, delta0J[BackwardDirection], points)[0],
+		Delta1:         p.stats.evaluate(ShareDelta, delta1J, points)[0],
+	}, nil
+}
+
+// crossTerms returns the cross terms of alpha, delta0 and delta1 with party j.
+func (p *SeparatePolyShares) crossTerms(j int) (alpha *poly.Polynomial, delta0 []*poly.Polynomial, delta1 *poly.Polynomial, err error) {
+	if !p.HasCrossShares(j) {
+		return nil, nil, nil, fmt.Errorf("shares of signer %d were not evaluated", j)
+	}
+	if alpha, err = p.alphaPoly.Get(j); err != nil {
+		return nil, nil, nil, err
+	}
+	if delta0, err = p.delta0Poly.Get(j); err != nil {
+		return nil, nil, nil, err
+	}
+	if delta1, err = p.delta1Poly.Get(j); err != nil {
+		return nil, nil, nil, err
+	}
+	return alpha, delta0, delta1, nil
+}
+
+// AggregateSignerSet aggregates the cross terms with the co-signers of the signer set and the local terms
+// to the shares alpha_i, delta_0i and delta_1i for the signer set. delta_0i and the sk share are weighted by the
+// weights of the signer set, e.g. its Lagrange coefficients (see ForwardDirection).
+func (p *SeparatePolyShares) AggregateSignerSet(signerSet []int, lambdas []*bls12381.Fr) (ShareProvider, error) {
+	ownIndex := p.OwnIndex()
+	var own *bls12381.Fr
+	for k, signer := range signerSet {
+		if signer == ownIndex {
+			own = lambdas[k]
+		}
+	}
+
+	// Calculate delta_0i based on the signer set: the local and backward terms hold the own sk share, while each
+	// forward term holds the sk share of the co-signer. alpha_i and delta_1i sum the cross terms with the co-signers.
+	delta0i := p.usk.DeepCopy()
+	forward := poly.NewEmpty()
+	alphai := poly.NewEmpty()
+	delta1i := poly.NewEmpty()
+	for k, signer := range signerSet {
+		if signer == ownIndex {
+			continue
+		}
+		alphaJ, delta0J, delta1J, err := p.crossTerms(signer)
+		if err != nil {
+			return nil, err
+		}
+		delta0i.Add(delta0J[BackwardDirection])
+		forward.Add(delta0J[ForwardDirection].MulByConstantInto(poly.NewEmpty(), lambdas[k]))
+		alphai.Add(alphaJ)
+		delta1i.Add(delta1J)
+	}
+	delta0i.MulByConstant(own)
+	delta0i.Add(forward)
+	alphai.Add(p.uk)
+	delta1i.Add(p.uv)
+
+	skShare := bls12381.NewFr()
+	skShare.Mul(own, p.skShare)
+	shares := NewPolyShares(skShare, p.aPoly, p.ePoly, p.sPoly, alphai, delta0i, delta1i)
+	shares.stats = p.stats
+	return shares, nil
+}
+
+func (p *SeparatePolyShares) recordStats(stats *statsCounter) {
+	p.stats = stats
+}
+
+// maxDegree returns the maximal degree of the polynomials.
+func maxDegree(polys ...*poly.Polynomial) int {
+	degree := poly.DegreeOfZero
+	for _, p := range polys {
+		d, _ := p.Degree()
+		degree = max(degree, d)
+	}
+	return degree
+}
@@ -0,0 +1,164 @@
+package tuplegen
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+)
+
+// The shares of delta0 with a co-signer consist of both directions of the VOLE correlation, i.e. the DSPF keys of the
+// party for the co-signer (forward) and of the co-signer for the party (backward).
+//
+// In the tau-out-of-n setting, sk_i is the Shamir share of party i and the signer index of a party is the index of its
+// share. For a signer set S with the Lagrange coefficients l_j at zero, sk = sum_{j in S} l_j*sk_j and a = sum_{i in S}
+// a_i, hence a*sk = sum_{i in S} sum_{j in S} l_j*a_i*sk_j. The forward term of party i with co-signer j is its share of
+// a_i*sk_j and the backward term is its share of a_j*sk_i, hence party i holds the share
+//
+//	delta0_i = l_i*(a_i*sk_i + sum_{j in S, j != i} backward_j) + sum_{j in S, j != i} l_j*forward_j
+//
+// of a*sk and the share l_i*sk_i of sk, which is correct for any signer set of at least tau parties.
+const (
+	ForwardDirection  = 0
+	BackwardDirection = 1
+)
+
+// RootSource provides the roots at which tuples are derived, e.g. the ring of the PCG.
+type RootSource interface {
+	RootAt(i int) (*bls12381.Fr, error)
+}
+
+// Shares are the shares of a party at a single root, from which its tuple is finalized.
+type Shares struct {
+	A     *bls12381.Fr
+	E     *bls12381.Fr
+	S     *bls12381.Fr
+	Alpha *bls12381.Fr // Alpha is the share of a*s
+	Delta *bls12381.Fr // Delta is the share of a*(sk+e), i.e. the sum of the shares delta0 of sk*a and delta1 of a*e
+}
+
+// ShareProvider provides the shares of a party for the n-out-of-n setting.
+type ShareProvider interface {
+	// SkShare returns the share of the secret key.
+	SkShare() *bls12381.Fr
+	// SharesAt returns the shares at the given root.
+	SharesAt(root *bls12381.Fr) (*Shares, error)
+}
+
+// BatchShareProvider is a ShareProvider that provides the shares at many roots at once more efficiently than root by
+// root, e.g. by evaluating its polynomials at all roots in a single pass (see GenBBSPlusTuples).
+type BatchShareProvider interface {
+	ShareProvider
+	// SharesAtRoots returns the shares at the given roots in their order.
+	SharesAtRoots(roots []*bls12381.Fr) ([]*Shares, error)
+}
+
+// VOLEShares are the shares of a party of the VOLE correlation sk*a at a single root.
+type VOLEShares struct {
+	A      *bls12381.Fr
+	Delta0 *bls12381.Fr // Delta0 is the share of sk*a
+}
+
+// VOLEProvider is a ShareProvider that additionally provides the shares of the VOLE correlation sk*a, which Shares
+// only holds as part of Delta.
+type VOLEProvider interface {
+	ShareProvider
+	// VOLESharesAt returns the shares of the VOLE correlation at the given root.
+	VOLESharesAt(root *bls12381.Fr) (*VOLEShares, error)
+}
+
+// LocalShares are the shares of a party at a single root that do not depend on the co-signers.
+type LocalShares struct {
+	A      *bls12381.Fr
+	E      *bls12381.Fr
+	S      *bls12381.Fr
+	Alpha  *bls12381.Fr // Alpha is the local term a_i*s_i of a*s
+	Delta0 *bls12381.Fr // Delta0 is the local term a_i*sk_i of sk*a, which is not yet weighted by the Lagrange coefficient
+	Delta1 *bls12381.Fr // Delta1 is the local term a_i*e_i of a*e
+}
+
+// CrossShares are the shares of the cross terms of a party with a single co-signer at a single root.
+type CrossShares struct {
+	Alpha          *bls12381.Fr // Alpha is the share of the cross terms of a*s
+	Delta0Forward  *bls12381.Fr // Delta0Forward is the share of a_i*sk_j of the party i with the co-signer j
+	Delta0Backward *bls12381.Fr // Delta0Backward is the share of a_j*sk_i of the party i with the co-signer j
+	Delta1         *bls12381.Fr // Delta1 is the share of the cross terms of a*e
+}
+
+// SeparateShareProvider provides the shares of a party for the tau-out-of-n setting. The cross terms with each
+// co-signer are provided separately, s.t. they can be combined for any signer set.
+type SeparateShareProvider interface {
+	// SkShare returns the share of the secret key.
+	SkShare() *bls12381.Fr
+	// OwnIndex returns the signer index of the party.
+	OwnIndex() int
+	// Parties returns the amount of parties n.
+	Parties() int
+	// HasCrossShares returns whether the cross terms with party j are available.
+	HasCrossShares(j int) bool
+	// LocalSharesAt returns the local terms at the given root.
+	LocalSharesAt(root *bls12381.Fr) (*LocalShares, error)
+	// CrossSharesAt returns the shares of the cross terms with party j at the given root.
+	CrossSharesAt(root *bls12381.Fr, j int) (*CrossShares, error)
+}
+
+// SignerSetAggregator is implemented by SeparateShareProviders that can combine the cross terms of a signer set once
+// for all roots, which is more efficient than combining them per root. The signer set is validated beforehand and
+// weights[k] is the weight of the sk share of signerSet[k] (see sharing.Scheme.LocalWeights).
+type SignerSetAggregator interface {
+	AggregateSignerSet(signerSet []int, weights []*bls12381.Fr) (ShareProvider, error)
+}
+
+// signerSetShares is the ShareProvider of a signer set for SeparateShareProviders that do not implement
+// SignerSetAggregator. It combines the local terms with the cross terms of the co-signers per root.
+type signerSetShares struct {
+	provider  SeparateShareProvider
+	signerSet []int
+	lambdas   []*bls12381.Fr // lambdas are the weights of the sk shares of the signer set, e.g. Lagrange coefficients
+	own       *bls12381.Fr   // own is the weight of the sk share of the party
+}
+
+// newSignerSetShares returns the ShareProvider of the validated signer set with the given weights of the sk shares.
+func newSignerSetShares(provider SeparateShareProvider, signerSet []int, weights []*bls12381.Fr) *signerSetShares {
+	shares := &signerSetShares{provider: provider, signerSet: signerSet, lambdas: weights}
+	for k, signer := range signerSet {
+		if signer == provider.OwnIndex() {
+			shares.own = weights[k]
+		}
+	}
+	return shares
+}
+
+// SkShare returns the share of the secret key weighted by the weight of the party.
+func (s *signerSetShares) SkShare() *bls12381.Fr {
+	skShare := bls12381.NewFr()
+	skShare.Mul(s.own, s.provider.SkShare())
+	return skShare
+}
+
+// SharesAt returns the shares of the signer set at the given root. The cross terms are recombined in constant time.
+func (s *signerSetShares) SharesAt(root *bls12381.Fr) (*Shares, error) {
+	shares, err := s.provider.LocalSharesAt(root)
+	if err != nil {
+		return nil, err
+	}
+	alpha := bls12381.NewFr().Set(shares.Alpha)
+	own := bls12381.NewFr().Set(shares.Delta0)   // own is weighted by the own weight
+	delta := bls12381.NewFr().Set(shares.Delta1) // the remaining terms of delta
+	tmp := bls12381.NewFr()
+	for k, signer := range s.signerSet {
+		if signer == s.provider.OwnIndex() {
+			continue
+		}
+		cross, err := s.provider.CrossSharesAt(root, signer)
+		if err != nil {
+			return nil, err
+		}
+		dpf.ConstantTimeAddFr(alpha, alpha, cross.Alpha)
+		dpf.ConstantTimeAddFr(own, own, cross.Delta0Backward)
+		tmp.Mul(s.lambdas[k], cross.Delta0Forward)
+		dpf.ConstantTimeAddFr(delta, delta, tmp)
+		dpf.ConstantTimeAddFr(delta, delta, cross.Delta1)
+	}
+	own.Mul(own, s.own)
+	dpf.ConstantTimeAddFr(delta, delta, own)
+	return &Shares{A: shares.A, E: shares.E, S: shares.S, Alpha: alpha, Delta: delta}, nil
+}
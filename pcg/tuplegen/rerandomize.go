@@ -0,0 +1,170 @@
+package tuplegen
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+)
+
+// reRandomizationDST is the domain separation tag of the masks of a ReRandomization.
+const reRandomizationDST = "pcg-bbs-plus/tuplegen/rerandomize/v1"
+
+// Components of the tuples, which are re-randomized by independent sharings of zero.
+const (
+	componentSk byte = iota
+	componentA
+	componentE
+	componentS
+	componentAlpha
+	componentDelta
+	componentDelta0
+)
+
+// ReRandomization adds pseudorandom sharings of zero to the shares of a party, s.t. they are unlinkable to the shares
+// without it for anyone not knowing the key, while the shares of all parties still sum to the same tuple. All parties
+// must use the same key, which they agree on jointly and never publish.
+//
+// Each pair of parties i < j derives a pseudorandom mask per component and root from the key, which i adds to and j
+// subtracts from its share. Hence the masks of any set of parties sum to zero, i.e. the shares of every signer set are
+// re-randomized.
+type ReRandomization struct {
+	key     [32]byte
+	own     int
+	parties []int // parties are all n parties, whose masks re-randomize the shares of the n-out-of-n setting
+}
+
+// NewReRandomization returns the ReRandomization of the party with the given index among n parties for the key.
+func NewReRandomization(key [32]byte, own, n int) (*ReRandomization, error) {
+	if own < 0 || own >= n {
+		return nil, fmt.Errorf("party index %d is not within [0, n=%d)", own, n)
+	}
+	parties := make([]int, n)
+	for i := range parties {
+		parties[i] = i
+	}
+	return &ReRandomization{key: key, own: own, parties: parties}, nil
+}
+
+// Shares returns a ShareProvider that re-randomizes the shares of the provider of the n-out-of-n setting.
+// A nil ReRandomization returns the provider itself.
+func (r *ReRandomization) Shares(provider ShareProvider) ShareProvider {
+	if r == nil {
+		return provider
+	}
+	return r.forSigners(provider, r.parties)
+}
+
+// forSigners returns a ShareProvider that re-randomizes the shares of the provider of the given signer set.
+// A nil ReRandomization returns the provider itself.
+func (r *ReRandomization) forSigners(provider ShareProvider, signerSet []int) ShareProvider {
+	if r == nil {
+		return provider
+	}
+	return &reRandomizedShares{provider: provider, r: r, parties: signerSet}
+}
+
+// addMasks adds the masks of the party with all other parties for the component at the root to the share.
+// The root is nil for the sk share.
+func (r *ReRandomization) addMasks(share *bls12381.Fr, component byte, root *bls12381.Fr, parties []int) *bls12381.Fr {
+	masked := bls12381.NewFr().Set(share)
+	for _, j := range parties {
+		if j == r.own {
+			continue
+		}
+		mask := r.mask(component, root, min(r.own, j), max(r.own, j))
+		if r.own < j {
+			dpf.ConstantTimeAddFr(masked, masked, mask)
+		} else {
+			dpf.ConstantTimeSubFr(masked, masked, mask)
+		}
+	}
+	return masked
+}
+
+// mask derives the mask of the parties i < j for the component at the root via HMAC-SHA-256 of the key. It expands to
+// 64 bytes, which are reduced mod q, s.t. the mask is statistically close to uniform over Fr.
+func (r *ReRandomization) mask(component byte, root *bls12381.Fr, i, j int) *bls12381.Fr {
+	msg := []byte(reRandomizationDST)
+	msg = append(msg, component)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(i))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(j))
+	if root != nil {
+		msg = append(msg, root.ToBytes()...)
+	}
+	uniform := make([]byte, 0, 2*sha256.Size)
+	for counter := byte(0); counter < 2; counter++ {
+		mac := hmac.New(sha256.New, r.key[:])
+		mac.Write([]byte{counter})
+		mac.Write(msg)
+		uniform = mac.Sum(uniform)
+	}
+	return bls12381.NewFr().FromBytes(uniform) // FromBytes reduces mod q
+}
+
+// reRandomizedShares is the ShareProvider of a ReRandomization over the shares of a provider for a set of parties.
+type reRandomizedShares struct {
+	provider ShareProvider
+	r        *ReRandomization
+	parties  []int
+}
+
+// SkShare returns the re-randomized share of the secret key.
+func (s *reRandomizedShares) SkShare() *bls12381.Fr {
+	return s.r.addMasks(s.provider.SkShare(), componentSk, nil, s.parties)
+}
+
+// SharesAt returns the re-randomized shares at the given root.
+func (s *reRandomizedShares) SharesAt(root *bls12381.Fr) (*Shares, error) {
+	shares, err := s.provider.SharesAt(root)
+	if err != nil {
+		return nil, err
+	}
+	return s.reRandomize(root, shares), nil
+}
+
+// SharesAtRoots returns the re-randomized shares at the given roots, at once if the provider is a BatchShareProvider.
+func (s *reRandomizedShares) SharesAtRoots(roots []*bls12381.Fr) ([]*Shares, error) {
+	shares, err := sharesAtRoots(s.provider, roots)
+	if err != nil {
+		return nil, err
+	}
+	for i, root := range roots {
+		shares[i] = s.reRandomize(root, shares[i])
+	}
+	return shares, nil
+}
+
+// VOLESharesAt returns the re-randomized shares of the VOLE correlation at the given root if the provider is a
+// VOLEProvider.
+func (s *reRandomizedShares) VOLESharesAt(root *bls12381.Fr) (*VOLEShares, error) {
+	provider, ok := s.provider.(VOLEProvider)
+	if !ok {
+		return nil, fmt.Errorf("the re-randomized share provider does not provide VOLE correlations")
+	}
+	shares, err := provider.VOLESharesAt(root)
+	if err != nil {
+		return nil, err
+	}
+	return &VOLEShares{
+		A:      s.r.addMasks(shares.A, componentA, root, s.parties),
+		Delta0: s.r.addMasks(shares.Delta0, componentDelta0, root, s.parties),
+	}, nil
+}
+
+// reRandomize adds the masks at the root to all shares.
+func (s *reRandomizedShares) reRandomize(root *bls12381.Fr, shares *Shares) *Shares {
+	return &Shares{
+		A:     s.r.addMasks(shares.A, componentA, root, s.parties),
+		E:     s.r.addMasks(shares.E, componentE, root, s.parties),
+		S:     s.r.addMasks(shares.S, componentS, root, s.parties),
+		Alpha: s.r.addMasks(shares.Alpha, componentAlpha, root, s.parties),
+		Delta: s.r.addMasks(shares.Delta, componentDelta, root, s.parties),
+	}
+}
+
+func (s *reRandomizedShares) recordStats(stats *statsCounter) {
+	recordStatsOf(s.provider, stats)
+}
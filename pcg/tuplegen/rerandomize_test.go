@@ -0,0 +1,40 @@
+package tuplegen_test
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReRandomizationSharesZero(t *testing.T) {
+	key := [32]byte{1, 2, 3}
+	root := randomFr(t)
+	zero := poly.NewEmpty()
+	sk, a, e, s, alpha, delta := bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr()
+	for own := 0; own < 4; own++ {
+		r, err := tuplegen.NewReRandomization(key, own, 4)
+		assert.Nil(t, err)
+		provider := r.Shares(tuplegen.NewPolyShares(bls12381.NewFr(), zero, zero, zero, zero, zero, zero))
+		shares, err := provider.SharesAt(root)
+		assert.Nil(t, err)
+		assert.False(t, shares.A.IsZero())
+		sk.Add(sk, provider.SkShare())
+		a.Add(a, shares.A)
+		e.Add(e, shares.E)
+		s.Add(s, shares.S)
+		alpha.Add(alpha, shares.Alpha)
+		delta.Add(delta, shares.Delta)
+	}
+	// The masks of all parties sum to zero
+	for _, sum := range []*bls12381.Fr{sk, a, e, s, alpha, delta} {
+		assert.True(t, sum.IsZero())
+	}
+
+	_, err := tuplegen.NewReRandomization(key, 4, 4)
+	assert.NotNil(t, err)
+	var none *tuplegen.ReRandomization
+	provider := tuplegen.NewPolyShares(bls12381.NewFr(), zero, zero, zero, zero, zero, zero)
+	assert.Same(t, provider, none.Shares(provider))
+}
@@ -0,0 +1,97 @@
+package tuplegen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SignerSetBatch is a batch of tuples precomputed for a fixed signer set of the tau-out-of-n setting.
+// The tuples are only valid with respect to this signer set.
+type SignerSetBatch struct {
+	Label     string // Label identifies the signer set, i.e. the comma-separated sorted signer indices
+	SignerSet []int  // SignerSet holds the sorted signer indices
+	*BBSPlusTupleGenerator
+}
+
+// PrecomputeSignerSet precomputes the batch of tuples for the given signer set.
+// The cross terms of the signer set are aggregated once, s.t. deriving a tuple from the batch only requires
+// evaluations instead of the aggregation for each tuple (see GenBBSPlusTuple).
+func (t *SeparateBBSPlusTupleGenerator) PrecomputeSignerSet(signerSet []int) (*SignerSetBatch, error) {
+	sorted := make([]int, len(signerSet))
+	copy(sorted, signerSet)
+	sort.Ints(sorted)
+
+	provider, err := t.signerSetProvider(sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	generator := NewGenerator(provider)
+	generator.tag = t.tag
+	generator.generators = t.generators
+	generator.committer = t.committer
+	generator.logger = t.logger
+	generator.stats.metrics = t.stats.metrics
+	return &SignerSetBatch{
+		Label:                 signerSetLabel(sorted),
+		SignerSet:             sorted,
+		BBSPlusTupleGenerator: generator,
+	}, nil
+}
+
+// PrecomputeSignerSets precomputes the batches for all given signer sets. The batches are indexed by their label.
+func (t *SeparateBBSPlusTupleGenerator) PrecomputeSignerSets(signerSets [][]int) (map[string]*SignerSetBatch, error) {
+	batches := make(map[string]*SignerSetBatch, len(signerSets))
+	for _, signerSet := range signerSets {
+		batch, err := t.PrecomputeSignerSet(signerSet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to precompute signer set %v: %w", signerSet, err)
+		}
+		batches[batch.Label] = batch
+	}
+	return batches, nil
+}
+
+// PrecomputeAllSignerSets precomputes the batches for all signer sets of size tau that contain the own index.
+// Note that there are (n-1 choose tau-1) such sets.
+func (t *SeparateBBSPlusTupleGenerator) PrecomputeAllSignerSets(tau int) (map[string]*SignerSetBatch, error) {
+	n := t.provider.Parties()
+	ownIndex := t.provider.OwnIndex()
+	if tau < 2 || tau > n {
+		return nil, fmt.Errorf("tau must be in [2, %d] but is %d", n, tau)
+	}
+
+	coSigners := make([]int, 0, n-1)
+	for j := 0; j < n; j++ {
+		if j != ownIndex {
+			coSigners = append(coSigners, j)
+		}
+	}
+
+	var signerSets [][]int
+	var choose func(start int, set []int)
+	choose = func(start int, set []int) {
+		if len(set) == tau-1 {
+			signerSet := append([]int{ownIndex}, set...)
+			signerSets = append(signerSets, signerSet)
+			return
+		}
+		for i := start; i < len(coSigners); i++ {
+			choose(i+1, append(set[:len(set):len(set)], coSigners[i]))
+		}
+	}
+	choose(0, nil)
+
+	return t.PrecomputeSignerSets(signerSets)
+}
+
+// signerSetLabel returns the label of the sorted signer set.
+func signerSetLabel(signerSet []int) string {
+	parts := make([]string, len(signerSet))
+	for i, signer := range signerSet {
+		parts[i] = strconv.Itoa(signer)
+	}
+	return strings.Join(parts, ",")
+}
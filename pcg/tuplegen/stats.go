@@ -0,0 +1,226 @@
+package tuplegen
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/metrics"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ShareType identifies a share of a tuple in the statistics of a generator.
+type ShareType int
+
+const (
+	ShareA ShareType = iota
+	ShareE
+	ShareS
+	ShareAlpha
+	ShareDelta
+	shareTypes // shareTypes is the amount of share types
+)
+
+func (s ShareType) String() string {
+	switch s {
+	case ShareA:
+		return "a"
+	case ShareE:
+		return "e"
+	case ShareS:
+		return "s"
+	case ShareAlpha:
+		return "alpha"
+	case ShareDelta:
+		return "delta"
+	default:
+		return fmt.Sprintf("ShareType(%d)", int(s))
+	}
+}
+
+// Stats are the statistics of a generator since its creation or the last reset (see BBSPlusTupleGenerator.Stats).
+// The evaluations are only counted for providers that record them, i.e. the polynomial providers of this package.
+type Stats struct {
+	Tuples      int64                     // Tuples is the amount of generated tuples.
+	Failures    int64                     // Failures is the amount of tuples that failed to be generated.
+	Duration    time.Duration             // Duration is the total time spent generating tuples, incl. failures.
+	Evaluations int64                     // Evaluations is the amount of polynomial evaluations.
+	Evaluated   [shareTypes]int64         // Evaluated holds the amount of evaluations per ShareType.
+	ShareTime   [shareTypes]time.Duration // ShareTime holds the time spent evaluating per ShareType.
+}
+
+// TuplesPerSecond returns the throughput of the generator, 0 if no tuple was generated.
+func (s Stats) TuplesPerSecond() float64 {
+	if s.Tuples == 0 || s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Tuples) / s.Duration.Seconds()
+}
+
+func (s Stats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d tuples (%d failed) in %v (%.1f tuples/s), %d evaluations", s.Tuples, s.Failures, s.Duration, s.TuplesPerSecond(), s.Evaluations)
+	for share := ShareType(0); share < shareTypes; share++ {
+		if s.Evaluated[share] > 0 {
+			fmt.Fprintf(&b, ", %s: %d in %v", share, s.Evaluated[share], s.ShareTime[share])
+		}
+	}
+	return b.String()
+}
+
+// statsCounter aggregates the statistics of a generator. It is safe for concurrent use, s.t. tuples can be generated
+// in parallel. A nil counter records nothing.
+type statsCounter struct {
+	tuples, failures, duration atomic.Int64
+	evaluated, shareTime       [shareTypes]atomic.Int64
+	metrics                    metrics.Metrics // metrics additionally receives the counts of the tuples. nil disables it.
+}
+
+// statsRecorder is implemented by providers that record their evaluations in the statistics of a generator. The
+// generator of a provider sets its counter on construction, hence a provider shared by several generators records in
+// the counter of the last one.
+type statsRecorder interface {
+	recordStats(stats *statsCounter)
+}
+
+// recordStatsOf sets the counter of the provider if it records statistics.
+func recordStatsOf(provider any, stats *statsCounter) {
+	if recorder, ok := provider.(statsRecorder); ok {
+		recorder.recordStats(stats)
+	}
+}
+
+// recordTuple records the generation of a tuple, which started at start and failed if err is set or no tuple was
+// generated.
+func (c *statsCounter) recordTuple(start time.Time, err error, missing bool) {
+	if c == nil {
+		return
+	}
+	c.duration.Add(int64(time.Since(start)))
+	if err != nil || missing {
+		c.failures.Add(1)
+		c.report(1, false)
+	} else {
+		c.tuples.Add(1)
+		c.report(1, true)
+	}
+}
+
+// recordTuples records the generation of a batch of tuples, which started at start and failed as a whole if err is set.
+func (c *statsCounter) recordTuples(start time.Time, tuples int, err error) {
+	if c == nil {
+		return
+	}
+	c.duration.Add(int64(time.Since(start)))
+	if err != nil {
+		c.failures.Add(int64(tuples))
+		c.report(tuples, false)
+	} else {
+		c.tuples.Add(int64(tuples))
+		c.report(tuples, true)
+	}
+}
+
+// report adds the generated or failed tuples to the metrics, if any.
+func (c *statsCounter) report(tuples int, ok bool) {
+	if c.metrics == nil {
+		return
+	}
+	result := "ok"
+	if !ok {
+		result = "failed"
+	}
+	c.metrics.Add(metrics.TuplesGenerated, float64(tuples), metrics.Label{Name: "result", Value: result})
+}
+
+// evaluate evaluates the polynomial of the given share type at the points and records the evaluations.
+func (c *statsCounter) evaluate(share ShareType, p *poly.Polynomial, points []*poly.EvaluationPoint) []*bls12381.Fr {
+	if c == nil {
+		return p.EvaluateAtPoints(points)
+	}
+	start := time.Now()
+	values := p.EvaluateAtPoints(points)
+	c.evaluated[share].Add(int64(len(points)))
+	c.shareTime[share].Add(int64(time.Since(start)))
+	return values
+}
+
+// evaluateDense evaluates the coefficient vector of the given share type at the points and records the evaluations
+// (see poly.EvaluateDenseAtPoints).
+func (c *statsCounter) evaluateDense(share ShareType, coefficients []*bls12381.Fr, points []*poly.EvaluationPoint) []*bls12381.Fr {
+	if c == nil {
+		return poly.EvaluateDenseAtPoints(coefficients, points)
+	}
+	start := time.Now()
+	values := poly.EvaluateDenseAtPoints(coefficients, points)
+	c.evaluated[share].Add(int64(len(points)))
+	c.shareTime[share].Add(int64(time.Since(start)))
+	return values
+}
+
+// snapshot returns the statistics recorded so far.
+func (c *statsCounter) snapshot() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	stats := Stats{
+		Tuples:   c.tuples.Load(),
+		Failures: c.failures.Load(),
+		Duration: time.Duration(c.duration.Load()),
+	}
+	for share := range stats.Evaluated {
+		stats.Evaluated[share] = c.evaluated[share].Load()
+		stats.ShareTime[share] = time.Duration(c.shareTime[share].Load())
+		stats.Evaluations += stats.Evaluated[share]
+	}
+	return stats
+}
+
+// reset discards the statistics recorded so far.
+func (c *statsCounter) reset() {
+	if c == nil {
+		return
+	}
+	for _, counter := range []*atomic.Int64{&c.tuples, &c.failures, &c.duration} {
+		counter.Store(0)
+	}
+	for share := range c.evaluated {
+		c.evaluated[share].Store(0)
+		c.shareTime[share].Store(0)
+	}
+}
+
+// SetMetrics sets the metrics the generator reports the amount of generated tuples to (see metrics.TuplesGenerated).
+// nil disables the reporting.
+func (t *BBSPlusTupleGenerator) SetMetrics(m metrics.Metrics) {
+	t.stats.metrics = m
+}
+
+// Stats returns the statistics of the tuples generated since the creation of the generator or the last ResetStats.
+func (t *BBSPlusTupleGenerator) Stats() Stats {
+	return t.stats.snapshot()
+}
+
+// ResetStats discards the statistics of the generator.
+func (t *BBSPlusTupleGenerator) ResetStats() {
+	t.stats.reset()
+}
+
+// SetMetrics sets the metrics the generator and the batches of its precomputed signer sets report the amount of
+// generated tuples to (see metrics.TuplesGenerated). nil disables the reporting.
+func (t *SeparateBBSPlusTupleGenerator) SetMetrics(m metrics.Metrics) {
+	t.stats.metrics = m
+}
+
+// Stats returns the statistics of the tuples generated since the creation of the generator or the last ResetStats.
+// The evaluations include those of the cross terms combined per root for signer sets (see GenBBSPlusTuple), but not
+// those of precomputed signer sets, whose generators hold their own statistics (see PrecomputeSignerSet).
+func (t *SeparateBBSPlusTupleGenerator) Stats() Stats {
+	return t.stats.snapshot()
+}
+
+// ResetStats discards the statistics of the generator.
+func (t *SeparateBBSPlusTupleGenerator) ResetStats() {
+	t.stats.reset()
+}
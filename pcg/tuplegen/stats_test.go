@@ -0,0 +1,67 @@
+package tuplegen
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func constantPoly(i uint64) *poly.Polynomial {
+	return poly.NewFromFr([]*bls12381.Fr{frOf(i), frOf(1)})
+}
+
+func TestGeneratorStats(t *testing.T) {
+	generator := NewBBSPlusTupleGenerator(frOf(1), constantPoly(2), constantPoly(3), constantPoly(4), constantPoly(5), constantPoly(6), constantPoly(7))
+	assert.Equal(t, Stats{}, generator.Stats())
+
+	for i := 0; i < 3; i++ {
+		_, err := generator.GenBBSPlusTupleAt(indexRing{}, i)
+		assert.Nil(t, err)
+	}
+	_, err := generator.GenBBSPlusTupleAt(indexRing{}, -1) // the root is rejected before any evaluation
+	assert.NotNil(t, err)
+
+	stats := generator.Stats()
+	assert.Equal(t, int64(3), stats.Tuples)
+	assert.Equal(t, int64(15), stats.Evaluations)
+	for share := ShareA; share <= ShareDelta; share++ {
+		assert.Equal(t, int64(3), stats.Evaluated[share], share.String())
+	}
+	assert.True(t, stats.Duration > 0)
+	assert.True(t, stats.TuplesPerSecond() > 0)
+	assert.Contains(t, stats.String(), "3 tuples")
+
+	generator.ResetStats()
+	assert.Equal(t, Stats{}, generator.Stats())
+}
+
+func TestSeparateGeneratorStats(t *testing.T) {
+	// Providers that do not record evaluations only count the tuples
+	generator := NewSeparateGenerator(&constantShares{ownIndex: 0, n: 2, missing: -1})
+	assert.NotNil(t, generator.GenBBSPlusTuple(frOf(3), []int{0, 1}))
+	assert.Nil(t, generator.GenBBSPlusTuple(frOf(3), []int{1})) // invalid signer set
+	stats := generator.Stats()
+	assert.Equal(t, int64(1), stats.Tuples)
+	assert.Equal(t, int64(1), stats.Failures)
+	assert.Equal(t, int64(0), stats.Evaluations)
+
+	// The evaluations of aggregated signer sets are recorded by the separate generator, while batches hold their own
+	delta0 := NewPartyIndexed[[]*poly.Polynomial](0, 2)
+	delta0.Set(1, []*poly.Polynomial{constantPoly(8), constantPoly(9)})
+	alpha, delta1 := NewPartyIndexed[*poly.Polynomial](0, 2), NewPartyIndexed[*poly.Polynomial](0, 2)
+	alpha.Set(1, constantPoly(10))
+	delta1.Set(1, constantPoly(11))
+	separate, err := NewSeparateBBSPlusTupleGenerator(constantPoly(1), constantPoly(2), constantPoly(3), frOf(4), constantPoly(5), constantPoly(6), constantPoly(7), delta0, alpha, delta1)
+	assert.Nil(t, err)
+	assert.NotNil(t, separate.GenBBSPlusTuple(frOf(3), []int{0, 1}))
+	assert.Equal(t, int64(5), separate.Stats().Evaluations)
+
+	batch, err := separate.PrecomputeSignerSet([]int{0, 1})
+	assert.Nil(t, err)
+	_, err = batch.GenBBSPlusTupleAt(indexRing{}, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), batch.Stats().Evaluations)
+	assert.Equal(t, int64(1), batch.Stats().Tuples)
+	assert.Equal(t, int64(1), separate.Stats().Tuples)
+}
@@ -0,0 +1,385 @@
+package tuplegen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/metrics"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TupleState is the state of the tuple of a root in a Store.
+type TupleState int
+
+const (
+	TupleAvailable TupleState = iota // TupleAvailable tuples may be reserved for a signing session.
+	TupleReserved                    // TupleReserved tuples are used by a running signing session.
+	TupleConsumed                    // TupleConsumed tuples must never be used again.
+)
+
+func (s TupleState) String() string {
+	switch s {
+	case TupleAvailable:
+		return "available"
+	case TupleReserved:
+		return "reserved"
+	case TupleConsumed:
+		return "consumed"
+	default:
+		return fmt.Sprintf("TupleState(%d)", int(s))
+	}
+}
+
+// ErrNoTupleAvailable is returned by Store.ReserveNext if all tuples of the store are reserved or consumed.
+var ErrNoTupleAvailable = errors.New("no tuple is available")
+
+// StateError is returned by a Store if a transition is not allowed in the current state of a tuple, e.g. reserving a
+// consumed tuple.
+type StateError struct {
+	Index     int        // Index is the root index of the tuple.
+	State     TupleState // State is the current state of the tuple.
+	Operation string     // Operation is the refused transition, i.e. "reserve", "commit" or "abort".
+}
+
+func (e *StateError) Error() string {
+	return fmt.Sprintf("cannot %s the tuple of root %d, as it is %s", e.Operation, e.Index, e.State)
+}
+
+// Store tracks the consumption of the one-time tuples of a party in an interactive signing protocol via a two-phase
+// commit: a session reserves the tuple of a root (Reserve), which prevents concurrent sessions from using it, and
+// either commits it after the signature round completes (Commit) or returns it to the store if the session aborts
+// before the party released its partial signature (Abort). Hence, tuples are neither reused nor wasted on aborted
+// sessions.
+//
+// A store opened via OpenStore writes each transition to an append-only journal and syncs it to disk before the
+// transition returns. On recovery, the journal is replayed with the following rules:
+//   - Committed tuples stay consumed.
+//   - Aborted tuples are available again.
+//   - Tuples that were reserved at the time of the crash are consumed, as the session may have released a partial
+//     signature with the tuple (see Recovered). Reusing such a tuple could leak the secret key.
+//   - A torn last entry, i.e. a transition that did not return, is discarded. A torn reservation therefore leaves the
+//     tuple available, which is safe as Reserve did not return it to a session.
+//
+// If writing or syncing the journal fails, the store refuses all further transitions, as another entry would follow a
+// possibly torn one. The store then has to be reopened via OpenStore, which recovers it by the rules above.
+//
+// A Store is safe for concurrent use.
+type Store struct {
+	mu        sync.Mutex
+	size      int                // size is the amount of roots, i.e. of tuples.
+	states    map[int]TupleState // states holds the state of all tuples that are not available.
+	journal   storeJournal       // journal receives the transitions. nil for stores held in memory only.
+	recovered []int              // recovered holds the tuples, which were reserved at the time of a crash.
+	closed    bool               // closed is set by Close.
+	failed    error              // failed is the error of a failed journal write, after which all transitions are refused.
+	metrics   metrics.Metrics    // metrics receives the amounts of available and reserved tuples. nil disables it.
+}
+
+// storeJournal is the journal of a Store, i.e. the journal file opened by OpenStore.
+type storeJournal interface {
+	io.Writer
+	Sync() error
+	Close() error
+}
+
+// NewStore returns a store of size tuples, which are all available. The store is held in memory only, hence its
+// state is lost on a crash (see OpenStore).
+func NewStore(size int) (*Store, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("the size of the store must be positive but is %d", size)
+	}
+	return &Store{size: size, states: make(map[int]TupleState)}, nil
+}
+
+// OpenStore opens the store of size tuples journaled at path and recovers its state (see Store), or creates it if the
+// journal does not exist. A journal of another size is refused, as it belongs to other tuples.
+func OpenStore(path string, size int) (*Store, error) {
+	s, err := NewStore(size)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read the journal: %w", err)
+	}
+	if len(data) > 0 {
+		if err := s.replay(string(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	// The recovered state is persisted before any transition, s.t. the consumption of the recovered reservations
+	// survives another crash
+	if err := s.compact(path); err != nil {
+		return nil, err
+	}
+	s.journal, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the journal: %w", err)
+	}
+	return s, nil
+}
+
+// replay recovers the state from the entries of a journal.
+func (s *Store) replay(journal string) error {
+	lines := strings.Split(journal, "\n")
+	lines = lines[:len(lines)-1] // the last line is torn or empty, as complete entries end with a newline
+	if len(lines) == 0 {
+		return nil
+	}
+	if header := fmt.Sprintf("tuplestore %d", s.size); lines[0] != header {
+		return fmt.Errorf("the journal is not a journal of %d tuples, but starts with %q", s.size, lines[0])
+	}
+	for number, line := range lines[1:] {
+		operation, index, err := s.parseEntry(line)
+		if err != nil {
+			return fmt.Errorf("invalid journal entry %d: %w", number+1, err)
+		}
+		s.apply(operation, index)
+	}
+	for index, state := range s.states {
+		if state == TupleReserved {
+			s.states[index] = TupleConsumed
+			s.recovered = append(s.recovered, index)
+		}
+	}
+	sort.Ints(s.recovered)
+	return nil
+}
+
+// parseEntry parses an entry "<operation> <index>" of the journal.
+func (s *Store) parseEntry(line string) (string, int, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return "", 0, fmt.Errorf("%q is not of the form <operation> <index>", line)
+	}
+	index, err := strconv.Atoi(fields[1])
+	if err != nil || index < 0 || index >= s.size {
+		return "", 0, fmt.Errorf("%q holds no index in [0, %d)", line, s.size)
+	}
+	switch fields[0] {
+	case "reserve", "commit", "abort", "consumed":
+		return fields[0], index, nil
+	default:
+		return "", 0, fmt.Errorf("unknown operation %q", fields[0])
+	}
+}
+
+// apply applies a transition without checking it.
+func (s *Store) apply(operation string, index int) {
+	switch operation {
+	case "reserve":
+		s.states[index] = TupleReserved
+	case "abort":
+		delete(s.states, index)
+	default: // commit and consumed
+		s.states[index] = TupleConsumed
+	}
+}
+
+// compact atomically replaces the journal at path by a journal of the current state, which only holds the consumed
+// tuples. The journal is written to a temporary file first, s.t. a crash leaves either the old or the new journal.
+func (s *Store) compact(path string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "tuplestore %d\n", s.size)
+	for _, index := range s.sortedIndices() {
+		fmt.Fprintf(&b, "consumed %d\n", index)
+	}
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to compact the journal: %w", err)
+	}
+	_, err = file.WriteString(b.String())
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to compact the journal: %w", err)
+	}
+	return nil
+}
+
+// sortedIndices returns the indices of all tuples that are not available in ascending order.
+func (s *Store) sortedIndices() []int {
+	indices := make([]int, 0, len(s.states))
+	for index := range s.states {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// transition changes the state of the tuple from the expected state via the operation and journals it.
+func (s *Store) transition(operation string, index int, from TupleState) error {
+	if s.closed {
+		return errors.New("the store is closed")
+	}
+	if s.failed != nil {
+		return fmt.Errorf("the store failed to journal a transition and must be reopened: %w", s.failed)
+	}
+	if index < 0 || index >= s.size {
+		return fmt.Errorf("root index %d is out of range [0, %d)", index, s.size)
+	}
+	if state := s.state(index); state != from {
+		return &StateError{Index: index, State: state, Operation: operation}
+	}
+	if s.journal != nil {
+		if err := s.writeJournal(operation, index); err != nil {
+			// The journal may end in a torn entry now, which replay only discards as the last entry. Hence, the store
+			// refuses all further transitions, s.t. it can be reopened via OpenStore.
+			s.failed = err
+			_ = s.journal.Close()
+			return fmt.Errorf("failed to journal %s of root %d: %w", operation, index, err)
+		}
+	}
+	s.apply(operation, index)
+	s.report()
+	return nil
+}
+
+// writeJournal appends the entry of the transition to the journal and syncs it to disk.
+func (s *Store) writeJournal(operation string, index int) error {
+	if _, err := fmt.Fprintf(s.journal, "%s %d\n", operation, index); err != nil {
+		return err
+	}
+	return s.journal.Sync()
+}
+
+// SetMetrics sets the metrics the store reports the amounts of available and reserved tuples to after each transition
+// (see metrics.StoreAvailable and metrics.StoreReserved). The amount of reserved tuples is the amount of running
+// signing sessions. nil disables the reporting.
+func (s *Store) SetMetrics(m metrics.Metrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = m
+	s.report()
+}
+
+// report reports the amounts of available and reserved tuples to the metrics, if any, without locking.
+func (s *Store) report() {
+	if s.metrics == nil {
+		return
+	}
+	reserved := 0
+	for _, state := range s.states {
+		if state == TupleReserved {
+			reserved++
+		}
+	}
+	s.metrics.Set(metrics.StoreAvailable, float64(s.size-len(s.states)))
+	s.metrics.Set(metrics.StoreReserved, float64(reserved))
+}
+
+// state returns the state of the tuple without locking.
+func (s *Store) state(index int) TupleState {
+	return s.states[index] // absent tuples are available
+}
+
+// Reserve reserves the available tuple of the root with the given index for a signing session.
+func (s *Store) Reserve(index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transition("reserve", index, TupleAvailable)
+}
+
+// ReserveNext reserves the available tuple with the smallest sequence number and returns its root index, i.e. the
+// tuples are reserved in the order of TupleRootIndex. For stores whose size is no power of two, and hence no ring,
+// the sequence number is the root index. It returns ErrNoTupleAvailable if the store is exhausted.
+func (s *Store) ReserveNext() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sequence := 0; sequence < s.size; sequence++ {
+		index, err := TupleRootIndex(sequence, s.size)
+		if err != nil {
+			index = sequence
+		}
+		if s.state(index) == TupleAvailable {
+			return index, s.transition("reserve", index, TupleAvailable)
+		}
+	}
+	return 0, ErrNoTupleAvailable
+}
+
+// Commit marks the reserved tuple as consumed after the signing session completed.
+func (s *Store) Commit(index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transition("commit", index, TupleReserved)
+}
+
+// Abort returns the reserved tuple to the store if its signing session aborted. It must only be called if the party
+// did not release its partial signature with the tuple, otherwise the tuple has to be committed (see Commit).
+func (s *Store) Abort(index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transition("abort", index, TupleReserved)
+}
+
+// ReserveTuple reserves the tuple of the root with the given index and derives it via gen, e.g. via
+// BBSPlusTupleGenerator.GenBBSPlusTupleAt. The reservation is aborted if the derivation fails.
+func (s *Store) ReserveTuple(index int, gen func(index int) (*BBSPlusTuple, error)) (*BBSPlusTuple, error) {
+	if err := s.Reserve(index); err != nil {
+		return nil, err
+	}
+	tuple, err := gen(index)
+	if err != nil {
+		if abortErr := s.Abort(index); abortErr != nil {
+			return nil, fmt.Errorf("%w (failed to abort the reservation: %v)", err, abortErr)
+		}
+		return nil, err
+	}
+	return tuple, nil
+}
+
+// State returns the state of the tuple of the root with the given index. Indices outside the store are consumed.
+func (s *Store) State(index int) TupleState {
+	if index < 0 || index >= s.size {
+		return TupleConsumed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state(index)
+}
+
+// Size returns the amount of tuples of the store.
+func (s *Store) Size() int {
+	return s.size
+}
+
+// Available returns the amount of available tuples.
+func (s *Store) Available() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size - len(s.states)
+}
+
+// Recovered returns the root indices of the tuples, which were reserved when the store crashed and hence were
+// consumed on recovery. Their signing sessions are to be treated as failed.
+func (s *Store) Recovered() []int {
+	return append([]int(nil), s.recovered...)
+}
+
+// Close closes the journal of the store. Running reservations are consumed when the store is opened again, hence
+// sessions should be committed or aborted before.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.journal == nil || s.failed != nil {
+		s.closed = true // the journal of a failed store is closed already
+		return nil
+	}
+	s.closed = true
+	return s.journal.Close()
+}
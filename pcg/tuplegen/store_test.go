@@ -0,0 +1,216 @@
+package tuplegen
+
+import (
+	"errors"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/metrics"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestStoreTransitions(t *testing.T) {
+	store, err := NewStore(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, store.Available())
+
+	assert.Nil(t, store.Reserve(1))
+	var stateErr *StateError
+	assert.True(t, errors.As(store.Reserve(1), &stateErr)) // no concurrent sessions on the same tuple
+	assert.Equal(t, TupleReserved, stateErr.State)
+	assert.Nil(t, store.Abort(1))
+	assert.Equal(t, TupleAvailable, store.State(1))
+
+	assert.Nil(t, store.Reserve(1))
+	assert.Nil(t, store.Commit(1))
+	assert.Equal(t, TupleConsumed, store.State(1))
+	assert.NotNil(t, store.Reserve(1)) // no reuse
+	assert.NotNil(t, store.Abort(1))
+	assert.NotNil(t, store.Commit(2)) // commits require a reservation
+	assert.NotNil(t, store.Reserve(3))
+	assert.Equal(t, TupleConsumed, store.State(-1))
+
+	index, err := store.ReserveNext()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, index)
+	index, err = store.ReserveNext()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, index)
+	_, err = store.ReserveNext()
+	assert.True(t, errors.Is(err, ErrNoTupleAvailable))
+	assert.Equal(t, 0, store.Available())
+
+	_, err = NewStore(0)
+	assert.NotNil(t, err)
+}
+
+func TestStoreReserveTuple(t *testing.T) {
+	store, err := NewStore(4)
+	assert.Nil(t, err)
+	generator := NewBBSPlusTupleGenerator(frOf(1), constantPoly(2), constantPoly(3), constantPoly(4), constantPoly(5), constantPoly(6), constantPoly(7))
+	gen := func(index int) (*BBSPlusTuple, error) {
+		return generator.GenBBSPlusTupleAt(indexRing{}, index-1)
+	}
+
+	tuple, err := store.ReserveTuple(2, gen)
+	assert.Nil(t, err)
+	assert.NotNil(t, tuple)
+	assert.Equal(t, TupleReserved, store.State(2))
+
+	// A failed derivation aborts the reservation
+	_, err = store.ReserveTuple(0, gen)
+	assert.NotNil(t, err)
+	assert.Equal(t, TupleAvailable, store.State(0))
+}
+
+func TestStoreConcurrentReservations(t *testing.T) {
+	store, err := NewStore(64)
+	assert.Nil(t, err)
+	reserved := make([]int, 64)
+	var wg sync.WaitGroup
+	for i := range reserved {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			index, err := store.ReserveNext()
+			assert.Nil(t, err)
+			reserved[index]++
+		}(i)
+	}
+	wg.Wait()
+	for _, count := range reserved {
+		assert.Equal(t, 1, count)
+	}
+}
+
+func TestStoreRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuples.journal")
+	store, err := OpenStore(path, 8)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Reserve(0))
+	assert.Nil(t, store.Commit(0))
+	assert.Nil(t, store.Reserve(1))
+	assert.Nil(t, store.Abort(1))
+	assert.Nil(t, store.Reserve(2)) // crashes during the signing session
+	assert.Nil(t, store.Close())
+	assert.NotNil(t, store.Reserve(3))
+
+	// Simulate a torn entry of a reservation that did not return
+	journal, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	assert.Nil(t, err)
+	_, err = journal.WriteString("reserve 3")
+	assert.Nil(t, err)
+	assert.Nil(t, journal.Close())
+
+	store, err = OpenStore(path, 8)
+	assert.Nil(t, err)
+	assert.Equal(t, TupleConsumed, store.State(0))
+	assert.Equal(t, TupleAvailable, store.State(1))
+	assert.Equal(t, TupleConsumed, store.State(2))
+	assert.Equal(t, TupleAvailable, store.State(3))
+	assert.Equal(t, []int{2}, store.Recovered())
+	assert.Equal(t, 6, store.Available())
+	assert.Nil(t, store.Close())
+
+	// The recovered consumption persists, while the recovered reservations are only reported once
+	store, err = OpenStore(path, 8)
+	assert.Nil(t, err)
+	assert.Equal(t, TupleConsumed, store.State(2))
+	assert.Empty(t, store.Recovered())
+	assert.Nil(t, store.Close())
+
+	_, err = OpenStore(path, 16) // journal of other tuples
+	assert.NotNil(t, err)
+	assert.Nil(t, os.WriteFile(path, []byte("tuplestore 8\nreuse 1\n"), 0o600))
+	_, err = OpenStore(path, 8)
+	assert.NotNil(t, err)
+}
+
+// failingJournal is a journal whose writes are torn, i.e. only write the first half of an entry, or whose syncs fail.
+type failingJournal struct {
+	*os.File
+	tornWrite bool
+}
+
+func (j *failingJournal) Write(p []byte) (int, error) {
+	if !j.tornWrite {
+		return j.File.Write(p)
+	}
+	n, _ := j.File.Write(p[:len(p)/2])
+	return n, errors.New("injected write failure")
+}
+
+func (j *failingJournal) Sync() error {
+	if j.tornWrite {
+		return j.File.Sync()
+	}
+	return errors.New("injected sync failure")
+}
+
+func TestStoreJournalFailure(t *testing.T) {
+	for _, tornWrite := range []bool{true, false} {
+		path := filepath.Join(t.TempDir(), "tuples.journal")
+		store, err := OpenStore(path, 8)
+		assert.Nil(t, err)
+		assert.Nil(t, store.Reserve(1))
+		assert.Nil(t, store.Commit(1))
+
+		store.journal = &failingJournal{File: store.journal.(*os.File), tornWrite: tornWrite}
+		assert.NotNil(t, store.Reserve(2))
+		// The failed store refuses all further transitions, s.t. no entry follows the torn one
+		assert.NotNil(t, store.Reserve(3))
+		assert.NotNil(t, store.Abort(2))
+		assert.Nil(t, store.Close())
+
+		// The journal is recovered: a torn reservation is discarded, while a complete one is consumed
+		store, err = OpenStore(path, 8)
+		assert.Nil(t, err)
+		assert.Equal(t, TupleConsumed, store.State(1))
+		assert.Equal(t, TupleAvailable, store.State(3))
+		if tornWrite {
+			assert.Equal(t, TupleAvailable, store.State(2))
+			assert.Empty(t, store.Recovered())
+		} else {
+			assert.Equal(t, TupleConsumed, store.State(2))
+			assert.Equal(t, []int{2}, store.Recovered())
+		}
+		assert.Nil(t, store.Reserve(3))
+		assert.Nil(t, store.Close())
+	}
+}
+
+func TestStoreReserveNextInSequence(t *testing.T) {
+	store, err := NewStore(8)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Reserve(2)) // the root of sequence number 2
+	for _, expected := range []int{0, 4, 6, 1, 5, 3, 7} {
+		index, err := store.ReserveNext()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, index)
+	}
+	_, err = store.ReserveNext()
+	assert.ErrorIs(t, err, ErrNoTupleAvailable)
+}
+
+func TestStoreMetrics(t *testing.T) {
+	store, err := NewStore(4)
+	assert.Nil(t, err)
+	registry := metrics.NewRegistry()
+	store.SetMetrics(registry)
+	assertGauges := func(available, reserved float64) {
+		value, _ := registry.Value(metrics.StoreAvailable)
+		assert.Equal(t, available, value)
+		value, _ = registry.Value(metrics.StoreReserved)
+		assert.Equal(t, reserved, value)
+	}
+	assertGauges(4, 0)
+
+	assert.Nil(t, store.Reserve(0))
+	assert.Nil(t, store.Reserve(1))
+	assertGauges(2, 2)
+	assert.Nil(t, store.Commit(0))
+	assertGauges(2, 1)
+	assert.Nil(t, store.Abort(1))
+	assertGauges(3, 0)
+}
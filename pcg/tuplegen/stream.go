@@ -0,0 +1,55 @@
+package tuplegen
+
+import (
+	"fmt"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/stream"
+)
+
+// WriteTuples writes the batch of tuples to w, one chunk per tuple (see BBSPlusTuple.Serialize).
+func WriteTuples(w *stream.Writer, tuples []*BBSPlusTuple) error {
+	return stream.WriteAll(w, tuples)
+}
+
+// ReadTuples reads the remaining tuples of a stream written by WriteTuples. Each tuple is checked by Deserialize.
+func ReadTuples(r *stream.Reader) ([]*BBSPlusTuple, error) {
+	return stream.ReadAll(r, func(data []byte) (*BBSPlusTuple, error) {
+		tuple := &BBSPlusTuple{}
+		if err := tuple.Deserialize(data); err != nil {
+			return nil, err
+		}
+		return tuple, nil
+	})
+}
+
+// WritePolyShares writes the precomputed shares to w, i.e. the sk share followed by the polynomials of the a, e, s,
+// alpha, delta0 and delta1 shares, one chunk each (see poly.Polynomial.Serialize).
+func WritePolyShares(w *stream.Writer, shares *PolyShares) error {
+	if err := w.WriteChunk(shares.skShare.ToBytes()); err != nil {
+		return err
+	}
+	return stream.WriteAll(w, []*poly.Polynomial{shares.aPoly, shares.ePoly, shares.sPoly, shares.alphaPoly, shares.delta0Poly, shares.delta1Poly})
+}
+
+// ReadPolyShares reads shares written by WritePolyShares from r.
+func ReadPolyShares(r *stream.Reader) (*PolyShares, error) {
+	data, err := r.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sk share: %w", err)
+	}
+	skShare, err := decodeFr(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sk share: %w", err)
+	}
+	polys := make([]*poly.Polynomial, 6)
+	for i := range polys {
+		if data, err = r.Next(); err != nil {
+			return nil, fmt.Errorf("failed to read polynomial %d: %w", i, err)
+		}
+		polys[i] = poly.NewEmpty()
+		if err := polys[i].Deserialize(data); err != nil {
+			return nil, fmt.Errorf("invalid polynomial %d: %w", i, err)
+		}
+	}
+	return NewPolyShares(skShare, polys[0], polys[1], polys[2], polys[3], polys[4], polys[5]), nil
+}
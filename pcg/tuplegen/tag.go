@@ -0,0 +1,38 @@
+package tuplegen
+
+import (
+	"fmt"
+	"time"
+)
+
+// TupleTag holds metadata of a BBSPlusTuple, s.t. tuple stores can be audited and tuples of different
+// precomputation runs are not combined accidentally.
+type TupleTag struct {
+	RootIndex    int       // RootIndex is the index of the root the tuple was generated for, -1 if unknown
+	SeedHash     [32]byte  // SeedHash identifies the seed generation (and re-randomization) the tuple stems from. It is equal for all parties.
+	ParamsDigest [32]byte  // ParamsDigest identifies the parameters of the PCG the tuple was generated with
+	Timestamp    time.Time // Timestamp is the time of the generation of the tuple
+}
+
+// CheckCompatible returns an error if the tuples of t and other cannot be combined,
+// i.e. if they stem from different seed generations, PCG parameters or roots.
+func (t *TupleTag) CheckCompatible(other *TupleTag) error {
+	if t.ParamsDigest != other.ParamsDigest {
+		return fmt.Errorf("tuples were generated with different PCG parameters")
+	}
+	if t.SeedHash != other.SeedHash {
+		return fmt.Errorf("tuples stem from different seed generations")
+	}
+	if t.RootIndex != other.RootIndex {
+		return fmt.Errorf("tuples were generated for different roots (%d and %d)", t.RootIndex, other.RootIndex)
+	}
+	return nil
+}
+
+// forRoot returns a copy of the tag for the root with the given index, stamped with the current time.
+func (t *TupleTag) forRoot(index int) *TupleTag {
+	tag := *t
+	tag.RootIndex = index
+	tag.Timestamp = time.Now()
+	return &tag
+}
@@ -0,0 +1,274 @@
+// Package tuplegen implements the derivation of BBS+ tuples from the shares of a party, i.e. the combination of the
+// alpha, delta0 and delta1 shares, the tagging and the serialization of tuples. The shares are obtained from a
+// ShareProvider (n-out-of-n) or a SeparateShareProvider (tau-out-of-n), s.t. expansions other than the PCG, e.g.
+// non-PCG-based preprocessing, can reuse it.
+package tuplegen
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/artifact"
+	"strings"
+	"time"
+)
+
+// BBSPlusTuple is a share of a pre-computed BBS+ signature, e.g. generated by the EvalCombined function of the PCG.
+type BBSPlusTuple struct {
+	SkShare    *bls12381.Fr
+	AShare     *bls12381.Fr
+	EShare     *bls12381.Fr
+	SShare     *bls12381.Fr
+	AlphaShare *bls12381.Fr
+	DeltaShare *bls12381.Fr
+	Tag        *TupleTag       // Tag is the optional metadata of the tuple. It is nil for untagged tuples.
+	Base       *CommitmentBase // Base is the optional precomputed commitment base of the tuple.
+
+	// Commitments are the optional Pedersen commitments to AShare, EShare and SShare (see CommitShares). They are not
+	// serialized, but can be exported on their own (see ShareCommitments.Serialize).
+	Commitments *ShareCommitments
+}
+
+// EmptyTuple returns an empty BBSPlusTuple.
+// The amount of AeTerms, SeTerms and AskTerms is determined by s.
+func NewBBSPlusTuple(SkShare, AShare, EShare, SShare, AlphaShare, DeltaShare *bls12381.Fr) *BBSPlusTuple {
+	tuple := &BBSPlusTuple{
+		SkShare:    bls12381.NewFr(),
+		AShare:     bls12381.NewFr(),
+		EShare:     bls12381.NewFr(),
+		SShare:     bls12381.NewFr(),
+		AlphaShare: bls12381.NewFr(),
+		DeltaShare: bls12381.NewFr(),
+	}
+	// Copy the values of the parameters into the tuple
+	tuple.SkShare.FromBytes(SkShare.ToBytes())
+	tuple.AShare.FromBytes(AShare.ToBytes())
+	tuple.EShare.FromBytes(EShare.ToBytes())
+	tuple.SShare.FromBytes(SShare.ToBytes())
+	tuple.AlphaShare.FromBytes(AlphaShare.ToBytes())
+	tuple.DeltaShare.FromBytes(DeltaShare.ToBytes())
+	return tuple
+}
+
+// shareNames are the names of the shares of a tuple in the order of BBSPlusTuple.shares.
+var shareNames = []string{"SkShare", "AShare", "EShare", "SShare", "AlphaShare", "DeltaShare"}
+
+// Serialize converts a BBSPlusTuple into a byte slice, which is prefixed by an artifact header.
+// All six shares must be present.
+func (t *BBSPlusTuple) Serialize() ([]byte, error) {
+	b := bytes.NewBuffer(artifact.NewHeader(artifact.KindTuple, [32]byte{}).Encode())
+	encoder := gob.NewEncoder(b)
+
+	// serialize each share of BBSPlusTuple
+	for k, share := range t.shares() {
+		if share == nil {
+			return nil, fmt.Errorf("cannot serialize tuple without %s", shareNames[k])
+		}
+		if err := encoder.Encode(share.ToBytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	// serialize the optional tag
+	if err := encoder.Encode(t.Tag != nil); err != nil {
+		return nil, err
+	}
+	if t.Tag != nil {
+		if err := encoder.Encode(t.Tag); err != nil {
+			return nil, err
+		}
+	}
+
+	// serialize the optional commitment base. Its generators are derived from the amount of messages on decoding.
+	if err := encoder.Encode(t.Base != nil); err != nil {
+		return nil, err
+	}
+	if t.Base != nil {
+		if err := encoder.Encode(t.Base.Generators.MessageCount()); err != nil {
+			return nil, err
+		}
+		if err := encoder.Encode(bls12381.NewG1().ToCompressed(t.Base.Point)); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// TupleDescription is a summary of a tuple for debugging. It holds no shares, but only which shares are present and
+// the metadata of the tuple, s.t. it can be shared to compare tuples across machines.
+type TupleDescription struct {
+	Shares  []string  // Shares are the names of the present shares, e.g. "AShare".
+	Tag     *TupleTag // Tag is a copy of the tag of the tuple, nil for untagged tuples.
+	HasBase bool      // HasBase is set if the commitment base is precomputed.
+	Bytes   int       // Bytes is the size of the serialized tuple (see Serialize), 0 if a serialized share is missing.
+}
+
+// Describe returns a summary of the tuple (see TupleDescription).
+func (t *BBSPlusTuple) Describe() *TupleDescription {
+	description := &TupleDescription{HasBase: t.Base != nil}
+	if data, err := t.Serialize(); err == nil {
+		description.Bytes = len(data)
+	}
+	for k, share := range t.shares() {
+		if share != nil {
+			description.Shares = append(description.Shares, shareNames[k])
+		}
+	}
+	if t.Tag != nil {
+		tag := *t.Tag
+		description.Tag = &tag
+	}
+	return description
+}
+
+func (d *TupleDescription) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "shares: %s\n", strings.Join(d.Shares, ", "))
+	if d.Tag == nil {
+		b.WriteString("tag: none\n")
+	} else {
+		fmt.Fprintf(&b, "tag: root %d, seed %x, params %x, generated %s\n", d.Tag.RootIndex, d.Tag.SeedHash[:8], d.Tag.ParamsDigest[:8], d.Tag.Timestamp.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "commitment base: %t\nserialized size: %d bytes", d.HasBase, d.Bytes)
+	return b.String()
+}
+
+// MaxSerializedTupleSize is the maximum size of a serialized tuple accepted by Deserialize. Serialized tuples are
+// well below 1 KiB, including the type information of the gob encoded tag.
+const MaxSerializedTupleSize = 4 << 10
+
+// MaxBaseMessageCount is the maximum amount of messages of the commitment base of a tuple accepted by Deserialize,
+// which derives the generators of the messages.
+const MaxBaseMessageCount = 1 << 10
+
+// ErrLegacyTuple is returned (wrapped in a DeserializeError) for tuples of the legacy format without artifact header,
+// which lacks the AlphaShare and DeltaShare. Tuples of the format version 2 lack them as well and are rejected by the
+// artifact header.
+var ErrLegacyTuple = errors.New("tuples of the legacy format lack the AlphaShare and DeltaShare and are no longer supported")
+
+// ErrTupleTooLarge is returned (wrapped in a DeserializeError) if a serialized tuple exceeds MaxSerializedTupleSize.
+var ErrTupleTooLarge = errors.New("serialized tuple exceeds the maximum tuple size")
+
+// DeserializeError is returned by Deserialize if the serialized tuple is malformed. It identifies the offending field.
+type DeserializeError struct {
+	Field string // Field is the name of the field that could not be decoded, e.g. "SkShare" or "Tag".
+	Err   error  // Err is the underlying error.
+}
+
+func (e *DeserializeError) Error() string {
+	return fmt.Sprintf("invalid serialized tuple: field %s: %v", e.Field, e.Err)
+}
+
+func (e *DeserializeError) Unwrap() error {
+	return e.Err
+}
+
+// Deserialize converts a byte slice into a BBSPlusTuple.
+// Serialized tuples may cross trust boundaries, hence the size of the data is bounded by MaxSerializedTupleSize and
+// each share must be the canonical 32 byte encoding of a field element. A commitment base must match the shares. On error, a DeserializeError is returned
+// and the tuple is left unchanged. Tuples of the legacy format without artifact header are rejected (see ErrLegacyTuple).
+func (t *BBSPlusTuple) Deserialize(data []byte) error {
+	if len(data) > MaxSerializedTupleSize {
+		return &DeserializeError{Field: "tuple", Err: ErrTupleTooLarge}
+	}
+	if !artifact.HasHeader(data) {
+		return &DeserializeError{Field: "header", Err: ErrLegacyTuple}
+	}
+	_, body, err := artifact.Decode(data, artifact.KindTuple)
+	if err != nil {
+		return &DeserializeError{Field: "header", Err: err}
+	}
+	b := bytes.NewBuffer(body)
+	decoder := gob.NewDecoder(b)
+
+	// Deserialize all six shares, none of which may be missing
+	shares := make([]*bls12381.Fr, len(shareNames))
+	for i, field := range shareNames {
+		var shareBytes []byte
+		if err := decoder.Decode(&shareBytes); err != nil {
+			return &DeserializeError{Field: field, Err: err}
+		}
+		share, err := decodeFr(shareBytes)
+		if err != nil {
+			return &DeserializeError{Field: field, Err: err}
+		}
+		shares[i] = share
+	}
+
+	// Deserialize the optional tag
+	var tag *TupleTag
+	var hasTag bool
+	if err := decoder.Decode(&hasTag); err != nil {
+		return &DeserializeError{Field: "Tag", Err: err}
+	}
+	if hasTag {
+		tag = &TupleTag{}
+		if err := decoder.Decode(tag); err != nil {
+			return &DeserializeError{Field: "Tag", Err: err}
+		}
+		if tag.RootIndex < -1 {
+			return &DeserializeError{Field: "Tag", Err: fmt.Errorf("invalid root index %d", tag.RootIndex)}
+		}
+	}
+	decoded := &BBSPlusTuple{SkShare: shares[0], AShare: shares[1], EShare: shares[2], SShare: shares[3], AlphaShare: shares[4], DeltaShare: shares[5], Tag: tag}
+	if err := decoded.deserializeBase(decoder); err != nil {
+		return &DeserializeError{Field: "Base", Err: err}
+	}
+	if b.Len() != 0 {
+		return &DeserializeError{Field: "tuple", Err: errors.New("trailing bytes")}
+	}
+
+	*t = *decoded
+	return nil
+}
+
+// deserializeBase decodes the optional commitment base written by Serialize and checks it against the shares.
+func (t *BBSPlusTuple) deserializeBase(decoder *gob.Decoder) error {
+	var hasBase bool
+	if err := decoder.Decode(&hasBase); err != nil {
+		return err
+	}
+	if !hasBase {
+		return nil
+	}
+	var messageCount int
+	if err := decoder.Decode(&messageCount); err != nil {
+		return err
+	}
+	if messageCount < 1 || messageCount > MaxBaseMessageCount {
+		return fmt.Errorf("message count must be in [1, %d] but is %d", MaxBaseMessageCount, messageCount)
+	}
+	var pointBytes []byte
+	if err := decoder.Decode(&pointBytes); err != nil {
+		return err
+	}
+	point, err := bls12381.NewG1().FromCompressed(pointBytes)
+	if err != nil {
+		return err
+	}
+	generators, err := NewGenerators(messageCount)
+	if err != nil {
+		return err
+	}
+	t.PrecomputeBase(generators)
+	if !bls12381.NewG1().Equal(point, t.Base.Point) {
+		return errors.New("the commitment base does not match the shares")
+	}
+	return nil
+}
+
+// decodeFr decodes the canonical 32 byte encoding of a field element.
+func decodeFr(data []byte) (*bls12381.Fr, error) {
+	if len(data) != 32 {
+		return nil, fmt.Errorf("field element must be 32 bytes long but is %d bytes long", len(data))
+	}
+	value, err := dpf.FrFromBytesCanonical(data)
+	if err != nil {
+		return nil, errors.New("field element is not reduced modulo the group order")
+	}
+	return value, nil
+}
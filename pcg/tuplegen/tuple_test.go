@@ -0,0 +1,178 @@
+package tuplegen_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/artifact"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestTupleSerialization(t *testing.T) {
+	one := bls12381.NewFr().One()
+	two := bls12381.NewFr()
+	two.Add(one, one)
+	tuple := tuplegen.NewBBSPlusTuple(one, two, one, two, one, two)
+	tuple.Tag = &tuplegen.TupleTag{
+		RootIndex:    42,
+		SeedHash:     [32]byte{1, 2, 3},
+		ParamsDigest: [32]byte{4, 5, 6},
+		Timestamp:    time.Unix(1700000000, 0).UTC(),
+	}
+
+	data, err := tuple.Serialize()
+	assert.Nil(t, err)
+	deserialized := emptyTuple()
+	assert.Nil(t, deserialized.Deserialize(data))
+	assert.True(t, tuple.SkShare.Equal(deserialized.SkShare))
+	assert.True(t, tuple.AShare.Equal(deserialized.AShare))
+	assert.True(t, tuple.EShare.Equal(deserialized.EShare))
+	assert.True(t, tuple.SShare.Equal(deserialized.SShare))
+	assert.True(t, tuple.AlphaShare.Equal(deserialized.AlphaShare))
+	assert.True(t, tuple.DeltaShare.Equal(deserialized.DeltaShare))
+	assert.NotNil(t, deserialized.Tag)
+	assert.Equal(t, tuple.Tag.RootIndex, deserialized.Tag.RootIndex)
+	assert.Equal(t, tuple.Tag.SeedHash, deserialized.Tag.SeedHash)
+	assert.Equal(t, tuple.Tag.ParamsDigest, deserialized.Tag.ParamsDigest)
+	assert.True(t, tuple.Tag.Timestamp.Equal(deserialized.Tag.Timestamp))
+
+	// Untagged tuples stay untagged
+	tuple.Tag = nil
+	data, err = tuple.Serialize()
+	assert.Nil(t, err)
+	deserialized = emptyTuple()
+	assert.Nil(t, deserialized.Deserialize(data))
+	assert.Nil(t, deserialized.Tag)
+	assert.True(t, tuple.SShare.Equal(deserialized.SShare))
+	assert.Nil(t, deserialized.Base)
+
+	// The commitment base is restored along with its generators
+	generators, err := tuplegen.NewGenerators(2)
+	assert.Nil(t, err)
+	tuple.PrecomputeBase(generators)
+	data, err = tuple.Serialize()
+	assert.Nil(t, err)
+	deserialized = emptyTuple()
+	assert.Nil(t, deserialized.Deserialize(data))
+	assert.NotNil(t, deserialized.Base)
+	assert.Equal(t, 2, deserialized.Base.Generators.MessageCount())
+	assert.True(t, bls12381.NewG1().Equal(tuple.Base.Point, deserialized.Base.Point))
+
+	// A commitment base of other shares is rejected
+	tuple.AlphaShare = two
+	data, err = tuple.Serialize()
+	assert.Nil(t, err)
+	var deserializeErr *tuplegen.DeserializeError
+	assert.True(t, errors.As(emptyTuple().Deserialize(data), &deserializeErr))
+	assert.Equal(t, "Base", deserializeErr.Field)
+}
+
+func TestTupleDescribe(t *testing.T) {
+	one := bls12381.NewFr().One()
+	tuple := tuplegen.NewBBSPlusTuple(one, one, one, one, one, one)
+	tuple.AlphaShare = nil
+	description := tuple.Describe()
+	assert.Equal(t, []string{"SkShare", "AShare", "EShare", "SShare", "DeltaShare"}, description.Shares)
+	assert.Nil(t, description.Tag)
+	assert.False(t, description.HasBase)
+	assert.Equal(t, 0, description.Bytes) // tuples with missing shares are not serialized
+	_, err := tuple.Serialize()
+	assert.ErrorContains(t, err, "AlphaShare")
+	tuple.AlphaShare = one
+	data, err := tuple.Serialize()
+	assert.Nil(t, err)
+	assert.Equal(t, len(data), tuple.Describe().Bytes)
+	assert.Contains(t, description.String(), "tag: none")
+
+	// The description holds a copy of the tag, but no share values
+	tuple.Tag = &tuplegen.TupleTag{RootIndex: 3, Timestamp: time.Unix(1700000000, 0).UTC()}
+	description = tuple.Describe()
+	tuple.Tag.RootIndex = 4
+	assert.Equal(t, 3, description.Tag.RootIndex)
+	assert.Contains(t, description.String(), "tag: root 3")
+	assert.NotContains(t, description.String(), fmt.Sprintf("%x", one.ToBytes()))
+}
+
+func emptyTuple() *tuplegen.BBSPlusTuple {
+	zero := bls12381.NewFr().Zero()
+	return tuplegen.NewBBSPlusTuple(zero, zero, zero, zero, zero, zero)
+}
+
+func TestTupleDeserializeInvalid(t *testing.T) {
+	one := bls12381.NewFr().One()
+	tuple := tuplegen.NewBBSPlusTuple(one, one, one, one, one, one)
+	tuple.Tag = &tuplegen.TupleTag{RootIndex: 3}
+	data, err := tuple.Serialize()
+	assert.Nil(t, err)
+
+	var deserializeErr *tuplegen.DeserializeError
+	for _, invalid := range [][]byte{nil, data[:20], append(append([]byte{}, data...), 0), make([]byte, tuplegen.MaxSerializedTupleSize+1)} {
+		deserialized := emptyTuple()
+		err := deserialized.Deserialize(invalid)
+		assert.True(t, errors.As(err, &deserializeErr))
+		assert.True(t, deserialized.SkShare.IsZero()) // the tuple is left unchanged
+	}
+	assert.ErrorIs(t, emptyTuple().Deserialize(make([]byte, tuplegen.MaxSerializedTupleSize+1)), tuplegen.ErrTupleTooLarge)
+
+	// Artifacts of another kind are rejected by their header
+	err = emptyTuple().Deserialize(artifact.NewHeader(artifact.KindRing, [32]byte{}).Encode())
+	assert.True(t, errors.As(err, &deserializeErr))
+	assert.Equal(t, "header", deserializeErr.Field)
+
+	// Shares must be canonical 32 byte encodings
+	modulus, _ := new(big.Int).SetString(poly.FrModulus, 16)
+	for _, share := range [][]byte{make([]byte, 31), make([]byte, 33), modulus.Bytes()} {
+		buf := bytes.NewBuffer(artifact.NewHeader(artifact.KindTuple, [32]byte{}).Encode())
+		assert.Nil(t, gob.NewEncoder(buf).Encode(share))
+		err := emptyTuple().Deserialize(buf.Bytes())
+		assert.True(t, errors.As(err, &deserializeErr))
+		assert.Equal(t, "SkShare", deserializeErr.Field)
+	}
+
+	// Tuples without AlphaShare and DeltaShare are incomplete
+	buf := bytes.NewBuffer(artifact.NewHeader(artifact.KindTuple, [32]byte{}).Encode())
+	encoder := gob.NewEncoder(buf)
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, encoder.Encode(one.ToBytes()))
+	}
+	assert.Nil(t, encoder.Encode(false))
+	err = emptyTuple().Deserialize(buf.Bytes())
+	assert.True(t, errors.As(err, &deserializeErr))
+	assert.Equal(t, "AlphaShare", deserializeErr.Field)
+
+	// Tuples of the legacy format and of the format version 2, which lack these shares, are rejected explicitly
+	assert.ErrorIs(t, emptyTuple().Deserialize(buf.Bytes()[len(artifact.NewHeader(artifact.KindTuple, [32]byte{}).Encode()):]), tuplegen.ErrLegacyTuple)
+	header := artifact.NewHeader(artifact.KindTuple, [32]byte{})
+	header.Version = 2
+	var incompatible *artifact.IncompatibleError
+	assert.True(t, errors.As(emptyTuple().Deserialize(append(header.Encode(), data[len(header.Encode()):]...)), &incompatible))
+}
+
+func FuzzTupleDeserialize(f *testing.F) {
+	one := bls12381.NewFr().One()
+	tuple := tuplegen.NewBBSPlusTuple(one, one, one, one, one, one)
+	untagged, _ := tuple.Serialize()
+	tuple.Tag = &tuplegen.TupleTag{RootIndex: 3, Timestamp: time.Unix(1700000000, 0)}
+	tagged, _ := tuple.Serialize()
+	f.Add(untagged)
+	f.Add(tagged)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		deserialized := emptyTuple()
+		if err := deserialized.Deserialize(data); err != nil {
+			return
+		}
+		// Accepted tuples re-serialize without loss of their shares
+		again, err := deserialized.Serialize()
+		assert.Nil(t, err)
+		roundTrip := emptyTuple()
+		assert.Nil(t, roundTrip.Deserialize(again))
+		assert.True(t, deserialized.EqualsConstantTime(roundTrip))
+	})
+}
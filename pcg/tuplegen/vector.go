@@ -0,0 +1,100 @@
+package tuplegen
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+)
+
+// VectorShares is a ShareProvider holding the shares as dense coefficient vectors, i.e. the i-th element of a vector
+// is the coefficient of x^i of the share polynomial. It is provided by the PCG for the n-out-of-n setting if the
+// shares are requested as dense vectors, s.t. no polynomial maps are built.
+type VectorShares struct {
+	skShare   *bls12381.Fr
+	a         []*bls12381.Fr
+	e         []*bls12381.Fr
+	s         []*bls12381.Fr
+	alpha     []*bls12381.Fr
+	delta0    []*bls12381.Fr
+	delta     []*bls12381.Fr
+	maxDegree int           // maxDegree is the maximal degree of the vectors, for which the roots are prepared
+	stats     *statsCounter // stats records the evaluations in the statistics of the generator, nil if none
+}
+
+// NewVectorShares returns a new VectorShares. All vectors must have the same length.
+func NewVectorShares(skShare *bls12381.Fr, a, e, s, alpha, delta0, delta1 []*bls12381.Fr) (*VectorShares, error) {
+	for _, vector := range [][]*bls12381.Fr{e, s, alpha, delta0, delta1} {
+		if len(vector) != len(a) {
+			return nil, fmt.Errorf("share vectors must have the same length but have %d and %d", len(a), len(vector))
+		}
+	}
+	delta := make([]*bls12381.Fr, len(a))
+	values := make([]bls12381.Fr, len(a))
+	for i := range delta {
+		delta[i] = &values[i]
+		delta[i].Add(delta0[i], delta1[i])
+	}
+	return &VectorShares{
+		skShare:   skShare,
+		a:         a,
+		e:         e,
+		s:         s,
+		alpha:     alpha,
+		delta0:    delta0,
+		delta:     delta,
+		maxDegree: len(a) - 1,
+	}, nil
+}
+
+// SkShare returns the share of the secret key.
+func (v *VectorShares) SkShare() *bls12381.Fr {
+	return v.skShare
+}
+
+// SharesAt evaluates the vectors at the given root. The powers of the root are computed once for all vectors.
+func (v *VectorShares) SharesAt(root *bls12381.Fr) (*Shares, error) {
+	shares, err := v.SharesAtRoots([]*bls12381.Fr{root})
+	if err != nil {
+		return nil, err
+	}
+	return shares[0], nil
+}
+
+// SharesAtRoots evaluates the vectors at all given roots in a single pass over each vector
+// (see poly.EvaluateDenseAtPoints).
+func (v *VectorShares) SharesAtRoots(roots []*bls12381.Fr) ([]*Shares, error) {
+	points := v.points(roots)
+	a := v.stats.evaluateDense(ShareA, v.a, points)
+	e := v.stats.evaluateDense(ShareE, v.e, points)
+	s := v.stats.evaluateDense(ShareS, v.s, points)
+	alpha := v.stats.evaluateDense(ShareAlpha, v.alpha, points)
+	delta := v.stats.evaluateDense(ShareDelta, v.delta, points)
+
+	shares := make([]*Shares, len(roots))
+	for i := range shares {
+		shares[i] = &Shares{A: a[i], E: e[i], S: s[i], Alpha: alpha[i], Delta: delta[i]}
+	}
+	return shares, nil
+}
+
+// VOLESharesAt evaluates the vectors of the VOLE correlation at the given root.
+func (v *VectorShares) VOLESharesAt(root *bls12381.Fr) (*VOLEShares, error) {
+	points := v.points([]*bls12381.Fr{root})
+	return &VOLEShares{
+		A:      v.stats.evaluateDense(ShareA, v.a, points)[0],
+		Delta0: v.stats.evaluateDense(ShareDelta, v.delta0, points)[0],
+	}, nil
+}
+
+// points prepares the roots for the evaluation of the vectors.
+func (v *VectorShares) points(roots []*bls12381.Fr) []*poly.EvaluationPoint {
+	points := make([]*poly.EvaluationPoint, len(roots))
+	for i, root := range roots {
+		points[i] = poly.NewEvaluationPoint(root, v.maxDegree)
+	}
+	return points
+}
+
+func (v *VectorShares) recordStats(stats *statsCounter) {
+	v.stats = stats
+}
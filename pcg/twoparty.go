@@ -0,0 +1,149 @@
+package pcg
+
+import (
+	"fmt"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+)
+
+// The noise parameters of the 2-out-of-2 PCG of TwoPartyPrecompute, i.e. c polynomials with t noise positions each.
+// They provide 128 bit security against the known attacks on module-LPN for domains of at least 2^twoPartyMinN
+// (see the parameter estimates of Boyle et al., "Efficient Pseudorandom Correlation Generators from Ring-LPN",
+// CRYPTO 2020). N is picked per amount of tuples (see TwoPartyParameters).
+const (
+	twoPartyC    = 4
+	twoPartyT    = 16
+	twoPartyMinN = 20
+)
+
+// twoPartyPointEvalLimit is the largest amount of tuples a TwoPartyEvaluator derives via EvalCombinedAt. Beyond it,
+// the polynomials of EvalCombined are cheaper than evaluating the DSPF outputs at each root.
+const twoPartyPointEvalLimit = 64
+
+// TwoPartyParameters returns the parameters of the 2-out-of-2 PCG of TwoPartyPrecompute for numTuples tuples, i.e. the
+// smallest domain N >= twoPartyMinN with 2^N >= numTuples along with the fixed noise parameters c and t. Smaller
+// domains are never used, even for few tuples, as SecurityLevel.Effective only bounds the security from above and
+// does not capture the attacks on module-LPN with small domains.
+func TwoPartyParameters(numTuples int) (N, c, t int, err error) {
+	if numTuples < 1 {
+		return 0, 0, 0, fmt.Errorf("the amount of tuples must be positive but is %d", numTuples)
+	}
+	for N = twoPartyMinN; N <= MaxSupportedN; N++ {
+		if 1<<N >= numTuples {
+			return N, twoPartyC, twoPartyT, nil
+		}
+	}
+	return 0, 0, 0, fmt.Errorf("%d tuples exceed the 2^%d tuples of the largest supported domain", numTuples, MaxSupportedN)
+}
+
+// TwoPartySetup is the result of TwoPartyPrecompute.
+type TwoPartySetup struct {
+	Seeds     [2][]byte          // Seeds holds the serialized seed of each party, which must only be given to that party.
+	PublicKey *bls12381.PointG2  // PublicKey is the joint BBS+ public key of both parties.
+	Evaluator *TwoPartyEvaluator // Evaluator derives the tuples of a seed. Each party can also create it via NewTwoPartyEvaluator.
+}
+
+// TwoPartyPrecompute generates the seeds of a 2-out-of-2 PCG for numTuples BBS+ tuples, e.g. for a wallet and its
+// server. The parameters are picked by TwoPartyParameters, hence the seeds cover at least 2^twoPartyMinN tuples and
+// their evaluation is as expensive as for 2^twoPartyMinN tuples. The seeds are generated by a trusted dealer (see
+// TrustedSeedGen), i.e. the caller, which must discard them once they are handed to the parties.
+func TwoPartyPrecompute(numTuples int) (*TwoPartySetup, error) {
+	evaluator, err := NewTwoPartyEvaluator(numTuples)
+	if err != nil {
+		return nil, err
+	}
+	return twoPartyPrecompute(evaluator)
+}
+
+// twoPartyPrecompute generates the seeds of the PCG of the evaluator (see TwoPartyPrecompute).
+func twoPartyPrecompute(evaluator *TwoPartyEvaluator) (*TwoPartySetup, error) {
+	seeds, err := evaluator.pcg.TrustedSeedGen()
+	if err != nil {
+		return nil, err
+	}
+	setup := &TwoPartySetup{Evaluator: evaluator}
+	pkShares := make([]*bls12381.PointG2, len(seeds))
+	for i, seed := range seeds {
+		if setup.Seeds[i], err = seed.Serialize(); err != nil {
+			return nil, err
+		}
+		pkShares[i] = seed.PublicKeyShare()
+	}
+	if setup.PublicKey, err = AggregatePublicKey(pkShares); err != nil {
+		return nil, err
+	}
+	return setup, nil
+}
+
+// TwoPartyEvaluator derives the tuples of the seeds of TwoPartyPrecompute locally. The public random polynomials are
+// those of epoch 0 of the setup of the seed (see PickRandomPolynomialsForEpoch), s.t. both parties derive them without
+// interaction.
+type TwoPartyEvaluator struct {
+	pcg       *PCG
+	ring      *Ring
+	numTuples int
+}
+
+// NewTwoPartyEvaluator returns the evaluator of the seeds of TwoPartyPrecompute(numTuples).
+func NewTwoPartyEvaluator(numTuples int) (*TwoPartyEvaluator, error) {
+	N, c, t, err := TwoPartyParameters(numTuples)
+	if err != nil {
+		return nil, err
+	}
+	return newTwoPartyEvaluator(numTuples, N, c, t)
+}
+
+// newTwoPartyEvaluator returns the evaluator of numTuples tuples of the 2-out-of-2 PCG with the given parameters.
+func newTwoPartyEvaluator(numTuples, N, c, t int) (*TwoPartyEvaluator, error) {
+	if numTuples < 1 || numTuples > 1<<N {
+		return nil, fmt.Errorf("the amount of tuples must be in [1, 2^%d] but is %d", N, numTuples)
+	}
+	pcg, err := NewPCG(128, N, 2, 2, c, t)
+	if err != nil {
+		return nil, err
+	}
+	ring, err := pcg.GetLazyRing()
+	if err != nil {
+		return nil, err
+	}
+	return &TwoPartyEvaluator{pcg: pcg, ring: ring, numTuples: numTuples}, nil
+}
+
+// PCG returns the PCG of the evaluator, e.g. to inspect its parameters.
+func (e *TwoPartyEvaluator) PCG() *PCG {
+	return e.pcg
+}
+
+// Evaluate returns the numTuples tuple shares of the serialized seed, ordered by their sequence numbers (see
+// tuplegen.TupleRootIndex). Both parties obtain the shares of the same tuples, which combine to BBS+ tuples.
+func (e *TwoPartyEvaluator) Evaluate(serializedSeed []byte) ([]*BBSPlusTuple, error) {
+	seed := &Seed{}
+	if err := seed.Deserialize(serializedSeed); err != nil {
+		return nil, err
+	}
+	if err := seed.VerifyShare(); err != nil {
+		return nil, err
+	}
+	setup, err := seed.SetupDigest()
+	if err != nil {
+		return nil, err
+	}
+	rand, err := e.pcg.PickRandomPolynomialsForEpoch(setup, 0)
+	if err != nil {
+		return nil, err
+	}
+	indices := make([]int, e.numTuples)
+	for i := range indices {
+		if indices[i], err = tuplegen.TupleRootIndex(i, e.ring.Size()); err != nil {
+			return nil, err
+		}
+	}
+	if e.numTuples <= twoPartyPointEvalLimit {
+		return e.pcg.EvalCombinedAt(seed, rand, e.ring.Prepared(), indices)
+	}
+	generator, err := e.pcg.EvalCombined(seed, rand, e.ring.Prepared())
+	if err != nil {
+		return nil, err
+	}
+	return generator.GenBBSPlusTuplesAt(e.ring, indices)
+}
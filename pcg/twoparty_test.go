@@ -0,0 +1,71 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTwoPartyParameters(t *testing.T) {
+	// Few tuples use the smallest vetted domain
+	N, c, tt, err := TwoPartyParameters(1)
+	assert.Nil(t, err)
+	assert.Equal(t, twoPartyMinN, N)
+	assert.Equal(t, 4, c)
+	assert.Equal(t, 16, tt)
+	_, err = CheckParameters(128, N, 2, 2, c, tt)
+	assert.Nil(t, err)
+
+	N, _, _, err = TwoPartyParameters(1<<twoPartyMinN + 1)
+	assert.Nil(t, err)
+	assert.Equal(t, twoPartyMinN+1, N)
+
+	_, _, _, err = TwoPartyParameters(0)
+	assert.NotNil(t, err)
+	_, _, _, err = TwoPartyParameters(1<<MaxSupportedN + 1)
+	assert.NotNil(t, err)
+}
+
+// The evaluation with the parameters of TwoPartyParameters is too expensive for tests, hence they use smaller (insecure)
+// parameters.
+func TestTwoPartyPrecompute(t *testing.T) {
+	// Below and above twoPartyPointEvalLimit, i.e. via EvalCombinedAt and EvalCombined
+	for _, numTuples := range []int{4, twoPartyPointEvalLimit + 1} {
+		testTwoPartyPrecompute(t, numTuples)
+	}
+}
+
+func testTwoPartyPrecompute(t *testing.T, numTuples int) {
+	evaluator, err := newTwoPartyEvaluator(numTuples, 7, 2, 4)
+	assert.Nil(t, err)
+	setup, err := twoPartyPrecompute(evaluator)
+	assert.Nil(t, err)
+
+	// Each party evaluates its seed with its own evaluator
+	shares := make([][]*BBSPlusTuple, 2)
+	for i, seed := range setup.Seeds {
+		evaluator, err := newTwoPartyEvaluator(numTuples, 7, 2, 4)
+		assert.Nil(t, err)
+		shares[i], err = evaluator.Evaluate(seed)
+		assert.Nil(t, err)
+		assert.Len(t, shares[i], numTuples)
+	}
+
+	sk := bls12381.NewFr()
+	sk.Add(shares[0][0].SkShare, shares[1][0].SkShare)
+	g2 := bls12381.NewG2()
+	assert.True(t, g2.Equal(setup.PublicKey, g2.MulScalar(g2.New(), g2.One(), sk)))
+	for sequence := 0; sequence < numTuples; sequence++ {
+		rootIndex, err := tuplegen.TupleRootIndex(sequence, setup.Evaluator.ring.Size())
+		assert.Nil(t, err)
+		check, err := CheckRootCorrelation([]*BBSPlusTuple{shares[0][sequence], shares[1][sequence]}, rootIndex)
+		assert.Nil(t, err)
+		assert.True(t, check.Alpha && check.Delta, "tuple %d", sequence)
+	}
+
+	_, err = setup.Evaluator.Evaluate(setup.Seeds[0][:len(setup.Seeds[0])/2])
+	assert.NotNil(t, err)
+	_, err = newTwoPartyEvaluator(1<<7+1, 7, 2, 4)
+	assert.NotNil(t, err)
+}
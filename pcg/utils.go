@@ -1,247 +1,203 @@
 package pcg
 
 import (
-	"encoding/binary"
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/dpf"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/artifact"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
 	"math/big"
-	"math/rand"
-	"pcg-bbs-plus/pcg/poly"
+	"math/bits"
 	"runtime"
 	"sort"
-	"sync"
 )
 
-const forwardDirection = 0
-const backwardDirection = 1
+const forwardDirection = tuplegen.ForwardDirection
+const backwardDirection = tuplegen.BackwardDirection
 
-// getShamirSharedRandomElement generates a t-out-of-n shamir secret sharing of a random element.
-// This function is taken from the threshold-bbs-plus-signatures repository.
-func getShamirSharedRandomElement(rng *rand.Rand, t, n int) (*bls12381.Fr, []*bls12381.Fr) {
-	// Generate the secret key element
-	secretKeyElement := bls12381.NewFr()
-	_, err := secretKeyElement.Rand(rng)
-	if err != nil {
-		panic(err)
-	}
-
-	// Shamir Coefficients
-	coefficients := make([]*bls12381.Fr, t-1)
-	for i := 0; i < t-1; i++ {
-		coefficients[i] = bls12381.NewFr()
-		_, err := coefficients[i].Rand(rng)
-		if err != nil {
-			panic(err)
-		}
-	}
-
-	// Shares
-	shares := make([]*bls12381.Fr, n)
-	for i := 0; i < n; i++ {
-		share := bls12381.NewFr()
-		share.Set(secretKeyElement) // Share initialized with secret key element
-
-		incrExponentiation := bls12381.NewFr().One()
-
-		for j := 0; j < t-1; j++ {
-			incrExponentiation.Mul(incrExponentiation, uint64ToFr(uint64(i+1)))
-			tmp := bls12381.NewFr().Set(coefficients[j])
-			tmp.Mul(tmp, incrExponentiation)
-			share.Add(share, tmp)
-		}
-
-		shares[i] = share
+// domainSize returns 2^N as an int. It returns an error if 2^N does not fit into an int of the platform, e.g. for
+// N >= 31 on 32-bit platforms.
+func domainSize(N int) (int, error) {
+	if N < 0 || N >= bits.UintSize-1 {
+		return 0, fmt.Errorf("2^N does not fit into an int of %d bits for N = %d", bits.UintSize, N)
 	}
-	return secretKeyElement, shares
+	return 1 << N, nil
 }
 
-// uint64ToFr converts an uint64 into a bls12381.Fr.
-// This function is taken from the threshold-bbs-plus-signatures repository.
-func uint64ToFr(val uint64) *bls12381.Fr {
-	fr := bls12381.NewFr()
-	buf := make([]byte, 8)
-	binary.LittleEndian.PutUint64(buf, val)
-	fr.FromBytes(buf)
-	return fr
-}
-
-// bytesToInt64 converts a byte slice into an int64.
-func bytesToInt64(b []byte) (int64, error) {
-	// Make sure byte slice has enough bytes to represent a uint64 (8 bytes)
-	if len(b) < 8 {
-		return 0, fmt.Errorf("byte slice is too short to represent an int64")
+// randomPolynomialLength returns the amount of coefficients 2^N of the public random polynomials.
+// It returns an error if their products with the polynomials of Eval, i.e. 2^(N+1) coefficients, exceed the FFT or
+// if the polynomials exceed the memory limit.
+func (p *PCG) randomPolynomialLength() (int, error) {
+	if p.N > MaxSupportedN {
+		return 0, fmt.Errorf("N must be at most %d, as the products of the random polynomials require an FFT of size 2^(N+1) but the FFT supports at most 2^%d", MaxSupportedN, poly.MaxFFTOrder)
 	}
-
-	// Convert to uint64 first (you can use BigEndian or LittleEndian)
-	val := binary.BigEndian.Uint64(b)
-
-	// Cast uint64 to int64
-	return int64(val), nil
+	if err := p.checkMemory("PickRandomPolynomials", func(e *MemoryEstimate) int64 { return e.RandomPolys }, ""); err != nil {
+		return 0, err
+	}
+	return domainSize(p.N)
 }
 
 // outerSumInt calculates the outer sum of two slices of *big.Int.
 // the resulting matrix is returned in vector form.
 func outerSumBigInt(a, b []*big.Int) []*big.Int {
-	result := make([]*big.Int, len(a)*len(b))
-
-	for i, ai := range a {
-		baseIndex := i * len(b)
-		for j, bj := range b {
-			result[baseIndex+j] = new(big.Int).Add(ai, bj)
-		}
-	}
-
-	return result
+	return newOuterArena(len(a)*len(b)).outerSum(a, b)
 }
 
 // outerProductFr calculates the outer product of two slices of *bls12381.Fr.
 // the resulting matrix is returned in vector form.
 func outerProductFr(a, b []*bls12381.Fr) []*bls12381.Fr {
-	result := make([]*bls12381.Fr, len(a)*len(b))
-
+	_, values := newOuterArena(len(a) * len(b)).slices(len(a) * len(b))
+	outerProductFrInto(values, a, b)
+	return values
+}
+
+// maxInt64Summand bounds the summands of the int64 path of outerSumBigIntInto, s.t. their sums do not overflow.
+const maxInt64Summand = 1 << 62
+
+// outerSumBigIntInto sets dst[i*len(b)+j] = a[i] + b[j], where dst must hold len(a)*len(b) integers, which are set in
+// place. If all summands are within (-2^62, 2^62), e.g. the exponents of the PCG, the sums are computed on int64,
+// which are collected in the given scratch space. It returns the scratch space for reuse.
+func outerSumBigIntInto(dst, a, b []*big.Int, scratch []int64) []int64 {
+	summands, ok := int64Summands(scratch[:0], a, b)
+	if ok {
+		av, bv := summands[:len(a)], summands[len(a):]
+		for i, ai := range av {
+			row := dst[i*len(b) : (i+1)*len(b)]
+			for j, bj := range bv {
+				row[j].SetInt64(ai + bj)
+			}
+		}
+		return summands
+	}
 	for i, ai := range a {
-		baseIndex := i * len(b)
+		row := dst[i*len(b) : (i+1)*len(b)]
 		for j, bj := range b {
-			result[baseIndex+j] = bls12381.NewFr()
-			result[baseIndex+j].Mul(ai, bj)
+			row[j].Add(ai, bj)
 		}
 	}
-
-	return result
+	return summands
 }
 
-// outerProductPoly calculates the outer product of two slices of *poly.Polynomial.
-// The function is implemented using a worker pool to handle large polynomials.
-func outerProductPoly(a, b []*poly.Polynomial) ([]*poly.Polynomial, error) {
-	numCores := runtime.NumCPU()
-	tasks := make(chan polyTask, numCores)
-	results := make(chan polyResult, len(a)*len(b))
-	errs := make(chan error, 1)
-
-	// Worker function for polynomial multiplication
-	var wg sync.WaitGroup
-	worker := func() {
-		defer wg.Done()
-		for task := range tasks {
-			prod, err := poly.Mul(task.aPoly, task.bPoly)
-			if err != nil {
-				errs <- err
-				return
+// int64Summands appends the values of a and b to dst and reports whether all of them are within (-2^62, 2^62).
+func int64Summands(dst []int64, a, b []*big.Int) ([]int64, bool) {
+	for _, values := range [2][]*big.Int{a, b} {
+		for _, v := range values {
+			if !v.IsInt64() {
+				return dst, false
 			}
-			results <- polyResult{task.aIndex, task.bIndex, prod}
+			x := v.Int64()
+			if x <= -maxInt64Summand || x >= maxInt64Summand {
+				return dst, false
+			}
+			dst = append(dst, x)
 		}
 	}
+	return dst, true
+}
 
-	// Start workers
-	for i := 0; i < numCores; i++ {
-		wg.Add(1)
-		go worker()
-	}
-
-	// Distribute tasks
-	go func() {
-		for i, aPoly := range a {
-			for j, bPoly := range b {
-				tasks <- polyTask{i, j, aPoly, bPoly}
-			}
+// outerProductFrInto sets dst[i*len(b)+j] = a[i] * b[j], where dst must hold len(a)*len(b) field elements, which are
+// set in place.
+func outerProductFrInto(dst, a, b []*bls12381.Fr) {
+	for i, ai := range a {
+		row := dst[i*len(b) : (i+1)*len(b)]
+		for j, bj := range b {
+			row[j].Mul(ai, bj)
 		}
-		close(tasks)
-	}()
-
-	// Wait for workers to complete and close results channel
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	// Collect results
-	res := make([]*poly.Polynomial, len(a)*len(b))
-	for result := range results {
-		i, j := result.aIndex, result.bIndex
-		res[i*len(b)+j] = result.product
 	}
+}
 
-	// Check for errors
-	select {
-	case err := <-errs:
-		return nil, err
-	default:
-	}
+// outerArena holds preallocated outputs of the outer sums and products of t-vectors, s.t. the n*n*c*c OLE
+// correlations of the seed generation reuse them instead of allocating t*t integers and field elements each.
+// The outputs of a call are only valid until the next call on the arena, which is not safe for concurrent use.
+type outerArena struct {
+	points  []*big.Int
+	values  []*bls12381.Fr
+	scratch []int64 // scratch holds the int64 summands of outerSumBigIntInto
+}
 
-	return res, nil
+// newOuterArena returns an arena for outer sums and products of size entries.
+func newOuterArena(size int) *outerArena {
+	a := &outerArena{}
+	a.grow(size)
+	return a
 }
 
-// scalarMulFr multiplies a scalar with a vector of *bls12381.Fr.
-func scalarMulFr(scalar *bls12381.Fr, vector []*bls12381.Fr) []*bls12381.Fr {
-	result := make([]*bls12381.Fr, len(vector))
-	for i := 0; i < len(vector); i++ {
-		result[i] = bls12381.NewFr()
-		result[i].Mul(scalar, vector[i])
+// grow allocates the storage of size entries at once.
+func (a *outerArena) grow(size int) {
+	pointStorage := make([]big.Int, size)
+	valueStorage := make([]bls12381.Fr, size)
+	a.points = make([]*big.Int, size)
+	a.values = make([]*bls12381.Fr, size)
+	for k := range pointStorage {
+		a.points[k] = &pointStorage[k]
+		a.values[k] = &valueStorage[k]
 	}
-	return result
 }
 
-// init3DSliceBigInt initializes a 3D slice of *big.Int
-func init3DSliceBigInt(n, m, p int) [][][]*big.Int {
-	slice := make([][][]*big.Int, n)
-	for i := range slice {
-		slice[i] = make([][]*big.Int, m)
-		for j := range slice[i] {
-			slice[i][j] = make([]*big.Int, p)
-			for k := range slice[i][j] {
-				slice[i][j][k] = new(big.Int)
-			}
-		}
+// slices returns the first size points and values of the arena, growing it if needed.
+func (a *outerArena) slices(size int) ([]*big.Int, []*bls12381.Fr) {
+	if size > len(a.points) {
+		a.grow(size)
 	}
-	return slice
+	return a.points[:size], a.values[:size]
+}
+
+// outerSum returns the outer sum of a and b (see outerSumBigInt) in the storage of the arena.
+func (a *outerArena) outerSum(x, y []*big.Int) []*big.Int {
+	points, _ := a.slices(len(x) * len(y))
+	a.scratch = outerSumBigIntInto(points, x, y, a.scratch)
+	return points
 }
 
-// init3DSliceFr initializes a 3D slice of *bls12381.Fr
-func init3DSliceFr(n, m, p int) [][][]*bls12381.Fr {
-	slice := make([][][]*bls12381.Fr, n)
-	for i := range slice {
-		slice[i] = make([][]*bls12381.Fr, m)
-		for j := range slice[i] {
-			slice[i][j] = make([]*bls12381.Fr, p)
-			for k := range slice[i][j] {
-				slice[i][j][k] = bls12381.NewFr()
+// outerSumAndProduct returns the special points omega+o and the non-zero elements beta*b of the OLE correlation of the
+// t-sparse vectors (omega, beta) and (o, b) in a single pass, in the storage of the arena.
+func (a *outerArena) outerSumAndProduct(omega, o []*big.Int, beta, b []*bls12381.Fr) ([]*big.Int, []*bls12381.Fr) {
+	points, values := a.slices(len(omega) * len(o))
+	summands, ok := int64Summands(a.scratch[:0], omega, o)
+	a.scratch = summands
+	for i := range omega {
+		pointRow, valueRow := points[i*len(o):(i+1)*len(o)], values[i*len(o):(i+1)*len(o)]
+		for j := range o {
+			if ok {
+				pointRow[j].SetInt64(summands[i] + summands[len(omega)+j])
+			} else {
+				pointRow[j].Add(omega[i], o[j])
 			}
+			valueRow[j].Mul(beta[i], b[j])
 		}
 	}
-	return slice
+	return points, values
 }
 
-// init3DSliceDspfKey initializes a 3D slice of *DSPFKeyPair
-func init3DSliceDspfKey(n, m, p int) [][][]*DSPFKeyPair {
-	slice := make([][][]*DSPFKeyPair, n)
-	for i := range slice {
-		slice[i] = make([][]*DSPFKeyPair, m)
-		for j := range slice[i] {
-			slice[i][j] = make([]*DSPFKeyPair, p)
-			for k := range slice[i][j] {
-				slice[i][j][k] = new(DSPFKeyPair)
-			}
+// outerProductPoly calculates the outer product of two slices of *poly.Polynomial.
+// The multiplications are parallelized to handle large polynomials.
+func outerProductPoly(a, b []*poly.Polynomial) ([]*poly.Polynomial, error) {
+	res := make([]*poly.Polynomial, len(a)*len(b))
+	err := parallelFor(len(res), runtime.NumCPU(), func(i int) error {
+		prod, err := mulPoly(a[i/len(b)], b[i%len(b)])
+		if err != nil {
+			return err
 		}
+		res[i] = prod
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return slice
+	return res, nil
 }
 
-// init4DSliceDspfKey initializes a 3D slice of *DSPFKeyPair
-func init4DSliceDspfKey(n, m, p int) [][][][]*DSPFKeyPair {
-	slice := make([][][][]*DSPFKeyPair, n)
-	for i := range slice {
-		slice[i] = make([][][]*DSPFKeyPair, m)
-		for j := range slice[i] {
-			slice[i][j] = make([][]*DSPFKeyPair, p)
-			for k := range slice[i][j] {
-				slice[i][j][k] = make([]*DSPFKeyPair, p)
-				for p := range slice[i][j][k] {
-					slice[i][j][k][p] = new(DSPFKeyPair)
-				}
-			}
-		}
+// scalarMulFr multiplies a scalar with a vector of *bls12381.Fr.
+func scalarMulFr(scalar *bls12381.Fr, vector []*bls12381.Fr) []*bls12381.Fr {
+	result := make([]*bls12381.Fr, len(vector))
+	for i := 0; i < len(vector); i++ {
+		result[i] = bls12381.NewFr()
+		result[i].Mul(scalar, vector[i])
 	}
-	return slice
+	return result
 }
 
 // frSliceToBigIntSlice converts a slice of *bls12381.Fr to a slice of *big.Int
@@ -267,21 +223,6 @@ func hasDuplicates(slice []*big.Int) bool {
 	return false
 }
 
-func aggregateDSPFoutput(output [][]*big.Int) []*bls12381.Fr {
-	sums := make([]*bls12381.Fr, len(output[0]))
-	for i := 0; i < len(output[0]); i++ {
-		for j := 0; j < len(output); j++ {
-			if sums[i] == nil {
-				sums[i] = bls12381.NewFr()
-			}
-			val := bls12381.NewFr().FromBytes(output[j][i].Bytes())
-			sums[i].Add(sums[i], val)
-		}
-	}
-
-	return sums
-}
-
 // primeFactor represents a prime factor and its exponent.
 type primeFactor struct {
 	Factor   *big.Int // The prime factor
@@ -312,296 +253,265 @@ func multiplicativeGroupOrderFactorizationBLS12381() []primeFactor {
 
 // Ring defines the ring we work in.
 type Ring struct {
-	Div   *poly.Polynomial
-	Roots []*bls12381.Fr
+	Div      *poly.Polynomial
+	Roots    []*bls12381.Fr   // Roots are the 2^N roots of Div. Roots is nil for rings created via GetLazyRing.
+	prepared *PreparedDivisor // prepared is Div prepared for the reduction of Eval (see Prepared)
+	rootBase *bls12381.Fr     // rootBase is the primitive 2^(N+1)th root of unity, the i-th root is rootBase^(2i+1)
+	size     int              // size is the amount of roots 2^N
 }
 
-// evalFinalShareTask represents a task for the eval2D function.
-type evalFinalShareTask struct {
-	j, k        int
-	oprand      *poly.Polynomial
-	wPoly       *poly.Polynomial
-	div         *poly.Polynomial
-	isLastIndex bool
+// Prepared returns the divisor of the ring prepared for Eval (see PreparedDivisor).
+func (r *Ring) Prepared() *PreparedDivisor {
+	return r.prepared
 }
 
-// evalFinalShareResult represents the result of the eval2D function.
-type evalFinalShareResult struct {
-	poly *poly.Polynomial
-	err  error
+// prepare prepares the divisor of the ring.
+func (r *Ring) prepare() error {
+	prepared, err := NewPreparedDivisor(r.Div)
+	if err != nil {
+		return err
+	}
+	prepared.ring = r
+	r.prepared = prepared
+	return nil
 }
 
-// polyTask represents a task for the polynomial multiplication.
-type polyTask struct {
-	aIndex int
-	bIndex int
-	aPoly  *poly.Polynomial
-	bPoly  *poly.Polynomial
+// Size returns the amount of roots of the ring, i.e. the maximum amount of tuples that can be generated.
+func (r *Ring) Size() int {
+	return r.size
 }
 
-// polyResult represents the result of the polynomial multiplication.
-type polyResult struct {
-	aIndex  int
-	bIndex  int
-	product *poly.Polynomial
+// ringData is the gob format of a Ring. Div is not encoded, as it is determined by the size.
+type ringData struct {
+	Size     int
+	RootBase []byte
+	Roots    [][]byte // Roots is nil for rings whose roots are not materialized
 }
 
-// evalFinalShare evaluates the final share of the PCG for the given polynomial.
-// This function effectively calculates the inner product between the given polynomial and the random polynomials in div.
-func (p *PCG) evalFinalShare(u, rand []*poly.Polynomial, div *poly.Polynomial) (*poly.Polynomial, error) {
-	numCores := runtime.NumCPU()
-	tasks := make(chan evalFinalShareTask, numCores)
-	results := make(chan evalFinalShareResult, p.c)
-
-	var wg sync.WaitGroup
-	worker := func() {
-		defer wg.Done()
-		for task := range tasks {
-			prod, err := poly.Mul(task.oprand, task.wPoly)
-			if err != nil {
-				results <- evalFinalShareResult{nil, err}
-				return
-			}
-
-			remainder, err := prod.Mod(div)
-			results <- evalFinalShareResult{remainder, err}
+// Serialize serializes the ring including its materialized roots, s.t. the roots need not be recomputed.
+// The ring is prefixed by an artifact header, which records the order of its roots.
+func (r *Ring) Serialize() ([]byte, error) {
+	data := ringData{Size: r.size, RootBase: r.rootBase.ToBytes()}
+	if r.Roots != nil {
+		data.Roots = make([][]byte, len(r.Roots))
+		for i, root := range r.Roots {
+			data.Roots[i] = root.ToBytes()
 		}
 	}
-
-	for i := 0; i < numCores; i++ {
-		wg.Add(1)
-		go worker()
+	buf := bytes.NewBuffer(artifact.NewHeader(artifact.KindRing, [32]byte{}).Encode())
+	if err := gob.NewEncoder(buf).Encode(&data); err != nil {
+		return nil, fmt.Errorf("failed to encode ring: %w", err)
 	}
+	return buf.Bytes(), nil
+}
 
-	go func() {
-		for r := 0; r < p.c; r++ {
-			tasks <- evalFinalShareTask{0, 0, rand[r], u[r], div, false} // Indices and isLastIndex are not used here
+// Deserialize deserializes a ring serialized via Serialize and sets the ring the function is being called on.
+// It checks that the root base is a primitive 2^(N+1)th root of unity and that the first materialized root matches it,
+// but does not check all materialized roots. Rings of the legacy format without artifact header are accepted.
+func (r *Ring) Deserialize(data []byte) error {
+	if artifact.HasHeader(data) {
+		_, body, err := artifact.Decode(data, artifact.KindRing)
+		if err != nil {
+			return err
 		}
-		close(tasks)
-	}()
-
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+		data = body
+	}
 
-	ai := poly.NewEmpty()
-	for i := 0; i < p.c; i++ {
-		result := <-results
-		if result.err != nil {
-			return nil, result.err
-		}
-		ai.Add(result.poly)
+	var rd ringData
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rd); err != nil {
+		return fmt.Errorf("failed to decode ring: %w", err)
+	}
+	if rd.Size < 1 || rd.Size&(rd.Size-1) != 0 {
+		return fmt.Errorf("ring size %d is not a power of two", rd.Size)
+	}
+	rootBase, err := dpf.FrFromBytesCanonical(rd.RootBase)
+	if err != nil {
+		return fmt.Errorf("ring holds an invalid root base: %w", err)
 	}
 
-	return ai, nil
-}
+	// rootBase is a primitive 2^(N+1)th root of unity iff rootBase^(2^N) = -1
+	check := bls12381.NewFr()
+	check.Exp(rootBase, big.NewInt(int64(rd.Size)))
+	minusOne := bls12381.NewFr().One()
+	minusOne.Neg(minusOne)
+	if !check.Equal(minusOne) {
+		return fmt.Errorf("ring holds a root base that is no primitive root of unity of order %d", 2*rd.Size)
+	}
 
-// evalFinalShare2D evaluates the final share of the PCG for the given polynomial.
-// This function effectively calculates the inner product between the given polynomial and the random polynomials in div.
-func (p *PCG) evalFinalShare2D(w [][]*poly.Polynomial, oprand []*poly.Polynomial, div *poly.Polynomial) (*poly.Polynomial, error) {
-	numCores := runtime.NumCPU()
-	tasks := make(chan evalFinalShareTask, numCores)
-	results := make(chan evalFinalShareResult, numCores)
-
-	var wg sync.WaitGroup
-	worker := func() {
-		defer wg.Done()
-		for task := range tasks {
-			var result evalFinalShareResult
-			if task.isLastIndex {
-				remainder, err := task.wPoly.Mod(task.div)
-				result = evalFinalShareResult{remainder, err}
-			} else {
-				prod, err := poly.Mul(task.oprand, task.wPoly)
-				if err != nil {
-					results <- evalFinalShareResult{nil, err}
-					return
-				}
-				result = evalFinalShareResult{prod, err}
+	var roots []*bls12381.Fr
+	if rd.Roots != nil {
+		if len(rd.Roots) != rd.Size {
+			return fmt.Errorf("ring holds %d roots but %d are expected", len(rd.Roots), rd.Size)
+		}
+		roots = make([]*bls12381.Fr, rd.Size)
+		for i, root := range rd.Roots {
+			if roots[i], err = dpf.FrFromBytesCanonical(root); err != nil {
+				return fmt.Errorf("ring holds an invalid root at index %d: %w", i, err)
 			}
-			results <- result
+		}
+		if !roots[0].Equal(rootBase) {
+			return fmt.Errorf("roots of the ring do not match its root base")
 		}
 	}
 
-	for i := 0; i < numCores; i++ {
-		wg.Add(1)
-		go worker()
+	div, err := poly.NewCyclotomicPolynomial(big.NewInt(2 * int64(rd.Size)))
+	if err != nil {
+		return err
 	}
+	*r = Ring{Div: div, Roots: roots, rootBase: rootBase, size: rd.Size}
+	return r.prepare()
+}
 
-	go func() {
-		for j := 0; j < p.c; j++ {
-			for k := 0; k < p.c; k++ {
-				currentIndex := j*p.c + k
-				isLastIndex := currentIndex == p.c*p.c-1
-				task := evalFinalShareTask{j, k, oprand[currentIndex], w[j][k], div, isLastIndex}
-				tasks <- task
-			}
-		}
-		close(tasks)
-	}()
-
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+// EvaluationDomain returns the evaluation domain over the roots of the ring, s.t. ring elements can be represented
+// in point-value form (see poly.NTTPolynomial). The i-th value of such a polynomial is its evaluation at RootAt(i).
+func (r *Ring) EvaluationDomain() (*poly.EvaluationDomain, error) {
+	return poly.NewEvaluationDomain(r.rootBase, r.size)
+}
 
-	alphai := poly.NewEmpty()
-	for range w {
-		for range w[0] {
-			result := <-results
-			if result.err != nil {
-				return nil, result.err
-			}
-			alphai.Add(result.poly)
-		}
+// RootAt returns the i-th root of the ring.
+// If the roots are not materialized, it is computed via exponentiation.
+func (r *Ring) RootAt(i int) (*bls12381.Fr, error) {
+	if i < 0 || i >= r.size {
+		return nil, fmt.Errorf("root index %d is out of range [0, %d)", i, r.size)
+	}
+	if r.Roots != nil {
+		return r.Roots[i], nil
 	}
+	root := bls12381.NewFr()
+	root.Exp(r.rootBase, big.NewInt(int64(2*i+1)))
+	return root, nil
+}
 
-	alphai, err := alphai.Mod(div)
+// TupleRoot returns the root of the tuple with the given sequence number, i.e. the root with the index
+// TupleRootIndex(i). Parties consuming tuples in sequence should address them via TupleRoot, s.t. all parties derive
+// the i-th tuple at the same root and each root is used exactly once (see tuplegen.TupleRootIndex).
+func (r *Ring) TupleRoot(i int) (*bls12381.Fr, error) {
+	index, err := r.TupleRootIndex(i)
 	if err != nil {
 		return nil, err
 	}
-	return alphai, nil
+	return r.RootAt(index)
 }
 
-// evalVOLEwithSeed evaluates the VOLE correlation with the given seed.
-func (p *PCG) evalVOLEwithSeed(u []*poly.Polynomial, seedSk *bls12381.Fr, seedDSPFKeys [][][]*DSPFKeyPair, seedIndex int, div *poly.Polynomial) ([]*poly.Polynomial, error) {
-	utilde := make([]*poly.Polynomial, p.c)
-	for r := 0; r < p.c; r++ {
-		ur := u[r].DeepCopy()    // We need unmodified u[r] later on, so we copy it
-		ur.MulByConstant(seedSk) // u[r] * sk[i]
-		for j := 0; j < p.n; j++ {
-			if seedIndex != j {
-				eval0, err := p.dspfN.FullEvalFastAggregated(seedDSPFKeys[seedIndex][j][r].Key0)
-				if err != nil {
-					return nil, err
-				}
-				ur.Add(poly.NewFromFr(eval0))
+// TupleRootIndex returns the index of the root of the tuple with the given sequence number, i.e. the bit reversal of i
+// in N bits (see tuplegen.TupleRootIndex).
+func (r *Ring) TupleRootIndex(i int) (int, error) {
+	return tuplegen.TupleRootIndex(i, r.size)
+}
 
-				eval1, err := p.dspfN.FullEvalFastAggregated(seedDSPFKeys[j][seedIndex][r].Key1)
-				if err != nil {
-					return nil, err
-				}
-				ur.Add(poly.NewFromFr(eval1))
+// IndexOf returns the index of the given root, s.t. RootAt(IndexOf(root)) = root.
+// If the roots are not materialized, the roots are iterated in O(2^N) without storing them.
+func (r *Ring) IndexOf(root *bls12381.Fr) (int, error) {
+	if r.Roots != nil {
+		for i, candidate := range r.Roots {
+			if candidate.Equal(root) {
+				return i, nil
 			}
 		}
-		utilde[r] = ur
+		return -1, fmt.Errorf("element is not a root of the ring")
 	}
-	return utilde, nil
-}
 
-// evalOLEwithSeed evaluates the OLE correlation with the given seed.
-func (p *PCG) evalOLEwithSeed(u, v []*poly.Polynomial, seedDSPFKeys [][][][]*DSPFKeyPair, seedIndex int, div *poly.Polynomial) ([][]*poly.Polynomial, error) {
-	w := make([][]*poly.Polynomial, p.c)
-	for r := 0; r < p.c; r++ {
-		w[r] = make([]*poly.Polynomial, p.c)
-		for s := 0; s < p.c; s++ {
-			var err error
-			w[r][s], err = poly.Mul(u[r], v[s]) // u an r are t-sparse -> t*t complexity
-			if err != nil {
-				return nil, err
-			}
-			for j := 0; j < p.n; j++ {
-				if seedIndex != j { // Ony cross terms
-					eval0, err := p.dspf2N.FullEvalFastAggregated(seedDSPFKeys[seedIndex][j][r][s].Key0)
-					if err != nil {
-						return nil, err
-					}
-					w[r][s].Add(poly.NewFromFr(eval0)) // N
+	step := bls12381.NewFr()
+	step.Square(r.rootBase)
+	candidate := bls12381.NewFr().Set(r.rootBase)
+	for i := 0; i < r.size; i++ {
+		if candidate.Equal(root) {
+			return i, nil
+		}
+		candidate.Mul(candidate, step)
+	}
+	return -1, fmt.Errorf("element is not a root of the ring")
+}
 
-					eval1, err := p.dspf2N.FullEvalFastAggregated(seedDSPFKeys[j][seedIndex][r][s].Key1)
-					if err != nil {
-						return nil, err
-					}
-					w[r][s].Add(poly.NewFromFr(eval1)) // N
-				}
-			}
+// evalFinalShare evaluates the final share of the PCG for the given polynomial.
+// This function effectively calculates the inner product between the given polynomial and the random polynomials in div.
+// The products are collected by index and summed in index order, s.t. the summation and the reported error (the one
+// of the lowest failed index, see parallelFor) do not depend on the scheduling of the workers.
+func (p *PCG) evalFinalShare(u, rand []*poly.Polynomial, div *PreparedDivisor) (*poly.Polynomial, error) {
+	remainders := make([]*poly.Polynomial, p.c)
+	err := parallelFor(p.c, runtime.NumCPU(), func(r int) error {
+		prod, err := mulPoly(rand[r], u[r])
+		if err == nil {
+			remainders[r], err = div.Reduce(prod)
 		}
+		if err != nil {
+			return &coordinateError{counterparty: -1, r: r, s: -1, err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return w, nil
-}
-
-// evalVOLEwithSeed evaluates the VOLE correlation with the given seed.
-// Poly out is structured as: [j][direction][r], where j is the counter-parties index, direction is 0 for forward and 1 for backward and where r is in c.
-func (p *PCG) evalVOLEwithSeedSeparate(seedDSPFKeys [][][]*DSPFKeyPair, seedIndex int) ([][][]*poly.Polynomial, error) {
-	utilde := make([][][]*poly.Polynomial, p.n)
-	for j := 0; j < p.n; j++ {
-		if seedIndex != j {
-			utilde[j] = make([][]*poly.Polynomial, 2) // 0 is forward, 1 is backward
-			utilde[j][forwardDirection] = make([]*poly.Polynomial, p.c)
-			utilde[j][backwardDirection] = make([]*poly.Polynomial, p.c)
-			for r := 0; r < p.c; r++ {
-				eval0, err := p.dspfN.FullEvalFastAggregated(seedDSPFKeys[seedIndex][j][r].Key0)
-				if err != nil {
-					return nil, err
-				}
-				utilde[j][forwardDirection][r] = poly.NewFromFr(eval0)
 
-				eval1, err := p.dspfN.FullEvalFastAggregated(seedDSPFKeys[j][seedIndex][r].Key1)
-				if err != nil {
-					return nil, err
-				}
-				utilde[j][backwardDirection][r] = poly.NewFromFr(eval1)
-			}
+	ai := poly.NewEmpty()
+	for _, remainder := range remainders {
+		ai.Add(remainder)
+	}
+	return ai, nil
+}
+
+// evalFinalShare2D evaluates the final share of the PCG for the given polynomial.
+// This function effectively calculates the inner product between the given polynomial and the random polynomials in div.
+// Like evalFinalShare, the c*c products are collected by index and summed in index order.
+func (p *PCG) evalFinalShare2D(w [][]*poly.Polynomial, oprand []*poly.Polynomial, div *PreparedDivisor) (*poly.Polynomial, error) {
+	products := make([]*poly.Polynomial, p.c*p.c)
+	err := parallelFor(len(products), runtime.NumCPU(), func(i int) error {
+		wPoly := w[i/p.c][i%p.c]
+		var err error
+		if i == len(products)-1 {
+			products[i], err = div.Reduce(wPoly) // The last entry of oprand is 1
+		} else {
+			products[i], err = mulPoly(oprand[i], wPoly)
+		}
+		if err != nil {
+			return &coordinateError{counterparty: -1, r: i / p.c, s: i % p.c, err: err}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return utilde, nil
-}
-
-// evalOLEwithSeed evaluates the OLE correlation with the given seed.
-// Poly out is structured as: [j][r][s], where j is the counter-parties index and r and s are in c.
-func (p *PCG) evalOLEwithSeedSeparate(u, v []*poly.Polynomial, seedDSPFKeys [][][][]*DSPFKeyPair, seedIndex int) ([][][]*poly.Polynomial, [][]*poly.Polynomial, error) {
-	w := make([][][]*poly.Polynomial, p.n)
-	uv := make([][]*poly.Polynomial, p.c)
-	for j := 0; j < p.n; j++ {
-		if seedIndex != j { // Ony cross terms
-			w[j] = make([][]*poly.Polynomial, p.c)
-			for r := 0; r < p.c; r++ {
-				w[j][r] = make([]*poly.Polynomial, p.c)
-				uv[r] = make([]*poly.Polynomial, p.c)
-				for s := 0; s < p.c; s++ {
-					eval0, err := p.dspf2N.FullEvalFastAggregated(seedDSPFKeys[seedIndex][j][r][s].Key0)
-					if err != nil {
-						return nil, nil, err
-					}
-					w[j][r][s] = poly.NewFromFr(eval0)
 
-					eval1, err := p.dspf2N.FullEvalFastAggregated(seedDSPFKeys[j][seedIndex][r][s].Key1)
-					if err != nil {
-						return nil, nil, err
-					}
-					w[j][r][s].Add(poly.NewFromFr(eval1))
+	alphai := poly.NewEmpty()
+	for _, product := range products {
+		alphai.Add(product)
+	}
 
-					uv[r][s], err = poly.Mul(u[r], v[s])
-					if err != nil {
-						return nil, nil, err
-					}
-				}
-			}
+	alphai, err = div.Reduce(alphai)
+	if err != nil {
+		return nil, err
+	}
+	return alphai, nil
+}
 
-		}
+// skShareIndex returns the index of the sk share the dealer gives to the given party.
+// All parties > 1 get the share with index 1, as we do not interpolate the key shares (only for testing as this has
+// no performance impact on Eval).
+func skShareIndex(party int) int {
+	return min(party, 1)
+}
+
+// skShareIndex returns the index of the sk share of the given party within a sharing with the given amount of
+// shares. A sharing with a share per party, e.g. of TrustedSeedGenWithKeyShares, holds the share of party i at i.
+func (p *PCG) skShareIndex(party, shares int) int {
+	if shares == p.n {
+		return party
 	}
-	return w, uv, nil
+	return skShareIndex(party)
 }
 
 // embedVOLECorrelations embeds VOLE correlations into DSPF keys.
-func (p *PCG) embedVOLECorrelations(omega [][][]*big.Int, beta [][][]*bls12381.Fr, skShares []*bls12381.Fr) ([][][]*DSPFKeyPair, error) {
-	U := init3DSliceDspfKey(p.n, p.n, p.c)
+func (p *PCG) embedVOLECorrelations(omega *ExponentMatrix, beta *CoefficientMatrix, skShares []*bls12381.Fr) (*DSPFKeyMatrix, error) {
+	U := NewVOLEKeyMatrix(p.n, p.c)
 	for i := 0; i < p.n; i++ {
 		for j := 0; j < p.n; j++ {
 			if i != j {
 				for r := 0; r < p.c; r++ {
-					skShareIndex := j
-					if j > 1 {
-						skShareIndex = 1 // We do this here as we do not interpolate (for testing only)
-					}
-
-					nonZeroElements := scalarMulFr(skShares[skShareIndex], beta[i][r])
-					key0, key1, err := p.dspfN.Gen(omega[i][r], frSliceToBigIntSlice(nonZeroElements))
+					keys, err := p.embedVOLECorrelation(omega.At(i, r), scalarMulFr(skShares[p.skShareIndex(j, len(skShares))], beta.At(i, r)))
 					if err != nil {
 						return nil, err
 					}
-					U[i][j][r] = &DSPFKeyPair{key0, key1}
+					*U.At(i, j, r) = *keys
 				}
 			}
 		}
@@ -610,24 +520,20 @@ func (p *PCG) embedVOLECorrelations(omega [][][]*big.Int, beta [][][]*bls12381.F
 }
 
 // embedOLECorrelations embeds OLE correlations into DSPF keys.
-func (p *PCG) embedOLECorrelations(omega, o [][][]*big.Int, beta, b [][][]*bls12381.Fr) ([][][][]*DSPFKeyPair, error) {
-	U := init4DSliceDspfKey(p.n, p.n, p.c)
+// The special points and non-zero elements of all correlations share the storage of a single arena.
+func (p *PCG) embedOLECorrelations(omega, o *ExponentMatrix, beta, b *CoefficientMatrix) (*DSPFKeyMatrix, error) {
+	U := NewOLEKeyMatrix(p.n, p.c)
+	arena := newOuterArena(p.t * p.t)
 	for i := 0; i < p.n; i++ {
 		for j := 0; j < p.n; j++ {
 			if i != j {
 				for r := 0; r < p.c; r++ {
 					for s := 0; s < p.c; s++ {
-						specialPoints := outerSumBigInt(omega[i][r], o[j][s])
-						// For evaluating the performance, we allow duplicates for now
-						// if hasDuplicates(specialPoints) {
-						//	return nil, fmt.Errorf("special points contain duplicates")
-						// }
-						nonZeroElements := outerProductFr(beta[i][r], b[j][s])
-						key1, key2, err := p.dspf2N.Gen(specialPoints, frSliceToBigIntSlice(nonZeroElements))
+						keys, err := p.embedOLECorrelation(arena, omega.At(i, r), o.At(j, s), beta.At(i, r), b.At(j, s))
 						if err != nil {
 							return nil, err
 						}
-						U[i][j][r][s] = &DSPFKeyPair{key1, key2}
+						*U.AtOLE(i, j, r, s) = *keys
 					}
 				}
 			}
@@ -636,56 +542,123 @@ func (p *PCG) embedOLECorrelations(omega, o [][][]*big.Int, beta, b [][][]*bls12
 	return U, nil
 }
 
+// embedVOLECorrelation generates the DSPF key pair of a single VOLE correlation with the given special points.
+func (p *PCG) embedVOLECorrelation(specialPoints []*big.Int, nonZeroElements []*bls12381.Fr) (*DSPFKeyPair, error) {
+	if err := checkSpecialPoints(specialPoints, p.domain); err != nil {
+		return nil, err
+	}
+	key0, key1, err := p.dspfN.Gen(specialPoints, frSliceToBigIntSlice(nonZeroElements))
+	if err != nil {
+		return nil, err
+	}
+	return &DSPFKeyPair{key0, key1}, nil
+}
+
+// embedOLECorrelation generates the DSPF key pair of a single OLE correlation of the t-sparse vectors (omega, beta)
+// and (o, b), i.e. with the special points omega+o and the non-zero elements beta*b (see outerSumBigInt).
+// Duplicate special points are merged (see mergeDuplicatePoints), unless the PCG uses regular noise.
+// The special points and non-zero elements are computed in the storage of the arena (see outerSumAndProduct).
+func (p *PCG) embedOLECorrelation(arena *outerArena, omega, o []*big.Int, beta, b []*bls12381.Fr) (*DSPFKeyPair, error) {
+	specialPoints, nonZeroElements := arena.outerSumAndProduct(omega, o, beta, b)
+	if err := checkSpecialPoints(specialPoints, p.doubleDomain()); err != nil {
+		return nil, err
+	}
+	if !p.regularNoise {
+		var err error
+		if specialPoints, nonZeroElements, err = p.mergeDuplicatePoints(specialPoints, nonZeroElements, p.doubleDomain()); err != nil {
+			return nil, err
+		}
+	}
+	key0, key1, err := p.dspf2N.Gen(specialPoints, frSliceToBigIntSlice(nonZeroElements))
+	if err != nil {
+		return nil, err
+	}
+	return &DSPFKeyPair{key0, key1}, nil
+}
+
+// doubleDomain returns the bound 2^(N+1) of the special points of the OLE correlations (sums of two exponents).
+func (p *PCG) doubleDomain() *big.Int {
+	return new(big.Int).Lsh(p.domain, 1)
+}
+
+// checkSpecialPoints checks that all special points are within [0, bound).
+func checkSpecialPoints(points []*big.Int, bound *big.Int) error {
+	for _, point := range points {
+		if point.Sign() < 0 || point.Cmp(bound) >= 0 {
+			return &SpecialPointOutOfDomainError{Point: new(big.Int).Set(point), Bound: new(big.Int).Set(bound)}
+		}
+	}
+	return nil
+}
+
 // sampleExponents samples values later used as poly exponents by picking p.n*p.c random t-vectors from N.
-func (p *PCG) sampleExponents() [][][]*big.Int {
-	exp := init3DSliceBigInt(p.n, p.c, p.t)
-	for i := 0; i < p.n; i++ {
-		for j := 0; j < p.c; j++ {
-			vec := p.sampleTUniqueExponents()
-			sort.Slice(vec, func(i, j int) bool {
-				return vec[i].Cmp(vec[j]) < 0
-			})
-			exp[i][j] = vec
+// If the PCG uses regular noise, each t-vector holds one exponent per segment (see UseRegularNoise).
+func (p *PCG) sampleExponents() *ExponentMatrix {
+	exp := make([][][]*big.Int, p.n)
+	for i := range exp {
+		exp[i] = make([][]*big.Int, p.c)
+		for j := range exp[i] {
+			if p.regularNoise {
+				exp[i][j] = p.sampleRegularExponents()
+				continue
+			}
+			exp[i][j] = p.sampleExponentVector()
 		}
 	}
-	return exp
+	return &ExponentMatrix{vectorMatrix[*big.Int]{parties: p.n, blocks: p.c, slots: p.t, values: exp}}
+}
+
+// sampleExponentVector samples a sorted t-vector of unique exponents from [0, 2^N).
+func (p *PCG) sampleExponentVector() []*big.Int {
+	vec := p.sampleTUniqueExponents()
+	sort.Slice(vec, func(i, j int) bool {
+		return vec[i].Cmp(vec[j]) < 0
+	})
+	return vec
 }
 
 // sampleCoefficients samples values later used as poly coefficients by picking p.n*p.c random t-vectors from Fq.
-func (p *PCG) sampleCoefficients() [][][]*bls12381.Fr {
-	exp := init3DSliceFr(p.n, p.c, p.t)
-	for i := 0; i < p.n; i++ {
-		for j := 0; j < p.c; j++ {
+func (p *PCG) sampleCoefficients() *CoefficientMatrix {
+	coeffs := make([][][]*bls12381.Fr, p.n)
+	for i := range coeffs {
+		coeffs[i] = make([][]*bls12381.Fr, p.c)
+		for j := range coeffs[i] {
 			vec := make([]*bls12381.Fr, p.t)
 			for t := range vec {
-				randElement, _ := bls12381.NewFr().Rand(p.rng)
+				randElement, _ := bls12381.NewFr().Rand(p.rng.domain(domainCoefficients)) // the streams of p.rng never fail to read
 				vec[t] = bls12381.NewFr()
 				vec[t].Set(randElement)
 			}
-			exp[i][j] = vec
+			coeffs[i][j] = vec
 		}
 	}
-	return exp
+	return &CoefficientMatrix{vectorMatrix[*bls12381.Fr]{parties: p.n, blocks: p.c, slots: p.t, values: coeffs}}
 }
 
-// constructPolys constructs c t-sparse polynomial from the given coefficients and exponents.
-func (p *PCG) constructPolys(coefficients [][]*bls12381.Fr, exponents [][]*big.Int) ([]*poly.Polynomial, error) {
-	if len(coefficients) != p.c {
-		return nil, fmt.Errorf("amount of coefficient slices is %d but is expected to be c=%d", len(coefficients), p.c)
+// constructPolys constructs the c t-sparse polynomials of the party of the given matrices of a seed.
+func (p *PCG) constructPolys(coefficients *CoefficientMatrix, exponents *ExponentMatrix) ([]*poly.Polynomial, error) {
+	if coefficients == nil || exponents == nil {
+		return nil, fmt.Errorf("coefficients and exponents must not be nil")
 	}
-	if len(exponents) != p.c {
-		return nil, fmt.Errorf("amount of exponents slices is %d but is expected to be c=%d", len(coefficients), p.c)
+	if coefficients.Parties() != 1 || exponents.Parties() != 1 {
+		return nil, fmt.Errorf("matrices hold the vectors of %d and %d parties but of a single party are expected", coefficients.Parties(), exponents.Parties())
+	}
+	if coefficients.Blocks() != p.c {
+		return nil, fmt.Errorf("amount of coefficient vectors is %d but is expected to be c=%d", coefficients.Blocks(), p.c)
+	}
+	if exponents.Blocks() != p.c {
+		return nil, fmt.Errorf("amount of exponent vectors is %d but is expected to be c=%d", exponents.Blocks(), p.c)
+	}
+	if coefficients.Slots() != p.t {
+		return nil, fmt.Errorf("amount of coefficients is %d but is expected to be t=%d", coefficients.Slots(), p.t)
+	}
+	if exponents.Slots() != p.t {
+		return nil, fmt.Errorf("amount of exponents is %d but is expected to be t=%d", exponents.Slots(), p.t)
 	}
 
 	res := make([]*poly.Polynomial, p.c)
 	for r := 0; r < p.c; r++ {
-		if len(coefficients[r]) != p.t {
-			return nil, fmt.Errorf("amount of coefficients is %d but is expected to be t=%d", len(coefficients[r]), p.t)
-		}
-		if len(exponents[r]) != p.t {
-			return nil, fmt.Errorf("amount of exponents is %d but is expected to be t=%d", len(coefficients[r]), p.t)
-		}
-		generatedPoly, err := poly.NewSparse(coefficients[r], exponents[r])
+		generatedPoly, err := poly.NewSparse(coefficients.At(0, r), exponents.At(0, r))
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate polynomial: %w", err)
 		}
@@ -697,11 +670,10 @@ func (p *PCG) constructPolys(coefficients [][]*bls12381.Fr, exponents [][]*big.I
 
 // sampleTUniqueExponents samples t unique exponents from N.
 func (p *PCG) sampleTUniqueExponents() []*big.Int {
-	maxExp := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(p.N)), nil)
+	maxExp := p.domain
 	vec := make([]*big.Int, 0, p.t)
 	for len(vec) < p.t {
-		randNum := big.NewInt(0)
-		randNum.Rand(p.rng, maxExp)
+		randNum := p.rng.domain(domainExponents).Int(maxExp)
 
 		// Check if randNum is already in vec
 		exists := false
@@ -1,8 +1,13 @@
 package pcg
 
 import (
+	"errors"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/artifact"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/poly"
 	"github.com/stretchr/testify/assert"
 	"math/big"
+	"math/bits"
 	"testing"
 )
 
@@ -24,3 +29,168 @@ func TestBLS12381GroupOrderFactorization(t *testing.T) {
 
 	assert.Equal(t, 0, expected.Cmp(product))
 }
+
+func TestEmbedCorrelationsBoundaryExponents(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 2, 2, 1, 2)
+	assert.Nil(t, err)
+	maxExp := new(big.Int).Sub(pcg.domain, big.NewInt(1)) // 2^N - 1
+
+	// Sampled exponents are within the domain
+	sampled := pcg.sampleExponents()
+	for i := 0; i < sampled.Parties(); i++ {
+		assert.Nil(t, checkSpecialPoints(sampled.At(i, 0), pcg.domain))
+	}
+
+	beta := pcg.sampleCoefficients()
+	skShares := []*bls12381.Fr{bls12381.NewFr().One(), bls12381.NewFr().One()}
+	exponents := func(e0, e1 *big.Int) *ExponentMatrix {
+		m, err := NewExponentMatrix([][][]*big.Int{{{big.NewInt(0), e0}}, {{big.NewInt(0), e1}}})
+		assert.Nil(t, err)
+		return m
+	}
+
+	// Largest valid exponents: 2^N - 1 for VOLE and (2^N - 1) + (2^N - 1) for OLE
+	_, err = pcg.embedVOLECorrelations(exponents(maxExp, maxExp), beta, skShares)
+	assert.Nil(t, err)
+	_, err = pcg.embedOLECorrelations(exponents(maxExp, maxExp), exponents(maxExp, maxExp), beta, beta)
+	assert.Nil(t, err)
+
+	// Exponents of 2^N exceed the domain
+	_, err = pcg.embedVOLECorrelations(exponents(pcg.domain, maxExp), beta, skShares)
+	var domainErr *SpecialPointOutOfDomainError
+	assert.True(t, errors.As(err, &domainErr))
+	assert.Equal(t, 0, domainErr.Point.Cmp(pcg.domain))
+
+	// (2^N - 1) + (2^N + 1) = 2^(N+1) is outside the domain of dspf2N
+	tooLarge := new(big.Int).Add(pcg.domain, big.NewInt(1))
+	_, err = pcg.embedOLECorrelations(exponents(maxExp, maxExp), exponents(tooLarge, tooLarge), beta, beta)
+	assert.True(t, errors.As(err, &domainErr))
+	assert.Equal(t, 0, domainErr.Bound.Cmp(pcg.doubleDomain()))
+
+	// Negative exponents are rejected as well
+	_, err = pcg.embedOLECorrelations(exponents(big.NewInt(-5), maxExp), exponents(big.NewInt(1), maxExp), beta, beta)
+	assert.True(t, errors.As(err, &domainErr))
+}
+
+func TestDomainSize(t *testing.T) {
+	size, err := domainSize(10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1024, size)
+
+	_, err = domainSize(bits.UintSize - 1) // would overflow to a negative int
+	assert.NotNil(t, err)
+	_, err = domainSize(-1)
+	assert.NotNil(t, err)
+}
+
+func TestPickRandomPolynomialsBounds(t *testing.T) {
+	pcg, err := NewPCG(128, poly.MaxFFTOrder, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	_, err = pcg.PickRandomPolynomials()
+	assert.NotNil(t, err)
+	_, err = pcg.PickRandomPolynomialsForEpoch([32]byte{1}, 0)
+	assert.NotNil(t, err)
+
+	pcg, err = NewPCG(128, 6, 2, 2, 3, 4)
+	assert.Nil(t, err)
+	polys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	assert.Len(t, polys, 3)
+}
+
+func TestRingSerialize(t *testing.T) {
+	pcg, err := NewPCG(128, 8, 2, 2, 2, 4)
+	assert.Nil(t, err)
+	ring, err := pcg.GetRing(true)
+	assert.Nil(t, err)
+	lazy, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+
+	for _, r := range []*Ring{ring, lazy} {
+		data, err := r.Serialize()
+		assert.Nil(t, err)
+		deserialized := &Ring{}
+		assert.Nil(t, deserialized.Deserialize(data))
+		assert.Equal(t, r.Size(), deserialized.Size())
+		assert.Equal(t, r.Roots, deserialized.Roots)
+		assert.True(t, r.Div.Equal(deserialized.Div))
+		root, err := deserialized.RootAt(7)
+		assert.Nil(t, err)
+		expected, err := r.RootAt(7)
+		assert.Nil(t, err)
+		assert.True(t, expected.Equal(root))
+	}
+
+	// A root base that is no primitive root of unity of the ring is rejected
+	invalid := *lazy
+	invalid.rootBase = bls12381.NewFr().One()
+	data, err := invalid.Serialize()
+	assert.Nil(t, err)
+	assert.NotNil(t, (&Ring{}).Deserialize(data))
+	assert.NotNil(t, (&Ring{}).Deserialize([]byte("not a ring")))
+
+	// Rings of the legacy format without header are accepted
+	data, err = lazy.Serialize()
+	assert.Nil(t, err)
+	_, body, err := artifact.Decode(data, artifact.KindRing)
+	assert.Nil(t, err)
+	legacy := &Ring{}
+	assert.Nil(t, legacy.Deserialize(body))
+	assert.Equal(t, lazy.Size(), legacy.Size())
+}
+
+func TestOuterSumAndProduct(t *testing.T) {
+	frOf := func(v int64) *bls12381.Fr { return bls12381.NewFr().FromBytes(big.NewInt(v).Bytes()) }
+	huge := new(big.Int).Lsh(big.NewInt(1), 70)
+	for _, test := range []struct {
+		omega, o []*big.Int
+	}{
+		{[]*big.Int{big.NewInt(0), big.NewInt(5), big.NewInt(63)}, []*big.Int{big.NewInt(1), big.NewInt(62)}},
+		{[]*big.Int{big.NewInt(3), huge}, []*big.Int{big.NewInt(-4), big.NewInt(1 << 62), big.NewInt(7)}}, // beyond int64
+	} {
+		beta := make([]*bls12381.Fr, len(test.omega))
+		for i := range beta {
+			beta[i] = frOf(int64(i + 2))
+		}
+		b := make([]*bls12381.Fr, len(test.o))
+		for j := range b {
+			b[j] = frOf(int64(j + 5))
+		}
+
+		arena := newOuterArena(1) // grows on demand
+		for round := 0; round < 2; round++ {
+			points, values := arena.outerSumAndProduct(test.omega, test.o, beta, b)
+			assert.Equal(t, len(test.omega)*len(test.o), len(points))
+			for i := range test.omega {
+				for j := range test.o {
+					k := i*len(test.o) + j
+					assert.Equal(t, 0, new(big.Int).Add(test.omega[i], test.o[j]).Cmp(points[k]))
+					expected := bls12381.NewFr()
+					expected.Mul(beta[i], b[j])
+					assert.True(t, expected.Equal(values[k]))
+				}
+			}
+			sums := outerSumBigInt(test.omega, test.o)
+			for k := range sums {
+				assert.Equal(t, 0, sums[k].Cmp(points[k]))
+			}
+			products := outerProductFr(beta, b)
+			for k := range products {
+				assert.True(t, products[k].Equal(values[k]))
+			}
+		}
+	}
+}
+
+func BenchmarkEmbedOLECorrelations(b *testing.B) {
+	pcg, err := NewPCG(128, 10, 2, 2, 2, 16)
+	assert.Nil(b, err)
+	omega := pcg.sampleExponents()
+	beta := pcg.sampleCoefficients()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		_, err := pcg.embedOLECorrelations(omega, omega, beta, beta)
+		assert.Nil(b, err)
+	}
+}
@@ -0,0 +1,27 @@
+package pcg
+
+import (
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/sharing"
+)
+
+// VerifyShare checks the share of the party with the given index against the Feldman commitments of the dealer
+// (see sharing.VerifyShare).
+func VerifyShare(share *bls12381.Fr, index int, commitments []*bls12381.PointG1) error {
+	return sharing.VerifyShare(share, index, commitments)
+}
+
+// SharingScheme returns the scheme the sk term is shared with: an additive sharing in the n-out-of-n setting and a
+// Shamir sharing in the tau-out-of-n setting (see sharing.SchemeFor).
+func (p *PCG) SharingScheme() sharing.Scheme {
+	return sharing.SchemeFor(p.tau, p.n)
+}
+
+// sharingScheme returns the scheme the sk share of the seed belongs to. Seeds of the legacy formats hold no
+// parameters, their dealers always used a Shamir sharing.
+func (s *Seed) sharingScheme() sharing.Scheme {
+	if s.params == nil {
+		return sharing.Shamir{T: len(s.skCommitments)}
+	}
+	return sharing.SchemeFor(s.params.Tau, s.params.Parties)
+}
@@ -0,0 +1,159 @@
+package pcg
+
+import (
+	"crypto/rand"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/sharing"
+	"github.com/leandro-ro/Threshold-BBS-Plus-PCG/pcg/tuplegen"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSeedVerifyShare(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+
+	pk0, err := seeds[0].SharedPublicKey()
+	assert.Nil(t, err)
+	for _, seed := range seeds {
+		assert.Nil(t, seed.VerifyShare())
+		pk, err := seed.SharedPublicKey()
+		assert.Nil(t, err)
+		assert.True(t, bls12381.NewG1().Equal(pk0, pk))
+	}
+
+	// An inconsistent share handed out by a malicious dealer is detected
+	seeds[1].ski = bls12381.NewFr().One()
+	assert.NotNil(t, seeds[1].VerifyShare())
+}
+
+func TestTrustedSeedGenWithKeyShares(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+
+	// The parties of the n-out-of-n setting hold an additive sharing, e.g. of a DKG in which each party samples its share
+	_, skShares, skCommitments, err := sharing.Additive{N: 3}.Share(rand.Reader, nil)
+	assert.Nil(t, err)
+
+	seeds, err := pcg.TrustedSeedGenWithKeyShares(skShares, skCommitments)
+	assert.Nil(t, err)
+	for i, seed := range seeds {
+		assert.Nil(t, seed.VerifyShare())
+		assert.True(t, seed.ski.Equal(skShares[i]))
+	}
+
+	// The VOLE correlations embed the given shares
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	sk, delta0, a := bls12381.NewFr(), bls12381.NewFr(), bls12381.NewFr()
+	for _, seed := range seeds {
+		gen, err := pcg.EvalCombined(seed, randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		root, err := ring.RootAt(5)
+		assert.Nil(t, err)
+		shares, err := gen.Provider().(tuplegen.VOLEProvider).VOLESharesAt(root)
+		assert.Nil(t, err)
+		sk.Add(sk, seed.ski)
+		a.Add(a, shares.A)
+		delta0.Add(delta0, shares.Delta0)
+	}
+	ska := bls12381.NewFr()
+	ska.Mul(sk, a)
+	assert.True(t, ska.Equal(delta0))
+
+	// The shared public key is the one of the sum of the shares
+	pk, err := seeds[0].SharedPublicKey()
+	assert.Nil(t, err)
+	g1 := bls12381.NewG1()
+	assert.True(t, g1.Equal(pk, g1.MulScalar(g1.New(), g1.One(), sk)))
+
+	// Inconsistent shares are rejected
+	skShares[1] = bls12381.NewFr().One()
+	_, err = pcg.TrustedSeedGenWithKeyShares(skShares, skCommitments)
+	assert.NotNil(t, err)
+	_, err = pcg.TrustedSeedGenWithKeyShares(skShares[:2], skCommitments)
+	assert.NotNil(t, err)
+}
+
+func TestTrustedSeedGenWithKeySharesOfDKG(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+
+	// DKG of a 2-out-of-3 sharing among the parties
+	contributions := make([]*sharing.Contribution, 3)
+	for k := range contributions {
+		contributions[k], err = sharing.NewContribution(rand.Reader, 2, 3)
+		assert.Nil(t, err)
+	}
+	skShares := make([]*bls12381.Fr, 3)
+	var skCommitments []*bls12381.PointG1
+	for i := range skShares {
+		received := make([]*bls12381.Fr, len(contributions))
+		commitments := make([][]*bls12381.PointG1, len(contributions))
+		for k, contribution := range contributions {
+			received[k], commitments[k] = contribution.Shares[i], contribution.Commitments
+		}
+		skShares[i], skCommitments, err = sharing.CombineContributions(i, received, commitments)
+		assert.Nil(t, err)
+	}
+
+	seeds, err := pcg.TrustedSeedGenWithKeyShares(skShares, skCommitments)
+	assert.Nil(t, err)
+	for i, seed := range seeds {
+		assert.Nil(t, seed.VerifyShare())
+		assert.True(t, seed.ski.Equal(skShares[i]))
+	}
+
+	// A Shamir sharing does not fit the additive sharing of the n-out-of-n setting
+	combined, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	_, err = combined.TrustedSeedGenWithKeyShares(skShares, skCommitments)
+	assert.NotNil(t, err)
+}
+
+func TestSharingSchemeOfSetting(t *testing.T) {
+	pcg, err := NewPCG(128, 6, 3, 3, 2, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, sharing.Additive{N: 3}, pcg.SharingScheme())
+
+	// The sk of the n-out-of-n setting is the sum of the shares of all parties
+	seeds, err := pcg.TrustedSeedGen()
+	assert.Nil(t, err)
+	sk := bls12381.NewFr()
+	for _, seed := range seeds {
+		assert.Nil(t, seed.VerifyShare())
+		sk.Add(sk, seed.ski)
+	}
+	pk, err := seeds[0].SharedPublicKey()
+	assert.Nil(t, err)
+	g1 := bls12381.NewG1()
+	assert.True(t, g1.Equal(pk, g1.MulScalar(g1.New(), g1.One(), sk)))
+
+	// The separate evaluation of all parties weights the shares by one, s.t. the tuples share the same sk
+	randPolys, err := pcg.PickRandomPolynomials()
+	assert.Nil(t, err)
+	ring, err := pcg.GetLazyRing()
+	assert.Nil(t, err)
+	signerSet := []int{0, 1, 2}
+	tupleSk := bls12381.NewFr()
+	for _, seed := range seeds {
+		gen, err := pcg.EvalSeparate(seed, randPolys, ring.Prepared())
+		assert.Nil(t, err)
+		tuple, err := gen.GenBBSPlusTupleAt(ring, 3, signerSet)
+		assert.Nil(t, err)
+		tupleSk.Add(tupleSk, tuple.SkShare)
+		tuple, err = gen.GenBBSPlusTupleAt(ring, 3, []int{seed.index, (seed.index + 1) % 3})
+		assert.NotNil(t, err) // an additive sharing requires all parties
+		assert.Nil(t, tuple)
+	}
+	assert.True(t, tupleSk.Equal(sk))
+
+	threshold, err := NewPCG(128, 6, 3, 2, 2, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, sharing.Shamir{T: 2, N: 3}, threshold.SharingScheme())
+}